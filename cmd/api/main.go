@@ -12,12 +12,20 @@ import (
 	"github.com/go-chi/chi/v5"
 	chimiddleware "github.com/go-chi/chi/v5/middleware"
 	"github.com/ravindu/wallet-app-service/internal/config"
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	"github.com/ravindu/wallet-app-service/internal/events"
+	"github.com/ravindu/wallet-app-service/internal/fee"
+	"github.com/ravindu/wallet-app-service/internal/fx"
 	"github.com/ravindu/wallet-app-service/internal/handler"
+	"github.com/ravindu/wallet-app-service/internal/limit"
 	"github.com/ravindu/wallet-app-service/internal/middleware"
 	"github.com/ravindu/wallet-app-service/internal/repository"
 	"github.com/ravindu/wallet-app-service/internal/usecase"
+	"github.com/ravindu/wallet-app-service/internal/worker"
+	"github.com/ravindu/wallet-app-service/pkg/auth"
 	"github.com/ravindu/wallet-app-service/pkg/database"
 	"github.com/ravindu/wallet-app-service/pkg/logging"
+	"github.com/ravindu/wallet-app-service/pkg/webhook"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -44,16 +52,156 @@ func main() {
 		log.Println("Connected to Redis")
 	}
 
+	// Set up bearer token validation
+	var jwks *auth.KeySet
+	if cfg.Auth.JWKSPath != "" {
+		jwks, err = auth.NewKeySet(cfg.Auth.JWKSPath, cfg.Auth.JWKSRefreshInterval)
+		if err != nil {
+			log.Fatalf("Failed to load JWKS: %v", err)
+		}
+	}
+	verifier, err := auth.NewVerifier(auth.Config{
+		HMACSecret: cfg.Auth.HMACSecret,
+		Keys:       jwks,
+		Issuer:     cfg.Auth.Issuer,
+		Audience:   cfg.Auth.Audience,
+		Leeway:     cfg.Auth.Leeway,
+	})
+	if err != nil {
+		log.Fatalf("Failed to set up authentication, set AUTH_HMAC_SECRET or AUTH_JWKS_PATH: %v", err)
+	}
+
 	// Initialize repositories
 	userRepo := repository.NewUserRepository(db)
 	walletRepo := repository.NewWalletRepository(db)
 	transactionRepo := repository.NewTransactionRepository(db)
+	ledgerRepo := repository.NewLedgerRepository(db)
+	outboxRepo := repository.NewOutboxRepository(db)
+	webhookRepo := repository.NewWebhookRepository(db)
+	holdRepo := repository.NewHoldRepository(db)
+	fxQuoteRepo := repository.NewFXQuoteRepository(db)
+	snapshotRepo := repository.NewBalanceSnapshotRepository(db)
+	reconciliationRepo := repository.NewReconciliationRepository(db)
+	scheduleRepo := repository.NewScheduledTransferRepository(db)
+	batchRepo := repository.NewTransferBatchRepository(db)
+	requestRepo := repository.NewPaymentRequestRepository(db)
+	unitOfWork := repository.NewUnitOfWork(db)
+
+	// Pick where Idempotency-Key responses are kept
+	var idempotencyStore domain.IdempotencyStore
+	switch cfg.Idempotency.Store {
+	case "memory":
+		idempotencyStore = repository.NewIdempotencyMemoryRepository()
+	case "redis":
+		if redisClient != nil {
+			idempotencyStore = repository.NewIdempotencyRedisRepository(redisClient)
+		} else {
+			log.Println("Warning: Redis unavailable, keeping idempotency keys in PostgreSQL")
+			idempotencyStore = repository.NewIdempotencyRepository(db)
+		}
+	default:
+		idempotencyStore = repository.NewIdempotencyRepository(db)
+	}
+	idempotency := middleware.Idempotency(idempotencyStore, middleware.IdempotencyOptions{
+		TTL:         cfg.Idempotency.TTL,
+		LockTimeout: cfg.Idempotency.LockTimeout,
+	})
+
+	// Pick where outbox events are relayed to
+	var eventPublisher domain.EventPublisher
+	switch cfg.Outbox.Publisher {
+	case "none":
+		log.Println("Outbox relay disabled, events stay in the outbox")
+	case "redis":
+		if redisClient != nil {
+			eventPublisher = events.NewRedisStreamPublisher(redisClient, cfg.Outbox.Stream, cfg.Outbox.StreamMaxLen)
+		} else {
+			// Events are kept, and relayed once the service restarts with Redis
+			log.Println("Warning: Redis unavailable, outbox relay disabled")
+		}
+	default:
+		eventOutput := os.Stdout
+		if cfg.Outbox.File != "" {
+			eventOutput, err = os.OpenFile(cfg.Outbox.File, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+			if err != nil {
+				log.Fatalf("Failed to open outbox file: %v", err)
+			}
+			defer eventOutput.Close()
+		}
+		eventPublisher = events.NewLogPublisher(eventOutput)
+	}
+
+	// Pick where exchange rates come from
+	var rateProvider domain.RateProvider
+	switch cfg.FX.Provider {
+	case "static":
+		rateProvider, err = fx.LoadStaticProvider(cfg.FX.RatesFile)
+		if err != nil {
+			log.Fatalf("Failed to load FX rates: %v", err)
+		}
+	case "http":
+		rateProvider = fx.NewHTTPProvider(cfg.FX.HTTPURL, cfg.FX.HTTPTimeout)
+	default:
+		log.Println("No FX provider configured, currency conversion disabled")
+	}
+
+	// Load the fee schedules, every version of them
+	var feeSchedules domain.FeeSchedules
+	if cfg.Fee.ScheduleFile != "" {
+		feeSchedules, err = fee.LoadSchedules(cfg.Fee.ScheduleFile)
+		if err != nil {
+			log.Fatalf("Failed to load fee schedules: %v", err)
+		}
+		if cfg.Fee.WalletUserID == 0 {
+			log.Fatalf("FEE_WALLET_USER_ID must name the user whose wallets collect the fees")
+		}
+	} else {
+		log.Println("No fee schedule configured, transactions are free")
+	}
+
+	// Load each tier's transaction limits
+	var limitPolicy domain.LimitPolicy
+	if cfg.Limit.PolicyFile != "" {
+		limitPolicy, err = limit.LoadPolicy(cfg.Limit.PolicyFile)
+		if err != nil {
+			log.Fatalf("Failed to load transaction limits: %v", err)
+		}
+	} else {
+		log.Println("No transaction limits configured")
+	}
 
 	// Initialize use cases
-	walletUsecase := usecase.NewWalletUsecase(userRepo, walletRepo, transactionRepo, redisClient)
+	var fxUsecase domain.FXUsecase
+	if rateProvider != nil {
+		fxUsecase = usecase.NewFXUsecase(rateProvider, fxQuoteRepo, cfg.FX.SpreadBps, cfg.FX.QuoteTTL)
+	}
+	feeUsecase := usecase.NewFeeUsecase(feeSchedules, cfg.Fee.WalletUserID)
+	limitUsecase := usecase.NewLimitUsecase(userRepo, walletRepo, transactionRepo, limitPolicy)
+	walletUsecase := usecase.NewWalletUsecase(userRepo, walletRepo, transactionRepo, ledgerRepo, outboxRepo, webhookRepo, fxUsecase, feeUsecase, limitUsecase, unitOfWork, redisClient)
+	ledgerUsecase := usecase.NewLedgerUsecase(ledgerRepo)
+	statementUsecase := usecase.NewStatementUsecase(userRepo, walletRepo, transactionRepo, snapshotRepo)
+	balanceUsecase := usecase.NewBalanceUsecase(userRepo, walletRepo, transactionRepo, snapshotRepo)
+	reconciliationUsecase := usecase.NewReconciliationUsecase(walletRepo, ledgerRepo, reconciliationRepo, unitOfWork, redisClient)
+	userUsecase := usecase.NewUserUsecase(userRepo, walletRepo, ledgerRepo, unitOfWork)
+	webhookUsecase := usecase.NewWebhookUsecase(webhookRepo, cfg.Webhook.AllowInsecure)
+	holdUsecase := usecase.NewHoldUsecase(walletUsecase, limitUsecase, userRepo, walletRepo, holdRepo, unitOfWork, redisClient, cfg.Hold.DefaultTTL)
+	scheduleUsecase := usecase.NewScheduledTransferUsecase(walletUsecase, userRepo, walletRepo, scheduleRepo, unitOfWork, redisClient, cfg.Schedule.RetryInterval)
+	batchUsecase := usecase.NewTransferBatchUsecase(walletUsecase, feeUsecase, walletRepo, batchRepo, unitOfWork, redisClient)
+	requestUsecase := usecase.NewPaymentRequestUsecase(walletUsecase, userRepo, walletRepo, requestRepo, outboxRepo, webhookRepo, unitOfWork, redisClient, cfg.PaymentRequest.DefaultTTL)
 
 	// Initialize handlers
-	walletHandler := handler.NewWalletHandler(walletUsecase)
+	walletHandler := handler.NewWalletHandler(walletUsecase, balanceUsecase)
+	userHandler := handler.NewUserHandler(userUsecase)
+	webhookHandler := handler.NewWebhookHandler(webhookUsecase)
+	holdHandler := handler.NewHoldHandler(holdUsecase)
+	ledgerHandler := handler.NewLedgerHandler(ledgerUsecase)
+	fxHandler := handler.NewFXHandler(fxUsecase)
+	feeHandler := handler.NewFeeHandler(feeUsecase)
+	limitHandler := handler.NewLimitHandler(limitUsecase)
+	statementHandler := handler.NewStatementHandler(statementUsecase)
+	scheduleHandler := handler.NewScheduledTransferHandler(scheduleUsecase)
+	batchHandler := handler.NewTransferBatchHandler(batchUsecase)
+	requestHandler := handler.NewPaymentRequestHandler(requestUsecase)
 
 	// Set up router with middleware
 	r := chi.NewRouter()
@@ -66,37 +214,82 @@ func main() {
 	// Our custom RequestID middleware that checks for the Request-Id header
 	r.Use(middleware.RequestID)
 	
-	// TODO: Uncomment to enable authentication
-	// r.Use(middleware.AuthMiddleware)
-	
 	logger := logging.NewLogger()
 	logger.Info(context.Background(), "Starting wallet application service")
 
 	// API routes
 	r.Route("/api/v1", func(r chi.Router) {
 		// TODO: Implement public routes (no auth required)
-		// - Health check
 		// - API documentation
 		// - Authentication endpoints
 
-		// TODO: Protected routes - require authentication
-		// Wallet routes
-		// Once auth is implemented, replace with:
-		// r.Group(func(r chi.Router) {
-		//     r.Use(middleware.AuthMiddleware)
-		//     r.Post("/deposit", walletHandler.DepositHandler)
-		//     r.Post("/withdraw", walletHandler.WithdrawHandler)
-		//     r.Post("/transfer", walletHandler.TransferHandler)
-		//     r.Get("/balance/{userID}", walletHandler.GetBalanceHandler)
-		//     r.Get("/transactions/{userID}", walletHandler.GetTransactionHistoryHandler)
-		// })
-
-		// For now, routes are open without authentication
-		r.Post("/deposit", walletHandler.DepositHandler)
-		r.Post("/withdraw", walletHandler.WithdrawHandler)
-		r.Post("/transfer", walletHandler.TransferHandler)
-		r.Get("/balance/{userID}", walletHandler.GetBalanceHandler)
-		r.Get("/transactions/{userID}", walletHandler.GetTransactionHistoryHandler)
+		// Registration is public, it is how a user gets an ID to be issued a token for
+		r.Post("/users", userHandler.CreateUserHandler)
+
+		// Protected routes - require a valid bearer token
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.AuthMiddleware(verifier))
+
+			// Money-moving routes honour the Idempotency-Key header
+			r.With(idempotency).Post("/deposit", walletHandler.DepositHandler)
+			r.With(idempotency).Post("/withdraw", walletHandler.WithdrawHandler)
+			r.With(idempotency).Post("/transfer", walletHandler.TransferHandler)
+			r.With(idempotency).Post("/convert", walletHandler.ConvertHandler)
+			r.Get("/balance/{userID}", walletHandler.GetBalanceHandler)
+			r.Get("/transactions/{userID}", walletHandler.GetTransactionHistoryHandler)
+			r.Get("/statements/{userID}", statementHandler.ExportStatementHandler)
+			r.Get("/limits/{userID}", limitHandler.GetLimitsHandler)
+			r.With(idempotency).Post("/transactions/{id}/reverse", walletHandler.ReverseTransactionHandler)
+			r.With(idempotency).Post("/transactions/{id}/refund", walletHandler.RefundTransactionHandler)
+
+			// Quotes lock in an exchange rate for a short while
+			r.Post("/fx/quotes", fxHandler.CreateQuoteHandler)
+
+			// Fee quotes price a transaction before it is made
+			r.Get("/fees/quote", feeHandler.QuoteHandler)
+
+			// Hold routes; placing and capturing holds honour the Idempotency-Key header
+			r.With(idempotency).Post("/holds", holdHandler.PlaceHoldHandler)
+			r.Get("/holds/{id}", holdHandler.GetHoldHandler)
+			r.With(idempotency).Post("/holds/{id}/capture", holdHandler.CaptureHoldHandler)
+			r.With(idempotency).Post("/holds/{id}/void", holdHandler.VoidHoldHandler)
+
+			// Scheduled transfer routes, scoped to the caller
+			r.With(idempotency).Post("/scheduled-transfers", scheduleHandler.CreateScheduleHandler)
+			r.Get("/scheduled-transfers", scheduleHandler.ListSchedulesHandler)
+			r.Get("/scheduled-transfers/{id}", scheduleHandler.GetScheduleHandler)
+			r.Post("/scheduled-transfers/{id}/cancel", scheduleHandler.CancelScheduleHandler)
+			r.Get("/scheduled-transfers/{id}/executions", scheduleHandler.ListExecutionsHandler)
+
+			// Batch transfer routes, scoped to the caller
+			r.With(idempotency).Post("/transfers/batch", batchHandler.CreateBatchHandler)
+			r.Get("/transfers/batch/{id}", batchHandler.GetBatchHandler)
+			r.Get("/transfers/batch/{id}/items", batchHandler.ListItemsHandler)
+
+			// Payment request routes, visible to the requester and the payer
+			r.With(idempotency).Post("/payment-requests", requestHandler.CreateRequestHandler)
+			r.Get("/payment-requests", requestHandler.ListRequestsHandler)
+			r.Get("/payment-requests/{id}", requestHandler.GetRequestHandler)
+			r.With(idempotency).Post("/payment-requests/{id}/accept", requestHandler.AcceptRequestHandler)
+			r.Post("/payment-requests/{id}/decline", requestHandler.DeclineRequestHandler)
+			r.Post("/payment-requests/{id}/cancel", requestHandler.CancelRequestHandler)
+
+			// User profile routes
+			r.Get("/users/{id}", userHandler.GetUserHandler)
+			r.Patch("/users/{id}", userHandler.UpdateUserHandler)
+			r.Post("/users/{id}/wallets", userHandler.CreateWalletHandler)
+			r.Get("/users/{id}/wallets", userHandler.ListWalletsHandler)
+
+			// Webhook routes, scoped to the caller
+			r.Post("/webhooks", webhookHandler.CreateWebhookHandler)
+			r.Get("/webhooks", webhookHandler.ListWebhooksHandler)
+			r.Delete("/webhooks/{id}", webhookHandler.DeleteWebhookHandler)
+			r.Get("/webhooks/{id}/deliveries", webhookHandler.ListDeliveriesHandler)
+			r.Post("/webhooks/{id}/deliveries/{deliveryID}/redeliver", webhookHandler.RedeliverHandler)
+
+			// Ledger audit routes
+			r.Get("/ledger/trial-balance", ledgerHandler.GetTrialBalanceHandler)
+		})
 	})
 
 	// Health check
@@ -114,6 +307,62 @@ func main() {
 		IdleTimeout:  60 * time.Second,
 	}
 
+	// Relay outbox events, deliver webhooks, expire holds and payment requests, run
+	// scheduled transfers, process transfer batches, snapshot balances and reconcile
+	// wallets until shutdown
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	if eventPublisher != nil {
+		relay := worker.NewOutboxRelay(outboxRepo, unitOfWork, eventPublisher, worker.OutboxRelayOptions{
+			PollInterval: cfg.Outbox.PollInterval,
+			BatchSize:    cfg.Outbox.BatchSize,
+		})
+		go relay.Run(workerCtx)
+	}
+	dispatcher := worker.NewWebhookDispatcher(webhookRepo, webhook.NewClient(cfg.Webhook.AllowInsecure), worker.WebhookDispatcherOptions{
+		PollInterval: cfg.Webhook.PollInterval,
+		BatchSize:    cfg.Webhook.BatchSize,
+		Timeout:      cfg.Webhook.Timeout,
+		MaxAttempts:  cfg.Webhook.MaxAttempts,
+		RetryBase:    cfg.Webhook.RetryBase,
+		RetryMax:     cfg.Webhook.RetryMax,
+	})
+	go dispatcher.Run(workerCtx)
+	expirer := worker.NewHoldExpirer(holdUsecase, worker.HoldExpirerOptions{
+		PollInterval: cfg.Hold.ExpiryInterval,
+		BatchSize:    cfg.Hold.ExpiryBatchSize,
+	})
+	go expirer.Run(workerCtx)
+	requestExpirer := worker.NewPaymentRequestExpirer(requestUsecase, worker.PaymentRequestExpirerOptions{
+		PollInterval: cfg.PaymentRequest.ExpiryInterval,
+		BatchSize:    cfg.PaymentRequest.ExpiryBatchSize,
+	})
+	go requestExpirer.Run(workerCtx)
+	scheduler := worker.NewTransferScheduler(scheduleUsecase, worker.TransferSchedulerOptions{
+		PollInterval: cfg.Schedule.PollInterval,
+		BatchSize:    cfg.Schedule.BatchSize,
+	})
+	go scheduler.Run(workerCtx)
+	batchProcessor := worker.NewBatchProcessor(batchUsecase, worker.BatchProcessorOptions{
+		PollInterval: cfg.Batch.PollInterval,
+		BatchSize:    cfg.Batch.BatchSize,
+	})
+	go batchProcessor.Run(workerCtx)
+	snapshotter := worker.NewBalanceSnapshotter(balanceUsecase, worker.BalanceSnapshotterOptions{
+		PollInterval:    cfg.Snapshot.Interval,
+		BatchSize:       cfg.Snapshot.BatchSize,
+		MaxBackfillDays: cfg.Snapshot.MaxBackfillDays,
+	})
+	go snapshotter.Run(workerCtx)
+	if cfg.Reconcile.Interval > 0 {
+		reconciler := worker.NewReconciler(reconciliationUsecase, worker.ReconcilerOptions{
+			Interval:  cfg.Reconcile.Interval,
+			AutoFix:   cfg.Reconcile.AutoFix,
+			BatchSize: cfg.Reconcile.BatchSize,
+		})
+		go reconciler.Run(workerCtx)
+	}
+
 	// Start server in a goroutine so it doesn't block
 	go func() {
 		log.Printf("Server listening on port %s", cfg.Server.Port)
@@ -127,6 +376,7 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	log.Println("Shutting down server...")
+	stopWorkers()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
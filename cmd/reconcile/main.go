@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ravindu/wallet-app-service/internal/config"
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	"github.com/ravindu/wallet-app-service/internal/repository"
+	"github.com/ravindu/wallet-app-service/internal/usecase"
+	"github.com/ravindu/wallet-app-service/pkg/database"
+	"github.com/redis/go-redis/v9"
+)
+
+// exitDiscrepancies is the exit status when discrepancies are left unfixed,
+// so scheduled runs can alert on it
+const exitDiscrepancies = 2
+
+func main() {
+	fix := flag.Bool("fix", false, "reset balances that only disagree with an intact transaction history, recording each correction")
+	wallets := flag.String("wallets", "", "comma-separated wallet IDs to check instead of every wallet")
+	output := flag.String("output", "", "file to write the JSON report to instead of stdout")
+	batchSize := flag.Int("batch-size", 500, "wallet IDs read per query")
+	flag.Parse()
+
+	walletIDs, err := parseWalletIDs(*wallets)
+	if err != nil {
+		log.Fatalf("Invalid -wallets: %v", err)
+	}
+
+	// Load configuration
+	cfg := config.LoadConfig()
+
+	// Connect to PostgreSQL
+	db, err := database.NewPostgresDB(cfg.Postgres)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	// Redis is only needed to drop cached balances after a fix
+	var redisClient *redis.Client
+	if *fix {
+		redisClient, err = database.NewRedisClient(cfg.Redis)
+		if err != nil {
+			log.Printf("Warning: Failed to connect to Redis, cached balances will expire on their own: %v", err)
+		} else {
+			defer redisClient.Close()
+		}
+	}
+
+	reconciliationUsecase := usecase.NewReconciliationUsecase(
+		repository.NewWalletRepository(db),
+		repository.NewLedgerRepository(db),
+		repository.NewReconciliationRepository(db),
+		repository.NewUnitOfWork(db),
+		redisClient,
+	)
+
+	report, err := reconciliationUsecase.Reconcile(context.Background(), domain.ReconcileOptions{
+		WalletIDs: walletIDs,
+		AutoFix:   *fix,
+		BatchSize: *batchSize,
+	})
+	if err != nil {
+		log.Fatalf("Reconciliation failed: %v", err)
+	}
+
+	out := os.Stdout
+	if *output != "" {
+		out, err = os.Create(*output)
+		if err != nil {
+			log.Fatalf("Failed to create report file: %v", err)
+		}
+		defer out.Close()
+	}
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		log.Fatalf("Failed to write report: %v", err)
+	}
+
+	log.Printf("Checked %d wallets and %d transactions: %d discrepancies, %d fixed",
+		report.WalletsChecked, report.TransactionsChecked, len(report.Discrepancies), report.Fixed)
+	if report.Unresolved() > 0 {
+		// Deferred closes don't run after os.Exit
+		out.Close()
+		db.Close()
+		os.Exit(exitDiscrepancies)
+	}
+}
+
+// parseWalletIDs reads a comma-separated list of wallet IDs
+func parseWalletIDs(s string) ([]int64, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	var ids []int64
+	for _, part := range strings.Split(s, ",") {
+		id, err := strconv.ParseInt(strings.TrimSpace(part), 10, 64)
+		if err != nil || id <= 0 {
+			return nil, fmt.Errorf("%q is not a wallet ID", part)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
@@ -5,6 +5,8 @@ import (
 	"log"
 	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/ravindu/wallet-app-service/internal/config"
 	"github.com/ravindu/wallet-app-service/internal/domain"
 	"github.com/ravindu/wallet-app-service/pkg/database"
@@ -63,12 +65,7 @@ func main() {
 		
 		// Create wallet if it doesn't exist
 		if !walletExists {
-			_, err = db.Exec(ctx, `
-				INSERT INTO wallets (user_id, balance, currency, created_at, updated_at)
-				VALUES ($1, $2, $3, $4, $5)
-			`, userID, 1000.00, string(domain.USD), now, now)
-			
-			if err != nil {
+			if err := seedWallet(ctx, db, userID, domain.NewAmount(1000), domain.USD); err != nil {
 				log.Printf("Error creating wallet for user %s: %v", u.username, err)
 				continue
 			}
@@ -78,4 +75,59 @@ func main() {
 	}
 
 	log.Println("Database seeding completed")
-}
\ No newline at end of file
+}
+
+// seedWallet creates a wallet with its ledger account and books the starting
+// balance against cash-in, all in one transaction
+func seedWallet(ctx context.Context, db *pgxpool.Pool, userID int64, balance domain.Amount, currency domain.Currency) error {
+	return pgx.BeginFunc(ctx, db, func(tx pgx.Tx) error {
+		now := time.Now()
+
+		var walletID int64
+		err := tx.QueryRow(ctx, `
+			INSERT INTO wallets (user_id, balance, currency, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING id
+		`, userID, balance, string(currency), now, now).Scan(&walletID)
+		if err != nil {
+			return err
+		}
+
+		var walletAccountID int64
+		err = tx.QueryRow(ctx, `
+			INSERT INTO ledger_accounts (code, type, wallet_id, currency, created_at)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING id
+		`, domain.WalletAccountCode(walletID), domain.WalletAccountType, walletID, string(currency), now).Scan(&walletAccountID)
+		if err != nil {
+			return err
+		}
+
+		var cashInID int64
+		err = tx.QueryRow(ctx, `
+			INSERT INTO ledger_accounts (code, type, wallet_id, currency, created_at)
+			VALUES ($1, $2, NULL, $3, $4)
+			ON CONFLICT (code, currency) DO UPDATE SET code = EXCLUDED.code
+			RETURNING id
+		`, domain.CashInAccount, domain.SystemAccountType, string(currency), now).Scan(&cashInID)
+		if err != nil {
+			return err
+		}
+
+		var entryID int64
+		err = tx.QueryRow(ctx, `
+			INSERT INTO journal_entries (type, description, created_at)
+			VALUES ($1, $2, $3)
+			RETURNING id
+		`, domain.OpeningBalance, "Opening balance", now).Scan(&entryID)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, `
+			INSERT INTO postings (journal_entry_id, account_id, amount, currency, created_at)
+			VALUES ($1, $2, $3, $5, $6), ($1, $4, $7, $5, $6)
+		`, entryID, walletAccountID, balance, cashInID, string(currency), now, -balance)
+		return err
+	})
+}
@@ -3,15 +3,29 @@ package config
 import (
 	"os"
 	"strconv"
+	"time"
 
 	"github.com/ravindu/wallet-app-service/pkg/database"
 )
 
 // Config holds all the configuration for the application
 type Config struct {
-	Server   ServerConfig
-	Postgres database.PostgresConfig
-	Redis    database.RedisConfig
+	Server         ServerConfig
+	Postgres       database.PostgresConfig
+	Redis          database.RedisConfig
+	Idempotency    IdempotencyConfig
+	Auth           AuthConfig
+	Outbox         OutboxConfig
+	Webhook        WebhookConfig
+	Hold           HoldConfig
+	FX             FXConfig
+	Snapshot       SnapshotConfig
+	Reconcile      ReconcileConfig
+	Schedule       ScheduleConfig
+	Batch          BatchConfig
+	PaymentRequest PaymentRequestConfig
+	Fee            FeeConfig
+	Limit          LimitConfig
 }
 
 // ServerConfig holds HTTP server configuration
@@ -19,6 +33,132 @@ type ServerConfig struct {
 	Port string
 }
 
+// IdempotencyConfig holds settings for Idempotency-Key handling
+type IdempotencyConfig struct {
+	// Store is one of "postgres", "redis" or "memory"
+	Store       string
+	TTL         time.Duration
+	LockTimeout time.Duration
+}
+
+// AuthConfig holds settings for JWT bearer token validation
+type AuthConfig struct {
+	// HMACSecret verifies HS256 tokens
+	HMACSecret string
+	// JWKSPath is a JWKS file, or a directory of them, for RS256/ES256 keys
+	JWKSPath            string
+	JWKSRefreshInterval time.Duration
+	Issuer              string
+	Audience            string
+	Leeway              time.Duration
+}
+
+// OutboxConfig holds settings for relaying domain events
+type OutboxConfig struct {
+	// Publisher is one of "log", "redis" or "none" (relay disabled)
+	Publisher string
+	// File is where the log publisher appends events, stdout when empty
+	File string
+	// Stream and StreamMaxLen configure the Redis Streams publisher
+	Stream       string
+	StreamMaxLen int64
+	PollInterval time.Duration
+	BatchSize    int
+}
+
+// WebhookConfig holds settings for delivering webhooks
+type WebhookConfig struct {
+	PollInterval time.Duration
+	BatchSize    int
+	// Timeout bounds each HTTP attempt
+	Timeout time.Duration
+	// MaxAttempts failed attempts move a delivery to DEAD
+	MaxAttempts int
+	RetryBase   time.Duration
+	RetryMax    time.Duration
+	// AllowInsecure accepts http URLs and delivers to private and loopback
+	// addresses. Only turn it on for local development.
+	AllowInsecure bool
+}
+
+// HoldConfig holds settings for holds on wallet funds
+type HoldConfig struct {
+	// DefaultTTL is how long a hold lasts when the request sets no expiry
+	DefaultTTL time.Duration
+	// ExpiryInterval is how often expired holds are released
+	ExpiryInterval  time.Duration
+	ExpiryBatchSize int
+}
+
+// FXConfig holds settings for currency conversion
+type FXConfig struct {
+	// Provider is one of "static", "http" or "none" (conversion disabled)
+	Provider string
+	// RatesFile is the JSON file of "FROM/TO" rates the static provider serves
+	RatesFile string
+	// HTTPURL and HTTPTimeout configure the HTTP rate provider
+	HTTPURL     string
+	HTTPTimeout time.Duration
+	// SpreadBps is the platform's margin on the mid rate, in basis points
+	SpreadBps int
+	// QuoteTTL is how long a quoted rate can be used for
+	QuoteTTL time.Duration
+}
+
+// SnapshotConfig holds settings for the daily balance snapshots
+type SnapshotConfig struct {
+	// Interval is how often the snapshotter checks whether a day has ended
+	Interval  time.Duration
+	BatchSize int
+	// MaxBackfillDays caps how many missed days are caught up on
+	MaxBackfillDays int
+}
+
+// ReconcileConfig holds settings for the scheduled reconciliation
+type ReconcileConfig struct {
+	// Interval is the time between runs; zero leaves reconciliation to cmd/reconcile
+	Interval  time.Duration
+	AutoFix   bool
+	BatchSize int
+}
+
+// ScheduleConfig holds settings for running scheduled transfers
+type ScheduleConfig struct {
+	PollInterval time.Duration
+	BatchSize    int
+	// RetryInterval is how long a failed occurrence waits before it is retried
+	RetryInterval time.Duration
+}
+
+// BatchConfig holds settings for processing transfer batches
+type BatchConfig struct {
+	PollInterval time.Duration
+	BatchSize    int
+}
+
+// PaymentRequestConfig holds settings for payment requests between users
+type PaymentRequestConfig struct {
+	// DefaultTTL is how long a request stays open when it sets no expiry
+	DefaultTTL time.Duration
+	// ExpiryInterval is how often expired requests are closed
+	ExpiryInterval  time.Duration
+	ExpiryBatchSize int
+}
+
+// FeeConfig holds settings for transaction fees
+type FeeConfig struct {
+	// ScheduleFile is the JSON file of versioned fee schedules; empty charges no fees
+	ScheduleFile string
+	// WalletUserID is the platform user whose wallet in each currency collects fees
+	WalletUserID int64
+}
+
+// LimitConfig holds settings for per-user transaction limits
+type LimitConfig struct {
+	// PolicyFile is the JSON file of each tier's limits; empty limits nothing
+	PolicyFile string
+}
+
 // LoadConfig loads configuration from environment variables
 func LoadConfig() *Config {
 	// Server config
@@ -31,13 +171,87 @@ func LoadConfig() *Config {
 	pgPassword := getEnv("POSTGRES_PASSWORD", "postgres")
 	pgDBName := getEnv("POSTGRES_DBNAME", "wallet")
 	pgSSLMode := getEnv("POSTGRES_SSLMODE", "disable")
-	
+
 	// Redis config
 	redisHost := getEnv("REDIS_HOST", "localhost")
 	redisPort, _ := strconv.Atoi(getEnv("REDIS_PORT", "6379"))
 	redisPassword := getEnv("REDIS_PASSWORD", "")
 	redisDB, _ := strconv.Atoi(getEnv("REDIS_DB", "0"))
 
+	// Idempotency config
+	idempotencyStore := getEnv("IDEMPOTENCY_STORE", "postgres")
+	idempotencyTTL := getEnvDuration("IDEMPOTENCY_TTL", 24*time.Hour)
+	idempotencyLockTimeout := getEnvDuration("IDEMPOTENCY_LOCK_TIMEOUT", time.Minute)
+
+	// Auth config
+	authHMACSecret := getEnv("AUTH_HMAC_SECRET", "")
+	authJWKSPath := getEnv("AUTH_JWKS_PATH", "")
+	authJWKSRefreshInterval := getEnvDuration("AUTH_JWKS_REFRESH_INTERVAL", 5*time.Minute)
+	authIssuer := getEnv("AUTH_ISSUER", "")
+	authAudience := getEnv("AUTH_AUDIENCE", "")
+	authLeeway := getEnvDuration("AUTH_LEEWAY", 30*time.Second)
+
+	// Outbox config
+	outboxPublisher := getEnv("OUTBOX_PUBLISHER", "log")
+	outboxFile := getEnv("OUTBOX_FILE", "")
+	outboxStream := getEnv("OUTBOX_REDIS_STREAM", "wallet-events")
+	outboxStreamMaxLen, _ := strconv.ParseInt(getEnv("OUTBOX_REDIS_STREAM_MAXLEN", "0"), 10, 64)
+	outboxPollInterval := getEnvDuration("OUTBOX_POLL_INTERVAL", time.Second)
+	outboxBatchSize, _ := strconv.Atoi(getEnv("OUTBOX_BATCH_SIZE", "100"))
+
+	// Webhook config
+	webhookPollInterval := getEnvDuration("WEBHOOK_POLL_INTERVAL", time.Second)
+	webhookBatchSize, _ := strconv.Atoi(getEnv("WEBHOOK_BATCH_SIZE", "50"))
+	webhookTimeout := getEnvDuration("WEBHOOK_TIMEOUT", 10*time.Second)
+	webhookMaxAttempts, _ := strconv.Atoi(getEnv("WEBHOOK_MAX_ATTEMPTS", "10"))
+	webhookRetryBase := getEnvDuration("WEBHOOK_RETRY_BASE", 10*time.Second)
+	webhookRetryMax := getEnvDuration("WEBHOOK_RETRY_MAX", 6*time.Hour)
+	webhookAllowInsecure, _ := strconv.ParseBool(getEnv("WEBHOOK_ALLOW_INSECURE", "false"))
+
+	// Hold config
+	holdDefaultTTL := getEnvDuration("HOLD_DEFAULT_TTL", 7*24*time.Hour)
+	holdExpiryInterval := getEnvDuration("HOLD_EXPIRY_INTERVAL", time.Minute)
+	holdExpiryBatchSize, _ := strconv.Atoi(getEnv("HOLD_EXPIRY_BATCH_SIZE", "100"))
+
+	// FX config
+	fxProvider := getEnv("FX_PROVIDER", "none")
+	fxRatesFile := getEnv("FX_RATES_FILE", "")
+	fxHTTPURL := getEnv("FX_HTTP_URL", "")
+	fxHTTPTimeout := getEnvDuration("FX_HTTP_TIMEOUT", 5*time.Second)
+	fxSpreadBps, _ := strconv.Atoi(getEnv("FX_SPREAD_BPS", "50"))
+	fxQuoteTTL := getEnvDuration("FX_QUOTE_TTL", 30*time.Second)
+
+	// Balance snapshot config
+	snapshotInterval := getEnvDuration("BALANCE_SNAPSHOT_INTERVAL", time.Hour)
+	snapshotBatchSize, _ := strconv.Atoi(getEnv("BALANCE_SNAPSHOT_BATCH_SIZE", "500"))
+	snapshotMaxBackfillDays, _ := strconv.Atoi(getEnv("BALANCE_SNAPSHOT_MAX_BACKFILL_DAYS", "7"))
+
+	// Reconciliation config
+	reconcileInterval := getEnvDuration("RECONCILE_INTERVAL", 0)
+	reconcileAutoFix, _ := strconv.ParseBool(getEnv("RECONCILE_AUTO_FIX", "false"))
+	reconcileBatchSize, _ := strconv.Atoi(getEnv("RECONCILE_BATCH_SIZE", "500"))
+
+	// Scheduled transfer config
+	schedulePollInterval := getEnvDuration("SCHEDULE_POLL_INTERVAL", 30*time.Second)
+	scheduleBatchSize, _ := strconv.Atoi(getEnv("SCHEDULE_BATCH_SIZE", "50"))
+	scheduleRetryInterval := getEnvDuration("SCHEDULE_RETRY_INTERVAL", time.Hour)
+
+	// Transfer batch config
+	batchPollInterval := getEnvDuration("BATCH_POLL_INTERVAL", 5*time.Second)
+	batchSize, _ := strconv.Atoi(getEnv("BATCH_SIZE", "10"))
+
+	// Payment request config
+	requestDefaultTTL := getEnvDuration("PAYMENT_REQUEST_DEFAULT_TTL", 7*24*time.Hour)
+	requestExpiryInterval := getEnvDuration("PAYMENT_REQUEST_EXPIRY_INTERVAL", time.Minute)
+	requestExpiryBatchSize, _ := strconv.Atoi(getEnv("PAYMENT_REQUEST_EXPIRY_BATCH_SIZE", "100"))
+
+	// Fee config
+	feeScheduleFile := getEnv("FEE_SCHEDULE_FILE", "")
+	feeWalletUserID, _ := strconv.ParseInt(getEnv("FEE_WALLET_USER_ID", "0"), 10, 64)
+
+	// Limit config
+	limitPolicyFile := getEnv("LIMITS_FILE", "")
+
 	return &Config{
 		Server: ServerConfig{
 			Port: port,
@@ -56,6 +270,80 @@ func LoadConfig() *Config {
 			Password: redisPassword,
 			DB:       redisDB,
 		},
+		Idempotency: IdempotencyConfig{
+			Store:       idempotencyStore,
+			TTL:         idempotencyTTL,
+			LockTimeout: idempotencyLockTimeout,
+		},
+		Auth: AuthConfig{
+			HMACSecret:          authHMACSecret,
+			JWKSPath:            authJWKSPath,
+			JWKSRefreshInterval: authJWKSRefreshInterval,
+			Issuer:              authIssuer,
+			Audience:            authAudience,
+			Leeway:              authLeeway,
+		},
+		Outbox: OutboxConfig{
+			Publisher:    outboxPublisher,
+			File:         outboxFile,
+			Stream:       outboxStream,
+			StreamMaxLen: outboxStreamMaxLen,
+			PollInterval: outboxPollInterval,
+			BatchSize:    outboxBatchSize,
+		},
+		Webhook: WebhookConfig{
+			PollInterval:  webhookPollInterval,
+			BatchSize:     webhookBatchSize,
+			Timeout:       webhookTimeout,
+			MaxAttempts:   webhookMaxAttempts,
+			RetryBase:     webhookRetryBase,
+			RetryMax:      webhookRetryMax,
+			AllowInsecure: webhookAllowInsecure,
+		},
+		Hold: HoldConfig{
+			DefaultTTL:      holdDefaultTTL,
+			ExpiryInterval:  holdExpiryInterval,
+			ExpiryBatchSize: holdExpiryBatchSize,
+		},
+		FX: FXConfig{
+			Provider:    fxProvider,
+			RatesFile:   fxRatesFile,
+			HTTPURL:     fxHTTPURL,
+			HTTPTimeout: fxHTTPTimeout,
+			SpreadBps:   fxSpreadBps,
+			QuoteTTL:    fxQuoteTTL,
+		},
+		Snapshot: SnapshotConfig{
+			Interval:        snapshotInterval,
+			BatchSize:       snapshotBatchSize,
+			MaxBackfillDays: snapshotMaxBackfillDays,
+		},
+		Reconcile: ReconcileConfig{
+			Interval:  reconcileInterval,
+			AutoFix:   reconcileAutoFix,
+			BatchSize: reconcileBatchSize,
+		},
+		Schedule: ScheduleConfig{
+			PollInterval:  schedulePollInterval,
+			BatchSize:     scheduleBatchSize,
+			RetryInterval: scheduleRetryInterval,
+		},
+		Batch: BatchConfig{
+			PollInterval: batchPollInterval,
+			BatchSize:    batchSize,
+		},
+		PaymentRequest: PaymentRequestConfig{
+			DefaultTTL:      requestDefaultTTL,
+			ExpiryInterval:  requestExpiryInterval,
+			ExpiryBatchSize: requestExpiryBatchSize,
+		},
+		Fee: FeeConfig{
+			ScheduleFile: feeScheduleFile,
+			WalletUserID: feeWalletUserID,
+		},
+		Limit: LimitConfig{
+			PolicyFile: limitPolicyFile,
+		},
 	}
 }
 
@@ -66,4 +354,14 @@ func getEnv(key, defaultValue string) string {
 		return defaultValue
 	}
 	return value
-}
\ No newline at end of file
+}
+
+// getEnvDuration parses a duration such as "30s" from the environment, falling
+// back to the default when it's missing or malformed
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
@@ -0,0 +1,31 @@
+package domain
+
+import (
+	"time"
+)
+
+// BalanceSnapshot records a wallet's balance at the end of a UTC day, so old
+// balances can be looked up without walking the wallet's whole history.
+// AsOf is the midnight that ends the day; the balance counts every transaction
+// before it.
+type BalanceSnapshot struct {
+	WalletID  int64     `json:"wallet_id"`
+	AsOf      time.Time `json:"as_of"`
+	Balance   Amount    `json:"balance"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// HistoricalBalance is a wallet's ledger balance at a moment in the past.
+// Holds are not tracked over time, so there is no held or available balance.
+type HistoricalBalance struct {
+	WalletID int64     `json:"wallet_id"`
+	UserID   int64     `json:"user_id"`
+	Currency Currency  `json:"currency"`
+	Balance  Amount    `json:"balance"`
+	At       time.Time `json:"at"`
+}
+
+// SnapshotDay returns the midnight UTC that starts t's day
+func SnapshotDay(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
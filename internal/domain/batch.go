@@ -0,0 +1,183 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+)
+
+// MaxBatchItems caps the transfers in one batch
+const MaxBatchItems = 10000
+
+// MaxAllOrNothingItems caps an all-or-nothing batch, whose transfers share one
+// database transaction. That transaction keeps the sender's and every receiver's
+// wallet locked until it commits, so other transfers touching those wallets
+// wait on it; larger payouts have to run best effort.
+const MaxAllOrNothingItems = 100
+
+// BatchMode says what happens to the rest of a batch when one of its transfers fails
+type BatchMode string
+
+const (
+	// BatchAllOrNothing makes every transfer or none of them
+	BatchAllOrNothing BatchMode = "ALL_OR_NOTHING"
+	// BatchBestEffort makes every transfer it can and reports the ones it couldn't
+	BatchBestEffort BatchMode = "BEST_EFFORT"
+)
+
+// BatchStatus tracks a transfer batch from submission to its outcome
+type BatchStatus string
+
+const (
+	// BatchPending is waiting to be picked up
+	BatchPending BatchStatus = "PENDING"
+	// BatchProcessing is being worked through
+	BatchProcessing BatchStatus = "PROCESSING"
+	// BatchCompleted made every transfer
+	BatchCompleted BatchStatus = "COMPLETED"
+	// BatchPartiallyCompleted made some of the transfers; only best-effort batches end this way
+	BatchPartiallyCompleted BatchStatus = "PARTIALLY_COMPLETED"
+	// BatchFailed made none of the transfers
+	BatchFailed BatchStatus = "FAILED"
+)
+
+// BatchItemStatus is the outcome of one transfer in a batch
+type BatchItemStatus string
+
+const (
+	// BatchItemPending hasn't been attempted yet
+	BatchItemPending BatchItemStatus = "PENDING"
+	// BatchItemSucceeded made its transfer
+	BatchItemSucceeded BatchItemStatus = "SUCCEEDED"
+	// BatchItemFailed couldn't make its transfer; Error says why
+	BatchItemFailed BatchItemStatus = "FAILED"
+	// BatchItemSkipped wasn't made, or was rolled back, because another item of an
+	// all-or-nothing batch failed
+	BatchItemSkipped BatchItemStatus = "SKIPPED"
+)
+
+// TransferBatch pays many receivers from one sender wallet. It is submitted in
+// one request and worked through in the background.
+type TransferBatch struct {
+	ID             int64       `json:"id"`
+	UserID         int64       `json:"user_id"`
+	SenderWalletID int64       `json:"sender_wallet_id"`
+	Currency       Currency    `json:"currency"`
+	Mode           BatchMode   `json:"mode"`
+	Status         BatchStatus `json:"status"`
+	ItemCount      int         `json:"item_count"`
+	TotalAmount    Amount      `json:"total_amount"`
+	SucceededCount int         `json:"succeeded_count"`
+	FailedCount    int         `json:"failed_count"`
+	Error          string      `json:"error,omitempty"`
+	// LeaseUntil is how long the worker processing the batch has it to itself
+	LeaseUntil  *time.Time `json:"-"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// ClaimedBy reports whether b is still leased the way it was when it was
+// claimed, i.e. no other worker took it over since
+func (b *TransferBatch) ClaimedBy(claimed *TransferBatch) bool {
+	return b.Status == BatchProcessing &&
+		b.LeaseUntil != nil && claimed.LeaseUntil != nil &&
+		b.LeaseUntil.Equal(*claimed.LeaseUntil)
+}
+
+// Renew extends the lease to until, at the database's microsecond precision so
+// the stored lease compares equal to this one
+func (b *TransferBatch) Renew(until time.Time) {
+	until = until.UTC().Truncate(time.Microsecond)
+	b.LeaseUntil = &until
+}
+
+// Finish settles the batch's status from its item counts
+func (b *TransferBatch) Finish(now time.Time) {
+	switch {
+	case b.FailedCount == 0 && b.SucceededCount == b.ItemCount:
+		b.Status = BatchCompleted
+	case b.SucceededCount == 0:
+		b.Status = BatchFailed
+	default:
+		b.Status = BatchPartiallyCompleted
+	}
+	b.LeaseUntil = nil
+	b.CompletedAt = &now
+}
+
+// TransferBatchItem is one transfer of a batch
+type TransferBatchItem struct {
+	ID      int64 `json:"id"`
+	BatchID int64 `json:"batch_id"`
+	// Line is the item's 1-based position in the submitted list or CSV
+	Line             int             `json:"line"`
+	ReceiverID       int64           `json:"receiver_id"`
+	ReceiverWalletID int64           `json:"receiver_wallet_id,omitempty"`
+	Amount           Amount          `json:"amount"`
+	Comment          string          `json:"comment,omitempty"`
+	Status           BatchItemStatus `json:"status"`
+	TransactionID    *int64          `json:"transaction_id,omitempty"`
+	Error            string          `json:"error,omitempty"`
+}
+
+// TransferRequest is the transfer the item makes out of batch's sender wallet
+func (i *TransferBatchItem) TransferRequest(batch *TransferBatch) TransferRequest {
+	comment := i.Comment
+	if comment == "" {
+		comment = fmt.Sprintf("Batch transfer %d", batch.ID)
+	}
+
+	return TransferRequest{
+		SenderID:         batch.UserID,
+		ReceiverID:       i.ReceiverID,
+		SenderWalletID:   batch.SenderWalletID,
+		ReceiverWalletID: i.ReceiverWalletID,
+		Amount:           i.Amount,
+		Comment:          comment,
+	}
+}
+
+// Prepare checks a new batch and its items, totals them up and leaves them all pending
+func (b *TransferBatch) Prepare(items []*TransferBatchItem) error {
+	if b.Mode != BatchAllOrNothing && b.Mode != BatchBestEffort {
+		return fmt.Errorf("%w: mode must be %s or %s", apperrors.ErrInvalidInput, BatchAllOrNothing, BatchBestEffort)
+	}
+	if len(items) == 0 {
+		return fmt.Errorf("%w: a batch needs at least one item", apperrors.ErrInvalidInput)
+	}
+	if len(items) > MaxBatchItems {
+		return fmt.Errorf("%w: a batch holds at most %d items", apperrors.ErrInvalidInput, MaxBatchItems)
+	}
+	if b.Mode == BatchAllOrNothing && len(items) > MaxAllOrNothingItems {
+		return fmt.Errorf("%w: an %s batch holds at most %d items, send larger ones as %s",
+			apperrors.ErrInvalidInput, BatchAllOrNothing, MaxAllOrNothingItems, BatchBestEffort)
+	}
+
+	var total Amount
+	for _, item := range items {
+		switch {
+		case item.ReceiverID <= 0:
+			return fmt.Errorf("%w: line %d: receiver_id is required", apperrors.ErrInvalidInput, item.Line)
+		case item.ReceiverID == b.UserID:
+			return fmt.Errorf("%w: line %d: %v", apperrors.ErrInvalidInput, item.Line, apperrors.ErrSenderReceiverSame)
+		case item.Amount <= 0:
+			return fmt.Errorf("%w: line %d: %v", apperrors.ErrInvalidInput, item.Line, apperrors.ErrInvalidAmount)
+		}
+		if err := b.Currency.CheckPrecision(item.Amount); err != nil {
+			return fmt.Errorf("line %d: %w", item.Line, err)
+		}
+
+		var err error
+		if total, err = total.Add(item.Amount); err != nil {
+			return fmt.Errorf("batch total: %w", err)
+		}
+		item.Status = BatchItemPending
+	}
+
+	b.Status = BatchPending
+	b.ItemCount = len(items)
+	b.TotalAmount = total
+	return nil
+}
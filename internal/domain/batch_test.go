@@ -0,0 +1,153 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransferBatch_Prepare(t *testing.T) {
+	newItems := func() []*domain.TransferBatchItem {
+		return []*domain.TransferBatchItem{
+			{Line: 1, ReceiverID: 2, Amount: domain.NewAmount(10)},
+			{Line: 2, ReceiverID: 3, Amount: domain.NewAmount(3)},
+		}
+	}
+
+	tests := []struct {
+		name          string
+		modify        func(b *domain.TransferBatch, items []*domain.TransferBatchItem) []*domain.TransferBatchItem
+		expectedError error
+	}{
+		{name: "valid", modify: func(b *domain.TransferBatch, items []*domain.TransferBatchItem) []*domain.TransferBatchItem {
+			return items
+		}},
+		{
+			name: "unknown mode",
+			modify: func(b *domain.TransferBatch, items []*domain.TransferBatchItem) []*domain.TransferBatchItem {
+				b.Mode = "SOMETIMES"
+				return items
+			},
+			expectedError: apperrors.ErrInvalidInput,
+		},
+		{
+			name: "no items",
+			modify: func(b *domain.TransferBatch, items []*domain.TransferBatchItem) []*domain.TransferBatchItem {
+				return nil
+			},
+			expectedError: apperrors.ErrInvalidInput,
+		},
+		{
+			name: "too many items",
+			modify: func(b *domain.TransferBatch, items []*domain.TransferBatchItem) []*domain.TransferBatchItem {
+				return make([]*domain.TransferBatchItem, domain.MaxBatchItems+1)
+			},
+			expectedError: apperrors.ErrInvalidInput,
+		},
+		{
+			name: "too many items to make all or nothing",
+			modify: func(b *domain.TransferBatch, items []*domain.TransferBatchItem) []*domain.TransferBatchItem {
+				return make([]*domain.TransferBatchItem, domain.MaxAllOrNothingItems+1)
+			},
+			expectedError: apperrors.ErrInvalidInput,
+		},
+		{
+			name: "paying the sender",
+			modify: func(b *domain.TransferBatch, items []*domain.TransferBatchItem) []*domain.TransferBatchItem {
+				items[1].ReceiverID = b.UserID
+				return items
+			},
+			expectedError: apperrors.ErrInvalidInput,
+		},
+		{
+			name: "zero amount",
+			modify: func(b *domain.TransferBatch, items []*domain.TransferBatchItem) []*domain.TransferBatchItem {
+				items[0].Amount = 0
+				return items
+			},
+			expectedError: apperrors.ErrInvalidInput,
+		},
+		{
+			name: "finer than the currency allows",
+			modify: func(b *domain.TransferBatch, items []*domain.TransferBatchItem) []*domain.TransferBatchItem {
+				items[0].Amount = domain.Amount(1)
+				return items
+			},
+			expectedError: apperrors.ErrAmountPrecision,
+		},
+		{
+			name: "total overflows",
+			modify: func(b *domain.TransferBatch, items []*domain.TransferBatchItem) []*domain.TransferBatchItem {
+				items[0].Amount = domain.Amount((1<<63 - 1) / 100 * 100)
+				items[1].Amount = domain.Amount((1<<63 - 1) / 100 * 100)
+				return items
+			},
+			expectedError: apperrors.ErrAmountOverflow,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			batch := &domain.TransferBatch{UserID: 1, Currency: domain.USD, Mode: domain.BatchAllOrNothing}
+			items := tc.modify(batch, newItems())
+
+			err := batch.Prepare(items)
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, domain.BatchPending, batch.Status)
+			assert.Equal(t, 2, batch.ItemCount)
+			assert.Equal(t, domain.NewAmount(13), batch.TotalAmount)
+			assert.Equal(t, domain.BatchItemPending, items[0].Status)
+		})
+	}
+}
+
+func TestTransferBatch_Finish(t *testing.T) {
+	tests := []struct {
+		name      string
+		succeeded int
+		failed    int
+		expected  domain.BatchStatus
+	}{
+		{name: "every transfer made", succeeded: 3, expected: domain.BatchCompleted},
+		{name: "some transfers made", succeeded: 2, failed: 1, expected: domain.BatchPartiallyCompleted},
+		{name: "no transfers made", failed: 3, expected: domain.BatchFailed},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			lease := time.Now().Add(time.Minute)
+			batch := &domain.TransferBatch{
+				ItemCount: 3, SucceededCount: tc.succeeded, FailedCount: tc.failed,
+				Status: domain.BatchProcessing, LeaseUntil: &lease,
+			}
+
+			batch.Finish(time.Now())
+			assert.Equal(t, tc.expected, batch.Status)
+			assert.Nil(t, batch.LeaseUntil)
+			assert.NotNil(t, batch.CompletedAt)
+		})
+	}
+}
+
+func TestTransferBatch_ClaimedBy(t *testing.T) {
+	lease := time.Now().Add(time.Minute)
+	claimed := &domain.TransferBatch{ID: 1, Status: domain.BatchProcessing, LeaseUntil: &lease}
+
+	locked := *claimed
+	assert.True(t, locked.ClaimedBy(claimed))
+
+	locked.Renew(lease.Add(time.Minute))
+	assert.False(t, locked.ClaimedBy(claimed), "another worker renewed the lease")
+
+	locked = *claimed
+	locked.Finish(time.Now())
+	assert.False(t, locked.ClaimedBy(claimed), "the batch is finished")
+}
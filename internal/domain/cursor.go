@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+)
+
+// CursorDirection says which way a cursor pages through a listing
+type CursorDirection string
+
+const (
+	// CursorNext pages on through the listing, to older transactions when
+	// they are listed newest first
+	CursorNext CursorDirection = "next"
+	// CursorPrev pages back towards the start of the listing
+	CursorPrev CursorDirection = "prev"
+)
+
+// TransactionCursor marks a position in a wallet's history by the
+// (transaction_time, id) of the row it was taken from. A next cursor returns the
+// rows after it in listing order and a prev cursor the rows before it. Unlike an
+// offset it doesn't drift when new transactions arrive.
+type TransactionCursor struct {
+	Time      time.Time       `json:"t"`
+	ID        int64           `json:"id"`
+	Direction CursorDirection `json:"d"`
+}
+
+// NewTransactionCursor returns a cursor paging from transaction in direction
+func NewTransactionCursor(transaction *Transaction, direction CursorDirection) TransactionCursor {
+	return TransactionCursor{
+		Time:      transaction.TransactionTime.UTC(),
+		ID:        transaction.ID,
+		Direction: direction,
+	}
+}
+
+// Encode turns the cursor into the opaque token handed to clients
+func (c TransactionCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeTransactionCursor reads a token made by Encode
+func DecodeTransactionCursor(token string) (TransactionCursor, error) {
+	var cursor TransactionCursor
+
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return cursor, apperrors.ErrInvalidCursor
+	}
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return cursor, apperrors.ErrInvalidCursor
+	}
+	if cursor.ID <= 0 || cursor.Time.IsZero() {
+		return cursor, apperrors.ErrInvalidCursor
+	}
+	if cursor.Direction != CursorNext && cursor.Direction != CursorPrev {
+		return cursor, fmt.Errorf("%w: unknown direction %q", apperrors.ErrInvalidCursor, cursor.Direction)
+	}
+
+	return cursor, nil
+}
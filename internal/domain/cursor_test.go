@@ -0,0 +1,47 @@
+package domain_test
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransactionCursor_RoundTrip(t *testing.T) {
+	transaction := &domain.Transaction{
+		ID:              42,
+		TransactionTime: time.Date(2024, 5, 1, 12, 30, 0, 123456000, time.FixedZone("UTC+2", 2*60*60)),
+	}
+
+	cursor := domain.NewTransactionCursor(transaction, domain.CursorPrev)
+	decoded, err := domain.DecodeTransactionCursor(cursor.Encode())
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(42), decoded.ID)
+	assert.Equal(t, domain.CursorPrev, decoded.Direction)
+	assert.True(t, transaction.TransactionTime.Equal(decoded.Time))
+	assert.Equal(t, time.UTC, decoded.Time.Location())
+}
+
+func TestDecodeTransactionCursor_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{name: "not base64", token: "%%%"},
+		{name: "not JSON", token: base64.RawURLEncoding.EncodeToString([]byte("hello"))},
+		{name: "missing ID", token: base64.RawURLEncoding.EncodeToString([]byte(`{"t":"2024-05-01T00:00:00Z","d":"next"}`))},
+		{name: "missing time", token: base64.RawURLEncoding.EncodeToString([]byte(`{"id":1,"d":"next"}`))},
+		{name: "unknown direction", token: base64.RawURLEncoding.EncodeToString([]byte(`{"t":"2024-05-01T00:00:00Z","id":1,"d":"up"}`))},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := domain.DecodeTransactionCursor(tc.token)
+			assert.ErrorIs(t, err, apperrors.ErrInvalidCursor)
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// EventType names a domain event published to downstream services
+type EventType string
+
+const (
+	// WalletCredited is published when money arrives in a wallet
+	WalletCredited EventType = "wallet.credited"
+	// WalletDebited is published when money leaves a wallet
+	WalletDebited EventType = "wallet.debited"
+	// TransferCompleted is published once per transfer, after both wallets are updated
+	TransferCompleted EventType = "transfer.completed"
+	// PaymentRequestCreatedEvent is published when a user asks another for money
+	PaymentRequestCreatedEvent EventType = "payment_request.created"
+	// PaymentRequestAcceptedEvent is published once the payer has paid a request
+	PaymentRequestAcceptedEvent EventType = "payment_request.accepted"
+	// PaymentRequestDeclinedEvent is published when the payer turns a request down
+	PaymentRequestDeclinedEvent EventType = "payment_request.declined"
+	// PaymentRequestCancelledEvent is published when the requester withdraws a request
+	PaymentRequestCancelledEvent EventType = "payment_request.cancelled"
+	// PaymentRequestExpiredEvent is published when a request runs out unanswered
+	PaymentRequestExpiredEvent EventType = "payment_request.expired"
+)
+
+// WalletAggregate is the aggregate type of events about a single wallet.
+// Events are delivered in order per aggregate.
+const WalletAggregate = "wallet"
+
+// PaymentRequestAggregate is the aggregate type of events about a payment request
+const PaymentRequestAggregate = "payment_request"
+
+// OutboxEvent is a domain event waiting in, or relayed from, the outbox
+type OutboxEvent struct {
+	ID            int64           `json:"id"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   int64           `json:"aggregate_id"`
+	Type          EventType       `json:"type"`
+	Payload       json.RawMessage `json:"payload"`
+	CreatedAt     time.Time       `json:"created_at"`
+	PublishedAt   *time.Time      `json:"published_at,omitempty"`
+	Attempts      int             `json:"attempts"`
+	LastError     string          `json:"last_error,omitempty"`
+	NextAttemptAt time.Time       `json:"-"`
+}
+
+// NewWalletEvent builds an event about a wallet with payload encoded as JSON
+func NewWalletEvent(eventType EventType, walletID int64, payload any) (*OutboxEvent, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s event: %w", eventType, err)
+	}
+
+	return &OutboxEvent{
+		AggregateType: WalletAggregate,
+		AggregateID:   walletID,
+		Type:          eventType,
+		Payload:       data,
+	}, nil
+}
+
+// NewPaymentRequestEvent builds an event about a payment request, carrying the
+// request as it stands after the change
+func NewPaymentRequestEvent(eventType EventType, request *PaymentRequest) (*OutboxEvent, error) {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s event: %w", eventType, err)
+	}
+
+	return &OutboxEvent{
+		AggregateType: PaymentRequestAggregate,
+		AggregateID:   request.ID,
+		Type:          eventType,
+		Payload:       data,
+	}, nil
+}
+
+// WalletActivity is the payload of wallet.credited and wallet.debited events
+type WalletActivity struct {
+	UserID      int64        `json:"user_id"`
+	WalletID    int64        `json:"wallet_id"`
+	Currency    Currency     `json:"currency"`
+	Transaction *Transaction `json:"transaction"`
+}
+
+// TransferCompletion is the payload of transfer.completed events
+type TransferCompletion struct {
+	CorrelationID    string   `json:"correlation_id"`
+	SenderUserID     int64    `json:"sender_user_id"`
+	SenderWalletID   int64    `json:"sender_wallet_id"`
+	ReceiverUserID   int64    `json:"receiver_user_id"`
+	ReceiverWalletID int64    `json:"receiver_wallet_id"`
+	Amount           Amount   `json:"amount"`
+	Currency         Currency `json:"currency"`
+	Description      string   `json:"description,omitempty"`
+	// Fee is what the sender was charged on top of Amount, if anything
+	Fee *Amount `json:"fee,omitempty"`
+	// Set when the transfer was converted into the receiver's currency
+	ReceiverAmount   *Amount  `json:"receiver_amount,omitempty"`
+	ReceiverCurrency Currency `json:"receiver_currency,omitempty"`
+	FXRate           *Rate    `json:"fx_rate,omitempty"`
+}
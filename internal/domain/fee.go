@@ -0,0 +1,280 @@
+package domain
+
+import (
+	"fmt"
+	"math/big"
+	"slices"
+	"time"
+
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+)
+
+const (
+	// Fee is the transaction type of a fee charged to a wallet. Its row points at
+	// the transaction it was charged for via ParentTransactionID.
+	Fee TransactionType = "FEE"
+	// FeeCollected is the transaction type of a fee paid into the platform's fee
+	// wallet. Its row points at the payer's FEE row via ParentTransactionID.
+	FeeCollected TransactionType = "FEE_COLLECTED"
+)
+
+// maxFeeVersionLength matches the fee_version column
+const maxFeeVersionLength = 50
+
+// FeeTransactionTypes are the transactions a fee rule can price. Transfer covers
+// every transfer, including scheduled, batch and payment request ones.
+var FeeTransactionTypes = []TransactionType{Deposit, Withdrawal, Transfer}
+
+// FeeSchedule is one version of the platform's fee rules. It applies from
+// EffectiveFrom until the next version takes over.
+type FeeSchedule struct {
+	Version       string    `json:"version"`
+	EffectiveFrom time.Time `json:"effective_from"`
+	Rules         []FeeRule `json:"rules"`
+}
+
+// FeeRule prices one transaction type, in one currency or, with Currency left
+// out, in any currency without a rule of its own. The fee is Flat plus Bps basis
+// points of the amount, or comes from the first of Tiers that covers the amount,
+// and is then kept between Min and Max.
+type FeeRule struct {
+	TransactionType TransactionType `json:"transaction_type"`
+	Currency        Currency        `json:"currency,omitempty"`
+	Flat            Amount          `json:"flat,omitempty"`
+	Bps             int             `json:"bps,omitempty"`
+	Min             Amount          `json:"min,omitempty"`
+	// Max caps the fee; zero means no cap
+	Max   Amount    `json:"max,omitempty"`
+	Tiers []FeeTier `json:"tiers,omitempty"`
+}
+
+// FeeTier prices amounts up to UpTo. The last tier leaves UpTo out and covers
+// everything above the tier before it.
+type FeeTier struct {
+	UpTo Amount `json:"up_to,omitempty"`
+	Flat Amount `json:"flat,omitempty"`
+	Bps  int    `json:"bps,omitempty"`
+}
+
+// FeeQuote is the fee on a transaction under the schedule in force
+type FeeQuote struct {
+	TransactionType TransactionType `json:"transaction_type"`
+	Currency        Currency        `json:"currency"`
+	Amount          Amount          `json:"amount"`
+	Fee             Amount          `json:"fee"`
+	// Total is what leaves the wallet, the amount plus the fee
+	Total Amount `json:"total"`
+	// Version is the fee schedule the fee comes from, empty when none applies
+	Version string `json:"fee_version,omitempty"`
+}
+
+// FeeSchedules are every version of the fee rules, oldest first
+type FeeSchedules []FeeSchedule
+
+// NewFeeSchedules checks every version and sorts them by when they take effect
+func NewFeeSchedules(schedules []FeeSchedule) (FeeSchedules, error) {
+	sorted := slices.Clone(schedules)
+	slices.SortFunc(sorted, func(a, b FeeSchedule) int {
+		return a.EffectiveFrom.Compare(b.EffectiveFrom)
+	})
+
+	versions := make(map[string]bool, len(sorted))
+	for i, schedule := range sorted {
+		if schedule.Version == "" || len(schedule.Version) > maxFeeVersionLength {
+			return nil, fmt.Errorf("%w: every fee schedule needs a version of at most %d characters", apperrors.ErrInvalidInput, maxFeeVersionLength)
+		}
+		if versions[schedule.Version] {
+			return nil, fmt.Errorf("%w: fee schedule version %q is used twice", apperrors.ErrInvalidInput, schedule.Version)
+		}
+		versions[schedule.Version] = true
+
+		if i > 0 && schedule.EffectiveFrom.Equal(sorted[i-1].EffectiveFrom) {
+			return nil, fmt.Errorf("%w: fee schedules %q and %q take effect at the same time",
+				apperrors.ErrInvalidInput, sorted[i-1].Version, schedule.Version)
+		}
+		if err := schedule.Validate(); err != nil {
+			return nil, fmt.Errorf("fee schedule %q: %w", schedule.Version, err)
+		}
+	}
+
+	return sorted, nil
+}
+
+// At returns the schedule in force at t, or nil before the first one
+func (s FeeSchedules) At(t time.Time) *FeeSchedule {
+	for i := len(s) - 1; i >= 0; i-- {
+		if !s[i].EffectiveFrom.After(t) {
+			return &s[i]
+		}
+	}
+	return nil
+}
+
+// Validate checks every rule and that no two rules price the same transactions
+func (s *FeeSchedule) Validate() error {
+	seen := make(map[string]bool, len(s.Rules))
+	for _, rule := range s.Rules {
+		if err := rule.Validate(); err != nil {
+			return err
+		}
+
+		key := string(rule.TransactionType) + "/" + string(rule.Currency)
+		if seen[key] {
+			return fmt.Errorf("%w: more than one %s rule for %q", apperrors.ErrInvalidInput, rule.TransactionType, rule.Currency)
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
+// Validate checks a rule's type, prices and tiers
+func (r *FeeRule) Validate() error {
+	if !slices.Contains(FeeTransactionTypes, r.TransactionType) {
+		return fmt.Errorf("%w: fees can't be charged on %q transactions", apperrors.ErrInvalidInput, r.TransactionType)
+	}
+	if r.Currency != "" {
+		if _, err := r.Currency.MinorUnits(); err != nil {
+			return err
+		}
+	}
+	if r.Min < 0 || r.Max < 0 {
+		return fmt.Errorf("%w: %s fee caps can't be negative", apperrors.ErrInvalidInput, r.TransactionType)
+	}
+	if r.Max > 0 && r.Max < r.Min {
+		return fmt.Errorf("%w: %s fee max is below its min", apperrors.ErrInvalidInput, r.TransactionType)
+	}
+
+	if len(r.Tiers) == 0 {
+		return checkFeePrice(r.TransactionType, r.Flat, r.Bps)
+	}
+	if r.Flat != 0 || r.Bps != 0 {
+		return fmt.Errorf("%w: a tiered %s fee takes its price from the tiers", apperrors.ErrInvalidInput, r.TransactionType)
+	}
+
+	for i, tier := range r.Tiers {
+		if err := checkFeePrice(r.TransactionType, tier.Flat, tier.Bps); err != nil {
+			return err
+		}
+
+		last := i == len(r.Tiers)-1
+		switch {
+		case last && tier.UpTo != 0:
+			return fmt.Errorf("%w: the last %s fee tier must leave up_to out", apperrors.ErrInvalidInput, r.TransactionType)
+		case !last && tier.UpTo <= 0:
+			return fmt.Errorf("%w: only the last %s fee tier can leave up_to out", apperrors.ErrInvalidInput, r.TransactionType)
+		case i > 0 && !last && tier.UpTo <= r.Tiers[i-1].UpTo:
+			return fmt.Errorf("%w: %s fee tiers must be in increasing order of up_to", apperrors.ErrInvalidInput, r.TransactionType)
+		}
+	}
+	return nil
+}
+
+// checkFeePrice rejects negative flat fees and percentages outside 0-100%
+func checkFeePrice(transactionType TransactionType, flat Amount, bps int) error {
+	if flat < 0 {
+		return fmt.Errorf("%w: %s flat fee can't be negative", apperrors.ErrInvalidInput, transactionType)
+	}
+	if bps < 0 || bps > basisPoints {
+		return fmt.Errorf("%w: %s fee must be between 0 and %d basis points", apperrors.ErrInvalidInput, transactionType, basisPoints)
+	}
+	return nil
+}
+
+// Rule returns the rule pricing transactionType in currency, preferring one for
+// that currency over one for any currency, or nil when there is none
+func (s *FeeSchedule) Rule(transactionType TransactionType, currency Currency) *FeeRule {
+	var fallback *FeeRule
+	for i := range s.Rules {
+		rule := &s.Rules[i]
+		if rule.TransactionType != transactionType {
+			continue
+		}
+		if rule.Currency == currency {
+			return rule
+		}
+		if rule.Currency == "" {
+			fallback = rule
+		}
+	}
+	return fallback
+}
+
+// Calculate prices the fee on amount in currency. The fee is rounded half up to
+// the currency's minor unit.
+func (r *FeeRule) Calculate(amount Amount, currency Currency) (Amount, error) {
+	units, err := currency.MinorUnits()
+	if err != nil {
+		return 0, err
+	}
+
+	flat, bps := r.Flat, r.Bps
+	for _, tier := range r.Tiers {
+		if tier.UpTo == 0 || amount <= tier.UpTo {
+			flat, bps = tier.Flat, tier.Bps
+			break
+		}
+	}
+
+	// flat + amount * bps / 10000, exactly, before rounding
+	fee := new(big.Rat).SetFrac(new(big.Int).Mul(big.NewInt(int64(amount)), big.NewInt(int64(bps))), big.NewInt(basisPoints))
+	fee.Add(fee, new(big.Rat).SetInt64(int64(flat)))
+
+	if fee.Cmp(new(big.Rat).SetInt64(int64(r.Min))) < 0 {
+		fee.SetInt64(int64(r.Min))
+	}
+	if r.Max > 0 && fee.Cmp(new(big.Rat).SetInt64(int64(r.Max))) > 0 {
+		fee.SetInt64(int64(r.Max))
+	}
+
+	// Whole steps of the currency's minor unit, in Amount units
+	step := int64(1)
+	for i := units; i < AmountScale; i++ {
+		step *= 10
+	}
+
+	rounded := roundHalfUp(fee.Quo(fee, new(big.Rat).SetInt64(step)))
+	rounded.Mul(rounded, big.NewInt(step))
+	if !rounded.IsInt64() {
+		return 0, apperrors.ErrAmountOverflow
+	}
+	return Amount(rounded.Int64()), nil
+}
+
+// Quote prices the fee on a transaction at t. Transactions that no schedule or
+// rule covers are free.
+func (s FeeSchedules) Quote(transactionType TransactionType, currency Currency, amount Amount, t time.Time) (*FeeQuote, error) {
+	if amount <= 0 {
+		return nil, apperrors.ErrInvalidAmount
+	}
+
+	quote := &FeeQuote{
+		TransactionType: transactionType,
+		Currency:        currency,
+		Amount:          amount,
+		Total:           amount,
+	}
+
+	schedule := s.At(t)
+	if schedule == nil {
+		return quote, nil
+	}
+	quote.Version = schedule.Version
+
+	rule := schedule.Rule(transactionType, currency)
+	if rule == nil {
+		return quote, nil
+	}
+
+	fee, err := rule.Calculate(amount, currency)
+	if err != nil {
+		return nil, err
+	}
+	total, err := amount.Add(fee)
+	if err != nil {
+		return nil, err
+	}
+
+	quote.Fee = fee
+	quote.Total = total
+	return quote, nil
+}
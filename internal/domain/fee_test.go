@@ -0,0 +1,169 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeeRule_Calculate(t *testing.T) {
+	tiered := domain.FeeRule{
+		TransactionType: domain.Transfer,
+		Tiers: []domain.FeeTier{
+			{UpTo: domain.NewAmount(100), Flat: domain.NewAmount(1)},
+			{UpTo: domain.NewAmount(1000), Bps: 50},
+			{Bps: 25},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		rule     domain.FeeRule
+		amount   domain.Amount
+		currency domain.Currency
+		expected string
+	}{
+		{name: "no price", rule: domain.FeeRule{}, amount: domain.NewAmount(100), currency: domain.USD, expected: "0"},
+		{name: "flat", rule: domain.FeeRule{Flat: domain.NewAmount(1)}, amount: domain.NewAmount(100), currency: domain.USD, expected: "1"},
+		{name: "percentage", rule: domain.FeeRule{Bps: 150}, amount: domain.NewAmount(200), currency: domain.USD, expected: "3"},
+		{name: "flat plus percentage", rule: domain.FeeRule{Flat: domain.Amount(3000), Bps: 290}, amount: domain.NewAmount(10), currency: domain.USD, expected: "0.59"},
+		{name: "rounded half up to cents", rule: domain.FeeRule{Bps: 50}, amount: domain.Amount(10100), currency: domain.USD, expected: "0.01"},
+		{name: "rounded half up to whole yen", rule: domain.FeeRule{Bps: 100}, amount: domain.NewAmount(150), currency: domain.JPY, expected: "2"},
+		{name: "raised to the minimum", rule: domain.FeeRule{Bps: 10, Min: domain.Amount(5000)}, amount: domain.NewAmount(10), currency: domain.USD, expected: "0.5"},
+		{name: "capped at the maximum", rule: domain.FeeRule{Bps: 100, Max: domain.NewAmount(5)}, amount: domain.NewAmount(1000), currency: domain.USD, expected: "5"},
+		{name: "first tier", rule: tiered, amount: domain.NewAmount(100), currency: domain.USD, expected: "1"},
+		{name: "middle tier", rule: tiered, amount: domain.NewAmount(500), currency: domain.USD, expected: "2.5"},
+		{name: "last tier", rule: tiered, amount: domain.NewAmount(2000), currency: domain.USD, expected: "5"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fee, err := tc.rule.Calculate(tc.amount, tc.currency)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, fee.String())
+		})
+	}
+}
+
+func TestFeeRule_Validate(t *testing.T) {
+	tests := []struct {
+		name          string
+		rule          domain.FeeRule
+		expectedError error
+	}{
+		{name: "valid flat and percentage", rule: domain.FeeRule{TransactionType: domain.Transfer, Flat: domain.NewAmount(1), Bps: 50}},
+		{name: "valid tiers", rule: domain.FeeRule{TransactionType: domain.Withdrawal, Tiers: []domain.FeeTier{
+			{UpTo: domain.NewAmount(100), Flat: domain.NewAmount(1)},
+			{Bps: 25},
+		}}},
+		{name: "unpriced type", rule: domain.FeeRule{TransactionType: domain.Refund}, expectedError: apperrors.ErrInvalidInput},
+		{name: "unknown currency", rule: domain.FeeRule{TransactionType: domain.Transfer, Currency: "XYZ"}, expectedError: apperrors.ErrUnsupportedCurrency},
+		{name: "negative flat fee", rule: domain.FeeRule{TransactionType: domain.Transfer, Flat: domain.NewAmount(-1)}, expectedError: apperrors.ErrInvalidInput},
+		{name: "percentage above 100%", rule: domain.FeeRule{TransactionType: domain.Transfer, Bps: 10001}, expectedError: apperrors.ErrInvalidInput},
+		{name: "max below min", rule: domain.FeeRule{TransactionType: domain.Transfer, Min: domain.NewAmount(2), Max: domain.NewAmount(1)}, expectedError: apperrors.ErrInvalidInput},
+		{name: "tiers with a rule price", rule: domain.FeeRule{TransactionType: domain.Transfer, Bps: 10, Tiers: []domain.FeeTier{{Bps: 25}}}, expectedError: apperrors.ErrInvalidInput},
+		{name: "bounded last tier", rule: domain.FeeRule{TransactionType: domain.Transfer, Tiers: []domain.FeeTier{{UpTo: domain.NewAmount(100)}}}, expectedError: apperrors.ErrInvalidInput},
+		{name: "unbounded middle tier", rule: domain.FeeRule{TransactionType: domain.Transfer, Tiers: []domain.FeeTier{{Bps: 10}, {Bps: 25}}}, expectedError: apperrors.ErrInvalidInput},
+		{name: "tiers out of order", rule: domain.FeeRule{TransactionType: domain.Transfer, Tiers: []domain.FeeTier{
+			{UpTo: domain.NewAmount(100)},
+			{UpTo: domain.NewAmount(50)},
+			{Bps: 25},
+		}}, expectedError: apperrors.ErrInvalidInput},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.rule.Validate()
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestNewFeeSchedules(t *testing.T) {
+	jan := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	jun := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	schedules, err := domain.NewFeeSchedules([]domain.FeeSchedule{
+		{Version: "v2", EffectiveFrom: jun},
+		{Version: "v1", EffectiveFrom: jan},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "v1", schedules[0].Version)
+	assert.Equal(t, "v2", schedules[1].Version)
+
+	tests := []struct {
+		name      string
+		schedules []domain.FeeSchedule
+	}{
+		{name: "missing version", schedules: []domain.FeeSchedule{{EffectiveFrom: jan}}},
+		{name: "repeated version", schedules: []domain.FeeSchedule{{Version: "v1", EffectiveFrom: jan}, {Version: "v1", EffectiveFrom: jun}}},
+		{name: "same effective time", schedules: []domain.FeeSchedule{{Version: "v1", EffectiveFrom: jan}, {Version: "v2", EffectiveFrom: jan}}},
+		{name: "two rules for the same transactions", schedules: []domain.FeeSchedule{{Version: "v1", EffectiveFrom: jan, Rules: []domain.FeeRule{
+			{TransactionType: domain.Transfer, Bps: 10},
+			{TransactionType: domain.Transfer, Bps: 20},
+		}}}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := domain.NewFeeSchedules(tc.schedules)
+			assert.ErrorIs(t, err, apperrors.ErrInvalidInput)
+		})
+	}
+}
+
+func TestFeeSchedules_Quote(t *testing.T) {
+	jan := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	jun := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	schedules, err := domain.NewFeeSchedules([]domain.FeeSchedule{
+		{Version: "v1", EffectiveFrom: jan, Rules: []domain.FeeRule{
+			{TransactionType: domain.Transfer, Flat: domain.NewAmount(1)},
+		}},
+		{Version: "v2", EffectiveFrom: jun, Rules: []domain.FeeRule{
+			{TransactionType: domain.Transfer, Flat: domain.NewAmount(2)},
+			{TransactionType: domain.Transfer, Currency: domain.EUR, Flat: domain.NewAmount(3)},
+		}},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name            string
+		transactionType domain.TransactionType
+		currency        domain.Currency
+		at              time.Time
+		expectedFee     string
+		expectedVersion string
+	}{
+		{name: "before any schedule", transactionType: domain.Transfer, currency: domain.USD, at: jan.Add(-time.Hour), expectedFee: "0"},
+		{name: "first version", transactionType: domain.Transfer, currency: domain.USD, at: jan.AddDate(0, 2, 0), expectedFee: "1", expectedVersion: "v1"},
+		{name: "second version", transactionType: domain.Transfer, currency: domain.USD, at: jun, expectedFee: "2", expectedVersion: "v2"},
+		{name: "currency rule preferred", transactionType: domain.Transfer, currency: domain.EUR, at: jun, expectedFee: "3", expectedVersion: "v2"},
+		{name: "no rule for the type", transactionType: domain.Deposit, currency: domain.USD, at: jun, expectedFee: "0", expectedVersion: "v2"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			amount := domain.NewAmount(100)
+			quote, err := schedules.Quote(tc.transactionType, tc.currency, amount, tc.at)
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedFee, quote.Fee.String())
+			assert.Equal(t, tc.expectedVersion, quote.Version)
+			assert.Equal(t, amount+quote.Fee, quote.Total)
+		})
+	}
+
+	_, err = schedules.Quote(domain.Transfer, domain.USD, 0, jun)
+	assert.ErrorIs(t, err, apperrors.ErrInvalidAmount)
+}
@@ -0,0 +1,189 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+)
+
+// RateScale is the number of decimal places a Rate carries. It matches the
+// DECIMAL(19, 8) rate columns in the database.
+const RateScale = 8
+
+// rateFactor is 10^RateScale
+const rateFactor = 100000000
+
+// basisPoints is the number of basis points in a whole
+const basisPoints = 10000
+
+// Rate is an exchange rate stored as a fixed-point integer of 10^-8, so 0.9215
+// is held as 92150000. It says how many units of the target currency one unit
+// of the source currency buys.
+type Rate int64
+
+// ParseRate reads a decimal string such as "0.9215" into a Rate. Rates finer
+// than RateScale decimal places are rounded half up.
+func ParseRate(s string) (Rate, error) {
+	value, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+	if !ok {
+		return 0, fmt.Errorf("%w: invalid rate %q", apperrors.ErrInvalidInput, s)
+	}
+	if value.Sign() <= 0 {
+		return 0, fmt.Errorf("%w: rate %q must be positive", apperrors.ErrInvalidInput, s)
+	}
+
+	scaled := value.Mul(value, new(big.Rat).SetInt64(rateFactor))
+	rounded := roundHalfUp(scaled)
+	if !rounded.IsInt64() || rounded.Sign() <= 0 {
+		return 0, fmt.Errorf("%w: rate %q is out of range", apperrors.ErrInvalidInput, s)
+	}
+	return Rate(rounded.Int64()), nil
+}
+
+// roundHalfUp rounds a positive rational to the nearest integer
+func roundHalfUp(value *big.Rat) *big.Int {
+	doubled := new(big.Int).Mul(value.Num(), big.NewInt(2))
+	doubled.Add(doubled, value.Denom())
+	denom := new(big.Int).Mul(value.Denom(), big.NewInt(2))
+	return doubled.Quo(doubled, denom)
+}
+
+// String formats the rate as a plain decimal with trailing zeros removed
+func (r Rate) String() string {
+	return formatFixed(int64(r), RateScale, rateFactor)
+}
+
+// Inverse returns the rate of the opposite direction, rounded half up
+func (r Rate) Inverse() (Rate, error) {
+	if r <= 0 {
+		return 0, fmt.Errorf("%w: rate %s has no inverse", apperrors.ErrInvalidInput, r)
+	}
+
+	inverse := new(big.Rat).SetFrac(big.NewInt(rateFactor*rateFactor), big.NewInt(int64(r)))
+	rounded := roundHalfUp(inverse)
+	if !rounded.IsInt64() || rounded.Sign() <= 0 {
+		return 0, fmt.Errorf("%w: rate %s has no inverse", apperrors.ErrInvalidInput, r)
+	}
+	return Rate(rounded.Int64()), nil
+}
+
+// WithSpread returns the rate a customer gets once the platform's spread, in
+// basis points, is taken off it. The result is rounded down.
+func (r Rate) WithSpread(spreadBps int) (Rate, error) {
+	if spreadBps < 0 || spreadBps >= basisPoints {
+		return 0, fmt.Errorf("%w: spread must be between 0 and %d basis points", apperrors.ErrInvalidInput, basisPoints-1)
+	}
+
+	spread := new(big.Int).Mul(big.NewInt(int64(r)), big.NewInt(int64(basisPoints-spreadBps)))
+	spread.Quo(spread, big.NewInt(basisPoints))
+	if spread.Sign() <= 0 {
+		return 0, fmt.Errorf("%w: rate %s is too small for the spread", apperrors.ErrInvalidInput, r)
+	}
+	return Rate(spread.Int64()), nil
+}
+
+// Convert prices amount in the target currency. The result is rounded down to
+// the target currency's minor unit, so a conversion never pays out more than
+// the rate allows.
+func (r Rate) Convert(amount Amount, target Currency) (Amount, error) {
+	units, err := target.MinorUnits()
+	if err != nil {
+		return 0, err
+	}
+
+	// Whole steps of the target's minor unit, in Amount units
+	step := int64(1)
+	for i := units; i < AmountScale; i++ {
+		step *= 10
+	}
+
+	converted := new(big.Int).Mul(big.NewInt(int64(amount)), big.NewInt(int64(r)))
+	converted.Quo(converted, big.NewInt(rateFactor*step))
+	converted.Mul(converted, big.NewInt(step))
+	if !converted.IsInt64() {
+		return 0, apperrors.ErrAmountOverflow
+	}
+	if converted.Sign() <= 0 {
+		return 0, fmt.Errorf("%w: %s converts to less than the smallest %s unit", apperrors.ErrInvalidAmount, amount, target)
+	}
+	return Amount(converted.Int64()), nil
+}
+
+// MarshalJSON writes the rate as an exact JSON number
+func (r Rate) MarshalJSON() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// UnmarshalJSON accepts either a JSON number or a quoted decimal string
+func (r *Rate) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+
+	parsed, err := ParseRate(strings.Trim(s, `"`))
+	if err != nil {
+		return err
+	}
+
+	*r = parsed
+	return nil
+}
+
+// Scan reads a NUMERIC column, which pgx hands over in its text form
+func (r *Rate) Scan(src any) error {
+	switch v := src.(type) {
+	case string:
+		parsed, err := ParseRate(v)
+		if err != nil {
+			return err
+		}
+		*r = parsed
+		return nil
+	case []byte:
+		return r.Scan(string(v))
+	default:
+		return fmt.Errorf("%w: cannot scan %T into Rate", apperrors.ErrInvalidInput, src)
+	}
+}
+
+// Value writes the rate as an exact decimal string for NUMERIC columns
+func (r Rate) Value() (driver.Value, error) {
+	return r.String(), nil
+}
+
+// FXQuote locks an exchange rate for one conversion until it expires. MidRate
+// is what the rate provider quoted, Rate what the customer gets after the spread.
+type FXQuote struct {
+	ID           string     `json:"id"`
+	UserID       int64      `json:"user_id"`
+	FromCurrency Currency   `json:"from_currency"`
+	ToCurrency   Currency   `json:"to_currency"`
+	MidRate      Rate       `json:"mid_rate"`
+	Rate         Rate       `json:"rate"`
+	SpreadBps    int        `json:"spread_bps"`
+	Source       string     `json:"source"`
+	ExpiresAt    time.Time  `json:"expires_at"`
+	UsedAt       *time.Time `json:"used_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// Use marks the quote as spent on a conversion from one currency to another
+func (q *FXQuote) Use(from, to Currency, now time.Time) error {
+	if q.UsedAt != nil {
+		return fmt.Errorf("%w: quote %s was already used", apperrors.ErrQuoteExpired, q.ID)
+	}
+	if !now.Before(q.ExpiresAt) {
+		return fmt.Errorf("%w: quote %s expired at %s", apperrors.ErrQuoteExpired, q.ID, q.ExpiresAt.Format(time.RFC3339))
+	}
+	if q.FromCurrency != from || q.ToCurrency != to {
+		return fmt.Errorf("%w: quote %s is for %s to %s, not %s to %s", apperrors.ErrCurrencyMismatch, q.ID, q.FromCurrency, q.ToCurrency, from, to)
+	}
+
+	q.UsedAt = &now
+	return nil
+}
@@ -0,0 +1,177 @@
+package domain_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		expected      domain.Rate
+		expectedError error
+	}{
+		{name: "whole number", input: "2", expected: domain.Rate(200000000)},
+		{name: "decimal", input: "0.9215", expected: domain.Rate(92150000)},
+		{name: "rounded half up", input: "0.123456785", expected: domain.Rate(12345679)},
+		{name: "rounded down", input: "0.123456784", expected: domain.Rate(12345678)},
+		{name: "zero", input: "0", expectedError: apperrors.ErrInvalidInput},
+		{name: "negative", input: "-1.5", expectedError: apperrors.ErrInvalidInput},
+		{name: "too small", input: "0.000000001", expectedError: apperrors.ErrInvalidInput},
+		{name: "not a number", input: "abc", expectedError: apperrors.ErrInvalidInput},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rate, err := domain.ParseRate(tc.input)
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, rate)
+			}
+		})
+	}
+}
+
+func TestRate_Convert(t *testing.T) {
+	tests := []struct {
+		name          string
+		rate          string
+		amount        domain.Amount
+		target        domain.Currency
+		expected      domain.Amount
+		expectedError error
+	}{
+		{name: "USD to EUR", rate: "0.92", amount: domain.NewAmount(100), target: domain.EUR, expected: domain.NewAmount(92)},
+		{name: "rounded down to cents", rate: "0.9215", amount: domain.Amount(10001), target: domain.EUR, expected: domain.Amount(9200)},
+		{name: "rounded down to whole yen", rate: "151.23456789", amount: domain.NewAmount(10), target: domain.JPY, expected: domain.NewAmount(1512)},
+		{name: "JPY to USD", rate: "0.0066", amount: domain.NewAmount(1512), target: domain.USD, expected: domain.Amount(99700)},
+		{name: "less than a cent", rate: "0.5", amount: domain.Amount(1), target: domain.EUR, expectedError: apperrors.ErrInvalidAmount},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rate, err := domain.ParseRate(tc.rate)
+			assert.NoError(t, err)
+
+			converted, err := rate.Convert(tc.amount, tc.target)
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, converted)
+			}
+		})
+	}
+}
+
+func TestRate_WithSpread(t *testing.T) {
+	rate, err := domain.ParseRate("0.92")
+	assert.NoError(t, err)
+
+	// 50 basis points off 0.92
+	spread, err := rate.WithSpread(50)
+	assert.NoError(t, err)
+	assert.Equal(t, "0.9154", spread.String())
+
+	unchanged, err := rate.WithSpread(0)
+	assert.NoError(t, err)
+	assert.Equal(t, rate, unchanged)
+
+	_, err = rate.WithSpread(10000)
+	assert.ErrorIs(t, err, apperrors.ErrInvalidInput)
+
+	_, err = rate.WithSpread(-1)
+	assert.ErrorIs(t, err, apperrors.ErrInvalidInput)
+}
+
+func TestRate_Inverse(t *testing.T) {
+	rate, err := domain.ParseRate("0.8")
+	assert.NoError(t, err)
+
+	inverse, err := rate.Inverse()
+	assert.NoError(t, err)
+	assert.Equal(t, "1.25", inverse.String())
+
+	rate, err = domain.ParseRate("3")
+	assert.NoError(t, err)
+
+	inverse, err = rate.Inverse()
+	assert.NoError(t, err)
+	assert.Equal(t, "0.33333333", inverse.String())
+}
+
+func TestRate_JSON(t *testing.T) {
+	var quote domain.FXQuote
+	err := json.Unmarshal([]byte(`{"rate": 0.9215, "mid_rate": "0.925"}`), &quote)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.Rate(92150000), quote.Rate)
+	assert.Equal(t, domain.Rate(92500000), quote.MidRate)
+
+	data, err := json.Marshal(quote)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"rate":0.9215`)
+}
+
+func TestFXQuote_Use(t *testing.T) {
+	now := time.Now()
+	usedAt := now.Add(-time.Second)
+
+	tests := []struct {
+		name          string
+		quote         domain.FXQuote
+		from          domain.Currency
+		to            domain.Currency
+		expectedError error
+	}{
+		{
+			name:  "valid",
+			quote: domain.FXQuote{FromCurrency: domain.USD, ToCurrency: domain.EUR, ExpiresAt: now.Add(time.Minute)},
+			from:  domain.USD,
+			to:    domain.EUR,
+		},
+		{
+			name:          "expired",
+			quote:         domain.FXQuote{FromCurrency: domain.USD, ToCurrency: domain.EUR, ExpiresAt: now},
+			from:          domain.USD,
+			to:            domain.EUR,
+			expectedError: apperrors.ErrQuoteExpired,
+		},
+		{
+			name:          "already used",
+			quote:         domain.FXQuote{FromCurrency: domain.USD, ToCurrency: domain.EUR, ExpiresAt: now.Add(time.Minute), UsedAt: &usedAt},
+			from:          domain.USD,
+			to:            domain.EUR,
+			expectedError: apperrors.ErrQuoteExpired,
+		},
+		{
+			name:          "other direction",
+			quote:         domain.FXQuote{FromCurrency: domain.USD, ToCurrency: domain.EUR, ExpiresAt: now.Add(time.Minute)},
+			from:          domain.EUR,
+			to:            domain.USD,
+			expectedError: apperrors.ErrCurrencyMismatch,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.quote.Use(tc.from, tc.to, now)
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, now, *tc.quote.UsedAt)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+)
+
+// HoldStatus tracks a hold through its lifecycle. Only ACTIVE holds reserve money.
+type HoldStatus string
+
+const (
+	// HoldActive means the money is reserved and can still be captured or voided
+	HoldActive HoldStatus = "ACTIVE"
+	// HoldCaptured means the hold was settled, possibly for less than its amount
+	HoldCaptured HoldStatus = "CAPTURED"
+	// HoldVoided means the hold was cancelled and the money released
+	HoldVoided HoldStatus = "VOIDED"
+	// HoldExpired means the hold ran out before it was captured
+	HoldExpired HoldStatus = "EXPIRED"
+)
+
+// Hold reserves money in a wallet without moving it, like a card authorization.
+// Capturing it withdraws the captured amount; whatever is not captured is released.
+type Hold struct {
+	ID             int64      `json:"id"`
+	WalletID       int64      `json:"wallet_id"`
+	UserID         int64      `json:"user_id"`
+	Amount         Amount     `json:"amount"`
+	CapturedAmount Amount     `json:"captured_amount"`
+	Status         HoldStatus `json:"status"`
+	Description    string     `json:"description,omitempty"`
+	TransactionID  *int64     `json:"transaction_id,omitempty"`
+	ExpiresAt      time.Time  `json:"expires_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// checkActive rejects holds that can no longer be captured or voided
+func (h *Hold) checkActive(now time.Time) error {
+	if h.Status != HoldActive {
+		return fmt.Errorf("%w: hold is %s", apperrors.ErrHoldNotActive, h.Status)
+	}
+	if !now.Before(h.ExpiresAt) {
+		return fmt.Errorf("%w: hold expired at %s", apperrors.ErrHoldNotActive, h.ExpiresAt.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// Capture settles amount of the hold. A hold is captured once; the rest of it
+// is released.
+func (h *Hold) Capture(amount Amount, now time.Time) error {
+	if err := h.checkActive(now); err != nil {
+		return err
+	}
+	if amount <= 0 {
+		return apperrors.ErrInvalidAmount
+	}
+	if amount > h.Amount {
+		return apperrors.ErrCaptureTooLarge
+	}
+
+	h.CapturedAmount = amount
+	h.Status = HoldCaptured
+	h.UpdatedAt = now
+	return nil
+}
+
+// Void cancels the hold, releasing all of it
+func (h *Hold) Void(now time.Time) error {
+	if err := h.checkActive(now); err != nil {
+		return err
+	}
+
+	h.Status = HoldVoided
+	h.UpdatedAt = now
+	return nil
+}
+
+// Expire ends an active hold whose time has run out
+func (h *Hold) Expire(now time.Time) error {
+	if h.Status != HoldActive {
+		return fmt.Errorf("%w: hold is %s", apperrors.ErrHoldNotActive, h.Status)
+	}
+	if now.Before(h.ExpiresAt) {
+		return fmt.Errorf("%w: hold expires at %s", apperrors.ErrHoldNotActive, h.ExpiresAt.Format(time.RFC3339))
+	}
+
+	h.Status = HoldExpired
+	h.UpdatedAt = now
+	return nil
+}
@@ -0,0 +1,87 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHold_Capture(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name          string
+		status        domain.HoldStatus
+		expiresAt     time.Time
+		amount        domain.Amount
+		expectedError error
+	}{
+		{
+			name:      "full capture",
+			status:    domain.HoldActive,
+			expiresAt: now.Add(time.Hour),
+			amount:    domain.NewAmount(100),
+		},
+		{
+			name:      "partial capture",
+			status:    domain.HoldActive,
+			expiresAt: now.Add(time.Hour),
+			amount:    domain.NewAmount(40),
+		},
+		{
+			name:          "capture above the held amount",
+			status:        domain.HoldActive,
+			expiresAt:     now.Add(time.Hour),
+			amount:        domain.NewAmount(101),
+			expectedError: apperrors.ErrCaptureTooLarge,
+		},
+		{
+			name:          "already voided",
+			status:        domain.HoldVoided,
+			expiresAt:     now.Add(time.Hour),
+			amount:        domain.NewAmount(100),
+			expectedError: apperrors.ErrHoldNotActive,
+		},
+		{
+			name:          "expired but not yet swept",
+			status:        domain.HoldActive,
+			expiresAt:     now.Add(-time.Minute),
+			amount:        domain.NewAmount(100),
+			expectedError: apperrors.ErrHoldNotActive,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			hold := &domain.Hold{Amount: domain.NewAmount(100), Status: tc.status, ExpiresAt: tc.expiresAt}
+
+			err := hold.Capture(tc.amount, now)
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				assert.Equal(t, tc.status, hold.Status)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, domain.HoldCaptured, hold.Status)
+			assert.Equal(t, tc.amount, hold.CapturedAmount)
+		})
+	}
+}
+
+func TestHold_Expire(t *testing.T) {
+	now := time.Now()
+
+	hold := &domain.Hold{Amount: domain.NewAmount(100), Status: domain.HoldActive, ExpiresAt: now.Add(time.Minute)}
+	assert.ErrorIs(t, hold.Expire(now), apperrors.ErrHoldNotActive)
+
+	hold.ExpiresAt = now
+	assert.NoError(t, hold.Expire(now))
+	assert.Equal(t, domain.HoldExpired, hold.Status)
+
+	// An expired hold can't be voided any more
+	assert.ErrorIs(t, hold.Void(now), apperrors.ErrHoldNotActive)
+}
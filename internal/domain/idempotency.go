@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"time"
+)
+
+// IdempotencyStatus tracks where a keyed request is in its lifecycle
+type IdempotencyStatus string
+
+const (
+	// IdempotencyInProgress means the first request with this key is still running
+	IdempotencyInProgress IdempotencyStatus = "IN_PROGRESS"
+	// IdempotencyCompleted means the response has been stored and can be replayed
+	IdempotencyCompleted IdempotencyStatus = "COMPLETED"
+)
+
+// IdempotencyRecord is the stored outcome of a request sent with an Idempotency-Key.
+// While in progress ExpiresAt acts as a lock timeout, so a crashed request
+// doesn't block its key for the full retention period.
+type IdempotencyRecord struct {
+	Key          string            `json:"key"`
+	UserID       int64             `json:"user_id"`
+	Fingerprint  string            `json:"fingerprint"`
+	Status       IdempotencyStatus `json:"status"`
+	ResponseCode int               `json:"response_code,omitempty"`
+	ResponseBody []byte            `json:"response_body,omitempty"`
+	CreatedAt    time.Time         `json:"created_at"`
+	ExpiresAt    time.Time         `json:"expires_at"`
+}
+
+// HeldBy reports whether r is still the in-progress reservation that reserved
+// was given, and not one a retry took after reserved's lock timed out
+func (r *IdempotencyRecord) HeldBy(reserved *IdempotencyRecord) bool {
+	return r.Status == IdempotencyInProgress &&
+		r.Fingerprint == reserved.Fingerprint &&
+		r.CreatedAt.Equal(reserved.CreatedAt)
+}
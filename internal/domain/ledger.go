@@ -0,0 +1,171 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+)
+
+// AccountType separates user wallets from the platform's own accounts
+type AccountType string
+
+const (
+	// WalletAccountType is the ledger account behind a user's wallet
+	WalletAccountType AccountType = "WALLET"
+	// SystemAccountType is an account owned by the platform
+	SystemAccountType AccountType = "SYSTEM"
+)
+
+// System account codes. Each exists once per currency.
+const (
+	// CashInAccount is the source of money deposited from outside the platform
+	CashInAccount = "cash-in"
+	// CashOutAccount is where withdrawn money leaves the platform
+	CashOutAccount = "cash-out"
+	// FeesAccount collects fees charged by the platform
+	FeesAccount = "fees"
+	// FXAccount is the platform's position in a currency. Conversions pay into it
+	// in the source currency and out of it in the target currency.
+	FXAccount = "fx"
+)
+
+// OpeningBalance is the journal entry type used when a balance is brought onto the ledger
+const OpeningBalance TransactionType = "OPENING_BALANCE"
+
+// Conversion is the journal entry type of a conversion between a user's own wallets
+const Conversion TransactionType = "CONVERSION"
+
+// LedgerAccount is an account that postings are made against
+type LedgerAccount struct {
+	ID        int64       `json:"id"`
+	Code      string      `json:"code"`
+	Type      AccountType `json:"type"`
+	WalletID  *int64      `json:"wallet_id,omitempty"`
+	Currency  Currency    `json:"currency"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// WalletAccountCode is the account code used for a wallet's ledger account
+func WalletAccountCode(walletID int64) string {
+	return fmt.Sprintf("wallet:%d", walletID)
+}
+
+// Posting moves an amount into (positive) or out of (negative) one account
+type Posting struct {
+	ID             int64     `json:"id"`
+	JournalEntryID int64     `json:"journal_entry_id"`
+	AccountID      int64     `json:"account_id"`
+	Amount         Amount    `json:"amount"`
+	Currency       Currency  `json:"currency"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// JournalEntry groups the postings of a single money movement.
+// Its postings always sum to zero in every currency.
+type JournalEntry struct {
+	ID          int64           `json:"id"`
+	Type        TransactionType `json:"type"`
+	Description string          `json:"description"`
+	Postings    []Posting       `json:"postings"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+// NewJournalEntry builds an entry moving amount from one account to another
+func NewJournalEntry(entryType TransactionType, description string, from, to *LedgerAccount, amount Amount) *JournalEntry {
+	return &JournalEntry{
+		Type:        entryType,
+		Description: description,
+		Postings: []Posting{
+			{AccountID: from.ID, Amount: -amount, Currency: from.Currency},
+			{AccountID: to.ID, Amount: amount, Currency: to.Currency},
+		},
+	}
+}
+
+// NewConversionEntry builds an entry converting sourceAmount out of from into
+// targetAmount in to, through the platform's fx accounts in each currency
+func NewConversionEntry(
+	entryType TransactionType,
+	description string,
+	from, fxSource, fxTarget, to *LedgerAccount,
+	sourceAmount, targetAmount Amount,
+) *JournalEntry {
+	return &JournalEntry{
+		Type:        entryType,
+		Description: description,
+		Postings: []Posting{
+			{AccountID: from.ID, Amount: -sourceAmount, Currency: from.Currency},
+			{AccountID: fxSource.ID, Amount: sourceAmount, Currency: fxSource.Currency},
+			{AccountID: fxTarget.ID, Amount: -targetAmount, Currency: fxTarget.Currency},
+			{AccountID: to.ID, Amount: targetAmount, Currency: to.Currency},
+		},
+	}
+}
+
+// Validate checks the entry is balanced and every posting moves money
+func (e *JournalEntry) Validate() error {
+	if len(e.Postings) < 2 {
+		return fmt.Errorf("%w: an entry needs at least two postings", apperrors.ErrUnbalancedEntry)
+	}
+
+	totals := make(map[Currency]Amount)
+	for _, posting := range e.Postings {
+		if posting.Amount == 0 {
+			return fmt.Errorf("%w: posting to account %d is zero", apperrors.ErrUnbalancedEntry, posting.AccountID)
+		}
+
+		total, err := totals[posting.Currency].Add(posting.Amount)
+		if err != nil {
+			return err
+		}
+		totals[posting.Currency] = total
+	}
+
+	for currency, total := range totals {
+		if total != 0 {
+			return fmt.Errorf("%w: %s postings sum to %s", apperrors.ErrUnbalancedEntry, currency, total)
+		}
+	}
+
+	return nil
+}
+
+// AccountBalance is an account with the sum of all its postings
+type AccountBalance struct {
+	Account *LedgerAccount `json:"account"`
+	Balance Amount         `json:"balance"`
+}
+
+// TrialBalance lists every account balance. The books are balanced when the
+// total for every currency is zero.
+type TrialBalance struct {
+	Accounts []*AccountBalance   `json:"accounts"`
+	Totals   map[Currency]Amount `json:"totals"`
+	Balanced bool                `json:"balanced"`
+}
+
+// NewTrialBalance totals account balances per currency
+func NewTrialBalance(accounts []*AccountBalance) (*TrialBalance, error) {
+	trialBalance := &TrialBalance{
+		Accounts: accounts,
+		Totals:   make(map[Currency]Amount),
+		Balanced: true,
+	}
+
+	for _, account := range accounts {
+		total, err := trialBalance.Totals[account.Account.Currency].Add(account.Balance)
+		if err != nil {
+			return nil, err
+		}
+		trialBalance.Totals[account.Account.Currency] = total
+	}
+
+	for _, total := range trialBalance.Totals {
+		if total != 0 {
+			trialBalance.Balanced = false
+		}
+	}
+
+	return trialBalance, nil
+}
@@ -0,0 +1,85 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJournalEntry_Validate(t *testing.T) {
+	cashIn := &domain.LedgerAccount{ID: 1, Code: domain.CashInAccount, Currency: domain.USD}
+	wallet := &domain.LedgerAccount{ID: 2, Code: domain.WalletAccountCode(1), Currency: domain.USD}
+
+	tests := []struct {
+		name          string
+		entry         *domain.JournalEntry
+		expectedError bool
+	}{
+		{
+			name:  "balanced entry",
+			entry: domain.NewJournalEntry(domain.Deposit, "", cashIn, wallet, domain.NewAmount(50)),
+		},
+		{
+			name: "postings don't sum to zero",
+			entry: &domain.JournalEntry{Postings: []domain.Posting{
+				{AccountID: 1, Amount: domain.NewAmount(-50), Currency: domain.USD},
+				{AccountID: 2, Amount: domain.NewAmount(40), Currency: domain.USD},
+			}},
+			expectedError: true,
+		},
+		{
+			name: "balanced overall but not per currency",
+			entry: &domain.JournalEntry{Postings: []domain.Posting{
+				{AccountID: 1, Amount: domain.NewAmount(-50), Currency: domain.USD},
+				{AccountID: 2, Amount: domain.NewAmount(50), Currency: domain.Currency("EUR")},
+			}},
+			expectedError: true,
+		},
+		{
+			name: "single posting",
+			entry: &domain.JournalEntry{Postings: []domain.Posting{
+				{AccountID: 1, Amount: domain.NewAmount(50), Currency: domain.USD},
+			}},
+			expectedError: true,
+		},
+		{
+			name:          "zero amount",
+			entry:         domain.NewJournalEntry(domain.Deposit, "", cashIn, wallet, 0),
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.entry.Validate()
+
+			if tc.expectedError {
+				assert.ErrorIs(t, err, apperrors.ErrUnbalancedEntry)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestNewTrialBalance(t *testing.T) {
+	cashIn := &domain.LedgerAccount{ID: 1, Code: domain.CashInAccount, Currency: domain.USD}
+	wallet := &domain.LedgerAccount{ID: 2, Code: domain.WalletAccountCode(1), Currency: domain.USD}
+
+	balanced, err := domain.NewTrialBalance([]*domain.AccountBalance{
+		{Account: cashIn, Balance: domain.NewAmount(-150)},
+		{Account: wallet, Balance: domain.NewAmount(150)},
+	})
+	assert.NoError(t, err)
+	assert.True(t, balanced.Balanced)
+	assert.Equal(t, domain.Amount(0), balanced.Totals[domain.USD])
+
+	drifted, err := domain.NewTrialBalance([]*domain.AccountBalance{
+		{Account: cashIn, Balance: domain.NewAmount(-150)},
+		{Account: wallet, Balance: domain.NewAmount(100)},
+	})
+	assert.NoError(t, err)
+	assert.False(t, drifted.Balanced)
+}
@@ -0,0 +1,218 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+)
+
+// UserTier groups users who share the same transaction limits
+type UserTier string
+
+// StandardTier is every user's tier until they are moved to another. Users in a
+// tier the limit policy doesn't list get the standard tier's limits.
+const StandardTier UserTier = "standard"
+
+// maxUserTierLength matches the users.tier column
+const maxUserTierLength = 20
+
+// The rolling windows daily and monthly limits are counted over
+const (
+	DailyLimitWindow   = 24 * time.Hour
+	MonthlyLimitWindow = 30 * 24 * time.Hour
+)
+
+// LimitTransactionTypes are the transactions limits can be set on, in the order
+// they are listed
+var LimitTransactionTypes = []TransactionType{Deposit, Withdrawal, Transfer}
+
+// LimitRowTypes returns the transaction rows that count towards a limit on
+// transactionType. Transfers are counted on the sender's side, including
+// transfers recorded before they were booked on both sides.
+func LimitRowTypes(transactionType TransactionType) []TransactionType {
+	if transactionType == Transfer {
+		return []TransactionType{TransferOut, Transfer}
+	}
+	return []TransactionType{transactionType}
+}
+
+// LimitRule caps one transaction type for a tier, in one currency or, with
+// Currency left out, in any currency without a rule of its own. Amounts are in
+// the wallet's currency and totals are kept per wallet. A zero limit is no limit.
+type LimitRule struct {
+	TransactionType TransactionType `json:"transaction_type"`
+	Currency        Currency        `json:"currency,omitempty"`
+	PerTransaction  Amount          `json:"per_transaction,omitempty"`
+	DailyAmount     Amount          `json:"daily_amount,omitempty"`
+	DailyCount      int             `json:"daily_count,omitempty"`
+	MonthlyAmount   Amount          `json:"monthly_amount,omitempty"`
+	MonthlyCount    int             `json:"monthly_count,omitempty"`
+}
+
+// LimitPolicy holds the limit rules of each tier
+type LimitPolicy map[UserTier][]LimitRule
+
+// LimitUsage is the total and number of one type of transaction a wallet made
+// within a window
+type LimitUsage struct {
+	Amount Amount `json:"amount"`
+	Count  int    `json:"count"`
+}
+
+// LimitStatus shows a wallet's limits on one transaction type and what is left of them
+type LimitStatus struct {
+	TransactionType TransactionType `json:"transaction_type"`
+	PerTransaction  *Amount         `json:"per_transaction,omitempty"`
+	Daily           LimitWindow     `json:"daily"`
+	Monthly         LimitWindow     `json:"monthly"`
+}
+
+// LimitWindow shows what was used of a rolling window's limits and what is left.
+// Limits that aren't set are left out.
+type LimitWindow struct {
+	Used            LimitUsage `json:"used"`
+	AmountLimit     *Amount    `json:"amount_limit,omitempty"`
+	AmountRemaining *Amount    `json:"amount_remaining,omitempty"`
+	CountLimit      *int       `json:"count_limit,omitempty"`
+	CountRemaining  *int       `json:"count_remaining,omitempty"`
+}
+
+// NewLimitPolicy checks every tier's rules
+func NewLimitPolicy(tiers map[UserTier][]LimitRule) (LimitPolicy, error) {
+	for tier, rules := range tiers {
+		if tier == "" || len(tier) > maxUserTierLength {
+			return nil, fmt.Errorf("%w: tier names must be 1 to %d characters", apperrors.ErrInvalidInput, maxUserTierLength)
+		}
+
+		seen := make(map[string]bool, len(rules))
+		for _, rule := range rules {
+			if err := rule.Validate(); err != nil {
+				return nil, fmt.Errorf("tier %q: %w", tier, err)
+			}
+
+			key := string(rule.TransactionType) + "/" + string(rule.Currency)
+			if seen[key] {
+				return nil, fmt.Errorf("%w: tier %q has more than one %s limit for %q",
+					apperrors.ErrInvalidInput, tier, rule.TransactionType, rule.Currency)
+			}
+			seen[key] = true
+		}
+	}
+	return LimitPolicy(tiers), nil
+}
+
+// Rule returns the rule limiting transactionType in currency for tier, preferring
+// one for that currency over one for any currency, or nil when there is none
+func (p LimitPolicy) Rule(tier UserTier, transactionType TransactionType, currency Currency) *LimitRule {
+	rules, ok := p[tier]
+	if !ok {
+		rules = p[StandardTier]
+	}
+
+	var fallback *LimitRule
+	for i := range rules {
+		rule := &rules[i]
+		if rule.TransactionType != transactionType {
+			continue
+		}
+		if rule.Currency == currency {
+			return rule
+		}
+		if rule.Currency == "" {
+			fallback = rule
+		}
+	}
+	return fallback
+}
+
+// Validate checks a rule's type, currency and limits
+func (r *LimitRule) Validate() error {
+	if !slices.Contains(LimitTransactionTypes, r.TransactionType) {
+		return fmt.Errorf("%w: limits can't be set on %q transactions", apperrors.ErrInvalidInput, r.TransactionType)
+	}
+	if r.Currency != "" {
+		if _, err := r.Currency.MinorUnits(); err != nil {
+			return err
+		}
+	}
+	if r.PerTransaction < 0 || r.DailyAmount < 0 || r.MonthlyAmount < 0 || r.DailyCount < 0 || r.MonthlyCount < 0 {
+		return fmt.Errorf("%w: %s limits can't be negative", apperrors.ErrInvalidInput, r.TransactionType)
+	}
+	if r.DailyAmount > 0 && r.MonthlyAmount > 0 && r.DailyAmount > r.MonthlyAmount {
+		return fmt.Errorf("%w: %s daily amount limit is above the monthly one", apperrors.ErrInvalidInput, r.TransactionType)
+	}
+	if r.DailyCount > 0 && r.MonthlyCount > 0 && r.DailyCount > r.MonthlyCount {
+		return fmt.Errorf("%w: %s daily count limit is above the monthly one", apperrors.ErrInvalidInput, r.TransactionType)
+	}
+	return nil
+}
+
+// HasWindows reports whether the rule limits any rolling totals or counts
+func (r *LimitRule) HasWindows() bool {
+	return r.DailyAmount > 0 || r.DailyCount > 0 || r.MonthlyAmount > 0 || r.MonthlyCount > 0
+}
+
+// Check returns ErrLimitExceeded if a transaction of amount would break the rule,
+// given what the wallet already used over the last day and month. An amount over
+// the per-transaction limit gets ErrPerTransactionLimit.
+func (r *LimitRule) Check(amount Amount, daily, monthly LimitUsage) error {
+	if r.PerTransaction > 0 && amount > r.PerTransaction {
+		return fmt.Errorf("%w: %s is over the %s limit of %s per transaction",
+			apperrors.ErrPerTransactionLimit, amount, r.TransactionType, r.PerTransaction)
+	}
+	if err := checkLimitWindow(r.TransactionType, "daily", amount, daily, r.DailyAmount, r.DailyCount); err != nil {
+		return err
+	}
+	return checkLimitWindow(r.TransactionType, "monthly", amount, monthly, r.MonthlyAmount, r.MonthlyCount)
+}
+
+// checkLimitWindow checks one more transaction of amount against a window's limits
+func checkLimitWindow(transactionType TransactionType, window string, amount Amount, used LimitUsage, amountLimit Amount, countLimit int) error {
+	if countLimit > 0 && used.Count >= countLimit {
+		return fmt.Errorf("%w: the %s limit of %d %s transactions is used up",
+			apperrors.ErrLimitExceeded, window, countLimit, transactionType)
+	}
+	if amountLimit > 0 {
+		total, err := used.Amount.Add(amount)
+		if err != nil {
+			return err
+		}
+		if total > amountLimit {
+			return fmt.Errorf("%w: %s would take %s transactions over the %s limit of %s",
+				apperrors.ErrLimitExceeded, amount, transactionType, window, amountLimit)
+		}
+	}
+	return nil
+}
+
+// Status shows the rule's limits and what is left of them after daily and monthly usage
+func (r *LimitRule) Status(daily, monthly LimitUsage) LimitStatus {
+	status := LimitStatus{
+		TransactionType: r.TransactionType,
+		Daily:           newLimitWindow(daily, r.DailyAmount, r.DailyCount),
+		Monthly:         newLimitWindow(monthly, r.MonthlyAmount, r.MonthlyCount),
+	}
+	if r.PerTransaction > 0 {
+		perTransaction := r.PerTransaction
+		status.PerTransaction = &perTransaction
+	}
+	return status
+}
+
+// newLimitWindow works out what is left of a window's limits, never below zero
+func newLimitWindow(used LimitUsage, amountLimit Amount, countLimit int) LimitWindow {
+	window := LimitWindow{Used: used}
+	if amountLimit > 0 {
+		remaining := max(amountLimit-used.Amount, 0)
+		window.AmountLimit = &amountLimit
+		window.AmountRemaining = &remaining
+	}
+	if countLimit > 0 {
+		remaining := max(countLimit-used.Count, 0)
+		window.CountLimit = &countLimit
+		window.CountRemaining = &remaining
+	}
+	return window
+}
@@ -0,0 +1,158 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimitRule_Check(t *testing.T) {
+	rule := domain.LimitRule{
+		TransactionType: domain.Withdrawal,
+		PerTransaction:  domain.NewAmount(500),
+		DailyAmount:     domain.NewAmount(1000),
+		DailyCount:      3,
+		MonthlyAmount:   domain.NewAmount(5000),
+	}
+
+	tests := []struct {
+		name          string
+		amount        domain.Amount
+		daily         domain.LimitUsage
+		monthly       domain.LimitUsage
+		expectedError error
+	}{
+		{name: "within every limit", amount: domain.NewAmount(100)},
+		{name: "exactly the per-transaction limit", amount: domain.NewAmount(500)},
+		{name: "over the per-transaction limit", amount: domain.NewAmount(501), expectedError: apperrors.ErrPerTransactionLimit},
+		{
+			name:    "reaches the daily amount",
+			amount:  domain.NewAmount(400),
+			daily:   domain.LimitUsage{Amount: domain.NewAmount(600), Count: 2},
+			monthly: domain.LimitUsage{Amount: domain.NewAmount(600), Count: 2},
+		},
+		{
+			name:          "over the daily amount",
+			amount:        domain.NewAmount(401),
+			daily:         domain.LimitUsage{Amount: domain.NewAmount(600), Count: 2},
+			monthly:       domain.LimitUsage{Amount: domain.NewAmount(600), Count: 2},
+			expectedError: apperrors.ErrLimitExceeded,
+		},
+		{
+			name:          "daily count used up",
+			amount:        domain.NewAmount(1),
+			daily:         domain.LimitUsage{Amount: domain.NewAmount(30), Count: 3},
+			monthly:       domain.LimitUsage{Amount: domain.NewAmount(30), Count: 3},
+			expectedError: apperrors.ErrLimitExceeded,
+		},
+		{
+			name:          "over the monthly amount",
+			amount:        domain.NewAmount(200),
+			monthly:       domain.LimitUsage{Amount: domain.NewAmount(4900), Count: 20},
+			expectedError: apperrors.ErrLimitExceeded,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := rule.Check(tc.amount, tc.daily, tc.monthly)
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				if tc.expectedError != apperrors.ErrPerTransactionLimit {
+					assert.NotErrorIs(t, err, apperrors.ErrPerTransactionLimit)
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestLimitRule_Status(t *testing.T) {
+	rule := domain.LimitRule{
+		TransactionType: domain.Transfer,
+		PerTransaction:  domain.NewAmount(500),
+		DailyAmount:     domain.NewAmount(1000),
+		MonthlyCount:    10,
+	}
+
+	status := rule.Status(
+		domain.LimitUsage{Amount: domain.NewAmount(1200), Count: 4},
+		domain.LimitUsage{Amount: domain.NewAmount(3000), Count: 12},
+	)
+
+	assert.Equal(t, domain.Transfer, status.TransactionType)
+	assert.Equal(t, domain.NewAmount(500), *status.PerTransaction)
+
+	// Only the limits that are set show up, and what is left never goes below zero
+	assert.Equal(t, domain.NewAmount(1000), *status.Daily.AmountLimit)
+	assert.Equal(t, domain.Amount(0), *status.Daily.AmountRemaining)
+	assert.Nil(t, status.Daily.CountLimit)
+	assert.Nil(t, status.Monthly.AmountLimit)
+	assert.Equal(t, 10, *status.Monthly.CountLimit)
+	assert.Equal(t, 0, *status.Monthly.CountRemaining)
+	assert.Equal(t, 12, status.Monthly.Used.Count)
+}
+
+func TestLimitRule_Validate(t *testing.T) {
+	tests := []struct {
+		name          string
+		rule          domain.LimitRule
+		expectedError error
+	}{
+		{name: "valid", rule: domain.LimitRule{TransactionType: domain.Deposit, Currency: domain.EUR, DailyAmount: domain.NewAmount(10), MonthlyAmount: domain.NewAmount(100)}},
+		{name: "unlimited type", rule: domain.LimitRule{TransactionType: domain.Refund}, expectedError: apperrors.ErrInvalidInput},
+		{name: "unknown currency", rule: domain.LimitRule{TransactionType: domain.Deposit, Currency: "XYZ"}, expectedError: apperrors.ErrUnsupportedCurrency},
+		{name: "negative limit", rule: domain.LimitRule{TransactionType: domain.Deposit, DailyCount: -1}, expectedError: apperrors.ErrInvalidInput},
+		{name: "daily amount above monthly", rule: domain.LimitRule{TransactionType: domain.Deposit, DailyAmount: domain.NewAmount(100), MonthlyAmount: domain.NewAmount(10)}, expectedError: apperrors.ErrInvalidInput},
+		{name: "daily count above monthly", rule: domain.LimitRule{TransactionType: domain.Deposit, DailyCount: 10, MonthlyCount: 5}, expectedError: apperrors.ErrInvalidInput},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.rule.Validate()
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestLimitPolicy_Rule(t *testing.T) {
+	policy, err := domain.NewLimitPolicy(map[domain.UserTier][]domain.LimitRule{
+		domain.StandardTier: {
+			{TransactionType: domain.Transfer, PerTransaction: domain.NewAmount(100)},
+			{TransactionType: domain.Transfer, Currency: domain.JPY, PerTransaction: domain.NewAmount(10000)},
+		},
+		"premium": {
+			{TransactionType: domain.Transfer, PerTransaction: domain.NewAmount(1000)},
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, domain.NewAmount(100), policy.Rule(domain.StandardTier, domain.Transfer, domain.USD).PerTransaction)
+	assert.Equal(t, domain.NewAmount(10000), policy.Rule(domain.StandardTier, domain.Transfer, domain.JPY).PerTransaction)
+	assert.Equal(t, domain.NewAmount(1000), policy.Rule("premium", domain.Transfer, domain.JPY).PerTransaction)
+	assert.Nil(t, policy.Rule(domain.StandardTier, domain.Deposit, domain.USD))
+
+	// Tiers the policy doesn't list get the standard limits
+	assert.Equal(t, domain.NewAmount(100), policy.Rule("legacy", domain.Transfer, domain.USD).PerTransaction)
+
+	// Without a policy nothing is limited
+	assert.Nil(t, domain.LimitPolicy(nil).Rule(domain.StandardTier, domain.Transfer, domain.USD))
+
+	_, err = domain.NewLimitPolicy(map[domain.UserTier][]domain.LimitRule{
+		domain.StandardTier: {
+			{TransactionType: domain.Transfer, DailyCount: 5},
+			{TransactionType: domain.Transfer, DailyCount: 10},
+		},
+	})
+	assert.ErrorIs(t, err, apperrors.ErrInvalidInput)
+}
@@ -0,0 +1,179 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+	"strings"
+
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+)
+
+// AmountScale is the number of decimal places an Amount carries. It matches the
+// DECIMAL(19, 4) money columns in the database.
+const AmountScale = 4
+
+// amountFactor is 10^AmountScale, the number of Amount units in one currency unit
+const amountFactor = 10000
+
+// Amount is an exact monetary value stored as a fixed-point integer of
+// ten-thousandths of a currency unit, so 12.34 is held as 123400.
+// It never passes through float64, which keeps repeated arithmetic drift-free.
+type Amount int64
+
+// NewAmount builds an Amount from whole currency units
+func NewAmount(units int64) Amount {
+	return Amount(units * amountFactor)
+}
+
+// ParseAmount reads a plain decimal string such as "12.34" into an Amount.
+// Values with more than AmountScale decimal places are rejected.
+func ParseAmount(s string) (Amount, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, fmt.Errorf("%w: empty amount", apperrors.ErrInvalidAmountFormat)
+	}
+
+	negative := false
+	switch s[0] {
+	case '-':
+		negative = true
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+
+	intPart, fracPart, hasFrac := strings.Cut(s, ".")
+	if intPart == "" && fracPart == "" {
+		return 0, fmt.Errorf("%w: %q", apperrors.ErrInvalidAmountFormat, s)
+	}
+	if hasFrac && fracPart == "" {
+		return 0, fmt.Errorf("%w: %q", apperrors.ErrInvalidAmountFormat, s)
+	}
+	if !isDigits(intPart) || !isDigits(fracPart) {
+		return 0, fmt.Errorf("%w: %q", apperrors.ErrInvalidAmountFormat, s)
+	}
+
+	// Trailing zeros don't add precision, so "1.50000" is still fine
+	fracPart = strings.TrimRight(fracPart, "0")
+	if len(fracPart) > AmountScale {
+		return 0, apperrors.ErrAmountPrecision
+	}
+	fracPart += strings.Repeat("0", AmountScale-len(fracPart))
+
+	if intPart == "" {
+		intPart = "0"
+	}
+
+	value, err := strconv.ParseInt(intPart+fracPart, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q is out of range", apperrors.ErrInvalidAmountFormat, s)
+	}
+
+	if negative {
+		value = -value
+	}
+	return Amount(value), nil
+}
+
+// isDigits reports whether s only holds ASCII digits
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// String formats the amount as a plain decimal with trailing zeros removed
+func (a Amount) String() string {
+	return formatFixed(int64(a), AmountScale, amountFactor)
+}
+
+// formatFixed writes a fixed-point integer with scale decimal places as a plain
+// decimal, trailing zeros removed. factor is 10^scale.
+func formatFixed(value int64, scale int, factor int64) string {
+	sign := ""
+	if value < 0 {
+		sign = "-"
+		value = -value
+	}
+
+	units := value / factor
+	frac := value % factor
+	if frac == 0 {
+		return fmt.Sprintf("%s%d", sign, units)
+	}
+
+	fracStr := strings.TrimRight(fmt.Sprintf("%0*d", scale, frac), "0")
+	return fmt.Sprintf("%s%d.%s", sign, units, fracStr)
+}
+
+// Add returns a + b, failing rather than wrapping around on overflow
+func (a Amount) Add(b Amount) (Amount, error) {
+	sum := a + b
+	if (b > 0 && sum < a) || (b < 0 && sum > a) {
+		return 0, apperrors.ErrAmountOverflow
+	}
+	return sum, nil
+}
+
+// Sub returns a - b, failing rather than wrapping around on overflow
+func (a Amount) Sub(b Amount) (Amount, error) {
+	diff := a - b
+	if (b > 0 && diff > a) || (b < 0 && diff < a) {
+		return 0, apperrors.ErrAmountOverflow
+	}
+	return diff, nil
+}
+
+// MarshalJSON writes the amount as an exact JSON number
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalJSON accepts either a JSON number or a quoted decimal string.
+// The number is read from its text, never through float64.
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	s = strings.Trim(s, `"`)
+
+	parsed, err := ParseAmount(s)
+	if err != nil {
+		return err
+	}
+
+	*a = parsed
+	return nil
+}
+
+// Scan reads a NUMERIC column, which pgx hands over in its text form
+func (a *Amount) Scan(src any) error {
+	switch v := src.(type) {
+	case string:
+		parsed, err := ParseAmount(v)
+		if err != nil {
+			return err
+		}
+		*a = parsed
+		return nil
+	case []byte:
+		return a.Scan(string(v))
+	case int64:
+		*a = NewAmount(v)
+		return nil
+	case nil:
+		return fmt.Errorf("%w: cannot scan NULL into Amount", apperrors.ErrInvalidAmountFormat)
+	default:
+		return fmt.Errorf("%w: cannot scan %T into Amount", apperrors.ErrInvalidAmountFormat, src)
+	}
+}
+
+// Value writes the amount as an exact decimal string for NUMERIC columns
+func (a Amount) Value() (driver.Value, error) {
+	return a.String(), nil
+}
@@ -0,0 +1,115 @@
+package domain_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		expected      domain.Amount
+		expectedError error
+	}{
+		{name: "whole number", input: "150", expected: domain.NewAmount(150)},
+		{name: "two decimals", input: "12.34", expected: domain.Amount(123400)},
+		{name: "four decimals", input: "0.0001", expected: domain.Amount(1)},
+		{name: "trailing zeros", input: "1.500000", expected: domain.Amount(15000)},
+		{name: "leading dot", input: ".5", expected: domain.Amount(5000)},
+		{name: "negative", input: "-2.5", expected: domain.Amount(-25000)},
+		{name: "too precise", input: "0.00001", expectedError: apperrors.ErrAmountPrecision},
+		{name: "exponent", input: "1e3", expectedError: apperrors.ErrInvalidAmountFormat},
+		{name: "empty", input: "", expectedError: apperrors.ErrInvalidAmountFormat},
+		{name: "dangling dot", input: "1.", expectedError: apperrors.ErrInvalidAmountFormat},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			amount, err := domain.ParseAmount(tc.input)
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, amount)
+			}
+		})
+	}
+}
+
+func TestAmount_JSON(t *testing.T) {
+	var req domain.DepositRequest
+	err := json.Unmarshal([]byte(`{"user_id": 1, "amount": 10.10}`), &req)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.Amount(101000), req.Amount)
+
+	err = json.Unmarshal([]byte(`{"user_id": 1, "amount": "10.10"}`), &req)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.Amount(101000), req.Amount)
+
+	err = json.Unmarshal([]byte(`{"user_id": 1, "amount": 0.123456}`), &req)
+	assert.ErrorIs(t, err, apperrors.ErrAmountPrecision)
+
+	data, err := json.Marshal(domain.Wallet{Balance: domain.Amount(101000)})
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"balance":10.1`)
+}
+
+func TestAmount_RepeatedDepositsDoNotDrift(t *testing.T) {
+	wallet := domain.Wallet{Currency: domain.USD}
+	tenCents, err := domain.ParseAmount("0.1")
+	assert.NoError(t, err)
+
+	for i := 0; i < 1000; i++ {
+		assert.NoError(t, wallet.Deposit(tenCents))
+	}
+
+	assert.Equal(t, domain.NewAmount(100), wallet.Balance)
+	assert.Equal(t, "100", wallet.Balance.String())
+}
+
+func TestCurrency_CheckPrecision(t *testing.T) {
+	assert.NoError(t, domain.USD.CheckPrecision(domain.Amount(100)))
+	assert.ErrorIs(t, domain.USD.CheckPrecision(domain.Amount(10)), apperrors.ErrAmountPrecision)
+	assert.ErrorIs(t, domain.Currency("XXX").CheckPrecision(domain.Amount(100)), apperrors.ErrUnsupportedCurrency)
+
+	// Yen has no minor unit, dinar has three
+	assert.NoError(t, domain.JPY.CheckPrecision(domain.NewAmount(5)))
+	assert.ErrorIs(t, domain.JPY.CheckPrecision(domain.Amount(5000)), apperrors.ErrAmountPrecision)
+	assert.NoError(t, domain.Currency("KWD").CheckPrecision(domain.Amount(10)))
+}
+
+func TestCurrency_Format(t *testing.T) {
+	tests := []struct {
+		name     string
+		currency domain.Currency
+		amount   domain.Amount
+		expected string
+	}{
+		{name: "pads to minor units", currency: domain.USD, amount: domain.Amount(105000), expected: "10.50"},
+		{name: "whole amount", currency: domain.USD, amount: domain.NewAmount(3), expected: "3.00"},
+		{name: "negative", currency: domain.USD, amount: domain.Amount(-2500), expected: "-0.25"},
+		{name: "no minor unit", currency: domain.JPY, amount: domain.NewAmount(1512), expected: "1512"},
+		{name: "finer than minor unit", currency: domain.USD, amount: domain.Amount(12345), expected: "1.2345"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.currency.Format(tc.amount))
+		})
+	}
+}
+
+func TestParseCurrency(t *testing.T) {
+	currency, err := domain.ParseCurrency(" eur ")
+	assert.NoError(t, err)
+	assert.Equal(t, domain.EUR, currency)
+
+	_, err = domain.ParseCurrency("ABC")
+	assert.ErrorIs(t, err, apperrors.ErrUnsupportedCurrency)
+}
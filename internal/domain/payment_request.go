@@ -0,0 +1,163 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+)
+
+// MaxPaymentRequestMemoLength caps the optional memo shown to the payer
+const MaxPaymentRequestMemoLength = 255
+
+// PaymentRequestStatus tracks a payment request until the payer answers it or it
+// runs out. Only PENDING requests can change.
+type PaymentRequestStatus string
+
+const (
+	// PaymentRequestPending is waiting for the payer
+	PaymentRequestPending PaymentRequestStatus = "PENDING"
+	// PaymentRequestAccepted was paid by the payer
+	PaymentRequestAccepted PaymentRequestStatus = "ACCEPTED"
+	// PaymentRequestDeclined was turned down by the payer
+	PaymentRequestDeclined PaymentRequestStatus = "DECLINED"
+	// PaymentRequestCancelled was withdrawn by the requester
+	PaymentRequestCancelled PaymentRequestStatus = "CANCELLED"
+	// PaymentRequestExpired ran out before the payer answered
+	PaymentRequestExpired PaymentRequestStatus = "EXPIRED"
+)
+
+// PaymentRequestDirection picks the requests a user sent or the ones sent to them
+type PaymentRequestDirection string
+
+const (
+	// PaymentRequestsIncoming are the requests the user is asked to pay
+	PaymentRequestsIncoming PaymentRequestDirection = "incoming"
+	// PaymentRequestsOutgoing are the requests the user made
+	PaymentRequestsOutgoing PaymentRequestDirection = "outgoing"
+)
+
+// PaymentRequest asks another user for money. Accepting it transfers Amount
+// from the payer into the requester's wallet.
+type PaymentRequest struct {
+	ID          int64 `json:"id"`
+	RequesterID int64 `json:"requester_id"`
+	PayerID     int64 `json:"payer_id"`
+	// WalletID is the requester's wallet the money is paid into
+	WalletID      int64                `json:"wallet_id"`
+	Currency      Currency             `json:"currency"`
+	Amount        Amount               `json:"amount"`
+	Memo          string               `json:"memo,omitempty"`
+	Status        PaymentRequestStatus `json:"status"`
+	TransactionID *int64               `json:"transaction_id,omitempty"`
+	ExpiresAt     time.Time            `json:"expires_at"`
+	CreatedAt     time.Time            `json:"created_at"`
+	UpdatedAt     time.Time            `json:"updated_at"`
+}
+
+// PaymentRequestFilter narrows a user's payment requests. Empty fields match everything.
+type PaymentRequestFilter struct {
+	Direction PaymentRequestDirection
+	Status    PaymentRequestStatus
+}
+
+// Validate checks a new payment request
+func (p *PaymentRequest) Validate(now time.Time) error {
+	if p.Amount <= 0 {
+		return apperrors.ErrInvalidAmount
+	}
+	if p.PayerID == p.RequesterID {
+		return fmt.Errorf("%w: you cannot request money from yourself", apperrors.ErrInvalidInput)
+	}
+	if utf8.RuneCountInString(p.Memo) > MaxPaymentRequestMemoLength {
+		return fmt.Errorf("%w: memo must be at most %d characters", apperrors.ErrInvalidInput, MaxPaymentRequestMemoLength)
+	}
+	if !p.ExpiresAt.After(now) {
+		return fmt.Errorf("%w: expires_at must be in the future", apperrors.ErrInvalidInput)
+	}
+	return p.Currency.CheckPrecision(p.Amount)
+}
+
+// IsParty reports whether userID made the request or is asked to pay it
+func (p *PaymentRequest) IsParty(userID int64) bool {
+	return userID == p.RequesterID || userID == p.PayerID
+}
+
+// CheckPending rejects requests that can no longer be answered
+func (p *PaymentRequest) CheckPending(now time.Time) error {
+	if p.Status != PaymentRequestPending {
+		return fmt.Errorf("%w: payment request is %s", apperrors.ErrPaymentRequestNotPending, p.Status)
+	}
+	if !now.Before(p.ExpiresAt) {
+		return fmt.Errorf("%w: payment request expired at %s", apperrors.ErrPaymentRequestNotPending, p.ExpiresAt.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// Accept records that the payer paid the request with transactionID
+func (p *PaymentRequest) Accept(transactionID int64, now time.Time) error {
+	if err := p.CheckPending(now); err != nil {
+		return err
+	}
+
+	p.Status = PaymentRequestAccepted
+	p.TransactionID = &transactionID
+	p.UpdatedAt = now
+	return nil
+}
+
+// Decline records that the payer turned the request down
+func (p *PaymentRequest) Decline(now time.Time) error {
+	if err := p.CheckPending(now); err != nil {
+		return err
+	}
+
+	p.Status = PaymentRequestDeclined
+	p.UpdatedAt = now
+	return nil
+}
+
+// Cancel withdraws the request on behalf of the requester
+func (p *PaymentRequest) Cancel(now time.Time) error {
+	if err := p.CheckPending(now); err != nil {
+		return err
+	}
+
+	p.Status = PaymentRequestCancelled
+	p.UpdatedAt = now
+	return nil
+}
+
+// Expire ends a pending request whose time has run out
+func (p *PaymentRequest) Expire(now time.Time) error {
+	if p.Status != PaymentRequestPending {
+		return fmt.Errorf("%w: payment request is %s", apperrors.ErrPaymentRequestNotPending, p.Status)
+	}
+	if now.Before(p.ExpiresAt) {
+		return fmt.Errorf("%w: payment request expires at %s", apperrors.ErrPaymentRequestNotPending, p.ExpiresAt.Format(time.RFC3339))
+	}
+
+	p.Status = PaymentRequestExpired
+	p.UpdatedAt = now
+	return nil
+}
+
+// TransferRequest is the transfer that pays the request, out of the payer's
+// wallet in the request's currency or the one payerWalletID picks
+func (p *PaymentRequest) TransferRequest(payerWalletID int64) TransferRequest {
+	comment := p.Memo
+	if comment == "" {
+		comment = fmt.Sprintf("Payment request %d", p.ID)
+	}
+
+	return TransferRequest{
+		SenderID:         p.PayerID,
+		ReceiverID:       p.RequesterID,
+		SenderWalletID:   payerWalletID,
+		ReceiverWalletID: p.WalletID,
+		Currency:         p.Currency,
+		Amount:           p.Amount,
+		Comment:          comment,
+	}
+}
@@ -0,0 +1,116 @@
+package domain_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPaymentRequest_Validate(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name          string
+		modify        func(p *domain.PaymentRequest)
+		expectedError error
+	}{
+		{
+			name:   "valid",
+			modify: func(p *domain.PaymentRequest) {},
+		},
+		{
+			name:          "zero amount",
+			modify:        func(p *domain.PaymentRequest) { p.Amount = 0 },
+			expectedError: apperrors.ErrInvalidAmount,
+		},
+		{
+			name:          "asking yourself",
+			modify:        func(p *domain.PaymentRequest) { p.PayerID = p.RequesterID },
+			expectedError: apperrors.ErrInvalidInput,
+		},
+		{
+			name:   "memo of multi-byte characters up to the limit",
+			modify: func(p *domain.PaymentRequest) { p.Memo = strings.Repeat("é", domain.MaxPaymentRequestMemoLength) },
+		},
+		{
+			name:          "memo too long",
+			modify:        func(p *domain.PaymentRequest) { p.Memo = strings.Repeat("a", domain.MaxPaymentRequestMemoLength+1) },
+			expectedError: apperrors.ErrInvalidInput,
+		},
+		{
+			name:          "expiry in the past",
+			modify:        func(p *domain.PaymentRequest) { p.ExpiresAt = now.Add(-time.Minute) },
+			expectedError: apperrors.ErrInvalidInput,
+		},
+		{
+			name:          "too precise for the currency",
+			modify:        func(p *domain.PaymentRequest) { p.Currency = domain.JPY },
+			expectedError: apperrors.ErrAmountPrecision,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			amount, _ := domain.ParseAmount("10.50")
+			request := &domain.PaymentRequest{
+				RequesterID: 1,
+				PayerID:     2,
+				Currency:    domain.USD,
+				Amount:      amount,
+				ExpiresAt:   now.Add(time.Hour),
+			}
+			tc.modify(request)
+
+			err := request.Validate(now)
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestPaymentRequest_Transitions(t *testing.T) {
+	now := time.Now()
+	pending := func() *domain.PaymentRequest {
+		return &domain.PaymentRequest{Status: domain.PaymentRequestPending, ExpiresAt: now.Add(time.Hour)}
+	}
+
+	request := pending()
+	assert.NoError(t, request.Accept(42, now))
+	assert.Equal(t, domain.PaymentRequestAccepted, request.Status)
+	assert.Equal(t, int64(42), *request.TransactionID)
+
+	// Answered requests can't change again
+	assert.ErrorIs(t, request.Decline(now), apperrors.ErrPaymentRequestNotPending)
+	assert.ErrorIs(t, request.Cancel(now), apperrors.ErrPaymentRequestNotPending)
+	assert.ErrorIs(t, request.Expire(now.Add(2*time.Hour)), apperrors.ErrPaymentRequestNotPending)
+
+	request = pending()
+	assert.NoError(t, request.Decline(now))
+	assert.Equal(t, domain.PaymentRequestDeclined, request.Status)
+
+	request = pending()
+	assert.NoError(t, request.Cancel(now))
+	assert.Equal(t, domain.PaymentRequestCancelled, request.Status)
+	assert.ErrorIs(t, request.Accept(42, now), apperrors.ErrPaymentRequestNotPending)
+}
+
+func TestPaymentRequest_Expire(t *testing.T) {
+	now := time.Now()
+
+	request := &domain.PaymentRequest{Status: domain.PaymentRequestPending, ExpiresAt: now.Add(time.Minute)}
+	assert.ErrorIs(t, request.Expire(now), apperrors.ErrPaymentRequestNotPending)
+
+	// Past its expiry but not yet swept, it can no longer be paid
+	request.ExpiresAt = now
+	assert.ErrorIs(t, request.Accept(42, now), apperrors.ErrPaymentRequestNotPending)
+
+	assert.NoError(t, request.Expire(now))
+	assert.Equal(t, domain.PaymentRequestExpired, request.Status)
+}
@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"time"
+)
+
+// DiscrepancyKind names what a reconciliation found wrong with a wallet
+type DiscrepancyKind string
+
+const (
+	// DiscrepancyBrokenChain means a transaction's balance_before is not the
+	// balance_after of the wallet's previous transaction
+	DiscrepancyBrokenChain DiscrepancyKind = "BROKEN_CHAIN"
+	// DiscrepancyBalanceMismatch means the wallet's balance is not the
+	// balance_after of its latest transaction
+	DiscrepancyBalanceMismatch DiscrepancyKind = "BALANCE_MISMATCH"
+	// DiscrepancyLedgerMismatch means the wallet's balance is not the balance of
+	// its ledger account
+	DiscrepancyLedgerMismatch DiscrepancyKind = "LEDGER_MISMATCH"
+	// DiscrepancyNoLedgerAccount means the wallet has no ledger account at all
+	DiscrepancyNoLedgerAccount DiscrepancyKind = "NO_LEDGER_ACCOUNT"
+)
+
+// Discrepancy is one inconsistency found in a wallet. Expected is the value the
+// transactions or ledger imply and Actual the value found.
+type Discrepancy struct {
+	WalletID              int64           `json:"wallet_id"`
+	Currency              Currency        `json:"currency"`
+	Kind                  DiscrepancyKind `json:"kind"`
+	TransactionID         *int64          `json:"transaction_id,omitempty"`
+	PreviousTransactionID *int64          `json:"previous_transaction_id,omitempty"`
+	Expected              Amount          `json:"expected"`
+	Actual                Amount          `json:"actual"`
+	Fixed                 bool            `json:"fixed"`
+}
+
+// ReconciliationReport is the machine-readable outcome of a reconciliation run
+type ReconciliationReport struct {
+	StartedAt           time.Time      `json:"started_at"`
+	FinishedAt          time.Time      `json:"finished_at"`
+	AutoFix             bool           `json:"auto_fix"`
+	WalletsChecked      int            `json:"wallets_checked"`
+	TransactionsChecked int            `json:"transactions_checked"`
+	Discrepancies       []*Discrepancy `json:"discrepancies"`
+	Fixed               int            `json:"fixed"`
+}
+
+// Unresolved counts the discrepancies that were not fixed
+func (r *ReconciliationReport) Unresolved() int {
+	return len(r.Discrepancies) - r.Fixed
+}
+
+// ReconcileOptions chooses what a reconciliation run checks and whether it fixes
+type ReconcileOptions struct {
+	// WalletIDs limits the run to these wallets; empty means every wallet
+	WalletIDs []int64
+	// AutoFix resets a wallet's balance to its latest transaction's
+	// balance_after, when the chain is intact and the ledger agrees
+	AutoFix bool
+	// BatchSize caps the wallet IDs read per query
+	BatchSize int
+}
+
+// BalanceLink is the part of a transaction the balance chain is made of
+type BalanceLink struct {
+	TransactionID int64
+	BalanceBefore Amount
+	BalanceAfter  Amount
+}
+
+// BalanceCorrection is the audit record of a balance reset by reconciliation
+type BalanceCorrection struct {
+	ID         int64     `json:"id"`
+	WalletID   int64     `json:"wallet_id"`
+	OldBalance Amount    `json:"old_balance"`
+	NewBalance Amount    `json:"new_balance"`
+	Reason     string    `json:"reason"`
+	CreatedAt  time.Time `json:"created_at"`
+}
@@ -1,23 +1,241 @@
 package domain
 
-import "context"
+import (
+	"context"
+	"time"
+)
+
+// UnitOfWork runs a set of repository calls as one atomic database transaction.
+// Repositories called with the context handed to fn take part in it, and the
+// whole unit is rolled back if fn returns an error.
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
 
 // UserRepository defines operations for user management
 type UserRepository interface {
 	Create(ctx context.Context, user *User) error
 	GetByID(ctx context.Context, id int64) (*User, error)
+	GetByIDForUpdate(ctx context.Context, id int64) (*User, error)
+	Update(ctx context.Context, user *User) error
 }
 
 // WalletRepository defines operations for wallet management
 type WalletRepository interface {
 	Create(ctx context.Context, wallet *Wallet) error
-	GetByUserID(ctx context.Context, userID int64) (*Wallet, error)
+	GetByID(ctx context.Context, id int64) (*Wallet, error)
+	// GetByIDForUpdate loads the wallet and locks its row until the surrounding
+	// unit of work ends, so concurrent balance changes queue up instead of overwriting each other.
+	// Lock several wallets in ascending ID order so two units of work can't deadlock.
+	GetByIDForUpdate(ctx context.Context, id int64) (*Wallet, error)
+	// ListByUserID returns all of a user's wallets, oldest first
+	ListByUserID(ctx context.Context, userID int64) ([]*Wallet, error)
 	Update(ctx context.Context, wallet *Wallet) error
 }
 
 // TransactionRepository defines operations for transaction management
 type TransactionRepository interface {
 	Create(ctx context.Context, transaction *Transaction) error
-	GetByWalletID(ctx context.Context, walletID int64, limit, offset int) ([]*Transaction, error)
-	CountByWalletID(ctx context.Context, walletID int64) (int, error)
-}
\ No newline at end of file
+	// GetByWalletID lists the wallet's transactions matching filter, in its sort order
+	GetByWalletID(ctx context.Context, walletID int64, filter TransactionFilter, limit, offset int) ([]*Transaction, error)
+	// GetPageByWalletID returns up to limit matching transactions on the cursor's
+	// side of it, in the filter's sort order whichever way the cursor points
+	GetPageByWalletID(ctx context.Context, walletID int64, filter TransactionFilter, cursor TransactionCursor, limit int) ([]*Transaction, error)
+	CountByWalletID(ctx context.Context, walletID int64, filter TransactionFilter) (int, error)
+	// StreamByWalletID calls fn with each transaction matching filter, in its sort
+	// order, reading rows as it goes. It stops at the first error fn returns.
+	StreamByWalletID(ctx context.Context, walletID int64, filter TransactionFilter, fn func(*Transaction) error) error
+	// GetLastBetween returns the wallet's latest transaction in [from, to)
+	GetLastBetween(ctx context.Context, walletID int64, from, to time.Time) (*Transaction, error)
+	// GetFirstFrom returns the wallet's earliest transaction at or after at
+	GetFirstFrom(ctx context.Context, walletID int64, at time.Time) (*Transaction, error)
+	// GetByIDForUpdate loads the transaction and locks its row until the
+	// surrounding unit of work ends
+	GetByIDForUpdate(ctx context.Context, id int64) (*Transaction, error)
+	// GetByCorrelationIDForUpdate locks every row sharing correlationID,
+	// e.g. both sides of a transfer
+	GetByCorrelationIDForUpdate(ctx context.Context, correlationID string) ([]*Transaction, error)
+	// GetChildForUpdate loads and locks the row of transactionType that points at
+	// parentID, such as the FEE row charged for a transaction
+	GetChildForUpdate(ctx context.Context, parentID int64, transactionType TransactionType) (*Transaction, error)
+	// UpdateReversal saves the reversed amount and reversal status
+	UpdateReversal(ctx context.Context, transaction *Transaction) error
+	// GetUsageSince totals the wallet's transactions of the given types made at or after since
+	GetUsageSince(ctx context.Context, walletID int64, types []TransactionType, since time.Time) (LimitUsage, error)
+}
+
+// HoldRepository defines operations for holds on wallet funds
+type HoldRepository interface {
+	Create(ctx context.Context, hold *Hold) error
+	GetByID(ctx context.Context, id int64) (*Hold, error)
+	// GetByIDForUpdate loads the hold and locks its row until the surrounding unit of work ends
+	GetByIDForUpdate(ctx context.Context, id int64) (*Hold, error)
+	Update(ctx context.Context, hold *Hold) error
+	// ListExpiredIDs returns up to limit active holds that expired before now, oldest first
+	ListExpiredIDs(ctx context.Context, now time.Time, limit int) ([]int64, error)
+}
+
+// BalanceSnapshotRepository stores the daily wallet balance snapshots
+type BalanceSnapshotRepository interface {
+	// CreateBatch snapshots the balance at asOf of up to limit wallets with IDs
+	// above afterWalletID that existed by then, keeping any snapshot already
+	// taken. It returns the last wallet ID covered and how many were covered.
+	CreateBatch(ctx context.Context, asOf time.Time, afterWalletID int64, limit int) (int64, int, error)
+	// GetLatest returns the wallet's most recent snapshot at or before at
+	GetLatest(ctx context.Context, walletID int64, at time.Time) (*BalanceSnapshot, error)
+	// GetLatestAsOf returns the most recent snapshot time of any wallet
+	GetLatestAsOf(ctx context.Context) (time.Time, error)
+}
+
+// LedgerRepository defines operations for the double-entry ledger
+type LedgerRepository interface {
+	CreateAccount(ctx context.Context, account *LedgerAccount) error
+	GetWalletAccount(ctx context.Context, walletID int64) (*LedgerAccount, error)
+	// GetSystemAccount returns the platform account for code and currency,
+	// opening it on first use
+	GetSystemAccount(ctx context.Context, code string, currency Currency) (*LedgerAccount, error)
+	// CreateEntry writes a balanced journal entry together with its postings
+	CreateEntry(ctx context.Context, entry *JournalEntry) error
+	GetAccountBalances(ctx context.Context) ([]*AccountBalance, error)
+	// GetAccountBalance sums the postings of one account
+	GetAccountBalance(ctx context.Context, accountID int64) (Amount, error)
+}
+
+// ReconciliationRepository reads the balance chains reconciliation checks and
+// records its corrections
+type ReconciliationRepository interface {
+	// ListWalletIDs returns up to limit wallet IDs above afterWalletID, in order
+	ListWalletIDs(ctx context.Context, afterWalletID int64, limit int) ([]int64, error)
+	// StreamBalanceChain calls fn for each of the wallet's transactions in the
+	// order they were written, stopping at the first error
+	StreamBalanceChain(ctx context.Context, walletID int64, fn func(BalanceLink) error) error
+	// GetLastLink returns the wallet's most recently written transaction
+	GetLastLink(ctx context.Context, walletID int64) (*BalanceLink, error)
+	CreateCorrection(ctx context.Context, correction *BalanceCorrection) error
+}
+
+// IdempotencyStore keeps the outcome of requests sent with an Idempotency-Key
+type IdempotencyStore interface {
+	// Reserve claims record.Key for record.UserID. If a live record already holds
+	// the key it is returned unchanged and nothing is written; otherwise nil is returned.
+	Reserve(ctx context.Context, record *IdempotencyRecord) (*IdempotencyRecord, error)
+	// Complete stores the response for the reservation Reserve made with record and
+	// keeps it until ttl passes. It fails without writing if the key has since been
+	// reserved again, which happens when the request outlives its lock timeout.
+	Complete(ctx context.Context, record *IdempotencyRecord, statusCode int, body []byte, ttl time.Duration) error
+	// Release drops the reservation Reserve made with record so the request can be
+	// retried, leaving the key alone if it has since been reserved again
+	Release(ctx context.Context, record *IdempotencyRecord) error
+}
+
+// OutboxRepository stores domain events until they have been published
+type OutboxRepository interface {
+	// Create adds an event to the outbox. Call it inside the unit of work that
+	// makes the change, so the event exists exactly when the change commits.
+	Create(ctx context.Context, event *OutboxEvent) error
+	// TryLock takes the relay lock for the current unit of work, reporting false
+	// if another relay holds it. The lock is released when the unit ends.
+	TryLock(ctx context.Context) (bool, error)
+	// FetchPending returns up to limit unpublished events in id order, leaving out
+	// events queued behind an earlier event of the same aggregate that is waiting to retry
+	FetchPending(ctx context.Context, limit int) ([]*OutboxEvent, error)
+	MarkPublished(ctx context.Context, id int64) error
+	// MarkFailed records a failed publish attempt and when to try again
+	MarkFailed(ctx context.Context, id int64, reason string, nextAttemptAt time.Time) error
+}
+
+// FXQuoteRepository stores exchange rate quotes
+type FXQuoteRepository interface {
+	Create(ctx context.Context, quote *FXQuote) error
+	// GetByIDForUpdate loads the quote and locks it until the unit of work ends,
+	// so it can only be used once
+	GetByIDForUpdate(ctx context.Context, id string) (*FXQuote, error)
+	MarkUsed(ctx context.Context, quote *FXQuote) error
+}
+
+// RateProvider looks up the mid-market rate between two currencies
+type RateProvider interface {
+	// Name identifies the provider on the quotes it prices
+	Name() string
+	Rate(ctx context.Context, from, to Currency) (Rate, error)
+}
+
+// EventPublisher delivers outbox events to downstream consumers
+type EventPublisher interface {
+	Publish(ctx context.Context, event *OutboxEvent) error
+}
+
+// WebhookRepository stores webhook subscriptions and their delivery log
+type WebhookRepository interface {
+	CreateSubscription(ctx context.Context, subscription *WebhookSubscription) error
+	GetSubscription(ctx context.Context, id int64) (*WebhookSubscription, error)
+	ListSubscriptions(ctx context.Context, userID int64) ([]*WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, id int64) error
+	// EnqueueDeliveries queues event for every subscription of userIDs that wants
+	// its type. Call it in the unit of work that records the event.
+	EnqueueDeliveries(ctx context.Context, event *OutboxEvent, userIDs []int64) error
+	// ClaimDueDeliveries leases up to limit pending deliveries that are due by pushing
+	// their next attempt to leaseUntil, so other dispatchers skip them meanwhile
+	ClaimDueDeliveries(ctx context.Context, limit int, leaseUntil time.Time) ([]*WebhookJob, error)
+	// SaveAttempt saves the outcome of an attempt made under the lease ClaimDueDeliveries
+	// took until leaseUntil. It saves nothing and reports false if that lease ran out
+	// and another dispatcher has claimed the delivery since.
+	SaveAttempt(ctx context.Context, delivery *WebhookDelivery, leaseUntil time.Time) (bool, error)
+	// UpdateDelivery saves a redelivery request
+	UpdateDelivery(ctx context.Context, delivery *WebhookDelivery) error
+	GetDelivery(ctx context.Context, id int64) (*WebhookDelivery, error)
+	ListDeliveries(ctx context.Context, subscriptionID int64, limit, offset int) ([]*WebhookDelivery, error)
+	CountDeliveries(ctx context.Context, subscriptionID int64) (int, error)
+}
+
+// ScheduledTransferRepository stores scheduled transfers and their execution history
+type ScheduledTransferRepository interface {
+	Create(ctx context.Context, schedule *ScheduledTransfer) error
+	GetByID(ctx context.Context, id int64) (*ScheduledTransfer, error)
+	// GetByIDForUpdate loads the schedule and locks its row until the surrounding unit of work ends
+	GetByIDForUpdate(ctx context.Context, id int64) (*ScheduledTransfer, error)
+	// ListByUserID returns all of a user's schedules, newest first
+	ListByUserID(ctx context.Context, userID int64) ([]*ScheduledTransfer, error)
+	Update(ctx context.Context, schedule *ScheduledTransfer) error
+	// ClaimDue leases up to limit active schedules whose next attempt is due by
+	// pushing that attempt to leaseUntil, so other schedulers skip them meanwhile
+	ClaimDue(ctx context.Context, limit int, leaseUntil time.Time) ([]*ScheduledTransfer, error)
+	CreateExecution(ctx context.Context, execution *ScheduleExecution) error
+	// ListExecutions returns a page of the schedule's executions, newest first
+	ListExecutions(ctx context.Context, scheduleID int64, limit, offset int) ([]*ScheduleExecution, error)
+	CountExecutions(ctx context.Context, scheduleID int64) (int, error)
+}
+
+// TransferBatchRepository stores transfer batches and their items
+type TransferBatchRepository interface {
+	// Create stores the batch together with its items
+	Create(ctx context.Context, batch *TransferBatch, items []*TransferBatchItem) error
+	GetByID(ctx context.Context, id int64) (*TransferBatch, error)
+	// GetByIDForUpdate loads the batch and locks its row until the surrounding unit of work ends
+	GetByIDForUpdate(ctx context.Context, id int64) (*TransferBatch, error)
+	Update(ctx context.Context, batch *TransferBatch) error
+	// Claim leases up to limit batches that are pending, or whose worker's lease
+	// ran out, by moving them to PROCESSING with a lease until leaseUntil
+	Claim(ctx context.Context, limit int, leaseUntil time.Time) ([]*TransferBatch, error)
+	// ListItems returns a page of the batch's items in submission order; an
+	// empty status lists them all
+	ListItems(ctx context.Context, batchID int64, status BatchItemStatus, limit, offset int) ([]*TransferBatchItem, error)
+	CountItems(ctx context.Context, batchID int64, status BatchItemStatus) (int, error)
+	UpdateItem(ctx context.Context, item *TransferBatchItem) error
+	// SkipPendingItems marks every item still pending as skipped for reason
+	SkipPendingItems(ctx context.Context, batchID int64, reason string) error
+}
+
+// PaymentRequestRepository stores payment requests
+type PaymentRequestRepository interface {
+	Create(ctx context.Context, request *PaymentRequest) error
+	GetByID(ctx context.Context, id int64) (*PaymentRequest, error)
+	// GetByIDForUpdate loads the request and locks its row until the surrounding unit of work ends
+	GetByIDForUpdate(ctx context.Context, id int64) (*PaymentRequest, error)
+	// ListByUserID returns a page of the requests userID made or was sent, newest first
+	ListByUserID(ctx context.Context, userID int64, filter PaymentRequestFilter, limit, offset int) ([]*PaymentRequest, error)
+	CountByUserID(ctx context.Context, userID int64, filter PaymentRequestFilter) (int, error)
+	Update(ctx context.Context, request *PaymentRequest) error
+	// ListExpiredIDs returns up to limit pending requests that expired by now
+	ListExpiredIDs(ctx context.Context, now time.Time, limit int) ([]int64, error)
+}
@@ -0,0 +1,285 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ravindu/wallet-app-service/pkg/cron"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+)
+
+// MinScheduleInterval is the shortest repeat interval a schedule may use
+const MinScheduleInterval = time.Minute
+
+// MaxScheduleRetries caps how often one occurrence is retried
+const MaxScheduleRetries = 10
+
+// ScheduleStatus tracks a scheduled transfer through its lifecycle. Only ACTIVE
+// schedules are run.
+type ScheduleStatus string
+
+const (
+	// ScheduleActive has an occurrence still to run
+	ScheduleActive ScheduleStatus = "ACTIVE"
+	// ScheduleCompleted ran its last occurrence
+	ScheduleCompleted ScheduleStatus = "COMPLETED"
+	// ScheduleCancelled was cancelled by its owner
+	ScheduleCancelled ScheduleStatus = "CANCELLED"
+	// ScheduleFailed hit an error retrying can't fix, e.g. a wallet that no longer exists
+	ScheduleFailed ScheduleStatus = "FAILED"
+)
+
+// InsufficientFundsPolicy says what happens when the sender can't cover an occurrence
+type InsufficientFundsPolicy string
+
+const (
+	// PolicyRetry tries the occurrence again later, up to MaxRetries times, before skipping it
+	PolicyRetry InsufficientFundsPolicy = "RETRY"
+	// PolicySkip gives up on the occurrence straight away and waits for the next one
+	PolicySkip InsufficientFundsPolicy = "SKIP"
+)
+
+// ScheduledTransfer is a transfer that runs once at StartAt, or repeatedly by a
+// cron expression or a fixed interval, until EndsAt or until it is cancelled.
+type ScheduledTransfer struct {
+	ID               int64    `json:"id"`
+	UserID           int64    `json:"user_id"`
+	ReceiverID       int64    `json:"receiver_id"`
+	SenderWalletID   int64    `json:"sender_wallet_id,omitempty"`
+	ReceiverWalletID int64    `json:"receiver_wallet_id,omitempty"`
+	Currency         Currency `json:"currency,omitempty"`
+	ReceiverCurrency Currency `json:"receiver_currency,omitempty"`
+	Amount           Amount   `json:"amount"`
+	Comment          string   `json:"comment,omitempty"`
+	Convert          bool     `json:"convert,omitempty"`
+	// Cron and Interval are the repeat rule; a schedule with neither runs once
+	Cron     string `json:"cron,omitempty"`
+	Interval string `json:"interval,omitempty"`
+	// Timezone is the IANA zone Cron is read in
+	Timezone            string                  `json:"timezone"`
+	StartAt             time.Time               `json:"start_at"`
+	EndsAt              *time.Time              `json:"ends_at,omitempty"`
+	OnInsufficientFunds InsufficientFundsPolicy `json:"on_insufficient_funds"`
+	MaxRetries          int                     `json:"max_retries"`
+	Status              ScheduleStatus          `json:"status"`
+	// NextRunAt is the occurrence due to run next and NextAttemptAt when it will
+	// be tried, which is later while it is being retried
+	NextRunAt     *time.Time `json:"next_run_at,omitempty"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+	// Attempts counts failed attempts at the current occurrence
+	Attempts  int       `json:"attempts"`
+	LastError string    `json:"last_error,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Validate checks the transfer and its repeat rule
+func (s *ScheduledTransfer) Validate() error {
+	if s.Amount <= 0 {
+		return apperrors.ErrInvalidAmount
+	}
+	if s.ReceiverID <= 0 {
+		return fmt.Errorf("%w: receiver_id is required", apperrors.ErrInvalidInput)
+	}
+	if s.UserID == s.ReceiverID {
+		return apperrors.ErrSenderReceiverSame
+	}
+	if s.Cron != "" && s.Interval != "" {
+		return fmt.Errorf("%w: a schedule repeats by cron or by interval, not both", apperrors.ErrInvalidInput)
+	}
+
+	if s.Cron != "" {
+		if _, err := cron.Parse(s.Cron); err != nil {
+			return fmt.Errorf("%w: %v", apperrors.ErrInvalidInput, err)
+		}
+	}
+	if s.Interval != "" {
+		interval, err := time.ParseDuration(s.Interval)
+		if err != nil {
+			return fmt.Errorf("%w: interval must be a duration such as 24h", apperrors.ErrInvalidInput)
+		}
+		if interval < MinScheduleInterval {
+			return fmt.Errorf("%w: interval must be at least %s", apperrors.ErrInvalidInput, MinScheduleInterval)
+		}
+	}
+	if _, err := time.LoadLocation(s.Timezone); err != nil {
+		return fmt.Errorf("%w: unknown timezone %q", apperrors.ErrInvalidInput, s.Timezone)
+	}
+
+	if s.StartAt.IsZero() {
+		return fmt.Errorf("%w: start_at is required", apperrors.ErrInvalidInput)
+	}
+	if s.EndsAt != nil && s.EndsAt.Before(s.StartAt) {
+		return fmt.Errorf("%w: ends_at must not be before start_at", apperrors.ErrInvalidInput)
+	}
+
+	switch s.OnInsufficientFunds {
+	case PolicyRetry, PolicySkip:
+	default:
+		return fmt.Errorf("%w: on_insufficient_funds must be %s or %s", apperrors.ErrInvalidInput, PolicyRetry, PolicySkip)
+	}
+	if s.MaxRetries < 0 || s.MaxRetries > MaxScheduleRetries {
+		return fmt.Errorf("%w: max_retries must be between 0 and %d", apperrors.ErrInvalidInput, MaxScheduleRetries)
+	}
+
+	return nil
+}
+
+// Start activates a validated schedule at its first occurrence at or after StartAt
+func (s *ScheduledTransfer) Start() error {
+	first, ok := s.StartAt, true
+	if s.Cron != "" {
+		// Cron occurrences fall on the expression, not on StartAt itself
+		first, ok = s.nextAfter(s.StartAt.Add(-time.Nanosecond))
+	} else if s.EndsAt != nil && first.After(*s.EndsAt) {
+		ok = false
+	}
+	if !ok {
+		return fmt.Errorf("%w: schedule never runs between start_at and ends_at", apperrors.ErrInvalidInput)
+	}
+
+	s.Status = ScheduleActive
+	s.Attempts = 0
+	s.setNext(first)
+	return nil
+}
+
+// Advance moves the schedule past its current occurrence, to the first one
+// after now, completing it when there is none. Occurrences missed while no
+// scheduler was running are not made up.
+func (s *ScheduledTransfer) Advance(now time.Time) {
+	s.Attempts = 0
+
+	after := now
+	if s.NextRunAt != nil && s.NextRunAt.After(now) {
+		after = *s.NextRunAt
+	}
+
+	next, ok := s.nextAfter(after)
+	if !ok {
+		s.finish(ScheduleCompleted)
+		return
+	}
+	s.setNext(next)
+}
+
+// Retry keeps the current occurrence and tries it again at retryAt
+func (s *ScheduledTransfer) Retry(retryAt time.Time) {
+	s.NextAttemptAt = &retryAt
+}
+
+// Fail stops the schedule for good
+func (s *ScheduledTransfer) Fail() {
+	s.finish(ScheduleFailed)
+}
+
+// Cancel stops an active schedule at its owner's request
+func (s *ScheduledTransfer) Cancel() error {
+	if s.Status != ScheduleActive {
+		return fmt.Errorf("%w: schedule is %s", apperrors.ErrScheduleNotActive, s.Status)
+	}
+	s.finish(ScheduleCancelled)
+	return nil
+}
+
+// ClaimedBy reports whether s is still in the state it was in when it was
+// claimed, i.e. nobody ran, cancelled or reclaimed it since
+func (s *ScheduledTransfer) ClaimedBy(claimed *ScheduledTransfer) bool {
+	return s.Status == ScheduleActive &&
+		s.NextAttemptAt != nil && claimed.NextAttemptAt != nil &&
+		s.NextAttemptAt.Equal(*claimed.NextAttemptAt)
+}
+
+// TransferRequest is the transfer an occurrence makes
+func (s *ScheduledTransfer) TransferRequest() TransferRequest {
+	comment := s.Comment
+	if comment == "" {
+		comment = fmt.Sprintf("Scheduled transfer %d", s.ID)
+	}
+
+	return TransferRequest{
+		SenderID:         s.UserID,
+		ReceiverID:       s.ReceiverID,
+		SenderWalletID:   s.SenderWalletID,
+		ReceiverWalletID: s.ReceiverWalletID,
+		Currency:         s.Currency,
+		ReceiverCurrency: s.ReceiverCurrency,
+		Amount:           s.Amount,
+		Comment:          comment,
+		Convert:          s.Convert,
+	}
+}
+
+// nextAfter returns the first occurrence strictly after t, if there is one before EndsAt
+func (s *ScheduledTransfer) nextAfter(t time.Time) (time.Time, bool) {
+	var next time.Time
+	switch {
+	case s.Cron != "":
+		schedule, err := cron.Parse(s.Cron)
+		if err != nil {
+			return time.Time{}, false
+		}
+		loc, err := time.LoadLocation(s.Timezone)
+		if err != nil {
+			return time.Time{}, false
+		}
+		next = schedule.Next(t.In(loc))
+		if next.IsZero() {
+			return time.Time{}, false
+		}
+	case s.Interval != "":
+		// Occurrences stay on StartAt + n*interval however late they run
+		interval, err := time.ParseDuration(s.Interval)
+		if err != nil || interval <= 0 {
+			return time.Time{}, false
+		}
+		next = s.StartAt
+		if !t.Before(next) {
+			next = next.Add((t.Sub(next)/interval + 1) * interval)
+		}
+	default:
+		return time.Time{}, false
+	}
+
+	if s.EndsAt != nil && next.After(*s.EndsAt) {
+		return time.Time{}, false
+	}
+	return next.UTC(), true
+}
+
+// setNext makes at the occurrence to run next, trying it straight away
+func (s *ScheduledTransfer) setNext(at time.Time) {
+	s.NextRunAt = &at
+	s.NextAttemptAt = &at
+}
+
+// finish ends the schedule with status
+func (s *ScheduledTransfer) finish(status ScheduleStatus) {
+	s.Status = status
+	s.NextRunAt = nil
+	s.NextAttemptAt = nil
+}
+
+// ScheduleExecutionStatus is the outcome of one attempt at an occurrence
+type ScheduleExecutionStatus string
+
+const (
+	// ExecutionSucceeded made the transfer
+	ExecutionSucceeded ScheduleExecutionStatus = "SUCCEEDED"
+	// ExecutionFailed didn't make the transfer; the occurrence may be retried
+	ExecutionFailed ScheduleExecutionStatus = "FAILED"
+	// ExecutionSkipped didn't make the transfer and the occurrence was given up on
+	ExecutionSkipped ScheduleExecutionStatus = "SKIPPED"
+)
+
+// ScheduleExecution records one attempt at one occurrence of a scheduled transfer
+type ScheduleExecution struct {
+	ID            int64                   `json:"id"`
+	ScheduleID    int64                   `json:"schedule_id"`
+	ScheduledFor  time.Time               `json:"scheduled_for"`
+	Attempt       int                     `json:"attempt"`
+	Status        ScheduleExecutionStatus `json:"status"`
+	TransactionID *int64                  `json:"transaction_id,omitempty"`
+	Error         string                  `json:"error,omitempty"`
+	ExecutedAt    time.Time               `json:"executed_at"`
+}
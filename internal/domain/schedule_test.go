@@ -0,0 +1,145 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSchedule(start time.Time) *domain.ScheduledTransfer {
+	return &domain.ScheduledTransfer{
+		UserID:              1,
+		ReceiverID:          2,
+		Amount:              domain.NewAmount(100),
+		Timezone:            "UTC",
+		StartAt:             start,
+		OnInsufficientFunds: domain.PolicyRetry,
+		MaxRetries:          3,
+	}
+}
+
+func TestScheduledTransfer_Validate(t *testing.T) {
+	start := time.Date(2024, time.March, 1, 9, 0, 0, 0, time.UTC)
+	before := start.Add(-time.Hour)
+
+	tests := []struct {
+		name          string
+		modify        func(s *domain.ScheduledTransfer)
+		expectedError error
+	}{
+		{name: "one-off", modify: func(s *domain.ScheduledTransfer) {}},
+		{name: "cron", modify: func(s *domain.ScheduledTransfer) { s.Cron = "0 9 1 * *" }},
+		{name: "interval", modify: func(s *domain.ScheduledTransfer) { s.Interval = "24h" }},
+		{name: "zero amount", modify: func(s *domain.ScheduledTransfer) { s.Amount = 0 }, expectedError: apperrors.ErrInvalidAmount},
+		{name: "to self", modify: func(s *domain.ScheduledTransfer) { s.ReceiverID = 1 }, expectedError: apperrors.ErrSenderReceiverSame},
+		{name: "both rules", modify: func(s *domain.ScheduledTransfer) { s.Cron, s.Interval = "@daily", "24h" }, expectedError: apperrors.ErrInvalidInput},
+		{name: "bad cron", modify: func(s *domain.ScheduledTransfer) { s.Cron = "0 25 * * *" }, expectedError: apperrors.ErrInvalidInput},
+		{name: "interval too short", modify: func(s *domain.ScheduledTransfer) { s.Interval = "30s" }, expectedError: apperrors.ErrInvalidInput},
+		{name: "unknown timezone", modify: func(s *domain.ScheduledTransfer) { s.Timezone = "Mars/Olympus" }, expectedError: apperrors.ErrInvalidInput},
+		{name: "no start", modify: func(s *domain.ScheduledTransfer) { s.StartAt = time.Time{} }, expectedError: apperrors.ErrInvalidInput},
+		{name: "ends before it starts", modify: func(s *domain.ScheduledTransfer) { s.EndsAt = &before }, expectedError: apperrors.ErrInvalidInput},
+		{name: "unknown policy", modify: func(s *domain.ScheduledTransfer) { s.OnInsufficientFunds = "WAIT" }, expectedError: apperrors.ErrInvalidInput},
+		{name: "too many retries", modify: func(s *domain.ScheduledTransfer) { s.MaxRetries = 11 }, expectedError: apperrors.ErrInvalidInput},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			schedule := newSchedule(start)
+			tc.modify(schedule)
+
+			err := schedule.Validate()
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestScheduledTransfer_Occurrences(t *testing.T) {
+	start := time.Date(2024, time.January, 15, 12, 0, 0, 0, time.UTC)
+
+	t.Run("one-off runs once", func(t *testing.T) {
+		schedule := newSchedule(start)
+		require.NoError(t, schedule.Start())
+		assert.Equal(t, start, *schedule.NextRunAt)
+
+		schedule.Advance(start.Add(time.Second))
+		assert.Equal(t, domain.ScheduleCompleted, schedule.Status)
+		assert.Nil(t, schedule.NextRunAt)
+		assert.Nil(t, schedule.NextAttemptAt)
+	})
+
+	t.Run("cron runs on the expression in its timezone", func(t *testing.T) {
+		schedule := newSchedule(start)
+		schedule.Cron = "0 9 1 * *"
+		schedule.Timezone = "Asia/Colombo"
+		require.NoError(t, schedule.Start())
+
+		// 9:00 in Colombo is 3:30 UTC
+		assert.Equal(t, time.Date(2024, time.February, 1, 3, 30, 0, 0, time.UTC), *schedule.NextRunAt)
+
+		schedule.Advance(schedule.NextRunAt.Add(time.Minute))
+		assert.Equal(t, time.Date(2024, time.March, 1, 3, 30, 0, 0, time.UTC), *schedule.NextRunAt)
+	})
+
+	t.Run("interval stays aligned to the start when late", func(t *testing.T) {
+		schedule := newSchedule(start)
+		schedule.Interval = "1h0m0s"
+		require.NoError(t, schedule.Start())
+		assert.Equal(t, start, *schedule.NextRunAt)
+
+		// Ran 2.5 hours late; the missed occurrences aren't made up
+		schedule.Advance(start.Add(150 * time.Minute))
+		assert.Equal(t, start.Add(3*time.Hour), *schedule.NextRunAt)
+		assert.Equal(t, domain.ScheduleActive, schedule.Status)
+	})
+
+	t.Run("ends_at completes the schedule", func(t *testing.T) {
+		endsAt := start.Add(90 * time.Minute)
+		schedule := newSchedule(start)
+		schedule.Interval = "1h"
+		schedule.EndsAt = &endsAt
+		require.NoError(t, schedule.Start())
+
+		schedule.Advance(start)
+		assert.Equal(t, start.Add(time.Hour), *schedule.NextRunAt)
+
+		schedule.Advance(start.Add(time.Hour))
+		assert.Equal(t, domain.ScheduleCompleted, schedule.Status)
+	})
+
+	t.Run("cron that never fires before ends_at", func(t *testing.T) {
+		endsAt := start.Add(24 * time.Hour)
+		schedule := newSchedule(start)
+		schedule.Cron = "0 9 1 * *"
+		schedule.EndsAt = &endsAt
+
+		assert.ErrorIs(t, schedule.Start(), apperrors.ErrInvalidInput)
+	})
+
+	t.Run("retry keeps the occurrence", func(t *testing.T) {
+		schedule := newSchedule(start)
+		require.NoError(t, schedule.Start())
+
+		schedule.Retry(start.Add(time.Hour))
+		assert.Equal(t, start, *schedule.NextRunAt)
+		assert.Equal(t, start.Add(time.Hour), *schedule.NextAttemptAt)
+	})
+}
+
+func TestScheduledTransfer_Cancel(t *testing.T) {
+	schedule := newSchedule(time.Now().Add(time.Hour))
+	require.NoError(t, schedule.Start())
+
+	require.NoError(t, schedule.Cancel())
+	assert.Equal(t, domain.ScheduleCancelled, schedule.Status)
+	assert.Nil(t, schedule.NextAttemptAt)
+
+	assert.ErrorIs(t, schedule.Cancel(), apperrors.ErrScheduleNotActive)
+}
@@ -0,0 +1,71 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+)
+
+// StatementFormat is the file format a statement is exported in
+type StatementFormat string
+
+const (
+	// StatementCSV is a spreadsheet-friendly comma-separated file
+	StatementCSV StatementFormat = "csv"
+	// StatementOFX is an Open Financial Exchange file for accounting software
+	StatementOFX StatementFormat = "ofx"
+	// StatementPDF is a printable document
+	StatementPDF StatementFormat = "pdf"
+)
+
+// ParseStatementFormat reads a statement format, case-insensitively. Empty means CSV.
+func ParseStatementFormat(s string) (StatementFormat, error) {
+	switch format := StatementFormat(strings.ToLower(strings.TrimSpace(s))); format {
+	case "":
+		return StatementCSV, nil
+	case StatementCSV, StatementOFX, StatementPDF:
+		return format, nil
+	default:
+		return "", fmt.Errorf("%w: format must be csv, ofx or pdf", apperrors.ErrInvalidInput)
+	}
+}
+
+// StatementRequest asks for a wallet's statement over [From, To)
+type StatementRequest struct {
+	UserID   int64
+	Selector WalletSelector
+	From     time.Time
+	To       time.Time
+}
+
+// Validate checks the statement period makes sense
+func (r *StatementRequest) Validate() error {
+	if r.From.IsZero() || r.To.IsZero() {
+		return fmt.Errorf("%w: a statement needs from and to", apperrors.ErrInvalidInput)
+	}
+	if !r.From.Before(r.To) {
+		return fmt.Errorf("%w: from must be before to", apperrors.ErrInvalidInput)
+	}
+	return nil
+}
+
+// StatementHeader opens a statement: the wallet, the period and the balance at its start
+type StatementHeader struct {
+	Wallet         *Wallet
+	From           time.Time
+	To             time.Time
+	OpeningBalance Amount
+	GeneratedAt    time.Time
+}
+
+// StatementWriter renders a statement as its transactions arrive, so a long
+// history never has to be held in memory. Begin is called once, then
+// WriteTransaction for each transaction oldest first, then End with the
+// balance at the end of the period.
+type StatementWriter interface {
+	Begin(header StatementHeader) error
+	WriteTransaction(transaction *Transaction) error
+	End(closingBalance Amount) error
+}
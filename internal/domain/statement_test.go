@@ -0,0 +1,54 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseStatementFormat(t *testing.T) {
+	tests := []struct {
+		input         string
+		expected      domain.StatementFormat
+		expectedError error
+	}{
+		{input: "", expected: domain.StatementCSV},
+		{input: "csv", expected: domain.StatementCSV},
+		{input: " OFX ", expected: domain.StatementOFX},
+		{input: "pdf", expected: domain.StatementPDF},
+		{input: "xlsx", expectedError: apperrors.ErrInvalidInput},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.input, func(t *testing.T) {
+			format, err := domain.ParseStatementFormat(tc.input)
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, format)
+			}
+		})
+	}
+}
+
+func TestStatementRequest_Validate(t *testing.T) {
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	valid := domain.StatementRequest{UserID: 1, From: from, To: to}
+	assert.NoError(t, valid.Validate())
+
+	missing := domain.StatementRequest{UserID: 1, To: to}
+	assert.ErrorIs(t, missing.Validate(), apperrors.ErrInvalidInput)
+
+	reversed := domain.StatementRequest{UserID: 1, From: to, To: from}
+	assert.ErrorIs(t, reversed.Validate(), apperrors.ErrInvalidInput)
+
+	empty := domain.StatementRequest{UserID: 1, From: from, To: from}
+	assert.ErrorIs(t, empty.Validate(), apperrors.ErrInvalidInput)
+}
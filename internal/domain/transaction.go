@@ -2,6 +2,8 @@ package domain
 
 import (
 	"time"
+
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
 )
 
 // TransactionType represents the type of wallet transaction
@@ -12,20 +14,121 @@ const (
 	Deposit TransactionType = "DEPOSIT"
 	// Withdrawal represents money removed from wallet
 	Withdrawal TransactionType = "WITHDRAWAL"
-	// Transfer represents money sent to another user
+	// Transfer represents money sent to another user. Kept for transfers
+	// recorded before both sides got their own rows.
 	Transfer TransactionType = "TRANSFER"
+	// TransferOut represents the sender's side of a transfer
+	TransferOut TransactionType = "TRANSFER_OUT"
+	// TransferIn represents the receiver's side of a transfer
+	TransferIn TransactionType = "TRANSFER_IN"
+	// Reversal undoes whatever is left of an earlier transaction
+	Reversal TransactionType = "REVERSAL"
+	// Refund undoes part of an earlier transaction
+	Refund TransactionType = "REFUND"
+	// ConversionOut represents the source side of a conversion between a user's own wallets
+	ConversionOut TransactionType = "CONVERSION_OUT"
+	// ConversionIn represents the target side of a conversion between a user's own wallets
+	ConversionIn TransactionType = "CONVERSION_IN"
+)
+
+// ReversalStatus shows how much of a transaction has been undone
+type ReversalStatus string
+
+const (
+	// PartiallyRefunded means refunds cover part of the amount
+	PartiallyRefunded ReversalStatus = "PARTIALLY_REFUNDED"
+	// Refunded means refunds add up to the whole amount
+	Refunded ReversalStatus = "REFUNDED"
+	// Reversed means the transaction was reversed
+	Reversed ReversalStatus = "REVERSED"
 )
 
-// Transaction represents a wallet transaction
+// Transaction represents a wallet transaction.
+// A transfer is recorded as a TRANSFER_OUT row on the sender's wallet and a
+// TRANSFER_IN row on the receiver's, linked by CorrelationID, so each side
+// sees its own running balance. Reversals and refunds are recorded the same way,
+// each row pointing back at the row it compensates via OriginalTransactionID.
+// Both rows of a cross-currency transfer or conversion carry the rate applied and
+// the amounts on either side, each row's Amount being in its own wallet's currency.
+// A fee is charged as a FEE row of its own, pointing at the row it was charged for
+// via ParentTransactionID; that row shows the fee and the schedule version it
+// came from.
 type Transaction struct {
-	ID              int64           `json:"id"`
-	WalletID        int64           `json:"wallet_id"`
-	DestWalletID    *int64          `json:"dest_wallet_id,omitempty"`
-	Type            TransactionType `json:"type"`
-	Amount          float64         `json:"amount"`
-	BalanceBefore   float64         `json:"balance_before"`
-	BalanceAfter    float64         `json:"balance_after"`
-	Description     string          `json:"description"`
-	TransactionTime time.Time       `json:"transaction_time"`
-	CreatedAt       time.Time       `json:"created_at"`
-}
\ No newline at end of file
+	ID                    int64           `json:"id"`
+	WalletID              int64           `json:"wallet_id"`
+	DestWalletID          *int64          `json:"dest_wallet_id,omitempty"`
+	Type                  TransactionType `json:"type"`
+	Amount                Amount          `json:"amount"`
+	BalanceBefore         Amount          `json:"balance_before"`
+	BalanceAfter          Amount          `json:"balance_after"`
+	Description           string          `json:"description"`
+	JournalEntryID        *int64          `json:"journal_entry_id,omitempty"`
+	CorrelationID         string          `json:"correlation_id,omitempty"`
+	CounterpartyWalletID  *int64          `json:"counterparty_wallet_id,omitempty"`
+	CounterpartyUserID    *int64          `json:"counterparty_user_id,omitempty"`
+	OriginalTransactionID *int64          `json:"original_transaction_id,omitempty"`
+	ReversedAmount        Amount          `json:"reversed_amount"`
+	ReversalStatus        ReversalStatus  `json:"reversal_status,omitempty"`
+	FXQuoteID             string          `json:"fx_quote_id,omitempty"`
+	FXRate                *Rate           `json:"fx_rate,omitempty"`
+	SourceAmount          *Amount         `json:"source_amount,omitempty"`
+	TargetAmount          *Amount         `json:"target_amount,omitempty"`
+	Fee                   *Amount         `json:"fee,omitempty"`
+	FeeVersion            string          `json:"fee_version,omitempty"`
+	ParentTransactionID   *int64          `json:"parent_transaction_id,omitempty"`
+	TransactionTime       time.Time       `json:"transaction_time"`
+	CreatedAt             time.Time       `json:"created_at"`
+}
+
+// IsReversible reports whether the transaction can be reversed or refunded.
+// Converted amounts can't be, since the rate has moved on since.
+func (t *Transaction) IsReversible() bool {
+	if t.FXRate != nil {
+		return false
+	}
+	switch t.Type {
+	case Deposit, Withdrawal, TransferOut, TransferIn:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsCredit reports whether the transaction added money to its wallet
+func (t *Transaction) IsCredit() bool {
+	return t.Type == Deposit || t.Type == TransferIn || t.Type == FeeCollected
+}
+
+// Change returns the signed effect the transaction had on its wallet's
+// balance: positive for money in, negative for money out
+func (t *Transaction) Change() Amount {
+	return t.BalanceAfter - t.BalanceBefore
+}
+
+// Unreversed returns the part of the amount that has not been undone yet
+func (t *Transaction) Unreversed() Amount {
+	return t.Amount - t.ReversedAmount
+}
+
+// ApplyReversal records that amount of the transaction was undone by a
+// compensating transaction of type kind (Reversal or Refund)
+func (t *Transaction) ApplyReversal(kind TransactionType, amount Amount) error {
+	if amount <= 0 {
+		return apperrors.ErrInvalidAmount
+	}
+	if amount > t.Unreversed() {
+		return apperrors.ErrReversalTooLarge
+	}
+
+	t.ReversedAmount += amount
+
+	switch {
+	case kind == Reversal:
+		t.ReversalStatus = Reversed
+	case t.ReversedAmount == t.Amount:
+		t.ReversalStatus = Refunded
+	default:
+		t.ReversalStatus = PartiallyRefunded
+	}
+	return nil
+}
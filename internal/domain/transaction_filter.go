@@ -0,0 +1,103 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+)
+
+// MaxSearchLength caps the free-text search on transaction descriptions
+const MaxSearchLength = 100
+
+// SortOrder orders a transaction listing by transaction time
+type SortOrder string
+
+const (
+	// SortNewestFirst lists the latest transactions first, the default
+	SortNewestFirst SortOrder = "desc"
+	// SortOldestFirst lists the earliest transactions first
+	SortOldestFirst SortOrder = "asc"
+)
+
+// transactionTypes are the types a listing can be filtered by
+var transactionTypes = map[TransactionType]bool{
+	Deposit:       true,
+	Withdrawal:    true,
+	Transfer:      true,
+	TransferOut:   true,
+	TransferIn:    true,
+	Reversal:      true,
+	Refund:        true,
+	ConversionOut: true,
+	ConversionIn:  true,
+	Fee:           true,
+	FeeCollected:  true,
+}
+
+// TransactionFilter narrows a wallet's transaction listing. Zero fields don't
+// filter. From is inclusive and To exclusive, and the amount bounds are both
+// inclusive. Search matches descriptions containing the text, ignoring case.
+type TransactionFilter struct {
+	Types                []TransactionType `json:"types,omitempty"`
+	From                 *time.Time        `json:"from,omitempty"`
+	To                   *time.Time        `json:"to,omitempty"`
+	MinAmount            *Amount           `json:"min_amount,omitempty"`
+	MaxAmount            *Amount           `json:"max_amount,omitempty"`
+	CounterpartyWalletID *int64            `json:"counterparty_wallet_id,omitempty"`
+	Search               string            `json:"search,omitempty"`
+	Sort                 SortOrder         `json:"sort,omitempty"`
+}
+
+// ParseSortOrder reads a sort order, case-insensitively. Empty means newest first.
+func ParseSortOrder(s string) (SortOrder, error) {
+	switch order := SortOrder(strings.ToLower(strings.TrimSpace(s))); order {
+	case "":
+		return SortNewestFirst, nil
+	case SortNewestFirst, SortOldestFirst:
+		return order, nil
+	default:
+		return "", fmt.Errorf("%w: sort must be %q or %q", apperrors.ErrInvalidInput, SortNewestFirst, SortOldestFirst)
+	}
+}
+
+// ParseTransactionType reads a transaction type, case-insensitively
+func ParseTransactionType(s string) (TransactionType, error) {
+	transactionType := TransactionType(strings.ToUpper(strings.TrimSpace(s)))
+	if !transactionTypes[transactionType] {
+		return "", fmt.Errorf("%w: unknown transaction type %q", apperrors.ErrInvalidInput, s)
+	}
+	return transactionType, nil
+}
+
+// Validate checks the filter's bounds make sense
+func (f *TransactionFilter) Validate() error {
+	for _, transactionType := range f.Types {
+		if !transactionTypes[transactionType] {
+			return fmt.Errorf("%w: unknown transaction type %q", apperrors.ErrInvalidInput, transactionType)
+		}
+	}
+	if f.From != nil && f.To != nil && !f.From.Before(*f.To) {
+		return fmt.Errorf("%w: from must be before to", apperrors.ErrInvalidInput)
+	}
+	if f.MinAmount != nil && *f.MinAmount < 0 {
+		return fmt.Errorf("%w: min_amount can't be negative", apperrors.ErrInvalidInput)
+	}
+	if f.MinAmount != nil && f.MaxAmount != nil && *f.MinAmount > *f.MaxAmount {
+		return fmt.Errorf("%w: min_amount can't be above max_amount", apperrors.ErrInvalidInput)
+	}
+	if utf8.RuneCountInString(f.Search) > MaxSearchLength {
+		return fmt.Errorf("%w: search can't be longer than %d characters", apperrors.ErrInvalidInput, MaxSearchLength)
+	}
+	if _, err := ParseSortOrder(string(f.Sort)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Descending reports whether the listing runs newest first
+func (f *TransactionFilter) Descending() bool {
+	return f.Sort != SortOldestFirst
+}
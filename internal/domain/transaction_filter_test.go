@@ -0,0 +1,81 @@
+package domain_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransactionFilter_Validate(t *testing.T) {
+	may := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	june := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	small := domain.NewAmount(5)
+	large := domain.NewAmount(50)
+	negative := domain.NewAmount(-1)
+
+	tests := []struct {
+		name          string
+		filter        domain.TransactionFilter
+		expectedError error
+	}{
+		{name: "empty", filter: domain.TransactionFilter{}},
+		{
+			name: "every field",
+			filter: domain.TransactionFilter{
+				Types:     []domain.TransactionType{domain.Deposit, domain.ConversionIn},
+				From:      &may,
+				To:        &june,
+				MinAmount: &small,
+				MaxAmount: &large,
+				Search:    "rent",
+				Sort:      domain.SortOldestFirst,
+			},
+		},
+		{name: "equal amounts", filter: domain.TransactionFilter{MinAmount: &small, MaxAmount: &small}},
+		{name: "unknown type", filter: domain.TransactionFilter{Types: []domain.TransactionType{"GIFT"}}, expectedError: apperrors.ErrInvalidInput},
+		{name: "from after to", filter: domain.TransactionFilter{From: &june, To: &may}, expectedError: apperrors.ErrInvalidInput},
+		{name: "empty range", filter: domain.TransactionFilter{From: &may, To: &may}, expectedError: apperrors.ErrInvalidInput},
+		{name: "min above max", filter: domain.TransactionFilter{MinAmount: &large, MaxAmount: &small}, expectedError: apperrors.ErrInvalidInput},
+		{name: "negative min", filter: domain.TransactionFilter{MinAmount: &negative}, expectedError: apperrors.ErrInvalidInput},
+		{name: "search too long", filter: domain.TransactionFilter{Search: strings.Repeat("a", domain.MaxSearchLength+1)}, expectedError: apperrors.ErrInvalidInput},
+		{name: "unknown sort", filter: domain.TransactionFilter{Sort: "sideways"}, expectedError: apperrors.ErrInvalidInput},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.filter.Validate()
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestParseTransactionType(t *testing.T) {
+	transactionType, err := domain.ParseTransactionType(" transfer_out ")
+	assert.NoError(t, err)
+	assert.Equal(t, domain.TransferOut, transactionType)
+
+	_, err = domain.ParseTransactionType("OPENING_BALANCE")
+	assert.ErrorIs(t, err, apperrors.ErrInvalidInput)
+}
+
+func TestParseSortOrder(t *testing.T) {
+	order, err := domain.ParseSortOrder("")
+	assert.NoError(t, err)
+	assert.Equal(t, domain.SortNewestFirst, order)
+
+	order, err = domain.ParseSortOrder("ASC")
+	assert.NoError(t, err)
+	assert.Equal(t, domain.SortOldestFirst, order)
+
+	_, err = domain.ParseSortOrder("amount")
+	assert.ErrorIs(t, err, apperrors.ErrInvalidInput)
+}
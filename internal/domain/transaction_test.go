@@ -0,0 +1,91 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransaction_ApplyReversal(t *testing.T) {
+	tests := []struct {
+		name             string
+		reversedAmount   domain.Amount
+		kind             domain.TransactionType
+		amount           domain.Amount
+		expectedError    error
+		expectedReversed domain.Amount
+		expectedStatus   domain.ReversalStatus
+	}{
+		{
+			name:             "partial refund",
+			kind:             domain.Refund,
+			amount:           domain.NewAmount(30),
+			expectedReversed: domain.NewAmount(30),
+			expectedStatus:   domain.PartiallyRefunded,
+		},
+		{
+			name:             "refund of the remainder",
+			reversedAmount:   domain.NewAmount(30),
+			kind:             domain.Refund,
+			amount:           domain.NewAmount(70),
+			expectedReversed: domain.NewAmount(100),
+			expectedStatus:   domain.Refunded,
+		},
+		{
+			name:             "reversal after a refund",
+			reversedAmount:   domain.NewAmount(30),
+			kind:             domain.Reversal,
+			amount:           domain.NewAmount(70),
+			expectedReversed: domain.NewAmount(100),
+			expectedStatus:   domain.Reversed,
+		},
+		{
+			name:             "refund above the remainder",
+			reversedAmount:   domain.NewAmount(30),
+			kind:             domain.Refund,
+			amount:           domain.NewAmount(71),
+			expectedError:    apperrors.ErrReversalTooLarge,
+			expectedReversed: domain.NewAmount(30),
+		},
+		{
+			name:          "zero amount",
+			kind:          domain.Refund,
+			amount:        0,
+			expectedError: apperrors.ErrInvalidAmount,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tr := &domain.Transaction{
+				Type:           domain.Deposit,
+				Amount:         domain.NewAmount(100),
+				ReversedAmount: tc.reversedAmount,
+			}
+
+			err := tr.ApplyReversal(tc.kind, tc.amount)
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedStatus, tr.ReversalStatus)
+			}
+			assert.Equal(t, tc.expectedReversed, tr.ReversedAmount)
+		})
+	}
+}
+
+func TestTransaction_IsReversible(t *testing.T) {
+	reversible := []domain.TransactionType{domain.Deposit, domain.Withdrawal, domain.TransferOut, domain.TransferIn}
+	for _, transactionType := range reversible {
+		assert.True(t, (&domain.Transaction{Type: transactionType}).IsReversible(), transactionType)
+	}
+
+	// Legacy single-row transfers, compensating rows and fees can't be undone
+	for _, transactionType := range []domain.TransactionType{domain.Transfer, domain.Reversal, domain.Refund, domain.Fee} {
+		assert.False(t, (&domain.Transaction{Type: transactionType}).IsReversible(), transactionType)
+	}
+}
@@ -1,41 +1,271 @@
 package domain
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
-// DepositRequest represents deposit parameters
+// DepositRequest represents deposit parameters. The embedded selector names
+// the wallet by wallet_id or currency.
 type DepositRequest struct {
-	UserID  int64   `json:"user_id"`
-	Amount  float64 `json:"amount"`
-	Comment string  `json:"comment,omitempty"`
+	UserID int64 `json:"user_id"`
+	WalletSelector
+	Amount  Amount `json:"amount"`
+	Comment string `json:"comment,omitempty"`
 }
 
-// WithdrawRequest represents withdrawal parameters
+// WithdrawRequest represents withdrawal parameters. The embedded selector names
+// the wallet by wallet_id or currency.
 type WithdrawRequest struct {
-	UserID  int64   `json:"user_id"`
-	Amount  float64 `json:"amount"`
-	Comment string  `json:"comment,omitempty"`
+	UserID int64 `json:"user_id"`
+	WalletSelector
+	Amount  Amount `json:"amount"`
+	Comment string `json:"comment,omitempty"`
+	// WaiveFee skips the withdrawal fee and SkipLimits the withdrawal limits. Only
+	// hold captures set them, clients can't.
+	WaiveFee   bool `json:"-"`
+	SkipLimits bool `json:"-"`
 }
 
-// TransferRequest represents transfer parameters
+// TransferRequest represents transfer parameters. Currency picks the sender's
+// wallet when SenderWalletID is left out. The receiver's wallet is picked by
+// ReceiverWalletID or ReceiverCurrency, defaulting to the sender wallet's currency.
+// Wallets in different currencies need Convert or a QuoteID; Amount is always in
+// the sender's currency.
 type TransferRequest struct {
-	SenderID   int64   `json:"sender_id"`
-	ReceiverID int64   `json:"receiver_id"`
-	Amount     float64 `json:"amount"`
-	Comment    string  `json:"comment,omitempty"`
+	SenderID         int64    `json:"sender_id"`
+	ReceiverID       int64    `json:"receiver_id"`
+	SenderWalletID   int64    `json:"sender_wallet_id,omitempty"`
+	ReceiverWalletID int64    `json:"receiver_wallet_id,omitempty"`
+	Currency         Currency `json:"currency,omitempty"`
+	ReceiverCurrency Currency `json:"receiver_currency,omitempty"`
+	Amount           Amount   `json:"amount"`
+	Comment          string   `json:"comment,omitempty"`
+	// Convert prices a cross-currency transfer at the current rate
+	Convert bool `json:"convert,omitempty"`
+	// QuoteID prices a cross-currency transfer at a previously quoted rate
+	QuoteID string `json:"quote_id,omitempty"`
 }
 
-// PaginationRequest for limiting result sets
+// ConvertRequest represents conversion parameters. Amount is in the source
+// wallet's currency. Without a QuoteID the current rate is used.
+type ConvertRequest struct {
+	UserID  int64          `json:"user_id"`
+	From    WalletSelector `json:"from"`
+	To      WalletSelector `json:"to"`
+	Amount  Amount         `json:"amount"`
+	QuoteID string         `json:"quote_id,omitempty"`
+	Comment string         `json:"comment,omitempty"`
+}
+
+// ConversionResponse is both sides of a conversion
+type ConversionResponse struct {
+	Debit  *Transaction `json:"debit"`
+	Credit *Transaction `json:"credit"`
+}
+
+// CreateQuoteRequest represents quote parameters
+type CreateQuoteRequest struct {
+	UserID       int64    `json:"user_id"`
+	FromCurrency Currency `json:"from_currency"`
+	ToCurrency   Currency `json:"to_currency"`
+}
+
+// ReversalRequest represents reversal and refund parameters. Amount is only
+// used by refunds; a reversal always undoes whatever is left of the transaction.
+type ReversalRequest struct {
+	TransactionID int64  `json:"-"`
+	Amount        Amount `json:"amount,omitempty"`
+	Comment       string `json:"comment,omitempty"`
+	// AllowNegative lets the reversal go through even if it leaves the wallet
+	// it takes money back from below zero
+	AllowNegative bool `json:"allow_negative,omitempty"`
+}
+
+// ReversalResponse is the reversed transaction with its updated reversal status,
+// together with the compensating transactions that were created
+type ReversalResponse struct {
+	Original     *Transaction   `json:"original"`
+	Transactions []*Transaction `json:"transactions"`
+}
+
+// PlaceHoldRequest represents hold parameters. ExpiresAt defaults to the
+// configured hold lifetime when left out.
+type PlaceHoldRequest struct {
+	UserID int64 `json:"user_id"`
+	WalletSelector
+	Amount      Amount    `json:"amount"`
+	Description string    `json:"description,omitempty"`
+	ExpiresAt   time.Time `json:"expires_at,omitempty"`
+}
+
+// CaptureHoldRequest represents capture parameters. Amount defaults to the
+// whole hold when left out.
+type CaptureHoldRequest struct {
+	Amount  Amount `json:"amount,omitempty"`
+	Comment string `json:"comment,omitempty"`
+}
+
+// CreateWalletRequest represents the parameters of a user's additional wallet
+type CreateWalletRequest struct {
+	Currency Currency `json:"currency"`
+	Label    string   `json:"label,omitempty"`
+}
+
+// CreateUserRequest represents registration parameters
+type CreateUserRequest struct {
+	Username string   `json:"username"`
+	Email    string   `json:"email"`
+	Currency Currency `json:"currency,omitempty"`
+}
+
+// UpdateUserRequest represents profile changes. Omitted fields are left as they are.
+type UpdateUserRequest struct {
+	Username *string `json:"username,omitempty"`
+	Email    *string `json:"email,omitempty"`
+}
+
+// RegistrationResponse is a newly created user together with their wallet
+type RegistrationResponse struct {
+	User   *User   `json:"user"`
+	Wallet *Wallet `json:"wallet"`
+}
+
+// CreateWebhookRequest represents webhook subscription parameters
+type CreateWebhookRequest struct {
+	URL        string      `json:"url"`
+	EventTypes []EventType `json:"event_types"`
+}
+
+// CreateScheduledTransferRequest represents scheduled transfer parameters. The
+// sender is the caller. Without Cron or Interval the transfer runs once at
+// StartAt; a repeating schedule starts now when StartAt is left out.
+type CreateScheduledTransferRequest struct {
+	ReceiverID          int64                   `json:"receiver_id"`
+	SenderWalletID      int64                   `json:"sender_wallet_id,omitempty"`
+	ReceiverWalletID    int64                   `json:"receiver_wallet_id,omitempty"`
+	Currency            Currency                `json:"currency,omitempty"`
+	ReceiverCurrency    Currency                `json:"receiver_currency,omitempty"`
+	Amount              Amount                  `json:"amount"`
+	Comment             string                  `json:"comment,omitempty"`
+	Convert             bool                    `json:"convert,omitempty"`
+	StartAt             *time.Time              `json:"start_at,omitempty"`
+	Cron                string                  `json:"cron,omitempty"`
+	Interval            string                  `json:"interval,omitempty"`
+	Timezone            string                  `json:"timezone,omitempty"`
+	EndsAt              *time.Time              `json:"ends_at,omitempty"`
+	OnInsufficientFunds InsufficientFundsPolicy `json:"on_insufficient_funds,omitempty"`
+	// MaxRetries defaults to 3; it only applies with the RETRY policy
+	MaxRetries *int `json:"max_retries,omitempty"`
+}
+
+// CreateTransferBatchRequest represents batch transfer parameters. The sender
+// is the caller; every item is paid from the same sender wallet.
+type CreateTransferBatchRequest struct {
+	SenderWalletID int64    `json:"sender_wallet_id,omitempty"`
+	Currency       Currency `json:"currency,omitempty"`
+	// Mode defaults to ALL_OR_NOTHING
+	Mode  BatchMode                  `json:"mode,omitempty"`
+	Items []TransferBatchItemRequest `json:"items"`
+}
+
+// TransferBatchItemRequest is one receiver and amount of a batch
+type TransferBatchItemRequest struct {
+	ReceiverID       int64  `json:"receiver_id"`
+	ReceiverWalletID int64  `json:"receiver_wallet_id,omitempty"`
+	Amount           Amount `json:"amount"`
+	Comment          string `json:"comment,omitempty"`
+}
+
+// CreatePaymentRequestRequest represents payment request parameters. The
+// requester is the caller, paid into the wallet picked by WalletID or Currency.
+// ExpiresAt defaults to the configured payment request lifetime when left out.
+type CreatePaymentRequestRequest struct {
+	PayerID   int64      `json:"payer_id"`
+	WalletID  int64      `json:"wallet_id,omitempty"`
+	Currency  Currency   `json:"currency,omitempty"`
+	Amount    Amount     `json:"amount"`
+	Memo      string     `json:"memo,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// AcceptPaymentRequestRequest represents accept parameters. The payer pays from
+// their wallet in the request's currency unless WalletID picks another.
+type AcceptPaymentRequestRequest struct {
+	WalletID int64 `json:"wallet_id,omitempty"`
+}
+
+// FeeQuoteRequest represents fee quote parameters
+type FeeQuoteRequest struct {
+	TransactionType TransactionType `json:"transaction_type"`
+	Currency        Currency        `json:"currency"`
+	Amount          Amount          `json:"amount"`
+}
+
+// PaginationRequest for limiting result sets. Listings that support it page
+// with Cursor instead of Offset when one is given.
 type PaginationRequest struct {
-	Limit  int `json:"limit"`
-	Offset int `json:"offset"`
+	Limit  int    `json:"limit"`
+	Offset int    `json:"offset"`
+	Cursor string `json:"cursor,omitempty"`
+	// IncludeTotal asks for the total row count, which costs a full count
+	IncludeTotal bool `json:"include_total,omitempty"`
 }
 
-// TransactionHistoryResponse for transaction listings
+// TransactionHistoryResponse for transaction listings. NextCursor pages on
+// through the listing and PrevCursor back towards its start; either is left
+// out when there is nothing more that way.
 type TransactionHistoryResponse struct {
 	Transactions []*Transaction `json:"transactions"`
-	Total        int            `json:"total"`
+	Total        *int           `json:"total,omitempty"`
 	Limit        int            `json:"limit"`
 	Offset       int            `json:"offset"`
+	NextCursor   string         `json:"next_cursor,omitempty"`
+	PrevCursor   string         `json:"prev_cursor,omitempty"`
+	// Next and Prev are links to the pages the cursors point at
+	Next string `json:"next,omitempty"`
+	Prev string `json:"prev,omitempty"`
+}
+
+// WebhookDeliveryHistoryResponse for delivery log listings
+type WebhookDeliveryHistoryResponse struct {
+	Deliveries []*WebhookDelivery `json:"deliveries"`
+	Total      int                `json:"total"`
+	Limit      int                `json:"limit"`
+	Offset     int                `json:"offset"`
+}
+
+// ScheduleExecutionHistoryResponse for scheduled transfer execution listings
+type ScheduleExecutionHistoryResponse struct {
+	Executions []*ScheduleExecution `json:"executions"`
+	Total      int                  `json:"total"`
+	Limit      int                  `json:"limit"`
+	Offset     int                  `json:"offset"`
+}
+
+// TransferBatchItemsResponse for transfer batch item listings
+type TransferBatchItemsResponse struct {
+	Items  []*TransferBatchItem `json:"items"`
+	Total  int                  `json:"total"`
+	Limit  int                  `json:"limit"`
+	Offset int                  `json:"offset"`
+}
+
+// PaymentRequestListResponse for payment request listings
+type PaymentRequestListResponse struct {
+	PaymentRequests []*PaymentRequest `json:"payment_requests"`
+	Total           int               `json:"total"`
+	Limit           int               `json:"limit"`
+	Offset          int               `json:"offset"`
+}
+
+// LimitsResponse shows a wallet's transaction limits and what is left of them
+type LimitsResponse struct {
+	UserID   int64         `json:"user_id"`
+	Tier     UserTier      `json:"tier"`
+	WalletID int64         `json:"wallet_id"`
+	Currency Currency      `json:"currency"`
+	Limits   []LimitStatus `json:"limits"`
 }
 
 // WalletUsecase defines business logic for wallet operations
@@ -43,6 +273,139 @@ type WalletUsecase interface {
 	Deposit(ctx context.Context, req DepositRequest) (*Transaction, error)
 	Withdraw(ctx context.Context, req WithdrawRequest) (*Transaction, error)
 	Transfer(ctx context.Context, req TransferRequest) (*Transaction, error)
-	GetBalance(ctx context.Context, userID int64) (*Wallet, error)
-	GetTransactionHistory(ctx context.Context, userID int64, pagination PaginationRequest) (*TransactionHistoryResponse, error)
-}
\ No newline at end of file
+	Convert(ctx context.Context, req ConvertRequest) (*ConversionResponse, error)
+	Reverse(ctx context.Context, req ReversalRequest) (*ReversalResponse, error)
+	Refund(ctx context.Context, req ReversalRequest) (*ReversalResponse, error)
+	GetBalance(ctx context.Context, userID int64, selector WalletSelector) (*Wallet, error)
+	GetTransactionHistory(
+		ctx context.Context,
+		userID int64,
+		selector WalletSelector,
+		filter TransactionFilter,
+		pagination PaginationRequest,
+	) (*TransactionHistoryResponse, error)
+}
+
+// BalanceUsecase answers what a wallet held in the past
+type BalanceUsecase interface {
+	// GetBalanceAt returns the wallet's balance just before at
+	GetBalanceAt(ctx context.Context, userID int64, selector WalletSelector, at time.Time) (*HistoricalBalance, error)
+	// SnapshotBalances records every wallet's balance as of the midnight ending
+	// asOf's UTC day, batchSize wallets at a time, and returns how many wallets
+	// it covered
+	SnapshotBalances(ctx context.Context, asOf time.Time, batchSize int) (int, error)
+	// LatestSnapshot returns the most recent snapshot time, or the zero time if
+	// nothing has been snapshotted yet
+	LatestSnapshot(ctx context.Context) (time.Time, error)
+}
+
+// ReconciliationUsecase checks wallet balances against their transactions and ledger
+type ReconciliationUsecase interface {
+	Reconcile(ctx context.Context, opts ReconcileOptions) (*ReconciliationReport, error)
+}
+
+// HoldUsecase defines business logic for reserving wallet funds
+type HoldUsecase interface {
+	PlaceHold(ctx context.Context, req PlaceHoldRequest) (*Hold, error)
+	GetHold(ctx context.Context, holdID int64) (*Hold, error)
+	CaptureHold(ctx context.Context, holdID int64, req CaptureHoldRequest) (*Hold, error)
+	VoidHold(ctx context.Context, holdID int64) (*Hold, error)
+	// ExpireHolds ends up to limit holds whose time has run out and returns how many it ended
+	ExpireHolds(ctx context.Context, limit int) (int, error)
+}
+
+// FXUsecase defines how exchange rates are quoted and locked
+type FXUsecase interface {
+	CreateQuote(ctx context.Context, req CreateQuoteRequest) (*FXQuote, error)
+	// UseQuote spends a quote of userID's on a from-to conversion in the caller's
+	// unit of work. An empty quoteID prices the conversion at the current rate.
+	UseQuote(ctx context.Context, userID int64, quoteID string, from, to Currency) (*FXQuote, error)
+}
+
+// FeeUsecase defines how the platform's fees are priced
+type FeeUsecase interface {
+	// Quote prices the fee on a transaction made now
+	Quote(ctx context.Context, req FeeQuoteRequest) (*FeeQuote, error)
+	// WalletUserID returns the platform user whose wallets fees are paid into
+	WalletUserID() int64
+}
+
+// LimitUsecase defines how per-user transaction limits are enforced
+type LimitUsecase interface {
+	// Check returns ErrLimitExceeded if user may not make a transaction of amount
+	// from wallet. It runs in the caller's unit of work, with wallet locked.
+	Check(ctx context.Context, user *User, wallet *Wallet, transactionType TransactionType, amount Amount) error
+	// GetLimits shows the limits on the user's wallet the selector points at
+	GetLimits(ctx context.Context, userID int64, selector WalletSelector) (*LimitsResponse, error)
+}
+
+// UserUsecase defines business logic for user accounts
+type UserUsecase interface {
+	Register(ctx context.Context, req CreateUserRequest) (*RegistrationResponse, error)
+	GetUser(ctx context.Context, userID int64) (*User, error)
+	UpdateUser(ctx context.Context, userID int64, req UpdateUserRequest) (*User, error)
+	CreateWallet(ctx context.Context, userID int64, req CreateWalletRequest) (*Wallet, error)
+	ListWallets(ctx context.Context, userID int64) ([]*Wallet, error)
+}
+
+// WebhookUsecase defines how users manage their webhooks. Every method acts on
+// behalf of userID and only touches that user's subscriptions.
+type WebhookUsecase interface {
+	CreateSubscription(ctx context.Context, userID int64, req CreateWebhookRequest) (*WebhookSubscription, error)
+	ListSubscriptions(ctx context.Context, userID int64) ([]*WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, userID, subscriptionID int64) error
+	ListDeliveries(ctx context.Context, userID, subscriptionID int64, pagination PaginationRequest) (*WebhookDeliveryHistoryResponse, error)
+	Redeliver(ctx context.Context, userID, subscriptionID, deliveryID int64) (*WebhookDelivery, error)
+}
+
+// LedgerUsecase defines read access to the double-entry ledger
+type LedgerUsecase interface {
+	GetTrialBalance(ctx context.Context) (*TrialBalance, error)
+}
+
+// StatementUsecase defines exporting wallet statements
+type StatementUsecase interface {
+	// WriteStatement streams the statement for req into w
+	WriteStatement(ctx context.Context, req StatementRequest, w StatementWriter) error
+}
+
+// ScheduledTransferUsecase defines how users manage scheduled transfers and how
+// due ones are run. The management methods act on behalf of userID and only
+// touch that user's schedules.
+type ScheduledTransferUsecase interface {
+	CreateSchedule(ctx context.Context, userID int64, req CreateScheduledTransferRequest) (*ScheduledTransfer, error)
+	ListSchedules(ctx context.Context, userID int64) ([]*ScheduledTransfer, error)
+	GetSchedule(ctx context.Context, userID, scheduleID int64) (*ScheduledTransfer, error)
+	CancelSchedule(ctx context.Context, userID, scheduleID int64) (*ScheduledTransfer, error)
+	ListExecutions(ctx context.Context, userID, scheduleID int64, pagination PaginationRequest) (*ScheduleExecutionHistoryResponse, error)
+	// RunDueSchedules attempts up to limit due occurrences and returns how many it attempted
+	RunDueSchedules(ctx context.Context, limit int) (int, error)
+}
+
+// TransferBatchUsecase defines how users submit batch transfers and follow
+// their progress, and how submitted batches are processed. The user-facing
+// methods act on behalf of userID and only touch that user's batches.
+type TransferBatchUsecase interface {
+	CreateBatch(ctx context.Context, userID int64, req CreateTransferBatchRequest) (*TransferBatch, error)
+	GetBatch(ctx context.Context, userID, batchID int64) (*TransferBatch, error)
+	// ListItems returns a page of the batch's items; an empty status lists them all
+	ListItems(ctx context.Context, userID, batchID int64, status BatchItemStatus, pagination PaginationRequest) (*TransferBatchItemsResponse, error)
+	// ProcessBatches works through up to limit waiting batches and returns how many it processed
+	ProcessBatches(ctx context.Context, limit int) (int, error)
+}
+
+// PaymentRequestUsecase defines how users ask each other for money. Requests
+// are visible to both parties; only the payer can accept or decline one and
+// only the requester can cancel it.
+type PaymentRequestUsecase interface {
+	CreateRequest(ctx context.Context, userID int64, req CreatePaymentRequestRequest) (*PaymentRequest, error)
+	// ListRequests returns a page of the requests userID made or was sent, newest first
+	ListRequests(ctx context.Context, userID int64, filter PaymentRequestFilter, pagination PaginationRequest) (*PaymentRequestListResponse, error)
+	GetRequest(ctx context.Context, userID, requestID int64) (*PaymentRequest, error)
+	// AcceptRequest pays the request with a transfer from the payer to the requester
+	AcceptRequest(ctx context.Context, userID, requestID int64, req AcceptPaymentRequestRequest) (*PaymentRequest, error)
+	DeclineRequest(ctx context.Context, userID, requestID int64) (*PaymentRequest, error)
+	CancelRequest(ctx context.Context, userID, requestID int64) (*PaymentRequest, error)
+	// ExpireRequests ends up to limit requests whose time has run out and returns how many it ended
+	ExpireRequests(ctx context.Context, limit int) (int, error)
+}
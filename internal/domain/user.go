@@ -1,14 +1,52 @@
 package domain
 
 import (
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strings"
 	"time"
+
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
 )
 
-// User represents a user in the system
+// usernamePattern allows 3 to 50 letters, digits, dots, dashes and underscores
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9._-]{3,50}$`)
+
+// maxEmailLength matches the users.email column
+const maxEmailLength = 255
+
+// User represents a user in the system. Their Tier decides their transaction
+// limits and is set by operators, not through the API.
 type User struct {
 	ID        int64     `json:"id"`
 	Username  string    `json:"username"`
 	Email     string    `json:"email"`
+	Tier      UserTier  `json:"tier"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
-}
\ No newline at end of file
+}
+
+// NormalizeUsername trims the username and checks it is well formed
+func NormalizeUsername(username string) (string, error) {
+	username = strings.TrimSpace(username)
+	if !usernamePattern.MatchString(username) {
+		return "", fmt.Errorf("%w: username must be 3 to 50 letters, digits, '.', '-' or '_'", apperrors.ErrInvalidInput)
+	}
+	return username, nil
+}
+
+// NormalizeEmail trims and lower-cases the email and checks it is a plain address
+func NormalizeEmail(email string) (string, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" || len(email) > maxEmailLength {
+		return "", fmt.Errorf("%w: email must be between 1 and %d characters", apperrors.ErrInvalidInput, maxEmailLength)
+	}
+
+	// Reject display names such as "Bob <bob@example.com>"
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return "", fmt.Errorf("%w: email is not a valid address", apperrors.ErrInvalidInput)
+	}
+	return email, nil
+}
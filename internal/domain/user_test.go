@@ -0,0 +1,62 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeUsername(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		valid    bool
+	}{
+		{input: "alice", expected: "alice", valid: true},
+		{input: "  bob.smith_1 ", expected: "bob.smith_1", valid: true},
+		{input: "ab", valid: false},
+		{input: "has space", valid: false},
+		{input: "emoji🙂", valid: false},
+		{input: "", valid: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.input, func(t *testing.T) {
+			username, err := domain.NormalizeUsername(tc.input)
+			if tc.valid {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, username)
+			} else {
+				assert.ErrorIs(t, err, apperrors.ErrInvalidInput)
+			}
+		})
+	}
+}
+
+func TestNormalizeEmail(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		valid    bool
+	}{
+		{input: "alice@example.com", expected: "alice@example.com", valid: true},
+		{input: " Alice@Example.COM ", expected: "alice@example.com", valid: true},
+		{input: "Alice <alice@example.com>", valid: false},
+		{input: "alice", valid: false},
+		{input: "", valid: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.input, func(t *testing.T) {
+			email, err := domain.NormalizeEmail(tc.input)
+			if tc.valid {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, email)
+			} else {
+				assert.ErrorIs(t, err, apperrors.ErrInvalidInput)
+			}
+		})
+	}
+}
@@ -1,51 +1,173 @@
 package domain
 
 import (
+	"encoding/json"
+	"fmt"
+	"strings"
 	"time"
 	
 	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
 )
 
-// Currency type used for the wallet
-type Currency string
+// Wallet holds user's money and related info. A user has at most one wallet
+// per currency.
+// Balance is the ledger balance, everything the wallet owns. HeldBalance is the
+// part of it reserved by active holds, which can't be spent until the holds are
+// captured, voided or expire.
+type Wallet struct {
+	ID          int64     `json:"id"`
+	UserID      int64     `json:"user_id"`
+	Balance     Amount    `json:"balance"`
+	HeldBalance Amount    `json:"held_balance"`
+	Currency    Currency  `json:"currency"`
+	Label       string    `json:"label,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
 
-const (
-	// USD - US Dollars
-	USD Currency = "USD"
-)
+// MaxWalletLabelLength caps the optional wallet label
+const MaxWalletLabelLength = 100
 
-// Wallet holds user's money and related info
-type Wallet struct {
-	ID        int64     `json:"id"`
-	UserID    int64     `json:"user_id"`
-	Balance   float64   `json:"balance"`
-	Currency  Currency  `json:"currency"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+// WalletSelector picks one of a user's wallets, by ID or by currency.
+// Users with a single wallet may leave both empty.
+type WalletSelector struct {
+	WalletID int64    `json:"wallet_id,omitempty"`
+	Currency Currency `json:"currency,omitempty"`
+}
+
+// Select returns the wallet among a user's wallets that the selector points at
+func (s WalletSelector) Select(wallets []*Wallet) (*Wallet, error) {
+	switch {
+	case s.WalletID != 0:
+		for _, wallet := range wallets {
+			if wallet.ID != s.WalletID {
+				continue
+			}
+			if s.Currency != "" && !strings.EqualFold(string(s.Currency), string(wallet.Currency)) {
+				return nil, fmt.Errorf("%w: wallet %d holds %s, not %s", apperrors.ErrCurrencyMismatch, wallet.ID, wallet.Currency, s.Currency)
+			}
+			return wallet, nil
+		}
+	case s.Currency != "":
+		for _, wallet := range wallets {
+			if strings.EqualFold(string(s.Currency), string(wallet.Currency)) {
+				return wallet, nil
+			}
+		}
+		return nil, fmt.Errorf("%w: no %s wallet", apperrors.ErrWalletNotFound, strings.ToUpper(string(s.Currency)))
+	case len(wallets) == 1:
+		return wallets[0], nil
+	case len(wallets) > 1:
+		return nil, fmt.Errorf("%w: the user has several wallets, name a currency or wallet_id", apperrors.ErrInvalidInput)
+	}
+	return nil, apperrors.ErrWalletNotFound
+}
+
+// AvailableBalance is the money that can be spent right now
+func (w *Wallet) AvailableBalance() Amount {
+	return w.Balance - w.HeldBalance
+}
+
+// MarshalJSON adds the available balance next to the stored fields
+func (w Wallet) MarshalJSON() ([]byte, error) {
+	type wallet Wallet
+	return json.Marshal(struct {
+		wallet
+		AvailableBalance Amount `json:"available_balance"`
+	}{wallet(w), w.AvailableBalance()})
 }
 
 // Deposit money into the wallet
-func (w *Wallet) Deposit(amount float64) error {
+func (w *Wallet) Deposit(amount Amount) error {
 	if amount <= 0 {
 		return apperrors.ErrInvalidAmount
 	}
-	
-	w.Balance += amount
+
+	if err := w.Currency.CheckPrecision(amount); err != nil {
+		return err
+	}
+
+	balance, err := w.Balance.Add(amount)
+	if err != nil {
+		return err
+	}
+
+	w.Balance = balance
 	w.UpdatedAt = time.Now()
 	return nil
 }
 
 // Withdraw money from the wallet
-func (w *Wallet) Withdraw(amount float64) error {
+func (w *Wallet) Withdraw(amount Amount) error {
 	if amount <= 0 {
 		return apperrors.ErrInvalidAmount
 	}
-	
-	if w.Balance < amount {
+
+	if err := w.Currency.CheckPrecision(amount); err != nil {
+		return err
+	}
+
+	if w.AvailableBalance() < amount {
 		return apperrors.ErrInsufficientFunds
 	}
-	
+
 	w.Balance -= amount
 	w.UpdatedAt = time.Now()
 	return nil
-}
\ No newline at end of file
+}
+
+// Overdraw takes money out of the wallet even if that leaves the balance
+// negative. It is only used when an operator explicitly allows a reversal to
+// push the wallet below zero.
+func (w *Wallet) Overdraw(amount Amount) error {
+	if amount <= 0 {
+		return apperrors.ErrInvalidAmount
+	}
+
+	if err := w.Currency.CheckPrecision(amount); err != nil {
+		return err
+	}
+
+	balance, err := w.Balance.Sub(amount)
+	if err != nil {
+		return err
+	}
+
+	w.Balance = balance
+	w.UpdatedAt = time.Now()
+	return nil
+}
+
+// Reserve puts amount on hold. The money stays in the wallet but is no longer available.
+func (w *Wallet) Reserve(amount Amount) error {
+	if amount <= 0 {
+		return apperrors.ErrInvalidAmount
+	}
+
+	if err := w.Currency.CheckPrecision(amount); err != nil {
+		return err
+	}
+
+	if w.AvailableBalance() < amount {
+		return apperrors.ErrInsufficientFunds
+	}
+
+	w.HeldBalance += amount
+	w.UpdatedAt = time.Now()
+	return nil
+}
+
+// Release makes held money available again
+func (w *Wallet) Release(amount Amount) error {
+	if amount <= 0 {
+		return apperrors.ErrInvalidAmount
+	}
+
+	if amount > w.HeldBalance {
+		return fmt.Errorf("%w: releasing %s but only %s is held", apperrors.ErrInvalidAmount, amount, w.HeldBalance)
+	}
+
+	w.HeldBalance -= amount
+	w.UpdatedAt = time.Now()
+	return nil
+}
@@ -1,10 +1,12 @@
 package domain_test
 
 import (
+	"encoding/json"
 	"testing"
 	"time"
 
 	"github.com/ravindu/wallet-app-service/internal/domain"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -12,7 +14,7 @@ func TestWallet_Deposit(t *testing.T) {
 	tests := []struct {
 		name          string
 		wallet        domain.Wallet
-		amount        float64
+		amount        domain.Amount
 		expectedError bool
 	}{
 		{
@@ -20,10 +22,10 @@ func TestWallet_Deposit(t *testing.T) {
 			wallet: domain.Wallet{
 				ID:       1,
 				UserID:   1,
-				Balance:  100.0,
+				Balance:  domain.NewAmount(100),
 				Currency: domain.USD,
 			},
-			amount:        50.0,
+			amount:        domain.NewAmount(50),
 			expectedError: false,
 		},
 		{
@@ -31,10 +33,10 @@ func TestWallet_Deposit(t *testing.T) {
 			wallet: domain.Wallet{
 				ID:       1,
 				UserID:   1,
-				Balance:  100.0,
+				Balance:  domain.NewAmount(100),
 				Currency: domain.USD,
 			},
-			amount:        0.0,
+			amount:        domain.NewAmount(0),
 			expectedError: true,
 		},
 		{
@@ -42,10 +44,21 @@ func TestWallet_Deposit(t *testing.T) {
 			wallet: domain.Wallet{
 				ID:       1,
 				UserID:   1,
-				Balance:  100.0,
+				Balance:  domain.NewAmount(100),
 				Currency: domain.USD,
 			},
-			amount:        -50.0,
+			amount:        domain.NewAmount(-50),
+			expectedError: true,
+		},
+		{
+			name: "more precision than currency allows",
+			wallet: domain.Wallet{
+				ID:       1,
+				UserID:   1,
+				Balance:  domain.NewAmount(100),
+				Currency: domain.USD,
+			},
+			amount:        domain.Amount(5), // 0.0005 USD
 			expectedError: true,
 		},
 	}
@@ -75,7 +88,7 @@ func TestWallet_Withdraw(t *testing.T) {
 	tests := []struct {
 		name          string
 		wallet        domain.Wallet
-		amount        float64
+		amount        domain.Amount
 		expectedError bool
 	}{
 		{
@@ -83,10 +96,10 @@ func TestWallet_Withdraw(t *testing.T) {
 			wallet: domain.Wallet{
 				ID:       1,
 				UserID:   1,
-				Balance:  100.0,
+				Balance:  domain.NewAmount(100),
 				Currency: domain.USD,
 			},
-			amount:        50.0,
+			amount:        domain.NewAmount(50),
 			expectedError: false,
 		},
 		{
@@ -94,10 +107,22 @@ func TestWallet_Withdraw(t *testing.T) {
 			wallet: domain.Wallet{
 				ID:       1,
 				UserID:   1,
-				Balance:  100.0,
+				Balance:  domain.NewAmount(100),
 				Currency: domain.USD,
 			},
-			amount:        150.0,
+			amount:        domain.NewAmount(150),
+			expectedError: true,
+		},
+		{
+			name: "held funds are not available",
+			wallet: domain.Wallet{
+				ID:          1,
+				UserID:      1,
+				Balance:     domain.NewAmount(100),
+				HeldBalance: domain.NewAmount(60),
+				Currency:    domain.USD,
+			},
+			amount:        domain.NewAmount(50),
 			expectedError: true,
 		},
 		{
@@ -105,10 +130,10 @@ func TestWallet_Withdraw(t *testing.T) {
 			wallet: domain.Wallet{
 				ID:       1,
 				UserID:   1,
-				Balance:  100.0,
+				Balance:  domain.NewAmount(100),
 				Currency: domain.USD,
 			},
-			amount:        0.0,
+			amount:        domain.NewAmount(0),
 			expectedError: true,
 		},
 		{
@@ -116,10 +141,10 @@ func TestWallet_Withdraw(t *testing.T) {
 			wallet: domain.Wallet{
 				ID:       1,
 				UserID:   1,
-				Balance:  100.0,
+				Balance:  domain.NewAmount(100),
 				Currency: domain.USD,
 			},
-			amount:        -50.0,
+			amount:        domain.NewAmount(-50),
 			expectedError: true,
 		},
 	}
@@ -143,4 +168,68 @@ func TestWallet_Withdraw(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+func TestWallet_ReserveAndRelease(t *testing.T) {
+	wallet := domain.Wallet{ID: 1, UserID: 1, Balance: domain.NewAmount(100), Currency: domain.USD}
+
+	assert.NoError(t, wallet.Reserve(domain.NewAmount(70)))
+	assert.Equal(t, domain.NewAmount(100), wallet.Balance)
+	assert.Equal(t, domain.NewAmount(30), wallet.AvailableBalance())
+
+	// Only the available part can be reserved again
+	assert.ErrorIs(t, wallet.Reserve(domain.NewAmount(31)), apperrors.ErrInsufficientFunds)
+
+	assert.Error(t, wallet.Release(domain.NewAmount(71)))
+	assert.NoError(t, wallet.Release(domain.NewAmount(70)))
+	assert.Equal(t, domain.NewAmount(100), wallet.AvailableBalance())
+}
+
+func TestWallet_JSON(t *testing.T) {
+	wallet := domain.Wallet{ID: 1, UserID: 1, Balance: domain.NewAmount(100), HeldBalance: domain.NewAmount(25), Currency: domain.USD}
+
+	data, err := json.Marshal(wallet)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"balance":100`)
+	assert.Contains(t, string(data), `"held_balance":25`)
+	assert.Contains(t, string(data), `"available_balance":75`)
+
+	// Cached wallets are read back from the same JSON
+	var decoded domain.Wallet
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, wallet.HeldBalance, decoded.HeldBalance)
+	assert.Equal(t, wallet.Balance, decoded.Balance)
+}
+
+func TestWalletSelector_Select(t *testing.T) {
+	usd := &domain.Wallet{ID: 1, Currency: domain.USD}
+	eur := &domain.Wallet{ID: 2, Currency: domain.EUR}
+
+	tests := []struct {
+		name          string
+		selector      domain.WalletSelector
+		wallets       []*domain.Wallet
+		expected      *domain.Wallet
+		expectedError error
+	}{
+		{name: "by ID", selector: domain.WalletSelector{WalletID: 2}, wallets: []*domain.Wallet{usd, eur}, expected: eur},
+		{name: "by currency in any case", selector: domain.WalletSelector{Currency: "usd"}, wallets: []*domain.Wallet{usd, eur}, expected: usd},
+		{name: "only wallet", wallets: []*domain.Wallet{eur}, expected: eur},
+		{name: "several wallets and no selector", wallets: []*domain.Wallet{usd, eur}, expectedError: apperrors.ErrInvalidInput},
+		{name: "ID and currency disagree", selector: domain.WalletSelector{WalletID: 2, Currency: domain.USD}, wallets: []*domain.Wallet{usd, eur}, expectedError: apperrors.ErrCurrencyMismatch},
+		{name: "ID of another user's wallet", selector: domain.WalletSelector{WalletID: 3}, wallets: []*domain.Wallet{usd, eur}, expectedError: apperrors.ErrWalletNotFound},
+		{name: "no wallet in the currency", selector: domain.WalletSelector{Currency: domain.GBP}, wallets: []*domain.Wallet{usd, eur}, expectedError: apperrors.ErrWalletNotFound},
+		{name: "no wallets", expectedError: apperrors.ErrWalletNotFound},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			wallet, err := tc.selector.Select(tc.wallets)
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Same(t, tc.expected, wallet)
+		})
+	}
+}
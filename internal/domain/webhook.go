@@ -0,0 +1,131 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"strings"
+	"time"
+
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+	"github.com/ravindu/wallet-app-service/pkg/webhook"
+)
+
+// WebhookEventTypes are the events a webhook can subscribe to
+var WebhookEventTypes = []EventType{
+	WalletCredited,
+	WalletDebited,
+	TransferCompleted,
+	PaymentRequestCreatedEvent,
+	PaymentRequestAcceptedEvent,
+	PaymentRequestDeclinedEvent,
+	PaymentRequestCancelledEvent,
+	PaymentRequestExpiredEvent,
+}
+
+// WebhookSubscription is an endpoint a user wants wallet events sent to
+type WebhookSubscription struct {
+	ID         int64       `json:"id"`
+	UserID     int64       `json:"user_id"`
+	URL        string      `json:"url"`
+	Secret     string      `json:"secret,omitempty"`
+	EventTypes []EventType `json:"event_types"`
+	CreatedAt  time.Time   `json:"created_at"`
+	UpdatedAt  time.Time   `json:"updated_at"`
+}
+
+// Validate checks the URL is an absolute https URL and every event type is known.
+// URLs naming localhost or an internal IP address are refused too; hostnames
+// are checked again when each delivery is sent. allowInsecure lifts both rules
+// for local development.
+func (s *WebhookSubscription) Validate(allowInsecure bool) error {
+	parsed, err := url.Parse(s.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http or https URL", apperrors.ErrInvalidInput)
+	}
+
+	if !allowInsecure {
+		if parsed.Scheme != "https" {
+			return fmt.Errorf("%w: url must use https", apperrors.ErrInvalidInput)
+		}
+		if isInternalHost(parsed.Hostname()) {
+			return fmt.Errorf("%w: url must not point at an internal address", apperrors.ErrInvalidInput)
+		}
+	}
+
+	if len(s.EventTypes) == 0 {
+		return fmt.Errorf("%w: at least one event type is required", apperrors.ErrInvalidInput)
+	}
+	for _, eventType := range s.EventTypes {
+		if !isWebhookEventType(eventType) {
+			return fmt.Errorf("%w: unknown event type %q", apperrors.ErrInvalidInput, eventType)
+		}
+	}
+
+	return nil
+}
+
+// isInternalHost reports whether host is localhost or an IP address webhooks
+// may not be sent to
+func isInternalHost(host string) bool {
+	if strings.EqualFold(host, "localhost") || strings.HasSuffix(strings.ToLower(host), ".localhost") {
+		return true
+	}
+	addr, err := netip.ParseAddr(host)
+	return err == nil && !webhook.IsPublicAddress(addr)
+}
+
+// isWebhookEventType reports whether webhooks can subscribe to eventType
+func isWebhookEventType(eventType EventType) bool {
+	for _, known := range WebhookEventTypes {
+		if eventType == known {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDeliveryStatus tracks a delivery through its retries
+type WebhookDeliveryStatus string
+
+const (
+	// DeliveryPending is waiting for its first or next attempt
+	DeliveryPending WebhookDeliveryStatus = "PENDING"
+	// DeliverySucceeded got a 2xx response from the endpoint
+	DeliverySucceeded WebhookDeliveryStatus = "SUCCEEDED"
+	// DeliveryDead ran out of attempts and is only retried by a manual redelivery
+	DeliveryDead WebhookDeliveryStatus = "DEAD"
+)
+
+// WebhookDelivery is one event sent, or to be sent, to one subscription
+type WebhookDelivery struct {
+	ID             int64                 `json:"id"`
+	SubscriptionID int64                 `json:"subscription_id"`
+	EventID        int64                 `json:"event_id"`
+	EventType      EventType             `json:"event_type"`
+	Payload        json.RawMessage       `json:"payload"`
+	Status         WebhookDeliveryStatus `json:"status"`
+	Attempts       int                   `json:"attempts"`
+	LastStatusCode *int                  `json:"last_status_code,omitempty"`
+	LastError      string                `json:"last_error,omitempty"`
+	NextAttemptAt  time.Time             `json:"next_attempt_at"`
+	DeliveredAt    *time.Time            `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time             `json:"created_at"`
+	UpdatedAt      time.Time             `json:"updated_at"`
+}
+
+// WebhookJob is a claimed delivery together with where and how to send it
+type WebhookJob struct {
+	Delivery *WebhookDelivery
+	URL      string
+	Secret   string
+}
+
+// WebhookEnvelope is the JSON body posted to a webhook endpoint
+type WebhookEnvelope struct {
+	ID        int64           `json:"id"`
+	Type      EventType       `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
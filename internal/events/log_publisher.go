@@ -0,0 +1,41 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+)
+
+type logPublisher struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewLogPublisher creates a publisher that writes each event to w as a line of JSON.
+// Pass os.Stdout or an append-only file for local development.
+func NewLogPublisher(w io.Writer) domain.EventPublisher {
+	return &logPublisher{
+		w: w,
+	}
+}
+
+func (p *logPublisher) Publish(ctx context.Context, event *domain.OutboxEvent) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+	line = append(line, '\n')
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, err := p.w.Write(line); err != nil {
+		return fmt.Errorf("failed to write event: %w", err)
+	}
+
+	return nil
+}
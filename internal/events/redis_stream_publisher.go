@@ -0,0 +1,51 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	"github.com/redis/go-redis/v9"
+)
+
+type redisStreamPublisher struct {
+	client *redis.Client
+	stream string
+	maxLen int64
+}
+
+// NewRedisStreamPublisher creates a publisher that appends events to a Redis stream.
+// When maxLen is positive the stream is trimmed to roughly that many entries.
+func NewRedisStreamPublisher(client *redis.Client, stream string, maxLen int64) domain.EventPublisher {
+	return &redisStreamPublisher{
+		client: client,
+		stream: stream,
+		maxLen: maxLen,
+	}
+}
+
+func (p *redisStreamPublisher) Publish(ctx context.Context, event *domain.OutboxEvent) error {
+	args := &redis.XAddArgs{
+		Stream: p.stream,
+		Values: map[string]any{
+			"event_id":       strconv.FormatInt(event.ID, 10),
+			"event_type":     string(event.Type),
+			"aggregate_type": event.AggregateType,
+			"aggregate_id":   strconv.FormatInt(event.AggregateID, 10),
+			"payload":        string(event.Payload),
+			"created_at":     event.CreatedAt.UTC().Format(time.RFC3339Nano),
+		},
+	}
+	if p.maxLen > 0 {
+		args.MaxLen = p.maxLen
+		args.Approx = true
+	}
+
+	if err := p.client.XAdd(ctx, args).Err(); err != nil {
+		return fmt.Errorf("failed to add event to stream %s: %w", p.stream, err)
+	}
+
+	return nil
+}
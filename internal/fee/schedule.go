@@ -0,0 +1,30 @@
+// Package fee loads the platform's fee schedules
+package fee
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+)
+
+// LoadSchedules reads every version of the fee rules from a JSON file such as
+//
+//	[{"version": "2026-01", "effective_from": "2026-01-01T00:00:00Z",
+//	  "rules": [{"transaction_type": "TRANSFER", "bps": 50, "min": "0.25", "max": "10"}]}]
+//
+// Old versions should stay in the file, so the rules behind earlier fees stay on record.
+func LoadSchedules(path string) (domain.FeeSchedules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fee schedule file: %w", err)
+	}
+
+	var schedules []domain.FeeSchedule
+	if err := json.Unmarshal(data, &schedules); err != nil {
+		return nil, fmt.Errorf("failed to parse fee schedule file %s: %w", path, err)
+	}
+
+	return domain.NewFeeSchedules(schedules)
+}
@@ -0,0 +1,56 @@
+package fee_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	"github.com/ravindu/wallet-app-service/internal/fee"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadSchedules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fees.json")
+	require.NoError(t, os.WriteFile(path, []byte(`[
+		{"version": "2026-06", "effective_from": "2026-06-01T00:00:00Z",
+		 "rules": [{"transaction_type": "TRANSFER", "bps": 50, "min": "0.25", "max": 10}]},
+		{"version": "2026-01", "effective_from": "2026-01-01T00:00:00Z",
+		 "rules": [{"transaction_type": "WITHDRAWAL", "currency": "USD", "tiers": [
+		     {"up_to": 100, "flat": "1"}, {"bps": 25}
+		 ]}]}
+	]`), 0o600))
+
+	schedules, err := fee.LoadSchedules(path)
+	require.NoError(t, err)
+	require.Len(t, schedules, 2)
+
+	// Oldest first, whatever the order in the file
+	assert.Equal(t, "2026-01", schedules[0].Version)
+	assert.Len(t, schedules[0].Rules[0].Tiers, 2)
+
+	rule := schedules[1].Rules[0]
+	assert.Equal(t, domain.Transfer, rule.TransactionType)
+	assert.Equal(t, 50, rule.Bps)
+	assert.Equal(t, "0.25", rule.Min.String())
+	assert.Equal(t, "10", rule.Max.String())
+
+	quote, err := schedules.Quote(domain.Transfer, domain.USD, domain.NewAmount(10), time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, "0.25", quote.Fee.String())
+	assert.Equal(t, "2026-06", quote.Version)
+}
+
+func TestLoadSchedules_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fees.json")
+	require.NoError(t, os.WriteFile(path, []byte(`[{"version": "v1", "rules": [{"transaction_type": "TRANSFER", "bps": -5}]}]`), 0o600))
+
+	_, err := fee.LoadSchedules(path)
+	assert.ErrorIs(t, err, apperrors.ErrInvalidInput)
+
+	_, err = fee.LoadSchedules(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
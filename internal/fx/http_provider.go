@@ -0,0 +1,91 @@
+package fx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+)
+
+// maxRateResponseSize caps how much of a rate response is read
+const maxRateResponseSize = 1 << 20
+
+type httpProvider struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewHTTPProvider creates a provider that asks a rates API for each rate with
+// GET {baseURL}/latest?base=USD&symbols=EUR and expects a reply such as
+// {"base": "USD", "rates": {"EUR": 0.92}}. Any server answering that shape,
+// including a local stub, will do.
+func NewHTTPProvider(baseURL string, timeout time.Duration) domain.RateProvider {
+	return &httpProvider{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client: &http.Client{
+			Timeout: timeout,
+		},
+	}
+}
+
+// ratesResponse is the body the rates API answers with
+type ratesResponse struct {
+	Base  domain.Currency                 `json:"base"`
+	Rates map[domain.Currency]json.Number `json:"rates"`
+}
+
+func (p *httpProvider) Name() string {
+	return "http"
+}
+
+func (p *httpProvider) Rate(ctx context.Context, from, to domain.Currency) (domain.Rate, error) {
+	if from == to {
+		return domain.ParseRate("1")
+	}
+
+	query := url.Values{}
+	query.Set("base", string(from))
+	query.Set("symbols", string(to))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+"/latest?"+query.Encode(), nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to build rate request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", apperrors.ErrRateUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("%w: rates API answered %d", apperrors.ErrRateUnavailable, resp.StatusCode)
+	}
+
+	var body ratesResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxRateResponseSize)).Decode(&body); err != nil {
+		return 0, fmt.Errorf("%w: unreadable response: %v", apperrors.ErrRateUnavailable, err)
+	}
+	if body.Base != "" && body.Base != from {
+		return 0, fmt.Errorf("%w: asked for %s rates, got %s", apperrors.ErrRateUnavailable, from, body.Base)
+	}
+
+	value, ok := body.Rates[to]
+	if !ok {
+		return 0, fmt.Errorf("%w: no %s to %s rate", apperrors.ErrRateUnavailable, from, to)
+	}
+
+	rate, err := domain.ParseRate(value.String())
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", apperrors.ErrRateUnavailable, err)
+	}
+	return rate, nil
+}
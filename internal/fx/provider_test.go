@@ -0,0 +1,90 @@
+package fx_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	"github.com/ravindu/wallet-app-service/internal/fx"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStaticProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"USD/EUR": "0.8", "usd/jpy": 150}`), 0o600))
+
+	provider, err := fx.LoadStaticProvider(path)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		from          domain.Currency
+		to            domain.Currency
+		expected      string
+		expectedError error
+	}{
+		{name: "listed pair", from: domain.USD, to: domain.EUR, expected: "0.8"},
+		{name: "lower case pair", from: domain.USD, to: domain.JPY, expected: "150"},
+		{name: "inverse of a listed pair", from: domain.EUR, to: domain.USD, expected: "1.25"},
+		{name: "same currency", from: domain.EUR, to: domain.EUR, expected: "1"},
+		{name: "unknown pair", from: domain.EUR, to: domain.JPY, expectedError: apperrors.ErrRateUnavailable},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rate, err := provider.Rate(context.Background(), tc.from, tc.to)
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, rate.String())
+			}
+		})
+	}
+}
+
+func TestStaticProvider_InvalidPair(t *testing.T) {
+	_, err := fx.NewStaticProvider(map[string]domain.Rate{"USDEUR": 1})
+	assert.ErrorIs(t, err, apperrors.ErrInvalidInput)
+}
+
+func TestHTTPProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		base := r.URL.Query().Get("base")
+		symbol := r.URL.Query().Get("symbols")
+		switch {
+		case r.URL.Path != "/latest":
+			w.WriteHeader(http.StatusNotFound)
+		case base == "USD" && symbol == "EUR":
+			fmt.Fprint(w, `{"base": "USD", "rates": {"EUR": 0.9215}}`)
+		case base == "USD":
+			fmt.Fprint(w, `{"base": "USD", "rates": {}}`)
+		default:
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+
+	provider := fx.NewHTTPProvider(server.URL+"/", time.Second)
+
+	rate, err := provider.Rate(context.Background(), domain.USD, domain.EUR)
+	assert.NoError(t, err)
+	assert.Equal(t, "0.9215", rate.String())
+
+	// The API has no rate for the pair
+	_, err = provider.Rate(context.Background(), domain.USD, domain.JPY)
+	assert.ErrorIs(t, err, apperrors.ErrRateUnavailable)
+
+	// The API is failing
+	_, err = provider.Rate(context.Background(), domain.EUR, domain.USD)
+	assert.ErrorIs(t, err, apperrors.ErrRateUnavailable)
+}
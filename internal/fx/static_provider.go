@@ -0,0 +1,95 @@
+package fx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+)
+
+type staticProvider struct {
+	rates map[string]domain.Rate
+}
+
+// NewStaticProvider creates a provider serving a fixed rate table keyed by
+// "FROM/TO", e.g. "USD/EUR". Missing directions are derived from the inverse
+// rate when the table has it.
+func NewStaticProvider(rates map[string]domain.Rate) (domain.RateProvider, error) {
+	table := make(map[string]domain.Rate, len(rates))
+	for pair, rate := range rates {
+		from, to, err := parsePair(pair)
+		if err != nil {
+			return nil, err
+		}
+		if rate <= 0 {
+			return nil, fmt.Errorf("%w: rate for %s must be positive", apperrors.ErrInvalidInput, pair)
+		}
+		table[pairKey(from, to)] = rate
+	}
+
+	return &staticProvider{
+		rates: table,
+	}, nil
+}
+
+// LoadStaticProvider reads the rate table from a JSON file such as
+// {"USD/EUR": "0.92", "USD/JPY": "151.3"}
+func LoadStaticProvider(path string) (domain.RateProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rate file: %w", err)
+	}
+
+	var rates map[string]domain.Rate
+	if err := json.Unmarshal(data, &rates); err != nil {
+		return nil, fmt.Errorf("failed to parse rate file %s: %w", path, err)
+	}
+
+	return NewStaticProvider(rates)
+}
+
+func (p *staticProvider) Name() string {
+	return "static"
+}
+
+func (p *staticProvider) Rate(ctx context.Context, from, to domain.Currency) (domain.Rate, error) {
+	if from == to {
+		return domain.ParseRate("1")
+	}
+
+	if rate, ok := p.rates[pairKey(from, to)]; ok {
+		return rate, nil
+	}
+	if rate, ok := p.rates[pairKey(to, from)]; ok {
+		return rate.Inverse()
+	}
+
+	return 0, fmt.Errorf("%w: no %s to %s rate", apperrors.ErrRateUnavailable, from, to)
+}
+
+// parsePair reads a "FROM/TO" currency pair
+func parsePair(pair string) (domain.Currency, domain.Currency, error) {
+	fromStr, toStr, ok := strings.Cut(pair, "/")
+	if !ok {
+		return "", "", fmt.Errorf("%w: currency pair %q must look like USD/EUR", apperrors.ErrInvalidInput, pair)
+	}
+
+	from, err := domain.ParseCurrency(fromStr)
+	if err != nil {
+		return "", "", err
+	}
+	to, err := domain.ParseCurrency(toStr)
+	if err != nil {
+		return "", "", err
+	}
+	return from, to, nil
+}
+
+// pairKey is the rate table key of a currency pair
+func pairKey(from, to domain.Currency) string {
+	return string(from) + "/" + string(to)
+}
@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ravindu/wallet-app-service/internal/middleware"
+	"github.com/ravindu/wallet-app-service/pkg/auth"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+)
+
+// isPrivileged reports whether the caller may act on behalf of other users
+func isPrivileged(ctx context.Context) bool {
+	return middleware.HasRole(ctx, auth.RoleAdmin, auth.RoleService)
+}
+
+// authorizeUser checks that the authenticated caller may act on userID's wallet.
+// Users may only act on their own wallet; admin and service callers may act on any.
+func authorizeUser(ctx context.Context, userID int64) error {
+	callerID, ok := middleware.GetUserID(ctx)
+	if !ok {
+		return apperrors.ErrUnauthorized
+	}
+
+	if callerID == userID || isPrivileged(ctx) {
+		return nil
+	}
+
+	return fmt.Errorf("%w: user %d cannot act on the wallet of user %d", apperrors.ErrForbidden, callerID, userID)
+}
+
+// authorizePrivileged checks that the authenticated caller holds an admin or service role
+func authorizePrivileged(ctx context.Context) error {
+	callerID, ok := middleware.GetUserID(ctx)
+	if !ok {
+		return apperrors.ErrUnauthorized
+	}
+
+	if isPrivileged(ctx) {
+		return nil
+	}
+
+	return fmt.Errorf("%w: user %d is not an admin", apperrors.ErrForbidden, callerID)
+}
@@ -0,0 +1,751 @@
+package handler_test
+
+import (
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	"github.com/ravindu/wallet-app-service/internal/handler"
+	"github.com/ravindu/wallet-app-service/internal/middleware"
+	"github.com/ravindu/wallet-app-service/pkg/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// mockWalletUsecase is a mock implementation of domain.WalletUsecase
+type mockWalletUsecase struct {
+	mock.Mock
+}
+
+func (m *mockWalletUsecase) Deposit(ctx context.Context, req domain.DepositRequest) (*domain.Transaction, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(*domain.Transaction), args.Error(1)
+}
+
+func (m *mockWalletUsecase) Withdraw(ctx context.Context, req domain.WithdrawRequest) (*domain.Transaction, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(*domain.Transaction), args.Error(1)
+}
+
+func (m *mockWalletUsecase) Convert(ctx context.Context, req domain.ConvertRequest) (*domain.ConversionResponse, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(*domain.ConversionResponse), args.Error(1)
+}
+
+func (m *mockWalletUsecase) Transfer(ctx context.Context, req domain.TransferRequest) (*domain.Transaction, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(*domain.Transaction), args.Error(1)
+}
+
+func (m *mockWalletUsecase) Reverse(ctx context.Context, req domain.ReversalRequest) (*domain.ReversalResponse, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(*domain.ReversalResponse), args.Error(1)
+}
+
+func (m *mockWalletUsecase) Refund(ctx context.Context, req domain.ReversalRequest) (*domain.ReversalResponse, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(*domain.ReversalResponse), args.Error(1)
+}
+
+func (m *mockWalletUsecase) GetBalance(ctx context.Context, userID int64, selector domain.WalletSelector) (*domain.Wallet, error) {
+	args := m.Called(ctx, userID, selector)
+	return args.Get(0).(*domain.Wallet), args.Error(1)
+}
+
+func (m *mockWalletUsecase) GetTransactionHistory(
+	ctx context.Context,
+	userID int64,
+	selector domain.WalletSelector,
+	filter domain.TransactionFilter,
+	pagination domain.PaginationRequest,
+) (*domain.TransactionHistoryResponse, error) {
+	args := m.Called(ctx, userID, selector, filter, pagination)
+	return args.Get(0).(*domain.TransactionHistoryResponse), args.Error(1)
+}
+
+// withCaller puts an authenticated caller into the request context the way AuthMiddleware does
+// mockBalanceUsecase is a mock implementation of domain.BalanceUsecase
+type mockBalanceUsecase struct {
+	mock.Mock
+}
+
+func (m *mockBalanceUsecase) GetBalanceAt(ctx context.Context, userID int64, selector domain.WalletSelector, at time.Time) (*domain.HistoricalBalance, error) {
+	args := m.Called(ctx, userID, selector, at)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.HistoricalBalance), args.Error(1)
+}
+
+func (m *mockBalanceUsecase) SnapshotBalances(ctx context.Context, asOf time.Time, batchSize int) (int, error) {
+	args := m.Called(ctx, asOf, batchSize)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *mockBalanceUsecase) LatestSnapshot(ctx context.Context) (time.Time, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(time.Time), args.Error(1)
+}
+
+// mockStatementUsecase is a mock implementation of domain.StatementUsecase
+type mockStatementUsecase struct {
+	mock.Mock
+}
+
+func (m *mockStatementUsecase) WriteStatement(ctx context.Context, req domain.StatementRequest, w domain.StatementWriter) error {
+	args := m.Called(ctx, req, w)
+	return args.Error(0)
+}
+
+func withCaller(r *http.Request, userID int64, roles ...string) *http.Request {
+	ctx := context.WithValue(r.Context(), middleware.UserIDKey, userID)
+	ctx = context.WithValue(ctx, middleware.RolesKey, roles)
+	return r.WithContext(ctx)
+}
+
+func newRouter(walletUsecase domain.WalletUsecase) http.Handler {
+	return newRouterWithBalances(walletUsecase, new(mockBalanceUsecase))
+}
+
+func newRouterWithBalances(walletUsecase domain.WalletUsecase, balanceUsecase domain.BalanceUsecase) http.Handler {
+	walletHandler := handler.NewWalletHandler(walletUsecase, balanceUsecase)
+
+	r := chi.NewRouter()
+	r.Post("/deposit", walletHandler.DepositHandler)
+	r.Post("/withdraw", walletHandler.WithdrawHandler)
+	r.Post("/transfer", walletHandler.TransferHandler)
+	r.Post("/convert", walletHandler.ConvertHandler)
+	r.Get("/balance/{userID}", walletHandler.GetBalanceHandler)
+	r.Get("/transactions/{userID}", walletHandler.GetTransactionHistoryHandler)
+	r.Post("/transactions/{id}/reverse", walletHandler.ReverseTransactionHandler)
+	r.Post("/transactions/{id}/refund", walletHandler.RefundTransactionHandler)
+	return r
+}
+
+func TestWalletHandler_Ownership(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		callerID       int64
+		roles          []string
+		anonymous      bool
+		setupMock      func(*mockWalletUsecase)
+		expectedStatus int
+	}{
+		{
+			name:     "deposit into own wallet",
+			method:   http.MethodPost,
+			path:     "/deposit",
+			body:     `{"user_id": 1, "amount": 10}`,
+			callerID: 1,
+			setupMock: func(m *mockWalletUsecase) {
+				m.On("Deposit", mock.Anything, mock.Anything).Return(&domain.Transaction{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "deposit into another user's wallet",
+			method:         http.MethodPost,
+			path:           "/deposit",
+			body:           `{"user_id": 2, "amount": 10}`,
+			callerID:       1,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "withdraw from another user's wallet",
+			method:         http.MethodPost,
+			path:           "/withdraw",
+			body:           `{"user_id": 2, "amount": 10}`,
+			callerID:       1,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:     "transfer from own wallet",
+			method:   http.MethodPost,
+			path:     "/transfer",
+			body:     `{"sender_id": 1, "receiver_id": 2, "amount": 10}`,
+			callerID: 1,
+			setupMock: func(m *mockWalletUsecase) {
+				m.On("Transfer", mock.Anything, mock.Anything).Return(&domain.Transaction{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "transfer on behalf of another user",
+			method:         http.MethodPost,
+			path:           "/transfer",
+			body:           `{"sender_id": 2, "receiver_id": 1, "amount": 10}`,
+			callerID:       1,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:     "service transfers on behalf of a user",
+			method:   http.MethodPost,
+			path:     "/transfer",
+			body:     `{"sender_id": 2, "receiver_id": 1, "amount": 10}`,
+			callerID: 99,
+			roles:    []string{auth.RoleService},
+			setupMock: func(m *mockWalletUsecase) {
+				m.On("Transfer", mock.Anything, mock.Anything).Return(&domain.Transaction{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:     "convert in own wallets",
+			method:   http.MethodPost,
+			path:     "/convert",
+			body:     `{"user_id": 1, "from": {"currency": "USD"}, "to": {"currency": "EUR"}, "amount": 10}`,
+			callerID: 1,
+			setupMock: func(m *mockWalletUsecase) {
+				m.On("Convert", mock.Anything, mock.Anything).Return(&domain.ConversionResponse{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "convert in another user's wallets",
+			method:         http.MethodPost,
+			path:           "/convert",
+			body:           `{"user_id": 2, "from": {"currency": "USD"}, "to": {"currency": "EUR"}, "amount": 10}`,
+			callerID:       1,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:     "read own balance",
+			method:   http.MethodGet,
+			path:     "/balance/1",
+			callerID: 1,
+			setupMock: func(m *mockWalletUsecase) {
+				m.On("GetBalance", mock.Anything, int64(1), domain.WalletSelector{}).Return(&domain.Wallet{UserID: 1}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "read another user's balance",
+			method:         http.MethodGet,
+			path:           "/balance/2",
+			callerID:       1,
+			roles:          []string{"support"},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:     "admin reads another user's balance",
+			method:   http.MethodGet,
+			path:     "/balance/2",
+			callerID: 1,
+			roles:    []string{auth.RoleAdmin},
+			setupMock: func(m *mockWalletUsecase) {
+				m.On("GetBalance", mock.Anything, int64(2), domain.WalletSelector{}).Return(&domain.Wallet{UserID: 2}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:     "read the balance of one currency",
+			method:   http.MethodGet,
+			path:     "/balance/1?currency=eur",
+			callerID: 1,
+			setupMock: func(m *mockWalletUsecase) {
+				m.On("GetBalance", mock.Anything, int64(1), domain.WalletSelector{Currency: domain.EUR}).Return(&domain.Wallet{UserID: 1}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "read the balance of an unknown currency",
+			method:         http.MethodGet,
+			path:           "/balance/1?currency=ABC",
+			callerID:       1,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "read another user's transactions",
+			method:         http.MethodGet,
+			path:           "/transactions/2",
+			callerID:       1,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "user reverses a transaction",
+			method:         http.MethodPost,
+			path:           "/transactions/5/reverse",
+			callerID:       1,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:     "admin reverses a transaction",
+			method:   http.MethodPost,
+			path:     "/transactions/5/reverse",
+			callerID: 1,
+			roles:    []string{auth.RoleAdmin},
+			setupMock: func(m *mockWalletUsecase) {
+				m.On("Reverse", mock.Anything, domain.ReversalRequest{TransactionID: 5}).Return(&domain.ReversalResponse{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "admin refunds without an amount",
+			method:         http.MethodPost,
+			path:           "/transactions/5/refund",
+			body:           `{}`,
+			callerID:       1,
+			roles:          []string{auth.RoleAdmin},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "no caller identity",
+			method:         http.MethodGet,
+			path:           "/balance/1",
+			anonymous:      true,
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			walletUsecase := new(mockWalletUsecase)
+			if tc.setupMock != nil {
+				tc.setupMock(walletUsecase)
+			}
+
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			if !tc.anonymous {
+				req = withCaller(req, tc.callerID, tc.roles...)
+			}
+			rec := httptest.NewRecorder()
+
+			newRouter(walletUsecase).ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			walletUsecase.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetTransactionHistoryHandler_Pagination(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected domain.PaginationRequest
+	}{
+		{
+			name:     "offset paging counts the total",
+			query:    "?limit=5&offset=10",
+			expected: domain.PaginationRequest{Limit: 5, Offset: 10, IncludeTotal: true},
+		},
+		{
+			name:     "cursor paging skips the total",
+			query:    "?limit=5&cursor=abc",
+			expected: domain.PaginationRequest{Limit: 5, Cursor: "abc"},
+		},
+		{
+			name:     "total on request",
+			query:    "?cursor=abc&include_total=true",
+			expected: domain.PaginationRequest{Limit: 10, Cursor: "abc", IncludeTotal: true},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			walletUsecase := new(mockWalletUsecase)
+			walletUsecase.On("GetTransactionHistory", mock.Anything, int64(1), domain.WalletSelector{}, domain.TransactionFilter{Sort: domain.SortNewestFirst}, tc.expected).
+				Return(&domain.TransactionHistoryResponse{NextCursor: "older", PrevCursor: "newer"}, nil)
+
+			req := withCaller(httptest.NewRequest(http.MethodGet, "/transactions/1"+tc.query, nil), 1)
+			rec := httptest.NewRecorder()
+
+			newRouter(walletUsecase).ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			walletUsecase.AssertExpectations(t)
+
+			// Links keep the other parameters and swap any offset for the cursor
+			body := rec.Body.String()
+			assert.Contains(t, body, `cursor=older`)
+			assert.Contains(t, body, `cursor=newer`)
+			assert.NotContains(t, body, `offset=`)
+		})
+	}
+}
+
+func TestGetTransactionHistoryHandler_Filter(t *testing.T) {
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	minAmount := domain.NewAmount(10)
+	maxAmount := domain.Amount(995000)
+	counterpartyID := int64(7)
+
+	tests := []struct {
+		name           string
+		query          string
+		expected       domain.TransactionFilter
+		expectedStatus int
+	}{
+		{
+			name:  "every filter",
+			query: "?type=deposit,TRANSFER_IN&type=refund&from=2024-05-01&to=2024-05-31&min_amount=10&max_amount=99.5&counterparty_wallet_id=7&search=+rent+&sort=ASC",
+			expected: domain.TransactionFilter{
+				Types:                []domain.TransactionType{domain.Deposit, domain.TransferIn, domain.Refund},
+				From:                 &from,
+				To:                   &to,
+				MinAmount:            &minAmount,
+				MaxAmount:            &maxAmount,
+				CounterpartyWalletID: &counterpartyID,
+				Search:               "rent",
+				Sort:                 domain.SortOldestFirst,
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "RFC 3339 bounds are kept as given",
+			query:          "?from=2024-05-01T00:00:00Z&to=2024-06-01T00:00:00Z",
+			expected:       domain.TransactionFilter{From: &from, To: &to, Sort: domain.SortNewestFirst},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "unknown type",
+			query:          "?type=GIFT",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "malformed date",
+			query:          "?from=yesterday",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "malformed amount",
+			query:          "?min_amount=ten",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "unknown sort",
+			query:          "?sort=amount",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			walletUsecase := new(mockWalletUsecase)
+			if tc.expectedStatus == http.StatusOK {
+				walletUsecase.On("GetTransactionHistory", mock.Anything, int64(1), domain.WalletSelector{}, tc.expected, mock.Anything).
+					Return(&domain.TransactionHistoryResponse{}, nil)
+			}
+
+			req := withCaller(httptest.NewRequest(http.MethodGet, "/transactions/1"+tc.query, nil), 1)
+			rec := httptest.NewRecorder()
+
+			newRouter(walletUsecase).ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			walletUsecase.AssertExpectations(t)
+		})
+	}
+}
+
+func TestExportStatementHandler(t *testing.T) {
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	wallet := &domain.Wallet{ID: 7, UserID: 1, Currency: domain.USD}
+
+	tests := []struct {
+		name                string
+		query               string
+		callerID            int64
+		expected            domain.StatementRequest
+		expectedStatus      int
+		expectedContentType string
+		expectedFileName    string
+	}{
+		{
+			name:                "csv by default",
+			query:               "?from=2024-05-01&to=2024-05-31",
+			callerID:            1,
+			expected:            domain.StatementRequest{UserID: 1, From: from, To: to},
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "text/csv; charset=utf-8",
+			expectedFileName:    "statement-7-20240501-20240601.csv",
+		},
+		{
+			name:                "pdf for a chosen wallet",
+			query:               "?from=2024-05-01&to=2024-05-31&format=pdf&currency=USD",
+			callerID:            1,
+			expected:            domain.StatementRequest{UserID: 1, Selector: domain.WalletSelector{Currency: domain.USD}, From: from, To: to},
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/pdf",
+			expectedFileName:    "statement-7-20240501-20240601.pdf",
+		},
+		{
+			name:           "someone else's wallet",
+			query:          "?from=2024-05-01&to=2024-05-31",
+			callerID:       2,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "missing from",
+			query:          "?to=2024-05-31",
+			callerID:       1,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "unknown format",
+			query:          "?from=2024-05-01&format=xlsx",
+			callerID:       1,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			statementUsecase := new(mockStatementUsecase)
+			if tc.expectedStatus == http.StatusOK {
+				statementUsecase.On("WriteStatement", mock.Anything, tc.expected, mock.Anything).
+					Run(func(args mock.Arguments) {
+						w := args.Get(2).(domain.StatementWriter)
+						_ = w.Begin(domain.StatementHeader{Wallet: wallet, From: from, To: to})
+						_ = w.End(0)
+					}).
+					Return(nil)
+			}
+
+			r := chi.NewRouter()
+			r.Get("/statements/{userID}", handler.NewStatementHandler(statementUsecase).ExportStatementHandler)
+
+			req := withCaller(httptest.NewRequest(http.MethodGet, "/statements/1"+tc.query, nil), tc.callerID)
+			rec := httptest.NewRecorder()
+
+			r.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			if tc.expectedStatus == http.StatusOK {
+				assert.Equal(t, tc.expectedContentType, rec.Header().Get("Content-Type"))
+				assert.Equal(t, `attachment; filename="`+tc.expectedFileName+`"`, rec.Header().Get("Content-Disposition"))
+				assert.NotEmpty(t, rec.Body.String())
+			}
+			statementUsecase.AssertExpectations(t)
+		})
+	}
+}
+
+func TestExportStatementHandler_OutlastsWriteTimeout(t *testing.T) {
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	wallet := &domain.Wallet{ID: 7, UserID: 1, Currency: domain.USD}
+
+	// The statement is still being written well after the server's write
+	// timeout, and must still arrive whole
+	statementUsecase := new(mockStatementUsecase)
+	statementUsecase.On("WriteStatement", mock.Anything, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			w := args.Get(2).(domain.StatementWriter)
+			_ = w.Begin(domain.StatementHeader{Wallet: wallet, From: from, To: to})
+			_ = w.WriteTransaction(&domain.Transaction{ID: 1, Type: domain.Deposit, Amount: domain.NewAmount(10), TransactionTime: from})
+			time.Sleep(300 * time.Millisecond)
+			_ = w.WriteTransaction(&domain.Transaction{ID: 2, Type: domain.Deposit, Amount: domain.NewAmount(5), Description: "late", TransactionTime: from})
+			_ = w.End(domain.NewAmount(15))
+		}).
+		Return(nil)
+
+	r := chi.NewRouter()
+	r.Get("/statements/{userID}", handler.NewStatementHandler(statementUsecase).ExportStatementHandler)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.ServeHTTP(w, withCaller(req, 1))
+	}))
+	server.Config.WriteTimeout = 100 * time.Millisecond
+	server.Start()
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/statements/1?from=2024-05-01&to=2024-05-31")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(body), "late")
+	assert.Contains(t, string(body), "CLOSING_BALANCE")
+	statementUsecase.AssertExpectations(t)
+}
+
+func TestGetBalanceHandler_At(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		callerID       int64
+		expectedAt     time.Time
+		expectedStatus int
+	}{
+		{
+			name:           "date means the end of that day",
+			query:          "?at=2024-05-31",
+			callerID:       1,
+			expectedAt:     time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "exact time",
+			query:          "?at=2024-05-31T12:30:00Z",
+			callerID:       1,
+			expectedAt:     time.Date(2024, 5, 31, 12, 30, 0, 0, time.UTC),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "malformed time",
+			query:          "?at=yesterday",
+			callerID:       1,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "someone else's wallet",
+			query:          "?at=2024-05-31",
+			callerID:       2,
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			walletUsecase := new(mockWalletUsecase)
+			balanceUsecase := new(mockBalanceUsecase)
+			if tc.expectedStatus == http.StatusOK {
+				balanceUsecase.On("GetBalanceAt", mock.Anything, int64(1), domain.WalletSelector{}, tc.expectedAt).
+					Return(&domain.HistoricalBalance{WalletID: 7, UserID: 1, At: tc.expectedAt}, nil)
+			}
+
+			req := withCaller(httptest.NewRequest(http.MethodGet, "/balance/1"+tc.query, nil), tc.callerID)
+			rec := httptest.NewRecorder()
+
+			newRouterWithBalances(walletUsecase, balanceUsecase).ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			balanceUsecase.AssertExpectations(t)
+			walletUsecase.AssertNotCalled(t, "GetBalance", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+// mockTransferBatchUsecase is a mock implementation of domain.TransferBatchUsecase
+type mockTransferBatchUsecase struct {
+	mock.Mock
+}
+
+func (m *mockTransferBatchUsecase) CreateBatch(ctx context.Context, userID int64, req domain.CreateTransferBatchRequest) (*domain.TransferBatch, error) {
+	args := m.Called(ctx, userID, req)
+	return args.Get(0).(*domain.TransferBatch), args.Error(1)
+}
+
+func (m *mockTransferBatchUsecase) GetBatch(ctx context.Context, userID, batchID int64) (*domain.TransferBatch, error) {
+	args := m.Called(ctx, userID, batchID)
+	return args.Get(0).(*domain.TransferBatch), args.Error(1)
+}
+
+func (m *mockTransferBatchUsecase) ListItems(
+	ctx context.Context,
+	userID, batchID int64,
+	status domain.BatchItemStatus,
+	pagination domain.PaginationRequest,
+) (*domain.TransferBatchItemsResponse, error) {
+	args := m.Called(ctx, userID, batchID, status, pagination)
+	return args.Get(0).(*domain.TransferBatchItemsResponse), args.Error(1)
+}
+
+func (m *mockTransferBatchUsecase) ProcessBatches(ctx context.Context, limit int) (int, error) {
+	args := m.Called(ctx, limit)
+	return args.Int(0), args.Error(1)
+}
+
+func TestCreateBatchHandler(t *testing.T) {
+	items := []domain.TransferBatchItemRequest{
+		{ReceiverID: 2, Amount: domain.NewAmount(10), Comment: "March payout"},
+		{ReceiverID: 3, ReceiverWalletID: 30, Amount: domain.Amount(25000)},
+	}
+
+	multipartBody := func(csv string) (string, string) {
+		var body strings.Builder
+		writer := multipart.NewWriter(&body)
+		_ = writer.WriteField("mode", "best_effort")
+		part, _ := writer.CreateFormFile("file", "payouts.csv")
+		_, _ = part.Write([]byte(csv))
+		_ = writer.Close()
+		return body.String(), writer.FormDataContentType()
+	}
+	uploadBody, uploadType := multipartBody("receiver_id,amount,comment\n2,10,March payout\n")
+
+	tests := []struct {
+		name           string
+		url            string
+		contentType    string
+		body           string
+		expected       domain.CreateTransferBatchRequest
+		expectedStatus int
+	}{
+		{
+			name:           "json",
+			url:            "/transfers/batch",
+			contentType:    "application/json",
+			body:           `{"mode":"BEST_EFFORT","items":[{"receiver_id":2,"amount":10,"comment":"March payout"},{"receiver_id":3,"receiver_wallet_id":30,"amount":2.5}]}`,
+			expected:       domain.CreateTransferBatchRequest{Mode: domain.BatchBestEffort, Items: items},
+			expectedStatus: http.StatusAccepted,
+		},
+		{
+			name:           "csv body with options in the query",
+			url:            "/transfers/batch?currency=usd",
+			contentType:    "text/csv",
+			body:           "Receiver_ID,amount,receiver_wallet_id,comment\n2,10.00,,March payout\n3, 2.5,30,\n",
+			expected:       domain.CreateTransferBatchRequest{Currency: domain.USD, Items: items},
+			expectedStatus: http.StatusAccepted,
+		},
+		{
+			name:           "csv upload",
+			url:            "/transfers/batch",
+			contentType:    uploadType,
+			body:           uploadBody,
+			expected:       domain.CreateTransferBatchRequest{Mode: domain.BatchBestEffort, Items: items[:1]},
+			expectedStatus: http.StatusAccepted,
+		},
+		{
+			name:           "csv without an amount column",
+			url:            "/transfers/batch",
+			contentType:    "text/csv",
+			body:           "receiver_id,comment\n2,March payout\n",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "csv with a bad amount",
+			url:            "/transfers/batch",
+			contentType:    "text/csv",
+			body:           "receiver_id,amount\n2,ten\n",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			batchUsecase := new(mockTransferBatchUsecase)
+			if tc.expectedStatus == http.StatusAccepted {
+				batchUsecase.On("CreateBatch", mock.Anything, int64(1), tc.expected).Return(&domain.TransferBatch{ID: 7}, nil)
+			}
+
+			r := chi.NewRouter()
+			r.Post("/transfers/batch", handler.NewTransferBatchHandler(batchUsecase).CreateBatchHandler)
+
+			req := withCaller(httptest.NewRequest(http.MethodPost, tc.url, strings.NewReader(tc.body)), 1)
+			req.Header.Set("Content-Type", tc.contentType)
+			rec := httptest.NewRecorder()
+
+			r.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.expectedStatus, rec.Code, rec.Body.String())
+			batchUsecase.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+	"github.com/ravindu/wallet-app-service/pkg/logging"
+	"github.com/ravindu/wallet-app-service/pkg/response"
+)
+
+type FeeHandler struct {
+	feeUsecase domain.FeeUsecase
+	logger     *logging.Logger
+}
+
+// NewFeeHandler creates a new fee handler
+func NewFeeHandler(feeUsecase domain.FeeUsecase) *FeeHandler {
+	return &FeeHandler{
+		feeUsecase: feeUsecase,
+		logger:     logging.NewLogger(),
+	}
+}
+
+// QuoteHandler prices the fee a transaction would be charged if it were made now,
+// from the transaction_type, currency and amount query parameters
+func (h *FeeHandler) QuoteHandler(w http.ResponseWriter, r *http.Request) {
+	requestID := getRequestID(r)
+	ctx := r.Context()
+
+	h.logger.Info(ctx, "Processing fee quote request")
+
+	req, err := parseFeeQuoteRequest(r)
+	if err != nil {
+		h.logger.Error(ctx, "Invalid fee quote parameters: "+err.Error())
+		errResp := apperrors.MapErrorToResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	quote, err := h.feeUsecase.Quote(ctx, req)
+	if err != nil {
+		h.logger.Error(ctx, "Fee quote failed: "+err.Error())
+		errResp := apperrors.MapErrorToResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	h.logger.Info(ctx, "Fee quote successful")
+	response.JSON(w, requestID, quote, http.StatusOK)
+}
+
+// parseFeeQuoteRequest reads a fee quote request from the query string
+func parseFeeQuoteRequest(r *http.Request) (domain.FeeQuoteRequest, error) {
+	var req domain.FeeQuoteRequest
+	query := r.URL.Query()
+
+	transactionType := query.Get("transaction_type")
+	if transactionType == "" {
+		return req, fmt.Errorf("%w: transaction_type is required", apperrors.ErrInvalidInput)
+	}
+	req.TransactionType = domain.TransactionType(strings.ToUpper(transactionType))
+
+	currency, err := domain.ParseCurrency(query.Get("currency"))
+	if err != nil {
+		return req, err
+	}
+	req.Currency = currency
+
+	if req.Amount, err = domain.ParseAmount(query.Get("amount")); err != nil {
+		return req, err
+	}
+
+	return req, nil
+}
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+	"github.com/ravindu/wallet-app-service/pkg/logging"
+	"github.com/ravindu/wallet-app-service/pkg/response"
+)
+
+type FXHandler struct {
+	fxUsecase domain.FXUsecase
+	logger    *logging.Logger
+}
+
+// NewFXHandler creates a new fx handler. fxUsecase may be nil when no rate
+// provider is configured, in which case quotes are refused.
+func NewFXHandler(fxUsecase domain.FXUsecase) *FXHandler {
+	return &FXHandler{
+		fxUsecase: fxUsecase,
+		logger:    logging.NewLogger(),
+	}
+}
+
+// CreateQuoteHandler handles requests to lock in an exchange rate
+func (h *FXHandler) CreateQuoteHandler(w http.ResponseWriter, r *http.Request) {
+	requestID := getRequestID(r)
+	ctx := r.Context()
+
+	h.logger.Info(ctx, "Processing create quote request")
+
+	var req domain.CreateQuoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Error(ctx, "Failed to decode create quote request: "+err.Error())
+		errResp := decodeErrorResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	// Quotes are only spent by their owner, so callers may only quote for themselves
+	if err := authorizeUser(ctx, req.UserID); err != nil {
+		h.logger.Error(ctx, "Create quote rejected: "+err.Error())
+		errResp := apperrors.MapErrorToResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	if h.fxUsecase == nil {
+		err := fmt.Errorf("%w: currency conversion is not configured", apperrors.ErrRateUnavailable)
+		h.logger.Error(ctx, "Create quote failed: "+err.Error())
+		errResp := apperrors.MapErrorToResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	quote, err := h.fxUsecase.CreateQuote(ctx, req)
+	if err != nil {
+		h.logger.Error(ctx, "Create quote failed: "+err.Error())
+		errResp := apperrors.MapErrorToResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	h.logger.Info(ctx, "Create quote successful")
+	response.JSON(w, requestID, quote, http.StatusCreated)
+}
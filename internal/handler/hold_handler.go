@@ -0,0 +1,172 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+	"github.com/ravindu/wallet-app-service/pkg/logging"
+	"github.com/ravindu/wallet-app-service/pkg/response"
+)
+
+type HoldHandler struct {
+	holdUsecase domain.HoldUsecase
+	logger      *logging.Logger
+}
+
+// NewHoldHandler creates a new hold handler
+func NewHoldHandler(holdUsecase domain.HoldUsecase) *HoldHandler {
+	return &HoldHandler{
+		holdUsecase: holdUsecase,
+		logger:      logging.NewLogger(),
+	}
+}
+
+// PlaceHoldHandler handles requests to reserve wallet funds
+func (h *HoldHandler) PlaceHoldHandler(w http.ResponseWriter, r *http.Request) {
+	requestID := getRequestID(r)
+	ctx := r.Context()
+
+	h.logger.Info(ctx, "Processing place hold request")
+
+	var req domain.PlaceHoldRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Error(ctx, "Failed to decode place hold request: "+err.Error())
+		errResp := decodeErrorResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	// Callers may only reserve money in their own wallet
+	if err := authorizeUser(ctx, req.UserID); err != nil {
+		h.logger.Error(ctx, "Place hold rejected: "+err.Error())
+		errResp := apperrors.MapErrorToResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	if req.Amount <= 0 {
+		h.logger.Error(ctx, "Invalid hold amount: "+req.Amount.String())
+		errResp := apperrors.BadRequestError(requestID, "Amount must be positive")
+		response.Error(w, errResp)
+		return
+	}
+
+	hold, err := h.holdUsecase.PlaceHold(ctx, req)
+	if err != nil {
+		h.logger.Error(ctx, "Place hold failed: "+err.Error())
+		errResp := apperrors.MapErrorToResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	h.logger.Info(ctx, "Place hold successful")
+	response.JSON(w, requestID, hold, http.StatusCreated)
+}
+
+// GetHoldHandler handles hold lookups
+func (h *HoldHandler) GetHoldHandler(w http.ResponseWriter, r *http.Request) {
+	requestID := getRequestID(r)
+	ctx := r.Context()
+
+	h.logger.Info(ctx, "Processing get hold request")
+
+	hold, ok := h.ownedHold(w, r)
+	if !ok {
+		return
+	}
+
+	h.logger.Info(ctx, "Get hold request successful")
+	response.JSON(w, requestID, hold, http.StatusOK)
+}
+
+// CaptureHoldHandler handles requests to settle a hold
+func (h *HoldHandler) CaptureHoldHandler(w http.ResponseWriter, r *http.Request) {
+	requestID := getRequestID(r)
+	ctx := r.Context()
+
+	h.logger.Info(ctx, "Processing capture hold request")
+
+	hold, ok := h.ownedHold(w, r)
+	if !ok {
+		return
+	}
+
+	// The body is optional, an empty one captures the whole hold
+	var req domain.CaptureHoldRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		h.logger.Error(ctx, "Failed to decode capture hold request: "+err.Error())
+		errResp := decodeErrorResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	captured, err := h.holdUsecase.CaptureHold(ctx, hold.ID, req)
+	if err != nil {
+		h.logger.Error(ctx, "Capture hold failed: "+err.Error())
+		errResp := apperrors.MapErrorToResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	h.logger.Info(ctx, "Capture hold successful")
+	response.JSON(w, requestID, captured, http.StatusOK)
+}
+
+// VoidHoldHandler handles requests to cancel a hold
+func (h *HoldHandler) VoidHoldHandler(w http.ResponseWriter, r *http.Request) {
+	requestID := getRequestID(r)
+	ctx := r.Context()
+
+	h.logger.Info(ctx, "Processing void hold request")
+
+	hold, ok := h.ownedHold(w, r)
+	if !ok {
+		return
+	}
+
+	voided, err := h.holdUsecase.VoidHold(ctx, hold.ID)
+	if err != nil {
+		h.logger.Error(ctx, "Void hold failed: "+err.Error())
+		errResp := apperrors.MapErrorToResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	h.logger.Info(ctx, "Void hold successful")
+	response.JSON(w, requestID, voided, http.StatusOK)
+}
+
+// ownedHold loads the hold in the path and checks the caller may act on it,
+// writing the error response when not
+func (h *HoldHandler) ownedHold(w http.ResponseWriter, r *http.Request) (*domain.Hold, bool) {
+	requestID := getRequestID(r)
+	ctx := r.Context()
+
+	holdIDStr := chi.URLParam(r, "id")
+	holdID, err := strconv.ParseInt(holdIDStr, 10, 64)
+	if err != nil {
+		h.logger.Error(ctx, "Invalid hold ID format: "+holdIDStr)
+		errResp := apperrors.BadRequestError(requestID, "Hold ID must be a valid number")
+		response.Error(w, errResp)
+		return nil, false
+	}
+
+	hold, err := h.holdUsecase.GetHold(ctx, holdID)
+	if err == nil {
+		err = authorizeUser(ctx, hold.UserID)
+	}
+	if err != nil {
+		h.logger.Error(ctx, "Hold request rejected: "+err.Error())
+		errResp := apperrors.MapErrorToResponse(requestID, err)
+		response.Error(w, errResp)
+		return nil, false
+	}
+
+	return hold, true
+}
@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+	"github.com/ravindu/wallet-app-service/pkg/logging"
+	"github.com/ravindu/wallet-app-service/pkg/response"
+)
+
+type LedgerHandler struct {
+	ledgerUsecase domain.LedgerUsecase
+	logger        *logging.Logger
+}
+
+// NewLedgerHandler creates a new ledger handler
+func NewLedgerHandler(ledgerUsecase domain.LedgerUsecase) *LedgerHandler {
+	return &LedgerHandler{
+		ledgerUsecase: ledgerUsecase,
+		logger:        logging.NewLogger(),
+	}
+}
+
+// GetTrialBalanceHandler handles trial balance requests
+func (h *LedgerHandler) GetTrialBalanceHandler(w http.ResponseWriter, r *http.Request) {
+	requestID := getRequestID(r)
+	ctx := r.Context()
+
+	h.logger.Info(ctx, "Processing trial balance request")
+
+	// The trial balance covers every wallet, so only operators may read it
+	if err := authorizePrivileged(ctx); err != nil {
+		h.logger.Error(ctx, "Trial balance request rejected: "+err.Error())
+		errResp := apperrors.MapErrorToResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	trialBalance, err := h.ledgerUsecase.GetTrialBalance(ctx)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get trial balance: "+err.Error())
+		errResp := apperrors.MapErrorToResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	if !trialBalance.Balanced {
+		h.logger.Warn(ctx, "Trial balance does not sum to zero")
+	}
+
+	h.logger.Info(ctx, "Trial balance request successful")
+	response.JSON(w, requestID, trialBalance, http.StatusOK)
+}
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+	"github.com/ravindu/wallet-app-service/pkg/logging"
+	"github.com/ravindu/wallet-app-service/pkg/response"
+)
+
+type LimitHandler struct {
+	limitUsecase domain.LimitUsecase
+	logger       *logging.Logger
+}
+
+// NewLimitHandler creates a new limit handler
+func NewLimitHandler(limitUsecase domain.LimitUsecase) *LimitHandler {
+	return &LimitHandler{
+		limitUsecase: limitUsecase,
+		logger:       logging.NewLogger(),
+	}
+}
+
+// GetLimitsHandler shows the limits on one of a user's wallets, picked by the
+// wallet_id or currency query parameter, and what is left of them
+func (h *LimitHandler) GetLimitsHandler(w http.ResponseWriter, r *http.Request) {
+	requestID := getRequestID(r)
+	ctx := r.Context()
+
+	h.logger.Info(ctx, "Processing limits request")
+
+	userIDStr := chi.URLParam(r, "userID")
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		h.logger.Error(ctx, "Invalid user ID format: "+userIDStr)
+		errResp := apperrors.BadRequestError(requestID, "User ID must be a valid number")
+		response.Error(w, errResp)
+		return
+	}
+
+	if err := authorizeUser(ctx, userID); err != nil {
+		h.logger.Error(ctx, "Limits request rejected: "+err.Error())
+		errResp := apperrors.MapErrorToResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	selector, err := parseWalletSelector(r)
+	if err != nil {
+		h.logger.Error(ctx, "Invalid wallet selector: "+err.Error())
+		errResp := apperrors.MapErrorToResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	limits, err := h.limitUsecase.GetLimits(ctx, userID, selector)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get limits: "+err.Error())
+		errResp := apperrors.MapErrorToResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	h.logger.Info(ctx, "Limits request successful")
+	response.JSON(w, requestID, limits, http.StatusOK)
+}
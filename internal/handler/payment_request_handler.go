@@ -0,0 +1,246 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	"github.com/ravindu/wallet-app-service/internal/middleware"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+	"github.com/ravindu/wallet-app-service/pkg/logging"
+	"github.com/ravindu/wallet-app-service/pkg/response"
+)
+
+type PaymentRequestHandler struct {
+	requestUsecase domain.PaymentRequestUsecase
+	logger         *logging.Logger
+}
+
+// NewPaymentRequestHandler creates a new payment request handler
+func NewPaymentRequestHandler(requestUsecase domain.PaymentRequestUsecase) *PaymentRequestHandler {
+	return &PaymentRequestHandler{
+		requestUsecase: requestUsecase,
+		logger:         logging.NewLogger(),
+	}
+}
+
+// CreateRequestHandler asks another user for money on behalf of the caller
+func (h *PaymentRequestHandler) CreateRequestHandler(w http.ResponseWriter, r *http.Request) {
+	requestID := getRequestID(r)
+	ctx := r.Context()
+
+	h.logger.Info(ctx, "Processing create payment request request")
+
+	userID, ok := h.callerID(w, r)
+	if !ok {
+		return
+	}
+
+	var req domain.CreatePaymentRequestRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Error(ctx, "Failed to decode create payment request request: "+err.Error())
+		errResp := decodeErrorResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	request, err := h.requestUsecase.CreateRequest(ctx, userID, req)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to create payment request: "+err.Error())
+		errResp := apperrors.MapErrorToResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	h.logger.Info(ctx, "Create payment request request successful")
+	response.JSON(w, requestID, request, http.StatusCreated)
+}
+
+// ListRequestsHandler returns a page of the caller's payment requests, narrowed
+// by the direction and status query parameters
+func (h *PaymentRequestHandler) ListRequestsHandler(w http.ResponseWriter, r *http.Request) {
+	requestID := getRequestID(r)
+	ctx := r.Context()
+
+	h.logger.Info(ctx, "Processing list payment requests request")
+
+	userID, ok := h.callerID(w, r)
+	if !ok {
+		return
+	}
+
+	pagination, err := parsePagination(r)
+	if err != nil {
+		h.logger.Error(ctx, "Invalid pagination parameters: "+err.Error())
+		errResp := apperrors.MapErrorToResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	query := r.URL.Query()
+	filter := domain.PaymentRequestFilter{
+		Direction: domain.PaymentRequestDirection(strings.ToLower(query.Get("direction"))),
+		Status:    domain.PaymentRequestStatus(strings.ToUpper(query.Get("status"))),
+	}
+
+	requests, err := h.requestUsecase.ListRequests(ctx, userID, filter, pagination)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to list payment requests: "+err.Error())
+		errResp := apperrors.MapErrorToResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	h.logger.Info(ctx, "List payment requests request successful")
+	response.JSON(w, requestID, requests, http.StatusOK)
+}
+
+// GetRequestHandler returns a payment request the caller made or was sent
+func (h *PaymentRequestHandler) GetRequestHandler(w http.ResponseWriter, r *http.Request) {
+	requestID := getRequestID(r)
+	ctx := r.Context()
+
+	h.logger.Info(ctx, "Processing get payment request request")
+
+	userID, ok := h.callerID(w, r)
+	if !ok {
+		return
+	}
+	id, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	request, err := h.requestUsecase.GetRequest(ctx, userID, id)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get payment request: "+err.Error())
+		errResp := apperrors.MapErrorToResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	h.logger.Info(ctx, "Get payment request request successful")
+	response.JSON(w, requestID, request, http.StatusOK)
+}
+
+// AcceptRequestHandler pays a payment request sent to the caller
+func (h *PaymentRequestHandler) AcceptRequestHandler(w http.ResponseWriter, r *http.Request) {
+	requestID := getRequestID(r)
+	ctx := r.Context()
+
+	h.logger.Info(ctx, "Processing accept payment request request")
+
+	userID, ok := h.callerID(w, r)
+	if !ok {
+		return
+	}
+	id, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	// The body is optional, an empty one pays from the wallet in the request's currency
+	var req domain.AcceptPaymentRequestRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		h.logger.Error(ctx, "Failed to decode accept payment request request: "+err.Error())
+		errResp := decodeErrorResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	request, err := h.requestUsecase.AcceptRequest(ctx, userID, id, req)
+	if err != nil {
+		h.logger.Error(ctx, "Accept payment request failed: "+err.Error())
+		errResp := apperrors.MapErrorToResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	h.logger.Info(ctx, "Accept payment request successful")
+	response.JSON(w, requestID, request, http.StatusOK)
+}
+
+// DeclineRequestHandler turns down a payment request sent to the caller
+func (h *PaymentRequestHandler) DeclineRequestHandler(w http.ResponseWriter, r *http.Request) {
+	requestID := getRequestID(r)
+	ctx := r.Context()
+
+	h.logger.Info(ctx, "Processing decline payment request request")
+
+	userID, ok := h.callerID(w, r)
+	if !ok {
+		return
+	}
+	id, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	request, err := h.requestUsecase.DeclineRequest(ctx, userID, id)
+	if err != nil {
+		h.logger.Error(ctx, "Decline payment request failed: "+err.Error())
+		errResp := apperrors.MapErrorToResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	h.logger.Info(ctx, "Decline payment request successful")
+	response.JSON(w, requestID, request, http.StatusOK)
+}
+
+// CancelRequestHandler withdraws a payment request the caller made
+func (h *PaymentRequestHandler) CancelRequestHandler(w http.ResponseWriter, r *http.Request) {
+	requestID := getRequestID(r)
+	ctx := r.Context()
+
+	h.logger.Info(ctx, "Processing cancel payment request request")
+
+	userID, ok := h.callerID(w, r)
+	if !ok {
+		return
+	}
+	id, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	request, err := h.requestUsecase.CancelRequest(ctx, userID, id)
+	if err != nil {
+		h.logger.Error(ctx, "Cancel payment request failed: "+err.Error())
+		errResp := apperrors.MapErrorToResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	h.logger.Info(ctx, "Cancel payment request successful")
+	response.JSON(w, requestID, request, http.StatusOK)
+}
+
+// callerID returns the authenticated user, writing a 401 response if there is none
+func (h *PaymentRequestHandler) callerID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		errResp := apperrors.UnauthorizedError(getRequestID(r), "Authentication required")
+		response.Error(w, errResp)
+		return 0, false
+	}
+	return userID, true
+}
+
+// parseID reads the payment request ID from the URL, writing a 400 response if it is not a number
+func (h *PaymentRequestHandler) parseID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	value := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		h.logger.Error(r.Context(), "Invalid payment request ID format: "+value)
+		errResp := apperrors.BadRequestError(getRequestID(r), "Payment request ID must be a valid number")
+		response.Error(w, errResp)
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,199 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	"github.com/ravindu/wallet-app-service/internal/middleware"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+	"github.com/ravindu/wallet-app-service/pkg/logging"
+	"github.com/ravindu/wallet-app-service/pkg/response"
+)
+
+type ScheduledTransferHandler struct {
+	scheduleUsecase domain.ScheduledTransferUsecase
+	logger          *logging.Logger
+}
+
+// NewScheduledTransferHandler creates a new scheduled transfer handler
+func NewScheduledTransferHandler(scheduleUsecase domain.ScheduledTransferUsecase) *ScheduledTransferHandler {
+	return &ScheduledTransferHandler{
+		scheduleUsecase: scheduleUsecase,
+		logger:          logging.NewLogger(),
+	}
+}
+
+// CreateScheduleHandler schedules a transfer from the caller's wallet
+func (h *ScheduledTransferHandler) CreateScheduleHandler(w http.ResponseWriter, r *http.Request) {
+	requestID := getRequestID(r)
+	ctx := r.Context()
+
+	h.logger.Info(ctx, "Processing create scheduled transfer request")
+
+	userID, ok := h.callerID(w, r)
+	if !ok {
+		return
+	}
+
+	var req domain.CreateScheduledTransferRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Error(ctx, "Failed to decode create scheduled transfer request: "+err.Error())
+		errResp := decodeErrorResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	schedule, err := h.scheduleUsecase.CreateSchedule(ctx, userID, req)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to create scheduled transfer: "+err.Error())
+		errResp := apperrors.MapErrorToResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	h.logger.Info(ctx, "Create scheduled transfer request successful")
+	response.JSON(w, requestID, schedule, http.StatusCreated)
+}
+
+// ListSchedulesHandler lists the caller's scheduled transfers
+func (h *ScheduledTransferHandler) ListSchedulesHandler(w http.ResponseWriter, r *http.Request) {
+	requestID := getRequestID(r)
+	ctx := r.Context()
+
+	h.logger.Info(ctx, "Processing list scheduled transfers request")
+
+	userID, ok := h.callerID(w, r)
+	if !ok {
+		return
+	}
+
+	schedules, err := h.scheduleUsecase.ListSchedules(ctx, userID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to list scheduled transfers: "+err.Error())
+		errResp := apperrors.MapErrorToResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	h.logger.Info(ctx, "List scheduled transfers request successful")
+	response.JSON(w, requestID, schedules, http.StatusOK)
+}
+
+// GetScheduleHandler returns one of the caller's scheduled transfers
+func (h *ScheduledTransferHandler) GetScheduleHandler(w http.ResponseWriter, r *http.Request) {
+	requestID := getRequestID(r)
+	ctx := r.Context()
+
+	h.logger.Info(ctx, "Processing get scheduled transfer request")
+
+	userID, ok := h.callerID(w, r)
+	if !ok {
+		return
+	}
+	scheduleID, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	schedule, err := h.scheduleUsecase.GetSchedule(ctx, userID, scheduleID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get scheduled transfer: "+err.Error())
+		errResp := apperrors.MapErrorToResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	h.logger.Info(ctx, "Get scheduled transfer request successful")
+	response.JSON(w, requestID, schedule, http.StatusOK)
+}
+
+// CancelScheduleHandler stops one of the caller's scheduled transfers
+func (h *ScheduledTransferHandler) CancelScheduleHandler(w http.ResponseWriter, r *http.Request) {
+	requestID := getRequestID(r)
+	ctx := r.Context()
+
+	h.logger.Info(ctx, "Processing cancel scheduled transfer request")
+
+	userID, ok := h.callerID(w, r)
+	if !ok {
+		return
+	}
+	scheduleID, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	schedule, err := h.scheduleUsecase.CancelSchedule(ctx, userID, scheduleID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to cancel scheduled transfer: "+err.Error())
+		errResp := apperrors.MapErrorToResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	h.logger.Info(ctx, "Cancel scheduled transfer request successful")
+	response.JSON(w, requestID, schedule, http.StatusOK)
+}
+
+// ListExecutionsHandler returns a page of a scheduled transfer's execution history
+func (h *ScheduledTransferHandler) ListExecutionsHandler(w http.ResponseWriter, r *http.Request) {
+	requestID := getRequestID(r)
+	ctx := r.Context()
+
+	h.logger.Info(ctx, "Processing schedule execution history request")
+
+	userID, ok := h.callerID(w, r)
+	if !ok {
+		return
+	}
+	scheduleID, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	pagination, err := parsePagination(r)
+	if err != nil {
+		h.logger.Error(ctx, "Invalid pagination parameters: "+err.Error())
+		errResp := apperrors.MapErrorToResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	history, err := h.scheduleUsecase.ListExecutions(ctx, userID, scheduleID, pagination)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to list schedule executions: "+err.Error())
+		errResp := apperrors.MapErrorToResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	h.logger.Info(ctx, "Schedule execution history request successful")
+	response.JSON(w, requestID, history, http.StatusOK)
+}
+
+// callerID returns the authenticated user, writing a 401 response if there is none
+func (h *ScheduledTransferHandler) callerID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		errResp := apperrors.UnauthorizedError(getRequestID(r), "Authentication required")
+		response.Error(w, errResp)
+		return 0, false
+	}
+	return userID, true
+}
+
+// parseID reads the schedule ID from the URL, writing a 400 response if it is not a number
+func (h *ScheduledTransferHandler) parseID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	value := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		h.logger.Error(r.Context(), "Invalid scheduled transfer ID format: "+value)
+		errResp := apperrors.BadRequestError(getRequestID(r), "Scheduled transfer ID must be a valid number")
+		response.Error(w, errResp)
+		return 0, false
+	}
+	return id, true
+}
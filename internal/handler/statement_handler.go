@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	"github.com/ravindu/wallet-app-service/internal/statement"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+	"github.com/ravindu/wallet-app-service/pkg/logging"
+	"github.com/ravindu/wallet-app-service/pkg/response"
+)
+
+type StatementHandler struct {
+	statementUsecase domain.StatementUsecase
+	logger           *logging.Logger
+}
+
+// NewStatementHandler creates a new statement handler
+func NewStatementHandler(statementUsecase domain.StatementUsecase) *StatementHandler {
+	return &StatementHandler{
+		statementUsecase: statementUsecase,
+		logger:           logging.NewLogger(),
+	}
+}
+
+// ExportStatementHandler streams a wallet statement as a CSV, OFX or PDF download
+func (h *StatementHandler) ExportStatementHandler(w http.ResponseWriter, r *http.Request) {
+	requestID := getRequestID(r)
+	ctx := r.Context()
+
+	h.logger.Info(ctx, "Processing statement request")
+
+	userIDStr := chi.URLParam(r, "userID")
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		h.logger.Error(ctx, "Invalid user ID format: "+userIDStr)
+		errResp := apperrors.BadRequestError(requestID, "User ID must be a valid number")
+		response.Error(w, errResp)
+		return
+	}
+
+	if err := authorizeUser(ctx, userID); err != nil {
+		h.logger.Error(ctx, "Statement request rejected: "+err.Error())
+		errResp := apperrors.MapErrorToResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	req, format, err := parseStatementRequest(r, userID)
+	if err != nil {
+		h.logger.Error(ctx, "Invalid statement request: "+err.Error())
+		errResp := apperrors.MapErrorToResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	writer, err := statement.NewWriter(format, w)
+	if err != nil {
+		h.logger.Error(ctx, "Invalid statement format: "+err.Error())
+		errResp := apperrors.MapErrorToResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	// A long statement can take longer to stream than the server's write
+	// timeout allows, which would cut the file off part way through
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		h.logger.Warn(ctx, "Failed to clear statement write deadline: "+err.Error())
+	}
+
+	download := &statementDownload{StatementWriter: writer, w: w, format: format}
+	if err := h.statementUsecase.WriteStatement(ctx, req, download); err != nil {
+		h.logger.Error(ctx, "Statement failed: "+err.Error())
+
+		// Once the file has started there is no way to send an error, so the
+		// connection is cut rather than leaving a download that looks complete
+		if download.started {
+			panic(http.ErrAbortHandler)
+		}
+
+		errResp := apperrors.MapErrorToResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	h.logger.Info(ctx, "Statement request successful")
+}
+
+// parseStatementRequest reads the statement period and format. to defaults to now.
+func parseStatementRequest(r *http.Request, userID int64) (domain.StatementRequest, domain.StatementFormat, error) {
+	req := domain.StatementRequest{UserID: userID}
+	query := r.URL.Query()
+
+	selector, err := parseWalletSelector(r)
+	if err != nil {
+		return req, "", err
+	}
+	req.Selector = selector
+
+	format, err := domain.ParseStatementFormat(query.Get("format"))
+	if err != nil {
+		return req, "", err
+	}
+
+	from, err := parseTimeParam(query.Get("from"), false)
+	if err != nil {
+		return req, "", fmt.Errorf("%w: from %v", apperrors.ErrInvalidInput, err)
+	}
+	if from == nil {
+		return req, "", fmt.Errorf("%w: from is required", apperrors.ErrInvalidInput)
+	}
+	req.From = *from
+
+	to, err := parseTimeParam(query.Get("to"), true)
+	if err != nil {
+		return req, "", fmt.Errorf("%w: to %v", apperrors.ErrInvalidInput, err)
+	}
+	req.To = time.Now().UTC()
+	if to != nil {
+		req.To = *to
+	}
+
+	return req, format, nil
+}
+
+// statementDownload sets the download headers when the statement begins,
+// once the wallet it names is known
+type statementDownload struct {
+	domain.StatementWriter
+	w       http.ResponseWriter
+	format  domain.StatementFormat
+	started bool
+}
+
+func (d *statementDownload) Begin(header domain.StatementHeader) error {
+	d.w.Header().Set("Content-Type", statement.ContentType(d.format))
+	d.w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", statement.FileName(header, d.format)))
+	d.started = true
+
+	return d.StatementWriter.Begin(header)
+}
@@ -0,0 +1,278 @@
+package handler
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	"github.com/ravindu/wallet-app-service/internal/middleware"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+	"github.com/ravindu/wallet-app-service/pkg/logging"
+	"github.com/ravindu/wallet-app-service/pkg/response"
+)
+
+// maxBatchBodySize caps a batch upload, which is enough for MaxBatchItems rows
+const maxBatchBodySize = 4 << 20
+
+type TransferBatchHandler struct {
+	batchUsecase domain.TransferBatchUsecase
+	logger       *logging.Logger
+}
+
+// NewTransferBatchHandler creates a new transfer batch handler
+func NewTransferBatchHandler(batchUsecase domain.TransferBatchUsecase) *TransferBatchHandler {
+	return &TransferBatchHandler{
+		batchUsecase: batchUsecase,
+		logger:       logging.NewLogger(),
+	}
+}
+
+// CreateBatchHandler queues a batch of transfers from the caller's wallet. The
+// items come as JSON, as a text/csv body, or as a CSV file uploaded in the
+// "file" field of a multipart form.
+func (h *TransferBatchHandler) CreateBatchHandler(w http.ResponseWriter, r *http.Request) {
+	requestID := getRequestID(r)
+	ctx := r.Context()
+
+	h.logger.Info(ctx, "Processing create transfer batch request")
+
+	userID, ok := h.callerID(w, r)
+	if !ok {
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxBatchBodySize)
+
+	var req domain.CreateTransferBatchRequest
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "text/csv" || mediaType == "multipart/form-data" {
+		var err error
+		if req, err = decodeBatchCSV(r, mediaType); err != nil {
+			h.logger.Error(ctx, "Failed to read transfer batch CSV: "+err.Error())
+			errResp := apperrors.MapErrorToResponse(requestID, err)
+			response.Error(w, errResp)
+			return
+		}
+	} else if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Error(ctx, "Failed to decode create transfer batch request: "+err.Error())
+		errResp := decodeErrorResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	batch, err := h.batchUsecase.CreateBatch(ctx, userID, req)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to create transfer batch: "+err.Error())
+		errResp := apperrors.MapErrorToResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	h.logger.Info(ctx, "Create transfer batch request successful")
+	response.JSON(w, requestID, batch, http.StatusAccepted)
+}
+
+// GetBatchHandler returns the progress of one of the caller's batches
+func (h *TransferBatchHandler) GetBatchHandler(w http.ResponseWriter, r *http.Request) {
+	requestID := getRequestID(r)
+	ctx := r.Context()
+
+	h.logger.Info(ctx, "Processing get transfer batch request")
+
+	userID, ok := h.callerID(w, r)
+	if !ok {
+		return
+	}
+	batchID, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	batch, err := h.batchUsecase.GetBatch(ctx, userID, batchID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get transfer batch: "+err.Error())
+		errResp := apperrors.MapErrorToResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	h.logger.Info(ctx, "Get transfer batch request successful")
+	response.JSON(w, requestID, batch, http.StatusOK)
+}
+
+// ListItemsHandler returns a page of a batch's items with their outcomes,
+// optionally only those with the given status
+func (h *TransferBatchHandler) ListItemsHandler(w http.ResponseWriter, r *http.Request) {
+	requestID := getRequestID(r)
+	ctx := r.Context()
+
+	h.logger.Info(ctx, "Processing transfer batch items request")
+
+	userID, ok := h.callerID(w, r)
+	if !ok {
+		return
+	}
+	batchID, ok := h.parseID(w, r)
+	if !ok {
+		return
+	}
+
+	pagination, err := parsePagination(r)
+	if err != nil {
+		h.logger.Error(ctx, "Invalid pagination parameters: "+err.Error())
+		errResp := apperrors.MapErrorToResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+	status := domain.BatchItemStatus(strings.ToUpper(r.URL.Query().Get("status")))
+
+	items, err := h.batchUsecase.ListItems(ctx, userID, batchID, status, pagination)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to list transfer batch items: "+err.Error())
+		errResp := apperrors.MapErrorToResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	h.logger.Info(ctx, "Transfer batch items request successful")
+	response.JSON(w, requestID, items, http.StatusOK)
+}
+
+// callerID returns the authenticated user, writing a 401 response if there is none
+func (h *TransferBatchHandler) callerID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		errResp := apperrors.UnauthorizedError(getRequestID(r), "Authentication required")
+		response.Error(w, errResp)
+		return 0, false
+	}
+	return userID, true
+}
+
+// parseID reads the batch ID from the URL, writing a 400 response if it is not a number
+func (h *TransferBatchHandler) parseID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	value := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		h.logger.Error(r.Context(), "Invalid transfer batch ID format: "+value)
+		errResp := apperrors.BadRequestError(getRequestID(r), "Transfer batch ID must be a valid number")
+		response.Error(w, errResp)
+		return 0, false
+	}
+	return id, true
+}
+
+// decodeBatchCSV reads a batch sent as a text/csv body or uploaded in the "file"
+// field of a multipart form. The batch options come from the query string or,
+// for uploads, the form fields.
+func decodeBatchCSV(r *http.Request, mediaType string) (domain.CreateTransferBatchRequest, error) {
+	var req domain.CreateTransferBatchRequest
+
+	body := r.Body
+	if mediaType == "multipart/form-data" {
+		if err := r.ParseMultipartForm(maxBatchBodySize); err != nil {
+			return req, fmt.Errorf("%w: could not read the upload: %v", apperrors.ErrInvalidInput, err)
+		}
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			return req, fmt.Errorf("%w: the CSV must be uploaded in the file field", apperrors.ErrInvalidInput)
+		}
+		defer file.Close()
+		body = file
+	}
+
+	items, err := parseBatchCSV(body)
+	if err != nil {
+		return req, err
+	}
+	req.Items = items
+
+	if walletIDStr := r.FormValue("sender_wallet_id"); walletIDStr != "" {
+		walletID, err := strconv.ParseInt(walletIDStr, 10, 64)
+		if err != nil || walletID <= 0 {
+			return req, fmt.Errorf("%w: sender_wallet_id must be a positive number", apperrors.ErrInvalidInput)
+		}
+		req.SenderWalletID = walletID
+	}
+	if currencyStr := r.FormValue("currency"); currencyStr != "" {
+		currency, err := domain.ParseCurrency(currencyStr)
+		if err != nil {
+			return req, err
+		}
+		req.Currency = currency
+	}
+	req.Mode = domain.BatchMode(strings.ToUpper(r.FormValue("mode")))
+
+	return req, nil
+}
+
+// parseBatchCSV reads batch items from a CSV with a header row. receiver_id and
+// amount are required columns; receiver_wallet_id and comment are optional.
+func parseBatchCSV(body io.Reader) ([]domain.TransferBatchItemRequest, error) {
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: the CSV needs a header row: %v", apperrors.ErrInvalidInput, err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	for _, required := range []string{"receiver_id", "amount"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("%w: the CSV has no %s column", apperrors.ErrInvalidInput, required)
+		}
+	}
+
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var items []domain.TransferBatchItemRequest
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: malformed CSV: %v", apperrors.ErrInvalidInput, err)
+		}
+		line, _ := reader.FieldPos(0)
+
+		if len(items) == domain.MaxBatchItems {
+			return nil, fmt.Errorf("%w: a batch holds at most %d items", apperrors.ErrInvalidInput, domain.MaxBatchItems)
+		}
+
+		var item domain.TransferBatchItemRequest
+		if item.ReceiverID, err = strconv.ParseInt(field(record, "receiver_id"), 10, 64); err != nil {
+			return nil, fmt.Errorf("%w: CSV line %d: receiver_id must be a number", apperrors.ErrInvalidInput, line)
+		}
+		if item.Amount, err = domain.ParseAmount(field(record, "amount")); err != nil {
+			return nil, fmt.Errorf("CSV line %d: %w", line, err)
+		}
+		if walletIDStr := field(record, "receiver_wallet_id"); walletIDStr != "" {
+			if item.ReceiverWalletID, err = strconv.ParseInt(walletIDStr, 10, 64); err != nil {
+				return nil, fmt.Errorf("%w: CSV line %d: receiver_wallet_id must be a number", apperrors.ErrInvalidInput, line)
+			}
+		}
+		item.Comment = field(record, "comment")
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
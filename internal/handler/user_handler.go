@@ -0,0 +1,221 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+	"github.com/ravindu/wallet-app-service/pkg/logging"
+	"github.com/ravindu/wallet-app-service/pkg/response"
+)
+
+type UserHandler struct {
+	userUsecase domain.UserUsecase
+	logger      *logging.Logger
+}
+
+// NewUserHandler creates a new user handler
+func NewUserHandler(userUsecase domain.UserUsecase) *UserHandler {
+	return &UserHandler{
+		userUsecase: userUsecase,
+		logger:      logging.NewLogger(),
+	}
+}
+
+// CreateUserHandler registers a user and provisions their wallet
+func (h *UserHandler) CreateUserHandler(w http.ResponseWriter, r *http.Request) {
+	requestID := getRequestID(r)
+	ctx := r.Context()
+
+	h.logger.Info(ctx, "Processing user registration request")
+
+	var req domain.CreateUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Error(ctx, "Failed to decode user registration request: "+err.Error())
+		errResp := apperrors.BadRequestError(requestID, "Invalid request format, please check your JSON payload")
+		response.Error(w, errResp)
+		return
+	}
+
+	registration, err := h.userUsecase.Register(ctx, req)
+	if err != nil {
+		h.logger.Error(ctx, "User registration failed: "+err.Error())
+		errResp := apperrors.MapErrorToResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	h.logger.Info(ctx, "User registration successful")
+	response.JSON(w, requestID, registration, http.StatusCreated)
+}
+
+// GetUserHandler returns a user's profile
+func (h *UserHandler) GetUserHandler(w http.ResponseWriter, r *http.Request) {
+	requestID := getRequestID(r)
+	ctx := r.Context()
+
+	h.logger.Info(ctx, "Processing get user request")
+
+	userID, ok := h.parseUserID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := authorizeUser(ctx, userID); err != nil {
+		h.logger.Error(ctx, "Get user request rejected: "+err.Error())
+		errResp := apperrors.MapErrorToResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	user, err := h.userUsecase.GetUser(ctx, userID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get user: "+err.Error())
+		errResp := apperrors.MapErrorToResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	h.logger.Info(ctx, "Get user request successful")
+	response.JSON(w, requestID, user, http.StatusOK)
+}
+
+// UpdateUserHandler changes a user's profile fields
+func (h *UserHandler) UpdateUserHandler(w http.ResponseWriter, r *http.Request) {
+	requestID := getRequestID(r)
+	ctx := r.Context()
+
+	h.logger.Info(ctx, "Processing update user request")
+
+	userID, ok := h.parseUserID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := authorizeUser(ctx, userID); err != nil {
+		h.logger.Error(ctx, "Update user request rejected: "+err.Error())
+		errResp := apperrors.MapErrorToResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	var req domain.UpdateUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Error(ctx, "Failed to decode update user request: "+err.Error())
+		errResp := apperrors.BadRequestError(requestID, "Invalid request format, please check your JSON payload")
+		response.Error(w, errResp)
+		return
+	}
+
+	if req.Username == nil && req.Email == nil {
+		h.logger.Error(ctx, "Update user request has no fields to change")
+		errResp := apperrors.BadRequestError(requestID, "Nothing to update, provide username or email")
+		response.Error(w, errResp)
+		return
+	}
+
+	user, err := h.userUsecase.UpdateUser(ctx, userID, req)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to update user: "+err.Error())
+
+		if errors.Is(err, apperrors.ErrUserNotFound) {
+			errResp := apperrors.NotFoundError(requestID, "User not found")
+			response.Error(w, errResp)
+			return
+		}
+
+		errResp := apperrors.MapErrorToResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	h.logger.Info(ctx, "Update user request successful")
+	response.JSON(w, requestID, user, http.StatusOK)
+}
+
+// CreateWalletHandler opens another wallet for a user
+func (h *UserHandler) CreateWalletHandler(w http.ResponseWriter, r *http.Request) {
+	requestID := getRequestID(r)
+	ctx := r.Context()
+
+	h.logger.Info(ctx, "Processing create wallet request")
+
+	userID, ok := h.parseUserID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := authorizeUser(ctx, userID); err != nil {
+		h.logger.Error(ctx, "Create wallet request rejected: "+err.Error())
+		errResp := apperrors.MapErrorToResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	var req domain.CreateWalletRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Error(ctx, "Failed to decode create wallet request: "+err.Error())
+		errResp := apperrors.BadRequestError(requestID, "Invalid request format, please check your JSON payload")
+		response.Error(w, errResp)
+		return
+	}
+
+	wallet, err := h.userUsecase.CreateWallet(ctx, userID, req)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to create wallet: "+err.Error())
+		errResp := apperrors.MapErrorToResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	h.logger.Info(ctx, "Create wallet request successful")
+	response.JSON(w, requestID, wallet, http.StatusCreated)
+}
+
+// ListWalletsHandler returns all of a user's wallets
+func (h *UserHandler) ListWalletsHandler(w http.ResponseWriter, r *http.Request) {
+	requestID := getRequestID(r)
+	ctx := r.Context()
+
+	h.logger.Info(ctx, "Processing list wallets request")
+
+	userID, ok := h.parseUserID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := authorizeUser(ctx, userID); err != nil {
+		h.logger.Error(ctx, "List wallets request rejected: "+err.Error())
+		errResp := apperrors.MapErrorToResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	wallets, err := h.userUsecase.ListWallets(ctx, userID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to list wallets: "+err.Error())
+		errResp := apperrors.MapErrorToResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	h.logger.Info(ctx, "List wallets request successful")
+	response.JSON(w, requestID, wallets, http.StatusOK)
+}
+
+// parseUserID reads the {id} URL parameter, writing a 400 response if it is not a number
+func (h *UserHandler) parseUserID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	userIDStr := chi.URLParam(r, "id")
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		h.logger.Error(r.Context(), "Invalid user ID format: "+userIDStr)
+		errResp := apperrors.BadRequestError(getRequestID(r), "User ID must be a valid number")
+		response.Error(w, errResp)
+		return 0, false
+	}
+	return userID, true
+}
@@ -1,11 +1,16 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/ravindu/wallet-app-service/internal/domain"
@@ -16,15 +21,17 @@ import (
 )
 
 type WalletHandler struct {
-	walletUsecase domain.WalletUsecase
-	logger        *logging.Logger
+	walletUsecase  domain.WalletUsecase
+	balanceUsecase domain.BalanceUsecase
+	logger         *logging.Logger
 }
 
 // NewWalletHandler creates a new wallet handler
-func NewWalletHandler(walletUsecase domain.WalletUsecase) *WalletHandler {
+func NewWalletHandler(walletUsecase domain.WalletUsecase, balanceUsecase domain.BalanceUsecase) *WalletHandler {
 	return &WalletHandler{
-		walletUsecase: walletUsecase,
-		logger:        logging.NewLogger(),
+		walletUsecase:  walletUsecase,
+		balanceUsecase: balanceUsecase,
+		logger:         logging.NewLogger(),
 	}
 }
 
@@ -36,6 +43,129 @@ func getRequestID(r *http.Request) string {
 	return "no-request-id"
 }
 
+// decodeErrorResponse picks the client message for a request body that failed to decode
+func decodeErrorResponse(requestID string, err error) *apperrors.ErrorResponse {
+	switch {
+	case errors.Is(err, apperrors.ErrAmountPrecision):
+		return apperrors.BadRequestError(requestID, "Amount has more decimal places than the currency allows")
+	case errors.Is(err, apperrors.ErrInvalidAmountFormat):
+		return apperrors.BadRequestError(requestID, "Amount must be a decimal number")
+	default:
+		return apperrors.BadRequestError(requestID, "Invalid request format, please check your JSON payload")
+	}
+}
+
+// parseWalletSelector reads the optional wallet_id and currency query parameters
+func parseWalletSelector(r *http.Request) (domain.WalletSelector, error) {
+	var selector domain.WalletSelector
+	query := r.URL.Query()
+
+	if walletIDStr := query.Get("wallet_id"); walletIDStr != "" {
+		walletID, err := strconv.ParseInt(walletIDStr, 10, 64)
+		if err != nil || walletID <= 0 {
+			return selector, fmt.Errorf("%w: wallet_id must be a positive number", apperrors.ErrInvalidInput)
+		}
+		selector.WalletID = walletID
+	}
+
+	if currencyStr := query.Get("currency"); currencyStr != "" {
+		currency, err := domain.ParseCurrency(currencyStr)
+		if err != nil {
+			return selector, err
+		}
+		selector.Currency = currency
+	}
+
+	return selector, nil
+}
+
+// parseTransactionFilter reads the filter query parameters of a transaction
+// listing. type may repeat or hold a comma-separated list.
+func parseTransactionFilter(r *http.Request) (domain.TransactionFilter, error) {
+	var filter domain.TransactionFilter
+	query := r.URL.Query()
+
+	for _, typesStr := range query["type"] {
+		for _, typeStr := range strings.Split(typesStr, ",") {
+			if strings.TrimSpace(typeStr) == "" {
+				continue
+			}
+			transactionType, err := domain.ParseTransactionType(typeStr)
+			if err != nil {
+				return filter, err
+			}
+			filter.Types = append(filter.Types, transactionType)
+		}
+	}
+
+	from, err := parseTimeParam(query.Get("from"), false)
+	if err != nil {
+		return filter, fmt.Errorf("%w: from %v", apperrors.ErrInvalidInput, err)
+	}
+	filter.From = from
+
+	to, err := parseTimeParam(query.Get("to"), true)
+	if err != nil {
+		return filter, fmt.Errorf("%w: to %v", apperrors.ErrInvalidInput, err)
+	}
+	filter.To = to
+
+	if minStr := query.Get("min_amount"); minStr != "" {
+		minAmount, err := domain.ParseAmount(minStr)
+		if err != nil {
+			return filter, fmt.Errorf("min_amount: %w", err)
+		}
+		filter.MinAmount = &minAmount
+	}
+
+	if maxStr := query.Get("max_amount"); maxStr != "" {
+		maxAmount, err := domain.ParseAmount(maxStr)
+		if err != nil {
+			return filter, fmt.Errorf("max_amount: %w", err)
+		}
+		filter.MaxAmount = &maxAmount
+	}
+
+	if counterpartyStr := query.Get("counterparty_wallet_id"); counterpartyStr != "" {
+		counterpartyID, err := strconv.ParseInt(counterpartyStr, 10, 64)
+		if err != nil || counterpartyID <= 0 {
+			return filter, fmt.Errorf("%w: counterparty_wallet_id must be a positive number", apperrors.ErrInvalidInput)
+		}
+		filter.CounterpartyWalletID = &counterpartyID
+	}
+
+	filter.Search = strings.TrimSpace(query.Get("search"))
+
+	sort, err := domain.ParseSortOrder(query.Get("sort"))
+	if err != nil {
+		return filter, err
+	}
+	filter.Sort = sort
+
+	return filter, nil
+}
+
+// parseTimeParam reads an RFC 3339 time or a YYYY-MM-DD date in UTC. As an
+// exclusive upper bound a date means the end of that day.
+func parseTimeParam(value string, endOfDay bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return nil, fmt.Errorf("must be an RFC 3339 time or a YYYY-MM-DD date")
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, nil
+}
+
 // DepositHandler handles deposit requests
 func (h *WalletHandler) DepositHandler(w http.ResponseWriter, r *http.Request) {
 	requestID := getRequestID(r)
@@ -46,14 +176,22 @@ func (h *WalletHandler) DepositHandler(w http.ResponseWriter, r *http.Request) {
 	var req domain.DepositRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		h.logger.Error(ctx, "Failed to decode deposit request: "+err.Error())
-		errResp := apperrors.BadRequestError(requestID, "Invalid request format, please check your JSON payload")
+		errResp := decodeErrorResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	// Callers may only deposit into their own wallet
+	if err := authorizeUser(ctx, req.UserID); err != nil {
+		h.logger.Error(ctx, "Deposit rejected: "+err.Error())
+		errResp := apperrors.MapErrorToResponse(requestID, err)
 		response.Error(w, errResp)
 		return
 	}
 
 	// Validate request
 	if req.Amount <= 0 {
-		h.logger.Error(ctx, "Invalid deposit amount: "+fmt.Sprintf("%f", req.Amount))
+		h.logger.Error(ctx, "Invalid deposit amount: "+req.Amount.String())
 		errResp := apperrors.BadRequestError(requestID, "Amount must be positive")
 		response.Error(w, errResp)
 		return
@@ -82,14 +220,22 @@ func (h *WalletHandler) WithdrawHandler(w http.ResponseWriter, r *http.Request)
 	var req domain.WithdrawRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		h.logger.Error(ctx, "Failed to decode withdrawal request: "+err.Error())
-		errResp := apperrors.BadRequestError(requestID, "Invalid request format, please check your JSON payload")
+		errResp := decodeErrorResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	// Callers may only withdraw from their own wallet
+	if err := authorizeUser(ctx, req.UserID); err != nil {
+		h.logger.Error(ctx, "Withdrawal rejected: "+err.Error())
+		errResp := apperrors.MapErrorToResponse(requestID, err)
 		response.Error(w, errResp)
 		return
 	}
 
 	// Validate request
 	if req.Amount <= 0 {
-		h.logger.Error(ctx, "Invalid withdrawal amount: "+fmt.Sprintf("%f", req.Amount))
+		h.logger.Error(ctx, "Invalid withdrawal amount: "+req.Amount.String())
 		errResp := apperrors.BadRequestError(requestID, "Amount must be positive")
 		response.Error(w, errResp)
 		return
@@ -126,14 +272,22 @@ func (h *WalletHandler) TransferHandler(w http.ResponseWriter, r *http.Request)
 	var req domain.TransferRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		h.logger.Error(ctx, "Failed to decode transfer request: "+err.Error())
-		errResp := apperrors.BadRequestError(requestID, "Invalid request format, please check your JSON payload")
+		errResp := decodeErrorResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	// Callers may only send money from their own wallet
+	if err := authorizeUser(ctx, req.SenderID); err != nil {
+		h.logger.Error(ctx, "Transfer rejected: "+err.Error())
+		errResp := apperrors.MapErrorToResponse(requestID, err)
 		response.Error(w, errResp)
 		return
 	}
 
 	// Validate request
 	if req.Amount <= 0 {
-		h.logger.Error(ctx, "Invalid transfer amount: "+fmt.Sprintf("%f", req.Amount))
+		h.logger.Error(ctx, "Invalid transfer amount: "+req.Amount.String())
 		errResp := apperrors.BadRequestError(requestID, "Amount must be positive")
 		response.Error(w, errResp)
 		return
@@ -176,6 +330,48 @@ func (h *WalletHandler) TransferHandler(w http.ResponseWriter, r *http.Request)
 	response.JSON(w, requestID, transaction, http.StatusOK)
 }
 
+// ConvertHandler handles conversions between two of a user's wallets
+func (h *WalletHandler) ConvertHandler(w http.ResponseWriter, r *http.Request) {
+	requestID := getRequestID(r)
+	ctx := r.Context()
+
+	h.logger.Info(ctx, "Processing convert request")
+
+	var req domain.ConvertRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Error(ctx, "Failed to decode convert request: "+err.Error())
+		errResp := decodeErrorResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	// Callers may only convert money in their own wallets
+	if err := authorizeUser(ctx, req.UserID); err != nil {
+		h.logger.Error(ctx, "Convert rejected: "+err.Error())
+		errResp := apperrors.MapErrorToResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	if req.Amount <= 0 {
+		h.logger.Error(ctx, "Invalid convert amount: "+req.Amount.String())
+		errResp := apperrors.BadRequestError(requestID, "Amount must be positive")
+		response.Error(w, errResp)
+		return
+	}
+
+	conversion, err := h.walletUsecase.Convert(ctx, req)
+	if err != nil {
+		h.logger.Error(ctx, "Convert failed: "+err.Error())
+		errResp := apperrors.MapErrorToResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	h.logger.Info(ctx, "Convert successful")
+	response.JSON(w, requestID, conversion, http.StatusOK)
+}
+
 // GetBalanceHandler handles balance requests
 func (h *WalletHandler) GetBalanceHandler(w http.ResponseWriter, r *http.Request) {
 	requestID := getRequestID(r)
@@ -192,7 +388,27 @@ func (h *WalletHandler) GetBalanceHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	wallet, err := h.walletUsecase.GetBalance(ctx, userID)
+	if err := authorizeUser(ctx, userID); err != nil {
+		h.logger.Error(ctx, "Balance request rejected: "+err.Error())
+		errResp := apperrors.MapErrorToResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	selector, err := parseWalletSelector(r)
+	if err != nil {
+		h.logger.Error(ctx, "Invalid wallet selector: "+err.Error())
+		errResp := apperrors.MapErrorToResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	if atStr := r.URL.Query().Get("at"); atStr != "" {
+		h.getBalanceAt(w, r, userID, selector, atStr)
+		return
+	}
+
+	wallet, err := h.walletUsecase.GetBalance(ctx, userID, selector)
 	if err != nil {
 		h.logger.Error(ctx, "Failed to get balance: "+err.Error())
 		
@@ -213,6 +429,32 @@ func (h *WalletHandler) GetBalanceHandler(w http.ResponseWriter, r *http.Request
 	response.JSON(w, requestID, wallet, http.StatusOK)
 }
 
+// getBalanceAt answers a balance request for a point in time. A date means the
+// end of that day.
+func (h *WalletHandler) getBalanceAt(w http.ResponseWriter, r *http.Request, userID int64, selector domain.WalletSelector, atStr string) {
+	requestID := getRequestID(r)
+	ctx := r.Context()
+
+	at, err := parseTimeParam(atStr, true)
+	if err != nil {
+		h.logger.Error(ctx, "Invalid balance time: "+atStr)
+		errResp := apperrors.BadRequestError(requestID, "at must be an RFC 3339 time or a YYYY-MM-DD date")
+		response.Error(w, errResp)
+		return
+	}
+
+	balance, err := h.balanceUsecase.GetBalanceAt(ctx, userID, selector, *at)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to get balance: "+err.Error())
+		errResp := apperrors.MapErrorToResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	h.logger.Info(ctx, "Balance request successful")
+	response.JSON(w, requestID, balance, http.StatusOK)
+}
+
 // GetTransactionHistoryHandler handles transaction history requests
 func (h *WalletHandler) GetTransactionHistoryHandler(w http.ResponseWriter, r *http.Request) {
 	requestID := getRequestID(r)
@@ -229,6 +471,29 @@ func (h *WalletHandler) GetTransactionHistoryHandler(w http.ResponseWriter, r *h
 		return
 	}
 
+	if err := authorizeUser(ctx, userID); err != nil {
+		h.logger.Error(ctx, "Transaction history request rejected: "+err.Error())
+		errResp := apperrors.MapErrorToResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	selector, err := parseWalletSelector(r)
+	if err != nil {
+		h.logger.Error(ctx, "Invalid wallet selector: "+err.Error())
+		errResp := apperrors.MapErrorToResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	filter, err := parseTransactionFilter(r)
+	if err != nil {
+		h.logger.Error(ctx, "Invalid transaction filter: "+err.Error())
+		errResp := apperrors.MapErrorToResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
 	// Parse pagination parameters
 	limitStr := r.URL.Query().Get("limit")
 	offsetStr := r.URL.Query().Get("offset")
@@ -279,13 +544,29 @@ func (h *WalletHandler) GetTransactionHistoryHandler(w http.ResponseWriter, r *h
 		offset = offsetInt
 	}
 
+	// A cursor takes over from the offset. The total is counted for offset
+	// paging as it always was, and for cursor paging only when asked for.
+	cursor := r.URL.Query().Get("cursor")
+	includeTotal := cursor == ""
+	if includeTotalStr := r.URL.Query().Get("include_total"); includeTotalStr != "" {
+		includeTotal, err = strconv.ParseBool(includeTotalStr)
+		if err != nil {
+			h.logger.Error(ctx, "Invalid include_total parameter: "+includeTotalStr)
+			errResp := apperrors.BadRequestError(requestID, "include_total must be true or false")
+			response.Error(w, errResp)
+			return
+		}
+	}
+
 	pagination := domain.PaginationRequest{
-		Limit:  limit,
-		Offset: offset,
+		Limit:        limit,
+		Offset:       offset,
+		Cursor:       cursor,
+		IncludeTotal: includeTotal,
 	}
 
 	h.logger.Debug(ctx, "Getting transaction history")
-	history, err := h.walletUsecase.GetTransactionHistory(ctx, userID, pagination)
+	history, err := h.walletUsecase.GetTransactionHistory(ctx, userID, selector, filter, pagination)
 	if err != nil {
 		h.logger.Error(ctx, "Failed to get transaction history: "+err.Error())
 		
@@ -302,6 +583,99 @@ func (h *WalletHandler) GetTransactionHistoryHandler(w http.ResponseWriter, r *h
 		return
 	}
 
+	history.Next = pageLink(r, history.NextCursor)
+	history.Prev = pageLink(r, history.PrevCursor)
+
 	h.logger.Info(ctx, "Transaction history request successful")
 	response.JSON(w, requestID, history, http.StatusOK)
-}
\ No newline at end of file
+}
+
+// pageLink rewrites the request URL to fetch the page at cursor, keeping the
+// other query parameters
+func pageLink(r *http.Request, cursor string) string {
+	if cursor == "" {
+		return ""
+	}
+
+	query := r.URL.Query()
+	query.Del("offset")
+	query.Set("cursor", cursor)
+
+	link := url.URL{Path: r.URL.Path, RawQuery: query.Encode()}
+	return link.String()
+}
+
+// ReverseTransactionHandler handles requests to undo a whole transaction
+func (h *WalletHandler) ReverseTransactionHandler(w http.ResponseWriter, r *http.Request) {
+	h.handleReversal(w, r, "reversal", h.walletUsecase.Reverse)
+}
+
+// RefundTransactionHandler handles requests to undo part of a transaction
+func (h *WalletHandler) RefundTransactionHandler(w http.ResponseWriter, r *http.Request) {
+	h.handleReversal(w, r, "refund", h.walletUsecase.Refund)
+}
+
+// handleReversal runs a reversal or refund of the transaction in the path
+func (h *WalletHandler) handleReversal(
+	w http.ResponseWriter,
+	r *http.Request,
+	kind string,
+	run func(context.Context, domain.ReversalRequest) (*domain.ReversalResponse, error),
+) {
+	requestID := getRequestID(r)
+	ctx := r.Context()
+
+	h.logger.Info(ctx, "Processing "+kind+" request")
+
+	// Undoing a transaction touches other users' wallets, so only operators may do it
+	if err := authorizePrivileged(ctx); err != nil {
+		h.logger.Error(ctx, "Transaction "+kind+" rejected: "+err.Error())
+		errResp := apperrors.MapErrorToResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	transactionIDStr := chi.URLParam(r, "id")
+	transactionID, err := strconv.ParseInt(transactionIDStr, 10, 64)
+	if err != nil {
+		h.logger.Error(ctx, "Invalid transaction ID format: "+transactionIDStr)
+		errResp := apperrors.BadRequestError(requestID, "Transaction ID must be a valid number")
+		response.Error(w, errResp)
+		return
+	}
+
+	// The body is optional for a full reversal
+	var req domain.ReversalRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		h.logger.Error(ctx, "Failed to decode "+kind+" request: "+err.Error())
+		errResp := decodeErrorResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+	req.TransactionID = transactionID
+
+	if kind == "refund" && req.Amount <= 0 {
+		h.logger.Error(ctx, "Invalid refund amount: "+req.Amount.String())
+		errResp := apperrors.BadRequestError(requestID, "Amount must be positive")
+		response.Error(w, errResp)
+		return
+	}
+
+	result, err := run(ctx, req)
+	if err != nil {
+		h.logger.Error(ctx, "Transaction "+kind+" failed: "+err.Error())
+
+		if errors.Is(err, apperrors.ErrInsufficientFunds) {
+			errResp := apperrors.PaymentRequiredError(requestID, "The wallet no longer holds enough funds to give the money back")
+			response.Error(w, errResp)
+			return
+		}
+
+		errResp := apperrors.MapErrorToResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	h.logger.Info(ctx, "Transaction "+kind+" successful")
+	response.JSON(w, requestID, result, http.StatusOK)
+}
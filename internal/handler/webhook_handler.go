@@ -0,0 +1,226 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	"github.com/ravindu/wallet-app-service/internal/middleware"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+	"github.com/ravindu/wallet-app-service/pkg/logging"
+	"github.com/ravindu/wallet-app-service/pkg/response"
+)
+
+type WebhookHandler struct {
+	webhookUsecase domain.WebhookUsecase
+	logger         *logging.Logger
+}
+
+// NewWebhookHandler creates a new webhook handler
+func NewWebhookHandler(webhookUsecase domain.WebhookUsecase) *WebhookHandler {
+	return &WebhookHandler{
+		webhookUsecase: webhookUsecase,
+		logger:         logging.NewLogger(),
+	}
+}
+
+// CreateWebhookHandler registers a webhook for the caller
+func (h *WebhookHandler) CreateWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	requestID := getRequestID(r)
+	ctx := r.Context()
+
+	h.logger.Info(ctx, "Processing create webhook request")
+
+	userID, ok := h.callerID(w, r)
+	if !ok {
+		return
+	}
+
+	var req domain.CreateWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Error(ctx, "Failed to decode create webhook request: "+err.Error())
+		errResp := apperrors.BadRequestError(requestID, "Invalid request format, please check your JSON payload")
+		response.Error(w, errResp)
+		return
+	}
+
+	subscription, err := h.webhookUsecase.CreateSubscription(ctx, userID, req)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to create webhook: "+err.Error())
+		errResp := apperrors.MapErrorToResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	h.logger.Info(ctx, "Create webhook request successful")
+	response.JSON(w, requestID, subscription, http.StatusCreated)
+}
+
+// ListWebhooksHandler lists the caller's webhooks
+func (h *WebhookHandler) ListWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	requestID := getRequestID(r)
+	ctx := r.Context()
+
+	h.logger.Info(ctx, "Processing list webhooks request")
+
+	userID, ok := h.callerID(w, r)
+	if !ok {
+		return
+	}
+
+	subscriptions, err := h.webhookUsecase.ListSubscriptions(ctx, userID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to list webhooks: "+err.Error())
+		errResp := apperrors.MapErrorToResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	h.logger.Info(ctx, "List webhooks request successful")
+	response.JSON(w, requestID, subscriptions, http.StatusOK)
+}
+
+// DeleteWebhookHandler removes one of the caller's webhooks
+func (h *WebhookHandler) DeleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	requestID := getRequestID(r)
+	ctx := r.Context()
+
+	h.logger.Info(ctx, "Processing delete webhook request")
+
+	userID, ok := h.callerID(w, r)
+	if !ok {
+		return
+	}
+	subscriptionID, ok := h.parseID(w, r, "id", "Webhook ID")
+	if !ok {
+		return
+	}
+
+	if err := h.webhookUsecase.DeleteSubscription(ctx, userID, subscriptionID); err != nil {
+		h.logger.Error(ctx, "Failed to delete webhook: "+err.Error())
+		errResp := apperrors.MapErrorToResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	h.logger.Info(ctx, "Delete webhook request successful")
+	response.JSON(w, requestID, map[string]int64{"id": subscriptionID}, http.StatusOK)
+}
+
+// ListDeliveriesHandler returns a page of a webhook's delivery log
+func (h *WebhookHandler) ListDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	requestID := getRequestID(r)
+	ctx := r.Context()
+
+	h.logger.Info(ctx, "Processing webhook delivery log request")
+
+	userID, ok := h.callerID(w, r)
+	if !ok {
+		return
+	}
+	subscriptionID, ok := h.parseID(w, r, "id", "Webhook ID")
+	if !ok {
+		return
+	}
+
+	pagination, err := parsePagination(r)
+	if err != nil {
+		h.logger.Error(ctx, "Invalid pagination parameters: "+err.Error())
+		errResp := apperrors.MapErrorToResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	history, err := h.webhookUsecase.ListDeliveries(ctx, userID, subscriptionID, pagination)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to list webhook deliveries: "+err.Error())
+		errResp := apperrors.MapErrorToResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	h.logger.Info(ctx, "Webhook delivery log request successful")
+	response.JSON(w, requestID, history, http.StatusOK)
+}
+
+// RedeliverHandler queues a delivery to be sent again
+func (h *WebhookHandler) RedeliverHandler(w http.ResponseWriter, r *http.Request) {
+	requestID := getRequestID(r)
+	ctx := r.Context()
+
+	h.logger.Info(ctx, "Processing webhook redelivery request")
+
+	userID, ok := h.callerID(w, r)
+	if !ok {
+		return
+	}
+	subscriptionID, ok := h.parseID(w, r, "id", "Webhook ID")
+	if !ok {
+		return
+	}
+	deliveryID, ok := h.parseID(w, r, "deliveryID", "Delivery ID")
+	if !ok {
+		return
+	}
+
+	delivery, err := h.webhookUsecase.Redeliver(ctx, userID, subscriptionID, deliveryID)
+	if err != nil {
+		h.logger.Error(ctx, "Failed to redeliver webhook: "+err.Error())
+		errResp := apperrors.MapErrorToResponse(requestID, err)
+		response.Error(w, errResp)
+		return
+	}
+
+	h.logger.Info(ctx, "Webhook redelivery request successful")
+	response.JSON(w, requestID, delivery, http.StatusAccepted)
+}
+
+// callerID returns the authenticated user, writing a 401 response if there is none
+func (h *WebhookHandler) callerID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		errResp := apperrors.UnauthorizedError(getRequestID(r), "Authentication required")
+		response.Error(w, errResp)
+		return 0, false
+	}
+	return userID, true
+}
+
+// parseID reads a numeric URL parameter, writing a 400 response if it is not a number
+func (h *WebhookHandler) parseID(w http.ResponseWriter, r *http.Request, param, label string) (int64, bool) {
+	value := chi.URLParam(r, param)
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		h.logger.Error(r.Context(), "Invalid "+label+" format: "+value)
+		errResp := apperrors.BadRequestError(getRequestID(r), label+" must be a valid number")
+		response.Error(w, errResp)
+		return 0, false
+	}
+	return id, true
+}
+
+// parsePagination reads the limit and offset query parameters, capping limit at 100
+func parsePagination(r *http.Request) (domain.PaginationRequest, error) {
+	pagination := domain.PaginationRequest{Limit: 10}
+
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			return pagination, fmt.Errorf("%w: limit must be a positive number", apperrors.ErrInvalidInput)
+		}
+		pagination.Limit = min(limit, 100)
+	}
+
+	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+		offset, err := strconv.Atoi(offsetStr)
+		if err != nil || offset < 0 {
+			return pagination, fmt.Errorf("%w: offset must be a non-negative number", apperrors.ErrInvalidInput)
+		}
+		pagination.Offset = offset
+	}
+
+	return pagination, nil
+}
@@ -0,0 +1,29 @@
+// Package limit loads the per-tier transaction limits
+package limit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+)
+
+// LoadPolicy reads each tier's limit rules from a JSON file such as
+//
+//	{"standard": [{"transaction_type": "WITHDRAWAL", "per_transaction": 1000,
+//	               "daily_amount": 2000, "daily_count": 10}],
+//	 "premium": [{"transaction_type": "WITHDRAWAL", "daily_amount": 20000}]}
+func LoadPolicy(path string) (domain.LimitPolicy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read limits file: %w", err)
+	}
+
+	var tiers map[domain.UserTier][]domain.LimitRule
+	if err := json.Unmarshal(data, &tiers); err != nil {
+		return nil, fmt.Errorf("failed to parse limits file %s: %w", path, err)
+	}
+
+	return domain.NewLimitPolicy(tiers)
+}
@@ -0,0 +1,45 @@
+package limit_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	"github.com/ravindu/wallet-app-service/internal/limit"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadPolicy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "limits.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"standard": [{"transaction_type": "WITHDRAWAL", "per_transaction": "1000", "daily_amount": 2000, "daily_count": 10}],
+		"premium": [{"transaction_type": "TRANSFER", "currency": "USD", "monthly_amount": 50000}]
+	}`), 0o600))
+
+	policy, err := limit.LoadPolicy(path)
+	require.NoError(t, err)
+
+	rule := policy.Rule(domain.StandardTier, domain.Withdrawal, domain.USD)
+	require.NotNil(t, rule)
+	assert.Equal(t, domain.NewAmount(1000), rule.PerTransaction)
+	assert.Equal(t, domain.NewAmount(2000), rule.DailyAmount)
+	assert.Equal(t, 10, rule.DailyCount)
+
+	rule = policy.Rule("premium", domain.Transfer, domain.USD)
+	require.NotNil(t, rule)
+	assert.Equal(t, domain.NewAmount(50000), rule.MonthlyAmount)
+}
+
+func TestLoadPolicy_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "limits.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"standard": [{"transaction_type": "REFUND", "daily_count": 1}]}`), 0o600))
+
+	_, err := limit.LoadPolicy(path)
+	assert.ErrorIs(t, err, apperrors.ErrInvalidInput)
+
+	_, err = limit.LoadPolicy(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
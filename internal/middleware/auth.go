@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/ravindu/wallet-app-service/pkg/auth"
 	"github.com/ravindu/wallet-app-service/pkg/errors"
 	"github.com/ravindu/wallet-app-service/pkg/logging"
 	"github.com/ravindu/wallet-app-service/pkg/response"
@@ -16,56 +17,57 @@ type AuthContextKey string
 // UserIDKey is the context key for the authenticated user ID
 const UserIDKey AuthContextKey = "user_id"
 
-// AuthMiddleware provides authentication for API endpoints
-func AuthMiddleware(next http.Handler) http.Handler {
+// RolesKey is the context key for the authenticated user's roles
+const RolesKey AuthContextKey = "roles"
+
+// AuthMiddleware provides authentication for API endpoints. It accepts a
+// Bearer JWT, checks its signature and claims with verifier, and puts the
+// token's subject into the context as the user ID.
+func AuthMiddleware(verifier *auth.Verifier) func(http.Handler) http.Handler {
 	logger := logging.NewLogger()
-	
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		ctx := r.Context()
-		requestID := GetRequestID(ctx)
-		
-		// Get authorization header
-		authHeader := r.Header.Get("Authorization")
-		
-		// Check if authorization header exists
-		if authHeader == "" {
-			// TODO: Implement proper unauthorized response
-			logger.Error(ctx, "Missing Authorization header")
-			errResp := errors.UnauthorizedError(requestID, "Missing Authorization header")
-			response.Error(w, errResp)
-			return
-		}
-		
-		// Check if it's a Bearer token
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			// TODO: Implement proper error handling for invalid auth format
-			logger.Error(ctx, "Invalid Authorization format")
-			errResp := errors.UnauthorizedError(requestID, "Invalid Authorization format")
-			response.Error(w, errResp)
-			return
-		}
-		
-		// Extract token for validation
-		_ = parts[1] // Ignoring until token validation is implemented
-		
-		// TODO: Implement actual token validation logic
-		// - Parse and validate JWT token
-		// - Check token expiration
-		// - Verify signature with secret key
-		// - Check if token is blacklisted
-		
-		// TODO: Get user ID from token claims
-		var userID int64 = 0 // Placeholder value, replace with actual user ID from token
-		
-		// TODO: Check if user exists in database
-
-		// Add user ID to context for downstream handlers
-		ctx = context.WithValue(ctx, UserIDKey, userID)
-		
-		// Call next handler
-		next.ServeHTTP(w, r.WithContext(ctx))
-	})
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			requestID := GetRequestID(ctx)
+
+			// Get authorization header
+			authHeader := r.Header.Get("Authorization")
+
+			// Check if authorization header exists
+			if authHeader == "" {
+				logger.Error(ctx, "Missing Authorization header")
+				errResp := errors.UnauthorizedError(requestID, "Missing Authorization header")
+				response.Error(w, errResp)
+				return
+			}
+
+			// Check if it's a Bearer token
+			parts := strings.Split(authHeader, " ")
+			if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") || parts[1] == "" {
+				logger.Error(ctx, "Invalid Authorization format")
+				errResp := errors.UnauthorizedError(requestID, "Invalid Authorization format")
+				response.Error(w, errResp)
+				return
+			}
+
+			// Check signature, expiry, not-before, issuer and audience
+			identity, err := verifier.Verify(parts[1])
+			if err != nil {
+				logger.Error(ctx, "Invalid token: "+err.Error())
+				errResp := errors.UnauthorizedError(requestID, "Invalid or expired token")
+				response.Error(w, errResp)
+				return
+			}
+
+			// Add user ID and roles to context for downstream handlers
+			ctx = context.WithValue(ctx, UserIDKey, identity.UserID)
+			ctx = context.WithValue(ctx, RolesKey, identity.Roles)
+
+			// Call next handler
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
 }
 
 // GetUserID extracts the authenticated user ID from the context
@@ -74,20 +76,37 @@ func GetUserID(ctx context.Context) (int64, bool) {
 	return userID, ok
 }
 
+// GetRoles extracts the authenticated user's roles from the context
+func GetRoles(ctx context.Context) []string {
+	roles, _ := ctx.Value(RolesKey).([]string)
+	return roles
+}
+
+// HasRole reports whether the authenticated user holds any of the given roles
+func HasRole(ctx context.Context, roles ...string) bool {
+	for _, held := range GetRoles(ctx) {
+		for _, role := range roles {
+			if held == role {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // RequireAuth checks if a user is authenticated
 func RequireAuth(handler http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 		requestID := GetRequestID(ctx)
-		
+
 		userID, ok := GetUserID(ctx)
 		if !ok || userID == 0 {
-			// TODO: Implement proper unauthorized response
 			errResp := errors.UnauthorizedError(requestID, "Authentication required")
 			response.Error(w, errResp)
 			return
 		}
-		
+
 		handler(w, r)
 	}
-}
\ No newline at end of file
+}
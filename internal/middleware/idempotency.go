@@ -0,0 +1,162 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	"github.com/ravindu/wallet-app-service/pkg/errors"
+	"github.com/ravindu/wallet-app-service/pkg/logging"
+	"github.com/ravindu/wallet-app-service/pkg/response"
+)
+
+const (
+	// IdempotencyKeyHeader is the header clients use to make retries safe
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader is set on responses served from the store
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+	// maxIdempotentBodySize fits the largest batch transfer upload
+	maxIdempotentBodySize = 4 << 20
+)
+
+// IdempotencyOptions controls how long keys are held
+type IdempotencyOptions struct {
+	// TTL is how long a completed response can be replayed
+	TTL time.Duration
+	// LockTimeout is how long an unfinished request holds its key
+	LockTimeout time.Duration
+}
+
+// responseRecorder captures what the wrapped handler writes
+type responseRecorder struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (rec *responseRecorder) WriteHeader(statusCode int) {
+	rec.statusCode = statusCode
+	rec.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	if rec.statusCode == 0 {
+		rec.statusCode = http.StatusOK
+	}
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
+
+// Idempotency makes requests carrying an Idempotency-Key safe to retry.
+// The first response for a key is stored per user and replayed for retries with
+// the same payload; a retry while the first is still running gets a 409 and a
+// key reused for a different payload gets a 422. Requests without the header
+// pass straight through.
+func Idempotency(store domain.IdempotencyStore, opts IdempotencyOptions) func(http.Handler) http.Handler {
+	logger := logging.NewLogger()
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			requestID := GetRequestID(ctx)
+
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if len(key) > maxIdempotencyKeyLength {
+				errResp := errors.BadRequestError(requestID, "Idempotency-Key must be at most 255 characters")
+				response.Error(w, errResp)
+				return
+			}
+
+			body, err := io.ReadAll(io.LimitReader(r.Body, maxIdempotentBodySize+1))
+			if err != nil {
+				logger.Error(ctx, "Failed to read request body: "+err.Error())
+				errResp := errors.BadRequestError(requestID, "Could not read request body")
+				response.Error(w, errResp)
+				return
+			}
+			// Refuse rather than cut the body short, which would change the request
+			if len(body) > maxIdempotentBodySize {
+				errResp := errors.NewErrorResponse(requestID, "Request body is too large", http.StatusRequestEntityTooLarge)
+				response.Error(w, errResp)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			// Keys are scoped to the authenticated caller
+			userID, _ := GetUserID(ctx)
+
+			record := &domain.IdempotencyRecord{
+				Key:         key,
+				UserID:      userID,
+				Fingerprint: requestFingerprint(r, body),
+				ExpiresAt:   time.Now().Add(opts.LockTimeout),
+			}
+
+			existing, err := store.Reserve(ctx, record)
+			if err != nil {
+				logger.Error(ctx, "Failed to reserve idempotency key: "+err.Error())
+				errResp := errors.MapErrorToResponse(requestID, err)
+				response.Error(w, errResp)
+				return
+			}
+
+			if existing != nil {
+				replayIdempotentResponse(w, requestID, existing, record.Fingerprint)
+				return
+			}
+
+			rec := &responseRecorder{ResponseWriter: w}
+			next.ServeHTTP(rec, r)
+
+			// Keep going even if the client hung up, the outcome must be recorded
+			storeCtx := context.WithoutCancel(ctx)
+
+			// Server errors are not final, so let the client retry with the same key
+			if rec.statusCode == 0 || rec.statusCode >= http.StatusInternalServerError {
+				if err := store.Release(storeCtx, record); err != nil {
+					logger.Error(ctx, "Failed to release idempotency key: "+err.Error())
+				}
+				return
+			}
+
+			if err := store.Complete(storeCtx, record, rec.statusCode, rec.body.Bytes(), opts.TTL); err != nil {
+				logger.Error(ctx, "Failed to store idempotent response: "+err.Error())
+			}
+		})
+	}
+}
+
+// replayIdempotentResponse answers a request whose key is already taken
+func replayIdempotentResponse(w http.ResponseWriter, requestID string, existing *domain.IdempotencyRecord, fingerprint string) {
+	switch {
+	case existing.Fingerprint != fingerprint:
+		response.Error(w, errors.MapErrorToResponse(requestID, errors.ErrIdempotencyKeyReused))
+	case existing.Status != domain.IdempotencyCompleted:
+		response.Error(w, errors.MapErrorToResponse(requestID, errors.ErrIdempotencyInProgress))
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set(IdempotentReplayedHeader, "true")
+		w.WriteHeader(existing.ResponseCode)
+		w.Write(existing.ResponseBody)
+	}
+}
+
+// requestFingerprint hashes what makes two requests "the same"
+func requestFingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,158 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	"github.com/ravindu/wallet-app-service/internal/middleware"
+	"github.com/ravindu/wallet-app-service/internal/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdempotency(t *testing.T) {
+	opts := middleware.IdempotencyOptions{TTL: time.Hour, LockTimeout: time.Minute}
+
+	newRequest := func(key, body string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/deposit", strings.NewReader(body))
+		if key != "" {
+			req.Header.Set(middleware.IdempotencyKeyHeader, key)
+		}
+		return req
+	}
+
+	t.Run("replays the first response", func(t *testing.T) {
+		calls := 0
+		handler := middleware.Idempotency(repository.NewIdempotencyMemoryRepository(), opts)(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`{"data":{"id":1}}`))
+			}),
+		)
+
+		first := httptest.NewRecorder()
+		handler.ServeHTTP(first, newRequest("key-1", `{"user_id":1,"amount":10}`))
+		second := httptest.NewRecorder()
+		handler.ServeHTTP(second, newRequest("key-1", `{"user_id":1,"amount":10}`))
+
+		assert.Equal(t, 1, calls)
+		assert.Equal(t, http.StatusOK, second.Code)
+		assert.Equal(t, first.Body.String(), second.Body.String())
+		assert.Equal(t, "true", second.Header().Get(middleware.IdempotentReplayedHeader))
+	})
+
+	t.Run("rejects a reused key with a different payload", func(t *testing.T) {
+		handler := middleware.Idempotency(repository.NewIdempotencyMemoryRepository(), opts)(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}),
+		)
+
+		handler.ServeHTTP(httptest.NewRecorder(), newRequest("key-1", `{"user_id":1,"amount":10}`))
+		second := httptest.NewRecorder()
+		handler.ServeHTTP(second, newRequest("key-1", `{"user_id":1,"amount":20}`))
+
+		assert.Equal(t, http.StatusUnprocessableEntity, second.Code)
+	})
+
+	t.Run("rejects a concurrent duplicate", func(t *testing.T) {
+		store := repository.NewIdempotencyMemoryRepository()
+		handler := middleware.Idempotency(store, opts)(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				t.Fatal("handler should not run while the key is held")
+			}),
+		)
+
+		// Simulate the first request still being in flight
+		first := newRequest("key-1", `{"user_id":1,"amount":10}`)
+		inFlight := middleware.Idempotency(store, opts)(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				second := httptest.NewRecorder()
+				handler.ServeHTTP(second, newRequest("key-1", `{"user_id":1,"amount":10}`))
+				assert.Equal(t, http.StatusConflict, second.Code)
+				w.WriteHeader(http.StatusOK)
+			}),
+		)
+		inFlight.ServeHTTP(httptest.NewRecorder(), first)
+	})
+
+	t.Run("releases the key after a server error", func(t *testing.T) {
+		store := repository.NewIdempotencyMemoryRepository()
+		handler := middleware.Idempotency(store, opts)(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+			}),
+		)
+
+		handler.ServeHTTP(httptest.NewRecorder(), newRequest("key-1", `{}`))
+
+		existing, err := store.Reserve(context.Background(), &domain.IdempotencyRecord{
+			Key:       "key-1",
+			ExpiresAt: time.Now().Add(time.Minute),
+		})
+		assert.NoError(t, err)
+		assert.Nil(t, existing)
+	})
+
+	t.Run("a request that outlives its lock leaves the retry's record alone", func(t *testing.T) {
+		store := repository.NewIdempotencyMemoryRepository()
+		calls := 0
+
+		var handler http.Handler
+		handler = middleware.Idempotency(store, middleware.IdempotencyOptions{TTL: time.Hour, LockTimeout: time.Nanosecond})(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				if calls == 1 {
+					// The lock has run out, so a retry takes the key and finishes first
+					handler.ServeHTTP(httptest.NewRecorder(), newRequest("key-1", `{"user_id":1,"amount":10}`))
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`{"data":{"id":1}}`))
+			}),
+		)
+
+		handler.ServeHTTP(httptest.NewRecorder(), newRequest("key-1", `{"user_id":1,"amount":10}`))
+
+		// The first request's 500 must not have released the retry's stored response
+		third := httptest.NewRecorder()
+		handler.ServeHTTP(third, newRequest("key-1", `{"user_id":1,"amount":10}`))
+
+		assert.Equal(t, 2, calls)
+		assert.Equal(t, http.StatusOK, third.Code)
+		assert.Equal(t, "true", third.Header().Get(middleware.IdempotentReplayedHeader))
+	})
+
+	t.Run("refuses a body too large to fingerprint", func(t *testing.T) {
+		handler := middleware.Idempotency(repository.NewIdempotencyMemoryRepository(), opts)(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				t.Fatal("handler should not see a cut-short body")
+			}),
+		)
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, newRequest("key-1", strings.Repeat("x", 4<<20+1)))
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	})
+
+	t.Run("passes through without a key", func(t *testing.T) {
+		calls := 0
+		handler := middleware.Idempotency(repository.NewIdempotencyMemoryRepository(), opts)(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+			}),
+		)
+
+		handler.ServeHTTP(httptest.NewRecorder(), newRequest("", `{}`))
+		handler.ServeHTTP(httptest.NewRecorder(), newRequest("", `{}`))
+
+		assert.Equal(t, 2, calls)
+	})
+}
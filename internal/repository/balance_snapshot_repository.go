@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+)
+
+type balanceSnapshotRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewBalanceSnapshotRepository creates a new PostgreSQL balance snapshot repository
+func NewBalanceSnapshotRepository(db *pgxpool.Pool) domain.BalanceSnapshotRepository {
+	return &balanceSnapshotRepository{
+		db: db,
+	}
+}
+
+func (r *balanceSnapshotRepository) CreateBatch(ctx context.Context, asOf time.Time, afterWalletID int64, limit int) (int64, int, error) {
+	// The balance comes from the running balances on the wallet's transactions,
+	// the same way a point-in-time query works it out: after the last transaction
+	// before asOf, else before the first one after it, else the current balance
+	// of a wallet that has never moved. The insert runs even though the final
+	// SELECT only reads the batch.
+	query := `
+		WITH batch AS (
+			SELECT id, balance
+			FROM wallets
+			WHERE id > $2 AND created_at < $1
+			ORDER BY id
+			LIMIT $3
+		), inserted AS (
+			INSERT INTO balance_snapshots (wallet_id, as_of, balance, created_at)
+			SELECT b.id, $1, COALESCE(
+				(SELECT t.balance_after FROM transactions t
+				 WHERE t.wallet_id = b.id AND t.transaction_time < $1
+				 ORDER BY t.transaction_time DESC, t.id DESC LIMIT 1),
+				(SELECT t.balance_before FROM transactions t
+				 WHERE t.wallet_id = b.id AND t.transaction_time >= $1
+				 ORDER BY t.transaction_time, t.id LIMIT 1),
+				b.balance
+			), $4
+			FROM batch b
+			ON CONFLICT (wallet_id, as_of) DO NOTHING
+		)
+		SELECT COALESCE(MAX(id), 0), COUNT(*) FROM batch
+	`
+
+	var lastWalletID int64
+	var count int
+	err := conn(ctx, r.db).QueryRow(ctx, query, asOf.UTC(), afterWalletID, limit, time.Now().UTC()).Scan(&lastWalletID, &count)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to create balance snapshots: %w", err)
+	}
+
+	return lastWalletID, count, nil
+}
+
+func (r *balanceSnapshotRepository) GetLatest(ctx context.Context, walletID int64, at time.Time) (*domain.BalanceSnapshot, error) {
+	query := `
+		SELECT wallet_id, as_of, balance, created_at
+		FROM balance_snapshots
+		WHERE wallet_id = $1 AND as_of <= $2
+		ORDER BY as_of DESC
+		LIMIT 1
+	`
+
+	snapshot := &domain.BalanceSnapshot{}
+	err := conn(ctx, r.db).QueryRow(ctx, query, walletID, at.UTC()).Scan(
+		&snapshot.WalletID,
+		&snapshot.AsOf,
+		&snapshot.Balance,
+		&snapshot.CreatedAt,
+	)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperrors.ErrResourceNotFound
+		}
+		return nil, fmt.Errorf("failed to get balance snapshot: %w", err)
+	}
+
+	return snapshot, nil
+}
+
+func (r *balanceSnapshotRepository) GetLatestAsOf(ctx context.Context) (time.Time, error) {
+	query := `SELECT MAX(as_of) FROM balance_snapshots`
+
+	var asOf *time.Time
+	if err := conn(ctx, r.db).QueryRow(ctx, query).Scan(&asOf); err != nil {
+		return time.Time{}, fmt.Errorf("failed to get latest balance snapshot: %w", err)
+	}
+	if asOf == nil {
+		return time.Time{}, apperrors.ErrResourceNotFound
+	}
+
+	return *asOf, nil
+}
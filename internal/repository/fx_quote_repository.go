@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+)
+
+type fxQuoteRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewFXQuoteRepository creates a new PostgreSQL fx quote repository
+func NewFXQuoteRepository(db *pgxpool.Pool) domain.FXQuoteRepository {
+	return &fxQuoteRepository{
+		db: db,
+	}
+}
+
+func (r *fxQuoteRepository) Create(ctx context.Context, quote *domain.FXQuote) error {
+	quote.ID = uuid.New().String()
+	quote.CreatedAt = time.Now()
+
+	query := `
+		INSERT INTO fx_quotes (
+			id, user_id, from_currency, to_currency, mid_rate, rate,
+			spread_bps, source, expires_at, used_at, created_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`
+
+	_, err := conn(ctx, r.db).Exec(ctx, query,
+		quote.ID,
+		quote.UserID,
+		quote.FromCurrency,
+		quote.ToCurrency,
+		quote.MidRate,
+		quote.Rate,
+		quote.SpreadBps,
+		quote.Source,
+		quote.ExpiresAt,
+		quote.UsedAt,
+		quote.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create fx quote: %w", err)
+	}
+
+	return nil
+}
+
+func (r *fxQuoteRepository) GetByIDForUpdate(ctx context.Context, id string) (*domain.FXQuote, error) {
+	// Quote IDs are UUIDs, anything else can't match
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, apperrors.ErrResourceNotFound
+	}
+
+	query := `
+		SELECT id::text, user_id, from_currency, to_currency, mid_rate, rate,
+			spread_bps, source, expires_at, used_at, created_at
+		FROM fx_quotes
+		WHERE id = $1::uuid
+		FOR UPDATE
+	`
+
+	quote := &domain.FXQuote{}
+	err := conn(ctx, r.db).QueryRow(ctx, query, id).Scan(
+		&quote.ID,
+		&quote.UserID,
+		&quote.FromCurrency,
+		&quote.ToCurrency,
+		&quote.MidRate,
+		&quote.Rate,
+		&quote.SpreadBps,
+		&quote.Source,
+		&quote.ExpiresAt,
+		&quote.UsedAt,
+		&quote.CreatedAt,
+	)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperrors.ErrResourceNotFound
+		}
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == lockNotAvailableCode {
+			return nil, apperrors.ErrLockAcquisitionFailed
+		}
+		return nil, fmt.Errorf("failed to get fx quote: %w", err)
+	}
+
+	return quote, nil
+}
+
+func (r *fxQuoteRepository) MarkUsed(ctx context.Context, quote *domain.FXQuote) error {
+	query := `
+		UPDATE fx_quotes
+		SET used_at = $1
+		WHERE id = $2::uuid
+	`
+
+	tag, err := conn(ctx, r.db).Exec(ctx, query, quote.UsedAt, quote.ID)
+	if err != nil {
+		return fmt.Errorf("failed to mark fx quote used: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return apperrors.ErrResourceNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+)
+
+// holdColumns lists the columns getOne expects, in order. The owner comes
+// from the wallet.
+const holdColumns = `
+	h.id, h.wallet_id, w.user_id, h.amount, h.captured_amount, h.status,
+	COALESCE(h.description, ''), h.transaction_id, h.expires_at, h.created_at, h.updated_at
+`
+
+type holdRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewHoldRepository creates a new PostgreSQL hold repository
+func NewHoldRepository(db *pgxpool.Pool) domain.HoldRepository {
+	return &holdRepository{
+		db: db,
+	}
+}
+
+func (r *holdRepository) Create(ctx context.Context, hold *domain.Hold) error {
+	now := time.Now()
+	hold.CreatedAt = now
+	hold.UpdatedAt = now
+
+	query := `
+		INSERT INTO holds (
+			wallet_id, amount, captured_amount, status, description,
+			expires_at, created_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+	`
+
+	err := conn(ctx, r.db).QueryRow(ctx, query,
+		hold.WalletID,
+		hold.Amount,
+		hold.CapturedAmount,
+		hold.Status,
+		hold.Description,
+		hold.ExpiresAt,
+		hold.CreatedAt,
+		hold.UpdatedAt,
+	).Scan(&hold.ID)
+
+	if err != nil {
+		return fmt.Errorf("failed to create hold: %w", err)
+	}
+
+	return nil
+}
+
+func (r *holdRepository) GetByID(ctx context.Context, id int64) (*domain.Hold, error) {
+	query := `
+		SELECT ` + holdColumns + `
+		FROM holds h
+		JOIN wallets w ON w.id = h.wallet_id
+		WHERE h.id = $1
+	`
+
+	return r.getOne(ctx, query, id)
+}
+
+func (r *holdRepository) GetByIDForUpdate(ctx context.Context, id int64) (*domain.Hold, error) {
+	// Only the hold is locked; wallets are locked separately in a fixed order
+	query := `
+		SELECT ` + holdColumns + `
+		FROM holds h
+		JOIN wallets w ON w.id = h.wallet_id
+		WHERE h.id = $1
+		FOR UPDATE OF h
+	`
+
+	return r.getOne(ctx, query, id)
+}
+
+// getOne runs a single-hold query and scans the row
+func (r *holdRepository) getOne(ctx context.Context, query string, args ...any) (*domain.Hold, error) {
+	hold := &domain.Hold{}
+	err := conn(ctx, r.db).QueryRow(ctx, query, args...).Scan(
+		&hold.ID,
+		&hold.WalletID,
+		&hold.UserID,
+		&hold.Amount,
+		&hold.CapturedAmount,
+		&hold.Status,
+		&hold.Description,
+		&hold.TransactionID,
+		&hold.ExpiresAt,
+		&hold.CreatedAt,
+		&hold.UpdatedAt,
+	)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperrors.ErrResourceNotFound
+		}
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == lockNotAvailableCode {
+			return nil, apperrors.ErrLockAcquisitionFailed
+		}
+		return nil, fmt.Errorf("failed to get hold: %w", err)
+	}
+
+	return hold, nil
+}
+
+func (r *holdRepository) Update(ctx context.Context, hold *domain.Hold) error {
+	hold.UpdatedAt = time.Now()
+
+	query := `
+		UPDATE holds
+		SET captured_amount = $1, status = $2, transaction_id = $3, updated_at = $4
+		WHERE id = $5
+	`
+
+	tag, err := conn(ctx, r.db).Exec(ctx, query,
+		hold.CapturedAmount,
+		hold.Status,
+		hold.TransactionID,
+		hold.UpdatedAt,
+		hold.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update hold: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return apperrors.ErrResourceNotFound
+	}
+
+	return nil
+}
+
+func (r *holdRepository) ListExpiredIDs(ctx context.Context, now time.Time, limit int) ([]int64, error) {
+	query := `
+		SELECT id
+		FROM holds
+		WHERE status = $1 AND expires_at <= $2
+		ORDER BY expires_at
+		LIMIT $3
+	`
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, domain.HoldActive, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expired holds: %w", err)
+	}
+	defer rows.Close()
+
+	ids := make([]int64, 0)
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan hold row: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating hold rows: %w", err)
+	}
+
+	return ids, nil
+}
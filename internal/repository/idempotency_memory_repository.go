@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+)
+
+// idempotencyMemoryKey identifies a record in the in-memory store
+type idempotencyMemoryKey struct {
+	userID int64
+	key    string
+}
+
+type idempotencyMemoryRepository struct {
+	mu      sync.Mutex
+	records map[idempotencyMemoryKey]domain.IdempotencyRecord
+}
+
+// NewIdempotencyMemoryRepository creates an in-process idempotency store.
+// It only protects a single instance, so use it for local runs and tests.
+func NewIdempotencyMemoryRepository() domain.IdempotencyStore {
+	return &idempotencyMemoryRepository{
+		records: make(map[idempotencyMemoryKey]domain.IdempotencyRecord),
+	}
+}
+
+func (r *idempotencyMemoryRepository) Reserve(ctx context.Context, record *domain.IdempotencyRecord) (*domain.IdempotencyRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	mapKey := idempotencyMemoryKey{userID: record.UserID, key: record.Key}
+
+	if existing, ok := r.records[mapKey]; ok && existing.ExpiresAt.After(now) {
+		return &existing, nil
+	}
+
+	record.Status = domain.IdempotencyInProgress
+	record.CreatedAt = now
+	r.records[mapKey] = *record
+
+	return nil, nil
+}
+
+func (r *idempotencyMemoryRepository) Complete(
+	ctx context.Context,
+	reserved *domain.IdempotencyRecord,
+	statusCode int,
+	body []byte,
+	ttl time.Duration,
+) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	mapKey := idempotencyMemoryKey{userID: reserved.UserID, key: reserved.Key}
+	record, ok := r.records[mapKey]
+	if !ok || !record.HeldBy(reserved) {
+		return fmt.Errorf("failed to complete idempotency key: %q is no longer reserved by this request", reserved.Key)
+	}
+
+	record.Status = domain.IdempotencyCompleted
+	record.ResponseCode = statusCode
+	record.ResponseBody = body
+	record.ExpiresAt = time.Now().Add(ttl)
+	r.records[mapKey] = record
+
+	return nil
+}
+
+func (r *idempotencyMemoryRepository) Release(ctx context.Context, reserved *domain.IdempotencyRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	mapKey := idempotencyMemoryKey{userID: reserved.UserID, key: reserved.Key}
+	record, ok := r.records[mapKey]
+	if !ok || !record.HeldBy(reserved) {
+		return fmt.Errorf("failed to release idempotency key: %q is no longer reserved by this request", reserved.Key)
+	}
+
+	delete(r.records, mapKey)
+	return nil
+}
@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	"github.com/redis/go-redis/v9"
+)
+
+type idempotencyRedisRepository struct {
+	client *redis.Client
+}
+
+// NewIdempotencyRedisRepository creates a Redis-backed idempotency store.
+// Records live under their own key and Redis expires them for us.
+func NewIdempotencyRedisRepository(client *redis.Client) domain.IdempotencyStore {
+	return &idempotencyRedisRepository{
+		client: client,
+	}
+}
+
+// idempotencyRedisKey builds the Redis key for a user's idempotency key
+func idempotencyRedisKey(userID int64, key string) string {
+	return fmt.Sprintf("idempotency:%d:%s", userID, key)
+}
+
+func (r *idempotencyRedisRepository) Reserve(ctx context.Context, record *domain.IdempotencyRecord) (*domain.IdempotencyRecord, error) {
+	record.Status = domain.IdempotencyInProgress
+	record.CreatedAt = time.Now()
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode idempotency record: %w", err)
+	}
+
+	redisKey := idempotencyRedisKey(record.UserID, record.Key)
+	reserved, err := r.client.SetNX(ctx, redisKey, data, time.Until(record.ExpiresAt)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+	if reserved {
+		return nil, nil
+	}
+
+	stored, err := r.client.Get(ctx, redisKey).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			// Expired between SETNX and GET, so try again
+			return r.Reserve(ctx, record)
+		}
+		return nil, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+
+	existing := &domain.IdempotencyRecord{}
+	if err := json.Unmarshal(stored, existing); err != nil {
+		return nil, fmt.Errorf("failed to decode idempotency record: %w", err)
+	}
+
+	return existing, nil
+}
+
+func (r *idempotencyRedisRepository) Complete(
+	ctx context.Context,
+	reserved *domain.IdempotencyRecord,
+	statusCode int,
+	body []byte,
+	ttl time.Duration,
+) error {
+	err := r.whileHeld(ctx, reserved, func(pipe redis.Pipeliner, redisKey string, record *domain.IdempotencyRecord) error {
+		record.Status = domain.IdempotencyCompleted
+		record.ResponseCode = statusCode
+		record.ResponseBody = body
+		record.ExpiresAt = time.Now().Add(ttl)
+
+		data, err := json.Marshal(record)
+		if err != nil {
+			return fmt.Errorf("failed to encode idempotency record: %w", err)
+		}
+
+		pipe.Set(ctx, redisKey, data, ttl)
+		return nil
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to complete idempotency key: %w", err)
+	}
+
+	return nil
+}
+
+func (r *idempotencyRedisRepository) Release(ctx context.Context, reserved *domain.IdempotencyRecord) error {
+	err := r.whileHeld(ctx, reserved, func(pipe redis.Pipeliner, redisKey string, record *domain.IdempotencyRecord) error {
+		pipe.Del(ctx, redisKey)
+		return nil
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+
+	return nil
+}
+
+// whileHeld queues fn's writes in a transaction that only commits if the key is
+// still the reservation reserved was given, watching the key so a retry that
+// re-reserves it in between makes the transaction fail
+func (r *idempotencyRedisRepository) whileHeld(
+	ctx context.Context,
+	reserved *domain.IdempotencyRecord,
+	fn func(pipe redis.Pipeliner, redisKey string, record *domain.IdempotencyRecord) error,
+) error {
+	redisKey := idempotencyRedisKey(reserved.UserID, reserved.Key)
+	lost := fmt.Errorf("%q is no longer reserved by this request", reserved.Key)
+
+	err := r.client.Watch(ctx, func(tx *redis.Tx) error {
+		stored, err := tx.Get(ctx, redisKey).Bytes()
+		if err != nil {
+			if errors.Is(err, redis.Nil) {
+				return lost
+			}
+			return fmt.Errorf("failed to get idempotency key: %w", err)
+		}
+
+		record := &domain.IdempotencyRecord{}
+		if err := json.Unmarshal(stored, record); err != nil {
+			return fmt.Errorf("failed to decode idempotency record: %w", err)
+		}
+		if !record.HeldBy(reserved) {
+			return lost
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			return fn(pipe, redisKey, record)
+		})
+		return err
+	}, redisKey)
+
+	if errors.Is(err, redis.TxFailedErr) {
+		return lost
+	}
+	return err
+}
@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/ravindu/wallet-app-service/internal/domain"
+)
+
+type idempotencyRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewIdempotencyRepository creates a PostgreSQL-backed idempotency store
+func NewIdempotencyRepository(db *pgxpool.Pool) domain.IdempotencyStore {
+	return &idempotencyRepository{
+		db: db,
+	}
+}
+
+func (r *idempotencyRepository) Reserve(ctx context.Context, record *domain.IdempotencyRecord) (*domain.IdempotencyRecord, error) {
+	// Postgres keeps microseconds, and Complete and Release match on created_at
+	now := time.Now().Truncate(time.Microsecond)
+	record.Status = domain.IdempotencyInProgress
+	record.CreatedAt = now
+
+	// Take the key if it's free or the previous holder has expired
+	query := `
+		INSERT INTO idempotency_keys (
+			user_id, idempotency_key, fingerprint, status,
+			response_code, response_body, created_at, expires_at
+		)
+		VALUES ($1, $2, $3, $4, NULL, NULL, $5, $6)
+		ON CONFLICT (user_id, idempotency_key) DO UPDATE
+		SET fingerprint = EXCLUDED.fingerprint,
+			status = EXCLUDED.status,
+			response_code = NULL,
+			response_body = NULL,
+			created_at = EXCLUDED.created_at,
+			expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at < $5
+		RETURNING user_id
+	`
+
+	var userID int64
+	err := conn(ctx, r.db).QueryRow(ctx, query,
+		record.UserID,
+		record.Key,
+		record.Fingerprint,
+		record.Status,
+		record.CreatedAt,
+		record.ExpiresAt,
+	).Scan(&userID)
+
+	if err == nil {
+		return nil, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+
+	// Someone else holds a live record for this key
+	query = `
+		SELECT user_id, idempotency_key, fingerprint, status,
+			COALESCE(response_code, 0), response_body, created_at, expires_at
+		FROM idempotency_keys
+		WHERE user_id = $1 AND idempotency_key = $2
+	`
+
+	existing := &domain.IdempotencyRecord{}
+	err = conn(ctx, r.db).QueryRow(ctx, query, record.UserID, record.Key).Scan(
+		&existing.UserID,
+		&existing.Key,
+		&existing.Fingerprint,
+		&existing.Status,
+		&existing.ResponseCode,
+		&existing.ResponseBody,
+		&existing.CreatedAt,
+		&existing.ExpiresAt,
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+
+	return existing, nil
+}
+
+func (r *idempotencyRepository) Complete(
+	ctx context.Context,
+	record *domain.IdempotencyRecord,
+	statusCode int,
+	body []byte,
+	ttl time.Duration,
+) error {
+	// Only touch the row while it is still this request's reservation
+	query := `
+		UPDATE idempotency_keys
+		SET status = $1, response_code = $2, response_body = $3, expires_at = $4
+		WHERE user_id = $5 AND idempotency_key = $6
+			AND fingerprint = $7 AND created_at = $8 AND status = $9
+	`
+
+	result, err := conn(ctx, r.db).Exec(ctx, query,
+		domain.IdempotencyCompleted,
+		statusCode,
+		body,
+		time.Now().Add(ttl),
+		record.UserID,
+		record.Key,
+		record.Fingerprint,
+		record.CreatedAt,
+		domain.IdempotencyInProgress,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to complete idempotency key: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("failed to complete idempotency key: %q is no longer reserved by this request", record.Key)
+	}
+
+	return nil
+}
+
+func (r *idempotencyRepository) Release(ctx context.Context, record *domain.IdempotencyRecord) error {
+	query := `
+		DELETE FROM idempotency_keys
+		WHERE user_id = $1 AND idempotency_key = $2
+			AND fingerprint = $3 AND created_at = $4 AND status = $5
+	`
+
+	result, err := conn(ctx, r.db).Exec(ctx, query,
+		record.UserID,
+		record.Key,
+		record.Fingerprint,
+		record.CreatedAt,
+		domain.IdempotencyInProgress,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("failed to release idempotency key: %q is no longer reserved by this request", record.Key)
+	}
+
+	return nil
+}
@@ -0,0 +1,219 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+)
+
+type ledgerRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewLedgerRepository creates a new PostgreSQL ledger repository
+func NewLedgerRepository(db *pgxpool.Pool) domain.LedgerRepository {
+	return &ledgerRepository{
+		db: db,
+	}
+}
+
+func (r *ledgerRepository) CreateAccount(ctx context.Context, account *domain.LedgerAccount) error {
+	account.CreatedAt = time.Now()
+
+	query := `
+		INSERT INTO ledger_accounts (code, type, wallet_id, currency, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`
+
+	err := conn(ctx, r.db).QueryRow(ctx, query,
+		account.Code,
+		account.Type,
+		account.WalletID,
+		account.Currency,
+		account.CreatedAt,
+	).Scan(&account.ID)
+
+	if err != nil {
+		return fmt.Errorf("failed to create ledger account: %w", err)
+	}
+
+	return nil
+}
+
+func (r *ledgerRepository) GetWalletAccount(ctx context.Context, walletID int64) (*domain.LedgerAccount, error) {
+	query := `
+		SELECT id, code, type, wallet_id, currency, created_at
+		FROM ledger_accounts
+		WHERE wallet_id = $1
+	`
+
+	account := &domain.LedgerAccount{}
+	err := conn(ctx, r.db).QueryRow(ctx, query, walletID).Scan(
+		&account.ID,
+		&account.Code,
+		&account.Type,
+		&account.WalletID,
+		&account.Currency,
+		&account.CreatedAt,
+	)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperrors.ErrResourceNotFound
+		}
+		return nil, fmt.Errorf("failed to get wallet ledger account: %w", err)
+	}
+
+	return account, nil
+}
+
+func (r *ledgerRepository) GetSystemAccount(ctx context.Context, code string, currency domain.Currency) (*domain.LedgerAccount, error) {
+	// Open the account on first use, then read whichever row won
+	query := `
+		INSERT INTO ledger_accounts (code, type, wallet_id, currency, created_at)
+		VALUES ($1, $2, NULL, $3, $4)
+		ON CONFLICT (code, currency) DO NOTHING
+	`
+
+	_, err := conn(ctx, r.db).Exec(ctx, query, code, domain.SystemAccountType, currency, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open system ledger account: %w", err)
+	}
+
+	query = `
+		SELECT id, code, type, wallet_id, currency, created_at
+		FROM ledger_accounts
+		WHERE code = $1 AND currency = $2 AND type = $3
+	`
+
+	account := &domain.LedgerAccount{}
+	err = conn(ctx, r.db).QueryRow(ctx, query, code, currency, domain.SystemAccountType).Scan(
+		&account.ID,
+		&account.Code,
+		&account.Type,
+		&account.WalletID,
+		&account.Currency,
+		&account.CreatedAt,
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get system ledger account: %w", err)
+	}
+
+	return account, nil
+}
+
+func (r *ledgerRepository) CreateEntry(ctx context.Context, entry *domain.JournalEntry) error {
+	if err := entry.Validate(); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	entry.CreatedAt = now
+
+	query := `
+		INSERT INTO journal_entries (type, description, created_at)
+		VALUES ($1, $2, $3)
+		RETURNING id
+	`
+
+	err := conn(ctx, r.db).QueryRow(ctx, query,
+		entry.Type,
+		entry.Description,
+		entry.CreatedAt,
+	).Scan(&entry.ID)
+
+	if err != nil {
+		return fmt.Errorf("failed to create journal entry: %w", err)
+	}
+
+	query = `
+		INSERT INTO postings (journal_entry_id, account_id, amount, currency, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`
+
+	for i := range entry.Postings {
+		posting := &entry.Postings[i]
+		posting.JournalEntryID = entry.ID
+		posting.CreatedAt = now
+
+		err := conn(ctx, r.db).QueryRow(ctx, query,
+			posting.JournalEntryID,
+			posting.AccountID,
+			posting.Amount,
+			posting.Currency,
+			posting.CreatedAt,
+		).Scan(&posting.ID)
+
+		if err != nil {
+			return fmt.Errorf("failed to create posting: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (r *ledgerRepository) GetAccountBalances(ctx context.Context) ([]*domain.AccountBalance, error) {
+	query := `
+		SELECT
+			a.id, a.code, a.type, a.wallet_id, a.currency, a.created_at,
+			COALESCE(SUM(p.amount), 0)
+		FROM ledger_accounts a
+		LEFT JOIN postings p ON p.account_id = a.id
+		GROUP BY a.id
+		ORDER BY a.currency, a.type DESC, a.code
+	`
+
+	rows, err := conn(ctx, r.db).Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account balances: %w", err)
+	}
+	defer rows.Close()
+
+	balances := make([]*domain.AccountBalance, 0)
+	for rows.Next() {
+		balance := &domain.AccountBalance{Account: &domain.LedgerAccount{}}
+		err := rows.Scan(
+			&balance.Account.ID,
+			&balance.Account.Code,
+			&balance.Account.Type,
+			&balance.Account.WalletID,
+			&balance.Account.Currency,
+			&balance.Account.CreatedAt,
+			&balance.Balance,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan account balance row: %w", err)
+		}
+		balances = append(balances, balance)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating account balance rows: %w", err)
+	}
+
+	return balances, nil
+}
+
+func (r *ledgerRepository) GetAccountBalance(ctx context.Context, accountID int64) (domain.Amount, error) {
+	query := `
+		SELECT COALESCE(SUM(amount), 0)
+		FROM postings
+		WHERE account_id = $1
+	`
+
+	var balance domain.Amount
+	if err := conn(ctx, r.db).QueryRow(ctx, query, accountID).Scan(&balance); err != nil {
+		return 0, fmt.Errorf("failed to get account balance: %w", err)
+	}
+
+	return balance, nil
+}
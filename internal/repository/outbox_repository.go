@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/ravindu/wallet-app-service/internal/domain"
+)
+
+// outboxRelayLock names the advisory lock that keeps a single relay running
+const outboxRelayLock = "outbox_relay"
+
+type outboxRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewOutboxRepository creates a new PostgreSQL outbox repository
+func NewOutboxRepository(db *pgxpool.Pool) domain.OutboxRepository {
+	return &outboxRepository{
+		db: db,
+	}
+}
+
+func (r *outboxRepository) Create(ctx context.Context, event *domain.OutboxEvent) error {
+	now := time.Now()
+	event.CreatedAt = now
+	event.NextAttemptAt = now
+
+	query := `
+		INSERT INTO outbox_events (aggregate_type, aggregate_id, event_type, payload, created_at, next_attempt_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`
+
+	err := conn(ctx, r.db).QueryRow(ctx, query,
+		event.AggregateType,
+		event.AggregateID,
+		event.Type,
+		event.Payload,
+		event.CreatedAt,
+		event.NextAttemptAt,
+	).Scan(&event.ID)
+
+	if err != nil {
+		return fmt.Errorf("failed to create outbox event: %w", err)
+	}
+
+	return nil
+}
+
+func (r *outboxRepository) TryLock(ctx context.Context) (bool, error) {
+	var locked bool
+	err := conn(ctx, r.db).QueryRow(ctx, "SELECT pg_try_advisory_xact_lock(hashtext($1))", outboxRelayLock).Scan(&locked)
+	if err != nil {
+		return false, fmt.Errorf("failed to take outbox relay lock: %w", err)
+	}
+	return locked, nil
+}
+
+func (r *outboxRepository) FetchPending(ctx context.Context, limit int) ([]*domain.OutboxEvent, error) {
+	// An event waits while an earlier event of its aggregate is backing off,
+	// so consumers see each wallet's events in order
+	query := `
+		SELECT e.id, e.aggregate_type, e.aggregate_id, e.event_type, e.payload, e.created_at,
+		       e.attempts, COALESCE(e.last_error, ''), e.next_attempt_at
+		FROM outbox_events e
+		WHERE e.published_at IS NULL
+		  AND e.next_attempt_at <= $1
+		  AND NOT EXISTS (
+		      SELECT 1 FROM outbox_events earlier
+		      WHERE earlier.aggregate_type = e.aggregate_type
+		        AND earlier.aggregate_id = e.aggregate_id
+		        AND earlier.id < e.id
+		        AND earlier.published_at IS NULL
+		        AND earlier.next_attempt_at > $1
+		  )
+		ORDER BY e.id
+		LIMIT $2
+	`
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, time.Now(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch pending outbox events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*domain.OutboxEvent
+	for rows.Next() {
+		event := &domain.OutboxEvent{}
+		err := rows.Scan(
+			&event.ID,
+			&event.AggregateType,
+			&event.AggregateID,
+			&event.Type,
+			&event.Payload,
+			&event.CreatedAt,
+			&event.Attempts,
+			&event.LastError,
+			&event.NextAttemptAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan outbox event: %w", err)
+		}
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating outbox events: %w", err)
+	}
+
+	return events, nil
+}
+
+func (r *outboxRepository) MarkPublished(ctx context.Context, id int64) error {
+	query := `
+		UPDATE outbox_events
+		SET published_at = $1, attempts = attempts + 1, last_error = NULL
+		WHERE id = $2
+	`
+
+	if _, err := conn(ctx, r.db).Exec(ctx, query, time.Now(), id); err != nil {
+		return fmt.Errorf("failed to mark outbox event published: %w", err)
+	}
+
+	return nil
+}
+
+func (r *outboxRepository) MarkFailed(ctx context.Context, id int64, reason string, nextAttemptAt time.Time) error {
+	query := `
+		UPDATE outbox_events
+		SET attempts = attempts + 1, last_error = $1, next_attempt_at = $2
+		WHERE id = $3
+	`
+
+	if _, err := conn(ctx, r.db).Exec(ctx, query, reason, nextAttemptAt, id); err != nil {
+		return fmt.Errorf("failed to mark outbox event failed: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,213 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+)
+
+// paymentRequestColumns are the payment_requests columns read by paymentRequestFields
+const paymentRequestColumns = `id, requester_id, payer_id, wallet_id, currency, amount, COALESCE(memo, ''),
+	status, transaction_id, expires_at, created_at, updated_at`
+
+// paymentRequestWhere matches a user's requests under a PaymentRequestFilter,
+// given the user as $1, the direction as $2 and the status as $3
+const paymentRequestWhere = `
+	((requester_id = $1 AND $2::text <> 'incoming') OR (payer_id = $1 AND $2::text <> 'outgoing'))
+	AND ($3::text = '' OR status = $3)
+`
+
+type paymentRequestRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewPaymentRequestRepository creates a new PostgreSQL payment request repository
+func NewPaymentRequestRepository(db *pgxpool.Pool) domain.PaymentRequestRepository {
+	return &paymentRequestRepository{
+		db: db,
+	}
+}
+
+func (r *paymentRequestRepository) Create(ctx context.Context, request *domain.PaymentRequest) error {
+	now := time.Now()
+	request.CreatedAt = now
+	request.UpdatedAt = now
+
+	query := `
+		INSERT INTO payment_requests (
+			requester_id, payer_id, wallet_id, currency, amount, memo, status,
+			expires_at, created_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, $8, $9, $10)
+		RETURNING id
+	`
+
+	err := conn(ctx, r.db).QueryRow(ctx, query,
+		request.RequesterID,
+		request.PayerID,
+		request.WalletID,
+		string(request.Currency),
+		request.Amount,
+		request.Memo,
+		request.Status,
+		request.ExpiresAt,
+		request.CreatedAt,
+		request.UpdatedAt,
+	).Scan(&request.ID)
+
+	if err != nil {
+		return fmt.Errorf("failed to create payment request: %w", err)
+	}
+
+	return nil
+}
+
+func (r *paymentRequestRepository) GetByID(ctx context.Context, id int64) (*domain.PaymentRequest, error) {
+	query := `SELECT ` + paymentRequestColumns + ` FROM payment_requests WHERE id = $1`
+	return r.getOne(ctx, query, id)
+}
+
+func (r *paymentRequestRepository) GetByIDForUpdate(ctx context.Context, id int64) (*domain.PaymentRequest, error) {
+	query := `SELECT ` + paymentRequestColumns + ` FROM payment_requests WHERE id = $1 FOR UPDATE`
+	return r.getOne(ctx, query, id)
+}
+
+// getOne runs a single-request query and scans the row
+func (r *paymentRequestRepository) getOne(ctx context.Context, query string, args ...any) (*domain.PaymentRequest, error) {
+	request := &domain.PaymentRequest{}
+	err := conn(ctx, r.db).QueryRow(ctx, query, args...).Scan(paymentRequestFields(request)...)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperrors.ErrResourceNotFound
+		}
+		return nil, fmt.Errorf("failed to get payment request: %w", err)
+	}
+
+	return request, nil
+}
+
+func (r *paymentRequestRepository) ListByUserID(
+	ctx context.Context,
+	userID int64,
+	filter domain.PaymentRequestFilter,
+	limit, offset int,
+) ([]*domain.PaymentRequest, error) {
+	query := `
+		SELECT ` + paymentRequestColumns + `
+		FROM payment_requests
+		WHERE ` + paymentRequestWhere + `
+		ORDER BY created_at DESC, id DESC
+		LIMIT $4 OFFSET $5
+	`
+
+	rows, err := conn(ctx, r.db).Query(ctx, query,
+		userID, string(filter.Direction), string(filter.Status), limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list payment requests: %w", err)
+	}
+	defer rows.Close()
+
+	var requests []*domain.PaymentRequest
+	for rows.Next() {
+		request := &domain.PaymentRequest{}
+		if err := rows.Scan(paymentRequestFields(request)...); err != nil {
+			return nil, fmt.Errorf("failed to scan payment request: %w", err)
+		}
+		requests = append(requests, request)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating payment requests: %w", err)
+	}
+
+	return requests, nil
+}
+
+func (r *paymentRequestRepository) CountByUserID(ctx context.Context, userID int64, filter domain.PaymentRequestFilter) (int, error) {
+	query := `SELECT COUNT(*) FROM payment_requests WHERE ` + paymentRequestWhere
+
+	var count int
+	err := conn(ctx, r.db).QueryRow(ctx, query, userID, string(filter.Direction), string(filter.Status)).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count payment requests: %w", err)
+	}
+	return count, nil
+}
+
+func (r *paymentRequestRepository) Update(ctx context.Context, request *domain.PaymentRequest) error {
+	request.UpdatedAt = time.Now()
+
+	query := `
+		UPDATE payment_requests
+		SET status = $1, transaction_id = $2, updated_at = $3
+		WHERE id = $4
+	`
+
+	_, err := conn(ctx, r.db).Exec(ctx, query,
+		request.Status,
+		request.TransactionID,
+		request.UpdatedAt,
+		request.ID,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to update payment request: %w", err)
+	}
+
+	return nil
+}
+
+func (r *paymentRequestRepository) ListExpiredIDs(ctx context.Context, now time.Time, limit int) ([]int64, error) {
+	query := `
+		SELECT id
+		FROM payment_requests
+		WHERE status = $1 AND expires_at <= $2
+		ORDER BY expires_at
+		LIMIT $3
+	`
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, domain.PaymentRequestPending, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expired payment requests: %w", err)
+	}
+	defer rows.Close()
+
+	ids := make([]int64, 0)
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan payment request row: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating payment request rows: %w", err)
+	}
+
+	return ids, nil
+}
+
+// paymentRequestFields lists scan targets matching paymentRequestColumns
+func paymentRequestFields(request *domain.PaymentRequest) []any {
+	return []any{
+		&request.ID,
+		&request.RequesterID,
+		&request.PayerID,
+		&request.WalletID,
+		&request.Currency,
+		&request.Amount,
+		&request.Memo,
+		&request.Status,
+		&request.TransactionID,
+		&request.ExpiresAt,
+		&request.CreatedAt,
+		&request.UpdatedAt,
+	}
+}
@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+)
+
+type reconciliationRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewReconciliationRepository creates a new PostgreSQL reconciliation repository
+func NewReconciliationRepository(db *pgxpool.Pool) domain.ReconciliationRepository {
+	return &reconciliationRepository{
+		db: db,
+	}
+}
+
+func (r *reconciliationRepository) ListWalletIDs(ctx context.Context, afterWalletID int64, limit int) ([]int64, error) {
+	query := `
+		SELECT id
+		FROM wallets
+		WHERE id > $1
+		ORDER BY id
+		LIMIT $2
+	`
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, afterWalletID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list wallets: %w", err)
+	}
+	defer rows.Close()
+
+	ids := make([]int64, 0)
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan wallet row: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating wallet rows: %w", err)
+	}
+
+	return ids, nil
+}
+
+func (r *reconciliationRepository) StreamBalanceChain(ctx context.Context, walletID int64, fn func(domain.BalanceLink) error) error {
+	// IDs are handed out while the wallet row is locked, so they follow the order
+	// the balance changes were made in even where clocks disagree
+	query := `
+		SELECT id, balance_before, balance_after
+		FROM transactions
+		WHERE wallet_id = $1
+		ORDER BY id
+	`
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, walletID)
+	if err != nil {
+		return fmt.Errorf("failed to stream balance chain: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var link domain.BalanceLink
+		if err := rows.Scan(&link.TransactionID, &link.BalanceBefore, &link.BalanceAfter); err != nil {
+			return fmt.Errorf("failed to scan transaction row: %w", err)
+		}
+		if err := fn(link); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating transaction rows: %w", err)
+	}
+
+	return nil
+}
+
+func (r *reconciliationRepository) GetLastLink(ctx context.Context, walletID int64) (*domain.BalanceLink, error) {
+	query := `
+		SELECT id, balance_before, balance_after
+		FROM transactions
+		WHERE wallet_id = $1
+		ORDER BY id DESC
+		LIMIT 1
+	`
+
+	link := &domain.BalanceLink{}
+	err := conn(ctx, r.db).QueryRow(ctx, query, walletID).Scan(&link.TransactionID, &link.BalanceBefore, &link.BalanceAfter)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperrors.ErrResourceNotFound
+		}
+		return nil, fmt.Errorf("failed to get last transaction: %w", err)
+	}
+
+	return link, nil
+}
+
+func (r *reconciliationRepository) CreateCorrection(ctx context.Context, correction *domain.BalanceCorrection) error {
+	correction.CreatedAt = time.Now()
+
+	query := `
+		INSERT INTO balance_corrections (wallet_id, old_balance, new_balance, reason, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`
+
+	err := conn(ctx, r.db).QueryRow(ctx, query,
+		correction.WalletID,
+		correction.OldBalance,
+		correction.NewBalance,
+		correction.Reason,
+		correction.CreatedAt,
+	).Scan(&correction.ID)
+
+	if err != nil {
+		return fmt.Errorf("failed to create balance correction: %w", err)
+	}
+
+	return nil
+}
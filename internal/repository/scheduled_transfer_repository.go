@@ -0,0 +1,305 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+)
+
+// scheduleColumns are the scheduled_transfers columns read by scheduleFields
+const scheduleColumns = `id, user_id, receiver_id, COALESCE(sender_wallet_id, 0), COALESCE(receiver_wallet_id, 0),
+	COALESCE(currency, ''), COALESCE(receiver_currency, ''), amount, COALESCE(comment, ''), convert,
+	COALESCE(cron_expr, ''), COALESCE(repeat_interval, ''), timezone, start_at, ends_at,
+	on_insufficient_funds, max_retries, status, next_run_at, next_attempt_at, attempts,
+	COALESCE(last_error, ''), created_at, updated_at`
+
+// executionColumns are the schedule_executions columns read by executionFields
+const executionColumns = `id, schedule_id, scheduled_for, attempt, status, transaction_id, COALESCE(error, ''), executed_at`
+
+type scheduledTransferRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewScheduledTransferRepository creates a new PostgreSQL scheduled transfer repository
+func NewScheduledTransferRepository(db *pgxpool.Pool) domain.ScheduledTransferRepository {
+	return &scheduledTransferRepository{
+		db: db,
+	}
+}
+
+func (r *scheduledTransferRepository) Create(ctx context.Context, schedule *domain.ScheduledTransfer) error {
+	now := time.Now()
+	schedule.CreatedAt = now
+	schedule.UpdatedAt = now
+
+	query := `
+		INSERT INTO scheduled_transfers (
+			user_id, receiver_id, sender_wallet_id, receiver_wallet_id, currency, receiver_currency,
+			amount, comment, convert, cron_expr, repeat_interval, timezone, start_at, ends_at,
+			on_insufficient_funds, max_retries, status, next_run_at, next_attempt_at, attempts,
+			created_at, updated_at
+		)
+		VALUES (
+			$1, $2, NULLIF($3, 0), NULLIF($4, 0), NULLIF($5, ''), NULLIF($6, ''),
+			$7, NULLIF($8, ''), $9, NULLIF($10, ''), NULLIF($11, ''), $12, $13, $14,
+			$15, $16, $17, $18, $19, $20, $21, $22
+		)
+		RETURNING id
+	`
+
+	err := conn(ctx, r.db).QueryRow(ctx, query,
+		schedule.UserID,
+		schedule.ReceiverID,
+		schedule.SenderWalletID,
+		schedule.ReceiverWalletID,
+		string(schedule.Currency),
+		string(schedule.ReceiverCurrency),
+		schedule.Amount,
+		schedule.Comment,
+		schedule.Convert,
+		schedule.Cron,
+		schedule.Interval,
+		schedule.Timezone,
+		schedule.StartAt,
+		schedule.EndsAt,
+		schedule.OnInsufficientFunds,
+		schedule.MaxRetries,
+		schedule.Status,
+		schedule.NextRunAt,
+		schedule.NextAttemptAt,
+		schedule.Attempts,
+		schedule.CreatedAt,
+		schedule.UpdatedAt,
+	).Scan(&schedule.ID)
+
+	if err != nil {
+		return fmt.Errorf("failed to create scheduled transfer: %w", err)
+	}
+
+	return nil
+}
+
+func (r *scheduledTransferRepository) GetByID(ctx context.Context, id int64) (*domain.ScheduledTransfer, error) {
+	query := `SELECT ` + scheduleColumns + ` FROM scheduled_transfers WHERE id = $1`
+	return r.getOne(ctx, query, id)
+}
+
+func (r *scheduledTransferRepository) GetByIDForUpdate(ctx context.Context, id int64) (*domain.ScheduledTransfer, error) {
+	query := `SELECT ` + scheduleColumns + ` FROM scheduled_transfers WHERE id = $1 FOR UPDATE`
+	return r.getOne(ctx, query, id)
+}
+
+// getOne runs a single-schedule query and scans the row
+func (r *scheduledTransferRepository) getOne(ctx context.Context, query string, args ...any) (*domain.ScheduledTransfer, error) {
+	schedule := &domain.ScheduledTransfer{}
+	err := conn(ctx, r.db).QueryRow(ctx, query, args...).Scan(scheduleFields(schedule)...)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperrors.ErrResourceNotFound
+		}
+		return nil, fmt.Errorf("failed to get scheduled transfer: %w", err)
+	}
+
+	return schedule, nil
+}
+
+func (r *scheduledTransferRepository) ListByUserID(ctx context.Context, userID int64) ([]*domain.ScheduledTransfer, error) {
+	query := `
+		SELECT ` + scheduleColumns + `
+		FROM scheduled_transfers
+		WHERE user_id = $1
+		ORDER BY id DESC
+	`
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scheduled transfers: %w", err)
+	}
+
+	return scanSchedules(rows)
+}
+
+func (r *scheduledTransferRepository) Update(ctx context.Context, schedule *domain.ScheduledTransfer) error {
+	schedule.UpdatedAt = time.Now()
+
+	query := `
+		UPDATE scheduled_transfers
+		SET status = $1, next_run_at = $2, next_attempt_at = $3, attempts = $4,
+		    last_error = NULLIF($5, ''), updated_at = $6
+		WHERE id = $7
+	`
+
+	_, err := conn(ctx, r.db).Exec(ctx, query,
+		schedule.Status,
+		schedule.NextRunAt,
+		schedule.NextAttemptAt,
+		schedule.Attempts,
+		schedule.LastError,
+		schedule.UpdatedAt,
+		schedule.ID,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to update scheduled transfer: %w", err)
+	}
+
+	return nil
+}
+
+func (r *scheduledTransferRepository) ClaimDue(ctx context.Context, limit int, leaseUntil time.Time) ([]*domain.ScheduledTransfer, error) {
+	// SKIP LOCKED lets several schedulers claim disjoint batches
+	query := `
+		UPDATE scheduled_transfers
+		SET next_attempt_at = $1, updated_at = $2
+		WHERE id IN (
+		    SELECT id FROM scheduled_transfers
+		    WHERE status = $3 AND next_attempt_at <= $2
+		    ORDER BY next_attempt_at, id
+		    LIMIT $4
+		    FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + scheduleColumns
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, leaseUntil, time.Now(), domain.ScheduleActive, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim scheduled transfers: %w", err)
+	}
+
+	return scanSchedules(rows)
+}
+
+func (r *scheduledTransferRepository) CreateExecution(ctx context.Context, execution *domain.ScheduleExecution) error {
+	query := `
+		INSERT INTO schedule_executions (
+			schedule_id, scheduled_for, attempt, status, transaction_id, error, executed_at
+		)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7)
+		RETURNING id
+	`
+
+	err := conn(ctx, r.db).QueryRow(ctx, query,
+		execution.ScheduleID,
+		execution.ScheduledFor,
+		execution.Attempt,
+		execution.Status,
+		execution.TransactionID,
+		execution.Error,
+		execution.ExecutedAt,
+	).Scan(&execution.ID)
+
+	if err != nil {
+		return fmt.Errorf("failed to create schedule execution: %w", err)
+	}
+
+	return nil
+}
+
+func (r *scheduledTransferRepository) ListExecutions(ctx context.Context, scheduleID int64, limit, offset int) ([]*domain.ScheduleExecution, error) {
+	query := `
+		SELECT ` + executionColumns + `
+		FROM schedule_executions
+		WHERE schedule_id = $1
+		ORDER BY id DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, scheduleID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list schedule executions: %w", err)
+	}
+	defer rows.Close()
+
+	var executions []*domain.ScheduleExecution
+	for rows.Next() {
+		execution := &domain.ScheduleExecution{}
+		if err := rows.Scan(executionFields(execution)...); err != nil {
+			return nil, fmt.Errorf("failed to scan schedule execution: %w", err)
+		}
+		executions = append(executions, execution)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating schedule executions: %w", err)
+	}
+
+	return executions, nil
+}
+
+func (r *scheduledTransferRepository) CountExecutions(ctx context.Context, scheduleID int64) (int, error) {
+	var count int
+	err := conn(ctx, r.db).QueryRow(ctx, "SELECT COUNT(*) FROM schedule_executions WHERE schedule_id = $1", scheduleID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count schedule executions: %w", err)
+	}
+	return count, nil
+}
+
+// scanSchedules reads and closes rows of scheduleColumns
+func scanSchedules(rows pgx.Rows) ([]*domain.ScheduledTransfer, error) {
+	defer rows.Close()
+
+	var schedules []*domain.ScheduledTransfer
+	for rows.Next() {
+		schedule := &domain.ScheduledTransfer{}
+		if err := rows.Scan(scheduleFields(schedule)...); err != nil {
+			return nil, fmt.Errorf("failed to scan scheduled transfer: %w", err)
+		}
+		schedules = append(schedules, schedule)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating scheduled transfers: %w", err)
+	}
+
+	return schedules, nil
+}
+
+// scheduleFields lists scan targets matching scheduleColumns
+func scheduleFields(schedule *domain.ScheduledTransfer) []any {
+	return []any{
+		&schedule.ID,
+		&schedule.UserID,
+		&schedule.ReceiverID,
+		&schedule.SenderWalletID,
+		&schedule.ReceiverWalletID,
+		&schedule.Currency,
+		&schedule.ReceiverCurrency,
+		&schedule.Amount,
+		&schedule.Comment,
+		&schedule.Convert,
+		&schedule.Cron,
+		&schedule.Interval,
+		&schedule.Timezone,
+		&schedule.StartAt,
+		&schedule.EndsAt,
+		&schedule.OnInsufficientFunds,
+		&schedule.MaxRetries,
+		&schedule.Status,
+		&schedule.NextRunAt,
+		&schedule.NextAttemptAt,
+		&schedule.Attempts,
+		&schedule.LastError,
+		&schedule.CreatedAt,
+		&schedule.UpdatedAt,
+	}
+}
+
+// executionFields lists scan targets matching executionColumns
+func executionFields(execution *domain.ScheduleExecution) []any {
+	return []any{
+		&execution.ID,
+		&execution.ScheduleID,
+		&execution.ScheduledFor,
+		&execution.Attempt,
+		&execution.Status,
+		&execution.TransactionID,
+		&execution.Error,
+		&execution.ExecutedAt,
+	}
+}
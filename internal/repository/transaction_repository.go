@@ -2,13 +2,31 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/ravindu/wallet-app-service/internal/domain"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
 )
 
+// transactionColumns lists the columns scanTransaction expects, in order
+const transactionColumns = `
+	id, wallet_id, dest_wallet_id, type,
+	amount, balance_before, balance_after,
+	description, journal_entry_id, COALESCE(correlation_id::text, ''),
+	counterparty_wallet_id, counterparty_user_id, original_transaction_id,
+	reversed_amount, COALESCE(reversal_status, ''), COALESCE(fx_quote_id::text, ''),
+	fx_rate, source_amount, target_amount, fee, COALESCE(fee_version, ''),
+	parent_transaction_id, transaction_time, created_at
+`
+
 type transactionRepository struct {
 	db *pgxpool.Pool
 }
@@ -21,21 +39,28 @@ func NewTransactionRepository(db *pgxpool.Pool) domain.TransactionRepository {
 }
 
 func (r *transactionRepository) Create(ctx context.Context, transaction *domain.Transaction) error {
-	now := time.Now()
+	now := time.Now().UTC()
 	transaction.CreatedAt = now
 	transaction.TransactionTime = now
 
 	query := `
 		INSERT INTO transactions (
-			wallet_id, dest_wallet_id, type, amount, 
-			balance_before, balance_after, description, 
+			wallet_id, dest_wallet_id, type, amount,
+			balance_before, balance_after, description,
+			journal_entry_id, correlation_id, counterparty_wallet_id,
+			counterparty_user_id, original_transaction_id, fx_quote_id, fx_rate,
+			source_amount, target_amount, fee, fee_version, parent_transaction_id,
 			transaction_time, created_at
 		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, '')::uuid, $10,
+			$11, $12, NULLIF($13, '')::uuid, $14, $15, $16, $17, NULLIF($18, ''), $19,
+			$20, $21
+		)
 		RETURNING id
 	`
 
-	err := r.db.QueryRow(ctx, query,
+	err := conn(ctx, r.db).QueryRow(ctx, query,
 		transaction.WalletID,
 		transaction.DestWalletID,
 		transaction.Type,
@@ -43,6 +68,18 @@ func (r *transactionRepository) Create(ctx context.Context, transaction *domain.
 		transaction.BalanceBefore,
 		transaction.BalanceAfter,
 		transaction.Description,
+		transaction.JournalEntryID,
+		transaction.CorrelationID,
+		transaction.CounterpartyWalletID,
+		transaction.CounterpartyUserID,
+		transaction.OriginalTransactionID,
+		transaction.FXQuoteID,
+		transaction.FXRate,
+		transaction.SourceAmount,
+		transaction.TargetAmount,
+		transaction.Fee,
+		transaction.FeeVersion,
+		transaction.ParentTransactionID,
 		transaction.TransactionTime,
 		transaction.CreatedAt,
 	).Scan(&transaction.ID)
@@ -54,39 +91,273 @@ func (r *transactionRepository) Create(ctx context.Context, transaction *domain.
 	return nil
 }
 
-func (r *transactionRepository) GetByWalletID(ctx context.Context, walletID int64, limit, offset int) ([]*domain.Transaction, error) {
+func (r *transactionRepository) GetByWalletID(ctx context.Context, walletID int64, filter domain.TransactionFilter, limit, offset int) ([]*domain.Transaction, error) {
+	q := newTransactionQuery(walletID, filter)
+	order := sortDirection(filter.Descending())
+
+	query := `
+		SELECT ` + transactionColumns + `
+		FROM transactions
+		WHERE ` + q.where() + `
+		ORDER BY transaction_time ` + order + `, id ` + order + `
+		LIMIT ` + q.arg(limit) + ` OFFSET ` + q.arg(offset)
+
+	return r.getMany(ctx, query, q.args...)
+}
+
+func (r *transactionRepository) GetPageByWalletID(
+	ctx context.Context,
+	walletID int64,
+	filter domain.TransactionFilter,
+	cursor domain.TransactionCursor,
+	limit int,
+) ([]*domain.Transaction, error) {
+	q := newTransactionQuery(walletID, filter)
+
+	// Rows past the cursor in listing order for a next cursor. For a prev cursor
+	// the rows before it are read walking away from it, so the limit keeps the
+	// ones right next to it, and then put back in listing order.
+	forward := cursor.Direction == domain.CursorNext
+	older := forward == filter.Descending()
+	comparison := ">"
+	if older {
+		comparison = "<"
+	}
+	order := sortDirection(older)
+
+	query := `
+		SELECT ` + transactionColumns + `
+		FROM transactions
+		WHERE ` + q.where() + `
+		AND (transaction_time, id) ` + comparison + ` (` + q.arg(cursor.Time.UTC()) + `, ` + q.arg(cursor.ID) + `)
+		ORDER BY transaction_time ` + order + `, id ` + order + `
+		LIMIT ` + q.arg(limit)
+
+	transactions, err := r.getMany(ctx, query, q.args...)
+	if err != nil {
+		return nil, err
+	}
+
+	if !forward {
+		slices.Reverse(transactions)
+	}
+
+	return transactions, nil
+}
+
+func (r *transactionRepository) CountByWalletID(ctx context.Context, walletID int64, filter domain.TransactionFilter) (int, error) {
+	q := newTransactionQuery(walletID, filter)
+
+	query := `
+		SELECT COUNT(*)
+		FROM transactions
+		WHERE ` + q.where()
+
+	var count int
+	err := conn(ctx, r.db).QueryRow(ctx, query, q.args...).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count transactions: %w", err)
+	}
+
+	return count, nil
+}
+
+func (r *transactionRepository) StreamByWalletID(
+	ctx context.Context,
+	walletID int64,
+	filter domain.TransactionFilter,
+	fn func(*domain.Transaction) error,
+) error {
+	q := newTransactionQuery(walletID, filter)
+	order := sortDirection(filter.Descending())
+
+	query := `
+		SELECT ` + transactionColumns + `
+		FROM transactions
+		WHERE ` + q.where() + `
+		ORDER BY transaction_time ` + order + `, id ` + order
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, q.args...)
+	if err != nil {
+		return fmt.Errorf("failed to stream transactions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		tr, err := scanTransaction(rows)
+		if err != nil {
+			return fmt.Errorf("failed to scan transaction row: %w", err)
+		}
+		if err := fn(tr); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating transaction rows: %w", err)
+	}
+
+	return nil
+}
+
+func (r *transactionRepository) GetLastBetween(ctx context.Context, walletID int64, from, to time.Time) (*domain.Transaction, error) {
+	query := `
+		SELECT ` + transactionColumns + `
+		FROM transactions
+		WHERE wallet_id = $1 AND transaction_time >= $2 AND transaction_time < $3
+		ORDER BY transaction_time DESC, id DESC
+		LIMIT 1
+	`
+
+	return r.getOne(ctx, query, walletID, from.UTC(), to.UTC())
+}
+
+func (r *transactionRepository) GetFirstFrom(ctx context.Context, walletID int64, at time.Time) (*domain.Transaction, error) {
+	query := `
+		SELECT ` + transactionColumns + `
+		FROM transactions
+		WHERE wallet_id = $1 AND transaction_time >= $2
+		ORDER BY transaction_time ASC, id ASC
+		LIMIT 1
+	`
+
+	return r.getOne(ctx, query, walletID, at.UTC())
+}
+
+func (r *transactionRepository) GetByIDForUpdate(ctx context.Context, id int64) (*domain.Transaction, error) {
+	query := `
+		SELECT ` + transactionColumns + `
+		FROM transactions
+		WHERE id = $1
+		FOR UPDATE
+	`
+
+	tr, err := scanTransaction(conn(ctx, r.db).QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperrors.ErrResourceNotFound
+		}
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == lockNotAvailableCode {
+			return nil, apperrors.ErrLockAcquisitionFailed
+		}
+		return nil, fmt.Errorf("failed to get transaction: %w", err)
+	}
+
+	return tr, nil
+}
+
+func (r *transactionRepository) GetByCorrelationIDForUpdate(ctx context.Context, correlationID string) ([]*domain.Transaction, error) {
+	query := `
+		SELECT ` + transactionColumns + `
+		FROM transactions
+		WHERE correlation_id = $1::uuid
+		ORDER BY id
+		FOR UPDATE
+	`
+
+	return r.getMany(ctx, query, correlationID)
+}
+
+func (r *transactionRepository) GetChildForUpdate(ctx context.Context, parentID int64, transactionType domain.TransactionType) (*domain.Transaction, error) {
+	query := `
+		SELECT ` + transactionColumns + `
+		FROM transactions
+		WHERE parent_transaction_id = $1 AND type = $2
+		FOR UPDATE
+	`
+
+	tr, err := scanTransaction(conn(ctx, r.db).QueryRow(ctx, query, parentID, transactionType))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperrors.ErrResourceNotFound
+		}
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == lockNotAvailableCode {
+			return nil, apperrors.ErrLockAcquisitionFailed
+		}
+		return nil, fmt.Errorf("failed to get child transaction: %w", err)
+	}
+
+	return tr, nil
+}
+
+func (r *transactionRepository) UpdateReversal(ctx context.Context, transaction *domain.Transaction) error {
+	query := `
+		UPDATE transactions
+		SET reversed_amount = $1, reversal_status = NULLIF($2, '')
+		WHERE id = $3
+	`
+
+	tag, err := conn(ctx, r.db).Exec(ctx, query,
+		transaction.ReversedAmount,
+		transaction.ReversalStatus,
+		transaction.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update transaction reversal: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return apperrors.ErrResourceNotFound
+	}
+
+	return nil
+}
+
+func (r *transactionRepository) GetUsageSince(
+	ctx context.Context,
+	walletID int64,
+	types []domain.TransactionType,
+	since time.Time,
+) (domain.LimitUsage, error) {
+	typeNames := make([]string, 0, len(types))
+	for _, transactionType := range types {
+		typeNames = append(typeNames, string(transactionType))
+	}
+
 	query := `
-		SELECT 
-			id, wallet_id, dest_wallet_id, type, 
-			amount, balance_before, balance_after, 
-			description, transaction_time, created_at
+		SELECT COALESCE(SUM(amount), 0), COUNT(*)
 		FROM transactions
-		WHERE wallet_id = $1
-		ORDER BY transaction_time DESC
-		LIMIT $2 OFFSET $3
+		WHERE wallet_id = $1 AND type = ANY($2) AND transaction_time >= $3
 	`
 
-	rows, err := r.db.Query(ctx, query, walletID, limit, offset)
+	var usage domain.LimitUsage
+	err := conn(ctx, r.db).QueryRow(ctx, query, walletID, typeNames, since.UTC()).Scan(&usage.Amount, &usage.Count)
 	if err != nil {
+		return usage, fmt.Errorf("failed to get transaction usage: %w", err)
+	}
+
+	return usage, nil
+}
+
+// getOne runs a single-row transaction query, mapping no row to ErrResourceNotFound
+func (r *transactionRepository) getOne(ctx context.Context, query string, args ...any) (*domain.Transaction, error) {
+	tr, err := scanTransaction(conn(ctx, r.db).QueryRow(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperrors.ErrResourceNotFound
+		}
+		return nil, fmt.Errorf("failed to get transaction: %w", err)
+	}
+
+	return tr, nil
+}
+
+// getMany runs a multi-row transaction query and scans every row
+func (r *transactionRepository) getMany(ctx context.Context, query string, args ...any) ([]*domain.Transaction, error) {
+	rows, err := conn(ctx, r.db).Query(ctx, query, args...)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == lockNotAvailableCode {
+			return nil, apperrors.ErrLockAcquisitionFailed
+		}
 		return nil, fmt.Errorf("failed to get transactions: %w", err)
 	}
 	defer rows.Close()
 
 	transactions := make([]*domain.Transaction, 0)
 	for rows.Next() {
-		tr := &domain.Transaction{}
-		err := rows.Scan(
-			&tr.ID,
-			&tr.WalletID,
-			&tr.DestWalletID,
-			&tr.Type,
-			&tr.Amount,
-			&tr.BalanceBefore,
-			&tr.BalanceAfter,
-			&tr.Description,
-			&tr.TransactionTime,
-			&tr.CreatedAt,
-		)
+		tr, err := scanTransaction(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan transaction row: %w", err)
 		}
@@ -94,24 +365,110 @@ func (r *transactionRepository) GetByWalletID(ctx context.Context, walletID int6
 	}
 
 	if err = rows.Err(); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == lockNotAvailableCode {
+			return nil, apperrors.ErrLockAcquisitionFailed
+		}
 		return nil, fmt.Errorf("error iterating transaction rows: %w", err)
 	}
 
 	return transactions, nil
 }
 
-func (r *transactionRepository) CountByWalletID(ctx context.Context, walletID int64) (int, error) {
-	query := `
-		SELECT COUNT(*)
-		FROM transactions
-		WHERE wallet_id = $1
-	`
+// transactionQuery collects the conditions and numbered arguments of a
+// filtered wallet listing
+type transactionQuery struct {
+	conditions []string
+	args       []any
+}
 
-	var count int
-	err := r.db.QueryRow(ctx, query, walletID).Scan(&count)
-	if err != nil {
-		return 0, fmt.Errorf("failed to count transactions: %w", err)
+// likeEscaper escapes LIKE wildcards so search text matches literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// newTransactionQuery turns a filter into conditions on one wallet's rows
+func newTransactionQuery(walletID int64, filter domain.TransactionFilter) *transactionQuery {
+	q := &transactionQuery{}
+	q.conditions = append(q.conditions, "wallet_id = "+q.arg(walletID))
+
+	if len(filter.Types) > 0 {
+		types := make([]string, 0, len(filter.Types))
+		for _, transactionType := range filter.Types {
+			types = append(types, string(transactionType))
+		}
+		q.conditions = append(q.conditions, "type = ANY("+q.arg(types)+")")
+	}
+	if filter.From != nil {
+		q.conditions = append(q.conditions, "transaction_time >= "+q.arg(filter.From.UTC()))
+	}
+	if filter.To != nil {
+		q.conditions = append(q.conditions, "transaction_time < "+q.arg(filter.To.UTC()))
+	}
+	if filter.MinAmount != nil {
+		q.conditions = append(q.conditions, "amount >= "+q.arg(*filter.MinAmount))
+	}
+	if filter.MaxAmount != nil {
+		q.conditions = append(q.conditions, "amount <= "+q.arg(*filter.MaxAmount))
+	}
+	if filter.CounterpartyWalletID != nil {
+		q.conditions = append(q.conditions, "counterparty_wallet_id = "+q.arg(*filter.CounterpartyWalletID))
+	}
+	if filter.Search != "" {
+		q.conditions = append(q.conditions, "description ILIKE '%' || "+q.arg(likeEscaper.Replace(filter.Search))+` || '%'`)
 	}
 
-	return count, nil
-}
\ No newline at end of file
+	return q
+}
+
+// arg adds an argument and returns its placeholder
+func (q *transactionQuery) arg(value any) string {
+	q.args = append(q.args, value)
+	return "$" + strconv.Itoa(len(q.args))
+}
+
+// where joins the conditions for a WHERE clause
+func (q *transactionQuery) where() string {
+	return strings.Join(q.conditions, " AND ")
+}
+
+// sortDirection is the SQL keyword for a descending or ascending sort
+func sortDirection(descending bool) string {
+	if descending {
+		return "DESC"
+	}
+	return "ASC"
+}
+
+// scanTransaction reads one row selected with transactionColumns
+func scanTransaction(row pgx.Row) (*domain.Transaction, error) {
+	tr := &domain.Transaction{}
+	err := row.Scan(
+		&tr.ID,
+		&tr.WalletID,
+		&tr.DestWalletID,
+		&tr.Type,
+		&tr.Amount,
+		&tr.BalanceBefore,
+		&tr.BalanceAfter,
+		&tr.Description,
+		&tr.JournalEntryID,
+		&tr.CorrelationID,
+		&tr.CounterpartyWalletID,
+		&tr.CounterpartyUserID,
+		&tr.OriginalTransactionID,
+		&tr.ReversedAmount,
+		&tr.ReversalStatus,
+		&tr.FXQuoteID,
+		&tr.FXRate,
+		&tr.SourceAmount,
+		&tr.TargetAmount,
+		&tr.Fee,
+		&tr.FeeVersion,
+		&tr.ParentTransactionID,
+		&tr.TransactionTime,
+		&tr.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return tr, nil
+}
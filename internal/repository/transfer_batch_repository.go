@@ -0,0 +1,319 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+)
+
+// batchColumns are the transfer_batches columns read by batchFields
+const batchColumns = `id, user_id, sender_wallet_id, currency, mode, status, item_count, total_amount,
+	succeeded_count, failed_count, COALESCE(error, ''), lease_until, created_at, updated_at, completed_at`
+
+// batchItemColumns are the transfer_batch_items columns read by batchItemFields
+const batchItemColumns = `id, batch_id, line, receiver_id, COALESCE(receiver_wallet_id, 0), amount,
+	COALESCE(comment, ''), status, transaction_id, COALESCE(error, '')`
+
+type transferBatchRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewTransferBatchRepository creates a new PostgreSQL transfer batch repository
+func NewTransferBatchRepository(db *pgxpool.Pool) domain.TransferBatchRepository {
+	return &transferBatchRepository{
+		db: db,
+	}
+}
+
+func (r *transferBatchRepository) Create(ctx context.Context, batch *domain.TransferBatch, items []*domain.TransferBatchItem) error {
+	now := time.Now()
+	batch.CreatedAt = now
+	batch.UpdatedAt = now
+
+	query := `
+		INSERT INTO transfer_batches (
+			user_id, sender_wallet_id, currency, mode, status, item_count, total_amount,
+			created_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id
+	`
+
+	err := conn(ctx, r.db).QueryRow(ctx, query,
+		batch.UserID,
+		batch.SenderWalletID,
+		string(batch.Currency),
+		batch.Mode,
+		batch.Status,
+		batch.ItemCount,
+		batch.TotalAmount,
+		batch.CreatedAt,
+		batch.UpdatedAt,
+	).Scan(&batch.ID)
+
+	if err != nil {
+		return fmt.Errorf("failed to create transfer batch: %w", err)
+	}
+
+	// Insert every item in one statement, passing each column as an array
+	lines := make([]int, len(items))
+	receiverIDs := make([]int64, len(items))
+	receiverWalletIDs := make([]int64, len(items))
+	amounts := make([]string, len(items))
+	comments := make([]string, len(items))
+	for i, item := range items {
+		item.BatchID = batch.ID
+		lines[i] = item.Line
+		receiverIDs[i] = item.ReceiverID
+		receiverWalletIDs[i] = item.ReceiverWalletID
+		amounts[i] = item.Amount.String()
+		comments[i] = item.Comment
+	}
+
+	itemQuery := `
+		INSERT INTO transfer_batch_items (
+			batch_id, line, receiver_id, receiver_wallet_id, amount, comment, status
+		)
+		SELECT $1, line, receiver_id, NULLIF(receiver_wallet_id, 0), amount, NULLIF(comment, ''), $7
+		FROM unnest($2::int[], $3::bigint[], $4::bigint[], $5::numeric[], $6::text[])
+		    AS item(line, receiver_id, receiver_wallet_id, amount, comment)
+		RETURNING id, line
+	`
+
+	rows, err := conn(ctx, r.db).Query(ctx, itemQuery,
+		batch.ID, lines, receiverIDs, receiverWalletIDs, amounts, comments, domain.BatchItemPending)
+	if err != nil {
+		return fmt.Errorf("failed to create transfer batch items: %w", err)
+	}
+	defer rows.Close()
+
+	byLine := make(map[int]*domain.TransferBatchItem, len(items))
+	for _, item := range items {
+		byLine[item.Line] = item
+	}
+	for rows.Next() {
+		var id int64
+		var line int
+		if err := rows.Scan(&id, &line); err != nil {
+			return fmt.Errorf("failed to scan transfer batch item: %w", err)
+		}
+		if item, ok := byLine[line]; ok {
+			item.ID = id
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to create transfer batch items: %w", err)
+	}
+
+	return nil
+}
+
+func (r *transferBatchRepository) GetByID(ctx context.Context, id int64) (*domain.TransferBatch, error) {
+	query := `SELECT ` + batchColumns + ` FROM transfer_batches WHERE id = $1`
+	return r.getOne(ctx, query, id)
+}
+
+func (r *transferBatchRepository) GetByIDForUpdate(ctx context.Context, id int64) (*domain.TransferBatch, error) {
+	query := `SELECT ` + batchColumns + ` FROM transfer_batches WHERE id = $1 FOR UPDATE`
+	return r.getOne(ctx, query, id)
+}
+
+// getOne runs a single-batch query and scans the row
+func (r *transferBatchRepository) getOne(ctx context.Context, query string, args ...any) (*domain.TransferBatch, error) {
+	batch := &domain.TransferBatch{}
+	err := conn(ctx, r.db).QueryRow(ctx, query, args...).Scan(batchFields(batch)...)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperrors.ErrResourceNotFound
+		}
+		return nil, fmt.Errorf("failed to get transfer batch: %w", err)
+	}
+
+	return batch, nil
+}
+
+func (r *transferBatchRepository) Update(ctx context.Context, batch *domain.TransferBatch) error {
+	batch.UpdatedAt = time.Now()
+
+	query := `
+		UPDATE transfer_batches
+		SET status = $1, succeeded_count = $2, failed_count = $3, error = NULLIF($4, ''),
+		    lease_until = $5, updated_at = $6, completed_at = $7
+		WHERE id = $8
+	`
+
+	_, err := conn(ctx, r.db).Exec(ctx, query,
+		batch.Status,
+		batch.SucceededCount,
+		batch.FailedCount,
+		batch.Error,
+		batch.LeaseUntil,
+		batch.UpdatedAt,
+		batch.CompletedAt,
+		batch.ID,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to update transfer batch: %w", err)
+	}
+
+	return nil
+}
+
+func (r *transferBatchRepository) Claim(ctx context.Context, limit int, leaseUntil time.Time) ([]*domain.TransferBatch, error) {
+	// SKIP LOCKED lets several workers claim disjoint batches
+	query := `
+		UPDATE transfer_batches
+		SET status = $1, lease_until = $2, updated_at = $3
+		WHERE id IN (
+		    SELECT id FROM transfer_batches
+		    WHERE status = $4 OR (status = $1 AND lease_until <= $3)
+		    ORDER BY id
+		    LIMIT $5
+		    FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + batchColumns
+
+	rows, err := conn(ctx, r.db).Query(ctx, query,
+		domain.BatchProcessing, leaseUntil, time.Now(), domain.BatchPending, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim transfer batches: %w", err)
+	}
+	defer rows.Close()
+
+	var batches []*domain.TransferBatch
+	for rows.Next() {
+		batch := &domain.TransferBatch{}
+		if err := rows.Scan(batchFields(batch)...); err != nil {
+			return nil, fmt.Errorf("failed to scan transfer batch: %w", err)
+		}
+		batches = append(batches, batch)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating transfer batches: %w", err)
+	}
+
+	return batches, nil
+}
+
+func (r *transferBatchRepository) ListItems(
+	ctx context.Context,
+	batchID int64,
+	status domain.BatchItemStatus,
+	limit, offset int,
+) ([]*domain.TransferBatchItem, error) {
+	query := `
+		SELECT ` + batchItemColumns + `
+		FROM transfer_batch_items
+		WHERE batch_id = $1 AND ($2::text = '' OR status = $2)
+		ORDER BY line
+		LIMIT $3 OFFSET $4
+	`
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, batchID, string(status), limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list transfer batch items: %w", err)
+	}
+	defer rows.Close()
+
+	var items []*domain.TransferBatchItem
+	for rows.Next() {
+		item := &domain.TransferBatchItem{}
+		if err := rows.Scan(batchItemFields(item)...); err != nil {
+			return nil, fmt.Errorf("failed to scan transfer batch item: %w", err)
+		}
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating transfer batch items: %w", err)
+	}
+
+	return items, nil
+}
+
+func (r *transferBatchRepository) CountItems(ctx context.Context, batchID int64, status domain.BatchItemStatus) (int, error) {
+	query := `SELECT COUNT(*) FROM transfer_batch_items WHERE batch_id = $1 AND ($2::text = '' OR status = $2)`
+
+	var count int
+	err := conn(ctx, r.db).QueryRow(ctx, query, batchID, string(status)).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count transfer batch items: %w", err)
+	}
+	return count, nil
+}
+
+func (r *transferBatchRepository) UpdateItem(ctx context.Context, item *domain.TransferBatchItem) error {
+	query := `
+		UPDATE transfer_batch_items
+		SET status = $1, transaction_id = $2, error = NULLIF($3, '')
+		WHERE id = $4
+	`
+
+	_, err := conn(ctx, r.db).Exec(ctx, query, item.Status, item.TransactionID, item.Error, item.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update transfer batch item: %w", err)
+	}
+
+	return nil
+}
+
+func (r *transferBatchRepository) SkipPendingItems(ctx context.Context, batchID int64, reason string) error {
+	query := `
+		UPDATE transfer_batch_items
+		SET status = $1, error = NULLIF($2, '')
+		WHERE batch_id = $3 AND status = $4
+	`
+
+	_, err := conn(ctx, r.db).Exec(ctx, query, domain.BatchItemSkipped, reason, batchID, domain.BatchItemPending)
+	if err != nil {
+		return fmt.Errorf("failed to skip transfer batch items: %w", err)
+	}
+
+	return nil
+}
+
+// batchFields lists scan targets matching batchColumns
+func batchFields(batch *domain.TransferBatch) []any {
+	return []any{
+		&batch.ID,
+		&batch.UserID,
+		&batch.SenderWalletID,
+		&batch.Currency,
+		&batch.Mode,
+		&batch.Status,
+		&batch.ItemCount,
+		&batch.TotalAmount,
+		&batch.SucceededCount,
+		&batch.FailedCount,
+		&batch.Error,
+		&batch.LeaseUntil,
+		&batch.CreatedAt,
+		&batch.UpdatedAt,
+		&batch.CompletedAt,
+	}
+}
+
+// batchItemFields lists scan targets matching batchItemColumns
+func batchItemFields(item *domain.TransferBatchItem) []any {
+	return []any{
+		&item.ID,
+		&item.BatchID,
+		&item.Line,
+		&item.ReceiverID,
+		&item.ReceiverWalletID,
+		&item.Amount,
+		&item.Comment,
+		&item.Status,
+		&item.TransactionID,
+		&item.Error,
+	}
+}
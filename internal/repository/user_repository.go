@@ -2,11 +2,24 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/ravindu/wallet-app-service/internal/domain"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+)
+
+// uniqueViolationCode is the Postgres error code for a broken UNIQUE constraint
+const uniqueViolationCode = "23505"
+
+// Unique constraints on the users table
+const (
+	usersUsernameKey = "users_username_key"
+	usersEmailKey    = "users_email_key"
 )
 
 type userRepository struct {
@@ -24,21 +37,28 @@ func (r *userRepository) Create(ctx context.Context, user *domain.User) error {
 	now := time.Now()
 	user.CreatedAt = now
 	user.UpdatedAt = now
+	if user.Tier == "" {
+		user.Tier = domain.StandardTier
+	}
 
 	query := `
-		INSERT INTO users (username, email, created_at, updated_at)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO users (username, email, tier, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
 		RETURNING id
 	`
 
-	err := r.db.QueryRow(ctx, query,
+	err := conn(ctx, r.db).QueryRow(ctx, query,
 		user.Username,
 		user.Email,
+		user.Tier,
 		user.CreatedAt,
 		user.UpdatedAt,
 	).Scan(&user.ID)
 
 	if err != nil {
+		if conflict := userConflict(err); conflict != nil {
+			return conflict
+		}
 		return fmt.Errorf("failed to create user: %w", err)
 	}
 
@@ -47,24 +67,93 @@ func (r *userRepository) Create(ctx context.Context, user *domain.User) error {
 
 func (r *userRepository) GetByID(ctx context.Context, id int64) (*domain.User, error) {
 	query := `
-		SELECT id, username, email, created_at, updated_at
+		SELECT id, username, email, tier, created_at, updated_at
 		FROM users
 		WHERE id = $1
 	`
 
+	return r.getOne(ctx, query, id)
+}
+
+func (r *userRepository) GetByIDForUpdate(ctx context.Context, id int64) (*domain.User, error) {
+	query := `
+		SELECT id, username, email, tier, created_at, updated_at
+		FROM users
+		WHERE id = $1
+		FOR UPDATE
+	`
+
+	return r.getOne(ctx, query, id)
+}
+
+// getOne runs a single-user query and scans the row
+func (r *userRepository) getOne(ctx context.Context, query string, args ...any) (*domain.User, error) {
 	user := &domain.User{}
-	err := r.db.QueryRow(ctx, query, id).Scan(
+	err := conn(ctx, r.db).QueryRow(ctx, query, args...).Scan(
 		&user.ID,
 		&user.Username,
 		&user.Email,
+		&user.Tier,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
 
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperrors.ErrResourceNotFound
+		}
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == lockNotAvailableCode {
+			return nil, apperrors.ErrLockAcquisitionFailed
+		}
 		return nil, fmt.Errorf("failed to get user by ID: %w", err)
 	}
 
 	return user, nil
 }
 
+func (r *userRepository) Update(ctx context.Context, user *domain.User) error {
+	user.UpdatedAt = time.Now()
+
+	query := `
+		UPDATE users
+		SET username = $1, email = $2, updated_at = $3
+		WHERE id = $4
+	`
+
+	tag, err := conn(ctx, r.db).Exec(ctx, query,
+		user.Username,
+		user.Email,
+		user.UpdatedAt,
+		user.ID,
+	)
+
+	if err != nil {
+		if conflict := userConflict(err); conflict != nil {
+			return conflict
+		}
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return apperrors.ErrResourceNotFound
+	}
+
+	return nil
+}
+
+// userConflict turns a unique violation on username or email into its domain error
+func userConflict(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != uniqueViolationCode {
+		return nil
+	}
+
+	switch pgErr.ConstraintName {
+	case usersUsernameKey:
+		return apperrors.ErrUsernameTaken
+	case usersEmailKey:
+		return apperrors.ErrEmailTaken
+	default:
+		return nil
+	}
+}
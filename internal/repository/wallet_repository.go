@@ -2,13 +2,23 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/ravindu/wallet-app-service/internal/domain"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
 )
 
+// walletsUserCurrencyKey is the constraint allowing one wallet per user and currency
+const walletsUserCurrencyKey = "wallets_user_id_currency_key"
+
+// walletColumns lists the columns scanWallet expects, in order
+const walletColumns = `id, user_id, balance, held_balance, currency, COALESCE(label, ''), created_at, updated_at`
+
 type walletRepository struct {
 	db *pgxpool.Pool
 }
@@ -21,50 +31,99 @@ func NewWalletRepository(db *pgxpool.Pool) domain.WalletRepository {
 }
 
 func (r *walletRepository) Create(ctx context.Context, wallet *domain.Wallet) error {
-	now := time.Now()
+	now := time.Now().UTC()
 	wallet.CreatedAt = now
 	wallet.UpdatedAt = now
 
 	query := `
-		INSERT INTO wallets (user_id, balance, currency, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO wallets (user_id, balance, currency, label, created_at, updated_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6)
 		RETURNING id
 	`
 
-	err := r.db.QueryRow(ctx, query,
+	err := conn(ctx, r.db).QueryRow(ctx, query,
 		wallet.UserID,
 		wallet.Balance,
 		wallet.Currency,
+		wallet.Label,
 		wallet.CreatedAt,
 		wallet.UpdatedAt,
 	).Scan(&wallet.ID)
 
 	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolationCode && pgErr.ConstraintName == walletsUserCurrencyKey {
+			return apperrors.ErrWalletExists
+		}
 		return fmt.Errorf("failed to create wallet: %w", err)
 	}
 
 	return nil
 }
 
-func (r *walletRepository) GetByUserID(ctx context.Context, userID int64) (*domain.Wallet, error) {
+func (r *walletRepository) GetByID(ctx context.Context, id int64) (*domain.Wallet, error) {
 	query := `
-		SELECT id, user_id, balance, currency, created_at, updated_at
+		SELECT ` + walletColumns + `
+		FROM wallets
+		WHERE id = $1
+	`
+
+	return r.getOne(ctx, query, id)
+}
+
+func (r *walletRepository) GetByIDForUpdate(ctx context.Context, id int64) (*domain.Wallet, error) {
+	query := `
+		SELECT ` + walletColumns + `
+		FROM wallets
+		WHERE id = $1
+		FOR UPDATE
+	`
+
+	return r.getOne(ctx, query, id)
+}
+
+func (r *walletRepository) ListByUserID(ctx context.Context, userID int64) ([]*domain.Wallet, error) {
+	query := `
+		SELECT ` + walletColumns + `
 		FROM wallets
 		WHERE user_id = $1
+		ORDER BY id
 	`
 
-	wallet := &domain.Wallet{}
-	err := r.db.QueryRow(ctx, query, userID).Scan(
-		&wallet.ID,
-		&wallet.UserID,
-		&wallet.Balance,
-		&wallet.Currency,
-		&wallet.CreatedAt,
-		&wallet.UpdatedAt,
-	)
+	rows, err := conn(ctx, r.db).Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list wallets: %w", err)
+	}
+	defer rows.Close()
+
+	wallets := make([]*domain.Wallet, 0)
+	for rows.Next() {
+		wallet, err := scanWallet(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan wallet row: %w", err)
+		}
+		wallets = append(wallets, wallet)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating wallet rows: %w", err)
+	}
 
+	return wallets, nil
+}
+
+// getOne runs a single-wallet query and scans the row
+func (r *walletRepository) getOne(ctx context.Context, query string, args ...any) (*domain.Wallet, error) {
+	wallet, err := scanWallet(conn(ctx, r.db).QueryRow(ctx, query, args...))
 	if err != nil {
-		return nil, fmt.Errorf("failed to get wallet by user ID: %w", err)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperrors.ErrResourceNotFound
+		}
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == lockNotAvailableCode {
+			return nil, apperrors.ErrLockAcquisitionFailed
+		}
+		return nil, fmt.Errorf("failed to get wallet: %w", err)
 	}
 
 	return wallet, nil
@@ -75,12 +134,13 @@ func (r *walletRepository) Update(ctx context.Context, wallet *domain.Wallet) er
 
 	query := `
 		UPDATE wallets
-		SET balance = $1, updated_at = $2
-		WHERE id = $3
+		SET balance = $1, held_balance = $2, updated_at = $3
+		WHERE id = $4
 	`
 
-	_, err := r.db.Exec(ctx, query,
+	_, err := conn(ctx, r.db).Exec(ctx, query,
 		wallet.Balance,
+		wallet.HeldBalance,
 		wallet.UpdatedAt,
 		wallet.ID,
 	)
@@ -90,4 +150,22 @@ func (r *walletRepository) Update(ctx context.Context, wallet *domain.Wallet) er
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+// scanWallet reads one row selected with walletColumns
+func scanWallet(row pgx.Row) (*domain.Wallet, error) {
+	wallet := &domain.Wallet{}
+	err := row.Scan(
+		&wallet.ID,
+		&wallet.UserID,
+		&wallet.Balance,
+		&wallet.HeldBalance,
+		&wallet.Currency,
+		&wallet.Label,
+		&wallet.CreatedAt,
+		&wallet.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return wallet, nil
+}
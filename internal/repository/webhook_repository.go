@@ -0,0 +1,349 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+)
+
+// deliveryColumns are the webhook_deliveries columns read by scanDelivery
+const deliveryColumns = `d.id, d.subscription_id, d.event_id, d.event_type, d.payload, d.status, d.attempts,
+	d.last_status_code, COALESCE(d.last_error, ''), d.next_attempt_at, d.delivered_at, d.created_at, d.updated_at`
+
+type webhookRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewWebhookRepository creates a new PostgreSQL webhook repository
+func NewWebhookRepository(db *pgxpool.Pool) domain.WebhookRepository {
+	return &webhookRepository{
+		db: db,
+	}
+}
+
+func (r *webhookRepository) CreateSubscription(ctx context.Context, subscription *domain.WebhookSubscription) error {
+	now := time.Now()
+	subscription.CreatedAt = now
+	subscription.UpdatedAt = now
+
+	query := `
+		INSERT INTO webhook_subscriptions (user_id, url, secret, event_types, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`
+
+	err := conn(ctx, r.db).QueryRow(ctx, query,
+		subscription.UserID,
+		subscription.URL,
+		subscription.Secret,
+		eventTypeStrings(subscription.EventTypes),
+		subscription.CreatedAt,
+		subscription.UpdatedAt,
+	).Scan(&subscription.ID)
+
+	if err != nil {
+		return fmt.Errorf("failed to create webhook subscription: %w", err)
+	}
+
+	return nil
+}
+
+func (r *webhookRepository) GetSubscription(ctx context.Context, id int64) (*domain.WebhookSubscription, error) {
+	query := `
+		SELECT id, user_id, url, secret, event_types, created_at, updated_at
+		FROM webhook_subscriptions
+		WHERE id = $1
+	`
+
+	subscription, err := scanSubscription(conn(ctx, r.db).QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperrors.ErrResourceNotFound
+		}
+		return nil, fmt.Errorf("failed to get webhook subscription: %w", err)
+	}
+
+	return subscription, nil
+}
+
+func (r *webhookRepository) ListSubscriptions(ctx context.Context, userID int64) ([]*domain.WebhookSubscription, error) {
+	query := `
+		SELECT id, user_id, url, secret, event_types, created_at, updated_at
+		FROM webhook_subscriptions
+		WHERE user_id = $1
+		ORDER BY id
+	`
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	var subscriptions []*domain.WebhookSubscription
+	for rows.Next() {
+		subscription, err := scanSubscription(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook subscription: %w", err)
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating webhook subscriptions: %w", err)
+	}
+
+	return subscriptions, nil
+}
+
+func (r *webhookRepository) DeleteSubscription(ctx context.Context, id int64) error {
+	tag, err := conn(ctx, r.db).Exec(ctx, "DELETE FROM webhook_subscriptions WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook subscription: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return apperrors.ErrResourceNotFound
+	}
+	return nil
+}
+
+func (r *webhookRepository) EnqueueDeliveries(ctx context.Context, event *domain.OutboxEvent, userIDs []int64) error {
+	query := `
+		INSERT INTO webhook_deliveries (
+			subscription_id, event_id, event_type, payload, status, next_attempt_at, created_at, updated_at
+		)
+		SELECT s.id, $1, $2, $3, $4, $5, $5, $5
+		FROM webhook_subscriptions s
+		WHERE s.user_id = ANY($6) AND $2 = ANY(s.event_types)
+		ON CONFLICT (subscription_id, event_id) DO NOTHING
+	`
+
+	_, err := conn(ctx, r.db).Exec(ctx, query,
+		event.ID,
+		string(event.Type),
+		event.Payload,
+		domain.DeliveryPending,
+		time.Now(),
+		userIDs,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to enqueue webhook deliveries: %w", err)
+	}
+
+	return nil
+}
+
+func (r *webhookRepository) ClaimDueDeliveries(ctx context.Context, limit int, leaseUntil time.Time) ([]*domain.WebhookJob, error) {
+	// SKIP LOCKED lets several dispatchers claim disjoint batches
+	query := `
+		UPDATE webhook_deliveries d
+		SET next_attempt_at = $1, updated_at = $2
+		FROM webhook_subscriptions s
+		WHERE s.id = d.subscription_id
+		  AND d.id IN (
+		      SELECT id FROM webhook_deliveries
+		      WHERE status = $3 AND next_attempt_at <= $2
+		      ORDER BY next_attempt_at, id
+		      LIMIT $4
+		      FOR UPDATE SKIP LOCKED
+		  )
+		RETURNING ` + deliveryColumns + `, s.url, s.secret
+	`
+
+	// SaveAttempt matches on the lease, so keep it to the microseconds Postgres stores
+	leaseUntil = leaseUntil.Truncate(time.Microsecond)
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, leaseUntil, time.Now(), domain.DeliveryPending, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []*domain.WebhookJob
+	for rows.Next() {
+		job := &domain.WebhookJob{Delivery: &domain.WebhookDelivery{}}
+		dest := append(deliveryFields(job.Delivery), &job.URL, &job.Secret)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating webhook deliveries: %w", err)
+	}
+
+	return jobs, nil
+}
+
+func (r *webhookRepository) SaveAttempt(ctx context.Context, delivery *domain.WebhookDelivery, leaseUntil time.Time) (bool, error) {
+	delivery.UpdatedAt = time.Now()
+
+	// A delivery still under our lease has the lease as its next attempt
+	query := `
+		UPDATE webhook_deliveries
+		SET status = $1, attempts = $2, last_status_code = $3, last_error = NULLIF($4, ''),
+		    next_attempt_at = $5, delivered_at = $6, updated_at = $7
+		WHERE id = $8 AND status = $9 AND next_attempt_at = $10
+	`
+
+	result, err := conn(ctx, r.db).Exec(ctx, query,
+		delivery.Status,
+		delivery.Attempts,
+		delivery.LastStatusCode,
+		delivery.LastError,
+		delivery.NextAttemptAt,
+		delivery.DeliveredAt,
+		delivery.UpdatedAt,
+		delivery.ID,
+		domain.DeliveryPending,
+		leaseUntil.Truncate(time.Microsecond),
+	)
+
+	if err != nil {
+		return false, fmt.Errorf("failed to save webhook attempt: %w", err)
+	}
+
+	return result.RowsAffected() == 1, nil
+}
+
+func (r *webhookRepository) UpdateDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	delivery.UpdatedAt = time.Now()
+
+	query := `
+		UPDATE webhook_deliveries
+		SET status = $1, attempts = $2, last_status_code = $3, last_error = NULLIF($4, ''),
+		    next_attempt_at = $5, delivered_at = $6, updated_at = $7
+		WHERE id = $8
+	`
+
+	_, err := conn(ctx, r.db).Exec(ctx, query,
+		delivery.Status,
+		delivery.Attempts,
+		delivery.LastStatusCode,
+		delivery.LastError,
+		delivery.NextAttemptAt,
+		delivery.DeliveredAt,
+		delivery.UpdatedAt,
+		delivery.ID,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to update webhook delivery: %w", err)
+	}
+
+	return nil
+}
+
+func (r *webhookRepository) GetDelivery(ctx context.Context, id int64) (*domain.WebhookDelivery, error) {
+	query := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries d WHERE d.id = $1`
+
+	delivery := &domain.WebhookDelivery{}
+	err := conn(ctx, r.db).QueryRow(ctx, query, id).Scan(deliveryFields(delivery)...)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperrors.ErrResourceNotFound
+		}
+		return nil, fmt.Errorf("failed to get webhook delivery: %w", err)
+	}
+
+	return delivery, nil
+}
+
+func (r *webhookRepository) ListDeliveries(ctx context.Context, subscriptionID int64, limit, offset int) ([]*domain.WebhookDelivery, error) {
+	query := `
+		SELECT ` + deliveryColumns + `
+		FROM webhook_deliveries d
+		WHERE d.subscription_id = $1
+		ORDER BY d.id DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, subscriptionID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []*domain.WebhookDelivery
+	for rows.Next() {
+		delivery := &domain.WebhookDelivery{}
+		if err := rows.Scan(deliveryFields(delivery)...); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+func (r *webhookRepository) CountDeliveries(ctx context.Context, subscriptionID int64) (int, error) {
+	var count int
+	err := conn(ctx, r.db).QueryRow(ctx, "SELECT COUNT(*) FROM webhook_deliveries WHERE subscription_id = $1", subscriptionID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count webhook deliveries: %w", err)
+	}
+	return count, nil
+}
+
+// scanSubscription reads a webhook_subscriptions row
+func scanSubscription(row pgx.Row) (*domain.WebhookSubscription, error) {
+	subscription := &domain.WebhookSubscription{}
+	var eventTypes []string
+	err := row.Scan(
+		&subscription.ID,
+		&subscription.UserID,
+		&subscription.URL,
+		&subscription.Secret,
+		&eventTypes,
+		&subscription.CreatedAt,
+		&subscription.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, eventType := range eventTypes {
+		subscription.EventTypes = append(subscription.EventTypes, domain.EventType(eventType))
+	}
+	return subscription, nil
+}
+
+// deliveryFields lists scan targets matching deliveryColumns
+func deliveryFields(delivery *domain.WebhookDelivery) []any {
+	return []any{
+		&delivery.ID,
+		&delivery.SubscriptionID,
+		&delivery.EventID,
+		&delivery.EventType,
+		&delivery.Payload,
+		&delivery.Status,
+		&delivery.Attempts,
+		&delivery.LastStatusCode,
+		&delivery.LastError,
+		&delivery.NextAttemptAt,
+		&delivery.DeliveredAt,
+		&delivery.CreatedAt,
+		&delivery.UpdatedAt,
+	}
+}
+
+// eventTypeStrings converts event types for a TEXT[] column
+func eventTypeStrings(eventTypes []domain.EventType) []string {
+	values := make([]string, len(eventTypes))
+	for i, eventType := range eventTypes {
+		values[i] = string(eventType)
+	}
+	return values
+}
@@ -0,0 +1,77 @@
+package statement
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+)
+
+// csvHeader names the columns of a CSV statement
+var csvHeader = []string{"date", "transaction_id", "type", "description", "amount", "balance", "currency"}
+
+// csvWriter writes one row per transaction between an opening and a closing
+// balance row. The amount is signed, negative for money out.
+type csvWriter struct {
+	w      *csv.Writer
+	header domain.StatementHeader
+}
+
+func newCSVWriter(w io.Writer) *csvWriter {
+	return &csvWriter{
+		w: csv.NewWriter(w),
+	}
+}
+
+func (c *csvWriter) Begin(header domain.StatementHeader) error {
+	c.header = header
+	currency := header.Wallet.Currency
+
+	if err := c.w.Write(csvHeader); err != nil {
+		return err
+	}
+	return c.w.Write([]string{
+		statementDate(header.From), "", "OPENING_BALANCE", "Opening balance", "",
+		currency.Format(header.OpeningBalance), string(currency),
+	})
+}
+
+func (c *csvWriter) WriteTransaction(transaction *domain.Transaction) error {
+	currency := c.header.Wallet.Currency
+
+	// csv.Writer flushes on its own as its buffer fills, which streams the file out
+	return c.w.Write([]string{
+		statementDate(transaction.TransactionTime),
+		strconv.FormatInt(transaction.ID, 10),
+		string(transaction.Type),
+		escapeFormula(transaction.Description),
+		currency.Format(transaction.Change()),
+		currency.Format(transaction.BalanceAfter),
+		string(currency),
+	})
+}
+
+func (c *csvWriter) End(closingBalance domain.Amount) error {
+	currency := c.header.Wallet.Currency
+
+	if err := c.w.Write([]string{
+		statementDate(c.header.To), "", "CLOSING_BALANCE", "Closing balance", "",
+		currency.Format(closingBalance), string(currency),
+	}); err != nil {
+		return err
+	}
+
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// escapeFormula stops spreadsheets from running user-written descriptions
+// that look like formulas
+func escapeFormula(s string) string {
+	if s != "" && strings.ContainsAny(s[:1], "=+-@\t\r") {
+		return "'" + s
+	}
+	return s
+}
@@ -0,0 +1,108 @@
+package statement
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+)
+
+// ofxNameLength is the longest NAME an OFX transaction may carry
+const ofxNameLength = 32
+
+// ofxWriter writes an OFX 2.2 bank statement. The closing balance goes in
+// LEDGERBAL and the opening balance in BALLIST, both after the transaction list,
+// so the file can be written front to back.
+type ofxWriter struct {
+	w      *bufio.Writer
+	header domain.StatementHeader
+	err    error
+}
+
+func newOFXWriter(w io.Writer) *ofxWriter {
+	return &ofxWriter{
+		w: bufio.NewWriter(w),
+	}
+}
+
+func (o *ofxWriter) Begin(header domain.StatementHeader) error {
+	o.header = header
+
+	o.printf("<?xml version=\"1.0\" encoding=\"UTF-8\" standalone=\"no\"?>\n")
+	o.printf("<?OFX OFXHEADER=\"200\" VERSION=\"220\" SECURITY=\"NONE\" OLDFILEUID=\"NONE\" NEWFILEUID=\"NONE\"?>\n")
+	o.printf("<OFX>\n")
+	o.printf("<SIGNONMSGSRSV1><SONRS>")
+	o.printf("<STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS>")
+	o.printf("<DTSERVER>%s</DTSERVER><LANGUAGE>ENG</LANGUAGE>", ofxDate(header.GeneratedAt))
+	o.printf("</SONRS></SIGNONMSGSRSV1>\n")
+	o.printf("<BANKMSGSRSV1><STMTTRNRS>")
+	o.printf("<TRNUID>0</TRNUID><STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS>\n")
+	o.printf("<STMTRS><CURDEF>%s</CURDEF>\n", header.Wallet.Currency)
+	o.printf("<BANKACCTFROM><BANKID>WALLET</BANKID><ACCTID>%d</ACCTID><ACCTTYPE>CHECKING</ACCTTYPE></BANKACCTFROM>\n", header.Wallet.ID)
+	o.printf("<BANKTRANLIST><DTSTART>%s</DTSTART><DTEND>%s</DTEND>\n", ofxDate(header.From), ofxDate(header.To))
+
+	return o.err
+}
+
+func (o *ofxWriter) WriteTransaction(transaction *domain.Transaction) error {
+	change := transaction.Change()
+	trnType := "CREDIT"
+	if change < 0 {
+		trnType = "DEBIT"
+	}
+
+	o.printf("<STMTTRN><TRNTYPE>%s</TRNTYPE><DTPOSTED>%s</DTPOSTED><TRNAMT>%s</TRNAMT><FITID>%s</FITID>",
+		trnType,
+		ofxDate(transaction.TransactionTime),
+		o.header.Wallet.Currency.Format(change),
+		strconv.FormatInt(transaction.ID, 10),
+	)
+	o.printf("<NAME>%s</NAME>", ofxText(truncate(string(transaction.Type), ofxNameLength)))
+	if transaction.Description != "" {
+		o.printf("<MEMO>%s</MEMO>", ofxText(truncate(transaction.Description, 255)))
+	}
+	o.printf("</STMTTRN>\n")
+
+	return o.err
+}
+
+func (o *ofxWriter) End(closingBalance domain.Amount) error {
+	currency := o.header.Wallet.Currency
+
+	o.printf("</BANKTRANLIST>\n")
+	o.printf("<LEDGERBAL><BALAMT>%s</BALAMT><DTASOF>%s</DTASOF></LEDGERBAL>\n", currency.Format(closingBalance), ofxDate(o.header.To))
+	o.printf("<BALLIST><BAL><NAME>Opening balance</NAME><DESC>Balance at the start of the statement</DESC>")
+	o.printf("<BALTYPE>DOLLAR</BALTYPE><VALUE>%s</VALUE><DTASOF>%s</DTASOF></BAL></BALLIST>\n", currency.Format(o.header.OpeningBalance), ofxDate(o.header.From))
+	o.printf("</STMTRS></STMTTRNRS></BANKMSGSRSV1>\n")
+	o.printf("</OFX>\n")
+
+	if o.err != nil {
+		return o.err
+	}
+	return o.w.Flush()
+}
+
+// printf writes to the buffer, which passes full chunks on to the client,
+// keeping the first error
+func (o *ofxWriter) printf(format string, args ...any) {
+	if o.err == nil {
+		_, o.err = fmt.Fprintf(o.w, format, args...)
+	}
+}
+
+// ofxDate formats a time as an OFX datetime in UTC
+func ofxDate(t time.Time) string {
+	return t.UTC().Format("20060102150405.000") + "[0:GMT]"
+}
+
+// ofxText escapes text for an OFX element
+func ofxText(s string) string {
+	var escaped strings.Builder
+	_ = xml.EscapeText(&escaped, []byte(s))
+	return escaped.String()
+}
@@ -0,0 +1,199 @@
+package statement
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+)
+
+// Page layout of a PDF statement, in points. Text is set in Courier so the
+// columns line up without font metrics.
+const (
+	pdfPageWidth   = 595 // A4
+	pdfPageHeight  = 842
+	pdfMargin      = 40
+	pdfFontSize    = 8
+	pdfLeading     = 11
+	pdfLinesOnPage = (pdfPageHeight - 2*pdfMargin) / pdfLeading
+)
+
+// Objects written up front; each page adds a content stream and a page object
+const (
+	pdfCatalogID = 1
+	pdfPagesID   = 2
+	pdfFontID    = 3
+)
+
+// pdfRow lays out one line of the transaction table
+const pdfRow = "%-19s  %-14s  %-30s  %14s  %14s"
+
+// pdfWriter writes a plain text PDF a page at a time. Only the byte offsets of
+// the objects written so far are kept, for the cross-reference table at the end.
+type pdfWriter struct {
+	w       *bufio.Writer
+	written int64
+	err     error
+
+	header  domain.StatementHeader
+	offsets map[int]int64
+	nextID  int
+	pageIDs []int
+	lines   []string
+}
+
+func newPDFWriter(w io.Writer) *pdfWriter {
+	return &pdfWriter{
+		w:       bufio.NewWriter(w),
+		offsets: make(map[int]int64),
+		nextID:  pdfFontID + 1,
+	}
+}
+
+func (p *pdfWriter) Begin(header domain.StatementHeader) error {
+	p.header = header
+	wallet := header.Wallet
+
+	// The binary comment tells transfer tools the file isn't plain text
+	p.printf("%%PDF-1.4\n%%\xe2\xe3\xcf\xd3\n")
+	p.object(pdfCatalogID, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pdfPagesID))
+	p.object(pdfFontID, "<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>")
+
+	p.lines = append(p.lines,
+		"Wallet statement",
+		"",
+		fmt.Sprintf("Wallet:    %d (%s)", wallet.ID, wallet.Currency),
+		fmt.Sprintf("Period:    %s to %s UTC", statementDate(header.From), statementDate(header.To)),
+		fmt.Sprintf("Generated: %s UTC", statementDate(header.GeneratedAt)),
+		"",
+	)
+	p.tableHeader()
+	p.line(fmt.Sprintf(pdfRow, statementDate(header.From), "", "Opening balance", "", wallet.Currency.Format(header.OpeningBalance)))
+
+	return p.err
+}
+
+func (p *pdfWriter) WriteTransaction(transaction *domain.Transaction) error {
+	currency := p.header.Wallet.Currency
+
+	p.line(fmt.Sprintf(pdfRow,
+		statementDate(transaction.TransactionTime),
+		transaction.Type,
+		truncate(transaction.Description, 30),
+		currency.Format(transaction.Change()),
+		currency.Format(transaction.BalanceAfter),
+	))
+
+	return p.err
+}
+
+func (p *pdfWriter) End(closingBalance domain.Amount) error {
+	currency := p.header.Wallet.Currency
+
+	p.line(strings.Repeat("-", len(fmt.Sprintf(pdfRow, "", "", "", "", ""))))
+	p.line(fmt.Sprintf(pdfRow, statementDate(p.header.To), "", "Closing balance", "", currency.Format(closingBalance)))
+	p.flushPage()
+
+	kids := make([]string, 0, len(p.pageIDs))
+	for _, id := range p.pageIDs {
+		kids = append(kids, fmt.Sprintf("%d 0 R", id))
+	}
+	p.object(pdfPagesID, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(p.pageIDs)))
+
+	// Cross-reference table, one fixed-width entry per object
+	xref := p.written
+	size := p.nextID
+	p.printf("xref\n0 %d\n0000000000 65535 f \n", size)
+	for id := 1; id < size; id++ {
+		p.printf("%010d 00000 n \n", p.offsets[id])
+	}
+	p.printf("trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", size, pdfCatalogID, xref)
+
+	if p.err != nil {
+		return p.err
+	}
+	return p.w.Flush()
+}
+
+// tableHeader starts the transaction table
+func (p *pdfWriter) tableHeader() {
+	header := fmt.Sprintf(pdfRow, "Date", "Type", "Description", "Amount", "Balance")
+	p.lines = append(p.lines, header, strings.Repeat("-", len(header)))
+}
+
+// line adds a line to the page, starting a new page once it is full
+func (p *pdfWriter) line(text string) {
+	if len(p.lines) >= pdfLinesOnPage {
+		p.flushPage()
+		p.tableHeader()
+	}
+	p.lines = append(p.lines, text)
+}
+
+// flushPage writes the buffered lines out as a page and sends it on
+func (p *pdfWriter) flushPage() {
+	var content strings.Builder
+	fmt.Fprintf(&content, "BT\n/F1 %d Tf\n%d TL\n%d %d Td\n", pdfFontSize, pdfLeading, pdfMargin, pdfPageHeight-pdfMargin)
+	for _, text := range p.lines {
+		fmt.Fprintf(&content, "(%s) Tj T*\n", pdfText(text))
+	}
+	fmt.Fprintf(&content, "ET\nBT\n/F1 %d Tf\n%d %d Td\n(Page %d) Tj\nET", pdfFontSize, pdfMargin, pdfMargin/2, len(p.pageIDs)+1)
+	p.lines = p.lines[:0]
+
+	contentID := p.allocate()
+	p.object(contentID, fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", content.Len(), content.String()))
+
+	pageID := p.allocate()
+	p.object(pageID, fmt.Sprintf(
+		"<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 %d 0 R >> >> /Contents %d 0 R >>",
+		pdfPagesID, pdfPageWidth, pdfPageHeight, pdfFontID, contentID,
+	))
+	p.pageIDs = append(p.pageIDs, pageID)
+
+	if p.err == nil {
+		p.err = p.w.Flush()
+	}
+}
+
+// allocate reserves the next object number
+func (p *pdfWriter) allocate() int {
+	id := p.nextID
+	p.nextID++
+	return id
+}
+
+// object writes an indirect object, noting where it starts
+func (p *pdfWriter) object(id int, body string) {
+	p.offsets[id] = p.written
+	p.printf("%d 0 obj\n%s\nendobj\n", id, body)
+}
+
+// printf writes to the buffer, counting bytes for the offsets and keeping the first error
+func (p *pdfWriter) printf(format string, args ...any) {
+	if p.err != nil {
+		return
+	}
+	n, err := fmt.Fprintf(p.w, format, args...)
+	p.written += int64(n)
+	p.err = err
+}
+
+// pdfText escapes a string for a PDF literal, replacing what Courier's
+// encoding can't show
+func pdfText(s string) string {
+	var escaped strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			escaped.WriteRune('\\')
+			escaped.WriteRune(r)
+		case r < ' ' || r > '~':
+			escaped.WriteByte('?')
+		default:
+			escaped.WriteRune(r)
+		}
+	}
+	return escaped.String()
+}
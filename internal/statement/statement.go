@@ -0,0 +1,61 @@
+// Package statement renders wallet statements as CSV, OFX or PDF files
+package statement
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+)
+
+// NewWriter returns a statement writer for format writing to w
+func NewWriter(format domain.StatementFormat, w io.Writer) (domain.StatementWriter, error) {
+	switch format {
+	case domain.StatementCSV:
+		return newCSVWriter(w), nil
+	case domain.StatementOFX:
+		return newOFXWriter(w), nil
+	case domain.StatementPDF:
+		return newPDFWriter(w), nil
+	default:
+		return nil, fmt.Errorf("%w: unknown statement format %q", apperrors.ErrInvalidInput, format)
+	}
+}
+
+// ContentType is the MIME type of a statement in format
+func ContentType(format domain.StatementFormat) string {
+	switch format {
+	case domain.StatementOFX:
+		return "application/x-ofx"
+	case domain.StatementPDF:
+		return "application/pdf"
+	default:
+		return "text/csv; charset=utf-8"
+	}
+}
+
+// FileName names a downloaded statement after its wallet and period
+func FileName(header domain.StatementHeader, format domain.StatementFormat) string {
+	return fmt.Sprintf("statement-%d-%s-%s.%s",
+		header.Wallet.ID,
+		header.From.UTC().Format("20060102"),
+		header.To.UTC().Format("20060102"),
+		format,
+	)
+}
+
+// statementDate formats a time the way the CSV and PDF statements show it
+func statementDate(t time.Time) string {
+	return t.UTC().Format("2006-01-02 15:04:05")
+}
+
+// truncate cuts s to at most n characters
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
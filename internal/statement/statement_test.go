@@ -0,0 +1,152 @@
+package statement_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	"github.com/ravindu/wallet-app-service/internal/statement"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	statementFrom = time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	statementTo   = time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+)
+
+func statementHeader() domain.StatementHeader {
+	return domain.StatementHeader{
+		Wallet:         &domain.Wallet{ID: 7, UserID: 1, Currency: domain.USD},
+		From:           statementFrom,
+		To:             statementTo,
+		OpeningBalance: domain.NewAmount(100),
+		GeneratedAt:    statementTo,
+	}
+}
+
+// statementTransactions returns a deposit of 25 and a withdrawal of 10 on an opening balance of 100
+func statementTransactions() []*domain.Transaction {
+	return []*domain.Transaction{
+		{
+			ID:              11,
+			Type:            domain.Deposit,
+			Amount:          domain.NewAmount(25),
+			BalanceBefore:   domain.NewAmount(100),
+			BalanceAfter:    domain.NewAmount(125),
+			Description:     "Salary",
+			TransactionTime: statementFrom.Add(time.Hour),
+		},
+		{
+			ID:              12,
+			Type:            domain.Withdrawal,
+			Amount:          domain.NewAmount(10),
+			BalanceBefore:   domain.NewAmount(125),
+			BalanceAfter:    domain.NewAmount(115),
+			Description:     "=HYPERLINK(\"http://example.com\")",
+			TransactionTime: statementFrom.Add(2 * time.Hour),
+		},
+	}
+}
+
+func writeStatement(t *testing.T, format domain.StatementFormat, transactions []*domain.Transaction, closing domain.Amount) string {
+	var buf bytes.Buffer
+	w, err := statement.NewWriter(format, &buf)
+	require.NoError(t, err)
+
+	require.NoError(t, w.Begin(statementHeader()))
+	for _, tr := range transactions {
+		require.NoError(t, w.WriteTransaction(tr))
+	}
+	require.NoError(t, w.End(closing))
+	return buf.String()
+}
+
+func TestNewWriter_UnknownFormat(t *testing.T) {
+	_, err := statement.NewWriter(domain.StatementFormat("xlsx"), &bytes.Buffer{})
+	assert.ErrorIs(t, err, apperrors.ErrInvalidInput)
+}
+
+func TestFileName(t *testing.T) {
+	assert.Equal(t, "statement-7-20240501-20240601.ofx", statement.FileName(statementHeader(), domain.StatementOFX))
+}
+
+func TestCSVStatement(t *testing.T) {
+	out := writeStatement(t, domain.StatementCSV, statementTransactions(), domain.NewAmount(115))
+
+	records, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"date", "transaction_id", "type", "description", "amount", "balance", "currency"},
+		{"2024-05-01 00:00:00", "", "OPENING_BALANCE", "Opening balance", "", "100.00", "USD"},
+		{"2024-05-01 01:00:00", "11", "DEPOSIT", "Salary", "25.00", "125.00", "USD"},
+		{"2024-05-01 02:00:00", "12", "WITHDRAWAL", "'=HYPERLINK(\"http://example.com\")", "-10.00", "115.00", "USD"},
+		{"2024-06-01 00:00:00", "", "CLOSING_BALANCE", "Closing balance", "", "115.00", "USD"},
+	}, records)
+}
+
+func TestOFXStatement(t *testing.T) {
+	out := writeStatement(t, domain.StatementOFX, statementTransactions(), domain.NewAmount(115))
+
+	assert.True(t, strings.HasPrefix(out, "<?xml"))
+	assert.Equal(t, 2, strings.Count(out, "<STMTTRN>"))
+	assert.Contains(t, out, "<TRNTYPE>CREDIT</TRNTYPE><DTPOSTED>20240501010000.000[0:GMT]</DTPOSTED><TRNAMT>25.00</TRNAMT><FITID>11</FITID>")
+	assert.Contains(t, out, "<TRNTYPE>DEBIT</TRNTYPE>")
+	assert.Contains(t, out, "<TRNAMT>-10.00</TRNAMT>")
+	assert.Contains(t, out, "<MEMO>=HYPERLINK(&#34;http://example.com&#34;)</MEMO>")
+	assert.Contains(t, out, "<LEDGERBAL><BALAMT>115.00</BALAMT>")
+	assert.Contains(t, out, "<VALUE>100.00</VALUE>")
+	assert.True(t, strings.HasSuffix(out, "</OFX>\n"))
+}
+
+func TestPDFStatement(t *testing.T) {
+	tests := []struct {
+		name          string
+		transactions  int
+		expectedPages int
+	}{
+		{name: "empty period", transactions: 0, expectedPages: 1},
+		{name: "single page", transactions: 10, expectedPages: 1},
+		{name: "several pages", transactions: 200, expectedPages: 4},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			transactions := make([]*domain.Transaction, 0, tc.transactions)
+			balance := domain.NewAmount(100)
+			for i := range tc.transactions {
+				transactions = append(transactions, &domain.Transaction{
+					ID:              int64(i + 1),
+					Type:            domain.Deposit,
+					Amount:          domain.NewAmount(1),
+					BalanceBefore:   balance,
+					BalanceAfter:    balance + domain.NewAmount(1),
+					Description:     fmt.Sprintf("Top up (%d)", i+1),
+					TransactionTime: statementFrom.Add(time.Duration(i) * time.Minute),
+				})
+				balance += domain.NewAmount(1)
+			}
+
+			out := writeStatement(t, domain.StatementPDF, transactions, balance)
+
+			assert.True(t, strings.HasPrefix(out, "%PDF-1.4\n"))
+			assert.True(t, strings.HasSuffix(out, "%%EOF\n"))
+			assert.Equal(t, tc.expectedPages, strings.Count(out, "/Type /Page "))
+			assert.Contains(t, out, fmt.Sprintf("/Count %d", tc.expectedPages))
+			if tc.transactions > 0 {
+				assert.Contains(t, out, "Top up \\(1\\)")
+			}
+
+			// startxref points at the cross-reference table
+			var offset int
+			_, err := fmt.Sscanf(out[strings.LastIndex(out, "startxref\n"):], "startxref\n%d", &offset)
+			require.NoError(t, err)
+			assert.True(t, strings.HasPrefix(out[offset:], "xref\n"))
+		})
+	}
+}
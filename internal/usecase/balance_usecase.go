@@ -0,0 +1,149 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+)
+
+type balanceUsecase struct {
+	userRepo        domain.UserRepository
+	walletRepo      domain.WalletRepository
+	transactionRepo domain.TransactionRepository
+	snapshotRepo    domain.BalanceSnapshotRepository
+}
+
+// NewBalanceUsecase creates a balance use case for point-in-time balances
+func NewBalanceUsecase(
+	userRepo domain.UserRepository,
+	walletRepo domain.WalletRepository,
+	transactionRepo domain.TransactionRepository,
+	snapshotRepo domain.BalanceSnapshotRepository,
+) domain.BalanceUsecase {
+	return &balanceUsecase{
+		userRepo:        userRepo,
+		walletRepo:      walletRepo,
+		transactionRepo: transactionRepo,
+		snapshotRepo:    snapshotRepo,
+	}
+}
+
+// GetBalanceAt returns the balance the user's wallet held just before at
+func (u *balanceUsecase) GetBalanceAt(ctx context.Context, userID int64, selector domain.WalletSelector, at time.Time) (*domain.HistoricalBalance, error) {
+	if at.After(time.Now()) {
+		return nil, fmt.Errorf("%w: at must not be in the future", apperrors.ErrInvalidInput)
+	}
+
+	user, err := u.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrResourceNotFound) {
+			return nil, apperrors.ErrUserNotFound
+		}
+		return nil, apperrors.WrapError(err, "failed to get user")
+	}
+
+	wallet, err := selectWallet(ctx, u.walletRepo, user.ID, selector)
+	if err != nil {
+		return nil, err
+	}
+
+	balance, err := balanceAt(ctx, u.transactionRepo, u.snapshotRepo, wallet, at)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.HistoricalBalance{
+		WalletID: wallet.ID,
+		UserID:   wallet.UserID,
+		Currency: wallet.Currency,
+		Balance:  balance,
+		At:       at.UTC(),
+	}, nil
+}
+
+// SnapshotBalances records every wallet's balance as of the midnight ending asOf's
+// UTC day, where a midnight ends the day before it
+func (u *balanceUsecase) SnapshotBalances(ctx context.Context, asOf time.Time, batchSize int) (int, error) {
+	asOf = domain.SnapshotDay(asOf.Add(-time.Nanosecond)).AddDate(0, 0, 1)
+
+	var afterWalletID int64
+	total := 0
+	for {
+		lastWalletID, count, err := u.snapshotRepo.CreateBatch(ctx, asOf, afterWalletID, batchSize)
+		if err != nil {
+			return total, apperrors.WrapError(err, "failed to snapshot balances")
+		}
+		total += count
+
+		if count < batchSize {
+			return total, nil
+		}
+		afterWalletID = lastWalletID
+	}
+}
+
+// LatestSnapshot returns when the newest snapshot was taken, or the zero time
+func (u *balanceUsecase) LatestSnapshot(ctx context.Context) (time.Time, error) {
+	asOf, err := u.snapshotRepo.GetLatestAsOf(ctx)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrResourceNotFound) {
+			return time.Time{}, nil
+		}
+		return time.Time{}, apperrors.WrapError(err, "failed to get latest balance snapshot")
+	}
+	return asOf, nil
+}
+
+// balanceAt works out a wallet's balance just before at. The latest daily
+// snapshot bounds how far back transactions are searched; without one the
+// running balances on the transactions either side of at are used.
+func balanceAt(
+	ctx context.Context,
+	transactionRepo domain.TransactionRepository,
+	snapshotRepo domain.BalanceSnapshotRepository,
+	wallet *domain.Wallet,
+	at time.Time,
+) (domain.Amount, error) {
+	// Transaction times and snapshot boundaries are stored as UTC wall clocks
+	at = at.UTC()
+
+	var since time.Time
+	snapshot, err := snapshotRepo.GetLatest(ctx, wallet.ID, at)
+	switch {
+	case err == nil:
+		if snapshot.AsOf.Equal(at) {
+			return snapshot.Balance, nil
+		}
+		since = snapshot.AsOf
+	case !errors.Is(err, apperrors.ErrResourceNotFound):
+		return 0, apperrors.WrapError(err, "failed to get balance snapshot")
+	}
+
+	last, err := transactionRepo.GetLastBetween(ctx, wallet.ID, since, at)
+	if err == nil {
+		return last.BalanceAfter, nil
+	}
+	if !errors.Is(err, apperrors.ErrResourceNotFound) {
+		return 0, apperrors.WrapError(err, "failed to get balance")
+	}
+
+	// Nothing moved between the snapshot and at
+	if snapshot != nil {
+		return snapshot.Balance, nil
+	}
+
+	first, err := transactionRepo.GetFirstFrom(ctx, wallet.ID, at)
+	if err == nil {
+		return first.BalanceBefore, nil
+	}
+	if !errors.Is(err, apperrors.ErrResourceNotFound) {
+		return 0, apperrors.WrapError(err, "failed to get balance")
+	}
+
+	// Nothing has ever moved, so the balance has always been what it is now
+	return wallet.Balance, nil
+}
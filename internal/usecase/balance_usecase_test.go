@@ -0,0 +1,213 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	"github.com/ravindu/wallet-app-service/internal/usecase"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockBalanceSnapshotRepository struct {
+	mock.Mock
+}
+
+func (m *mockBalanceSnapshotRepository) CreateBatch(ctx context.Context, asOf time.Time, afterWalletID int64, limit int) (int64, int, error) {
+	args := m.Called(ctx, asOf, afterWalletID, limit)
+	return args.Get(0).(int64), args.Int(1), args.Error(2)
+}
+
+func (m *mockBalanceSnapshotRepository) GetLatest(ctx context.Context, walletID int64, at time.Time) (*domain.BalanceSnapshot, error) {
+	args := m.Called(ctx, walletID, at)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.BalanceSnapshot), args.Error(1)
+}
+
+func (m *mockBalanceSnapshotRepository) GetLatestAsOf(ctx context.Context) (time.Time, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(time.Time), args.Error(1)
+}
+
+func TestGetBalanceAt(t *testing.T) {
+	ctx := context.Background()
+	snapshotDay := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	at := snapshotDay.Add(15 * time.Hour)
+	wallet := &domain.Wallet{ID: 7, UserID: 1, Balance: domain.NewAmount(500), Currency: domain.USD}
+	snapshot := &domain.BalanceSnapshot{WalletID: 7, AsOf: snapshotDay, Balance: domain.NewAmount(80)}
+
+	tests := []struct {
+		name            string
+		at              time.Time
+		setupMocks      func(*mockTransactionRepository, *mockBalanceSnapshotRepository)
+		expectedBalance domain.Amount
+		expectedError   error
+	}{
+		{
+			name: "end of day answered by the snapshot alone",
+			at:   snapshotDay,
+			setupMocks: func(transactionRepo *mockTransactionRepository, snapshotRepo *mockBalanceSnapshotRepository) {
+				snapshotRepo.On("GetLatest", ctx, int64(7), snapshotDay).Return(snapshot, nil)
+			},
+			expectedBalance: domain.NewAmount(80),
+		},
+		{
+			name: "transactions since the snapshot",
+			at:   at,
+			setupMocks: func(transactionRepo *mockTransactionRepository, snapshotRepo *mockBalanceSnapshotRepository) {
+				snapshotRepo.On("GetLatest", ctx, int64(7), at).Return(snapshot, nil)
+				transactionRepo.On("GetLastBetween", ctx, int64(7), snapshotDay, at).
+					Return(&domain.Transaction{ID: 21, BalanceAfter: domain.NewAmount(95)}, nil)
+			},
+			expectedBalance: domain.NewAmount(95),
+		},
+		{
+			name: "nothing moved since the snapshot",
+			at:   at,
+			setupMocks: func(transactionRepo *mockTransactionRepository, snapshotRepo *mockBalanceSnapshotRepository) {
+				snapshotRepo.On("GetLatest", ctx, int64(7), at).Return(snapshot, nil)
+				transactionRepo.On("GetLastBetween", ctx, int64(7), snapshotDay, at).Return(nil, apperrors.ErrResourceNotFound)
+			},
+			expectedBalance: domain.NewAmount(80),
+		},
+		{
+			name: "no snapshot yet",
+			at:   at,
+			setupMocks: func(transactionRepo *mockTransactionRepository, snapshotRepo *mockBalanceSnapshotRepository) {
+				snapshotRepo.On("GetLatest", ctx, int64(7), at).Return(nil, apperrors.ErrResourceNotFound)
+				transactionRepo.On("GetLastBetween", ctx, int64(7), time.Time{}, at).
+					Return(&domain.Transaction{ID: 21, BalanceAfter: domain.NewAmount(95)}, nil)
+			},
+			expectedBalance: domain.NewAmount(95),
+		},
+		{
+			name: "before the wallet's first transaction",
+			at:   at,
+			setupMocks: func(transactionRepo *mockTransactionRepository, snapshotRepo *mockBalanceSnapshotRepository) {
+				snapshotRepo.On("GetLatest", ctx, int64(7), at).Return(nil, apperrors.ErrResourceNotFound)
+				transactionRepo.On("GetLastBetween", ctx, int64(7), time.Time{}, at).Return(nil, apperrors.ErrResourceNotFound)
+				transactionRepo.On("GetFirstFrom", ctx, int64(7), at).
+					Return(&domain.Transaction{ID: 21, BalanceBefore: domain.NewAmount(0)}, nil)
+			},
+			expectedBalance: domain.NewAmount(0),
+		},
+		{
+			name: "wallet that never moved",
+			at:   at,
+			setupMocks: func(transactionRepo *mockTransactionRepository, snapshotRepo *mockBalanceSnapshotRepository) {
+				snapshotRepo.On("GetLatest", ctx, int64(7), at).Return(nil, apperrors.ErrResourceNotFound)
+				transactionRepo.On("GetLastBetween", ctx, int64(7), time.Time{}, at).Return(nil, apperrors.ErrResourceNotFound)
+				transactionRepo.On("GetFirstFrom", ctx, int64(7), at).Return(nil, apperrors.ErrResourceNotFound)
+			},
+			expectedBalance: domain.NewAmount(500),
+		},
+		{
+			name: "time given in another zone",
+			at:   at.In(time.FixedZone("UTC+2", 2*60*60)),
+			setupMocks: func(transactionRepo *mockTransactionRepository, snapshotRepo *mockBalanceSnapshotRepository) {
+				snapshotRepo.On("GetLatest", ctx, int64(7), at).Return(snapshot, nil)
+				transactionRepo.On("GetLastBetween", ctx, int64(7), snapshotDay, at).
+					Return(&domain.Transaction{ID: 21, BalanceAfter: domain.NewAmount(95)}, nil)
+			},
+			expectedBalance: domain.NewAmount(95),
+		},
+		{
+			name:          "time in the future",
+			at:            time.Now().Add(time.Hour),
+			setupMocks:    func(*mockTransactionRepository, *mockBalanceSnapshotRepository) {},
+			expectedError: apperrors.ErrInvalidInput,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			userRepo := new(mockUserRepository)
+			walletRepo := new(mockWalletRepository)
+			transactionRepo := new(mockTransactionRepository)
+			snapshotRepo := new(mockBalanceSnapshotRepository)
+			userRepo.On("GetByID", ctx, int64(1)).Return(&domain.User{ID: 1}, nil).Maybe()
+			walletRepo.On("ListByUserID", ctx, int64(1)).Return([]*domain.Wallet{wallet}, nil).Maybe()
+			tc.setupMocks(transactionRepo, snapshotRepo)
+
+			balanceUsecase := usecase.NewBalanceUsecase(userRepo, walletRepo, transactionRepo, snapshotRepo)
+			balance, err := balanceUsecase.GetBalanceAt(ctx, 1, domain.WalletSelector{}, tc.at)
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				assert.Nil(t, balance)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedBalance, balance.Balance)
+				assert.Equal(t, int64(7), balance.WalletID)
+				assert.Equal(t, domain.USD, balance.Currency)
+				assert.Equal(t, tc.at.UTC(), balance.At)
+			}
+
+			transactionRepo.AssertExpectations(t)
+			snapshotRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSnapshotBalances(t *testing.T) {
+	ctx := context.Background()
+	asOf := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("walks the wallets batch by batch", func(t *testing.T) {
+		snapshotRepo := new(mockBalanceSnapshotRepository)
+		snapshotRepo.On("CreateBatch", ctx, asOf, int64(0), 2).Return(int64(4), 2, nil).Once()
+		snapshotRepo.On("CreateBatch", ctx, asOf, int64(4), 2).Return(int64(9), 2, nil).Once()
+		snapshotRepo.On("CreateBatch", ctx, asOf, int64(9), 2).Return(int64(12), 1, nil).Once()
+
+		balanceUsecase := usecase.NewBalanceUsecase(nil, nil, nil, snapshotRepo)
+		count, err := balanceUsecase.SnapshotBalances(ctx, asOf, 2)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 5, count)
+		snapshotRepo.AssertExpectations(t)
+	})
+
+	t.Run("snapshots the midnight ending the day", func(t *testing.T) {
+		snapshotRepo := new(mockBalanceSnapshotRepository)
+		snapshotRepo.On("CreateBatch", ctx, asOf, int64(0), 2).Return(int64(4), 1, nil).Once()
+
+		// 18:30 on 30 April in UTC-5 is 23:30 UTC, so 30 April is the day to close
+		within := time.Date(2024, 4, 30, 18, 30, 0, 0, time.FixedZone("UTC-5", -5*60*60))
+
+		balanceUsecase := usecase.NewBalanceUsecase(nil, nil, nil, snapshotRepo)
+		_, err := balanceUsecase.SnapshotBalances(ctx, within, 2)
+
+		assert.NoError(t, err)
+		snapshotRepo.AssertExpectations(t)
+	})
+
+	t.Run("stops at the first failure", func(t *testing.T) {
+		errDatabase := errors.New("connection reset")
+		snapshotRepo := new(mockBalanceSnapshotRepository)
+		snapshotRepo.On("CreateBatch", ctx, asOf, int64(0), 2).Return(int64(4), 2, nil).Once()
+		snapshotRepo.On("CreateBatch", ctx, asOf, int64(4), 2).Return(int64(0), 0, errDatabase).Once()
+
+		balanceUsecase := usecase.NewBalanceUsecase(nil, nil, nil, snapshotRepo)
+		count, err := balanceUsecase.SnapshotBalances(ctx, asOf, 2)
+
+		assert.ErrorIs(t, err, errDatabase)
+		assert.Equal(t, 2, count)
+	})
+}
+
+func TestLatestSnapshot(t *testing.T) {
+	ctx := context.Background()
+
+	snapshotRepo := new(mockBalanceSnapshotRepository)
+	snapshotRepo.On("GetLatestAsOf", ctx).Return(time.Time{}, apperrors.ErrResourceNotFound)
+
+	latest, err := usecase.NewBalanceUsecase(nil, nil, nil, snapshotRepo).LatestSnapshot(ctx)
+	assert.NoError(t, err)
+	assert.True(t, latest.IsZero())
+}
@@ -0,0 +1,42 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+)
+
+type feeUsecase struct {
+	schedules    domain.FeeSchedules
+	walletUserID int64
+}
+
+// NewFeeUsecase creates a fee use case pricing fees from schedules and paying
+// them into walletUserID's wallets. Without any schedules every transaction is free.
+func NewFeeUsecase(schedules domain.FeeSchedules, walletUserID int64) domain.FeeUsecase {
+	return &feeUsecase{
+		schedules:    schedules,
+		walletUserID: walletUserID,
+	}
+}
+
+// Quote prices the fee on a transaction made now, under the schedule in force
+func (u *feeUsecase) Quote(ctx context.Context, req domain.FeeQuoteRequest) (*domain.FeeQuote, error) {
+	if !slices.Contains(domain.FeeTransactionTypes, req.TransactionType) {
+		return nil, fmt.Errorf("%w: fees are only charged on %v transactions", apperrors.ErrInvalidInput, domain.FeeTransactionTypes)
+	}
+	if err := req.Currency.CheckPrecision(req.Amount); err != nil {
+		return nil, err
+	}
+
+	return u.schedules.Quote(req.TransactionType, req.Currency, req.Amount, time.Now())
+}
+
+// WalletUserID returns the platform user whose wallets fees are paid into
+func (u *feeUsecase) WalletUserID() int64 {
+	return u.walletUserID
+}
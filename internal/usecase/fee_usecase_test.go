@@ -0,0 +1,249 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	"github.com/ravindu/wallet-app-service/internal/usecase"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// testFeeUserID is the platform user whose wallets collect the test fees
+const testFeeUserID = int64(99)
+
+// newTestFeeUsecase charges 1% on transfers, at least 0.50, and 1 per withdrawal,
+// under version "v1", and pays them to testFeeUserID
+func newTestFeeUsecase(t *testing.T) domain.FeeUsecase {
+	schedules, err := domain.NewFeeSchedules([]domain.FeeSchedule{{
+		Version:       "v1",
+		EffectiveFrom: time.Now().Add(-time.Hour),
+		Rules: []domain.FeeRule{
+			{TransactionType: domain.Transfer, Bps: 100, Min: domain.Amount(5000)},
+			{TransactionType: domain.Withdrawal, Flat: domain.NewAmount(1)},
+		},
+	}})
+	require.NoError(t, err)
+	return usecase.NewFeeUsecase(schedules, testFeeUserID)
+}
+
+// newTestFeeWallet is testFeeUserID's USD wallet, which walletRepo can find and lock
+func newTestFeeWallet(walletRepo *mockWalletRepository, balance domain.Amount) *domain.Wallet {
+	wallet := &domain.Wallet{ID: 9, UserID: testFeeUserID, Balance: balance, Currency: domain.USD}
+	walletRepo.On("ListByUserID", mock.Anything, testFeeUserID).Return([]*domain.Wallet{wallet}, nil).Maybe()
+	walletRepo.On("GetByIDForUpdate", mock.Anything, wallet.ID).Return(wallet, nil).Maybe()
+	return wallet
+}
+
+func TestFeeQuote(t *testing.T) {
+	ctx := context.Background()
+	uc := newTestFeeUsecase(t)
+
+	tests := []struct {
+		name            string
+		req             domain.FeeQuoteRequest
+		expectedFee     domain.Amount
+		expectedVersion string
+		expectedError   error
+	}{
+		{
+			name:            "priced transfer",
+			req:             domain.FeeQuoteRequest{TransactionType: domain.Transfer, Currency: domain.USD, Amount: domain.NewAmount(200)},
+			expectedFee:     domain.NewAmount(2),
+			expectedVersion: "v1",
+		},
+		{
+			name:            "free deposit",
+			req:             domain.FeeQuoteRequest{TransactionType: domain.Deposit, Currency: domain.USD, Amount: domain.NewAmount(200)},
+			expectedVersion: "v1",
+		},
+		{
+			name:          "type fees are not charged on",
+			req:           domain.FeeQuoteRequest{TransactionType: domain.Refund, Currency: domain.USD, Amount: domain.NewAmount(200)},
+			expectedError: apperrors.ErrInvalidInput,
+		},
+		{
+			name:          "more decimals than the currency allows",
+			req:           domain.FeeQuoteRequest{TransactionType: domain.Transfer, Currency: domain.JPY, Amount: domain.Amount(15)},
+			expectedError: apperrors.ErrAmountPrecision,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			quote, err := uc.Quote(ctx, tc.req)
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedFee, quote.Fee)
+			assert.Equal(t, tc.req.Amount+tc.expectedFee, quote.Total)
+			assert.Equal(t, tc.expectedVersion, quote.Version)
+		})
+	}
+}
+
+func TestTransfer_WithFee(t *testing.T) {
+	ctx := context.Background()
+
+	newFixture := func(balance domain.Amount) (*mockTransactionRepository, *mockOutboxRepository, *domain.Wallet, domain.WalletUsecase) {
+		senderWallet := &domain.Wallet{ID: 1, UserID: 1, Balance: balance, Currency: domain.USD}
+		receiverWallet := &domain.Wallet{ID: 2, UserID: 2, Balance: domain.NewAmount(50), Currency: domain.USD}
+
+		userRepo := new(mockUserRepository)
+		walletRepo := new(mockWalletRepository)
+		transactionRepo := new(mockTransactionRepository)
+		outboxRepo := newMockOutboxRepository()
+
+		userRepo.On("GetByID", ctx, int64(1)).Return(&domain.User{ID: 1}, nil)
+		userRepo.On("GetByID", ctx, int64(2)).Return(&domain.User{ID: 2}, nil)
+		walletRepo.On("ListByUserID", ctx, int64(1)).Return([]*domain.Wallet{senderWallet}, nil)
+		walletRepo.On("ListByUserID", ctx, int64(2)).Return([]*domain.Wallet{receiverWallet}, nil)
+		walletRepo.On("GetByIDForUpdate", ctx, int64(1)).Return(senderWallet, nil)
+		walletRepo.On("GetByIDForUpdate", ctx, int64(2)).Return(receiverWallet, nil)
+		walletRepo.On("Update", ctx, mock.AnythingOfType("*domain.Wallet")).Return(nil)
+		transactionRepo.On("Create", ctx, mock.AnythingOfType("*domain.Transaction")).Return(nil)
+		feeWallet := newTestFeeWallet(walletRepo, domain.NewAmount(10))
+
+		uc := usecase.NewWalletUsecase(userRepo, walletRepo, transactionRepo, newMockLedgerRepository(1, 2, feeWallet.ID),
+			outboxRepo, newMockWebhookRepository(), nil, newTestFeeUsecase(t), nil, &mockUnitOfWork{}, nil)
+		return transactionRepo, outboxRepo, feeWallet, uc
+	}
+
+	req := domain.TransferRequest{SenderID: 1, ReceiverID: 2, Amount: domain.NewAmount(30)}
+
+	t.Run("fee charged on top", func(t *testing.T) {
+		transactionRepo, outboxRepo, feeWallet, uc := newFixture(domain.NewAmount(100))
+
+		transaction, err := uc.Transfer(ctx, req)
+		require.NoError(t, err)
+
+		// The transfer row shows the fee and the schedule it came from
+		require.NotNil(t, transaction.Fee)
+		assert.Equal(t, domain.Amount(5000), *transaction.Fee)
+		assert.Equal(t, "v1", transaction.FeeVersion)
+		assert.Equal(t, domain.NewAmount(70), transaction.BalanceAfter)
+
+		// The fee is its own row, linked to the transfer but not part of it, and
+		// paid into the fee wallet with a row linked to the fee
+		var fee, collected *domain.Transaction
+		for _, call := range transactionRepo.Calls {
+			switch tr := call.Arguments.Get(1).(*domain.Transaction); tr.Type {
+			case domain.Fee:
+				fee = tr
+			case domain.FeeCollected:
+				collected = tr
+			}
+		}
+		require.NotNil(t, fee)
+		assert.Equal(t, int64(1), fee.WalletID)
+		assert.Equal(t, domain.Amount(5000), fee.Amount)
+		assert.Equal(t, domain.NewAmount(70), fee.BalanceBefore)
+		assert.Equal(t, domain.Amount(695000), fee.BalanceAfter)
+		assert.Equal(t, "v1", fee.FeeVersion)
+		assert.Equal(t, &transaction.ID, fee.ParentTransactionID)
+		assert.Empty(t, fee.CorrelationID)
+
+		require.NotNil(t, collected)
+		assert.Equal(t, feeWallet.ID, collected.WalletID)
+		assert.Equal(t, domain.Amount(5000), collected.Amount)
+		assert.Equal(t, &fee.ID, collected.ParentTransactionID)
+		assert.Equal(t, domain.Amount(105000), feeWallet.Balance)
+
+		assert.Equal(t, []domain.EventType{
+			domain.WalletDebited,
+			domain.WalletCredited,
+			domain.WalletDebited,
+			domain.WalletCredited,
+			domain.TransferCompleted,
+		}, recordedEvents(outboxRepo))
+	})
+
+	t.Run("fee not covered", func(t *testing.T) {
+		_, _, _, uc := newFixture(domain.Amount(302000))
+
+		_, err := uc.Transfer(ctx, req)
+		assert.ErrorIs(t, err, apperrors.ErrInsufficientFunds)
+	})
+}
+
+func TestCompensate_RefundsFee(t *testing.T) {
+	ctx := context.Background()
+	parentID := int64(20)
+
+	tests := []struct {
+		name           string
+		refund         domain.Amount
+		alreadyUndone  domain.Amount
+		expectFee      bool
+		expectedType   domain.TransactionType
+		expectedAmount domain.Amount
+	}{
+		{name: "reversal gives the fee back", expectFee: true, expectedType: domain.Reversal, expectedAmount: domain.NewAmount(81)},
+		{name: "partial refund keeps the fee", refund: domain.NewAmount(10), expectedAmount: domain.NewAmount(60)},
+		{name: "last refund gives the fee back", refund: domain.NewAmount(10), alreadyUndone: domain.NewAmount(20), expectFee: true, expectedType: domain.Refund, expectedAmount: domain.NewAmount(61)},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			wallet := &domain.Wallet{ID: 1, UserID: 1, Balance: domain.NewAmount(50), Currency: domain.USD}
+			fee := domain.NewAmount(1)
+			withdrawal := &domain.Transaction{ID: parentID, WalletID: 1, Type: domain.Withdrawal, Amount: domain.NewAmount(30), ReversedAmount: tc.alreadyUndone, Fee: &fee}
+			feeRow := &domain.Transaction{ID: 21, WalletID: 1, Type: domain.Fee, Amount: fee, ParentTransactionID: &parentID}
+			collectedRow := &domain.Transaction{ID: 22, WalletID: 9, Type: domain.FeeCollected, Amount: fee, ParentTransactionID: &feeRow.ID}
+
+			walletRepo := new(mockWalletRepository)
+			transactionRepo := new(mockTransactionRepository)
+			walletRepo.On("GetByIDForUpdate", ctx, int64(1)).Return(wallet, nil)
+			walletRepo.On("Update", ctx, mock.AnythingOfType("*domain.Wallet")).Return(nil)
+			feeWallet := newTestFeeWallet(walletRepo, domain.NewAmount(10))
+			transactionRepo.On("GetByIDForUpdate", ctx, parentID).Return(withdrawal, nil)
+			transactionRepo.On("GetChildForUpdate", ctx, parentID, domain.Fee).Return(feeRow, nil).Maybe()
+			transactionRepo.On("GetChildForUpdate", ctx, feeRow.ID, domain.FeeCollected).Return(collectedRow, nil).Maybe()
+			transactionRepo.On("Create", ctx, mock.AnythingOfType("*domain.Transaction")).Return(nil)
+			transactionRepo.On("UpdateReversal", ctx, mock.AnythingOfType("*domain.Transaction")).Return(nil)
+
+			uc := usecase.NewWalletUsecase(new(mockUserRepository), walletRepo, transactionRepo, newMockLedgerRepository(1, feeWallet.ID),
+				newMockOutboxRepository(), newMockWebhookRepository(), nil, newTestFeeUsecase(t), nil, &mockUnitOfWork{}, nil)
+
+			var response *domain.ReversalResponse
+			var err error
+			if tc.refund == 0 {
+				response, err = uc.Reverse(ctx, domain.ReversalRequest{TransactionID: parentID})
+			} else {
+				response, err = uc.Refund(ctx, domain.ReversalRequest{TransactionID: parentID, Amount: tc.refund})
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedAmount, wallet.Balance)
+
+			if !tc.expectFee {
+				require.Len(t, response.Transactions, 1)
+				transactionRepo.AssertNotCalled(t, "GetChildForUpdate", mock.Anything, mock.Anything, mock.Anything)
+				assert.Equal(t, domain.NewAmount(10), feeWallet.Balance)
+				return
+			}
+
+			require.Len(t, response.Transactions, 2)
+			refunded := response.Transactions[1]
+			assert.Equal(t, tc.expectedType, refunded.Type)
+			assert.Equal(t, fee, refunded.Amount)
+			assert.Equal(t, &feeRow.ID, refunded.OriginalTransactionID)
+			assert.Equal(t, fee, feeRow.ReversedAmount)
+			transactionRepo.AssertCalled(t, "UpdateReversal", ctx, feeRow)
+
+			// The fee wallet gives it back, undoing its own row
+			assert.Equal(t, domain.NewAmount(9), feeWallet.Balance)
+			assert.Equal(t, fee, collectedRow.ReversedAmount)
+			transactionRepo.AssertCalled(t, "UpdateReversal", ctx, collectedRow)
+			transactionRepo.AssertCalled(t, "Create", ctx, mock.MatchedBy(func(tr *domain.Transaction) bool {
+				return tr.WalletID == feeWallet.ID && tr.Type == tc.expectedType && *tr.OriginalTransactionID == collectedRow.ID
+			}))
+		})
+	}
+}
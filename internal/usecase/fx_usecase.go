@@ -0,0 +1,138 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+)
+
+// defaultQuoteTTL is how long a quoted rate holds when the config doesn't say
+const defaultQuoteTTL = 30 * time.Second
+
+type fxUsecase struct {
+	provider  domain.RateProvider
+	quoteRepo domain.FXQuoteRepository
+	spreadBps int
+	quoteTTL  time.Duration
+}
+
+// NewFXUsecase creates an fx use case pricing conversions with provider's
+// mid-market rates less spreadBps basis points
+func NewFXUsecase(
+	provider domain.RateProvider,
+	quoteRepo domain.FXQuoteRepository,
+	spreadBps int,
+	quoteTTL time.Duration,
+) domain.FXUsecase {
+	if quoteTTL <= 0 {
+		quoteTTL = defaultQuoteTTL
+	}
+
+	return &fxUsecase{
+		provider:  provider,
+		quoteRepo: quoteRepo,
+		spreadBps: spreadBps,
+		quoteTTL:  quoteTTL,
+	}
+}
+
+// CreateQuote locks the current rate between two currencies for the quote TTL
+func (u *fxUsecase) CreateQuote(ctx context.Context, req domain.CreateQuoteRequest) (*domain.FXQuote, error) {
+	from, err := domain.ParseCurrency(string(req.FromCurrency))
+	if err != nil {
+		return nil, err
+	}
+	to, err := domain.ParseCurrency(string(req.ToCurrency))
+	if err != nil {
+		return nil, err
+	}
+	if from == to {
+		return nil, fmt.Errorf("%w: from_currency and to_currency must differ", apperrors.ErrInvalidInput)
+	}
+
+	quote, err := u.price(ctx, req.UserID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := u.quoteRepo.Create(ctx, quote); err != nil {
+		return nil, apperrors.WrapError(err, "failed to save fx quote")
+	}
+
+	return quote, nil
+}
+
+// UseQuote spends a quote on a conversion. Without a quote ID the current rate
+// is priced and recorded as an already used quote, so every conversion can be
+// traced back to the rate it got.
+func (u *fxUsecase) UseQuote(ctx context.Context, userID int64, quoteID string, from, to domain.Currency) (*domain.FXQuote, error) {
+	now := time.Now()
+
+	if quoteID == "" {
+		quote, err := u.price(ctx, userID, from, to)
+		if err != nil {
+			return nil, err
+		}
+		if err := quote.Use(from, to, now); err != nil {
+			return nil, err
+		}
+		if err := u.quoteRepo.Create(ctx, quote); err != nil {
+			return nil, apperrors.WrapError(err, "failed to save fx quote")
+		}
+		return quote, nil
+	}
+
+	quote, err := u.quoteRepo.GetByIDForUpdate(ctx, quoteID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrResourceNotFound) {
+			return nil, apperrors.ErrQuoteNotFound
+		}
+		return nil, apperrors.WrapError(err, "failed to get fx quote")
+	}
+
+	// Someone else's quote is treated as unknown rather than revealed
+	if quote.UserID != userID {
+		return nil, apperrors.ErrQuoteNotFound
+	}
+
+	if err := quote.Use(from, to, now); err != nil {
+		return nil, err
+	}
+
+	if err := u.quoteRepo.MarkUsed(ctx, quote); err != nil {
+		return nil, apperrors.WrapError(err, "failed to mark fx quote used")
+	}
+
+	return quote, nil
+}
+
+// price builds an unsaved quote at the provider's current rate
+func (u *fxUsecase) price(ctx context.Context, userID int64, from, to domain.Currency) (*domain.FXQuote, error) {
+	midRate, err := u.provider.Rate(ctx, from, to)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrRateUnavailable) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", apperrors.ErrRateUnavailable, err)
+	}
+
+	rate, err := midRate.WithSpread(u.spreadBps)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.FXQuote{
+		UserID:       userID,
+		FromCurrency: from,
+		ToCurrency:   to,
+		MidRate:      midRate,
+		Rate:         rate,
+		SpreadBps:    u.spreadBps,
+		Source:       u.provider.Name(),
+		ExpiresAt:    time.Now().Add(u.quoteTTL),
+	}, nil
+}
@@ -0,0 +1,205 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	"github.com/ravindu/wallet-app-service/internal/usecase"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type mockFXQuoteRepository struct {
+	mock.Mock
+}
+
+func (m *mockFXQuoteRepository) Create(ctx context.Context, quote *domain.FXQuote) error {
+	args := m.Called(ctx, quote)
+	return args.Error(0)
+}
+
+func (m *mockFXQuoteRepository) GetByIDForUpdate(ctx context.Context, id string) (*domain.FXQuote, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.FXQuote), args.Error(1)
+}
+
+func (m *mockFXQuoteRepository) MarkUsed(ctx context.Context, quote *domain.FXQuote) error {
+	args := m.Called(ctx, quote)
+	return args.Error(0)
+}
+
+type mockRateProvider struct {
+	mock.Mock
+}
+
+func (m *mockRateProvider) Name() string {
+	return "mock"
+}
+
+func (m *mockRateProvider) Rate(ctx context.Context, from, to domain.Currency) (domain.Rate, error) {
+	args := m.Called(ctx, from, to)
+	return args.Get(0).(domain.Rate), args.Error(1)
+}
+
+// mustParseRate parses a rate known to be valid
+func mustParseRate(t *testing.T, s string) domain.Rate {
+	rate, err := domain.ParseRate(s)
+	require.NoError(t, err)
+	return rate
+}
+
+func TestCreateQuote(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name          string
+		req           domain.CreateQuoteRequest
+		providerError error
+		expectedError error
+	}{
+		{
+			name: "valid pair",
+			req:  domain.CreateQuoteRequest{UserID: 1, FromCurrency: "usd", ToCurrency: "EUR"},
+		},
+		{
+			name:          "same currency",
+			req:           domain.CreateQuoteRequest{UserID: 1, FromCurrency: domain.USD, ToCurrency: domain.USD},
+			expectedError: apperrors.ErrInvalidInput,
+		},
+		{
+			name:          "unknown currency",
+			req:           domain.CreateQuoteRequest{UserID: 1, FromCurrency: domain.USD, ToCurrency: "XYZ"},
+			expectedError: apperrors.ErrUnsupportedCurrency,
+		},
+		{
+			name:          "provider failing",
+			req:           domain.CreateQuoteRequest{UserID: 1, FromCurrency: domain.USD, ToCurrency: domain.EUR},
+			providerError: errors.New("connection refused"),
+			expectedError: apperrors.ErrRateUnavailable,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			provider := new(mockRateProvider)
+			quoteRepo := new(mockFXQuoteRepository)
+
+			provider.On("Rate", ctx, domain.USD, domain.EUR).Return(mustParseRate(t, "0.92"), tc.providerError).Maybe()
+			quoteRepo.On("Create", ctx, mock.AnythingOfType("*domain.FXQuote")).Return(nil).Maybe()
+
+			uc := usecase.NewFXUsecase(provider, quoteRepo, 50, time.Minute)
+
+			quote, err := uc.CreateQuote(ctx, tc.req)
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				quoteRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, domain.USD, quote.FromCurrency)
+			assert.Equal(t, domain.EUR, quote.ToCurrency)
+			assert.Equal(t, "0.92", quote.MidRate.String())
+			assert.Equal(t, "0.9154", quote.Rate.String())
+			assert.Equal(t, "mock", quote.Source)
+			assert.Nil(t, quote.UsedAt)
+			assert.WithinDuration(t, time.Now().Add(time.Minute), quote.ExpiresAt, time.Second)
+		})
+	}
+}
+
+func TestUseQuote(t *testing.T) {
+	ctx := context.Background()
+	usedAt := time.Now().Add(-time.Second)
+
+	tests := []struct {
+		name          string
+		quoteID       string
+		quote         *domain.FXQuote
+		expectedError error
+	}{
+		{
+			name:    "priced on the spot",
+			quoteID: "",
+		},
+		{
+			name:    "valid quote",
+			quoteID: "q1",
+			quote:   &domain.FXQuote{ID: "q1", UserID: 1, FromCurrency: domain.USD, ToCurrency: domain.EUR, ExpiresAt: time.Now().Add(time.Minute)},
+		},
+		{
+			name:          "unknown quote",
+			quoteID:       "q1",
+			expectedError: apperrors.ErrQuoteNotFound,
+		},
+		{
+			name:          "another user's quote",
+			quoteID:       "q1",
+			quote:         &domain.FXQuote{ID: "q1", UserID: 2, FromCurrency: domain.USD, ToCurrency: domain.EUR, ExpiresAt: time.Now().Add(time.Minute)},
+			expectedError: apperrors.ErrQuoteNotFound,
+		},
+		{
+			name:          "expired quote",
+			quoteID:       "q1",
+			quote:         &domain.FXQuote{ID: "q1", UserID: 1, FromCurrency: domain.USD, ToCurrency: domain.EUR, ExpiresAt: time.Now().Add(-time.Second)},
+			expectedError: apperrors.ErrQuoteExpired,
+		},
+		{
+			name:          "quote already used",
+			quoteID:       "q1",
+			quote:         &domain.FXQuote{ID: "q1", UserID: 1, FromCurrency: domain.USD, ToCurrency: domain.EUR, ExpiresAt: time.Now().Add(time.Minute), UsedAt: &usedAt},
+			expectedError: apperrors.ErrQuoteExpired,
+		},
+		{
+			name:          "quote for another pair",
+			quoteID:       "q1",
+			quote:         &domain.FXQuote{ID: "q1", UserID: 1, FromCurrency: domain.EUR, ToCurrency: domain.USD, ExpiresAt: time.Now().Add(time.Minute)},
+			expectedError: apperrors.ErrCurrencyMismatch,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			provider := new(mockRateProvider)
+			quoteRepo := new(mockFXQuoteRepository)
+
+			provider.On("Rate", ctx, domain.USD, domain.EUR).Return(mustParseRate(t, "0.92"), nil).Maybe()
+			quoteRepo.On("Create", ctx, mock.AnythingOfType("*domain.FXQuote")).Return(nil).Maybe()
+			quoteRepo.On("MarkUsed", ctx, mock.AnythingOfType("*domain.FXQuote")).Return(nil).Maybe()
+			if tc.quote != nil {
+				quoteRepo.On("GetByIDForUpdate", ctx, "q1").Return(tc.quote, nil).Maybe()
+			} else {
+				quoteRepo.On("GetByIDForUpdate", ctx, "q1").Return(nil, apperrors.ErrResourceNotFound).Maybe()
+			}
+
+			uc := usecase.NewFXUsecase(provider, quoteRepo, 0, 0)
+
+			quote, err := uc.UseQuote(ctx, 1, tc.quoteID, domain.USD, domain.EUR)
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				quoteRepo.AssertNotCalled(t, "MarkUsed", mock.Anything, mock.Anything)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.NotNil(t, quote.UsedAt)
+			if tc.quoteID == "" {
+				// The spot rate is saved as a quote that is already used
+				quoteRepo.AssertCalled(t, "Create", ctx, quote)
+				quoteRepo.AssertNotCalled(t, "GetByIDForUpdate", mock.Anything, mock.Anything)
+			} else {
+				quoteRepo.AssertCalled(t, "MarkUsed", ctx, quote)
+			}
+		})
+	}
+}
@@ -0,0 +1,336 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+	"github.com/redis/go-redis/v9"
+)
+
+// defaultHoldTTL is how long a hold lasts when neither the request nor the config says
+const defaultHoldTTL = 7 * 24 * time.Hour
+
+type holdUsecase struct {
+	walletUsecase domain.WalletUsecase
+	limitUsecase  domain.LimitUsecase
+	userRepo      domain.UserRepository
+	walletRepo    domain.WalletRepository
+	holdRepo      domain.HoldRepository
+	unitOfWork    domain.UnitOfWork
+	redisClient   *redis.Client
+	defaultTTL    time.Duration
+}
+
+// NewHoldUsecase creates a hold use case for reserving and capturing wallet funds
+func NewHoldUsecase(
+	walletUsecase domain.WalletUsecase,
+	limitUsecase domain.LimitUsecase,
+	userRepo domain.UserRepository,
+	walletRepo domain.WalletRepository,
+	holdRepo domain.HoldRepository,
+	unitOfWork domain.UnitOfWork,
+	redisClient *redis.Client,
+	defaultTTL time.Duration,
+) domain.HoldUsecase {
+	if defaultTTL <= 0 {
+		defaultTTL = defaultHoldTTL
+	}
+
+	return &holdUsecase{
+		walletUsecase: walletUsecase,
+		limitUsecase:  limitUsecase,
+		userRepo:      userRepo,
+		walletRepo:    walletRepo,
+		holdRepo:      holdRepo,
+		unitOfWork:    unitOfWork,
+		redisClient:   redisClient,
+		defaultTTL:    defaultTTL,
+	}
+}
+
+// PlaceHold reserves money in the user's wallet. A hold whose capture would
+// break the user's withdrawal limits is refused, rather than left to expire.
+func (u *holdUsecase) PlaceHold(ctx context.Context, req domain.PlaceHoldRequest) (*domain.Hold, error) {
+	if req.Amount <= 0 {
+		return nil, apperrors.ErrInvalidAmount
+	}
+
+	now := time.Now()
+	expiresAt := req.ExpiresAt
+	if expiresAt.IsZero() {
+		expiresAt = now.Add(u.defaultTTL)
+	} else if !expiresAt.After(now) {
+		return nil, fmt.Errorf("%w: expires_at must be in the future", apperrors.ErrInvalidInput)
+	}
+
+	var hold *domain.Hold
+
+	// The reservation and the hold row commit or roll back together
+	err := u.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		selected, err := selectWallet(ctx, u.walletRepo, req.UserID, req.WalletSelector)
+		if err != nil {
+			return err
+		}
+		wallet, err := u.lockWallet(ctx, selected.ID)
+		if err != nil {
+			return err
+		}
+
+		if err := u.checkLimits(ctx, wallet, req.Amount); err != nil {
+			return err
+		}
+
+		if err := wallet.Reserve(req.Amount); err != nil {
+			return err
+		}
+
+		if err := u.walletRepo.Update(ctx, wallet); err != nil {
+			return apperrors.WrapError(err, "failed to update wallet")
+		}
+
+		hold = &domain.Hold{
+			WalletID:    wallet.ID,
+			UserID:      wallet.UserID,
+			Amount:      req.Amount,
+			Status:      domain.HoldActive,
+			Description: req.Description,
+			ExpiresAt:   expiresAt,
+		}
+
+		if err := u.holdRepo.Create(ctx, hold); err != nil {
+			return apperrors.WrapError(err, "failed to create hold")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Clear cache since the available balance changed
+	invalidateBalanceCache(ctx, u.redisClient, hold.UserID)
+
+	return hold, nil
+}
+
+// GetHold returns a hold by ID
+func (u *holdUsecase) GetHold(ctx context.Context, holdID int64) (*domain.Hold, error) {
+	hold, err := u.holdRepo.GetByID(ctx, holdID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrResourceNotFound) {
+			return nil, apperrors.ErrHoldNotFound
+		}
+		return nil, apperrors.WrapError(err, "failed to get hold")
+	}
+	return hold, nil
+}
+
+// CaptureHold releases the hold and withdraws the captured part of it
+func (u *holdUsecase) CaptureHold(ctx context.Context, holdID int64, req domain.CaptureHoldRequest) (*domain.Hold, error) {
+	var hold *domain.Hold
+
+	err := u.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		var err error
+		hold, err = u.lockHold(ctx, holdID)
+		if err != nil {
+			return err
+		}
+
+		amount := req.Amount
+		if amount == 0 {
+			amount = hold.Amount
+		}
+		if err := hold.Capture(amount, time.Now()); err != nil {
+			return err
+		}
+
+		// Free the reservation first so the withdrawal can spend it
+		if err := u.releaseFunds(ctx, hold); err != nil {
+			return err
+		}
+
+		comment := req.Comment
+		if comment == "" {
+			comment = hold.Description
+		}
+		if comment == "" {
+			comment = fmt.Sprintf("Capture of hold %d", hold.ID)
+		}
+
+		// Runs inside this unit of work, so a failed withdrawal keeps the hold active.
+		// The hold only reserved the amount, so a withdrawal fee on top could make
+		// a capture the hold promised fail; captures aren't charged one. Nor are
+		// they checked against the limits again, since PlaceHold already was.
+		transaction, err := u.walletUsecase.Withdraw(ctx, domain.WithdrawRequest{
+			UserID:         hold.UserID,
+			WalletSelector: domain.WalletSelector{WalletID: hold.WalletID},
+			Amount:         amount,
+			Comment:        comment,
+			WaiveFee:       true,
+			SkipLimits:     true,
+		})
+		if err != nil {
+			return err
+		}
+		hold.TransactionID = &transaction.ID
+
+		if err := u.holdRepo.Update(ctx, hold); err != nil {
+			return apperrors.WrapError(err, "failed to update hold")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	invalidateBalanceCache(ctx, u.redisClient, hold.UserID)
+
+	return hold, nil
+}
+
+// VoidHold cancels a hold and makes its money available again
+func (u *holdUsecase) VoidHold(ctx context.Context, holdID int64) (*domain.Hold, error) {
+	var hold *domain.Hold
+
+	err := u.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		var err error
+		hold, err = u.lockHold(ctx, holdID)
+		if err != nil {
+			return err
+		}
+
+		if err := hold.Void(time.Now()); err != nil {
+			return err
+		}
+
+		if err := u.releaseFunds(ctx, hold); err != nil {
+			return err
+		}
+
+		if err := u.holdRepo.Update(ctx, hold); err != nil {
+			return apperrors.WrapError(err, "failed to update hold")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	invalidateBalanceCache(ctx, u.redisClient, hold.UserID)
+
+	return hold, nil
+}
+
+// ExpireHolds releases holds whose time has run out, handing the reserved funds
+// back to each wallet. Holds captured or voided since being listed are skipped.
+func (u *holdUsecase) ExpireHolds(ctx context.Context, limit int) (int, error) {
+	ids, err := u.holdRepo.ListExpiredIDs(ctx, time.Now(), limit)
+	if err != nil {
+		return 0, apperrors.WrapError(err, "failed to list expired holds")
+	}
+
+	expired := 0
+	for _, id := range ids {
+		var hold *domain.Hold
+		ended := false
+
+		err := u.unitOfWork.Do(ctx, func(ctx context.Context) error {
+			var err error
+			hold, err = u.lockHold(ctx, id)
+			if err != nil {
+				return err
+			}
+
+			// Captured or voided since it was listed
+			if hold.Status != domain.HoldActive {
+				return nil
+			}
+
+			if err := hold.Expire(time.Now()); err != nil {
+				return err
+			}
+
+			if err := u.releaseFunds(ctx, hold); err != nil {
+				return err
+			}
+
+			if err := u.holdRepo.Update(ctx, hold); err != nil {
+				return apperrors.WrapError(err, "failed to update hold")
+			}
+			ended = true
+			return nil
+		})
+		if err != nil {
+			return expired, fmt.Errorf("failed to expire hold %d: %w", id, err)
+		}
+
+		if ended {
+			expired++
+			invalidateBalanceCache(ctx, u.redisClient, hold.UserID)
+		}
+	}
+
+	return expired, nil
+}
+
+// checkLimits returns ErrLimitExceeded if capturing amount from the locked wallet
+// would break its owner's withdrawal limits
+func (u *holdUsecase) checkLimits(ctx context.Context, wallet *domain.Wallet, amount domain.Amount) error {
+	if u.limitUsecase == nil {
+		return nil
+	}
+
+	user, err := u.userRepo.GetByID(ctx, wallet.UserID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrResourceNotFound) {
+			return apperrors.ErrUserNotFound
+		}
+		return apperrors.WrapError(err, "failed to get user")
+	}
+
+	return u.limitUsecase.Check(ctx, user, wallet, domain.Withdrawal, amount)
+}
+
+// lockHold loads a hold and locks it until the unit of work ends
+func (u *holdUsecase) lockHold(ctx context.Context, holdID int64) (*domain.Hold, error) {
+	hold, err := u.holdRepo.GetByIDForUpdate(ctx, holdID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrResourceNotFound) {
+			return nil, apperrors.ErrHoldNotFound
+		}
+		return nil, apperrors.WrapError(err, "failed to get hold")
+	}
+	return hold, nil
+}
+
+// lockWallet loads a wallet and locks it until the unit of work ends
+func (u *holdUsecase) lockWallet(ctx context.Context, walletID int64) (*domain.Wallet, error) {
+	wallet, err := u.walletRepo.GetByIDForUpdate(ctx, walletID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrResourceNotFound) {
+			return nil, apperrors.ErrWalletNotFound
+		}
+		return nil, apperrors.WrapError(err, "failed to get wallet")
+	}
+	return wallet, nil
+}
+
+// releaseFunds gives the whole held amount back to the wallet's available balance
+func (u *holdUsecase) releaseFunds(ctx context.Context, hold *domain.Hold) error {
+	wallet, err := u.lockWallet(ctx, hold.WalletID)
+	if err != nil {
+		return err
+	}
+
+	if err := wallet.Release(hold.Amount); err != nil {
+		return err
+	}
+
+	if err := u.walletRepo.Update(ctx, wallet); err != nil {
+		return apperrors.WrapError(err, "failed to update wallet")
+	}
+	return nil
+}
@@ -0,0 +1,262 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	"github.com/ravindu/wallet-app-service/internal/usecase"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type mockHoldRepository struct {
+	mock.Mock
+}
+
+func (m *mockHoldRepository) Create(ctx context.Context, hold *domain.Hold) error {
+	args := m.Called(ctx, hold)
+	return args.Error(0)
+}
+
+func (m *mockHoldRepository) GetByID(ctx context.Context, id int64) (*domain.Hold, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Hold), args.Error(1)
+}
+
+func (m *mockHoldRepository) GetByIDForUpdate(ctx context.Context, id int64) (*domain.Hold, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Hold), args.Error(1)
+}
+
+func (m *mockHoldRepository) Update(ctx context.Context, hold *domain.Hold) error {
+	args := m.Called(ctx, hold)
+	return args.Error(0)
+}
+
+func (m *mockHoldRepository) ListExpiredIDs(ctx context.Context, now time.Time, limit int) ([]int64, error) {
+	args := m.Called(ctx, now, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]int64), args.Error(1)
+}
+
+// newHoldTestUsecase wires a hold use case over a real wallet use case, so captures
+// run the actual withdrawal rules, and fees if feeUsecase is set, against the mocked
+// wallet. Its owner is a standard tier user held to limitPolicy.
+func newHoldTestUsecase(
+	wallet *domain.Wallet,
+	holdRepo *mockHoldRepository,
+	feeUsecase domain.FeeUsecase,
+	limitPolicy domain.LimitPolicy,
+) (domain.HoldUsecase, *mockTransactionRepository) {
+	userRepo := new(mockUserRepository)
+	userRepo.On("GetByID", mock.Anything, wallet.UserID).Return(&domain.User{ID: wallet.UserID, Tier: domain.StandardTier}, nil).Maybe()
+
+	walletRepo := new(mockWalletRepository)
+	walletRepo.On("ListByUserID", mock.Anything, wallet.UserID).Return([]*domain.Wallet{wallet}, nil).Maybe()
+	walletRepo.On("GetByIDForUpdate", mock.Anything, wallet.ID).Return(wallet, nil).Maybe()
+	walletRepo.On("Update", mock.Anything, wallet).Return(nil).Maybe()
+
+	transactionRepo := new(mockTransactionRepository)
+	transactionRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Transaction")).Run(func(args mock.Arguments) {
+		args.Get(1).(*domain.Transaction).ID = 42
+	}).Return(nil).Maybe()
+
+	limitUsecase := usecase.NewLimitUsecase(userRepo, walletRepo, transactionRepo, limitPolicy)
+	walletUsecase := usecase.NewWalletUsecase(userRepo, walletRepo, transactionRepo, newMockLedgerRepository(wallet.ID), newMockOutboxRepository(), newMockWebhookRepository(), nil, feeUsecase, limitUsecase, &mockUnitOfWork{}, nil)
+	return usecase.NewHoldUsecase(walletUsecase, limitUsecase, userRepo, walletRepo, holdRepo, &mockUnitOfWork{}, nil, 0), transactionRepo
+}
+
+func TestPlaceHold(t *testing.T) {
+	tests := []struct {
+		name          string
+		req           domain.PlaceHoldRequest
+		expectedError error
+	}{
+		{
+			name: "within the available balance",
+			req:  domain.PlaceHoldRequest{UserID: 1, Amount: domain.NewAmount(60)},
+		},
+		{
+			name:          "above the available balance",
+			req:           domain.PlaceHoldRequest{UserID: 1, Amount: domain.NewAmount(81)},
+			expectedError: apperrors.ErrInsufficientFunds,
+		},
+		{
+			name:          "expiry in the past",
+			req:           domain.PlaceHoldRequest{UserID: 1, Amount: domain.NewAmount(10), ExpiresAt: time.Now().Add(-time.Hour)},
+			expectedError: apperrors.ErrInvalidInput,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// 20 of the 100 is already held
+			wallet := &domain.Wallet{ID: 1, UserID: 1, Balance: domain.NewAmount(100), HeldBalance: domain.NewAmount(20), Currency: domain.USD}
+			holdRepo := new(mockHoldRepository)
+			holdRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Hold")).Return(nil).Maybe()
+
+			uc, _ := newHoldTestUsecase(wallet, holdRepo, nil, nil)
+			hold, err := uc.PlaceHold(context.Background(), tc.req)
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				assert.Equal(t, domain.NewAmount(20), wallet.HeldBalance)
+				holdRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, domain.HoldActive, hold.Status)
+			assert.True(t, hold.ExpiresAt.After(time.Now().Add(6*24*time.Hour)))
+			assert.Equal(t, domain.NewAmount(80), wallet.HeldBalance)
+			assert.Equal(t, domain.NewAmount(100), wallet.Balance)
+		})
+	}
+}
+
+func TestPlaceHold_WithdrawalLimits(t *testing.T) {
+	wallet := &domain.Wallet{ID: 1, UserID: 1, Balance: domain.NewAmount(1000), Currency: domain.USD}
+	holdRepo := new(mockHoldRepository)
+
+	uc, _ := newHoldTestUsecase(wallet, holdRepo, nil, newTestLimitPolicy(t))
+
+	// Standard users may withdraw at most 500 at once, so a 600 hold could never be captured
+	_, err := uc.PlaceHold(context.Background(), domain.PlaceHoldRequest{UserID: 1, Amount: domain.NewAmount(600)})
+	assert.ErrorIs(t, err, apperrors.ErrLimitExceeded)
+	assert.Equal(t, domain.Amount(0), wallet.HeldBalance)
+	holdRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestCaptureHold(t *testing.T) {
+	ctx := context.Background()
+	wallet := &domain.Wallet{ID: 1, UserID: 1, Balance: domain.NewAmount(100), HeldBalance: domain.NewAmount(60), Currency: domain.USD}
+	hold := &domain.Hold{ID: 7, WalletID: 1, UserID: 1, Amount: domain.NewAmount(60), Status: domain.HoldActive, ExpiresAt: time.Now().Add(time.Hour)}
+
+	holdRepo := new(mockHoldRepository)
+	holdRepo.On("GetByIDForUpdate", ctx, int64(7)).Return(hold, nil)
+	holdRepo.On("Update", ctx, hold).Return(nil)
+
+	uc, transactionRepo := newHoldTestUsecase(wallet, holdRepo, nil, nil)
+
+	// A partial capture withdraws what was captured and releases the rest
+	captured, err := uc.CaptureHold(ctx, 7, domain.CaptureHoldRequest{Amount: domain.NewAmount(45)})
+	assert.NoError(t, err)
+	assert.Equal(t, domain.HoldCaptured, captured.Status)
+	assert.Equal(t, domain.NewAmount(45), captured.CapturedAmount)
+	assert.Equal(t, int64(42), *captured.TransactionID)
+	assert.Equal(t, domain.NewAmount(55), wallet.Balance)
+	assert.Equal(t, domain.Amount(0), wallet.HeldBalance)
+	transactionRepo.AssertCalled(t, "Create", mock.Anything, mock.MatchedBy(func(tr *domain.Transaction) bool {
+		return tr.Type == domain.Withdrawal && tr.Amount == domain.NewAmount(45)
+	}))
+
+	// Captured holds are done
+	_, err = uc.CaptureHold(ctx, 7, domain.CaptureHoldRequest{})
+	assert.ErrorIs(t, err, apperrors.ErrHoldNotActive)
+	_, err = uc.VoidHold(ctx, 7)
+	assert.ErrorIs(t, err, apperrors.ErrHoldNotActive)
+}
+
+func TestCaptureHold_NoWithdrawalFee(t *testing.T) {
+	ctx := context.Background()
+	// Everything in the wallet is held, so a fee on top of the capture couldn't be paid
+	wallet := &domain.Wallet{ID: 1, UserID: 1, Balance: domain.NewAmount(60), HeldBalance: domain.NewAmount(60), Currency: domain.USD}
+	hold := &domain.Hold{ID: 7, WalletID: 1, UserID: 1, Amount: domain.NewAmount(60), Status: domain.HoldActive, ExpiresAt: time.Now().Add(time.Hour)}
+
+	holdRepo := new(mockHoldRepository)
+	holdRepo.On("GetByIDForUpdate", ctx, int64(7)).Return(hold, nil)
+	holdRepo.On("Update", ctx, hold).Return(nil)
+
+	uc, transactionRepo := newHoldTestUsecase(wallet, holdRepo, newTestFeeUsecase(t), nil)
+
+	captured, err := uc.CaptureHold(ctx, 7, domain.CaptureHoldRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, domain.HoldCaptured, captured.Status)
+	assert.Equal(t, domain.Amount(0), wallet.Balance)
+	transactionRepo.AssertNotCalled(t, "Create", mock.Anything, mock.MatchedBy(func(tr *domain.Transaction) bool {
+		return tr.Type == domain.Fee || tr.Fee != nil
+	}))
+}
+
+func TestCaptureHold_LimitsCheckedWhenPlaced(t *testing.T) {
+	ctx := context.Background()
+	wallet := &domain.Wallet{ID: 1, UserID: 1, Balance: domain.NewAmount(1000), Currency: domain.USD}
+
+	holdRepo := new(mockHoldRepository)
+	var placed *domain.Hold
+	holdRepo.On("Create", ctx, mock.AnythingOfType("*domain.Hold")).Run(func(args mock.Arguments) {
+		placed = args.Get(1).(*domain.Hold)
+		placed.ID = 7
+	}).Return(nil)
+
+	uc, transactionRepo := newHoldTestUsecase(wallet, holdRepo, nil, newTestLimitPolicy(t))
+
+	expectUsage(transactionRepo, wallet.ID, []domain.TransactionType{domain.Withdrawal}, domain.LimitUsage{}, domain.LimitUsage{})
+	_, err := uc.PlaceHold(ctx, domain.PlaceHoldRequest{UserID: 1, Amount: domain.NewAmount(400)})
+	require.NoError(t, err)
+
+	// Other withdrawals fill the day's 1000 before the capture
+	transactionRepo.On("GetUsageSince", mock.Anything, wallet.ID, mock.Anything, mock.Anything).
+		Return(domain.LimitUsage{Amount: domain.NewAmount(900), Count: 2}, nil).Maybe()
+	holdRepo.On("GetByIDForUpdate", ctx, int64(7)).Return(placed, nil)
+	holdRepo.On("Update", ctx, placed).Return(nil)
+
+	captured, err := uc.CaptureHold(ctx, 7, domain.CaptureHoldRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, domain.HoldCaptured, captured.Status)
+	assert.Equal(t, domain.NewAmount(600), wallet.Balance)
+}
+
+func TestVoidHold(t *testing.T) {
+	ctx := context.Background()
+	wallet := &domain.Wallet{ID: 1, UserID: 1, Balance: domain.NewAmount(100), HeldBalance: domain.NewAmount(60), Currency: domain.USD}
+	hold := &domain.Hold{ID: 7, WalletID: 1, UserID: 1, Amount: domain.NewAmount(60), Status: domain.HoldActive, ExpiresAt: time.Now().Add(time.Hour)}
+
+	holdRepo := new(mockHoldRepository)
+	holdRepo.On("GetByIDForUpdate", ctx, int64(7)).Return(hold, nil)
+	holdRepo.On("Update", ctx, hold).Return(nil)
+
+	uc, transactionRepo := newHoldTestUsecase(wallet, holdRepo, nil, nil)
+
+	voided, err := uc.VoidHold(ctx, 7)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.HoldVoided, voided.Status)
+	assert.Equal(t, domain.NewAmount(100), wallet.AvailableBalance())
+	transactionRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestExpireHolds(t *testing.T) {
+	ctx := context.Background()
+	wallet := &domain.Wallet{ID: 1, UserID: 1, Balance: domain.NewAmount(100), HeldBalance: domain.NewAmount(30), Currency: domain.USD}
+	stale := &domain.Hold{ID: 1, WalletID: 1, UserID: 1, Amount: domain.NewAmount(30), Status: domain.HoldActive, ExpiresAt: time.Now().Add(-time.Minute)}
+	// Captured by someone else after it was listed
+	captured := &domain.Hold{ID: 2, WalletID: 1, UserID: 1, Amount: domain.NewAmount(10), Status: domain.HoldCaptured, ExpiresAt: time.Now().Add(-time.Minute)}
+
+	holdRepo := new(mockHoldRepository)
+	holdRepo.On("ListExpiredIDs", ctx, mock.AnythingOfType("time.Time"), 10).Return([]int64{1, 2}, nil)
+	holdRepo.On("GetByIDForUpdate", ctx, int64(1)).Return(stale, nil)
+	holdRepo.On("GetByIDForUpdate", ctx, int64(2)).Return(captured, nil)
+	holdRepo.On("Update", ctx, stale).Return(nil)
+
+	uc, _ := newHoldTestUsecase(wallet, holdRepo, nil, nil)
+
+	expired, err := uc.ExpireHolds(ctx, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, expired)
+	assert.Equal(t, domain.HoldExpired, stale.Status)
+	assert.Equal(t, domain.HoldCaptured, captured.Status)
+	assert.Equal(t, domain.Amount(0), wallet.HeldBalance)
+	holdRepo.AssertNotCalled(t, "Update", ctx, captured)
+}
@@ -0,0 +1,29 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+)
+
+type ledgerUsecase struct {
+	ledgerRepo domain.LedgerRepository
+}
+
+// NewLedgerUsecase creates a ledger use case for auditing the books
+func NewLedgerUsecase(ledgerRepo domain.LedgerRepository) domain.LedgerUsecase {
+	return &ledgerUsecase{
+		ledgerRepo: ledgerRepo,
+	}
+}
+
+// GetTrialBalance sums every ledger account so the books can be checked
+func (u *ledgerUsecase) GetTrialBalance(ctx context.Context) (*domain.TrialBalance, error) {
+	balances, err := u.ledgerRepo.GetAccountBalances(ctx)
+	if err != nil {
+		return nil, apperrors.WrapError(err, "failed to get account balances")
+	}
+
+	return domain.NewTrialBalance(balances)
+}
@@ -0,0 +1,122 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+)
+
+type limitUsecase struct {
+	userRepo        domain.UserRepository
+	walletRepo      domain.WalletRepository
+	transactionRepo domain.TransactionRepository
+	policy          domain.LimitPolicy
+}
+
+// NewLimitUsecase creates a limit use case enforcing policy. Without a policy
+// nothing is limited.
+func NewLimitUsecase(
+	userRepo domain.UserRepository,
+	walletRepo domain.WalletRepository,
+	transactionRepo domain.TransactionRepository,
+	policy domain.LimitPolicy,
+) domain.LimitUsecase {
+	return &limitUsecase{
+		userRepo:        userRepo,
+		walletRepo:      walletRepo,
+		transactionRepo: transactionRepo,
+		policy:          policy,
+	}
+}
+
+// Check returns ErrLimitExceeded if the transaction would break the user's tier's
+// rule for it. The wallet is locked by the caller, so the totals can't change
+// under the check.
+func (u *limitUsecase) Check(
+	ctx context.Context,
+	user *domain.User,
+	wallet *domain.Wallet,
+	transactionType domain.TransactionType,
+	amount domain.Amount,
+) error {
+	rule := u.policy.Rule(user.Tier, transactionType, wallet.Currency)
+	if rule == nil {
+		return nil
+	}
+
+	// Anything the amount breaks on its own needs no totals
+	if err := rule.Check(amount, domain.LimitUsage{}, domain.LimitUsage{}); err != nil || !rule.HasWindows() {
+		return err
+	}
+
+	daily, monthly, err := u.usage(ctx, wallet.ID, transactionType, time.Now())
+	if err != nil {
+		return err
+	}
+	return rule.Check(amount, daily, monthly)
+}
+
+// GetLimits shows the limits on each transaction type the user's tier limits
+// in the wallet's currency, with what is left of them
+func (u *limitUsecase) GetLimits(ctx context.Context, userID int64, selector domain.WalletSelector) (*domain.LimitsResponse, error) {
+	user, err := u.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrResourceNotFound) {
+			return nil, apperrors.ErrUserNotFound
+		}
+		return nil, apperrors.WrapError(err, "failed to get user")
+	}
+
+	wallet, err := selectWallet(ctx, u.walletRepo, user.ID, selector)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &domain.LimitsResponse{
+		UserID:   user.ID,
+		Tier:     user.Tier,
+		WalletID: wallet.ID,
+		Currency: wallet.Currency,
+		Limits:   []domain.LimitStatus{},
+	}
+
+	now := time.Now()
+	for _, transactionType := range domain.LimitTransactionTypes {
+		rule := u.policy.Rule(user.Tier, transactionType, wallet.Currency)
+		if rule == nil {
+			continue
+		}
+
+		daily, monthly, err := u.usage(ctx, wallet.ID, transactionType, now)
+		if err != nil {
+			return nil, err
+		}
+		response.Limits = append(response.Limits, rule.Status(daily, monthly))
+	}
+
+	return response, nil
+}
+
+// usage totals the wallet's transactions of a type over the daily and monthly windows up to now
+func (u *limitUsecase) usage(
+	ctx context.Context,
+	walletID int64,
+	transactionType domain.TransactionType,
+	now time.Time,
+) (domain.LimitUsage, domain.LimitUsage, error) {
+	types := domain.LimitRowTypes(transactionType)
+
+	daily, err := u.transactionRepo.GetUsageSince(ctx, walletID, types, now.Add(-domain.DailyLimitWindow))
+	if err != nil {
+		return domain.LimitUsage{}, domain.LimitUsage{}, apperrors.WrapError(err, "failed to get daily usage")
+	}
+	monthly, err := u.transactionRepo.GetUsageSince(ctx, walletID, types, now.Add(-domain.MonthlyLimitWindow))
+	if err != nil {
+		return domain.LimitUsage{}, domain.LimitUsage{}, apperrors.WrapError(err, "failed to get monthly usage")
+	}
+
+	return daily, monthly, nil
+}
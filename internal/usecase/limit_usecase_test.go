@@ -0,0 +1,167 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	"github.com/ravindu/wallet-app-service/internal/usecase"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestLimitPolicy limits standard withdrawals to 500 each and 1000 or 3 a day,
+// and standard transfers to 5 a day; premium users may withdraw 5000 each
+func newTestLimitPolicy(t *testing.T) domain.LimitPolicy {
+	policy, err := domain.NewLimitPolicy(map[domain.UserTier][]domain.LimitRule{
+		domain.StandardTier: {
+			{TransactionType: domain.Withdrawal, PerTransaction: domain.NewAmount(500), DailyAmount: domain.NewAmount(1000), DailyCount: 3},
+			{TransactionType: domain.Transfer, DailyCount: 5},
+		},
+		"premium": {
+			{TransactionType: domain.Withdrawal, PerTransaction: domain.NewAmount(5000)},
+		},
+	})
+	require.NoError(t, err)
+	return policy
+}
+
+// expectUsage has the repository report daily, then monthly, usage of a wallet
+func expectUsage(transactionRepo *mockTransactionRepository, walletID int64, types []domain.TransactionType, daily, monthly domain.LimitUsage) {
+	transactionRepo.On("GetUsageSince", mock.Anything, walletID, types, mock.Anything).Return(daily, nil).Once()
+	transactionRepo.On("GetUsageSince", mock.Anything, walletID, types, mock.Anything).Return(monthly, nil).Once()
+}
+
+func TestLimitCheck(t *testing.T) {
+	ctx := context.Background()
+	wallet := &domain.Wallet{ID: 1, UserID: 1, Currency: domain.USD}
+
+	tests := []struct {
+		name          string
+		tier          domain.UserTier
+		amount        domain.Amount
+		daily         *domain.LimitUsage
+		expectedError error
+	}{
+		{name: "within the limits", tier: domain.StandardTier, amount: domain.NewAmount(100), daily: &domain.LimitUsage{Amount: domain.NewAmount(200), Count: 1}},
+		{name: "over the per-transaction limit", tier: domain.StandardTier, amount: domain.NewAmount(600), expectedError: apperrors.ErrLimitExceeded},
+		{name: "over the daily amount", tier: domain.StandardTier, amount: domain.NewAmount(500), daily: &domain.LimitUsage{Amount: domain.NewAmount(600), Count: 2}, expectedError: apperrors.ErrLimitExceeded},
+		{name: "daily count used up", tier: domain.StandardTier, amount: domain.NewAmount(1), daily: &domain.LimitUsage{Amount: domain.NewAmount(3), Count: 3}, expectedError: apperrors.ErrLimitExceeded},
+		{name: "higher tier needs no totals", tier: "premium", amount: domain.NewAmount(4000)},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			transactionRepo := new(mockTransactionRepository)
+			if tc.daily != nil {
+				expectUsage(transactionRepo, wallet.ID, []domain.TransactionType{domain.Withdrawal}, *tc.daily, *tc.daily)
+			}
+
+			uc := usecase.NewLimitUsecase(new(mockUserRepository), new(mockWalletRepository), transactionRepo, newTestLimitPolicy(t))
+			err := uc.Check(ctx, &domain.User{ID: 1, Tier: tc.tier}, wallet, domain.Withdrawal, tc.amount)
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+			transactionRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetLimits(t *testing.T) {
+	ctx := context.Background()
+	wallet := &domain.Wallet{ID: 1, UserID: 1, Currency: domain.USD}
+
+	userRepo := new(mockUserRepository)
+	walletRepo := new(mockWalletRepository)
+	transactionRepo := new(mockTransactionRepository)
+
+	userRepo.On("GetByID", ctx, int64(1)).Return(&domain.User{ID: 1, Tier: domain.StandardTier}, nil)
+	walletRepo.On("ListByUserID", ctx, int64(1)).Return([]*domain.Wallet{wallet}, nil)
+	expectUsage(transactionRepo, wallet.ID, []domain.TransactionType{domain.Withdrawal},
+		domain.LimitUsage{Amount: domain.NewAmount(300), Count: 1},
+		domain.LimitUsage{Amount: domain.NewAmount(900), Count: 4})
+	expectUsage(transactionRepo, wallet.ID, []domain.TransactionType{domain.TransferOut, domain.Transfer},
+		domain.LimitUsage{Amount: domain.NewAmount(50), Count: 2},
+		domain.LimitUsage{Amount: domain.NewAmount(50), Count: 2})
+
+	uc := usecase.NewLimitUsecase(userRepo, walletRepo, transactionRepo, newTestLimitPolicy(t))
+	limits, err := uc.GetLimits(ctx, 1, domain.WalletSelector{})
+	require.NoError(t, err)
+
+	assert.Equal(t, domain.StandardTier, limits.Tier)
+	assert.Equal(t, int64(1), limits.WalletID)
+
+	// Deposits aren't limited, so only withdrawals and transfers are listed
+	require.Len(t, limits.Limits, 2)
+	withdrawals := limits.Limits[0]
+	assert.Equal(t, domain.Withdrawal, withdrawals.TransactionType)
+	assert.Equal(t, domain.NewAmount(500), *withdrawals.PerTransaction)
+	assert.Equal(t, domain.NewAmount(700), *withdrawals.Daily.AmountRemaining)
+	assert.Equal(t, 2, *withdrawals.Daily.CountRemaining)
+	assert.Equal(t, 4, withdrawals.Monthly.Used.Count)
+
+	transfers := limits.Limits[1]
+	assert.Equal(t, domain.Transfer, transfers.TransactionType)
+	assert.Equal(t, 3, *transfers.Daily.CountRemaining)
+
+	transactionRepo.AssertExpectations(t)
+}
+
+func TestWalletLimits(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("withdrawal over the limit moves no money", func(t *testing.T) {
+		wallet := &domain.Wallet{ID: 1, UserID: 1, Balance: domain.NewAmount(1000), Currency: domain.USD}
+
+		userRepo := new(mockUserRepository)
+		walletRepo := new(mockWalletRepository)
+		transactionRepo := new(mockTransactionRepository)
+
+		userRepo.On("GetByID", ctx, int64(1)).Return(&domain.User{ID: 1, Tier: domain.StandardTier}, nil)
+		walletRepo.On("ListByUserID", ctx, int64(1)).Return([]*domain.Wallet{wallet}, nil)
+		walletRepo.On("GetByIDForUpdate", ctx, int64(1)).Return(wallet, nil)
+
+		limits := usecase.NewLimitUsecase(userRepo, walletRepo, transactionRepo, newTestLimitPolicy(t))
+		uc := usecase.NewWalletUsecase(userRepo, walletRepo, transactionRepo, newMockLedgerRepository(1),
+			newMockOutboxRepository(), newMockWebhookRepository(), nil, nil, limits, &mockUnitOfWork{}, nil)
+
+		_, err := uc.Withdraw(ctx, domain.WithdrawRequest{UserID: 1, Amount: domain.NewAmount(600)})
+		assert.ErrorIs(t, err, apperrors.ErrLimitExceeded)
+		assert.Equal(t, domain.NewAmount(1000), wallet.Balance)
+		walletRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+		transactionRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("transfer counts against the sender's limits", func(t *testing.T) {
+		senderWallet := &domain.Wallet{ID: 1, UserID: 1, Balance: domain.NewAmount(100), Currency: domain.USD}
+		receiverWallet := &domain.Wallet{ID: 2, UserID: 2, Balance: domain.NewAmount(50), Currency: domain.USD}
+
+		userRepo := new(mockUserRepository)
+		walletRepo := new(mockWalletRepository)
+		transactionRepo := new(mockTransactionRepository)
+
+		userRepo.On("GetByID", ctx, int64(1)).Return(&domain.User{ID: 1, Tier: domain.StandardTier}, nil)
+		userRepo.On("GetByID", ctx, int64(2)).Return(&domain.User{ID: 2, Tier: domain.StandardTier}, nil)
+		walletRepo.On("ListByUserID", ctx, int64(1)).Return([]*domain.Wallet{senderWallet}, nil)
+		walletRepo.On("ListByUserID", ctx, int64(2)).Return([]*domain.Wallet{receiverWallet}, nil)
+		walletRepo.On("GetByIDForUpdate", ctx, int64(1)).Return(senderWallet, nil)
+		walletRepo.On("GetByIDForUpdate", ctx, int64(2)).Return(receiverWallet, nil)
+		expectUsage(transactionRepo, senderWallet.ID, []domain.TransactionType{domain.TransferOut, domain.Transfer},
+			domain.LimitUsage{Amount: domain.NewAmount(5), Count: 5},
+			domain.LimitUsage{Amount: domain.NewAmount(5), Count: 5})
+
+		limits := usecase.NewLimitUsecase(userRepo, walletRepo, transactionRepo, newTestLimitPolicy(t))
+		uc := usecase.NewWalletUsecase(userRepo, walletRepo, transactionRepo, newMockLedgerRepository(1, 2),
+			newMockOutboxRepository(), newMockWebhookRepository(), nil, nil, limits, &mockUnitOfWork{}, nil)
+
+		_, err := uc.Transfer(ctx, domain.TransferRequest{SenderID: 1, ReceiverID: 2, Amount: domain.NewAmount(1)})
+		assert.ErrorIs(t, err, apperrors.ErrLimitExceeded)
+		assert.Equal(t, domain.NewAmount(100), senderWallet.Balance)
+		walletRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,338 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+	"github.com/redis/go-redis/v9"
+)
+
+// defaultPaymentRequestTTL is how long a payment request stays open when neither
+// the request nor the config says
+const defaultPaymentRequestTTL = 7 * 24 * time.Hour
+
+type paymentRequestUsecase struct {
+	walletUsecase domain.WalletUsecase
+	userRepo      domain.UserRepository
+	walletRepo    domain.WalletRepository
+	requestRepo   domain.PaymentRequestRepository
+	outboxRepo    domain.OutboxRepository
+	webhookRepo   domain.WebhookRepository
+	unitOfWork    domain.UnitOfWork
+	redisClient   *redis.Client
+	defaultTTL    time.Duration
+}
+
+// NewPaymentRequestUsecase creates a use case for asking other users for money
+func NewPaymentRequestUsecase(
+	walletUsecase domain.WalletUsecase,
+	userRepo domain.UserRepository,
+	walletRepo domain.WalletRepository,
+	requestRepo domain.PaymentRequestRepository,
+	outboxRepo domain.OutboxRepository,
+	webhookRepo domain.WebhookRepository,
+	unitOfWork domain.UnitOfWork,
+	redisClient *redis.Client,
+	defaultTTL time.Duration,
+) domain.PaymentRequestUsecase {
+	if defaultTTL <= 0 {
+		defaultTTL = defaultPaymentRequestTTL
+	}
+
+	return &paymentRequestUsecase{
+		walletUsecase: walletUsecase,
+		userRepo:      userRepo,
+		walletRepo:    walletRepo,
+		requestRepo:   requestRepo,
+		outboxRepo:    outboxRepo,
+		webhookRepo:   webhookRepo,
+		unitOfWork:    unitOfWork,
+		redisClient:   redisClient,
+		defaultTTL:    defaultTTL,
+	}
+}
+
+// CreateRequest asks the payer for money on behalf of userID
+func (u *paymentRequestUsecase) CreateRequest(
+	ctx context.Context,
+	userID int64,
+	req domain.CreatePaymentRequestRequest,
+) (*domain.PaymentRequest, error) {
+	now := time.Now()
+
+	expiresAt := now.Add(u.defaultTTL)
+	if req.ExpiresAt != nil {
+		expiresAt = *req.ExpiresAt
+	}
+
+	wallet, err := selectWallet(ctx, u.walletRepo, userID, domain.WalletSelector{
+		WalletID: req.WalletID,
+		Currency: req.Currency,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	request := &domain.PaymentRequest{
+		RequesterID: userID,
+		PayerID:     req.PayerID,
+		WalletID:    wallet.ID,
+		Currency:    wallet.Currency,
+		Amount:      req.Amount,
+		Memo:        req.Memo,
+		Status:      domain.PaymentRequestPending,
+		ExpiresAt:   expiresAt,
+	}
+	if err := request.Validate(now); err != nil {
+		return nil, err
+	}
+
+	if _, err := u.userRepo.GetByID(ctx, req.PayerID); err != nil {
+		if errors.Is(err, apperrors.ErrResourceNotFound) {
+			return nil, apperrors.ErrUserNotFound
+		}
+		return nil, apperrors.WrapError(err, "failed to get payer")
+	}
+
+	// The request and its event commit or roll back together
+	err = u.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		if err := u.requestRepo.Create(ctx, request); err != nil {
+			return apperrors.WrapError(err, "failed to create payment request")
+		}
+		return u.recordEvent(ctx, domain.PaymentRequestCreatedEvent, request)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return request, nil
+}
+
+// ListRequests returns a page of the requests userID made or was sent
+func (u *paymentRequestUsecase) ListRequests(
+	ctx context.Context,
+	userID int64,
+	filter domain.PaymentRequestFilter,
+	pagination domain.PaginationRequest,
+) (*domain.PaymentRequestListResponse, error) {
+	switch filter.Direction {
+	case "", domain.PaymentRequestsIncoming, domain.PaymentRequestsOutgoing:
+	default:
+		return nil, fmt.Errorf("%w: direction must be incoming or outgoing", apperrors.ErrInvalidInput)
+	}
+	switch filter.Status {
+	case "", domain.PaymentRequestPending, domain.PaymentRequestAccepted, domain.PaymentRequestDeclined,
+		domain.PaymentRequestCancelled, domain.PaymentRequestExpired:
+	default:
+		return nil, fmt.Errorf("%w: unknown payment request status %q", apperrors.ErrInvalidInput, filter.Status)
+	}
+
+	requests, err := u.requestRepo.ListByUserID(ctx, userID, filter, pagination.Limit, pagination.Offset)
+	if err != nil {
+		return nil, apperrors.WrapError(err, "failed to list payment requests")
+	}
+
+	total, err := u.requestRepo.CountByUserID(ctx, userID, filter)
+	if err != nil {
+		return nil, apperrors.WrapError(err, "failed to count payment requests")
+	}
+
+	if requests == nil {
+		requests = []*domain.PaymentRequest{}
+	}
+
+	return &domain.PaymentRequestListResponse{
+		PaymentRequests: requests,
+		Total:           total,
+		Limit:           pagination.Limit,
+		Offset:          pagination.Offset,
+	}, nil
+}
+
+// GetRequest returns a request userID made or was sent
+func (u *paymentRequestUsecase) GetRequest(ctx context.Context, userID, requestID int64) (*domain.PaymentRequest, error) {
+	request, err := u.requestRepo.GetByID(ctx, requestID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrResourceNotFound) {
+			return nil, apperrors.ErrPaymentRequestNotFound
+		}
+		return nil, apperrors.WrapError(err, "failed to get payment request")
+	}
+
+	if !request.IsParty(userID) {
+		return nil, fmt.Errorf("%w: payment request %d belongs to other users", apperrors.ErrForbidden, request.ID)
+	}
+	return request, nil
+}
+
+// AcceptRequest pays a request sent to userID. The transfer and the status
+// change commit together, so a failed transfer leaves the request pending.
+func (u *paymentRequestUsecase) AcceptRequest(
+	ctx context.Context,
+	userID, requestID int64,
+	req domain.AcceptPaymentRequestRequest,
+) (*domain.PaymentRequest, error) {
+	request, err := u.respond(ctx, requestID, domain.PaymentRequestAcceptedEvent, func(ctx context.Context, request *domain.PaymentRequest) error {
+		if err := checkPayer(request, userID, "accept"); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if err := request.CheckPending(now); err != nil {
+			return err
+		}
+
+		transaction, err := u.walletUsecase.Transfer(ctx, request.TransferRequest(req.WalletID))
+		if err != nil {
+			return err
+		}
+
+		return request.Accept(transaction.ID, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Transfer cleared the cache before the outer unit of work committed
+	invalidateBalanceCache(ctx, u.redisClient, request.PayerID, request.RequesterID)
+
+	return request, nil
+}
+
+// DeclineRequest turns down a request sent to userID
+func (u *paymentRequestUsecase) DeclineRequest(ctx context.Context, userID, requestID int64) (*domain.PaymentRequest, error) {
+	return u.respond(ctx, requestID, domain.PaymentRequestDeclinedEvent, func(_ context.Context, request *domain.PaymentRequest) error {
+		if err := checkPayer(request, userID, "decline"); err != nil {
+			return err
+		}
+		return request.Decline(time.Now())
+	})
+}
+
+// CancelRequest withdraws a request userID made
+func (u *paymentRequestUsecase) CancelRequest(ctx context.Context, userID, requestID int64) (*domain.PaymentRequest, error) {
+	return u.respond(ctx, requestID, domain.PaymentRequestCancelledEvent, func(_ context.Context, request *domain.PaymentRequest) error {
+		if request.RequesterID != userID {
+			return fmt.Errorf("%w: only the requester can cancel payment request %d", apperrors.ErrForbidden, request.ID)
+		}
+		return request.Cancel(time.Now())
+	})
+}
+
+// ExpireRequests ends pending requests whose time has run out and notifies both
+// parties. Requests answered since being listed are left as they are.
+func (u *paymentRequestUsecase) ExpireRequests(ctx context.Context, limit int) (int, error) {
+	ids, err := u.requestRepo.ListExpiredIDs(ctx, time.Now(), limit)
+	if err != nil {
+		return 0, apperrors.WrapError(err, "failed to list expired payment requests")
+	}
+
+	expired := 0
+	for _, id := range ids {
+		ended := false
+
+		err := u.unitOfWork.Do(ctx, func(ctx context.Context) error {
+			request, err := u.lockRequest(ctx, id)
+			if err != nil {
+				return err
+			}
+
+			// Answered since it was listed
+			if request.Status != domain.PaymentRequestPending {
+				return nil
+			}
+
+			if err := request.Expire(time.Now()); err != nil {
+				return err
+			}
+
+			if err := u.requestRepo.Update(ctx, request); err != nil {
+				return apperrors.WrapError(err, "failed to update payment request")
+			}
+			ended = true
+			return u.recordEvent(ctx, domain.PaymentRequestExpiredEvent, request)
+		})
+		if err != nil {
+			return expired, fmt.Errorf("failed to expire payment request %d: %w", id, err)
+		}
+
+		if ended {
+			expired++
+		}
+	}
+
+	return expired, nil
+}
+
+// respond locks a request, lets change move it on, then saves it and records
+// eventType, all in one unit of work
+func (u *paymentRequestUsecase) respond(
+	ctx context.Context,
+	requestID int64,
+	eventType domain.EventType,
+	change func(ctx context.Context, request *domain.PaymentRequest) error,
+) (*domain.PaymentRequest, error) {
+	var request *domain.PaymentRequest
+
+	err := u.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		var err error
+		request, err = u.lockRequest(ctx, requestID)
+		if err != nil {
+			return err
+		}
+
+		if err := change(ctx, request); err != nil {
+			return err
+		}
+
+		if err := u.requestRepo.Update(ctx, request); err != nil {
+			return apperrors.WrapError(err, "failed to update payment request")
+		}
+		return u.recordEvent(ctx, eventType, request)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return request, nil
+}
+
+// lockRequest loads a payment request and locks it until the unit of work ends
+func (u *paymentRequestUsecase) lockRequest(ctx context.Context, requestID int64) (*domain.PaymentRequest, error) {
+	request, err := u.requestRepo.GetByIDForUpdate(ctx, requestID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrResourceNotFound) {
+			return nil, apperrors.ErrPaymentRequestNotFound
+		}
+		return nil, apperrors.WrapError(err, "failed to get payment request")
+	}
+	return request, nil
+}
+
+// recordEvent adds a payment request event to the outbox and queues it for the
+// webhooks of both parties
+func (u *paymentRequestUsecase) recordEvent(ctx context.Context, eventType domain.EventType, request *domain.PaymentRequest) error {
+	event, err := domain.NewPaymentRequestEvent(eventType, request)
+	if err != nil {
+		return err
+	}
+	if err := u.outboxRepo.Create(ctx, event); err != nil {
+		return apperrors.WrapError(err, "failed to record event")
+	}
+	if err := u.webhookRepo.EnqueueDeliveries(ctx, event, []int64{request.RequesterID, request.PayerID}); err != nil {
+		return apperrors.WrapError(err, "failed to queue webhooks")
+	}
+	return nil
+}
+
+// checkPayer rejects anyone but the payer answering a request
+func checkPayer(request *domain.PaymentRequest, userID int64, action string) error {
+	if request.PayerID != userID {
+		return fmt.Errorf("%w: only the payer can %s payment request %d", apperrors.ErrForbidden, action, request.ID)
+	}
+	return nil
+}
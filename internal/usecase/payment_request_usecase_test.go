@@ -0,0 +1,358 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	"github.com/ravindu/wallet-app-service/internal/usecase"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type mockPaymentRequestRepository struct {
+	mock.Mock
+}
+
+func (m *mockPaymentRequestRepository) Create(ctx context.Context, request *domain.PaymentRequest) error {
+	args := m.Called(ctx, request)
+	return args.Error(0)
+}
+
+func (m *mockPaymentRequestRepository) GetByID(ctx context.Context, id int64) (*domain.PaymentRequest, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.PaymentRequest), args.Error(1)
+}
+
+func (m *mockPaymentRequestRepository) GetByIDForUpdate(ctx context.Context, id int64) (*domain.PaymentRequest, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.PaymentRequest), args.Error(1)
+}
+
+func (m *mockPaymentRequestRepository) ListByUserID(
+	ctx context.Context,
+	userID int64,
+	filter domain.PaymentRequestFilter,
+	limit, offset int,
+) ([]*domain.PaymentRequest, error) {
+	args := m.Called(ctx, userID, filter, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.PaymentRequest), args.Error(1)
+}
+
+func (m *mockPaymentRequestRepository) CountByUserID(ctx context.Context, userID int64, filter domain.PaymentRequestFilter) (int, error) {
+	args := m.Called(ctx, userID, filter)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *mockPaymentRequestRepository) Update(ctx context.Context, request *domain.PaymentRequest) error {
+	args := m.Called(ctx, request)
+	return args.Error(0)
+}
+
+func (m *mockPaymentRequestRepository) ListExpiredIDs(ctx context.Context, now time.Time, limit int) ([]int64, error) {
+	args := m.Called(ctx, now, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]int64), args.Error(1)
+}
+
+// newPaymentRequestTestUsecase wires a payment request use case over mocks,
+// with user 1 as the requester and user 2 as the payer
+func newPaymentRequestTestUsecase(
+	transferUsecase *mockTransferUsecase,
+	requestRepo *mockPaymentRequestRepository,
+) (domain.PaymentRequestUsecase, *mockOutboxRepository) {
+	userRepo := new(mockUserRepository)
+	userRepo.On("GetByID", mock.Anything, int64(2)).Return(&domain.User{ID: 2}, nil).Maybe()
+	userRepo.On("GetByID", mock.Anything, int64(3)).Return(nil, apperrors.ErrResourceNotFound).Maybe()
+
+	walletRepo := new(mockWalletRepository)
+	walletRepo.On("ListByUserID", mock.Anything, int64(1)).Return([]*domain.Wallet{
+		{ID: 10, UserID: 1, Currency: domain.USD},
+		{ID: 11, UserID: 1, Currency: domain.EUR},
+	}, nil).Maybe()
+
+	outboxRepo := newMockOutboxRepository()
+	requestUsecase := usecase.NewPaymentRequestUsecase(transferUsecase, userRepo, walletRepo, requestRepo, outboxRepo,
+		newMockWebhookRepository(), &mockUnitOfWork{}, nil, 0)
+	return requestUsecase, outboxRepo
+}
+
+func TestCreatePaymentRequest(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name          string
+		req           domain.CreatePaymentRequestRequest
+		expectedError error
+		check         func(t *testing.T, request *domain.PaymentRequest)
+	}{
+		{
+			name: "into the wallet in the currency",
+			req:  domain.CreatePaymentRequestRequest{PayerID: 2, Currency: domain.USD, Amount: domain.NewAmount(25), Memo: "Dinner"},
+			check: func(t *testing.T, request *domain.PaymentRequest) {
+				assert.Equal(t, int64(10), request.WalletID)
+				assert.Equal(t, domain.USD, request.Currency)
+				assert.Equal(t, domain.PaymentRequestPending, request.Status)
+				assert.True(t, request.ExpiresAt.After(time.Now().Add(6*24*time.Hour)))
+			},
+		},
+		{
+			name: "into a wallet by id",
+			req:  domain.CreatePaymentRequestRequest{PayerID: 2, WalletID: 11, Amount: domain.NewAmount(25)},
+			check: func(t *testing.T, request *domain.PaymentRequest) {
+				assert.Equal(t, int64(11), request.WalletID)
+				assert.Equal(t, domain.EUR, request.Currency)
+			},
+		},
+		{
+			name:          "unknown payer",
+			req:           domain.CreatePaymentRequestRequest{PayerID: 3, Currency: domain.USD, Amount: domain.NewAmount(25)},
+			expectedError: apperrors.ErrUserNotFound,
+		},
+		{
+			name:          "asking yourself",
+			req:           domain.CreatePaymentRequestRequest{PayerID: 1, Currency: domain.USD, Amount: domain.NewAmount(25)},
+			expectedError: apperrors.ErrInvalidInput,
+		},
+		{
+			name:          "several wallets and no currency",
+			req:           domain.CreatePaymentRequestRequest{PayerID: 2, Amount: domain.NewAmount(25)},
+			expectedError: apperrors.ErrInvalidInput,
+		},
+		{
+			name:          "no wallet in the currency",
+			req:           domain.CreatePaymentRequestRequest{PayerID: 2, Currency: domain.JPY, Amount: domain.NewAmount(25)},
+			expectedError: apperrors.ErrWalletNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			requestRepo := new(mockPaymentRequestRepository)
+			requestRepo.On("Create", ctx, mock.AnythingOfType("*domain.PaymentRequest")).Return(nil).Maybe()
+
+			requestUsecase, outboxRepo := newPaymentRequestTestUsecase(new(mockTransferUsecase), requestRepo)
+			request, err := requestUsecase.CreateRequest(ctx, 1, tc.req)
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				requestRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, []domain.EventType{domain.PaymentRequestCreatedEvent}, recordedEvents(outboxRepo))
+			tc.check(t, request)
+		})
+	}
+}
+
+func TestAcceptPaymentRequest(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name          string
+		callerID      int64
+		status        domain.PaymentRequestStatus
+		expiresIn     time.Duration
+		transferError error
+		expectedError error
+	}{
+		{
+			name:      "payer accepts",
+			callerID:  2,
+			status:    domain.PaymentRequestPending,
+			expiresIn: time.Hour,
+		},
+		{
+			name:          "requester can't accept",
+			callerID:      1,
+			status:        domain.PaymentRequestPending,
+			expiresIn:     time.Hour,
+			expectedError: apperrors.ErrForbidden,
+		},
+		{
+			name:          "already declined",
+			callerID:      2,
+			status:        domain.PaymentRequestDeclined,
+			expiresIn:     time.Hour,
+			expectedError: apperrors.ErrPaymentRequestNotPending,
+		},
+		{
+			name:          "expired but not yet swept",
+			callerID:      2,
+			status:        domain.PaymentRequestPending,
+			expiresIn:     -time.Minute,
+			expectedError: apperrors.ErrPaymentRequestNotPending,
+		},
+		{
+			name:          "payer can't cover it",
+			callerID:      2,
+			status:        domain.PaymentRequestPending,
+			expiresIn:     time.Hour,
+			transferError: apperrors.ErrInsufficientFunds,
+			expectedError: apperrors.ErrInsufficientFunds,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			request := &domain.PaymentRequest{
+				ID:          5,
+				RequesterID: 1,
+				PayerID:     2,
+				WalletID:    10,
+				Currency:    domain.USD,
+				Amount:      domain.NewAmount(25),
+				Status:      tc.status,
+				ExpiresAt:   time.Now().Add(tc.expiresIn),
+			}
+			requestRepo := new(mockPaymentRequestRepository)
+			requestRepo.On("GetByIDForUpdate", ctx, int64(5)).Return(request, nil)
+			requestRepo.On("Update", ctx, request).Return(nil).Maybe()
+
+			transferUsecase := new(mockTransferUsecase)
+			if tc.transferError != nil {
+				transferUsecase.On("Transfer", ctx, mock.Anything).Return(nil, tc.transferError).Maybe()
+			} else {
+				transferUsecase.On("Transfer", ctx, mock.Anything).Return(&domain.Transaction{ID: 42}, nil).Maybe()
+			}
+
+			requestUsecase, outboxRepo := newPaymentRequestTestUsecase(transferUsecase, requestRepo)
+			accepted, err := requestUsecase.AcceptRequest(ctx, tc.callerID, 5, domain.AcceptPaymentRequestRequest{})
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				assert.Equal(t, tc.status, request.Status)
+				requestRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+				assert.Empty(t, recordedEvents(outboxRepo))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, domain.PaymentRequestAccepted, accepted.Status)
+			assert.Equal(t, int64(42), *accepted.TransactionID)
+			assert.Equal(t, []domain.EventType{domain.PaymentRequestAcceptedEvent}, recordedEvents(outboxRepo))
+
+			// The payer pays the requester's wallet in the request's currency
+			transferUsecase.AssertCalled(t, "Transfer", ctx, domain.TransferRequest{
+				SenderID:         2,
+				ReceiverID:       1,
+				ReceiverWalletID: 10,
+				Currency:         domain.USD,
+				Amount:           domain.NewAmount(25),
+				Comment:          "Payment request 5",
+			})
+		})
+	}
+}
+
+func TestDeclineAndCancelPaymentRequest(t *testing.T) {
+	ctx := context.Background()
+
+	newRequest := func() (*domain.PaymentRequest, domain.PaymentRequestUsecase) {
+		request := &domain.PaymentRequest{
+			ID:          5,
+			RequesterID: 1,
+			PayerID:     2,
+			Status:      domain.PaymentRequestPending,
+			ExpiresAt:   time.Now().Add(time.Hour),
+		}
+		requestRepo := new(mockPaymentRequestRepository)
+		requestRepo.On("GetByIDForUpdate", ctx, int64(5)).Return(request, nil)
+		requestRepo.On("Update", ctx, request).Return(nil)
+
+		requestUsecase, _ := newPaymentRequestTestUsecase(new(mockTransferUsecase), requestRepo)
+		return request, requestUsecase
+	}
+
+	request, requestUsecase := newRequest()
+	_, err := requestUsecase.DeclineRequest(ctx, 1, 5)
+	assert.ErrorIs(t, err, apperrors.ErrForbidden)
+	_, err = requestUsecase.DeclineRequest(ctx, 2, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.PaymentRequestDeclined, request.Status)
+
+	request, requestUsecase = newRequest()
+	_, err = requestUsecase.CancelRequest(ctx, 2, 5)
+	assert.ErrorIs(t, err, apperrors.ErrForbidden)
+	_, err = requestUsecase.CancelRequest(ctx, 1, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.PaymentRequestCancelled, request.Status)
+
+	// Once cancelled the payer can no longer answer it
+	_, err = requestUsecase.DeclineRequest(ctx, 2, 5)
+	assert.ErrorIs(t, err, apperrors.ErrPaymentRequestNotPending)
+}
+
+func TestGetPaymentRequest(t *testing.T) {
+	ctx := context.Background()
+
+	requestRepo := new(mockPaymentRequestRepository)
+	requestRepo.On("GetByID", ctx, int64(5)).Return(&domain.PaymentRequest{ID: 5, RequesterID: 1, PayerID: 2}, nil)
+	requestUsecase, _ := newPaymentRequestTestUsecase(new(mockTransferUsecase), requestRepo)
+
+	for _, userID := range []int64{1, 2} {
+		_, err := requestUsecase.GetRequest(ctx, userID, 5)
+		assert.NoError(t, err)
+	}
+
+	_, err := requestUsecase.GetRequest(ctx, 3, 5)
+	assert.ErrorIs(t, err, apperrors.ErrForbidden)
+}
+
+func TestListPaymentRequests(t *testing.T) {
+	ctx := context.Background()
+	pagination := domain.PaginationRequest{Limit: 10}
+	filter := domain.PaymentRequestFilter{Direction: domain.PaymentRequestsIncoming, Status: domain.PaymentRequestPending}
+
+	requestRepo := new(mockPaymentRequestRepository)
+	requestRepo.On("ListByUserID", ctx, int64(2), filter, 10, 0).Return([]*domain.PaymentRequest{{ID: 5}}, nil)
+	requestRepo.On("CountByUserID", ctx, int64(2), filter).Return(1, nil)
+	requestUsecase, _ := newPaymentRequestTestUsecase(new(mockTransferUsecase), requestRepo)
+
+	resp, err := requestUsecase.ListRequests(ctx, 2, filter, pagination)
+	require.NoError(t, err)
+	assert.Len(t, resp.PaymentRequests, 1)
+	assert.Equal(t, 1, resp.Total)
+
+	_, err = requestUsecase.ListRequests(ctx, 2, domain.PaymentRequestFilter{Direction: "sideways"}, pagination)
+	assert.ErrorIs(t, err, apperrors.ErrInvalidInput)
+}
+
+func TestExpirePaymentRequests(t *testing.T) {
+	ctx := context.Background()
+	past := time.Now().Add(-time.Minute)
+
+	stale := &domain.PaymentRequest{ID: 5, RequesterID: 1, PayerID: 2, Status: domain.PaymentRequestPending, ExpiresAt: past}
+	// Accepted after it was listed
+	answered := &domain.PaymentRequest{ID: 6, RequesterID: 1, PayerID: 2, Status: domain.PaymentRequestAccepted, ExpiresAt: past}
+
+	requestRepo := new(mockPaymentRequestRepository)
+	requestRepo.On("ListExpiredIDs", ctx, mock.AnythingOfType("time.Time"), 100).Return([]int64{5, 6}, nil)
+	requestRepo.On("GetByIDForUpdate", ctx, int64(5)).Return(stale, nil)
+	requestRepo.On("GetByIDForUpdate", ctx, int64(6)).Return(answered, nil)
+	requestRepo.On("Update", ctx, stale).Return(nil)
+
+	requestUsecase, outboxRepo := newPaymentRequestTestUsecase(new(mockTransferUsecase), requestRepo)
+	expired, err := requestUsecase.ExpireRequests(ctx, 100)
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, expired)
+	assert.Equal(t, domain.PaymentRequestExpired, stale.Status)
+	assert.Equal(t, domain.PaymentRequestAccepted, answered.Status)
+	assert.Equal(t, []domain.EventType{domain.PaymentRequestExpiredEvent}, recordedEvents(outboxRepo))
+	requestRepo.AssertNotCalled(t, "Update", ctx, answered)
+}
@@ -0,0 +1,274 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+	"github.com/redis/go-redis/v9"
+)
+
+// defaultReconcileBatchSize is how many wallet IDs are read at a time when
+// the options set none
+const defaultReconcileBatchSize = 500
+
+type reconciliationUsecase struct {
+	walletRepo         domain.WalletRepository
+	ledgerRepo         domain.LedgerRepository
+	reconciliationRepo domain.ReconciliationRepository
+	unitOfWork         domain.UnitOfWork
+	redisClient        *redis.Client
+}
+
+// NewReconciliationUsecase creates a use case that checks wallet balances
+// against their transaction history and ledger accounts
+func NewReconciliationUsecase(
+	walletRepo domain.WalletRepository,
+	ledgerRepo domain.LedgerRepository,
+	reconciliationRepo domain.ReconciliationRepository,
+	unitOfWork domain.UnitOfWork,
+	redisClient *redis.Client,
+) domain.ReconciliationUsecase {
+	return &reconciliationUsecase{
+		walletRepo:         walletRepo,
+		ledgerRepo:         ledgerRepo,
+		reconciliationRepo: reconciliationRepo,
+		unitOfWork:         unitOfWork,
+		redisClient:        redisClient,
+	}
+}
+
+// walletCheck is what reconciling one wallet found
+type walletCheck struct {
+	wallet        *domain.Wallet
+	transactions  int
+	chainIntact   bool
+	discrepancies []*domain.Discrepancy
+}
+
+// Reconcile replays every chosen wallet's transactions, checking each links
+// onto the one before and that the last matches the wallet's balance, which
+// must also match its ledger account.
+func (u *reconciliationUsecase) Reconcile(ctx context.Context, opts domain.ReconcileOptions) (*domain.ReconciliationReport, error) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultReconcileBatchSize
+	}
+
+	report := &domain.ReconciliationReport{
+		StartedAt:     time.Now().UTC(),
+		AutoFix:       opts.AutoFix,
+		Discrepancies: make([]*domain.Discrepancy, 0),
+	}
+
+	reconcile := func(walletID int64) error {
+		check, err := u.reconcileWallet(ctx, walletID, opts.AutoFix)
+		if errors.Is(err, apperrors.ErrResourceNotFound) {
+			// Deleted since it was listed
+			return nil
+		}
+		if err != nil {
+			return apperrors.WrapError(err, fmt.Sprintf("failed to reconcile wallet %d", walletID))
+		}
+
+		report.WalletsChecked++
+		report.TransactionsChecked += check.transactions
+		for _, discrepancy := range check.discrepancies {
+			report.Discrepancies = append(report.Discrepancies, discrepancy)
+			if discrepancy.Fixed {
+				report.Fixed++
+			}
+		}
+		return nil
+	}
+
+	if len(opts.WalletIDs) > 0 {
+		for _, walletID := range opts.WalletIDs {
+			if err := reconcile(walletID); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		var afterWalletID int64
+		for {
+			walletIDs, err := u.reconciliationRepo.ListWalletIDs(ctx, afterWalletID, opts.BatchSize)
+			if err != nil {
+				return nil, apperrors.WrapError(err, "failed to list wallets")
+			}
+			for _, walletID := range walletIDs {
+				if err := reconcile(walletID); err != nil {
+					return nil, err
+				}
+			}
+			if len(walletIDs) < opts.BatchSize {
+				break
+			}
+			afterWalletID = walletIDs[len(walletIDs)-1]
+		}
+	}
+
+	report.FinishedAt = time.Now().UTC()
+	return report, nil
+}
+
+// reconcileWallet checks one wallet without locking it. Transactions are never
+// rewritten, so a broken chain is real, but a balance can look wrong only
+// because a change committed mid-check; those are checked again under the
+// wallet's lock before being reported or fixed.
+func (u *reconciliationUsecase) reconcileWallet(ctx context.Context, walletID int64, autoFix bool) (*walletCheck, error) {
+	wallet, err := u.walletRepo.GetByID(ctx, walletID)
+	if err != nil {
+		return nil, err
+	}
+
+	check := &walletCheck{wallet: wallet, chainIntact: true}
+	var last *domain.BalanceLink
+	err = u.reconciliationRepo.StreamBalanceChain(ctx, walletID, func(link domain.BalanceLink) error {
+		check.transactions++
+		if last != nil && link.BalanceBefore != last.BalanceAfter {
+			check.chainIntact = false
+			check.discrepancies = append(check.discrepancies, &domain.Discrepancy{
+				WalletID:              wallet.ID,
+				Currency:              wallet.Currency,
+				Kind:                  domain.DiscrepancyBrokenChain,
+				TransactionID:         &link.TransactionID,
+				PreviousTransactionID: &last.TransactionID,
+				Expected:              last.BalanceAfter,
+				Actual:                link.BalanceBefore,
+			})
+		}
+		last = &link
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ends, err := u.checkBalance(ctx, wallet, last)
+	if err != nil {
+		return nil, err
+	}
+	if len(ends) == 0 {
+		return check, nil
+	}
+
+	fixed := false
+	err = u.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		locked, err := u.walletRepo.GetByIDForUpdate(ctx, walletID)
+		if err != nil {
+			return err
+		}
+		last, err := u.reconciliationRepo.GetLastLink(ctx, walletID)
+		if err != nil && !errors.Is(err, apperrors.ErrResourceNotFound) {
+			return err
+		}
+
+		ends, err = u.checkBalance(ctx, locked, last)
+		if err != nil {
+			return err
+		}
+		if !autoFix || !check.chainIntact || !fixable(ends) {
+			return nil
+		}
+		fixed = true
+		return u.fixBalance(ctx, locked, last, ends)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if fixed {
+		invalidateBalanceCache(ctx, u.redisClient, wallet.UserID)
+	}
+	check.discrepancies = append(check.discrepancies, ends...)
+	return check, nil
+}
+
+// checkBalance compares the wallet's balance with its latest transaction and
+// its ledger account
+func (u *reconciliationUsecase) checkBalance(ctx context.Context, wallet *domain.Wallet, last *domain.BalanceLink) ([]*domain.Discrepancy, error) {
+	var found []*domain.Discrepancy
+
+	if last != nil && last.BalanceAfter != wallet.Balance {
+		found = append(found, &domain.Discrepancy{
+			WalletID:      wallet.ID,
+			Currency:      wallet.Currency,
+			Kind:          domain.DiscrepancyBalanceMismatch,
+			TransactionID: &last.TransactionID,
+			Expected:      last.BalanceAfter,
+			Actual:        wallet.Balance,
+		})
+	}
+
+	account, err := u.ledgerRepo.GetWalletAccount(ctx, wallet.ID)
+	if errors.Is(err, apperrors.ErrResourceNotFound) {
+		return append(found, &domain.Discrepancy{
+			WalletID: wallet.ID,
+			Currency: wallet.Currency,
+			Kind:     domain.DiscrepancyNoLedgerAccount,
+			Actual:   wallet.Balance,
+		}), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ledgerBalance, err := u.ledgerRepo.GetAccountBalance(ctx, account.ID)
+	if err != nil {
+		return nil, err
+	}
+	if ledgerBalance != wallet.Balance {
+		found = append(found, &domain.Discrepancy{
+			WalletID: wallet.ID,
+			Currency: wallet.Currency,
+			Kind:     domain.DiscrepancyLedgerMismatch,
+			Expected: ledgerBalance,
+			Actual:   wallet.Balance,
+		})
+	}
+
+	return found, nil
+}
+
+// fixable reports whether the wallet's balance is the only thing wrong, with
+// its transactions and ledger agreeing on what it should be
+func fixable(found []*domain.Discrepancy) bool {
+	var balance, ledger *domain.Discrepancy
+	for _, discrepancy := range found {
+		switch discrepancy.Kind {
+		case domain.DiscrepancyBalanceMismatch:
+			balance = discrepancy
+		case domain.DiscrepancyLedgerMismatch:
+			ledger = discrepancy
+		default:
+			return false
+		}
+	}
+	return balance != nil && ledger != nil && balance.Expected == ledger.Expected
+}
+
+// fixBalance resets the locked wallet's balance to its latest transaction's
+// and records the correction
+func (u *reconciliationUsecase) fixBalance(ctx context.Context, wallet *domain.Wallet, last *domain.BalanceLink, found []*domain.Discrepancy) error {
+	correction := &domain.BalanceCorrection{
+		WalletID:   wallet.ID,
+		OldBalance: wallet.Balance,
+		NewBalance: last.BalanceAfter,
+		Reason:     fmt.Sprintf("balance did not match transaction %d or the ledger", last.TransactionID),
+	}
+
+	wallet.Balance = last.BalanceAfter
+	if err := u.walletRepo.Update(ctx, wallet); err != nil {
+		return err
+	}
+	if err := u.reconciliationRepo.CreateCorrection(ctx, correction); err != nil {
+		return err
+	}
+
+	for _, discrepancy := range found {
+		discrepancy.Fixed = true
+	}
+	return nil
+}
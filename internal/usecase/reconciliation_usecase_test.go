@@ -0,0 +1,271 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	"github.com/ravindu/wallet-app-service/internal/usecase"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type mockReconciliationRepository struct {
+	mock.Mock
+}
+
+func (m *mockReconciliationRepository) ListWalletIDs(ctx context.Context, afterWalletID int64, limit int) ([]int64, error) {
+	args := m.Called(ctx, afterWalletID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]int64), args.Error(1)
+}
+
+func (m *mockReconciliationRepository) StreamBalanceChain(ctx context.Context, walletID int64, fn func(domain.BalanceLink) error) error {
+	args := m.Called(ctx, walletID)
+	if links, ok := args.Get(0).([]domain.BalanceLink); ok {
+		for _, link := range links {
+			if err := fn(link); err != nil {
+				return err
+			}
+		}
+	}
+	return args.Error(1)
+}
+
+func (m *mockReconciliationRepository) GetLastLink(ctx context.Context, walletID int64) (*domain.BalanceLink, error) {
+	args := m.Called(ctx, walletID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.BalanceLink), args.Error(1)
+}
+
+func (m *mockReconciliationRepository) CreateCorrection(ctx context.Context, correction *domain.BalanceCorrection) error {
+	args := m.Called(ctx, correction)
+	return args.Error(0)
+}
+
+// balanceChain builds links from balance pairs, numbering transactions from 1
+func balanceChain(balances ...[2]int64) []domain.BalanceLink {
+	links := make([]domain.BalanceLink, 0, len(balances))
+	for i, b := range balances {
+		links = append(links, domain.BalanceLink{
+			TransactionID: int64(i + 1),
+			BalanceBefore: domain.NewAmount(b[0]),
+			BalanceAfter:  domain.NewAmount(b[1]),
+		})
+	}
+	return links
+}
+
+func TestReconcile(t *testing.T) {
+	ctx := context.Background()
+	intact := balanceChain([2]int64{0, 100}, [2]int64{100, 70}, [2]int64{70, 90})
+	broken := balanceChain([2]int64{0, 100}, [2]int64{80, 60}, [2]int64{60, 90})
+	lastLink := &intact[2]
+	walletAt := func(balance int64) *domain.Wallet {
+		return &domain.Wallet{ID: 7, UserID: 1, Balance: domain.NewAmount(balance), Currency: domain.USD}
+	}
+
+	tests := []struct {
+		name            string
+		autoFix         bool
+		setupMocks      func(*mockWalletRepository, *mockLedgerRepository, *mockReconciliationRepository)
+		noLedgerAccount bool
+		expectedKinds   []domain.DiscrepancyKind
+		expectedFixed   int
+	}{
+		{
+			name: "consistent wallet",
+			setupMocks: func(walletRepo *mockWalletRepository, ledgerRepo *mockLedgerRepository, reconciliationRepo *mockReconciliationRepository) {
+				walletRepo.On("GetByID", ctx, int64(7)).Return(walletAt(90), nil)
+				reconciliationRepo.On("StreamBalanceChain", ctx, int64(7)).Return(intact, nil)
+				ledgerRepo.On("GetAccountBalance", ctx, int64(70)).Return(domain.NewAmount(90), nil)
+			},
+		},
+		{
+			name: "broken chain",
+			setupMocks: func(walletRepo *mockWalletRepository, ledgerRepo *mockLedgerRepository, reconciliationRepo *mockReconciliationRepository) {
+				walletRepo.On("GetByID", ctx, int64(7)).Return(walletAt(90), nil)
+				reconciliationRepo.On("StreamBalanceChain", ctx, int64(7)).Return(broken, nil)
+				ledgerRepo.On("GetAccountBalance", ctx, int64(70)).Return(domain.NewAmount(90), nil)
+			},
+			expectedKinds: []domain.DiscrepancyKind{domain.DiscrepancyBrokenChain},
+		},
+		{
+			name: "drifted balance is only reported without auto-fix",
+			setupMocks: func(walletRepo *mockWalletRepository, ledgerRepo *mockLedgerRepository, reconciliationRepo *mockReconciliationRepository) {
+				walletRepo.On("GetByID", ctx, int64(7)).Return(walletAt(95), nil)
+				walletRepo.On("GetByIDForUpdate", ctx, int64(7)).Return(walletAt(95), nil)
+				reconciliationRepo.On("StreamBalanceChain", ctx, int64(7)).Return(intact, nil)
+				reconciliationRepo.On("GetLastLink", ctx, int64(7)).Return(lastLink, nil)
+				ledgerRepo.On("GetAccountBalance", ctx, int64(70)).Return(domain.NewAmount(90), nil)
+			},
+			expectedKinds: []domain.DiscrepancyKind{domain.DiscrepancyBalanceMismatch, domain.DiscrepancyLedgerMismatch},
+		},
+		{
+			name:    "drifted balance is reset and audited",
+			autoFix: true,
+			setupMocks: func(walletRepo *mockWalletRepository, ledgerRepo *mockLedgerRepository, reconciliationRepo *mockReconciliationRepository) {
+				walletRepo.On("GetByID", ctx, int64(7)).Return(walletAt(95), nil)
+				walletRepo.On("GetByIDForUpdate", ctx, int64(7)).Return(walletAt(95), nil)
+				walletRepo.On("Update", ctx, mock.MatchedBy(func(wallet *domain.Wallet) bool {
+					return wallet.Balance == domain.NewAmount(90)
+				})).Return(nil)
+				reconciliationRepo.On("StreamBalanceChain", ctx, int64(7)).Return(intact, nil)
+				reconciliationRepo.On("GetLastLink", ctx, int64(7)).Return(lastLink, nil)
+				reconciliationRepo.On("CreateCorrection", ctx, mock.MatchedBy(func(correction *domain.BalanceCorrection) bool {
+					return correction.WalletID == 7 &&
+						correction.OldBalance == domain.NewAmount(95) &&
+						correction.NewBalance == domain.NewAmount(90)
+				})).Return(nil)
+				ledgerRepo.On("GetAccountBalance", ctx, int64(70)).Return(domain.NewAmount(90), nil)
+			},
+			expectedKinds: []domain.DiscrepancyKind{domain.DiscrepancyBalanceMismatch, domain.DiscrepancyLedgerMismatch},
+			expectedFixed: 2,
+		},
+		{
+			name:    "ledger disagreeing with the history is not fixed",
+			autoFix: true,
+			setupMocks: func(walletRepo *mockWalletRepository, ledgerRepo *mockLedgerRepository, reconciliationRepo *mockReconciliationRepository) {
+				walletRepo.On("GetByID", ctx, int64(7)).Return(walletAt(95), nil)
+				walletRepo.On("GetByIDForUpdate", ctx, int64(7)).Return(walletAt(95), nil)
+				reconciliationRepo.On("StreamBalanceChain", ctx, int64(7)).Return(intact, nil)
+				reconciliationRepo.On("GetLastLink", ctx, int64(7)).Return(lastLink, nil)
+				ledgerRepo.On("GetAccountBalance", ctx, int64(70)).Return(domain.NewAmount(95), nil)
+			},
+			expectedKinds: []domain.DiscrepancyKind{domain.DiscrepancyBalanceMismatch},
+		},
+		{
+			name:    "broken chain is never fixed",
+			autoFix: true,
+			setupMocks: func(walletRepo *mockWalletRepository, ledgerRepo *mockLedgerRepository, reconciliationRepo *mockReconciliationRepository) {
+				walletRepo.On("GetByID", ctx, int64(7)).Return(walletAt(95), nil)
+				walletRepo.On("GetByIDForUpdate", ctx, int64(7)).Return(walletAt(95), nil)
+				reconciliationRepo.On("StreamBalanceChain", ctx, int64(7)).Return(broken, nil)
+				reconciliationRepo.On("GetLastLink", ctx, int64(7)).Return(&broken[2], nil)
+				ledgerRepo.On("GetAccountBalance", ctx, int64(70)).Return(domain.NewAmount(90), nil)
+			},
+			expectedKinds: []domain.DiscrepancyKind{
+				domain.DiscrepancyBrokenChain, domain.DiscrepancyBalanceMismatch, domain.DiscrepancyLedgerMismatch,
+			},
+		},
+		{
+			name: "change committed during the check",
+			setupMocks: func(walletRepo *mockWalletRepository, ledgerRepo *mockLedgerRepository, reconciliationRepo *mockReconciliationRepository) {
+				// The balance was read before a deposit the chain already includes
+				walletRepo.On("GetByID", ctx, int64(7)).Return(walletAt(70), nil)
+				walletRepo.On("GetByIDForUpdate", ctx, int64(7)).Return(walletAt(90), nil)
+				reconciliationRepo.On("StreamBalanceChain", ctx, int64(7)).Return(intact, nil)
+				reconciliationRepo.On("GetLastLink", ctx, int64(7)).Return(lastLink, nil)
+				ledgerRepo.On("GetAccountBalance", ctx, int64(70)).Return(domain.NewAmount(90), nil)
+			},
+		},
+		{
+			name: "wallet without a ledger account",
+			setupMocks: func(walletRepo *mockWalletRepository, ledgerRepo *mockLedgerRepository, reconciliationRepo *mockReconciliationRepository) {
+				walletRepo.On("GetByID", ctx, int64(7)).Return(walletAt(0), nil)
+				walletRepo.On("GetByIDForUpdate", ctx, int64(7)).Return(walletAt(0), nil)
+				reconciliationRepo.On("StreamBalanceChain", ctx, int64(7)).Return(nil, nil)
+				reconciliationRepo.On("GetLastLink", ctx, int64(7)).Return(nil, apperrors.ErrResourceNotFound)
+			},
+			noLedgerAccount: true,
+			expectedKinds:   []domain.DiscrepancyKind{domain.DiscrepancyNoLedgerAccount},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			walletRepo := new(mockWalletRepository)
+			ledgerRepo := new(mockLedgerRepository)
+			reconciliationRepo := new(mockReconciliationRepository)
+			if tc.noLedgerAccount {
+				ledgerRepo.On("GetWalletAccount", ctx, int64(7)).Return(nil, apperrors.ErrResourceNotFound)
+			} else {
+				ledgerRepo.On("GetWalletAccount", ctx, int64(7)).Return(&domain.LedgerAccount{ID: 70}, nil)
+			}
+			tc.setupMocks(walletRepo, ledgerRepo, reconciliationRepo)
+
+			reconciliationUsecase := usecase.NewReconciliationUsecase(walletRepo, ledgerRepo, reconciliationRepo, &mockUnitOfWork{}, nil)
+			report, err := reconciliationUsecase.Reconcile(ctx, domain.ReconcileOptions{
+				WalletIDs: []int64{7},
+				AutoFix:   tc.autoFix,
+			})
+
+			require.NoError(t, err)
+			kinds := make([]domain.DiscrepancyKind, 0, len(report.Discrepancies))
+			for _, discrepancy := range report.Discrepancies {
+				kinds = append(kinds, discrepancy.Kind)
+			}
+			if tc.expectedKinds == nil {
+				tc.expectedKinds = []domain.DiscrepancyKind{}
+			}
+			assert.Equal(t, tc.expectedKinds, kinds)
+			assert.Equal(t, tc.expectedFixed, report.Fixed)
+			assert.Equal(t, 1, report.WalletsChecked)
+			assert.Equal(t, tc.autoFix, report.AutoFix)
+
+			walletRepo.AssertExpectations(t)
+			reconciliationRepo.AssertExpectations(t)
+			if tc.expectedFixed == 0 {
+				walletRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestReconcile_BrokenChainDetails(t *testing.T) {
+	ctx := context.Background()
+	broken := balanceChain([2]int64{0, 100}, [2]int64{80, 60})
+
+	walletRepo := new(mockWalletRepository)
+	ledgerRepo := new(mockLedgerRepository)
+	reconciliationRepo := new(mockReconciliationRepository)
+	walletRepo.On("GetByID", ctx, int64(7)).Return(&domain.Wallet{ID: 7, Balance: domain.NewAmount(60), Currency: domain.USD}, nil)
+	reconciliationRepo.On("StreamBalanceChain", ctx, int64(7)).Return(broken, nil)
+	ledgerRepo.On("GetWalletAccount", ctx, int64(7)).Return(&domain.LedgerAccount{ID: 70}, nil)
+	ledgerRepo.On("GetAccountBalance", ctx, int64(70)).Return(domain.NewAmount(60), nil)
+
+	report, err := usecase.NewReconciliationUsecase(walletRepo, ledgerRepo, reconciliationRepo, &mockUnitOfWork{}, nil).
+		Reconcile(ctx, domain.ReconcileOptions{WalletIDs: []int64{7}})
+
+	require.NoError(t, err)
+	require.Len(t, report.Discrepancies, 1)
+	discrepancy := report.Discrepancies[0]
+	assert.Equal(t, int64(2), *discrepancy.TransactionID)
+	assert.Equal(t, int64(1), *discrepancy.PreviousTransactionID)
+	assert.Equal(t, domain.NewAmount(100), discrepancy.Expected)
+	assert.Equal(t, domain.NewAmount(80), discrepancy.Actual)
+	assert.Equal(t, 2, report.TransactionsChecked)
+	assert.Equal(t, 1, report.Unresolved())
+}
+
+func TestReconcile_AllWallets(t *testing.T) {
+	ctx := context.Background()
+
+	walletRepo := new(mockWalletRepository)
+	ledgerRepo := new(mockLedgerRepository)
+	reconciliationRepo := new(mockReconciliationRepository)
+	reconciliationRepo.On("ListWalletIDs", ctx, int64(0), 2).Return([]int64{3, 5}, nil).Once()
+	reconciliationRepo.On("ListWalletIDs", ctx, int64(5), 2).Return([]int64{8}, nil).Once()
+	for _, walletID := range []int64{3, 5} {
+		walletRepo.On("GetByID", ctx, walletID).Return(&domain.Wallet{ID: walletID}, nil)
+		reconciliationRepo.On("StreamBalanceChain", ctx, walletID).Return(nil, nil)
+		ledgerRepo.On("GetWalletAccount", ctx, walletID).Return(&domain.LedgerAccount{ID: walletID * 10}, nil)
+		ledgerRepo.On("GetAccountBalance", ctx, walletID*10).Return(domain.Amount(0), nil)
+	}
+	// Deleted between being listed and checked
+	walletRepo.On("GetByID", ctx, int64(8)).Return(nil, apperrors.ErrResourceNotFound)
+
+	report, err := usecase.NewReconciliationUsecase(walletRepo, ledgerRepo, reconciliationRepo, &mockUnitOfWork{}, nil).
+		Reconcile(ctx, domain.ReconcileOptions{BatchSize: 2})
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.WalletsChecked)
+	assert.Empty(t, report.Discrepancies)
+	reconciliationRepo.AssertExpectations(t)
+}
@@ -0,0 +1,396 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// defaultScheduleRetryInterval is how long a failed occurrence waits before it is retried
+	defaultScheduleRetryInterval = time.Hour
+	// defaultScheduleMaxRetries applies when a schedule doesn't set max_retries
+	defaultScheduleMaxRetries = 3
+	// scheduleLease is how long a claimed occurrence is left to one scheduler
+	// before another may pick it up. It only matters if a scheduler dies mid-run.
+	scheduleLease = 5 * time.Minute
+)
+
+type scheduledTransferUsecase struct {
+	walletUsecase domain.WalletUsecase
+	userRepo      domain.UserRepository
+	walletRepo    domain.WalletRepository
+	scheduleRepo  domain.ScheduledTransferRepository
+	unitOfWork    domain.UnitOfWork
+	redisClient   *redis.Client
+	retryInterval time.Duration
+}
+
+// NewScheduledTransferUsecase creates a use case for one-off and recurring transfers
+func NewScheduledTransferUsecase(
+	walletUsecase domain.WalletUsecase,
+	userRepo domain.UserRepository,
+	walletRepo domain.WalletRepository,
+	scheduleRepo domain.ScheduledTransferRepository,
+	unitOfWork domain.UnitOfWork,
+	redisClient *redis.Client,
+	retryInterval time.Duration,
+) domain.ScheduledTransferUsecase {
+	if retryInterval <= 0 {
+		retryInterval = defaultScheduleRetryInterval
+	}
+
+	return &scheduledTransferUsecase{
+		walletUsecase: walletUsecase,
+		userRepo:      userRepo,
+		walletRepo:    walletRepo,
+		scheduleRepo:  scheduleRepo,
+		unitOfWork:    unitOfWork,
+		redisClient:   redisClient,
+		retryInterval: retryInterval,
+	}
+}
+
+// CreateSchedule sets up a transfer from one of userID's wallets to run later
+func (u *scheduledTransferUsecase) CreateSchedule(
+	ctx context.Context,
+	userID int64,
+	req domain.CreateScheduledTransferRequest,
+) (*domain.ScheduledTransfer, error) {
+	now := time.Now()
+
+	schedule := &domain.ScheduledTransfer{
+		UserID:              userID,
+		ReceiverID:          req.ReceiverID,
+		SenderWalletID:      req.SenderWalletID,
+		ReceiverWalletID:    req.ReceiverWalletID,
+		Currency:            req.Currency,
+		ReceiverCurrency:    req.ReceiverCurrency,
+		Amount:              req.Amount,
+		Comment:             req.Comment,
+		Convert:             req.Convert,
+		Cron:                req.Cron,
+		Interval:            req.Interval,
+		Timezone:            req.Timezone,
+		EndsAt:              req.EndsAt,
+		OnInsufficientFunds: req.OnInsufficientFunds,
+		MaxRetries:          defaultScheduleMaxRetries,
+	}
+	if schedule.Timezone == "" {
+		schedule.Timezone = "UTC"
+	}
+	if schedule.OnInsufficientFunds == "" {
+		schedule.OnInsufficientFunds = domain.PolicyRetry
+	}
+	if req.MaxRetries != nil {
+		schedule.MaxRetries = *req.MaxRetries
+	}
+
+	// A one-off transfer needs a time; a repeating one starts now by default
+	switch {
+	case req.StartAt != nil:
+		if !req.StartAt.After(now) {
+			return nil, fmt.Errorf("%w: start_at must be in the future", apperrors.ErrInvalidInput)
+		}
+		schedule.StartAt = req.StartAt.UTC()
+	case req.Interval != "":
+		if interval, err := time.ParseDuration(req.Interval); err == nil {
+			schedule.StartAt = now.Add(interval).UTC()
+		}
+	case req.Cron != "":
+		schedule.StartAt = now.UTC()
+	}
+
+	if err := schedule.Validate(); err != nil {
+		return nil, err
+	}
+
+	// Store the interval in one spelling, e.g. "24h0m0s" for "1440m"
+	if schedule.Interval != "" {
+		interval, _ := time.ParseDuration(schedule.Interval)
+		schedule.Interval = interval.String()
+	}
+
+	if err := schedule.Start(); err != nil {
+		return nil, err
+	}
+
+	// Catch a missing wallet or receiver now rather than at the first run
+	if _, err := selectWallet(ctx, u.walletRepo, userID, domain.WalletSelector{
+		WalletID: req.SenderWalletID,
+		Currency: req.Currency,
+	}); err != nil {
+		return nil, err
+	}
+	if _, err := u.userRepo.GetByID(ctx, req.ReceiverID); err != nil {
+		if errors.Is(err, apperrors.ErrResourceNotFound) {
+			return nil, apperrors.ErrUserNotFound
+		}
+		return nil, apperrors.WrapError(err, "failed to get receiver")
+	}
+
+	if err := u.scheduleRepo.Create(ctx, schedule); err != nil {
+		return nil, apperrors.WrapError(err, "failed to create scheduled transfer")
+	}
+
+	return schedule, nil
+}
+
+// ListSchedules returns all of the user's schedules, newest first
+func (u *scheduledTransferUsecase) ListSchedules(ctx context.Context, userID int64) ([]*domain.ScheduledTransfer, error) {
+	schedules, err := u.scheduleRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		return nil, apperrors.WrapError(err, "failed to list scheduled transfers")
+	}
+
+	if schedules == nil {
+		schedules = []*domain.ScheduledTransfer{}
+	}
+	return schedules, nil
+}
+
+// GetSchedule returns one of the user's schedules
+func (u *scheduledTransferUsecase) GetSchedule(ctx context.Context, userID, scheduleID int64) (*domain.ScheduledTransfer, error) {
+	schedule, err := u.scheduleRepo.GetByID(ctx, scheduleID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrResourceNotFound) {
+			return nil, apperrors.ErrScheduleNotFound
+		}
+		return nil, apperrors.WrapError(err, "failed to get scheduled transfer")
+	}
+
+	if err := checkScheduleOwner(schedule, userID); err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// CancelSchedule stops a schedule; occurrences that already ran are kept. A run
+// in progress finishes first, since it holds the schedule's lock.
+func (u *scheduledTransferUsecase) CancelSchedule(ctx context.Context, userID, scheduleID int64) (*domain.ScheduledTransfer, error) {
+	var schedule *domain.ScheduledTransfer
+
+	err := u.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		var err error
+		schedule, err = u.lockSchedule(ctx, scheduleID)
+		if err != nil {
+			return err
+		}
+
+		if err := checkScheduleOwner(schedule, userID); err != nil {
+			return err
+		}
+		if err := schedule.Cancel(); err != nil {
+			return err
+		}
+
+		if err := u.scheduleRepo.Update(ctx, schedule); err != nil {
+			return apperrors.WrapError(err, "failed to update scheduled transfer")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return schedule, nil
+}
+
+// ListExecutions returns a page of a schedule's execution history, newest first
+func (u *scheduledTransferUsecase) ListExecutions(
+	ctx context.Context,
+	userID, scheduleID int64,
+	pagination domain.PaginationRequest,
+) (*domain.ScheduleExecutionHistoryResponse, error) {
+	if _, err := u.GetSchedule(ctx, userID, scheduleID); err != nil {
+		return nil, err
+	}
+
+	executions, err := u.scheduleRepo.ListExecutions(ctx, scheduleID, pagination.Limit, pagination.Offset)
+	if err != nil {
+		return nil, apperrors.WrapError(err, "failed to list schedule executions")
+	}
+
+	total, err := u.scheduleRepo.CountExecutions(ctx, scheduleID)
+	if err != nil {
+		return nil, apperrors.WrapError(err, "failed to count schedule executions")
+	}
+
+	if executions == nil {
+		executions = []*domain.ScheduleExecution{}
+	}
+
+	return &domain.ScheduleExecutionHistoryResponse{
+		Executions: executions,
+		Total:      total,
+		Limit:      pagination.Limit,
+		Offset:     pagination.Offset,
+	}, nil
+}
+
+// RunDueSchedules claims due occurrences and runs them in turn. Claiming leases
+// the schedules in the database, so with several replicas each occurrence is run
+// by one of them.
+func (u *scheduledTransferUsecase) RunDueSchedules(ctx context.Context, limit int) (int, error) {
+	claimed, err := u.scheduleRepo.ClaimDue(ctx, limit, time.Now().Add(scheduleLease))
+	if err != nil {
+		return 0, apperrors.WrapError(err, "failed to claim scheduled transfers")
+	}
+
+	for i, schedule := range claimed {
+		if err := u.runOccurrence(ctx, schedule); err != nil {
+			// The lease runs out and the occurrence is tried again
+			return i, fmt.Errorf("failed to run scheduled transfer %d: %w", schedule.ID, err)
+		}
+	}
+
+	return len(claimed), nil
+}
+
+// runOccurrence makes the transfer for a claimed schedule. The transfer, its
+// execution record and the move to the next occurrence commit together, so an
+// occurrence can't be paid twice. A failed transfer is recorded afterwards.
+func (u *scheduledTransferUsecase) runOccurrence(ctx context.Context, claimed *domain.ScheduledTransfer) error {
+	var transferErr error
+
+	err := u.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		schedule, err := u.lockSchedule(ctx, claimed.ID)
+		if err != nil {
+			return err
+		}
+
+		// Cancelled, or run by another scheduler after our lease ran out
+		if !schedule.ClaimedBy(claimed) {
+			return nil
+		}
+
+		// Joins this unit of work, so a failed execution record undoes the transfer
+		transaction, err := u.walletUsecase.Transfer(ctx, schedule.TransferRequest())
+		if err != nil {
+			transferErr = err
+			return err
+		}
+
+		now := time.Now()
+		execution := &domain.ScheduleExecution{
+			ScheduleID:    schedule.ID,
+			ScheduledFor:  *schedule.NextRunAt,
+			Attempt:       schedule.Attempts + 1,
+			Status:        domain.ExecutionSucceeded,
+			TransactionID: &transaction.ID,
+			ExecutedAt:    now,
+		}
+		if err := u.scheduleRepo.CreateExecution(ctx, execution); err != nil {
+			return apperrors.WrapError(err, "failed to record schedule execution")
+		}
+
+		schedule.LastError = ""
+		schedule.Advance(now)
+		if err := u.scheduleRepo.Update(ctx, schedule); err != nil {
+			return apperrors.WrapError(err, "failed to update scheduled transfer")
+		}
+		return nil
+	})
+	if transferErr == nil {
+		if err == nil {
+			// Transfer cleared the cache before this unit committed; clear it again
+			invalidateBalanceCache(ctx, u.redisClient, claimed.UserID, claimed.ReceiverID)
+		}
+		return err
+	}
+
+	return u.recordFailure(ctx, claimed, transferErr)
+}
+
+// recordFailure logs a failed attempt and decides what happens to the occurrence:
+// retried later, skipped, or, for errors no retry can fix, the end of the schedule
+func (u *scheduledTransferUsecase) recordFailure(ctx context.Context, claimed *domain.ScheduledTransfer, transferErr error) error {
+	return u.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		schedule, err := u.lockSchedule(ctx, claimed.ID)
+		if err != nil {
+			return err
+		}
+		if !schedule.ClaimedBy(claimed) {
+			return nil
+		}
+
+		now := time.Now()
+		schedule.Attempts++
+		schedule.LastError = transferErr.Error()
+		execution := &domain.ScheduleExecution{
+			ScheduleID:   schedule.ID,
+			ScheduledFor: *schedule.NextRunAt,
+			Attempt:      schedule.Attempts,
+			Status:       domain.ExecutionFailed,
+			Error:        transferErr.Error(),
+			ExecutedAt:   now,
+		}
+
+		insufficientFunds := errors.Is(transferErr, apperrors.ErrInsufficientFunds)
+		switch {
+		case isPermanentTransferError(transferErr):
+			schedule.Fail()
+		case insufficientFunds && schedule.OnInsufficientFunds == domain.PolicySkip,
+			schedule.Attempts > schedule.MaxRetries:
+			execution.Status = domain.ExecutionSkipped
+			schedule.Advance(now)
+		default:
+			schedule.Retry(now.Add(u.retryInterval))
+		}
+
+		if err := u.scheduleRepo.CreateExecution(ctx, execution); err != nil {
+			return apperrors.WrapError(err, "failed to record schedule execution")
+		}
+		if err := u.scheduleRepo.Update(ctx, schedule); err != nil {
+			return apperrors.WrapError(err, "failed to update scheduled transfer")
+		}
+		return nil
+	})
+}
+
+// lockSchedule loads a schedule and locks it until the unit of work ends
+func (u *scheduledTransferUsecase) lockSchedule(ctx context.Context, scheduleID int64) (*domain.ScheduledTransfer, error) {
+	schedule, err := u.scheduleRepo.GetByIDForUpdate(ctx, scheduleID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrResourceNotFound) {
+			return nil, apperrors.ErrScheduleNotFound
+		}
+		return nil, apperrors.WrapError(err, "failed to get scheduled transfer")
+	}
+	return schedule, nil
+}
+
+// checkScheduleOwner refuses schedules that belong to another user
+func checkScheduleOwner(schedule *domain.ScheduledTransfer, userID int64) error {
+	if schedule.UserID != userID {
+		return fmt.Errorf("%w: scheduled transfer %d belongs to another user", apperrors.ErrForbidden, schedule.ID)
+	}
+	return nil
+}
+
+// isPermanentTransferError reports whether a transfer failed for a reason that
+// will fail every later occurrence too. A daily or monthly limit isn't one: the
+// window moves on, so those are retried like a shortfall in funds.
+func isPermanentTransferError(err error) bool {
+	for _, permanent := range []error{
+		apperrors.ErrUserNotFound,
+		apperrors.ErrWalletNotFound,
+		apperrors.ErrCurrencyMismatch,
+		apperrors.ErrUnsupportedCurrency,
+		apperrors.ErrAmountPrecision,
+		apperrors.ErrInvalidAmount,
+		apperrors.ErrInvalidInput,
+		apperrors.ErrSenderReceiverSame,
+		apperrors.ErrPerTransactionLimit,
+	} {
+		if errors.Is(err, permanent) {
+			return true
+		}
+	}
+	return false
+}
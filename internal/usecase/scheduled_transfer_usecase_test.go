@@ -0,0 +1,359 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	"github.com/ravindu/wallet-app-service/internal/usecase"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type mockScheduledTransferRepository struct {
+	mock.Mock
+}
+
+func (m *mockScheduledTransferRepository) Create(ctx context.Context, schedule *domain.ScheduledTransfer) error {
+	args := m.Called(ctx, schedule)
+	return args.Error(0)
+}
+
+func (m *mockScheduledTransferRepository) GetByID(ctx context.Context, id int64) (*domain.ScheduledTransfer, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ScheduledTransfer), args.Error(1)
+}
+
+func (m *mockScheduledTransferRepository) GetByIDForUpdate(ctx context.Context, id int64) (*domain.ScheduledTransfer, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ScheduledTransfer), args.Error(1)
+}
+
+func (m *mockScheduledTransferRepository) ListByUserID(ctx context.Context, userID int64) ([]*domain.ScheduledTransfer, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.ScheduledTransfer), args.Error(1)
+}
+
+func (m *mockScheduledTransferRepository) Update(ctx context.Context, schedule *domain.ScheduledTransfer) error {
+	args := m.Called(ctx, schedule)
+	return args.Error(0)
+}
+
+func (m *mockScheduledTransferRepository) ClaimDue(ctx context.Context, limit int, leaseUntil time.Time) ([]*domain.ScheduledTransfer, error) {
+	args := m.Called(ctx, limit, leaseUntil)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.ScheduledTransfer), args.Error(1)
+}
+
+func (m *mockScheduledTransferRepository) CreateExecution(ctx context.Context, execution *domain.ScheduleExecution) error {
+	args := m.Called(ctx, execution)
+	return args.Error(0)
+}
+
+func (m *mockScheduledTransferRepository) ListExecutions(ctx context.Context, scheduleID int64, limit, offset int) ([]*domain.ScheduleExecution, error) {
+	args := m.Called(ctx, scheduleID, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.ScheduleExecution), args.Error(1)
+}
+
+func (m *mockScheduledTransferRepository) CountExecutions(ctx context.Context, scheduleID int64) (int, error) {
+	args := m.Called(ctx, scheduleID)
+	return args.Int(0), args.Error(1)
+}
+
+// mockTransferUsecase stands in for the wallet use case; scheduled transfers only call Transfer
+type mockTransferUsecase struct {
+	domain.WalletUsecase
+	mock.Mock
+}
+
+func (m *mockTransferUsecase) Transfer(ctx context.Context, req domain.TransferRequest) (*domain.Transaction, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Transaction), args.Error(1)
+}
+
+func TestCreateSchedule(t *testing.T) {
+	ctx := context.Background()
+	future := time.Now().Add(24 * time.Hour)
+	past := time.Now().Add(-time.Minute)
+
+	tests := []struct {
+		name          string
+		req           domain.CreateScheduledTransferRequest
+		receiverFound bool
+		expectedError error
+		check         func(t *testing.T, schedule *domain.ScheduledTransfer)
+	}{
+		{
+			name:          "one-off with defaults",
+			req:           domain.CreateScheduledTransferRequest{ReceiverID: 2, Amount: domain.NewAmount(100), StartAt: &future},
+			receiverFound: true,
+			check: func(t *testing.T, schedule *domain.ScheduledTransfer) {
+				assert.Equal(t, domain.ScheduleActive, schedule.Status)
+				assert.Equal(t, domain.PolicyRetry, schedule.OnInsufficientFunds)
+				assert.Equal(t, 3, schedule.MaxRetries)
+				assert.Equal(t, "UTC", schedule.Timezone)
+				assert.True(t, future.Equal(*schedule.NextRunAt))
+			},
+		},
+		{
+			name:          "interval starts one interval from now",
+			req:           domain.CreateScheduledTransferRequest{ReceiverID: 2, Amount: domain.NewAmount(100), Interval: "1440m"},
+			receiverFound: true,
+			check: func(t *testing.T, schedule *domain.ScheduledTransfer) {
+				assert.Equal(t, "24h0m0s", schedule.Interval)
+				assert.WithinDuration(t, time.Now().Add(24*time.Hour), *schedule.NextRunAt, time.Minute)
+			},
+		},
+		{
+			name:          "start in the past",
+			req:           domain.CreateScheduledTransferRequest{ReceiverID: 2, Amount: domain.NewAmount(100), StartAt: &past},
+			expectedError: apperrors.ErrInvalidInput,
+		},
+		{
+			name:          "one-off without a start",
+			req:           domain.CreateScheduledTransferRequest{ReceiverID: 2, Amount: domain.NewAmount(100)},
+			expectedError: apperrors.ErrInvalidInput,
+		},
+		{
+			name:          "unknown receiver",
+			req:           domain.CreateScheduledTransferRequest{ReceiverID: 2, Amount: domain.NewAmount(100), Cron: "0 9 1 * *"},
+			expectedError: apperrors.ErrUserNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			userRepo := new(mockUserRepository)
+			if tc.receiverFound {
+				userRepo.On("GetByID", ctx, int64(2)).Return(&domain.User{ID: 2}, nil).Maybe()
+			} else {
+				userRepo.On("GetByID", ctx, int64(2)).Return(nil, apperrors.ErrResourceNotFound).Maybe()
+			}
+			walletRepo := new(mockWalletRepository)
+			walletRepo.On("ListByUserID", ctx, int64(1)).Return([]*domain.Wallet{{ID: 10, UserID: 1, Currency: domain.USD}}, nil).Maybe()
+			scheduleRepo := new(mockScheduledTransferRepository)
+			scheduleRepo.On("Create", ctx, mock.AnythingOfType("*domain.ScheduledTransfer")).Return(nil).Maybe()
+
+			scheduleUsecase := usecase.NewScheduledTransferUsecase(new(mockTransferUsecase), userRepo, walletRepo, scheduleRepo, &mockUnitOfWork{}, nil, 0)
+			schedule, err := scheduleUsecase.CreateSchedule(ctx, 1, tc.req)
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				scheduleRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			tc.check(t, schedule)
+		})
+	}
+}
+
+func TestRunDueSchedules(t *testing.T) {
+	ctx := context.Background()
+	occurrence := time.Now().Add(-time.Minute).UTC().Truncate(time.Second)
+	lease := time.Now().Add(5 * time.Minute).UTC().Truncate(time.Second)
+
+	newClaimed := func(attempts int, policy domain.InsufficientFundsPolicy) *domain.ScheduledTransfer {
+		return &domain.ScheduledTransfer{
+			ID: 5, UserID: 1, ReceiverID: 2, Amount: domain.NewAmount(100),
+			Interval: "24h0m0s", Timezone: "UTC", StartAt: occurrence,
+			OnInsufficientFunds: policy, MaxRetries: 2, Status: domain.ScheduleActive,
+			NextRunAt: &occurrence, NextAttemptAt: &lease, Attempts: attempts,
+		}
+	}
+
+	// What the sender's limits return for the scheduled amount of 100: one rule
+	// it breaks on its own, one it breaks only until the day's transfer ages out
+	perTransaction := domain.LimitRule{TransactionType: domain.Transfer, PerTransaction: domain.NewAmount(50)}
+	perTransactionErr := perTransaction.Check(domain.NewAmount(100), domain.LimitUsage{}, domain.LimitUsage{})
+	daily := domain.LimitRule{TransactionType: domain.Transfer, DailyCount: 1}
+	dailyErr := daily.Check(domain.NewAmount(100), domain.LimitUsage{Amount: domain.NewAmount(100), Count: 1}, domain.LimitUsage{})
+
+	tests := []struct {
+		name              string
+		schedule          *domain.ScheduledTransfer
+		reclaimed         bool
+		transferErr       error
+		expectedExecution domain.ScheduleExecutionStatus
+		expectedStatus    domain.ScheduleStatus
+		expectedAttempts  int
+		// expectedNextRun is how far the occurrence moved on; zero means it is retried
+		expectedNextRun time.Duration
+	}{
+		{
+			name:              "transfer made and next occurrence set",
+			schedule:          newClaimed(1, domain.PolicyRetry),
+			expectedExecution: domain.ExecutionSucceeded,
+			expectedStatus:    domain.ScheduleActive,
+			expectedNextRun:   24 * time.Hour,
+		},
+		{
+			name:              "insufficient funds is retried",
+			schedule:          newClaimed(0, domain.PolicyRetry),
+			transferErr:       apperrors.ErrInsufficientFunds,
+			expectedExecution: domain.ExecutionFailed,
+			expectedStatus:    domain.ScheduleActive,
+			expectedAttempts:  1,
+		},
+		{
+			name:              "insufficient funds skipped once retries run out",
+			schedule:          newClaimed(2, domain.PolicyRetry),
+			transferErr:       apperrors.ErrInsufficientFunds,
+			expectedExecution: domain.ExecutionSkipped,
+			expectedStatus:    domain.ScheduleActive,
+			expectedNextRun:   24 * time.Hour,
+		},
+		{
+			name:              "insufficient funds skipped straight away",
+			schedule:          newClaimed(0, domain.PolicySkip),
+			transferErr:       apperrors.ErrInsufficientFunds,
+			expectedExecution: domain.ExecutionSkipped,
+			expectedStatus:    domain.ScheduleActive,
+			expectedNextRun:   24 * time.Hour,
+		},
+		{
+			name:              "missing wallet fails the schedule",
+			schedule:          newClaimed(0, domain.PolicyRetry),
+			transferErr:       apperrors.ErrWalletNotFound,
+			expectedExecution: domain.ExecutionFailed,
+			expectedStatus:    domain.ScheduleFailed,
+			expectedAttempts:  1,
+		},
+		{
+			name:              "per-transaction limit fails the schedule",
+			schedule:          newClaimed(0, domain.PolicyRetry),
+			transferErr:       perTransactionErr,
+			expectedExecution: domain.ExecutionFailed,
+			expectedStatus:    domain.ScheduleFailed,
+			expectedAttempts:  1,
+		},
+		{
+			name:              "daily limit is retried",
+			schedule:          newClaimed(0, domain.PolicyRetry),
+			transferErr:       dailyErr,
+			expectedExecution: domain.ExecutionFailed,
+			expectedStatus:    domain.ScheduleActive,
+			expectedAttempts:  1,
+		},
+		{
+			name:      "reclaimed by another scheduler",
+			schedule:  newClaimed(0, domain.PolicyRetry),
+			reclaimed: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			claimed := *tc.schedule
+			locked := *tc.schedule
+			if tc.reclaimed {
+				later := lease.Add(time.Minute)
+				locked.NextAttemptAt = &later
+			}
+
+			scheduleRepo := new(mockScheduledTransferRepository)
+			scheduleRepo.On("ClaimDue", ctx, 10, mock.AnythingOfType("time.Time")).Return([]*domain.ScheduledTransfer{&claimed}, nil)
+			scheduleRepo.On("GetByIDForUpdate", ctx, int64(5)).Return(&locked, nil)
+			var execution *domain.ScheduleExecution
+			scheduleRepo.On("CreateExecution", ctx, mock.AnythingOfType("*domain.ScheduleExecution")).Run(func(args mock.Arguments) {
+				execution = args.Get(1).(*domain.ScheduleExecution)
+			}).Return(nil).Maybe()
+			scheduleRepo.On("Update", ctx, &locked).Return(nil).Maybe()
+
+			walletUsecase := new(mockTransferUsecase)
+			if tc.transferErr != nil {
+				walletUsecase.On("Transfer", ctx, mock.AnythingOfType("domain.TransferRequest")).Return(nil, tc.transferErr)
+			} else {
+				walletUsecase.On("Transfer", ctx, mock.AnythingOfType("domain.TransferRequest")).Return(&domain.Transaction{ID: 42}, nil).Maybe()
+			}
+
+			scheduleUsecase := usecase.NewScheduledTransferUsecase(walletUsecase, new(mockUserRepository), new(mockWalletRepository), scheduleRepo, &mockUnitOfWork{}, nil, time.Hour)
+			ran, err := scheduleUsecase.RunDueSchedules(ctx, 10)
+			require.NoError(t, err)
+			assert.Equal(t, 1, ran)
+
+			if tc.reclaimed {
+				walletUsecase.AssertNotCalled(t, "Transfer", mock.Anything, mock.Anything)
+				scheduleRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+				return
+			}
+
+			require.NotNil(t, execution)
+			assert.Equal(t, tc.expectedExecution, execution.Status)
+			assert.True(t, occurrence.Equal(execution.ScheduledFor))
+			if tc.transferErr == nil {
+				assert.Equal(t, int64(42), *execution.TransactionID)
+			}
+
+			assert.Equal(t, tc.expectedStatus, locked.Status)
+			assert.Equal(t, tc.expectedAttempts, locked.Attempts)
+			switch {
+			case tc.expectedStatus != domain.ScheduleActive:
+				assert.Nil(t, locked.NextRunAt)
+			case tc.expectedNextRun > 0:
+				assert.True(t, occurrence.Add(tc.expectedNextRun).Equal(*locked.NextRunAt))
+			default:
+				assert.True(t, occurrence.Equal(*locked.NextRunAt))
+				assert.WithinDuration(t, time.Now().Add(time.Hour), *locked.NextAttemptAt, time.Minute)
+			}
+		})
+	}
+}
+
+func TestCancelSchedule(t *testing.T) {
+	ctx := context.Background()
+	next := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name          string
+		callerID      int64
+		status        domain.ScheduleStatus
+		expectedError error
+	}{
+		{name: "owner cancels", callerID: 1, status: domain.ScheduleActive},
+		{name: "another user's schedule", callerID: 2, status: domain.ScheduleActive, expectedError: apperrors.ErrForbidden},
+		{name: "already completed", callerID: 1, status: domain.ScheduleCompleted, expectedError: apperrors.ErrScheduleNotActive},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			schedule := &domain.ScheduledTransfer{ID: 5, UserID: 1, Status: tc.status, NextRunAt: &next, NextAttemptAt: &next}
+
+			scheduleRepo := new(mockScheduledTransferRepository)
+			scheduleRepo.On("GetByIDForUpdate", ctx, int64(5)).Return(schedule, nil)
+			scheduleRepo.On("Update", ctx, schedule).Return(nil).Maybe()
+
+			scheduleUsecase := usecase.NewScheduledTransferUsecase(new(mockTransferUsecase), new(mockUserRepository), new(mockWalletRepository), scheduleRepo, &mockUnitOfWork{}, nil, 0)
+			cancelled, err := scheduleUsecase.CancelSchedule(ctx, tc.callerID, 5)
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				scheduleRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, domain.ScheduleCancelled, cancelled.Status)
+			assert.Nil(t, cancelled.NextAttemptAt)
+		})
+	}
+}
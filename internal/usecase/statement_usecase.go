@@ -0,0 +1,85 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+)
+
+type statementUsecase struct {
+	userRepo        domain.UserRepository
+	walletRepo      domain.WalletRepository
+	transactionRepo domain.TransactionRepository
+	snapshotRepo    domain.BalanceSnapshotRepository
+}
+
+// NewStatementUsecase creates a statement use case for exporting wallet histories
+func NewStatementUsecase(
+	userRepo domain.UserRepository,
+	walletRepo domain.WalletRepository,
+	transactionRepo domain.TransactionRepository,
+	snapshotRepo domain.BalanceSnapshotRepository,
+) domain.StatementUsecase {
+	return &statementUsecase{
+		userRepo:        userRepo,
+		walletRepo:      walletRepo,
+		transactionRepo: transactionRepo,
+		snapshotRepo:    snapshotRepo,
+	}
+}
+
+// WriteStatement streams a wallet's opening balance, every transaction in the
+// period oldest first, and its closing balance into w
+func (u *statementUsecase) WriteStatement(ctx context.Context, req domain.StatementRequest, w domain.StatementWriter) error {
+	if err := req.Validate(); err != nil {
+		return err
+	}
+
+	user, err := u.userRepo.GetByID(ctx, req.UserID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrResourceNotFound) {
+			return apperrors.ErrUserNotFound
+		}
+		return apperrors.WrapError(err, "failed to get user")
+	}
+
+	wallet, err := selectWallet(ctx, u.walletRepo, user.ID, req.Selector)
+	if err != nil {
+		return err
+	}
+
+	opening, err := balanceAt(ctx, u.transactionRepo, u.snapshotRepo, wallet, req.From)
+	if err != nil {
+		return err
+	}
+
+	if err := w.Begin(domain.StatementHeader{
+		Wallet:         wallet,
+		From:           req.From,
+		To:             req.To,
+		OpeningBalance: opening,
+		GeneratedAt:    time.Now().UTC(),
+	}); err != nil {
+		return err
+	}
+
+	// Each row carries its own running balance, so the last one is the closing balance
+	closing := opening
+	filter := domain.TransactionFilter{
+		From: &req.From,
+		To:   &req.To,
+		Sort: domain.SortOldestFirst,
+	}
+	err = u.transactionRepo.StreamByWalletID(ctx, wallet.ID, filter, func(transaction *domain.Transaction) error {
+		closing = transaction.BalanceAfter
+		return w.WriteTransaction(transaction)
+	})
+	if err != nil {
+		return apperrors.WrapError(err, "failed to write statement transactions")
+	}
+
+	return w.End(closing)
+}
@@ -0,0 +1,164 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	"github.com/ravindu/wallet-app-service/internal/usecase"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// recordingStatementWriter keeps what a statement was asked to write
+type recordingStatementWriter struct {
+	header       *domain.StatementHeader
+	transactions []int64
+	closing      *domain.Amount
+	failWrite    error
+}
+
+func (w *recordingStatementWriter) Begin(header domain.StatementHeader) error {
+	w.header = &header
+	return nil
+}
+
+func (w *recordingStatementWriter) WriteTransaction(transaction *domain.Transaction) error {
+	if w.failWrite != nil {
+		return w.failWrite
+	}
+	w.transactions = append(w.transactions, transaction.ID)
+	return nil
+}
+
+func (w *recordingStatementWriter) End(closingBalance domain.Amount) error {
+	w.closing = &closingBalance
+	return nil
+}
+
+func TestWriteStatement(t *testing.T) {
+	ctx := context.Background()
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	filter := domain.TransactionFilter{From: &from, To: &to, Sort: domain.SortOldestFirst}
+	wallet := &domain.Wallet{ID: 7, UserID: 1, Balance: domain.NewAmount(500), Currency: domain.USD}
+	errBrokenPipe := errors.New("broken pipe")
+	inPeriod := []*domain.Transaction{
+		{ID: 11, BalanceBefore: domain.NewAmount(100), BalanceAfter: domain.NewAmount(125), TransactionTime: from.Add(time.Hour)},
+		{ID: 12, BalanceBefore: domain.NewAmount(125), BalanceAfter: domain.NewAmount(115), TransactionTime: from.Add(2 * time.Hour)},
+	}
+
+	tests := []struct {
+		name                 string
+		request              domain.StatementRequest
+		failWrite            error
+		setupMocks           func(*mockUserRepository, *mockWalletRepository, *mockTransactionRepository)
+		expectedOpening      domain.Amount
+		expectedClosing      domain.Amount
+		expectedTransactions []int64
+		expectedError        error
+	}{
+		{
+			name:    "opening balance from the last transaction before the period",
+			request: domain.StatementRequest{UserID: 1, From: from, To: to},
+			setupMocks: func(userRepo *mockUserRepository, walletRepo *mockWalletRepository, transactionRepo *mockTransactionRepository) {
+				userRepo.On("GetByID", ctx, int64(1)).Return(&domain.User{ID: 1}, nil)
+				walletRepo.On("ListByUserID", ctx, int64(1)).Return([]*domain.Wallet{wallet}, nil)
+				transactionRepo.On("GetLastBetween", ctx, int64(7), time.Time{}, from).Return(&domain.Transaction{ID: 10, BalanceAfter: domain.NewAmount(100)}, nil)
+				transactionRepo.On("StreamByWalletID", ctx, int64(7), filter).Return(inPeriod, nil)
+			},
+			expectedOpening:      domain.NewAmount(100),
+			expectedClosing:      domain.NewAmount(115),
+			expectedTransactions: []int64{11, 12},
+		},
+		{
+			name:    "opening balance from the first transaction when the wallet is new",
+			request: domain.StatementRequest{UserID: 1, From: from, To: to},
+			setupMocks: func(userRepo *mockUserRepository, walletRepo *mockWalletRepository, transactionRepo *mockTransactionRepository) {
+				userRepo.On("GetByID", ctx, int64(1)).Return(&domain.User{ID: 1}, nil)
+				walletRepo.On("ListByUserID", ctx, int64(1)).Return([]*domain.Wallet{wallet}, nil)
+				transactionRepo.On("GetLastBetween", ctx, int64(7), time.Time{}, from).Return(nil, apperrors.ErrResourceNotFound)
+				transactionRepo.On("GetFirstFrom", ctx, int64(7), from).Return(inPeriod[0], nil)
+				transactionRepo.On("StreamByWalletID", ctx, int64(7), filter).Return(inPeriod, nil)
+			},
+			expectedOpening:      domain.NewAmount(100),
+			expectedClosing:      domain.NewAmount(115),
+			expectedTransactions: []int64{11, 12},
+		},
+		{
+			name:    "quiet wallet opens and closes on its current balance",
+			request: domain.StatementRequest{UserID: 1, From: from, To: to},
+			setupMocks: func(userRepo *mockUserRepository, walletRepo *mockWalletRepository, transactionRepo *mockTransactionRepository) {
+				userRepo.On("GetByID", ctx, int64(1)).Return(&domain.User{ID: 1}, nil)
+				walletRepo.On("ListByUserID", ctx, int64(1)).Return([]*domain.Wallet{wallet}, nil)
+				transactionRepo.On("GetLastBetween", ctx, int64(7), time.Time{}, from).Return(nil, apperrors.ErrResourceNotFound)
+				transactionRepo.On("GetFirstFrom", ctx, int64(7), from).Return(nil, apperrors.ErrResourceNotFound)
+				transactionRepo.On("StreamByWalletID", ctx, int64(7), filter).Return(nil, nil)
+			},
+			expectedOpening: domain.NewAmount(500),
+			expectedClosing: domain.NewAmount(500),
+		},
+		{
+			name:          "period the wrong way round",
+			request:       domain.StatementRequest{UserID: 1, From: to, To: from},
+			setupMocks:    func(*mockUserRepository, *mockWalletRepository, *mockTransactionRepository) {},
+			expectedError: apperrors.ErrInvalidInput,
+		},
+		{
+			name:    "user not found",
+			request: domain.StatementRequest{UserID: 1, From: from, To: to},
+			setupMocks: func(userRepo *mockUserRepository, walletRepo *mockWalletRepository, transactionRepo *mockTransactionRepository) {
+				userRepo.On("GetByID", ctx, int64(1)).Return(nil, apperrors.ErrResourceNotFound)
+			},
+			expectedError: apperrors.ErrUserNotFound,
+		},
+		{
+			name:      "writer failure stops the statement",
+			request:   domain.StatementRequest{UserID: 1, From: from, To: to},
+			failWrite: errBrokenPipe,
+			setupMocks: func(userRepo *mockUserRepository, walletRepo *mockWalletRepository, transactionRepo *mockTransactionRepository) {
+				userRepo.On("GetByID", ctx, int64(1)).Return(&domain.User{ID: 1}, nil)
+				walletRepo.On("ListByUserID", ctx, int64(1)).Return([]*domain.Wallet{wallet}, nil)
+				transactionRepo.On("GetLastBetween", ctx, int64(7), time.Time{}, from).Return(&domain.Transaction{ID: 10, BalanceAfter: domain.NewAmount(100)}, nil)
+				transactionRepo.On("StreamByWalletID", ctx, int64(7), filter).Return(inPeriod, nil)
+			},
+			expectedError: errBrokenPipe,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			userRepo := new(mockUserRepository)
+			walletRepo := new(mockWalletRepository)
+			transactionRepo := new(mockTransactionRepository)
+			tc.setupMocks(userRepo, walletRepo, transactionRepo)
+
+			// Snapshots are covered by the balance use case tests
+			snapshotRepo := new(mockBalanceSnapshotRepository)
+			snapshotRepo.On("GetLatest", ctx, int64(7), from).Return(nil, apperrors.ErrResourceNotFound).Maybe()
+
+			statementUsecase := usecase.NewStatementUsecase(userRepo, walletRepo, transactionRepo, snapshotRepo)
+			writer := &recordingStatementWriter{failWrite: tc.failWrite}
+			err := statementUsecase.WriteStatement(ctx, tc.request, writer)
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				assert.Nil(t, writer.closing)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedOpening, writer.header.OpeningBalance)
+				assert.Equal(t, wallet, writer.header.Wallet)
+				assert.Equal(t, tc.expectedTransactions, writer.transactions)
+				assert.Equal(t, tc.expectedClosing, *writer.closing)
+			}
+
+			userRepo.AssertExpectations(t)
+			walletRepo.AssertExpectations(t)
+			transactionRepo.AssertExpectations(t)
+			transactionRepo.AssertNotCalled(t, "GetByWalletID", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
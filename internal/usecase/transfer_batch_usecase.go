@@ -0,0 +1,416 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+	"github.com/redis/go-redis/v9"
+)
+
+// batchLease is how long a claimed batch is left to one worker before another
+// may pick it up. A best-effort batch renews it after every item, so it only
+// runs out if the worker dies.
+const batchLease = 5 * time.Minute
+
+type transferBatchUsecase struct {
+	walletUsecase domain.WalletUsecase
+	feeUsecase    domain.FeeUsecase
+	walletRepo    domain.WalletRepository
+	batchRepo     domain.TransferBatchRepository
+	unitOfWork    domain.UnitOfWork
+	redisClient   *redis.Client
+}
+
+// NewTransferBatchUsecase creates a transfer batch use case for bulk payouts
+func NewTransferBatchUsecase(
+	walletUsecase domain.WalletUsecase,
+	feeUsecase domain.FeeUsecase,
+	walletRepo domain.WalletRepository,
+	batchRepo domain.TransferBatchRepository,
+	unitOfWork domain.UnitOfWork,
+	redisClient *redis.Client,
+) domain.TransferBatchUsecase {
+	return &transferBatchUsecase{
+		walletUsecase: walletUsecase,
+		feeUsecase:    feeUsecase,
+		walletRepo:    walletRepo,
+		batchRepo:     batchRepo,
+		unitOfWork:    unitOfWork,
+		redisClient:   redisClient,
+	}
+}
+
+// CreateBatch checks a batch from one of userID's wallets and queues it. A batch
+// the wallet can't cover as a whole, transfer fees included, is refused up front.
+func (u *transferBatchUsecase) CreateBatch(
+	ctx context.Context,
+	userID int64,
+	req domain.CreateTransferBatchRequest,
+) (*domain.TransferBatch, error) {
+	wallet, err := selectWallet(ctx, u.walletRepo, userID, domain.WalletSelector{
+		WalletID: req.SenderWalletID,
+		Currency: req.Currency,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	batch := &domain.TransferBatch{
+		UserID:         userID,
+		SenderWalletID: wallet.ID,
+		Currency:       wallet.Currency,
+		Mode:           req.Mode,
+	}
+	if batch.Mode == "" {
+		batch.Mode = domain.BatchAllOrNothing
+	}
+
+	items := make([]*domain.TransferBatchItem, len(req.Items))
+	for i, item := range req.Items {
+		items[i] = &domain.TransferBatchItem{
+			Line:             i + 1,
+			ReceiverID:       item.ReceiverID,
+			ReceiverWalletID: item.ReceiverWalletID,
+			Amount:           item.Amount,
+			Comment:          item.Comment,
+		}
+	}
+
+	if err := batch.Prepare(items); err != nil {
+		return nil, err
+	}
+
+	required, err := u.withFees(ctx, batch, items)
+	if err != nil {
+		return nil, err
+	}
+	if wallet.AvailableBalance() < required {
+		return nil, fmt.Errorf("%w: the batch needs %s %s with fees but %s is available",
+			apperrors.ErrInsufficientFunds, required, batch.Currency, wallet.AvailableBalance())
+	}
+
+	err = u.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		if err := u.batchRepo.Create(ctx, batch, items); err != nil {
+			return apperrors.WrapError(err, "failed to create transfer batch")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return batch, nil
+}
+
+// withFees adds the fee each item will be charged as a transfer to the batch's
+// total, giving what has to leave the wallet for every item to go through
+func (u *transferBatchUsecase) withFees(ctx context.Context, batch *domain.TransferBatch, items []*domain.TransferBatchItem) (domain.Amount, error) {
+	total := batch.TotalAmount
+	if u.feeUsecase == nil {
+		return total, nil
+	}
+
+	for _, item := range items {
+		fee, err := u.feeUsecase.Quote(ctx, domain.FeeQuoteRequest{
+			TransactionType: domain.Transfer,
+			Currency:        batch.Currency,
+			Amount:          item.Amount,
+		})
+		if err != nil {
+			return 0, err
+		}
+
+		if total, err = total.Add(fee.Fee); err != nil {
+			return 0, fmt.Errorf("%w: the batch total with fees is too large", apperrors.ErrInvalidInput)
+		}
+	}
+
+	return total, nil
+}
+
+// GetBatch returns one of the user's batches
+func (u *transferBatchUsecase) GetBatch(ctx context.Context, userID, batchID int64) (*domain.TransferBatch, error) {
+	batch, err := u.batchRepo.GetByID(ctx, batchID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrResourceNotFound) {
+			return nil, apperrors.ErrBatchNotFound
+		}
+		return nil, apperrors.WrapError(err, "failed to get transfer batch")
+	}
+
+	if batch.UserID != userID {
+		return nil, fmt.Errorf("%w: transfer batch %d belongs to another user", apperrors.ErrForbidden, batch.ID)
+	}
+	return batch, nil
+}
+
+// ListItems returns a page of a batch's items in submission order
+func (u *transferBatchUsecase) ListItems(
+	ctx context.Context,
+	userID, batchID int64,
+	status domain.BatchItemStatus,
+	pagination domain.PaginationRequest,
+) (*domain.TransferBatchItemsResponse, error) {
+	switch status {
+	case "", domain.BatchItemPending, domain.BatchItemSucceeded, domain.BatchItemFailed, domain.BatchItemSkipped:
+	default:
+		return nil, fmt.Errorf("%w: unknown item status %q", apperrors.ErrInvalidInput, status)
+	}
+
+	if _, err := u.GetBatch(ctx, userID, batchID); err != nil {
+		return nil, err
+	}
+
+	items, err := u.batchRepo.ListItems(ctx, batchID, status, pagination.Limit, pagination.Offset)
+	if err != nil {
+		return nil, apperrors.WrapError(err, "failed to list transfer batch items")
+	}
+
+	total, err := u.batchRepo.CountItems(ctx, batchID, status)
+	if err != nil {
+		return nil, apperrors.WrapError(err, "failed to count transfer batch items")
+	}
+
+	if items == nil {
+		items = []*domain.TransferBatchItem{}
+	}
+
+	return &domain.TransferBatchItemsResponse{
+		Items:  items,
+		Total:  total,
+		Limit:  pagination.Limit,
+		Offset: pagination.Offset,
+	}, nil
+}
+
+// ProcessBatches claims waiting batches and works through each. Claiming leases
+// the batches in the database, so with several replicas each batch is worked
+// through by one of them at a time.
+func (u *transferBatchUsecase) ProcessBatches(ctx context.Context, limit int) (int, error) {
+	claimed, err := u.batchRepo.Claim(ctx, limit, time.Now().Add(batchLease))
+	if err != nil {
+		return 0, apperrors.WrapError(err, "failed to claim transfer batches")
+	}
+
+	for i, batch := range claimed {
+		process := u.processBestEffort
+		if batch.Mode == domain.BatchAllOrNothing {
+			process = u.processAllOrNothing
+		}
+
+		if err := process(ctx, batch); err != nil {
+			// The lease runs out and the batch carries on from where it stopped
+			return i, fmt.Errorf("failed to process transfer batch %d: %w", batch.ID, err)
+		}
+	}
+
+	return len(claimed), nil
+}
+
+// processAllOrNothing makes every transfer of the batch in one unit of work, so
+// either they all commit or none do. When one fails, the batch is recorded as
+// failed afterwards. Batches of this mode hold at most MaxAllOrNothingItems
+// transfers, which finish well inside the lease, so it isn't renewed here.
+func (u *transferBatchUsecase) processAllOrNothing(ctx context.Context, claimed *domain.TransferBatch) error {
+	var failed *domain.TransferBatchItem
+	var transferErr error
+	var receivers []int64
+
+	owned, err := u.withClaimedBatch(ctx, claimed, func(ctx context.Context, batch *domain.TransferBatch) error {
+		items, err := u.pendingItems(ctx, batch.ID)
+		if err != nil {
+			return err
+		}
+
+		for _, item := range items {
+			// Joins this unit of work, so a later failure undoes this transfer too
+			transaction, err := u.walletUsecase.Transfer(ctx, item.TransferRequest(batch))
+			if err != nil {
+				failed, transferErr = item, err
+				return err
+			}
+
+			item.Status = domain.BatchItemSucceeded
+			item.TransactionID = &transaction.ID
+			if err := u.batchRepo.UpdateItem(ctx, item); err != nil {
+				return apperrors.WrapError(err, "failed to update transfer batch item")
+			}
+			batch.SucceededCount++
+			receivers = append(receivers, item.ReceiverID)
+		}
+
+		batch.Finish(time.Now())
+		return u.updateBatch(ctx, batch)
+	})
+	if transferErr == nil {
+		if owned {
+			// Transfer cleared the cache before this unit committed; clear it again
+			invalidateBalanceCache(ctx, u.redisClient, append(receivers, claimed.UserID)...)
+		}
+		return err
+	}
+	if !isBatchItemError(transferErr) {
+		return err
+	}
+
+	// Every transfer was rolled back; say which item sank the batch
+	_, err = u.withClaimedBatch(ctx, claimed, func(ctx context.Context, batch *domain.TransferBatch) error {
+		failed.Status = domain.BatchItemFailed
+		failed.TransactionID = nil
+		failed.Error = transferErr.Error()
+		if err := u.batchRepo.UpdateItem(ctx, failed); err != nil {
+			return apperrors.WrapError(err, "failed to update transfer batch item")
+		}
+		if err := u.batchRepo.SkipPendingItems(ctx, batch.ID, fmt.Sprintf("line %d failed", failed.Line)); err != nil {
+			return apperrors.WrapError(err, "failed to skip transfer batch items")
+		}
+
+		batch.SucceededCount = 0
+		batch.FailedCount = 1
+		batch.Error = fmt.Sprintf("line %d: %v", failed.Line, transferErr)
+		batch.Finish(time.Now())
+		return u.updateBatch(ctx, batch)
+	})
+	return err
+}
+
+// processBestEffort makes each transfer of the batch in its own unit of work,
+// recording the ones that fail and carrying on with the rest
+func (u *transferBatchUsecase) processBestEffort(ctx context.Context, claimed *domain.TransferBatch) error {
+	items, err := u.pendingItems(ctx, claimed.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		owned, err := u.processItem(ctx, claimed, item)
+		if err != nil {
+			return err
+		}
+		if !owned {
+			return nil
+		}
+	}
+
+	_, err = u.withClaimedBatch(ctx, claimed, func(ctx context.Context, batch *domain.TransferBatch) error {
+		batch.Finish(time.Now())
+		return u.updateBatch(ctx, batch)
+	})
+	return err
+}
+
+// processItem makes one transfer of a best-effort batch and records its outcome,
+// renewing the lease on the batch. It reports false once the batch is no longer
+// this worker's.
+func (u *transferBatchUsecase) processItem(ctx context.Context, claimed *domain.TransferBatch, item *domain.TransferBatchItem) (bool, error) {
+	var transferErr error
+
+	owned, err := u.withClaimedBatch(ctx, claimed, func(ctx context.Context, batch *domain.TransferBatch) error {
+		transaction, err := u.walletUsecase.Transfer(ctx, item.TransferRequest(batch))
+		if err != nil {
+			transferErr = err
+			return err
+		}
+
+		item.Status = domain.BatchItemSucceeded
+		item.TransactionID = &transaction.ID
+		if err := u.batchRepo.UpdateItem(ctx, item); err != nil {
+			return apperrors.WrapError(err, "failed to update transfer batch item")
+		}
+
+		batch.SucceededCount++
+		batch.Renew(time.Now().Add(batchLease))
+		return u.updateBatch(ctx, batch)
+	})
+	if transferErr == nil {
+		if owned {
+			invalidateBalanceCache(ctx, u.redisClient, claimed.UserID, item.ReceiverID)
+		}
+		return owned, err
+	}
+	if !isBatchItemError(transferErr) {
+		return false, err
+	}
+
+	return u.withClaimedBatch(ctx, claimed, func(ctx context.Context, batch *domain.TransferBatch) error {
+		item.Status = domain.BatchItemFailed
+		item.TransactionID = nil
+		item.Error = transferErr.Error()
+		if err := u.batchRepo.UpdateItem(ctx, item); err != nil {
+			return apperrors.WrapError(err, "failed to update transfer batch item")
+		}
+
+		batch.FailedCount++
+		batch.Renew(time.Now().Add(batchLease))
+		return u.updateBatch(ctx, batch)
+	})
+}
+
+// withClaimedBatch runs fn in a unit of work holding the batch's lock, as long
+// as the batch is still leased to this worker. It reports whether fn ran and
+// committed, and then brings claimed up to date with the lease fn left behind.
+func (u *transferBatchUsecase) withClaimedBatch(
+	ctx context.Context,
+	claimed *domain.TransferBatch,
+	fn func(ctx context.Context, batch *domain.TransferBatch) error,
+) (bool, error) {
+	var locked *domain.TransferBatch
+
+	err := u.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		batch, err := u.batchRepo.GetByIDForUpdate(ctx, claimed.ID)
+		if err != nil {
+			if errors.Is(err, apperrors.ErrResourceNotFound) {
+				return apperrors.ErrBatchNotFound
+			}
+			return apperrors.WrapError(err, "failed to get transfer batch")
+		}
+
+		// Finished, or taken over by another worker after our lease ran out
+		if !batch.ClaimedBy(claimed) {
+			return nil
+		}
+
+		if err := fn(ctx, batch); err != nil {
+			return err
+		}
+		locked = batch
+		return nil
+	})
+	if err != nil || locked == nil {
+		return false, err
+	}
+
+	claimed.Status = locked.Status
+	claimed.LeaseUntil = locked.LeaseUntil
+	return true, nil
+}
+
+// pendingItems returns the batch's items that haven't been attempted yet
+func (u *transferBatchUsecase) pendingItems(ctx context.Context, batchID int64) ([]*domain.TransferBatchItem, error) {
+	items, err := u.batchRepo.ListItems(ctx, batchID, domain.BatchItemPending, domain.MaxBatchItems, 0)
+	if err != nil {
+		return nil, apperrors.WrapError(err, "failed to list transfer batch items")
+	}
+	return items, nil
+}
+
+// updateBatch saves the batch's progress
+func (u *transferBatchUsecase) updateBatch(ctx context.Context, batch *domain.TransferBatch) error {
+	if err := u.batchRepo.Update(ctx, batch); err != nil {
+		return apperrors.WrapError(err, "failed to update transfer batch")
+	}
+	return nil
+}
+
+// isBatchItemError reports whether a transfer failed because of the item itself,
+// rather than something a later attempt could get past. Retrying the batch
+// wouldn't wait out a limit window, so any limit the item breaks fails it.
+func isBatchItemError(err error) bool {
+	return isPermanentTransferError(err) ||
+		errors.Is(err, apperrors.ErrInsufficientFunds) ||
+		errors.Is(err, apperrors.ErrLimitExceeded)
+}
@@ -0,0 +1,362 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	"github.com/ravindu/wallet-app-service/internal/usecase"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type mockTransferBatchRepository struct {
+	mock.Mock
+}
+
+func (m *mockTransferBatchRepository) Create(ctx context.Context, batch *domain.TransferBatch, items []*domain.TransferBatchItem) error {
+	args := m.Called(ctx, batch, items)
+	return args.Error(0)
+}
+
+func (m *mockTransferBatchRepository) GetByID(ctx context.Context, id int64) (*domain.TransferBatch, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.TransferBatch), args.Error(1)
+}
+
+func (m *mockTransferBatchRepository) GetByIDForUpdate(ctx context.Context, id int64) (*domain.TransferBatch, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.TransferBatch), args.Error(1)
+}
+
+func (m *mockTransferBatchRepository) Update(ctx context.Context, batch *domain.TransferBatch) error {
+	args := m.Called(ctx, batch)
+	return args.Error(0)
+}
+
+func (m *mockTransferBatchRepository) Claim(ctx context.Context, limit int, leaseUntil time.Time) ([]*domain.TransferBatch, error) {
+	args := m.Called(ctx, limit, leaseUntil)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.TransferBatch), args.Error(1)
+}
+
+func (m *mockTransferBatchRepository) ListItems(
+	ctx context.Context,
+	batchID int64,
+	status domain.BatchItemStatus,
+	limit, offset int,
+) ([]*domain.TransferBatchItem, error) {
+	args := m.Called(ctx, batchID, status, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.TransferBatchItem), args.Error(1)
+}
+
+func (m *mockTransferBatchRepository) CountItems(ctx context.Context, batchID int64, status domain.BatchItemStatus) (int, error) {
+	args := m.Called(ctx, batchID, status)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *mockTransferBatchRepository) UpdateItem(ctx context.Context, item *domain.TransferBatchItem) error {
+	args := m.Called(ctx, item)
+	return args.Error(0)
+}
+
+func (m *mockTransferBatchRepository) SkipPendingItems(ctx context.Context, batchID int64, reason string) error {
+	args := m.Called(ctx, batchID, reason)
+	return args.Error(0)
+}
+
+func TestCreateBatch(t *testing.T) {
+	ctx := context.Background()
+
+	items := func(amounts ...int64) []domain.TransferBatchItemRequest {
+		var reqs []domain.TransferBatchItemRequest
+		for i, amount := range amounts {
+			reqs = append(reqs, domain.TransferBatchItemRequest{ReceiverID: int64(i + 2), Amount: domain.NewAmount(amount)})
+		}
+		return reqs
+	}
+
+	tests := []struct {
+		name          string
+		req           domain.CreateTransferBatchRequest
+		heldBalance   domain.Amount
+		feeUsecase    domain.FeeUsecase
+		expectedError error
+		check         func(t *testing.T, batch *domain.TransferBatch)
+	}{
+		{
+			name: "queued with defaults",
+			req:  domain.CreateTransferBatchRequest{Items: items(60, 40)},
+			check: func(t *testing.T, batch *domain.TransferBatch) {
+				assert.Equal(t, domain.BatchAllOrNothing, batch.Mode)
+				assert.Equal(t, domain.BatchPending, batch.Status)
+				assert.Equal(t, int64(10), batch.SenderWalletID)
+				assert.Equal(t, 2, batch.ItemCount)
+				assert.Equal(t, domain.NewAmount(100), batch.TotalAmount)
+			},
+		},
+		{
+			name: "best effort",
+			req:  domain.CreateTransferBatchRequest{Mode: domain.BatchBestEffort, Items: items(10)},
+			check: func(t *testing.T, batch *domain.TransferBatch) {
+				assert.Equal(t, domain.BatchBestEffort, batch.Mode)
+			},
+		},
+		{
+			name:          "total more than the balance",
+			req:           domain.CreateTransferBatchRequest{Items: items(60, 50)},
+			expectedError: apperrors.ErrInsufficientFunds,
+		},
+		{
+			name:       "fees covered",
+			req:        domain.CreateTransferBatchRequest{Items: items(50, 40)},
+			feeUsecase: newTestFeeUsecase(t),
+			check: func(t *testing.T, batch *domain.TransferBatch) {
+				assert.Equal(t, domain.NewAmount(90), batch.TotalAmount)
+			},
+		},
+		{
+			// 99 fits the balance, but the 0.60 and 0.50 transfer fees don't
+			name:          "fees take the total over the balance",
+			req:           domain.CreateTransferBatchRequest{Items: items(60, 39)},
+			feeUsecase:    newTestFeeUsecase(t),
+			expectedError: apperrors.ErrInsufficientFunds,
+		},
+		{
+			name:          "held funds don't count",
+			req:           domain.CreateTransferBatchRequest{Items: items(60, 40)},
+			heldBalance:   domain.NewAmount(1),
+			expectedError: apperrors.ErrInsufficientFunds,
+		},
+		{
+			name:          "invalid item",
+			req:           domain.CreateTransferBatchRequest{Items: []domain.TransferBatchItemRequest{{ReceiverID: 1, Amount: domain.NewAmount(10)}}},
+			expectedError: apperrors.ErrInvalidInput,
+		},
+		{
+			name:          "wallet in another currency",
+			req:           domain.CreateTransferBatchRequest{Currency: domain.EUR, Items: items(10)},
+			expectedError: apperrors.ErrWalletNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			walletRepo := new(mockWalletRepository)
+			walletRepo.On("ListByUserID", ctx, int64(1)).Return([]*domain.Wallet{
+				{ID: 10, UserID: 1, Currency: domain.USD, Balance: domain.NewAmount(100), HeldBalance: tc.heldBalance},
+			}, nil)
+			batchRepo := new(mockTransferBatchRepository)
+			batchRepo.On("Create", ctx, mock.AnythingOfType("*domain.TransferBatch"), mock.AnythingOfType("[]*domain.TransferBatchItem")).Return(nil).Maybe()
+
+			batchUsecase := usecase.NewTransferBatchUsecase(new(mockTransferUsecase), tc.feeUsecase, walletRepo, batchRepo, &mockUnitOfWork{}, nil)
+			batch, err := batchUsecase.CreateBatch(ctx, 1, tc.req)
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				batchRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			tc.check(t, batch)
+		})
+	}
+}
+
+func TestProcessBatches(t *testing.T) {
+	ctx := context.Background()
+	lease := time.Now().Add(5 * time.Minute).UTC().Truncate(time.Microsecond)
+	errDatabase := errors.New("connection reset")
+
+	tests := []struct {
+		name      string
+		mode      domain.BatchMode
+		reclaimed bool
+		// failures maps receivers to the error their transfer fails with
+		failures       map[int64]error
+		expectedErr    error
+		expectedStatus domain.BatchStatus
+		// expectedItems maps lines to the status their item ends with
+		expectedItems map[int]domain.BatchItemStatus
+		expectSkip    bool
+	}{
+		{
+			name:           "all or nothing makes every transfer",
+			mode:           domain.BatchAllOrNothing,
+			expectedStatus: domain.BatchCompleted,
+			expectedItems:  map[int]domain.BatchItemStatus{1: domain.BatchItemSucceeded, 2: domain.BatchItemSucceeded, 3: domain.BatchItemSucceeded},
+		},
+		{
+			name:           "all or nothing fails on one item",
+			mode:           domain.BatchAllOrNothing,
+			failures:       map[int64]error{3: apperrors.ErrInsufficientFunds},
+			expectedStatus: domain.BatchFailed,
+			expectedItems:  map[int]domain.BatchItemStatus{2: domain.BatchItemFailed},
+			expectSkip:     true,
+		},
+		{
+			name:           "best effort carries on past a failure",
+			mode:           domain.BatchBestEffort,
+			failures:       map[int64]error{3: apperrors.ErrUserNotFound},
+			expectedStatus: domain.BatchPartiallyCompleted,
+			expectedItems:  map[int]domain.BatchItemStatus{1: domain.BatchItemSucceeded, 2: domain.BatchItemFailed, 3: domain.BatchItemSucceeded},
+		},
+		{
+			name:           "all or nothing fails on an item over the sender's limits",
+			mode:           domain.BatchAllOrNothing,
+			failures:       map[int64]error{3: apperrors.ErrLimitExceeded},
+			expectedStatus: domain.BatchFailed,
+			expectedItems:  map[int]domain.BatchItemStatus{2: domain.BatchItemFailed},
+			expectSkip:     true,
+		},
+		{
+			name:           "best effort fails only the item over the sender's limits",
+			mode:           domain.BatchBestEffort,
+			failures:       map[int64]error{3: apperrors.ErrLimitExceeded},
+			expectedStatus: domain.BatchPartiallyCompleted,
+			expectedItems:  map[int]domain.BatchItemStatus{1: domain.BatchItemSucceeded, 2: domain.BatchItemFailed, 3: domain.BatchItemSucceeded},
+		},
+		{
+			name:           "best effort with every item failing",
+			mode:           domain.BatchBestEffort,
+			failures:       map[int64]error{2: apperrors.ErrInsufficientFunds, 3: apperrors.ErrInsufficientFunds, 4: apperrors.ErrInsufficientFunds},
+			expectedStatus: domain.BatchFailed,
+			expectedItems:  map[int]domain.BatchItemStatus{1: domain.BatchItemFailed, 2: domain.BatchItemFailed, 3: domain.BatchItemFailed},
+		},
+		{
+			name:           "database error leaves the batch to be picked up again",
+			mode:           domain.BatchBestEffort,
+			failures:       map[int64]error{3: errDatabase},
+			expectedErr:    errDatabase,
+			expectedStatus: domain.BatchProcessing,
+			expectedItems:  map[int]domain.BatchItemStatus{1: domain.BatchItemSucceeded, 2: domain.BatchItemPending, 3: domain.BatchItemPending},
+		},
+		{
+			name:           "reclaimed by another worker",
+			mode:           domain.BatchAllOrNothing,
+			reclaimed:      true,
+			expectedStatus: domain.BatchProcessing,
+			expectedItems:  map[int]domain.BatchItemStatus{1: domain.BatchItemPending, 2: domain.BatchItemPending, 3: domain.BatchItemPending},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			claimed := &domain.TransferBatch{
+				ID: 7, UserID: 1, SenderWalletID: 10, Currency: domain.USD, Mode: tc.mode,
+				Status: domain.BatchProcessing, ItemCount: 3, LeaseUntil: &lease,
+			}
+			locked := *claimed
+			if tc.reclaimed {
+				later := lease.Add(time.Minute)
+				locked.LeaseUntil = &later
+			}
+
+			var items []*domain.TransferBatchItem
+			for i := range 3 {
+				items = append(items, &domain.TransferBatchItem{
+					ID: int64(i + 1), BatchID: 7, Line: i + 1, ReceiverID: int64(i + 2),
+					Amount: domain.NewAmount(10), Status: domain.BatchItemPending,
+				})
+			}
+
+			batchRepo := new(mockTransferBatchRepository)
+			batchRepo.On("Claim", ctx, 5, mock.AnythingOfType("time.Time")).Return([]*domain.TransferBatch{claimed}, nil)
+			batchRepo.On("GetByIDForUpdate", ctx, int64(7)).Return(&locked, nil)
+			batchRepo.On("ListItems", ctx, int64(7), domain.BatchItemPending, domain.MaxBatchItems, 0).Return(items, nil).Maybe()
+			batchRepo.On("UpdateItem", ctx, mock.AnythingOfType("*domain.TransferBatchItem")).Return(nil).Maybe()
+			batchRepo.On("Update", ctx, &locked).Return(nil).Maybe()
+			batchRepo.On("SkipPendingItems", ctx, int64(7), "line 2 failed").Return(nil).Maybe()
+
+			walletUsecase := new(mockTransferUsecase)
+			for receiverID, err := range tc.failures {
+				walletUsecase.On("Transfer", ctx, mock.MatchedBy(func(req domain.TransferRequest) bool {
+					return req.ReceiverID == receiverID
+				})).Return(nil, err)
+			}
+			walletUsecase.On("Transfer", ctx, mock.MatchedBy(func(req domain.TransferRequest) bool {
+				return req.SenderID == 1 && req.SenderWalletID == 10
+			})).Return(&domain.Transaction{ID: 42}, nil).Maybe()
+
+			batchUsecase := usecase.NewTransferBatchUsecase(walletUsecase, nil, new(mockWalletRepository), batchRepo, &mockUnitOfWork{}, nil)
+			processed, err := batchUsecase.ProcessBatches(ctx, 5)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				assert.Equal(t, 0, processed)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, 1, processed)
+			}
+
+			if tc.reclaimed {
+				walletUsecase.AssertNotCalled(t, "Transfer", mock.Anything, mock.Anything)
+			}
+			if tc.expectSkip {
+				batchRepo.AssertCalled(t, "SkipPendingItems", ctx, int64(7), "line 2 failed")
+			} else {
+				batchRepo.AssertNotCalled(t, "SkipPendingItems", mock.Anything, mock.Anything, mock.Anything)
+			}
+
+			assert.Equal(t, tc.expectedStatus, locked.Status)
+			for line, expected := range tc.expectedItems {
+				assert.Equal(t, expected, items[line-1].Status, "line %d", line)
+			}
+			if tc.expectedStatus == domain.BatchFailed && tc.mode == domain.BatchAllOrNothing {
+				assert.Equal(t, 0, locked.SucceededCount)
+				assert.Contains(t, locked.Error, "line 2")
+			}
+		})
+	}
+}
+
+func TestListBatchItems(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("another user's batch", func(t *testing.T) {
+		batchRepo := new(mockTransferBatchRepository)
+		batchRepo.On("GetByID", ctx, int64(7)).Return(&domain.TransferBatch{ID: 7, UserID: 1}, nil)
+
+		batchUsecase := usecase.NewTransferBatchUsecase(new(mockTransferUsecase), nil, new(mockWalletRepository), batchRepo, &mockUnitOfWork{}, nil)
+		_, err := batchUsecase.ListItems(ctx, 2, 7, "", domain.PaginationRequest{Limit: 10})
+
+		assert.ErrorIs(t, err, apperrors.ErrForbidden)
+		batchRepo.AssertNotCalled(t, "ListItems", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("unknown status", func(t *testing.T) {
+		batchUsecase := usecase.NewTransferBatchUsecase(new(mockTransferUsecase), nil, new(mockWalletRepository), new(mockTransferBatchRepository), &mockUnitOfWork{}, nil)
+		_, err := batchUsecase.ListItems(ctx, 1, 7, "DONE", domain.PaginationRequest{Limit: 10})
+
+		assert.ErrorIs(t, err, apperrors.ErrInvalidInput)
+	})
+
+	t.Run("filtered by status", func(t *testing.T) {
+		batchRepo := new(mockTransferBatchRepository)
+		batchRepo.On("GetByID", ctx, int64(7)).Return(&domain.TransferBatch{ID: 7, UserID: 1}, nil)
+		batchRepo.On("ListItems", ctx, int64(7), domain.BatchItemFailed, 10, 0).Return(nil, nil)
+		batchRepo.On("CountItems", ctx, int64(7), domain.BatchItemFailed).Return(0, nil)
+
+		batchUsecase := usecase.NewTransferBatchUsecase(new(mockTransferUsecase), nil, new(mockWalletRepository), batchRepo, &mockUnitOfWork{}, nil)
+		resp, err := batchUsecase.ListItems(ctx, 1, 7, domain.BatchItemFailed, domain.PaginationRequest{Limit: 10})
+
+		require.NoError(t, err)
+		assert.NotNil(t, resp.Items)
+		assert.Equal(t, 0, resp.Total)
+	})
+}
@@ -0,0 +1,212 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+)
+
+type userUsecase struct {
+	userRepo   domain.UserRepository
+	walletRepo domain.WalletRepository
+	ledgerRepo domain.LedgerRepository
+	unitOfWork domain.UnitOfWork
+}
+
+// NewUserUsecase creates a user use case for registration and profile changes
+func NewUserUsecase(
+	userRepo domain.UserRepository,
+	walletRepo domain.WalletRepository,
+	ledgerRepo domain.LedgerRepository,
+	unitOfWork domain.UnitOfWork,
+) domain.UserUsecase {
+	return &userUsecase{
+		userRepo:   userRepo,
+		walletRepo: walletRepo,
+		ledgerRepo: ledgerRepo,
+		unitOfWork: unitOfWork,
+	}
+}
+
+// Register creates a user along with an empty wallet and its ledger account
+func (u *userUsecase) Register(ctx context.Context, req domain.CreateUserRequest) (*domain.RegistrationResponse, error) {
+	username, err := domain.NormalizeUsername(req.Username)
+	if err != nil {
+		return nil, err
+	}
+	email, err := domain.NormalizeEmail(req.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	currency := domain.USD
+	if req.Currency != "" {
+		if currency, err = domain.ParseCurrency(string(req.Currency)); err != nil {
+			return nil, err
+		}
+	}
+
+	user := &domain.User{
+		Username: username,
+		Email:    email,
+	}
+	wallet := &domain.Wallet{
+		Currency: currency,
+	}
+
+	// The user never exists without a wallet, or a wallet without its ledger account
+	err = u.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		if err := u.userRepo.Create(ctx, user); err != nil {
+			return apperrors.WrapError(err, "failed to create user")
+		}
+
+		wallet.UserID = user.ID
+		return u.openWallet(ctx, wallet)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.RegistrationResponse{
+		User:   user,
+		Wallet: wallet,
+	}, nil
+}
+
+// GetUser returns a user's profile
+func (u *userUsecase) GetUser(ctx context.Context, userID int64) (*domain.User, error) {
+	user, err := u.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrResourceNotFound) {
+			return nil, apperrors.ErrUserNotFound
+		}
+		return nil, apperrors.WrapError(err, "failed to get user")
+	}
+
+	return user, nil
+}
+
+// UpdateUser changes the profile fields present in the request
+func (u *userUsecase) UpdateUser(ctx context.Context, userID int64, req domain.UpdateUserRequest) (*domain.User, error) {
+	var username, email string
+	var err error
+
+	if req.Username != nil {
+		if username, err = domain.NormalizeUsername(*req.Username); err != nil {
+			return nil, err
+		}
+	}
+	if req.Email != nil {
+		if email, err = domain.NormalizeEmail(*req.Email); err != nil {
+			return nil, err
+		}
+	}
+
+	var user *domain.User
+
+	err = u.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		locked, err := u.userRepo.GetByIDForUpdate(ctx, userID)
+		if err != nil {
+			if errors.Is(err, apperrors.ErrResourceNotFound) {
+				return apperrors.ErrUserNotFound
+			}
+			return apperrors.WrapError(err, "failed to get user")
+		}
+
+		if req.Username != nil {
+			locked.Username = username
+		}
+		if req.Email != nil {
+			locked.Email = email
+		}
+
+		if err := u.userRepo.Update(ctx, locked); err != nil {
+			return apperrors.WrapError(err, "failed to update user")
+		}
+
+		user = locked
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// CreateWallet opens another wallet for the user in a currency they don't hold yet
+func (u *userUsecase) CreateWallet(ctx context.Context, userID int64, req domain.CreateWalletRequest) (*domain.Wallet, error) {
+	currency, err := domain.ParseCurrency(string(req.Currency))
+	if err != nil {
+		return nil, err
+	}
+
+	label := strings.TrimSpace(req.Label)
+	if utf8.RuneCountInString(label) > domain.MaxWalletLabelLength {
+		return nil, fmt.Errorf("%w: label must be at most %d characters", apperrors.ErrInvalidInput, domain.MaxWalletLabelLength)
+	}
+
+	wallet := &domain.Wallet{
+		UserID:   userID,
+		Currency: currency,
+		Label:    label,
+	}
+
+	err = u.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		if _, err := u.userRepo.GetByID(ctx, userID); err != nil {
+			if errors.Is(err, apperrors.ErrResourceNotFound) {
+				return apperrors.ErrUserNotFound
+			}
+			return apperrors.WrapError(err, "failed to get user")
+		}
+
+		return u.openWallet(ctx, wallet)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return wallet, nil
+}
+
+// ListWallets returns all of a user's wallets
+func (u *userUsecase) ListWallets(ctx context.Context, userID int64) ([]*domain.Wallet, error) {
+	if _, err := u.userRepo.GetByID(ctx, userID); err != nil {
+		if errors.Is(err, apperrors.ErrResourceNotFound) {
+			return nil, apperrors.ErrUserNotFound
+		}
+		return nil, apperrors.WrapError(err, "failed to get user")
+	}
+
+	wallets, err := u.walletRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		return nil, apperrors.WrapError(err, "failed to list wallets")
+	}
+
+	return wallets, nil
+}
+
+// openWallet creates a wallet together with its ledger account, in the caller's unit of work
+func (u *userUsecase) openWallet(ctx context.Context, wallet *domain.Wallet) error {
+	if err := u.walletRepo.Create(ctx, wallet); err != nil {
+		return apperrors.WrapError(err, "failed to create wallet")
+	}
+
+	walletID := wallet.ID
+	account := &domain.LedgerAccount{
+		Code:     domain.WalletAccountCode(wallet.ID),
+		Type:     domain.WalletAccountType,
+		WalletID: &walletID,
+		Currency: wallet.Currency,
+	}
+	if err := u.ledgerRepo.CreateAccount(ctx, account); err != nil {
+		return apperrors.WrapError(err, "failed to create wallet ledger account")
+	}
+
+	return nil
+}
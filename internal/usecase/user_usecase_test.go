@@ -0,0 +1,192 @@
+package usecase_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	"github.com/ravindu/wallet-app-service/internal/usecase"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRegister(t *testing.T) {
+	tests := []struct {
+		name          string
+		req           domain.CreateUserRequest
+		setupMocks    func(*mockUserRepository, *mockWalletRepository, *mockLedgerRepository)
+		expectedError error
+	}{
+		{
+			name: "creates user, wallet and ledger account",
+			req:  domain.CreateUserRequest{Username: " alice ", Email: "Alice@Example.com"},
+			setupMocks: func(userRepo *mockUserRepository, walletRepo *mockWalletRepository, ledgerRepo *mockLedgerRepository) {
+				userRepo.On("Create", mock.Anything, mock.MatchedBy(func(user *domain.User) bool {
+					return user.Username == "alice" && user.Email == "alice@example.com"
+				})).Run(func(args mock.Arguments) {
+					args.Get(1).(*domain.User).ID = 7
+				}).Return(nil)
+				walletRepo.On("Create", mock.Anything, mock.MatchedBy(func(wallet *domain.Wallet) bool {
+					return wallet.UserID == 7 && wallet.Currency == domain.USD && wallet.Balance == 0
+				})).Run(func(args mock.Arguments) {
+					args.Get(1).(*domain.Wallet).ID = 3
+				}).Return(nil)
+				ledgerRepo.On("CreateAccount", mock.Anything, mock.MatchedBy(func(account *domain.LedgerAccount) bool {
+					return account.Code == "wallet:3" && account.Type == domain.WalletAccountType && *account.WalletID == 3
+				})).Return(nil)
+			},
+		},
+		{
+			name: "username taken",
+			req:  domain.CreateUserRequest{Username: "alice", Email: "alice@example.com"},
+			setupMocks: func(userRepo *mockUserRepository, walletRepo *mockWalletRepository, ledgerRepo *mockLedgerRepository) {
+				userRepo.On("Create", mock.Anything, mock.Anything).Return(apperrors.ErrUsernameTaken)
+			},
+			expectedError: apperrors.ErrUsernameTaken,
+		},
+		{
+			name: "email taken",
+			req:  domain.CreateUserRequest{Username: "alice", Email: "alice@example.com"},
+			setupMocks: func(userRepo *mockUserRepository, walletRepo *mockWalletRepository, ledgerRepo *mockLedgerRepository) {
+				userRepo.On("Create", mock.Anything, mock.Anything).Return(apperrors.ErrEmailTaken)
+			},
+			expectedError: apperrors.ErrEmailTaken,
+		},
+		{
+			name:          "invalid username",
+			req:           domain.CreateUserRequest{Username: "a", Email: "alice@example.com"},
+			setupMocks:    func(*mockUserRepository, *mockWalletRepository, *mockLedgerRepository) {},
+			expectedError: apperrors.ErrInvalidInput,
+		},
+		{
+			name:          "invalid email",
+			req:           domain.CreateUserRequest{Username: "alice", Email: "not-an-email"},
+			setupMocks:    func(*mockUserRepository, *mockWalletRepository, *mockLedgerRepository) {},
+			expectedError: apperrors.ErrInvalidInput,
+		},
+		{
+			name:          "unsupported currency",
+			req:           domain.CreateUserRequest{Username: "alice", Email: "alice@example.com", Currency: "XYZ"},
+			setupMocks:    func(*mockUserRepository, *mockWalletRepository, *mockLedgerRepository) {},
+			expectedError: apperrors.ErrUnsupportedCurrency,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			userRepo := new(mockUserRepository)
+			walletRepo := new(mockWalletRepository)
+			ledgerRepo := new(mockLedgerRepository)
+			tc.setupMocks(userRepo, walletRepo, ledgerRepo)
+
+			uc := usecase.NewUserUsecase(userRepo, walletRepo, ledgerRepo, &mockUnitOfWork{})
+			registration, err := uc.Register(context.Background(), tc.req)
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				assert.Nil(t, registration)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, int64(7), registration.User.ID)
+				assert.Equal(t, int64(3), registration.Wallet.ID)
+			}
+
+			userRepo.AssertExpectations(t)
+			walletRepo.AssertExpectations(t)
+			ledgerRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUpdateUser(t *testing.T) {
+	newEmail := "bob@example.com"
+
+	t.Run("changes only the given fields", func(t *testing.T) {
+		userRepo := new(mockUserRepository)
+		userRepo.On("GetByIDForUpdate", mock.Anything, int64(1)).Return(&domain.User{ID: 1, Username: "alice", Email: "alice@example.com"}, nil)
+		userRepo.On("Update", mock.Anything, mock.MatchedBy(func(user *domain.User) bool {
+			return user.Username == "alice" && user.Email == newEmail
+		})).Return(nil)
+
+		uc := usecase.NewUserUsecase(userRepo, new(mockWalletRepository), new(mockLedgerRepository), &mockUnitOfWork{})
+		user, err := uc.UpdateUser(context.Background(), 1, domain.UpdateUserRequest{Email: &newEmail})
+
+		assert.NoError(t, err)
+		assert.Equal(t, newEmail, user.Email)
+		userRepo.AssertExpectations(t)
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		userRepo := new(mockUserRepository)
+		userRepo.On("GetByIDForUpdate", mock.Anything, int64(9)).Return(nil, apperrors.ErrResourceNotFound)
+
+		uc := usecase.NewUserUsecase(userRepo, new(mockWalletRepository), new(mockLedgerRepository), &mockUnitOfWork{})
+		user, err := uc.UpdateUser(context.Background(), 9, domain.UpdateUserRequest{Email: &newEmail})
+
+		assert.ErrorIs(t, err, apperrors.ErrUserNotFound)
+		assert.Nil(t, user)
+	})
+}
+
+func TestCreateWallet(t *testing.T) {
+	tests := []struct {
+		name          string
+		req           domain.CreateWalletRequest
+		createErr     error
+		expectedError error
+	}{
+		{
+			name: "opens a wallet in a new currency",
+			req:  domain.CreateWalletRequest{Currency: "eur", Label: " Travel "},
+		},
+		{
+			name:          "already has a wallet in the currency",
+			req:           domain.CreateWalletRequest{Currency: domain.USD},
+			createErr:     apperrors.ErrWalletExists,
+			expectedError: apperrors.ErrWalletExists,
+		},
+		{
+			name:          "not an ISO 4217 currency",
+			req:           domain.CreateWalletRequest{Currency: "ABC"},
+			expectedError: apperrors.ErrUnsupportedCurrency,
+		},
+		{
+			name:          "label too long",
+			req:           domain.CreateWalletRequest{Currency: domain.EUR, Label: strings.Repeat("a", domain.MaxWalletLabelLength+1)},
+			expectedError: apperrors.ErrInvalidInput,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			userRepo := new(mockUserRepository)
+			walletRepo := new(mockWalletRepository)
+			ledgerRepo := new(mockLedgerRepository)
+
+			userRepo.On("GetByID", mock.Anything, int64(1)).Return(&domain.User{ID: 1}, nil).Maybe()
+			walletRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Wallet")).Run(func(args mock.Arguments) {
+				args.Get(1).(*domain.Wallet).ID = 4
+			}).Return(tc.createErr).Maybe()
+			ledgerRepo.On("CreateAccount", mock.Anything, mock.MatchedBy(func(account *domain.LedgerAccount) bool {
+				return *account.WalletID == 4 && account.Currency == domain.EUR
+			})).Return(nil).Maybe()
+
+			uc := usecase.NewUserUsecase(userRepo, walletRepo, ledgerRepo, &mockUnitOfWork{})
+			wallet, err := uc.CreateWallet(context.Background(), 1, tc.req)
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				assert.Nil(t, wallet)
+				ledgerRepo.AssertNotCalled(t, "CreateAccount", mock.Anything, mock.Anything)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, domain.EUR, wallet.Currency)
+			assert.Equal(t, "Travel", wallet.Label)
+			ledgerRepo.AssertExpectations(t)
+		})
+	}
+}
@@ -5,8 +5,11 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/ravindu/wallet-app-service/internal/domain"
 	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
 	"github.com/redis/go-redis/v9"
@@ -20,20 +23,44 @@ type walletUsecase struct {
 	userRepo        domain.UserRepository
 	walletRepo      domain.WalletRepository
 	transactionRepo domain.TransactionRepository
+	ledgerRepo      domain.LedgerRepository
+	outboxRepo      domain.OutboxRepository
+	webhookRepo     domain.WebhookRepository
+	fxUsecase       domain.FXUsecase
+	feeUsecase      domain.FeeUsecase
+	limitUsecase    domain.LimitUsecase
+	unitOfWork      domain.UnitOfWork
 	redisClient     *redis.Client
 }
 
-// NewWalletUsecase creates a wallet use case with all the necessary repos
+// NewWalletUsecase creates a wallet use case with all the necessary repos.
+// fxUsecase prices conversions; without it wallets can't be converted between currencies.
+// feeUsecase prices fees on deposits, withdrawals and transfers; without it they are free.
+// limitUsecase enforces the users' transaction limits; without it nothing is limited.
 func NewWalletUsecase(
 	userRepo domain.UserRepository,
 	walletRepo domain.WalletRepository,
 	transactionRepo domain.TransactionRepository,
+	ledgerRepo domain.LedgerRepository,
+	outboxRepo domain.OutboxRepository,
+	webhookRepo domain.WebhookRepository,
+	fxUsecase domain.FXUsecase,
+	feeUsecase domain.FeeUsecase,
+	limitUsecase domain.LimitUsecase,
+	unitOfWork domain.UnitOfWork,
 	redisClient *redis.Client,
 ) domain.WalletUsecase {
 	return &walletUsecase{
 		userRepo:        userRepo,
 		walletRepo:      walletRepo,
 		transactionRepo: transactionRepo,
+		ledgerRepo:      ledgerRepo,
+		outboxRepo:      outboxRepo,
+		webhookRepo:     webhookRepo,
+		fxUsecase:       fxUsecase,
+		feeUsecase:      feeUsecase,
+		limitUsecase:    limitUsecase,
+		unitOfWork:      unitOfWork,
 		redisClient:     redisClient,
 	}
 }
@@ -44,256 +71,1253 @@ func (u *walletUsecase) Deposit(ctx context.Context, req domain.DepositRequest)
 		return nil, apperrors.ErrInvalidAmount
 	}
 
-	// Find the user
-	user, err := u.userRepo.GetByID(ctx, req.UserID)
-	if err != nil {
-		if errors.Is(err, apperrors.ErrResourceNotFound) {
-			return nil, apperrors.ErrUserNotFound
+	var transaction *domain.Transaction
+
+	// Balance update and ledger row commit or roll back together
+	err := u.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		// Find the user
+		user, err := u.userRepo.GetByID(ctx, req.UserID)
+		if err != nil {
+			if errors.Is(err, apperrors.ErrResourceNotFound) {
+				return apperrors.ErrUserNotFound
+			}
+			return apperrors.WrapError(err, "failed to get user")
 		}
-		return nil, apperrors.WrapError(err, "failed to get user")
-	}
 
-	// Get their wallet
-	wallet, err := u.walletRepo.GetByUserID(ctx, user.ID)
-	if err != nil {
-		if errors.Is(err, apperrors.ErrResourceNotFound) {
-			return nil, apperrors.ErrWalletNotFound
+		// Get the wallet the request names, locking it until we commit
+		wallet, err := u.lockUserWallet(ctx, user.ID, req.WalletSelector)
+		if err != nil {
+			return err
+		}
+
+		if err := u.checkLimits(ctx, user, wallet, domain.Deposit, req.Amount); err != nil {
+			return err
+		}
+
+		// Price the fee before any money moves
+		fee, err := u.quoteFee(ctx, domain.Deposit, wallet.Currency, req.Amount)
+		if err != nil {
+			return err
+		}
+
+		balanceBefore := wallet.Balance
+
+		// Add the money
+		if err := wallet.Deposit(req.Amount); err != nil {
+			return err // No need to wrap - just pass through domain errors
+		}
+
+		// Save the updated wallet
+		if err := u.walletRepo.Update(ctx, wallet); err != nil {
+			return apperrors.WrapError(err, "failed to update wallet")
+		}
+
+		// Money comes in from outside the platform
+		cashIn, err := u.systemLedgerAccount(ctx, domain.CashInAccount, wallet.Currency)
+		if err != nil {
+			return err
+		}
+		walletAccount, err := u.walletLedgerAccount(ctx, wallet.ID)
+		if err != nil {
+			return err
+		}
+		entry, err := u.postEntry(ctx, domain.Deposit, req.Comment, cashIn, walletAccount, req.Amount)
+		if err != nil {
+			return err
+		}
+
+		// Record the transaction
+		transaction = &domain.Transaction{
+			WalletID:       wallet.ID,
+			Type:           domain.Deposit,
+			Amount:         req.Amount,
+			BalanceBefore:  balanceBefore,
+			BalanceAfter:   wallet.Balance,
+			Description:    req.Comment,
+			JournalEntryID: &entry.ID,
+		}
+		applyFee(transaction, fee)
+
+		if err := u.transactionRepo.Create(ctx, transaction); err != nil {
+			return apperrors.WrapError(err, "failed to create transaction record")
+		}
+
+		// Tell downstream services, committed together with the deposit
+		if err := u.recordEvent(ctx, domain.WalletCredited, wallet.ID, domain.WalletActivity{
+			UserID:      user.ID,
+			WalletID:    wallet.ID,
+			Currency:    wallet.Currency,
+			Transaction: transaction,
+		}, user.ID); err != nil {
+			return err
 		}
-		return nil, apperrors.WrapError(err, "failed to get wallet")
+
+		return u.chargeFee(ctx, wallet, fee, transaction)
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	balanceBefore := wallet.Balance
+	// Clear cache since balance changed
+	invalidateBalanceCache(ctx, u.redisClient, req.UserID)
+
+	return transaction, nil
+}
 
-	// Add the money
-	if err := wallet.Deposit(req.Amount); err != nil {
-		return nil, err // No need to wrap - just pass through domain errors
+// Withdraw takes money from a user's wallet
+func (u *walletUsecase) Withdraw(ctx context.Context, req domain.WithdrawRequest) (*domain.Transaction, error) {
+	if req.Amount <= 0 {
+		return nil, apperrors.ErrInvalidAmount
 	}
 
-	// Save the updated wallet
-	if err := u.walletRepo.Update(ctx, wallet); err != nil {
-		return nil, apperrors.WrapError(err, "failed to update wallet")
+	var transaction *domain.Transaction
+
+	// Balance update and ledger row commit or roll back together
+	err := u.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		// Find the user
+		user, err := u.userRepo.GetByID(ctx, req.UserID)
+		if err != nil {
+			if errors.Is(err, apperrors.ErrResourceNotFound) {
+				return apperrors.ErrUserNotFound
+			}
+			return apperrors.WrapError(err, "failed to get user")
+		}
+
+		// Get the wallet the request names, locking it until we commit
+		wallet, err := u.lockUserWallet(ctx, user.ID, req.WalletSelector)
+		if err != nil {
+			return err
+		}
+
+		if !req.SkipLimits {
+			if err := u.checkLimits(ctx, user, wallet, domain.Withdrawal, req.Amount); err != nil {
+				return err
+			}
+		}
+
+		// Price the fee before any money moves
+		var fee *domain.FeeQuote
+		if !req.WaiveFee {
+			if fee, err = u.quoteFee(ctx, domain.Withdrawal, wallet.Currency, req.Amount); err != nil {
+				return err
+			}
+		}
+
+		balanceBefore := wallet.Balance
+
+		// Take out the money
+		if err := wallet.Withdraw(req.Amount); err != nil {
+			if errors.Is(err, apperrors.ErrInsufficientFunds) {
+				return apperrors.ErrInsufficientFunds
+			}
+			return err // Just pass through domain errors
+		}
+
+		// Save the updated wallet
+		if err := u.walletRepo.Update(ctx, wallet); err != nil {
+			return apperrors.WrapError(err, "failed to update wallet")
+		}
+
+		// Money leaves the platform
+		walletAccount, err := u.walletLedgerAccount(ctx, wallet.ID)
+		if err != nil {
+			return err
+		}
+		cashOut, err := u.systemLedgerAccount(ctx, domain.CashOutAccount, wallet.Currency)
+		if err != nil {
+			return err
+		}
+		entry, err := u.postEntry(ctx, domain.Withdrawal, req.Comment, walletAccount, cashOut, req.Amount)
+		if err != nil {
+			return err
+		}
+
+		// Record the transaction
+		transaction = &domain.Transaction{
+			WalletID:       wallet.ID,
+			Type:           domain.Withdrawal,
+			Amount:         req.Amount,
+			BalanceBefore:  balanceBefore,
+			BalanceAfter:   wallet.Balance,
+			Description:    req.Comment,
+			JournalEntryID: &entry.ID,
+		}
+		applyFee(transaction, fee)
+
+		if err := u.transactionRepo.Create(ctx, transaction); err != nil {
+			return apperrors.WrapError(err, "failed to create transaction record")
+		}
+
+		// Tell downstream services, committed together with the withdrawal
+		if err := u.recordEvent(ctx, domain.WalletDebited, wallet.ID, domain.WalletActivity{
+			UserID:      user.ID,
+			WalletID:    wallet.ID,
+			Currency:    wallet.Currency,
+			Transaction: transaction,
+		}, user.ID); err != nil {
+			return err
+		}
+
+		return u.chargeFee(ctx, wallet, fee, transaction)
+	})
+	if err != nil {
+		return nil, err
 	}
-	
+
 	// Clear cache since balance changed
-	if u.redisClient != nil {
-		cacheKey := fmt.Sprintf("wallet:balance:%d", req.UserID)
-		u.redisClient.Del(ctx, cacheKey)
+	invalidateBalanceCache(ctx, u.redisClient, req.UserID)
+
+	return transaction, nil
+}
+
+// Transfer moves money between wallets. Wallets in different currencies are
+// only bridged when the request asks for a conversion.
+func (u *walletUsecase) Transfer(ctx context.Context, req domain.TransferRequest) (*domain.Transaction, error) {
+	if req.Amount <= 0 {
+		return nil, apperrors.ErrInvalidAmount
 	}
 
-	// Record the transaction
-	transaction := &domain.Transaction{
-		WalletID:      wallet.ID,
-		Type:          domain.Deposit,
-		Amount:        req.Amount,
-		BalanceBefore: balanceBefore,
-		BalanceAfter:  wallet.Balance,
-		Description:   req.Comment,
+	if req.SenderID == req.ReceiverID {
+		return nil, apperrors.ErrSenderReceiverSame
 	}
 
-	if err := u.transactionRepo.Create(ctx, transaction); err != nil {
-		return nil, apperrors.WrapError(err, "failed to create transaction record")
+	var transaction *domain.Transaction
+
+	// Both balance updates and the ledger row commit or roll back together
+	err := u.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		// Get sender
+		sender, err := u.userRepo.GetByID(ctx, req.SenderID)
+		if err != nil {
+			if errors.Is(err, apperrors.ErrResourceNotFound) {
+				return apperrors.ErrUserNotFound
+			}
+			return apperrors.WrapError(err, "failed to get sender")
+		}
+
+		// Get receiver
+		receiver, err := u.userRepo.GetByID(ctx, req.ReceiverID)
+		if err != nil {
+			if errors.Is(err, apperrors.ErrResourceNotFound) {
+				return apperrors.ErrUserNotFound
+			}
+			return apperrors.WrapError(err, "failed to get receiver")
+		}
+
+		// Pick the two wallets. The receiver's defaults to the sender's currency.
+		senderWallet, err := selectWallet(ctx, u.walletRepo, sender.ID, domain.WalletSelector{
+			WalletID: req.SenderWalletID,
+			Currency: req.Currency,
+		})
+		if err != nil {
+			return err
+		}
+		receiverSelector := domain.WalletSelector{WalletID: req.ReceiverWalletID, Currency: req.ReceiverCurrency}
+		if receiverSelector.WalletID == 0 && receiverSelector.Currency == "" {
+			receiverSelector.Currency = senderWallet.Currency
+		}
+		receiverWallet, err := selectWallet(ctx, u.walletRepo, receiver.ID, receiverSelector)
+		if err != nil {
+			return err
+		}
+
+		// Price the conversion when one was asked for
+		var quote *domain.FXQuote
+		if req.Convert || req.QuoteID != "" {
+			if quote, err = u.useQuote(ctx, sender.ID, req.QuoteID, senderWallet.Currency, receiverWallet.Currency); err != nil {
+				return err
+			}
+		} else if receiverWallet.Currency != senderWallet.Currency {
+			return fmt.Errorf("%w: cannot send %s to a %s wallet without convert or quote_id", apperrors.ErrCurrencyMismatch, senderWallet.Currency, receiverWallet.Currency)
+		}
+
+		// The sender pays the fee, in the sender's currency
+		fee, err := u.quoteFee(ctx, domain.Transfer, senderWallet.Currency, req.Amount)
+		if err != nil {
+			return err
+		}
+
+		outgoing, incoming, err := u.moveFunds(ctx, fundsMovement{
+			entryType:  domain.Transfer,
+			debitType:  domain.TransferOut,
+			creditType: domain.TransferIn,
+			from:       senderWallet,
+			to:         receiverWallet,
+			amount:     req.Amount,
+			comment:    req.Comment,
+			quote:      quote,
+			fee:        fee,
+			limitUser:  sender,
+		})
+		if err != nil {
+			return err
+		}
+		transaction = outgoing
+
+		completion := domain.TransferCompletion{
+			CorrelationID:    outgoing.CorrelationID,
+			SenderUserID:     sender.ID,
+			SenderWalletID:   outgoing.WalletID,
+			ReceiverUserID:   receiver.ID,
+			ReceiverWalletID: incoming.WalletID,
+			Amount:           req.Amount,
+			Currency:         senderWallet.Currency,
+			Description:      req.Comment,
+			Fee:              outgoing.Fee,
+		}
+		if quote != nil {
+			completion.ReceiverAmount = &incoming.Amount
+			completion.ReceiverCurrency = receiverWallet.Currency
+			completion.FXRate = &quote.Rate
+		}
+
+		// Tell both sides the transfer completed, committed together with it
+		return u.recordEvent(ctx, domain.TransferCompleted, outgoing.WalletID, completion, sender.ID, receiver.ID)
+	})
+	if err != nil {
+		return nil, err
 	}
 
+	// Clear both caches
+	invalidateBalanceCache(ctx, u.redisClient, req.SenderID, req.ReceiverID)
+
 	return transaction, nil
 }
 
-// Withdraw takes money from a user's wallet
-func (u *walletUsecase) Withdraw(ctx context.Context, req domain.WithdrawRequest) (*domain.Transaction, error) {
+// Convert moves money between two of a user's wallets in different currencies
+func (u *walletUsecase) Convert(ctx context.Context, req domain.ConvertRequest) (*domain.ConversionResponse, error) {
 	if req.Amount <= 0 {
 		return nil, apperrors.ErrInvalidAmount
 	}
 
-	// Find the user
-	user, err := u.userRepo.GetByID(ctx, req.UserID)
+	var response *domain.ConversionResponse
+
+	// Both balance updates, the ledger rows and the used quote commit or roll back together
+	err := u.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		user, err := u.userRepo.GetByID(ctx, req.UserID)
+		if err != nil {
+			if errors.Is(err, apperrors.ErrResourceNotFound) {
+				return apperrors.ErrUserNotFound
+			}
+			return apperrors.WrapError(err, "failed to get user")
+		}
+
+		from, err := selectWallet(ctx, u.walletRepo, user.ID, req.From)
+		if err != nil {
+			return err
+		}
+		to, err := selectWallet(ctx, u.walletRepo, user.ID, req.To)
+		if err != nil {
+			return err
+		}
+		if from.ID == to.ID {
+			return fmt.Errorf("%w: from and to must be different wallets", apperrors.ErrInvalidInput)
+		}
+
+		quote, err := u.useQuote(ctx, user.ID, req.QuoteID, from.Currency, to.Currency)
+		if err != nil {
+			return err
+		}
+
+		debit, credit, err := u.moveFunds(ctx, fundsMovement{
+			entryType:  domain.Conversion,
+			debitType:  domain.ConversionOut,
+			creditType: domain.ConversionIn,
+			from:       from,
+			to:         to,
+			amount:     req.Amount,
+			comment:    req.Comment,
+			quote:      quote,
+		})
+		if err != nil {
+			return err
+		}
+
+		response = &domain.ConversionResponse{
+			Debit:  debit,
+			Credit: credit,
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	invalidateBalanceCache(ctx, u.redisClient, req.UserID)
+
+	return response, nil
+}
+
+// fundsMovement describes money leaving one wallet for another. With a quote the
+// amount is converted into the receiving wallet's currency at the quoted rate.
+// With a fee, the sending wallet is charged it on top of the amount. With a
+// limitUser, the movement counts towards that user's limits on entryType.
+type fundsMovement struct {
+	entryType  domain.TransactionType
+	debitType  domain.TransactionType
+	creditType domain.TransactionType
+	from       *domain.Wallet
+	to         *domain.Wallet
+	amount     domain.Amount
+	comment    string
+	quote      *domain.FXQuote
+	fee        *domain.FeeQuote
+	limitUser  *domain.User
+}
+
+// moveFunds locks both wallets, moves the money, posts it to the ledger and
+// records a linked row and event for each side. It runs in the caller's unit of work.
+func (u *walletUsecase) moveFunds(ctx context.Context, m fundsMovement) (*domain.Transaction, *domain.Transaction, error) {
+	// Lock both wallets in wallet ID order so two opposite movements can't deadlock
+	wallets, err := u.lockWallets(ctx, m.from.ID, m.to.ID)
 	if err != nil {
-		if errors.Is(err, apperrors.ErrResourceNotFound) {
-			return nil, apperrors.ErrUserNotFound
+		return nil, nil, err
+	}
+
+	senderWallet := wallets[m.from.ID]
+	receiverWallet := wallets[m.to.ID]
+
+	// Check limits under the lock, so concurrent movements can't both squeeze under them
+	if m.limitUser != nil {
+		if err := u.checkLimits(ctx, m.limitUser, senderWallet, m.entryType, m.amount); err != nil {
+			return nil, nil, err
 		}
-		return nil, apperrors.WrapError(err, "failed to get user")
 	}
 
-	// Get their wallet
-	wallet, err := u.walletRepo.GetByUserID(ctx, user.ID)
+	received := m.amount
+	if m.quote != nil {
+		if received, err = m.quote.Rate.Convert(m.amount, receiverWallet.Currency); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	senderBalanceBefore := senderWallet.Balance
+	receiverBalanceBefore := receiverWallet.Balance
+
+	// Take from sender
+	if err := senderWallet.Withdraw(m.amount); err != nil {
+		if errors.Is(err, apperrors.ErrInsufficientFunds) {
+			return nil, nil, apperrors.ErrInsufficientFunds
+		}
+		return nil, nil, err
+	}
+
+	// Give to receiver
+	if err := receiverWallet.Deposit(received); err != nil {
+		// Should never happen since we've already validated the amount
+		return nil, nil, err
+	}
+
+	// Save sender's wallet
+	if err := u.walletRepo.Update(ctx, senderWallet); err != nil {
+		return nil, nil, apperrors.WrapError(err, "failed to update sender wallet")
+	}
+
+	// Save receiver's wallet - a failure here rolls back the sender's update too
+	if err := u.walletRepo.Update(ctx, receiverWallet); err != nil {
+		return nil, nil, apperrors.WrapError(err, "failed to update receiver wallet")
+	}
+
+	// Money moves between the two wallet accounts, through the fx accounts when converted
+	senderAccount, err := u.walletLedgerAccount(ctx, senderWallet.ID)
 	if err != nil {
-		if errors.Is(err, apperrors.ErrResourceNotFound) {
-			return nil, apperrors.ErrWalletNotFound
+		return nil, nil, err
+	}
+	receiverAccount, err := u.walletLedgerAccount(ctx, receiverWallet.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var entry *domain.JournalEntry
+	if m.quote == nil {
+		entry, err = u.postEntry(ctx, m.entryType, m.comment, senderAccount, receiverAccount, m.amount)
+	} else {
+		entry, err = u.postConversion(ctx, m.entryType, m.comment, senderAccount, receiverAccount, m.amount, received)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Record both sides, linked by a shared correlation ID
+	correlationID := uuid.New().String()
+
+	outgoing := &domain.Transaction{
+		WalletID:             senderWallet.ID,
+		DestWalletID:         &receiverWallet.ID,
+		Type:                 m.debitType,
+		Amount:               m.amount,
+		BalanceBefore:        senderBalanceBefore,
+		BalanceAfter:         senderWallet.Balance,
+		Description:          m.comment,
+		JournalEntryID:       &entry.ID,
+		CorrelationID:        correlationID,
+		CounterpartyWalletID: &receiverWallet.ID,
+		CounterpartyUserID:   &receiverWallet.UserID,
+	}
+
+	incoming := &domain.Transaction{
+		WalletID:             receiverWallet.ID,
+		Type:                 m.creditType,
+		Amount:               received,
+		BalanceBefore:        receiverBalanceBefore,
+		BalanceAfter:         receiverWallet.Balance,
+		Description:          m.comment,
+		JournalEntryID:       &entry.ID,
+		CorrelationID:        correlationID,
+		CounterpartyWalletID: &senderWallet.ID,
+		CounterpartyUserID:   &senderWallet.UserID,
+	}
+
+	applyFee(outgoing, m.fee)
+
+	// Both rows carry the rate and the amounts on either side
+	if m.quote != nil {
+		for _, tr := range []*domain.Transaction{outgoing, incoming} {
+			tr.FXQuoteID = m.quote.ID
+			tr.FXRate = &m.quote.Rate
+			tr.SourceAmount = &m.amount
+			tr.TargetAmount = &received
 		}
-		return nil, apperrors.WrapError(err, "failed to get wallet")
+	}
+
+	if err := u.transactionRepo.Create(ctx, outgoing); err != nil {
+		return nil, nil, apperrors.WrapError(err, "failed to create sender transaction record")
+	}
+
+	if err := u.transactionRepo.Create(ctx, incoming); err != nil {
+		return nil, nil, apperrors.WrapError(err, "failed to create receiver transaction record")
+	}
+
+	// Tell downstream services, committed together with the movement
+	if err := u.recordEvent(ctx, domain.WalletDebited, senderWallet.ID, domain.WalletActivity{
+		UserID:      senderWallet.UserID,
+		WalletID:    senderWallet.ID,
+		Currency:    senderWallet.Currency,
+		Transaction: outgoing,
+	}, senderWallet.UserID); err != nil {
+		return nil, nil, err
+	}
+	if err := u.recordEvent(ctx, domain.WalletCredited, receiverWallet.ID, domain.WalletActivity{
+		UserID:      receiverWallet.UserID,
+		WalletID:    receiverWallet.ID,
+		Currency:    receiverWallet.Currency,
+		Transaction: incoming,
+	}, receiverWallet.UserID); err != nil {
+		return nil, nil, err
+	}
+
+	if err := u.chargeFee(ctx, senderWallet, m.fee, outgoing); err != nil {
+		return nil, nil, err
+	}
+
+	return outgoing, incoming, nil
+}
+
+// checkLimits returns ErrLimitExceeded if the user may not make the transaction
+// from their locked wallet
+func (u *walletUsecase) checkLimits(
+	ctx context.Context,
+	user *domain.User,
+	wallet *domain.Wallet,
+	transactionType domain.TransactionType,
+	amount domain.Amount,
+) error {
+	if u.limitUsecase == nil {
+		return nil
+	}
+	return u.limitUsecase.Check(ctx, user, wallet, transactionType, amount)
+}
+
+// quoteFee prices the fee on a transaction, or returns nil when it is free
+func (u *walletUsecase) quoteFee(
+	ctx context.Context,
+	transactionType domain.TransactionType,
+	currency domain.Currency,
+	amount domain.Amount,
+) (*domain.FeeQuote, error) {
+	if u.feeUsecase == nil {
+		return nil, nil
+	}
+
+	fee, err := u.feeUsecase.Quote(ctx, domain.FeeQuoteRequest{
+		TransactionType: transactionType,
+		Currency:        currency,
+		Amount:          amount,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if fee.Fee == 0 {
+		return nil, nil
+	}
+	return fee, nil
+}
+
+// applyFee shows the fee a transaction is charged, and the schedule version it
+// came from, on the transaction's row
+func applyFee(transaction *domain.Transaction, fee *domain.FeeQuote) {
+	if fee == nil {
+		return
+	}
+	transaction.Fee = &fee.Fee
+	transaction.FeeVersion = fee.Version
+}
+
+// chargeFee takes the fee for parent out of its locked wallet and pays it into
+// the platform's fee wallet in the same currency. The payer gets a FEE row linked
+// to parent, and the fee wallet a FEE_COLLECTED row linked to that. It does
+// nothing without a fee and runs in the caller's unit of work.
+func (u *walletUsecase) chargeFee(ctx context.Context, wallet *domain.Wallet, fee *domain.FeeQuote, parent *domain.Transaction) error {
+	if fee == nil {
+		return nil
 	}
 
 	balanceBefore := wallet.Balance
 
-	// Take out the money
-	if err := wallet.Withdraw(req.Amount); err != nil {
+	if err := wallet.Withdraw(fee.Fee); err != nil {
 		if errors.Is(err, apperrors.ErrInsufficientFunds) {
-			return nil, apperrors.ErrInsufficientFunds
+			return fmt.Errorf("%w: the %s fee is not covered", apperrors.ErrInsufficientFunds, wallet.Currency.Format(fee.Fee))
 		}
-		return nil, err // Just pass through domain errors
+		return err
 	}
 
-	// Save the updated wallet
+	// Saved before the fee wallet is locked, which reloads the row if the
+	// platform is paying a fee itself
 	if err := u.walletRepo.Update(ctx, wallet); err != nil {
-		return nil, apperrors.WrapError(err, "failed to update wallet")
+		return apperrors.WrapError(err, "failed to update wallet")
 	}
-	
-	// Clear cache since balance changed
-	if u.redisClient != nil {
-		cacheKey := fmt.Sprintf("wallet:balance:%d", req.UserID)
-		u.redisClient.Del(ctx, cacheKey)
+
+	feeWallet, err := u.lockFeeWallet(ctx, wallet.Currency)
+	if err != nil {
+		return err
+	}
+	feeBalanceBefore := feeWallet.Balance
+	if err := feeWallet.Deposit(fee.Fee); err != nil {
+		return err
+	}
+	if err := u.walletRepo.Update(ctx, feeWallet); err != nil {
+		return apperrors.WrapError(err, "failed to update fee wallet")
 	}
 
-	// Record the transaction
-	transaction := &domain.Transaction{
-		WalletID:      wallet.ID,
-		Type:          domain.Withdrawal,
-		Amount:        req.Amount,
-		BalanceBefore: balanceBefore,
-		BalanceAfter:  wallet.Balance,
-		Description:   req.Comment,
+	walletAccount, err := u.walletLedgerAccount(ctx, wallet.ID)
+	if err != nil {
+		return err
+	}
+	feeAccount, err := u.walletLedgerAccount(ctx, feeWallet.ID)
+	if err != nil {
+		return err
 	}
 
-	if err := u.transactionRepo.Create(ctx, transaction); err != nil {
-		return nil, apperrors.WrapError(err, "failed to create transaction record")
+	description := fmt.Sprintf("Fee for transaction %d", parent.ID)
+	entry, err := u.postEntry(ctx, domain.Fee, description, walletAccount, feeAccount, fee.Fee)
+	if err != nil {
+		return err
 	}
 
-	return transaction, nil
+	charged := &domain.Transaction{
+		WalletID:             wallet.ID,
+		Type:                 domain.Fee,
+		Amount:               fee.Fee,
+		BalanceBefore:        balanceBefore,
+		BalanceAfter:         wallet.Balance,
+		Description:          description,
+		JournalEntryID:       &entry.ID,
+		FeeVersion:           fee.Version,
+		ParentTransactionID:  &parent.ID,
+		CounterpartyWalletID: &feeWallet.ID,
+		CounterpartyUserID:   &feeWallet.UserID,
+	}
+	if err := u.transactionRepo.Create(ctx, charged); err != nil {
+		return apperrors.WrapError(err, "failed to create fee transaction record")
+	}
+
+	collected := &domain.Transaction{
+		WalletID:             feeWallet.ID,
+		Type:                 domain.FeeCollected,
+		Amount:               fee.Fee,
+		BalanceBefore:        feeBalanceBefore,
+		BalanceAfter:         feeWallet.Balance,
+		Description:          description,
+		JournalEntryID:       &entry.ID,
+		FeeVersion:           fee.Version,
+		ParentTransactionID:  &charged.ID,
+		CounterpartyWalletID: &wallet.ID,
+		CounterpartyUserID:   &wallet.UserID,
+	}
+	if err := u.transactionRepo.Create(ctx, collected); err != nil {
+		return apperrors.WrapError(err, "failed to create fee transaction record")
+	}
+
+	if err := u.recordEvent(ctx, domain.WalletDebited, wallet.ID, domain.WalletActivity{
+		UserID:      wallet.UserID,
+		WalletID:    wallet.ID,
+		Currency:    wallet.Currency,
+		Transaction: charged,
+	}, wallet.UserID); err != nil {
+		return err
+	}
+	return u.recordEvent(ctx, domain.WalletCredited, feeWallet.ID, domain.WalletActivity{
+		UserID:      feeWallet.UserID,
+		WalletID:    feeWallet.ID,
+		Currency:    feeWallet.Currency,
+		Transaction: collected,
+	}, feeWallet.UserID)
 }
 
-// Transfer moves money between wallets
-func (u *walletUsecase) Transfer(ctx context.Context, req domain.TransferRequest) (*domain.Transaction, error) {
+// lockFeeWallet locks the platform's fee wallet in currency. Every fee charged in
+// that currency waits on this row, so it is locked as late as possible.
+func (u *walletUsecase) lockFeeWallet(ctx context.Context, currency domain.Currency) (*domain.Wallet, error) {
+	userID := u.feeUsecase.WalletUserID()
+	wallet, err := u.lockUserWallet(ctx, userID, domain.WalletSelector{Currency: currency})
+	if err != nil {
+		// The platform's setup is missing, not anything the caller asked for
+		return nil, fmt.Errorf("failed to get the %s fee wallet of user %d: %v", currency, userID, err)
+	}
+	return wallet, nil
+}
+
+// useQuote prices a conversion, failing when no rate provider is configured
+func (u *walletUsecase) useQuote(ctx context.Context, userID int64, quoteID string, from, to domain.Currency) (*domain.FXQuote, error) {
+	if u.fxUsecase == nil {
+		return nil, fmt.Errorf("%w: currency conversion is not configured", apperrors.ErrRateUnavailable)
+	}
+	if from == to {
+		return nil, fmt.Errorf("%w: both wallets hold %s, there is nothing to convert", apperrors.ErrCurrencyMismatch, from)
+	}
+	return u.fxUsecase.UseQuote(ctx, userID, quoteID, from, to)
+}
+
+// Reverse undoes whatever is left of a transaction. Transfers are undone on
+// both sides at once.
+func (u *walletUsecase) Reverse(ctx context.Context, req domain.ReversalRequest) (*domain.ReversalResponse, error) {
+	return u.compensate(ctx, domain.Reversal, req)
+}
+
+// Refund undoes part of a transaction. It can be repeated until the whole
+// amount has been refunded.
+func (u *walletUsecase) Refund(ctx context.Context, req domain.ReversalRequest) (*domain.ReversalResponse, error) {
 	if req.Amount <= 0 {
 		return nil, apperrors.ErrInvalidAmount
 	}
+	return u.compensate(ctx, domain.Refund, req)
+}
 
-	if req.SenderID == req.ReceiverID {
-		return nil, apperrors.ErrSenderReceiverSame
-	}
+// compensate moves money back for a reversal or refund and links the
+// compensating transactions to the ones they undo
+func (u *walletUsecase) compensate(ctx context.Context, kind domain.TransactionType, req domain.ReversalRequest) (*domain.ReversalResponse, error) {
+	var response *domain.ReversalResponse
+	var userIDs []int64
 
-	// Lock wallets if Redis is available to prevent concurrent transfers
-	if u.redisClient != nil {
-		// Lock in ID order to prevent deadlocks
-		var firstID, secondID int64
-		if req.SenderID < req.ReceiverID {
-			firstID, secondID = req.SenderID, req.ReceiverID
-		} else {
-			firstID, secondID = req.ReceiverID, req.SenderID
+	// Balance updates, ledger rows and the original's reversal status commit together
+	err := u.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		original, err := u.transactionRepo.GetByIDForUpdate(ctx, req.TransactionID)
+		if err != nil {
+			if errors.Is(err, apperrors.ErrResourceNotFound) {
+				return apperrors.ErrTransactionNotFound
+			}
+			return apperrors.WrapError(err, "failed to get transaction")
 		}
-		
-		// Set up lock keys
-		firstLockKey := fmt.Sprintf("lock:wallet:%d", firstID)
-		secondLockKey := fmt.Sprintf("lock:wallet:%d", secondID)
-		
-		// Get first lock
-		firstLock, err := u.redisClient.SetNX(ctx, firstLockKey, "1", 10*time.Second).Result()
-		if err != nil || !firstLock {
-			return nil, apperrors.ErrLockAcquisitionFailed
+
+		if !original.IsReversible() {
+			return fmt.Errorf("%w: %s transactions cannot be reversed", apperrors.ErrNotReversible, original.Type)
 		}
-		
-		defer u.redisClient.Del(ctx, firstLockKey)
-		
-		// Get second lock
-		secondLock, err := u.redisClient.SetNX(ctx, secondLockKey, "1", 10*time.Second).Result()
-		if err != nil || !secondLock {
-			return nil, apperrors.ErrLockAcquisitionFailed
+
+		// Both sides of a transfer are undone together
+		legs := []*domain.Transaction{original}
+		if original.CorrelationID != "" {
+			legs, err = u.transactionRepo.GetByCorrelationIDForUpdate(ctx, original.CorrelationID)
+			if err != nil {
+				return apperrors.WrapError(err, "failed to get transfer transactions")
+			}
+			if len(legs) != 2 {
+				return fmt.Errorf("%w: transfer %s is not recorded on both sides", apperrors.ErrNotReversible, original.CorrelationID)
+			}
+			for _, leg := range legs {
+				if leg.ID == original.ID {
+					original = leg
+				}
+			}
 		}
-		
-		defer u.redisClient.Del(ctx, secondLockKey)
+
+		amount := req.Amount
+		if kind == domain.Reversal {
+			amount = original.Unreversed()
+			if amount == 0 {
+				return fmt.Errorf("%w: transaction %d has already been undone", apperrors.ErrNotReversible, original.ID)
+			}
+		}
+
+		for _, leg := range legs {
+			if err := leg.ApplyReversal(kind, amount); err != nil {
+				return err
+			}
+		}
+
+		walletIDs := make([]int64, 0, len(legs))
+		for _, leg := range legs {
+			walletIDs = append(walletIDs, leg.WalletID)
+		}
+		wallets, err := u.lockWallets(ctx, walletIDs...)
+		if err != nil {
+			return err
+		}
+
+		// Move the money back: whatever the original credited is debited
+		var from, to *domain.LedgerAccount
+		balancesBefore := make(map[int64]domain.Amount, len(legs))
+		for _, leg := range legs {
+			wallet := wallets[leg.WalletID]
+			balancesBefore[leg.ID] = wallet.Balance
+
+			account, err := u.walletLedgerAccount(ctx, wallet.ID)
+			if err != nil {
+				return err
+			}
+
+			if leg.IsCredit() {
+				if req.AllowNegative {
+					err = wallet.Overdraw(amount)
+				} else {
+					err = wallet.Withdraw(amount)
+				}
+				from = account
+			} else {
+				err = wallet.Deposit(amount)
+				to = account
+			}
+			if err != nil {
+				return err
+			}
+
+			if err := u.walletRepo.Update(ctx, wallet); err != nil {
+				return apperrors.WrapError(err, "failed to update wallet")
+			}
+		}
+
+		// Deposits and withdrawals settle against the same platform account they came from
+		currency := wallets[original.WalletID].Currency
+		if from == nil {
+			if from, err = u.systemLedgerAccount(ctx, domain.CashOutAccount, currency); err != nil {
+				return err
+			}
+		}
+		if to == nil {
+			if to, err = u.systemLedgerAccount(ctx, domain.CashInAccount, currency); err != nil {
+				return err
+			}
+		}
+
+		description := req.Comment
+		if description == "" {
+			description = fmt.Sprintf("%s of transaction %d", kindLabel(kind), original.ID)
+		}
+
+		entry, err := u.postEntry(ctx, kind, description, from, to, amount)
+		if err != nil {
+			return err
+		}
+
+		// Compensating rows of a transfer are linked like the transfer itself
+		correlationID := ""
+		if len(legs) > 1 {
+			correlationID = uuid.New().String()
+		}
+
+		response = &domain.ReversalResponse{
+			Original:     original,
+			Transactions: make([]*domain.Transaction, 0, len(legs)),
+		}
+
+		for _, leg := range legs {
+			wallet := wallets[leg.WalletID]
+
+			transaction := &domain.Transaction{
+				WalletID:              wallet.ID,
+				Type:                  kind,
+				Amount:                amount,
+				BalanceBefore:         balancesBefore[leg.ID],
+				BalanceAfter:          wallet.Balance,
+				Description:           description,
+				JournalEntryID:        &entry.ID,
+				CorrelationID:         correlationID,
+				CounterpartyWalletID:  leg.CounterpartyWalletID,
+				CounterpartyUserID:    leg.CounterpartyUserID,
+				OriginalTransactionID: &leg.ID,
+			}
+
+			if err := u.transactionRepo.Create(ctx, transaction); err != nil {
+				return apperrors.WrapError(err, "failed to create transaction record")
+			}
+
+			if err := u.transactionRepo.UpdateReversal(ctx, leg); err != nil {
+				return apperrors.WrapError(err, "failed to update original transaction")
+			}
+
+			eventType := domain.WalletCredited
+			if leg.IsCredit() {
+				eventType = domain.WalletDebited
+			}
+			if err := u.recordEvent(ctx, eventType, wallet.ID, domain.WalletActivity{
+				UserID:      wallet.UserID,
+				WalletID:    wallet.ID,
+				Currency:    wallet.Currency,
+				Transaction: transaction,
+			}, wallet.UserID); err != nil {
+				return err
+			}
+
+			response.Transactions = append(response.Transactions, transaction)
+			userIDs = append(userIDs, wallet.UserID)
+		}
+
+		// Once the charged transaction is wholly undone, its fee goes back too
+		for _, leg := range legs {
+			if leg.Fee == nil || leg.Unreversed() > 0 {
+				continue
+			}
+
+			transaction, err := u.refundFee(ctx, kind, leg, wallets[leg.WalletID])
+			if err != nil {
+				return err
+			}
+			if transaction != nil {
+				response.Transactions = append(response.Transactions, transaction)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// Get sender
-	sender, err := u.userRepo.GetByID(ctx, req.SenderID)
+	// Clear cache since balances changed
+	invalidateBalanceCache(ctx, u.redisClient, userIDs...)
+
+	return response, nil
+}
+
+// refundFee moves the fee charged for parent out of the platform's fee wallet and
+// back to the locked wallet that paid it. Each side gets a compensating row of
+// type kind linked to its own fee row. It returns the payer's row, or nil if
+// the fee has already been given back.
+func (u *walletUsecase) refundFee(
+	ctx context.Context,
+	kind domain.TransactionType,
+	parent *domain.Transaction,
+	wallet *domain.Wallet,
+) (*domain.Transaction, error) {
+	charged, err := u.getChildForUpdate(ctx, parent.ID, domain.Fee)
 	if err != nil {
-		if errors.Is(err, apperrors.ErrResourceNotFound) {
-			return nil, apperrors.ErrUserNotFound
-		}
-		return nil, apperrors.WrapError(err, "failed to get sender")
+		return nil, err
+	}
+
+	amount := charged.Unreversed()
+	if amount == 0 {
+		return nil, nil
 	}
 
-	// Get receiver
-	receiver, err := u.userRepo.GetByID(ctx, req.ReceiverID)
+	collected, err := u.getChildForUpdate(ctx, charged.ID, domain.FeeCollected)
 	if err != nil {
-		if errors.Is(err, apperrors.ErrResourceNotFound) {
-			return nil, apperrors.ErrUserNotFound
+		return nil, err
+	}
+	if err := charged.ApplyReversal(kind, amount); err != nil {
+		return nil, err
+	}
+	if err := collected.ApplyReversal(kind, amount); err != nil {
+		return nil, err
+	}
+
+	balanceBefore := wallet.Balance
+	if err := wallet.Deposit(amount); err != nil {
+		return nil, err
+	}
+	// Saved before the fee wallet is locked, as in chargeFee
+	if err := u.walletRepo.Update(ctx, wallet); err != nil {
+		return nil, apperrors.WrapError(err, "failed to update wallet")
+	}
+
+	feeWallet, err := u.lockFeeWallet(ctx, wallet.Currency)
+	if err != nil {
+		return nil, err
+	}
+	feeBalanceBefore := feeWallet.Balance
+	if err := feeWallet.Withdraw(amount); err != nil {
+		if errors.Is(err, apperrors.ErrInsufficientFunds) {
+			return nil, fmt.Errorf("%w: the fee wallet can't give back the %s fee", apperrors.ErrInsufficientFunds, wallet.Currency.Format(amount))
 		}
-		return nil, apperrors.WrapError(err, "failed to get receiver")
+		return nil, err
+	}
+	if err := u.walletRepo.Update(ctx, feeWallet); err != nil {
+		return nil, apperrors.WrapError(err, "failed to update fee wallet")
 	}
 
-	// Get both wallets
-	senderWallet, err := u.walletRepo.GetByUserID(ctx, sender.ID)
+	walletAccount, err := u.walletLedgerAccount(ctx, wallet.ID)
 	if err != nil {
-		if errors.Is(err, apperrors.ErrResourceNotFound) {
-			return nil, apperrors.ErrWalletNotFound
+		return nil, err
+	}
+	feeAccount, err := u.walletLedgerAccount(ctx, feeWallet.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	description := fmt.Sprintf("%s of the fee for transaction %d", kindLabel(kind), parent.ID)
+	entry, err := u.postEntry(ctx, kind, description, feeAccount, walletAccount, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	refunded := &domain.Transaction{
+		WalletID:              wallet.ID,
+		Type:                  kind,
+		Amount:                amount,
+		BalanceBefore:         balanceBefore,
+		BalanceAfter:          wallet.Balance,
+		Description:           description,
+		JournalEntryID:        &entry.ID,
+		CounterpartyWalletID:  &feeWallet.ID,
+		CounterpartyUserID:    &feeWallet.UserID,
+		OriginalTransactionID: &charged.ID,
+	}
+	returned := &domain.Transaction{
+		WalletID:              feeWallet.ID,
+		Type:                  kind,
+		Amount:                amount,
+		BalanceBefore:         feeBalanceBefore,
+		BalanceAfter:          feeWallet.Balance,
+		Description:           description,
+		JournalEntryID:        &entry.ID,
+		CounterpartyWalletID:  &wallet.ID,
+		CounterpartyUserID:    &wallet.UserID,
+		OriginalTransactionID: &collected.ID,
+	}
+
+	for _, row := range []struct {
+		transaction *domain.Transaction
+		original    *domain.Transaction
+		wallet      *domain.Wallet
+		eventType   domain.EventType
+	}{
+		{refunded, charged, wallet, domain.WalletCredited},
+		{returned, collected, feeWallet, domain.WalletDebited},
+	} {
+		if err := u.transactionRepo.Create(ctx, row.transaction); err != nil {
+			return nil, apperrors.WrapError(err, "failed to create transaction record")
+		}
+		if err := u.transactionRepo.UpdateReversal(ctx, row.original); err != nil {
+			return nil, apperrors.WrapError(err, "failed to update fee transaction")
+		}
+		if err := u.recordEvent(ctx, row.eventType, row.wallet.ID, domain.WalletActivity{
+			UserID:      row.wallet.UserID,
+			WalletID:    row.wallet.ID,
+			Currency:    row.wallet.Currency,
+			Transaction: row.transaction,
+		}, row.wallet.UserID); err != nil {
+			return nil, err
 		}
-		return nil, apperrors.WrapError(err, "failed to get sender wallet")
 	}
 
-	receiverWallet, err := u.walletRepo.GetByUserID(ctx, receiver.ID)
+	return refunded, nil
+}
+
+// getChildForUpdate loads and locks the row of transactionType linked to parentID
+func (u *walletUsecase) getChildForUpdate(ctx context.Context, parentID int64, transactionType domain.TransactionType) (*domain.Transaction, error) {
+	child, err := u.transactionRepo.GetChildForUpdate(ctx, parentID, transactionType)
 	if err != nil {
 		if errors.Is(err, apperrors.ErrResourceNotFound) {
-			return nil, apperrors.ErrWalletNotFound
+			return nil, fmt.Errorf("%w: the %s row for transaction %d is not recorded", apperrors.ErrNotReversible, transactionType, parentID)
 		}
-		return nil, apperrors.WrapError(err, "failed to get receiver wallet")
+		return nil, apperrors.WrapError(err, "failed to get fee transaction")
 	}
+	return child, nil
+}
 
-	senderBalanceBefore := senderWallet.Balance
+// kindLabel names a compensating transaction type in descriptions
+func kindLabel(kind domain.TransactionType) string {
+	if kind == domain.Refund {
+		return "Refund"
+	}
+	return "Reversal"
+}
 
-	// Take from sender
-	if err := senderWallet.Withdraw(req.Amount); err != nil {
-		if errors.Is(err, apperrors.ErrInsufficientFunds) {
-			return nil, apperrors.ErrInsufficientFunds
+// lockWallets locks the given wallets in ascending ID order, so units of work
+// locking the same wallets can't deadlock. The result is keyed by wallet ID.
+func (u *walletUsecase) lockWallets(ctx context.Context, walletIDs ...int64) (map[int64]*domain.Wallet, error) {
+	lockOrder := slices.Clone(walletIDs)
+	slices.Sort(lockOrder)
+	lockOrder = slices.Compact(lockOrder)
+
+	wallets := make(map[int64]*domain.Wallet, len(lockOrder))
+	for _, walletID := range lockOrder {
+		wallet, err := u.walletRepo.GetByIDForUpdate(ctx, walletID)
+		if err != nil {
+			if errors.Is(err, apperrors.ErrResourceNotFound) {
+				return nil, apperrors.ErrWalletNotFound
+			}
+			return nil, apperrors.WrapError(err, "failed to get wallet")
 		}
+		wallets[wallet.ID] = wallet
+	}
+
+	return wallets, nil
+}
+
+// lockUserWallet locks the user's wallet the selector points at
+func (u *walletUsecase) lockUserWallet(ctx context.Context, userID int64, selector domain.WalletSelector) (*domain.Wallet, error) {
+	wallet, err := selectWallet(ctx, u.walletRepo, userID, selector)
+	if err != nil {
 		return nil, err
 	}
 
-	// Give to receiver
-	if err := receiverWallet.Deposit(req.Amount); err != nil {
-		// Should never happen since we've already validated the amount
+	wallets, err := u.lockWallets(ctx, wallet.ID)
+	if err != nil {
 		return nil, err
 	}
+	return wallets[wallet.ID], nil
+}
 
-	// Save sender's wallet
-	if err := u.walletRepo.Update(ctx, senderWallet); err != nil {
-		return nil, apperrors.WrapError(err, "failed to update sender wallet")
+// selectWallet returns the user's wallet the selector points at, without locking it
+func selectWallet(ctx context.Context, walletRepo domain.WalletRepository, userID int64, selector domain.WalletSelector) (*domain.Wallet, error) {
+	wallets, err := walletRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		return nil, apperrors.WrapError(err, "failed to get wallets")
 	}
+	return selector.Select(wallets)
+}
 
-	// Save receiver's wallet
-	if err := u.walletRepo.Update(ctx, receiverWallet); err != nil {
-		// This is bad - we already took money from sender but couldn't give to receiver
-		// In a real app, we'd need transactions or a way to roll back
-		return nil, apperrors.WrapError(err, "failed to update receiver wallet")
+// walletLedgerAccount returns the ledger account behind a wallet
+func (u *walletUsecase) walletLedgerAccount(ctx context.Context, walletID int64) (*domain.LedgerAccount, error) {
+	account, err := u.ledgerRepo.GetWalletAccount(ctx, walletID)
+	if err != nil {
+		return nil, apperrors.WrapError(err, "failed to get wallet ledger account")
 	}
+	return account, nil
+}
 
-	// Clear both caches
-	if u.redisClient != nil {
-		senderCacheKey := fmt.Sprintf("wallet:balance:%d", req.SenderID)
-		receiverCacheKey := fmt.Sprintf("wallet:balance:%d", req.ReceiverID)
-		u.redisClient.Del(ctx, senderCacheKey, receiverCacheKey)
+// systemLedgerAccount returns one of the platform's own ledger accounts
+func (u *walletUsecase) systemLedgerAccount(ctx context.Context, code string, currency domain.Currency) (*domain.LedgerAccount, error) {
+	account, err := u.ledgerRepo.GetSystemAccount(ctx, code, currency)
+	if err != nil {
+		return nil, apperrors.WrapError(err, "failed to get system ledger account")
 	}
+	return account, nil
+}
 
-	// Record the transaction
-	transaction := &domain.Transaction{
-		WalletID:      senderWallet.ID,
-		DestWalletID:  &receiverWallet.ID,
-		Type:          domain.Transfer,
-		Amount:        req.Amount,
-		BalanceBefore: senderBalanceBefore,
-		BalanceAfter:  senderWallet.Balance,
-		Description:   req.Comment,
+// recordEvent adds a domain event to the outbox in the current unit of work and
+// queues it for the webhooks of the given users
+func (u *walletUsecase) recordEvent(ctx context.Context, eventType domain.EventType, walletID int64, payload any, notifyUserIDs ...int64) error {
+	event, err := domain.NewWalletEvent(eventType, walletID, payload)
+	if err != nil {
+		return err
+	}
+	if err := u.outboxRepo.Create(ctx, event); err != nil {
+		return apperrors.WrapError(err, "failed to record event")
 	}
+	if err := u.webhookRepo.EnqueueDeliveries(ctx, event, notifyUserIDs); err != nil {
+		return apperrors.WrapError(err, "failed to queue webhooks")
+	}
+	return nil
+}
 
-	if err := u.transactionRepo.Create(ctx, transaction); err != nil {
-		return nil, apperrors.WrapError(err, "failed to create transaction record")
+// postEntry writes a balanced journal entry moving amount between two accounts
+func (u *walletUsecase) postEntry(
+	ctx context.Context,
+	entryType domain.TransactionType,
+	description string,
+	from, to *domain.LedgerAccount,
+	amount domain.Amount,
+) (*domain.JournalEntry, error) {
+	entry := domain.NewJournalEntry(entryType, description, from, to, amount)
+	if err := u.ledgerRepo.CreateEntry(ctx, entry); err != nil {
+		return nil, apperrors.WrapError(err, "failed to post journal entry")
 	}
+	return entry, nil
+}
 
-	return transaction, nil
+// postConversion writes a balanced journal entry converting sourceAmount out of
+// one account into targetAmount in another, through the platform's fx accounts
+func (u *walletUsecase) postConversion(
+	ctx context.Context,
+	entryType domain.TransactionType,
+	description string,
+	from, to *domain.LedgerAccount,
+	sourceAmount, targetAmount domain.Amount,
+) (*domain.JournalEntry, error) {
+	fxSource, err := u.systemLedgerAccount(ctx, domain.FXAccount, from.Currency)
+	if err != nil {
+		return nil, err
+	}
+	fxTarget, err := u.systemLedgerAccount(ctx, domain.FXAccount, to.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := domain.NewConversionEntry(entryType, description, from, fxSource, fxTarget, to, sourceAmount, targetAmount)
+	if err := u.ledgerRepo.CreateEntry(ctx, entry); err != nil {
+		return nil, apperrors.WrapError(err, "failed to post journal entry")
+	}
+	return entry, nil
+}
+
+// pageTransactions trims rows fetched with one extra past the limit to the page
+// and works out the cursors either side of it. A prev cursor's extra row is the
+// first one, a next cursor's or an offset's the last.
+func pageTransactions(
+	rows []*domain.Transaction,
+	pagination domain.PaginationRequest,
+	cursor *domain.TransactionCursor,
+) (page []*domain.Transaction, next, prev string) {
+	backward := cursor != nil && cursor.Direction == domain.CursorPrev
+
+	hasMore := len(rows) > pagination.Limit
+	page = rows
+	if hasMore {
+		if backward {
+			page = rows[len(rows)-pagination.Limit:]
+		} else {
+			page = rows[:pagination.Limit]
+		}
+	}
+	if len(page) == 0 {
+		return page, "", ""
+	}
+
+	// Paging one way means there is a page the other way, where we came from
+	hasOlder := hasMore || backward
+	hasNewer := (hasMore && backward) || (!backward && (cursor != nil || pagination.Offset > 0))
+
+	if hasOlder {
+		next = domain.NewTransactionCursor(page[len(page)-1], domain.CursorNext).Encode()
+	}
+	if hasNewer {
+		prev = domain.NewTransactionCursor(page[0], domain.CursorPrev).Encode()
+	}
+	return page, next, prev
+}
+
+// invalidateBalanceCache drops cached balances once a change has committed
+func invalidateBalanceCache(ctx context.Context, redisClient *redis.Client, userIDs ...int64) {
+	if redisClient == nil {
+		return
+	}
+
+	cacheKeys := make([]string, 0, len(userIDs))
+	for _, userID := range userIDs {
+		cacheKeys = append(cacheKeys, fmt.Sprintf("wallet:balance:%d", userID))
+	}
+	redisClient.Del(ctx, cacheKeys...)
+}
+
+// balanceCacheField names a selector's entry in the user's balance cache hash
+func balanceCacheField(selector domain.WalletSelector) string {
+	return fmt.Sprintf("%d:%s", selector.WalletID, strings.ToUpper(string(selector.Currency)))
 }
 
-// GetBalance returns a user's current wallet balance
-func (u *walletUsecase) GetBalance(ctx context.Context, userID int64) (*domain.Wallet, error) {
-	// Try cache first
+// GetBalance returns the balance of the user's wallet the selector points at
+func (u *walletUsecase) GetBalance(ctx context.Context, userID int64, selector domain.WalletSelector) (*domain.Wallet, error) {
+	// Try cache first. Each user has one hash, so a balance change drops all their wallets at once.
+	cacheKey := fmt.Sprintf("wallet:balance:%d", userID)
 	if u.redisClient != nil {
-		cacheKey := fmt.Sprintf("wallet:balance:%d", userID)
-		cachedData, err := u.redisClient.Get(ctx, cacheKey).Bytes()
+		cachedData, err := u.redisClient.HGet(ctx, cacheKey, balanceCacheField(selector)).Bytes()
 		
 		if err == nil {
 			var wallet domain.Wallet
@@ -313,32 +1337,37 @@ func (u *walletUsecase) GetBalance(ctx context.Context, userID int64) (*domain.W
 		return nil, apperrors.WrapError(err, "failed to get user")
 	}
 
-	wallet, err := u.walletRepo.GetByUserID(ctx, user.ID)
+	wallet, err := selectWallet(ctx, u.walletRepo, user.ID, selector)
 	if err != nil {
-		if errors.Is(err, apperrors.ErrResourceNotFound) {
-			return nil, apperrors.ErrWalletNotFound
-		}
-		return nil, apperrors.WrapError(err, "failed to get wallet")
+		return nil, err
 	}
 	
 	// Cache the result
 	if u.redisClient != nil {
-		cacheKey := fmt.Sprintf("wallet:balance:%d", userID)
 		if walletData, err := json.Marshal(wallet); err == nil {
 			// Cache for a short time since balance changes frequently
-			u.redisClient.Set(ctx, cacheKey, walletData, balanceCacheTTL)
+			pipe := u.redisClient.TxPipeline()
+			pipe.HSet(ctx, cacheKey, balanceCacheField(selector), walletData)
+			pipe.Expire(ctx, cacheKey, balanceCacheTTL)
+			pipe.Exec(ctx)
 		}
 	}
 
 	return wallet, nil
 }
 
-// GetTransactionHistory returns a user's past transactions
+// GetTransactionHistory returns a user's past transactions matching filter
 func (u *walletUsecase) GetTransactionHistory(
 	ctx context.Context,
 	userID int64,
+	selector domain.WalletSelector,
+	filter domain.TransactionFilter,
 	pagination domain.PaginationRequest,
 ) (*domain.TransactionHistoryResponse, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
 	// Set defaults for pagination
 	if pagination.Limit <= 0 {
 		pagination.Limit = 10
@@ -356,34 +1385,47 @@ func (u *walletUsecase) GetTransactionHistory(
 		return nil, apperrors.WrapError(err, "failed to get user")
 	}
 
-	// Get their wallet
-	wallet, err := u.walletRepo.GetByUserID(ctx, user.ID)
+	// Get the wallet the request names
+	wallet, err := selectWallet(ctx, u.walletRepo, user.ID, selector)
 	if err != nil {
-		if errors.Is(err, apperrors.ErrResourceNotFound) {
-			return nil, apperrors.ErrWalletNotFound
-		}
-		return nil, apperrors.WrapError(err, "failed to get wallet")
+		return nil, err
 	}
 
-	// Get their transactions
-	transactions, err := u.transactionRepo.GetByWalletID(
-		ctx, wallet.ID, pagination.Limit, pagination.Offset,
-	)
-	if err != nil {
-		return nil, apperrors.WrapError(err, "failed to get transactions")
-	}
+	// Fetch one row past the page to learn whether there is more beyond it
+	var transactions []*domain.Transaction
+	var cursor *domain.TransactionCursor
+	if pagination.Cursor != "" {
+		decoded, err := domain.DecodeTransactionCursor(pagination.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		cursor = &decoded
+		pagination.Offset = 0
 
-	// Get total count for pagination info
-	total, err := u.transactionRepo.CountByWalletID(ctx, wallet.ID)
-	if err != nil {
-		return nil, apperrors.WrapError(err, "failed to count transactions")
+		transactions, err = u.transactionRepo.GetPageByWalletID(ctx, wallet.ID, filter, decoded, pagination.Limit+1)
+		if err != nil {
+			return nil, apperrors.WrapError(err, "failed to get transactions")
+		}
+	} else {
+		transactions, err = u.transactionRepo.GetByWalletID(ctx, wallet.ID, filter, pagination.Limit+1, pagination.Offset)
+		if err != nil {
+			return nil, apperrors.WrapError(err, "failed to get transactions")
+		}
 	}
 
 	response := &domain.TransactionHistoryResponse{
-		Transactions: transactions,
-		Total:        total,
-		Limit:        pagination.Limit,
-		Offset:       pagination.Offset,
+		Limit:  pagination.Limit,
+		Offset: pagination.Offset,
+	}
+	response.Transactions, response.NextCursor, response.PrevCursor = pageTransactions(transactions, pagination, cursor)
+
+	// Counting walks the whole history, so it's only done on request
+	if pagination.IncludeTotal {
+		total, err := u.transactionRepo.CountByWalletID(ctx, wallet.ID, filter)
+		if err != nil {
+			return nil, apperrors.WrapError(err, "failed to count transactions")
+		}
+		response.Total = &total
 	}
 
 	return response, nil
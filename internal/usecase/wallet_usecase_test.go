@@ -7,6 +7,7 @@ import (
 
 	"github.com/ravindu/wallet-app-service/internal/domain"
 	"github.com/ravindu/wallet-app-service/internal/usecase"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -29,6 +30,18 @@ func (m *mockUserRepository) GetByID(ctx context.Context, id int64) (*domain.Use
 	return args.Get(0).(*domain.User), args.Error(1)
 }
 
+func (m *mockUserRepository) GetByIDForUpdate(ctx context.Context, id int64) (*domain.User, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.User), args.Error(1)
+}
+
+func (m *mockUserRepository) Update(ctx context.Context, user *domain.User) error {
+	args := m.Called(ctx, user)
+	return args.Error(0)
+}
 
 type mockWalletRepository struct {
 	mock.Mock
@@ -39,14 +52,30 @@ func (m *mockWalletRepository) Create(ctx context.Context, wallet *domain.Wallet
 	return args.Error(0)
 }
 
-func (m *mockWalletRepository) GetByUserID(ctx context.Context, userID int64) (*domain.Wallet, error) {
-	args := m.Called(ctx, userID)
+func (m *mockWalletRepository) GetByID(ctx context.Context, id int64) (*domain.Wallet, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Wallet), args.Error(1)
+}
+
+func (m *mockWalletRepository) GetByIDForUpdate(ctx context.Context, id int64) (*domain.Wallet, error) {
+	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*domain.Wallet), args.Error(1)
 }
 
+func (m *mockWalletRepository) ListByUserID(ctx context.Context, userID int64) ([]*domain.Wallet, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Wallet), args.Error(1)
+}
+
 func (m *mockWalletRepository) Update(ctx context.Context, wallet *domain.Wallet) error {
 	args := m.Called(ctx, wallet)
 	return args.Error(0)
@@ -61,19 +90,298 @@ func (m *mockTransactionRepository) Create(ctx context.Context, transaction *dom
 	return args.Error(0)
 }
 
-func (m *mockTransactionRepository) GetByWalletID(ctx context.Context, walletID int64, limit, offset int) ([]*domain.Transaction, error) {
-	args := m.Called(ctx, walletID, limit, offset)
+func (m *mockTransactionRepository) GetByWalletID(ctx context.Context, walletID int64, filter domain.TransactionFilter, limit, offset int) ([]*domain.Transaction, error) {
+	args := m.Called(ctx, walletID, filter, limit, offset)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).([]*domain.Transaction), args.Error(1)
 }
 
-func (m *mockTransactionRepository) CountByWalletID(ctx context.Context, walletID int64) (int, error) {
+func (m *mockTransactionRepository) GetPageByWalletID(ctx context.Context, walletID int64, filter domain.TransactionFilter, cursor domain.TransactionCursor, limit int) ([]*domain.Transaction, error) {
+	args := m.Called(ctx, walletID, filter, cursor, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Transaction), args.Error(1)
+}
+
+func (m *mockTransactionRepository) CountByWalletID(ctx context.Context, walletID int64, filter domain.TransactionFilter) (int, error) {
+	args := m.Called(ctx, walletID, filter)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *mockTransactionRepository) StreamByWalletID(ctx context.Context, walletID int64, filter domain.TransactionFilter, fn func(*domain.Transaction) error) error {
+	args := m.Called(ctx, walletID, filter)
+	if transactions, ok := args.Get(0).([]*domain.Transaction); ok {
+		for _, tr := range transactions {
+			if err := fn(tr); err != nil {
+				return err
+			}
+		}
+	}
+	return args.Error(1)
+}
+
+func (m *mockTransactionRepository) GetLastBetween(ctx context.Context, walletID int64, from, to time.Time) (*domain.Transaction, error) {
+	args := m.Called(ctx, walletID, from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Transaction), args.Error(1)
+}
+
+func (m *mockTransactionRepository) GetFirstFrom(ctx context.Context, walletID int64, at time.Time) (*domain.Transaction, error) {
+	args := m.Called(ctx, walletID, at)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Transaction), args.Error(1)
+}
+
+func (m *mockTransactionRepository) GetByIDForUpdate(ctx context.Context, id int64) (*domain.Transaction, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Transaction), args.Error(1)
+}
+
+func (m *mockTransactionRepository) GetByCorrelationIDForUpdate(ctx context.Context, correlationID string) ([]*domain.Transaction, error) {
+	args := m.Called(ctx, correlationID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Transaction), args.Error(1)
+}
+
+func (m *mockTransactionRepository) GetChildForUpdate(ctx context.Context, parentID int64, transactionType domain.TransactionType) (*domain.Transaction, error) {
+	args := m.Called(ctx, parentID, transactionType)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Transaction), args.Error(1)
+}
+
+func (m *mockTransactionRepository) UpdateReversal(ctx context.Context, transaction *domain.Transaction) error {
+	args := m.Called(ctx, transaction)
+	return args.Error(0)
+}
+
+func (m *mockTransactionRepository) GetUsageSince(ctx context.Context, walletID int64, types []domain.TransactionType, since time.Time) (domain.LimitUsage, error) {
+	args := m.Called(ctx, walletID, types, since)
+	return args.Get(0).(domain.LimitUsage), args.Error(1)
+}
+
+type mockLedgerRepository struct {
+	mock.Mock
+}
+
+func (m *mockLedgerRepository) CreateAccount(ctx context.Context, account *domain.LedgerAccount) error {
+	args := m.Called(ctx, account)
+	return args.Error(0)
+}
+
+func (m *mockLedgerRepository) GetWalletAccount(ctx context.Context, walletID int64) (*domain.LedgerAccount, error) {
 	args := m.Called(ctx, walletID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.LedgerAccount), args.Error(1)
+}
+
+func (m *mockLedgerRepository) GetSystemAccount(ctx context.Context, code string, currency domain.Currency) (*domain.LedgerAccount, error) {
+	args := m.Called(ctx, code, currency)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.LedgerAccount), args.Error(1)
+}
+
+func (m *mockLedgerRepository) CreateEntry(ctx context.Context, entry *domain.JournalEntry) error {
+	args := m.Called(ctx, entry)
+	return args.Error(0)
+}
+
+func (m *mockLedgerRepository) GetAccountBalances(ctx context.Context) ([]*domain.AccountBalance, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.AccountBalance), args.Error(1)
+}
+
+func (m *mockLedgerRepository) GetAccountBalance(ctx context.Context, accountID int64) (domain.Amount, error) {
+	args := m.Called(ctx, accountID)
+	return args.Get(0).(domain.Amount), args.Error(1)
+}
+
+// newMockLedgerRepository returns a ledger mock with an account for each wallet ID
+// and every system account, accepting any balanced entry
+func newMockLedgerRepository(walletIDs ...int64) *mockLedgerRepository {
+	ledgerRepo := new(mockLedgerRepository)
+	for _, walletID := range walletIDs {
+		id := walletID
+		ledgerRepo.On("GetWalletAccount", mock.Anything, id).Return(&domain.LedgerAccount{
+			ID:       100 + id,
+			Code:     domain.WalletAccountCode(id),
+			Type:     domain.WalletAccountType,
+			WalletID: &id,
+			Currency: domain.USD,
+		}, nil).Maybe()
+	}
+	for i, code := range []string{domain.CashInAccount, domain.CashOutAccount, domain.FeesAccount} {
+		ledgerRepo.On("GetSystemAccount", mock.Anything, code, domain.USD).Return(&domain.LedgerAccount{
+			ID:       int64(i + 1),
+			Code:     code,
+			Type:     domain.SystemAccountType,
+			Currency: domain.USD,
+		}, nil).Maybe()
+	}
+	ledgerRepo.On("CreateEntry", mock.Anything, mock.MatchedBy(func(entry *domain.JournalEntry) bool {
+		return entry.Validate() == nil
+	})).Return(nil)
+	return ledgerRepo
+}
+
+type mockOutboxRepository struct {
+	mock.Mock
+}
+
+func (m *mockOutboxRepository) Create(ctx context.Context, event *domain.OutboxEvent) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
+
+func (m *mockOutboxRepository) TryLock(ctx context.Context) (bool, error) {
+	args := m.Called(ctx)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockOutboxRepository) FetchPending(ctx context.Context, limit int) ([]*domain.OutboxEvent, error) {
+	args := m.Called(ctx, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.OutboxEvent), args.Error(1)
+}
+
+func (m *mockOutboxRepository) MarkPublished(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *mockOutboxRepository) MarkFailed(ctx context.Context, id int64, reason string, nextAttemptAt time.Time) error {
+	args := m.Called(ctx, id, reason, nextAttemptAt)
+	return args.Error(0)
+}
+
+// newMockOutboxRepository returns an outbox mock that accepts any event
+func newMockOutboxRepository() *mockOutboxRepository {
+	outboxRepo := new(mockOutboxRepository)
+	outboxRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.OutboxEvent")).Return(nil).Maybe()
+	return outboxRepo
+}
+
+// recordedEvents lists the types of the events written to the outbox mock, in order
+func recordedEvents(outboxRepo *mockOutboxRepository) []domain.EventType {
+	var types []domain.EventType
+	for _, call := range outboxRepo.Calls {
+		if call.Method == "Create" {
+			types = append(types, call.Arguments.Get(1).(*domain.OutboxEvent).Type)
+		}
+	}
+	return types
+}
+
+type mockWebhookRepository struct {
+	mock.Mock
+}
+
+func (m *mockWebhookRepository) CreateSubscription(ctx context.Context, subscription *domain.WebhookSubscription) error {
+	args := m.Called(ctx, subscription)
+	return args.Error(0)
+}
+
+func (m *mockWebhookRepository) GetSubscription(ctx context.Context, id int64) (*domain.WebhookSubscription, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.WebhookSubscription), args.Error(1)
+}
+
+func (m *mockWebhookRepository) ListSubscriptions(ctx context.Context, userID int64) ([]*domain.WebhookSubscription, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.WebhookSubscription), args.Error(1)
+}
+
+func (m *mockWebhookRepository) DeleteSubscription(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *mockWebhookRepository) EnqueueDeliveries(ctx context.Context, event *domain.OutboxEvent, userIDs []int64) error {
+	args := m.Called(ctx, event, userIDs)
+	return args.Error(0)
+}
+
+func (m *mockWebhookRepository) ClaimDueDeliveries(ctx context.Context, limit int, leaseUntil time.Time) ([]*domain.WebhookJob, error) {
+	args := m.Called(ctx, limit, leaseUntil)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.WebhookJob), args.Error(1)
+}
+
+func (m *mockWebhookRepository) SaveAttempt(ctx context.Context, delivery *domain.WebhookDelivery, leaseUntil time.Time) (bool, error) {
+	args := m.Called(ctx, delivery, leaseUntil)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockWebhookRepository) UpdateDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	args := m.Called(ctx, delivery)
+	return args.Error(0)
+}
+
+func (m *mockWebhookRepository) GetDelivery(ctx context.Context, id int64) (*domain.WebhookDelivery, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.WebhookDelivery), args.Error(1)
+}
+
+func (m *mockWebhookRepository) ListDeliveries(ctx context.Context, subscriptionID int64, limit, offset int) ([]*domain.WebhookDelivery, error) {
+	args := m.Called(ctx, subscriptionID, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.WebhookDelivery), args.Error(1)
+}
+
+func (m *mockWebhookRepository) CountDeliveries(ctx context.Context, subscriptionID int64) (int, error) {
+	args := m.Called(ctx, subscriptionID)
 	return args.Int(0), args.Error(1)
 }
 
+// newMockWebhookRepository returns a webhook mock that accepts any queued event
+func newMockWebhookRepository() *mockWebhookRepository {
+	webhookRepo := new(mockWebhookRepository)
+	webhookRepo.On("EnqueueDeliveries", mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	return webhookRepo
+}
+
+// mockUnitOfWork runs the unit inline, standing in for a database transaction
+type mockUnitOfWork struct{}
+
+func (m *mockUnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
 func TestDeposit(t *testing.T) {
 	// Setup
 	ctx := context.Background()
@@ -90,7 +398,7 @@ func TestDeposit(t *testing.T) {
 	mockWallet := &domain.Wallet{
 		ID:        1,
 		UserID:    1,
-		Balance:   100.0,
+		Balance:   domain.NewAmount(100),
 		Currency:  domain.USD,
 		CreatedAt: now,
 		UpdatedAt: now,
@@ -100,20 +408,24 @@ func TestDeposit(t *testing.T) {
 	userRepo := new(mockUserRepository)
 	walletRepo := new(mockWalletRepository)
 	transactionRepo := new(mockTransactionRepository)
+	ledgerRepo := newMockLedgerRepository(1)
+	outboxRepo := newMockOutboxRepository()
+	webhookRepo := newMockWebhookRepository()
 	
 	// Setup expectations
 	userRepo.On("GetByID", ctx, int64(1)).Return(mockUser, nil)
-	walletRepo.On("GetByUserID", ctx, int64(1)).Return(mockWallet, nil)
+	walletRepo.On("ListByUserID", ctx, int64(1)).Return([]*domain.Wallet{mockWallet}, nil)
+	walletRepo.On("GetByIDForUpdate", ctx, int64(1)).Return(mockWallet, nil)
 	walletRepo.On("Update", ctx, mock.AnythingOfType("*domain.Wallet")).Return(nil)
 	transactionRepo.On("Create", ctx, mock.AnythingOfType("*domain.Transaction")).Return(nil)
 	
 	// Create usecase with mocks
-	uc := usecase.NewWalletUsecase(userRepo, walletRepo, transactionRepo)
+	uc := usecase.NewWalletUsecase(userRepo, walletRepo, transactionRepo, ledgerRepo, outboxRepo, webhookRepo, nil, nil, nil, &mockUnitOfWork{}, nil)
 	
 	// Test success case
 	req := domain.DepositRequest{
 		UserID:  1,
-		Amount:  50.0,
+		Amount:  domain.NewAmount(50),
 		Comment: "Test deposit",
 	}
 	
@@ -123,14 +435,16 @@ func TestDeposit(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, transaction)
 	assert.Equal(t, domain.Deposit, transaction.Type)
-	assert.Equal(t, 50.0, transaction.Amount)
-	assert.Equal(t, 100.0, transaction.BalanceBefore)
-	assert.Equal(t, 150.0, transaction.BalanceAfter)
+	assert.Equal(t, domain.NewAmount(50), transaction.Amount)
+	assert.Equal(t, domain.NewAmount(100), transaction.BalanceBefore)
+	assert.Equal(t, domain.NewAmount(150), transaction.BalanceAfter)
+	assert.Equal(t, []domain.EventType{domain.WalletCredited}, recordedEvents(outboxRepo))
 	
 	// Verify expectations
 	userRepo.AssertExpectations(t)
 	walletRepo.AssertExpectations(t)
 	transactionRepo.AssertExpectations(t)
+	ledgerRepo.AssertExpectations(t)
 }
 
 func TestWithdraw(t *testing.T) {
@@ -149,7 +463,7 @@ func TestWithdraw(t *testing.T) {
 	mockWallet := &domain.Wallet{
 		ID:        1,
 		UserID:    1,
-		Balance:   100.0,
+		Balance:   domain.NewAmount(100),
 		Currency:  domain.USD,
 		CreatedAt: now,
 		UpdatedAt: now,
@@ -159,20 +473,24 @@ func TestWithdraw(t *testing.T) {
 	userRepo := new(mockUserRepository)
 	walletRepo := new(mockWalletRepository)
 	transactionRepo := new(mockTransactionRepository)
+	ledgerRepo := newMockLedgerRepository(1)
+	outboxRepo := newMockOutboxRepository()
+	webhookRepo := newMockWebhookRepository()
 	
 	// Setup expectations
 	userRepo.On("GetByID", ctx, int64(1)).Return(mockUser, nil)
-	walletRepo.On("GetByUserID", ctx, int64(1)).Return(mockWallet, nil)
+	walletRepo.On("ListByUserID", ctx, int64(1)).Return([]*domain.Wallet{mockWallet}, nil)
+	walletRepo.On("GetByIDForUpdate", ctx, int64(1)).Return(mockWallet, nil)
 	walletRepo.On("Update", ctx, mock.AnythingOfType("*domain.Wallet")).Return(nil)
 	transactionRepo.On("Create", ctx, mock.AnythingOfType("*domain.Transaction")).Return(nil)
 	
 	// Create usecase with mocks
-	uc := usecase.NewWalletUsecase(userRepo, walletRepo, transactionRepo)
+	uc := usecase.NewWalletUsecase(userRepo, walletRepo, transactionRepo, ledgerRepo, outboxRepo, webhookRepo, nil, nil, nil, &mockUnitOfWork{}, nil)
 	
 	// Test success case
 	req := domain.WithdrawRequest{
 		UserID:  1,
-		Amount:  50.0,
+		Amount:  domain.NewAmount(50),
 		Comment: "Test withdrawal",
 	}
 	
@@ -182,30 +500,34 @@ func TestWithdraw(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, transaction)
 	assert.Equal(t, domain.Withdrawal, transaction.Type)
-	assert.Equal(t, 50.0, transaction.Amount)
-	assert.Equal(t, 100.0, transaction.BalanceBefore)
-	assert.Equal(t, 50.0, transaction.BalanceAfter)
+	assert.Equal(t, domain.NewAmount(50), transaction.Amount)
+	assert.Equal(t, domain.NewAmount(100), transaction.BalanceBefore)
+	assert.Equal(t, domain.NewAmount(50), transaction.BalanceAfter)
 	
 	// Test insufficient funds
 	insufficientReq := domain.WithdrawRequest{
 		UserID:  1,
-		Amount:  200.0,
+		Amount:  domain.NewAmount(200),
 		Comment: "Insufficient withdrawal",
 	}
 	
 	// Reset wallet for this test
-	mockWallet.Balance = 100.0
+	mockWallet.Balance = domain.NewAmount(100)
 	
 	transaction, err = uc.Withdraw(ctx, insufficientReq)
 	
 	assert.Error(t, err)
 	assert.Nil(t, transaction)
-	assert.Equal(t, domain.ErrInsufficientBalance, err)
+	assert.Equal(t, apperrors.ErrInsufficientFunds, err)
+
+	// Only the successful withdrawal is announced
+	assert.Equal(t, []domain.EventType{domain.WalletDebited}, recordedEvents(outboxRepo))
 	
 	// Verify expectations
 	userRepo.AssertExpectations(t)
 	walletRepo.AssertExpectations(t)
 	transactionRepo.AssertExpectations(t)
+	ledgerRepo.AssertExpectations(t)
 }
 
 func TestTransfer(t *testing.T) {
@@ -232,7 +554,7 @@ func TestTransfer(t *testing.T) {
 	senderWallet := &domain.Wallet{
 		ID:        1,
 		UserID:    1,
-		Balance:   100.0,
+		Balance:   domain.NewAmount(100),
 		Currency:  domain.USD,
 		CreatedAt: now,
 		UpdatedAt: now,
@@ -241,7 +563,7 @@ func TestTransfer(t *testing.T) {
 	receiverWallet := &domain.Wallet{
 		ID:        2,
 		UserID:    2,
-		Balance:   50.0,
+		Balance:   domain.NewAmount(50),
 		Currency:  domain.USD,
 		CreatedAt: now,
 		UpdatedAt: now,
@@ -251,23 +573,33 @@ func TestTransfer(t *testing.T) {
 	userRepo := new(mockUserRepository)
 	walletRepo := new(mockWalletRepository)
 	transactionRepo := new(mockTransactionRepository)
+	ledgerRepo := newMockLedgerRepository(1, 2)
+	outboxRepo := newMockOutboxRepository()
+	webhookRepo := newMockWebhookRepository()
 	
 	// Setup expectations
 	userRepo.On("GetByID", ctx, int64(1)).Return(sender, nil)
 	userRepo.On("GetByID", ctx, int64(2)).Return(receiver, nil)
-	walletRepo.On("GetByUserID", ctx, int64(1)).Return(senderWallet, nil)
-	walletRepo.On("GetByUserID", ctx, int64(2)).Return(receiverWallet, nil)
+	walletRepo.On("ListByUserID", ctx, int64(1)).Return([]*domain.Wallet{senderWallet}, nil)
+	walletRepo.On("ListByUserID", ctx, int64(2)).Return([]*domain.Wallet{receiverWallet}, nil)
+	walletRepo.On("GetByIDForUpdate", ctx, int64(1)).Return(senderWallet, nil)
+	walletRepo.On("GetByIDForUpdate", ctx, int64(2)).Return(receiverWallet, nil)
 	walletRepo.On("Update", ctx, mock.AnythingOfType("*domain.Wallet")).Return(nil)
-	transactionRepo.On("Create", ctx, mock.AnythingOfType("*domain.Transaction")).Return(nil)
+	transactionRepo.On("Create", ctx, mock.MatchedBy(func(tr *domain.Transaction) bool {
+		return tr.Type == domain.TransferOut
+	})).Return(nil).Once()
+	transactionRepo.On("Create", ctx, mock.MatchedBy(func(tr *domain.Transaction) bool {
+		return tr.Type == domain.TransferIn
+	})).Return(nil).Once()
 	
 	// Create usecase with mocks
-	uc := usecase.NewWalletUsecase(userRepo, walletRepo, transactionRepo)
+	uc := usecase.NewWalletUsecase(userRepo, walletRepo, transactionRepo, ledgerRepo, outboxRepo, webhookRepo, nil, nil, nil, &mockUnitOfWork{}, nil)
 	
 	// Test success case
 	req := domain.TransferRequest{
 		SenderID:   1,
 		ReceiverID: 2,
-		Amount:     30.0,
+		Amount:     domain.NewAmount(30),
 		Comment:    "Test transfer",
 	}
 	
@@ -276,13 +608,568 @@ func TestTransfer(t *testing.T) {
 	// Assertions
 	assert.NoError(t, err)
 	assert.NotNil(t, transaction)
-	assert.Equal(t, domain.Transfer, transaction.Type)
-	assert.Equal(t, 30.0, transaction.Amount)
-	assert.Equal(t, 100.0, transaction.BalanceBefore)
-	assert.Equal(t, 70.0, transaction.BalanceAfter)
+	assert.Equal(t, domain.TransferOut, transaction.Type)
+	assert.Equal(t, domain.NewAmount(30), transaction.Amount)
+	assert.Equal(t, domain.NewAmount(100), transaction.BalanceBefore)
+	assert.Equal(t, domain.NewAmount(70), transaction.BalanceAfter)
+	assert.NotEmpty(t, transaction.CorrelationID)
+	assert.Equal(t, int64(2), *transaction.CounterpartyWalletID)
+
+	// The receiver gets their own credit row with their own running balance
+	var incoming *domain.Transaction
+	for _, call := range transactionRepo.Calls {
+		if tr := call.Arguments.Get(1).(*domain.Transaction); tr.Type == domain.TransferIn {
+			incoming = tr
+		}
+	}
+	assert.NotNil(t, incoming)
+	assert.Equal(t, int64(2), incoming.WalletID)
+	assert.Equal(t, domain.NewAmount(50), incoming.BalanceBefore)
+	assert.Equal(t, domain.NewAmount(80), incoming.BalanceAfter)
+	assert.Equal(t, transaction.CorrelationID, incoming.CorrelationID)
+	assert.Equal(t, int64(1), *incoming.CounterpartyUserID)
+
+	// Both wallets and the transfer itself are announced
+	assert.Equal(t, []domain.EventType{
+		domain.WalletDebited,
+		domain.WalletCredited,
+		domain.TransferCompleted,
+	}, recordedEvents(outboxRepo))
+
+	// Each side's webhooks hear about their own wallet, both hear the transfer completed
+	webhookRepo.AssertCalled(t, "EnqueueDeliveries", ctx, mock.MatchedBy(func(event *domain.OutboxEvent) bool {
+		return event.Type == domain.WalletCredited && event.AggregateID == 2
+	}), []int64{2})
+	webhookRepo.AssertCalled(t, "EnqueueDeliveries", ctx, mock.MatchedBy(func(event *domain.OutboxEvent) bool {
+		return event.Type == domain.TransferCompleted
+	}), []int64{1, 2})
 	
 	// Verify expectations
 	userRepo.AssertExpectations(t)
 	walletRepo.AssertExpectations(t)
 	transactionRepo.AssertExpectations(t)
-}
\ No newline at end of file
+	ledgerRepo.AssertExpectations(t)
+}
+
+func TestTransfer_WalletSelection(t *testing.T) {
+	ctx := context.Background()
+
+	// The sender holds USD and EUR, the receiver only USD
+	senderUSD := &domain.Wallet{ID: 1, UserID: 1, Balance: domain.NewAmount(100), Currency: domain.USD}
+	senderEUR := &domain.Wallet{ID: 3, UserID: 1, Balance: domain.NewAmount(100), Currency: domain.EUR}
+	receiverUSD := &domain.Wallet{ID: 2, UserID: 2, Balance: domain.NewAmount(50), Currency: domain.USD}
+
+	tests := []struct {
+		name          string
+		req           domain.TransferRequest
+		expectedError error
+	}{
+		{
+			name: "by currency",
+			req:  domain.TransferRequest{SenderID: 1, ReceiverID: 2, Currency: domain.USD, Amount: domain.NewAmount(10)},
+		},
+		{
+			name: "by wallet ID",
+			req:  domain.TransferRequest{SenderID: 1, ReceiverID: 2, SenderWalletID: 1, ReceiverWalletID: 2, Amount: domain.NewAmount(10)},
+		},
+		{
+			name:          "sender with several wallets names none",
+			req:           domain.TransferRequest{SenderID: 1, ReceiverID: 2, Amount: domain.NewAmount(10)},
+			expectedError: apperrors.ErrInvalidInput,
+		},
+		{
+			name:          "receiver has no wallet in the currency",
+			req:           domain.TransferRequest{SenderID: 1, ReceiverID: 2, Currency: domain.EUR, Amount: domain.NewAmount(10)},
+			expectedError: apperrors.ErrWalletNotFound,
+		},
+		{
+			name:          "wallets hold different currencies",
+			req:           domain.TransferRequest{SenderID: 1, ReceiverID: 2, SenderWalletID: 3, ReceiverWalletID: 2, Amount: domain.NewAmount(10)},
+			expectedError: apperrors.ErrCurrencyMismatch,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			userRepo := new(mockUserRepository)
+			walletRepo := new(mockWalletRepository)
+			transactionRepo := new(mockTransactionRepository)
+
+			userRepo.On("GetByID", ctx, int64(1)).Return(&domain.User{ID: 1}, nil)
+			userRepo.On("GetByID", ctx, int64(2)).Return(&domain.User{ID: 2}, nil)
+			walletRepo.On("ListByUserID", ctx, int64(1)).Return([]*domain.Wallet{senderUSD, senderEUR}, nil)
+			walletRepo.On("ListByUserID", ctx, int64(2)).Return([]*domain.Wallet{receiverUSD}, nil)
+			walletRepo.On("GetByIDForUpdate", ctx, int64(1)).Return(senderUSD, nil).Maybe()
+			walletRepo.On("GetByIDForUpdate", ctx, int64(2)).Return(receiverUSD, nil).Maybe()
+			walletRepo.On("Update", ctx, mock.AnythingOfType("*domain.Wallet")).Return(nil).Maybe()
+			transactionRepo.On("Create", ctx, mock.AnythingOfType("*domain.Transaction")).Return(nil).Maybe()
+
+			uc := usecase.NewWalletUsecase(userRepo, walletRepo, transactionRepo, newMockLedgerRepository(1, 2), newMockOutboxRepository(), newMockWebhookRepository(), nil, nil, nil, &mockUnitOfWork{}, nil)
+
+			transaction, err := uc.Transfer(ctx, tc.req)
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				walletRepo.AssertNotCalled(t, "GetByIDForUpdate", mock.Anything, mock.Anything)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, int64(1), transaction.WalletID)
+			assert.Equal(t, int64(2), *transaction.DestWalletID)
+		})
+	}
+}
+
+// newFXTestLedgerRepository returns a ledger mock for a USD wallet 1 and a EUR
+// wallet 3, with fx accounts in both currencies, accepting any balanced entry
+func newFXTestLedgerRepository() *mockLedgerRepository {
+	ledgerRepo := new(mockLedgerRepository)
+	for walletID, currency := range map[int64]domain.Currency{1: domain.USD, 3: domain.EUR} {
+		id := walletID
+		ledgerRepo.On("GetWalletAccount", mock.Anything, id).Return(&domain.LedgerAccount{
+			ID:       100 + id,
+			Code:     domain.WalletAccountCode(id),
+			Type:     domain.WalletAccountType,
+			WalletID: &id,
+			Currency: currency,
+		}, nil).Maybe()
+	}
+	for i, currency := range []domain.Currency{domain.USD, domain.EUR} {
+		ledgerRepo.On("GetSystemAccount", mock.Anything, domain.FXAccount, currency).Return(&domain.LedgerAccount{
+			ID:       int64(10 + i),
+			Code:     domain.FXAccount,
+			Type:     domain.SystemAccountType,
+			Currency: currency,
+		}, nil).Maybe()
+	}
+	ledgerRepo.On("CreateEntry", mock.Anything, mock.MatchedBy(func(entry *domain.JournalEntry) bool {
+		return entry.Validate() == nil
+	})).Return(nil)
+	return ledgerRepo
+}
+
+func TestConvert(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name           string
+		req            domain.ConvertRequest
+		withoutFX      bool
+		expectedCredit domain.Amount
+		expectedError  error
+	}{
+		{
+			name:           "USD to EUR",
+			req:            domain.ConvertRequest{UserID: 1, From: domain.WalletSelector{Currency: domain.USD}, To: domain.WalletSelector{Currency: domain.EUR}, Amount: domain.NewAmount(100)},
+			expectedCredit: domain.NewAmount(92),
+		},
+		{
+			name:          "insufficient funds",
+			req:           domain.ConvertRequest{UserID: 1, From: domain.WalletSelector{Currency: domain.USD}, To: domain.WalletSelector{Currency: domain.EUR}, Amount: domain.NewAmount(500)},
+			expectedError: apperrors.ErrInsufficientFunds,
+		},
+		{
+			name:          "same wallet",
+			req:           domain.ConvertRequest{UserID: 1, From: domain.WalletSelector{WalletID: 1}, To: domain.WalletSelector{Currency: domain.USD}, Amount: domain.NewAmount(10)},
+			expectedError: apperrors.ErrInvalidInput,
+		},
+		{
+			name:          "no rate provider",
+			req:           domain.ConvertRequest{UserID: 1, From: domain.WalletSelector{Currency: domain.USD}, To: domain.WalletSelector{Currency: domain.EUR}, Amount: domain.NewAmount(10)},
+			withoutFX:     true,
+			expectedError: apperrors.ErrRateUnavailable,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			usdWallet := &domain.Wallet{ID: 1, UserID: 1, Balance: domain.NewAmount(200), Currency: domain.USD}
+			eurWallet := &domain.Wallet{ID: 3, UserID: 1, Balance: domain.NewAmount(10), Currency: domain.EUR}
+
+			userRepo := new(mockUserRepository)
+			walletRepo := new(mockWalletRepository)
+			transactionRepo := new(mockTransactionRepository)
+			provider := new(mockRateProvider)
+			quoteRepo := new(mockFXQuoteRepository)
+
+			userRepo.On("GetByID", ctx, int64(1)).Return(&domain.User{ID: 1}, nil)
+			walletRepo.On("ListByUserID", ctx, int64(1)).Return([]*domain.Wallet{usdWallet, eurWallet}, nil)
+			walletRepo.On("GetByIDForUpdate", ctx, int64(1)).Return(usdWallet, nil).Maybe()
+			walletRepo.On("GetByIDForUpdate", ctx, int64(3)).Return(eurWallet, nil).Maybe()
+			walletRepo.On("Update", ctx, mock.AnythingOfType("*domain.Wallet")).Return(nil).Maybe()
+			transactionRepo.On("Create", ctx, mock.AnythingOfType("*domain.Transaction")).Return(nil).Maybe()
+			provider.On("Rate", ctx, domain.USD, domain.EUR).Return(mustParseRate(t, "0.92"), nil).Maybe()
+			quoteRepo.On("Create", ctx, mock.AnythingOfType("*domain.FXQuote")).Return(nil).Maybe()
+
+			var fxUsecase domain.FXUsecase
+			if !tc.withoutFX {
+				fxUsecase = usecase.NewFXUsecase(provider, quoteRepo, 0, time.Minute)
+			}
+			outboxRepo := newMockOutboxRepository()
+			uc := usecase.NewWalletUsecase(userRepo, walletRepo, transactionRepo, newFXTestLedgerRepository(), outboxRepo, newMockWebhookRepository(), fxUsecase, nil, nil, &mockUnitOfWork{}, nil)
+
+			conversion, err := uc.Convert(ctx, tc.req)
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				transactionRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, domain.ConversionOut, conversion.Debit.Type)
+			assert.Equal(t, domain.ConversionIn, conversion.Credit.Type)
+			assert.Equal(t, tc.req.Amount, conversion.Debit.Amount)
+			assert.Equal(t, tc.expectedCredit, conversion.Credit.Amount)
+			assert.Equal(t, "0.92", conversion.Credit.FXRate.String())
+			assert.Equal(t, conversion.Debit.CorrelationID, conversion.Credit.CorrelationID)
+			assert.Equal(t, domain.NewAmount(100), usdWallet.Balance)
+			assert.Equal(t, domain.NewAmount(102), eurWallet.Balance)
+			assert.Equal(t, []domain.EventType{domain.WalletDebited, domain.WalletCredited}, recordedEvents(outboxRepo))
+		})
+	}
+}
+
+func TestTransfer_CrossCurrency(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name          string
+		req           domain.TransferRequest
+		expectedError error
+	}{
+		{
+			name: "converted",
+			req:  domain.TransferRequest{SenderID: 1, ReceiverID: 2, ReceiverCurrency: domain.EUR, Convert: true, Amount: domain.NewAmount(50)},
+		},
+		{
+			name:          "conversion not asked for",
+			req:           domain.TransferRequest{SenderID: 1, ReceiverID: 2, ReceiverCurrency: domain.EUR, Amount: domain.NewAmount(50)},
+			expectedError: apperrors.ErrCurrencyMismatch,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			senderWallet := &domain.Wallet{ID: 1, UserID: 1, Balance: domain.NewAmount(100), Currency: domain.USD}
+			receiverWallet := &domain.Wallet{ID: 3, UserID: 2, Balance: domain.NewAmount(0), Currency: domain.EUR}
+
+			userRepo := new(mockUserRepository)
+			walletRepo := new(mockWalletRepository)
+			transactionRepo := new(mockTransactionRepository)
+			provider := new(mockRateProvider)
+			quoteRepo := new(mockFXQuoteRepository)
+
+			userRepo.On("GetByID", ctx, int64(1)).Return(&domain.User{ID: 1}, nil)
+			userRepo.On("GetByID", ctx, int64(2)).Return(&domain.User{ID: 2}, nil)
+			walletRepo.On("ListByUserID", ctx, int64(1)).Return([]*domain.Wallet{senderWallet}, nil)
+			walletRepo.On("ListByUserID", ctx, int64(2)).Return([]*domain.Wallet{receiverWallet}, nil)
+			walletRepo.On("GetByIDForUpdate", ctx, int64(1)).Return(senderWallet, nil).Maybe()
+			walletRepo.On("GetByIDForUpdate", ctx, int64(3)).Return(receiverWallet, nil).Maybe()
+			walletRepo.On("Update", ctx, mock.AnythingOfType("*domain.Wallet")).Return(nil).Maybe()
+			transactionRepo.On("Create", ctx, mock.AnythingOfType("*domain.Transaction")).Return(nil).Maybe()
+			provider.On("Rate", ctx, domain.USD, domain.EUR).Return(mustParseRate(t, "0.9"), nil).Maybe()
+			quoteRepo.On("Create", ctx, mock.AnythingOfType("*domain.FXQuote")).Return(nil).Maybe()
+
+			fxUsecase := usecase.NewFXUsecase(provider, quoteRepo, 0, time.Minute)
+			outboxRepo := newMockOutboxRepository()
+			uc := usecase.NewWalletUsecase(userRepo, walletRepo, transactionRepo, newFXTestLedgerRepository(), outboxRepo, newMockWebhookRepository(), fxUsecase, nil, nil, &mockUnitOfWork{}, nil)
+
+			transaction, err := uc.Transfer(ctx, tc.req)
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				walletRepo.AssertNotCalled(t, "GetByIDForUpdate", mock.Anything, mock.Anything)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, domain.TransferOut, transaction.Type)
+			assert.Equal(t, domain.NewAmount(45), *transaction.TargetAmount)
+			assert.Equal(t, domain.NewAmount(50), senderWallet.Balance)
+			assert.Equal(t, domain.NewAmount(45), receiverWallet.Balance)
+			assert.Equal(t, []domain.EventType{domain.WalletDebited, domain.WalletCredited, domain.TransferCompleted}, recordedEvents(outboxRepo))
+		})
+	}
+}
+
+// historyTransactionRepository serves a fixed wallet history, newest first,
+// paging through it the way the database would
+type historyTransactionRepository struct {
+	mockTransactionRepository
+	history []*domain.Transaction
+	counted bool
+}
+
+// newHistoryTransactionRepository returns a history of count rows, one a minute
+func newHistoryTransactionRepository(count int) *historyTransactionRepository {
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	history := make([]*domain.Transaction, 0, count)
+	for id := count; id > 0; id-- {
+		history = append(history, &domain.Transaction{
+			ID:              int64(id),
+			WalletID:        1,
+			TransactionTime: start.Add(time.Duration(id) * time.Minute),
+		})
+	}
+	return &historyTransactionRepository{history: history}
+}
+
+func (r *historyTransactionRepository) GetByWalletID(ctx context.Context, walletID int64, filter domain.TransactionFilter, limit, offset int) ([]*domain.Transaction, error) {
+	return r.history[min(offset, len(r.history)):min(offset+limit, len(r.history))], nil
+}
+
+func (r *historyTransactionRepository) GetPageByWalletID(ctx context.Context, walletID int64, filter domain.TransactionFilter, cursor domain.TransactionCursor, limit int) ([]*domain.Transaction, error) {
+	var page []*domain.Transaction
+	for _, tr := range r.history {
+		if (cursor.Direction == domain.CursorNext && tr.ID < cursor.ID) || (cursor.Direction == domain.CursorPrev && tr.ID > cursor.ID) {
+			page = append(page, tr)
+		}
+	}
+	if len(page) > limit {
+		// The limit keeps the rows closest to the cursor
+		if cursor.Direction == domain.CursorPrev {
+			return page[len(page)-limit:], nil
+		}
+		return page[:limit], nil
+	}
+	return page, nil
+}
+
+func (r *historyTransactionRepository) CountByWalletID(ctx context.Context, walletID int64, filter domain.TransactionFilter) (int, error) {
+	r.counted = true
+	return len(r.history), nil
+}
+
+// transactionIDs lists the IDs of a page in order
+func transactionIDs(transactions []*domain.Transaction) []int64 {
+	ids := make([]int64, 0, len(transactions))
+	for _, tr := range transactions {
+		ids = append(ids, tr.ID)
+	}
+	return ids
+}
+
+func TestGetTransactionHistory_Cursor(t *testing.T) {
+	ctx := context.Background()
+
+	userRepo := new(mockUserRepository)
+	walletRepo := new(mockWalletRepository)
+	userRepo.On("GetByID", ctx, int64(1)).Return(&domain.User{ID: 1}, nil)
+	walletRepo.On("ListByUserID", ctx, int64(1)).Return([]*domain.Wallet{{ID: 1, UserID: 1, Currency: domain.USD}}, nil)
+	transactionRepo := newHistoryTransactionRepository(7)
+
+	uc := usecase.NewWalletUsecase(userRepo, walletRepo, transactionRepo, newMockLedgerRepository(), newMockOutboxRepository(), newMockWebhookRepository(), nil, nil, nil, &mockUnitOfWork{}, nil)
+
+	// First page, newest first, with only a next cursor and no count
+	first, err := uc.GetTransactionHistory(ctx, 1, domain.WalletSelector{}, domain.TransactionFilter{}, domain.PaginationRequest{Limit: 3})
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{7, 6, 5}, transactionIDs(first.Transactions))
+	assert.NotEmpty(t, first.NextCursor)
+	assert.Empty(t, first.PrevCursor)
+	assert.Nil(t, first.Total)
+	assert.False(t, transactionRepo.counted)
+
+	// Following next cursors walks to the end
+	second, err := uc.GetTransactionHistory(ctx, 1, domain.WalletSelector{}, domain.TransactionFilter{}, domain.PaginationRequest{Limit: 3, Cursor: first.NextCursor})
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{4, 3, 2}, transactionIDs(second.Transactions))
+	assert.NotEmpty(t, second.NextCursor)
+	assert.NotEmpty(t, second.PrevCursor)
+
+	last, err := uc.GetTransactionHistory(ctx, 1, domain.WalletSelector{}, domain.TransactionFilter{}, domain.PaginationRequest{Limit: 3, Cursor: second.NextCursor})
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1}, transactionIDs(last.Transactions))
+	assert.Empty(t, last.NextCursor)
+	assert.NotEmpty(t, last.PrevCursor)
+
+	// A prev cursor returns the rows right before it, still newest first
+	back, err := uc.GetTransactionHistory(ctx, 1, domain.WalletSelector{}, domain.TransactionFilter{}, domain.PaginationRequest{Limit: 3, Cursor: last.PrevCursor})
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{4, 3, 2}, transactionIDs(back.Transactions))
+	assert.NotEmpty(t, back.NextCursor)
+	assert.NotEmpty(t, back.PrevCursor)
+
+	top, err := uc.GetTransactionHistory(ctx, 1, domain.WalletSelector{}, domain.TransactionFilter{}, domain.PaginationRequest{Limit: 3, Cursor: back.PrevCursor, IncludeTotal: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{7, 6, 5}, transactionIDs(top.Transactions))
+	assert.Empty(t, top.PrevCursor)
+	assert.NotEmpty(t, top.NextCursor)
+	assert.Equal(t, 7, *top.Total)
+
+	// A broken cursor is rejected
+	_, err = uc.GetTransactionHistory(ctx, 1, domain.WalletSelector{}, domain.TransactionFilter{}, domain.PaginationRequest{Limit: 3, Cursor: "garbage"})
+	assert.ErrorIs(t, err, apperrors.ErrInvalidCursor)
+}
+
+func TestGetTransactionHistory_Offset(t *testing.T) {
+	ctx := context.Background()
+
+	userRepo := new(mockUserRepository)
+	walletRepo := new(mockWalletRepository)
+	userRepo.On("GetByID", ctx, int64(1)).Return(&domain.User{ID: 1}, nil)
+	walletRepo.On("ListByUserID", ctx, int64(1)).Return([]*domain.Wallet{{ID: 1, UserID: 1, Currency: domain.USD}}, nil)
+	transactionRepo := newHistoryTransactionRepository(7)
+
+	uc := usecase.NewWalletUsecase(userRepo, walletRepo, transactionRepo, newMockLedgerRepository(), newMockOutboxRepository(), newMockWebhookRepository(), nil, nil, nil, &mockUnitOfWork{}, nil)
+
+	history, err := uc.GetTransactionHistory(ctx, 1, domain.WalletSelector{}, domain.TransactionFilter{}, domain.PaginationRequest{Limit: 3, Offset: 3, IncludeTotal: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{4, 3, 2}, transactionIDs(history.Transactions))
+	assert.Equal(t, 7, *history.Total)
+	assert.Equal(t, 3, history.Offset)
+
+	// Offset pages hand out cursors too, so clients can switch over
+	assert.NotEmpty(t, history.NextCursor)
+	assert.NotEmpty(t, history.PrevCursor)
+	next, err := uc.GetTransactionHistory(ctx, 1, domain.WalletSelector{}, domain.TransactionFilter{}, domain.PaginationRequest{Limit: 3, Cursor: history.NextCursor})
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1}, transactionIDs(next.Transactions))
+}
+
+func TestReverse(t *testing.T) {
+	ctx := context.Background()
+	senderID, receiverID := int64(1), int64(2)
+	senderWalletID, receiverWalletID := int64(1), int64(2)
+
+	newTransfer := func() []*domain.Transaction {
+		return []*domain.Transaction{
+			{
+				ID:                   10,
+				WalletID:             senderWalletID,
+				Type:                 domain.TransferOut,
+				Amount:               domain.NewAmount(30),
+				CorrelationID:        "c0ffee00-0000-0000-0000-000000000000",
+				CounterpartyWalletID: &receiverWalletID,
+				CounterpartyUserID:   &receiverID,
+			},
+			{
+				ID:                   11,
+				WalletID:             receiverWalletID,
+				Type:                 domain.TransferIn,
+				Amount:               domain.NewAmount(30),
+				CorrelationID:        "c0ffee00-0000-0000-0000-000000000000",
+				CounterpartyWalletID: &senderWalletID,
+				CounterpartyUserID:   &senderID,
+			},
+		}
+	}
+
+	tests := []struct {
+		name            string
+		receiverBalance domain.Amount
+		allowNegative   bool
+		expectedError   error
+	}{
+		{
+			name:            "receiver still holds the money",
+			receiverBalance: domain.NewAmount(50),
+		},
+		{
+			name:            "receiver spent the money",
+			receiverBalance: domain.NewAmount(10),
+			expectedError:   apperrors.ErrInsufficientFunds,
+		},
+		{
+			name:            "receiver spent the money but negative balances are allowed",
+			receiverBalance: domain.NewAmount(10),
+			allowNegative:   true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			transfer := newTransfer()
+			senderWallet := &domain.Wallet{ID: senderWalletID, UserID: senderID, Balance: domain.NewAmount(70), Currency: domain.USD}
+			receiverWallet := &domain.Wallet{ID: receiverWalletID, UserID: receiverID, Balance: tc.receiverBalance, Currency: domain.USD}
+
+			walletRepo := new(mockWalletRepository)
+			transactionRepo := new(mockTransactionRepository)
+			ledgerRepo := newMockLedgerRepository(senderWalletID, receiverWalletID)
+			outboxRepo := newMockOutboxRepository()
+
+			transactionRepo.On("GetByIDForUpdate", ctx, int64(11)).Return(transfer[1], nil)
+			transactionRepo.On("GetByCorrelationIDForUpdate", ctx, transfer[0].CorrelationID).Return(transfer, nil)
+			transactionRepo.On("Create", ctx, mock.AnythingOfType("*domain.Transaction")).Return(nil).Maybe()
+			transactionRepo.On("UpdateReversal", ctx, mock.AnythingOfType("*domain.Transaction")).Return(nil).Maybe()
+			walletRepo.On("GetByIDForUpdate", ctx, senderWalletID).Return(senderWallet, nil)
+			walletRepo.On("GetByIDForUpdate", ctx, receiverWalletID).Return(receiverWallet, nil)
+			walletRepo.On("Update", ctx, mock.AnythingOfType("*domain.Wallet")).Return(nil).Maybe()
+
+			uc := usecase.NewWalletUsecase(new(mockUserRepository), walletRepo, transactionRepo, ledgerRepo, outboxRepo, newMockWebhookRepository(), nil, nil, nil, &mockUnitOfWork{}, nil)
+
+			result, err := uc.Reverse(ctx, domain.ReversalRequest{TransactionID: 11, AllowNegative: tc.allowNegative})
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				transactionRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, int64(11), result.Original.ID)
+			assert.Equal(t, domain.Reversed, result.Original.ReversalStatus)
+			assert.Equal(t, domain.NewAmount(30), transfer[0].ReversedAmount)
+
+			assert.Len(t, result.Transactions, 2)
+			for i, tr := range result.Transactions {
+				assert.Equal(t, domain.Reversal, tr.Type)
+				assert.Equal(t, transfer[i].ID, *tr.OriginalTransactionID)
+				assert.NotEmpty(t, tr.CorrelationID)
+			}
+			assert.Equal(t, domain.NewAmount(100), senderWallet.Balance)
+			assert.Equal(t, tc.receiverBalance-domain.NewAmount(30), receiverWallet.Balance)
+			assert.ElementsMatch(t, []domain.EventType{domain.WalletCredited, domain.WalletDebited}, recordedEvents(outboxRepo))
+		})
+	}
+}
+
+func TestRefund(t *testing.T) {
+	ctx := context.Background()
+
+	deposit := &domain.Transaction{
+		ID:       5,
+		WalletID: 1,
+		Type:     domain.Deposit,
+		Amount:   domain.NewAmount(100),
+	}
+	wallet := &domain.Wallet{ID: 1, UserID: 1, Balance: domain.NewAmount(100), Currency: domain.USD}
+
+	walletRepo := new(mockWalletRepository)
+	transactionRepo := new(mockTransactionRepository)
+	ledgerRepo := newMockLedgerRepository(1)
+
+	transactionRepo.On("GetByIDForUpdate", ctx, int64(5)).Return(deposit, nil)
+	transactionRepo.On("Create", ctx, mock.AnythingOfType("*domain.Transaction")).Return(nil)
+	transactionRepo.On("UpdateReversal", ctx, deposit).Return(nil)
+	walletRepo.On("GetByIDForUpdate", ctx, int64(1)).Return(wallet, nil)
+	walletRepo.On("Update", ctx, wallet).Return(nil)
+
+	uc := usecase.NewWalletUsecase(new(mockUserRepository), walletRepo, transactionRepo, ledgerRepo, newMockOutboxRepository(), newMockWebhookRepository(), nil, nil, nil, &mockUnitOfWork{}, nil)
+
+	// First refund leaves the deposit partially refunded
+	result, err := uc.Refund(ctx, domain.ReversalRequest{TransactionID: 5, Amount: domain.NewAmount(40)})
+	assert.NoError(t, err)
+	assert.Equal(t, domain.PartiallyRefunded, result.Original.ReversalStatus)
+	assert.Equal(t, domain.Refund, result.Transactions[0].Type)
+	assert.Equal(t, domain.NewAmount(100), result.Transactions[0].BalanceBefore)
+	assert.Equal(t, domain.NewAmount(60), result.Transactions[0].BalanceAfter)
+
+	// Refunding more than what is left is rejected
+	_, err = uc.Refund(ctx, domain.ReversalRequest{TransactionID: 5, Amount: domain.NewAmount(61)})
+	assert.ErrorIs(t, err, apperrors.ErrReversalTooLarge)
+
+	// The rest refunds the deposit completely
+	result, err = uc.Refund(ctx, domain.ReversalRequest{TransactionID: 5, Amount: domain.NewAmount(60)})
+	assert.NoError(t, err)
+	assert.Equal(t, domain.Refunded, result.Original.ReversalStatus)
+	assert.Equal(t, domain.Amount(0), wallet.Balance)
+
+	// Nothing is left to reverse
+	_, err = uc.Reverse(ctx, domain.ReversalRequest{TransactionID: 5})
+	assert.ErrorIs(t, err, apperrors.ErrNotReversible)
+
+	// The money went back to cash-in
+	ledgerRepo.AssertCalled(t, "GetSystemAccount", mock.Anything, domain.CashInAccount, domain.USD)
+}
@@ -0,0 +1,169 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+)
+
+// webhookSecretPrefix marks webhook signing secrets so they're recognisable if leaked
+const webhookSecretPrefix = "whsec_"
+
+type webhookUsecase struct {
+	webhookRepo   domain.WebhookRepository
+	allowInsecure bool
+}
+
+// NewWebhookUsecase creates a webhook use case for managing subscriptions and
+// deliveries. allowInsecure accepts http and internal URLs, for local development.
+func NewWebhookUsecase(webhookRepo domain.WebhookRepository, allowInsecure bool) domain.WebhookUsecase {
+	return &webhookUsecase{
+		webhookRepo:   webhookRepo,
+		allowInsecure: allowInsecure,
+	}
+}
+
+// CreateSubscription registers a webhook endpoint. The signing secret is only
+// returned here, so the caller has to keep it.
+func (u *webhookUsecase) CreateSubscription(ctx context.Context, userID int64, req domain.CreateWebhookRequest) (*domain.WebhookSubscription, error) {
+	subscription := &domain.WebhookSubscription{
+		UserID:     userID,
+		URL:        req.URL,
+		EventTypes: req.EventTypes,
+	}
+	if err := subscription.Validate(u.allowInsecure); err != nil {
+		return nil, err
+	}
+
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return nil, err
+	}
+	subscription.Secret = secret
+
+	if err := u.webhookRepo.CreateSubscription(ctx, subscription); err != nil {
+		return nil, apperrors.WrapError(err, "failed to create webhook subscription")
+	}
+
+	return subscription, nil
+}
+
+// ListSubscriptions returns the user's webhooks without their secrets
+func (u *webhookUsecase) ListSubscriptions(ctx context.Context, userID int64) ([]*domain.WebhookSubscription, error) {
+	subscriptions, err := u.webhookRepo.ListSubscriptions(ctx, userID)
+	if err != nil {
+		return nil, apperrors.WrapError(err, "failed to list webhook subscriptions")
+	}
+
+	for _, subscription := range subscriptions {
+		subscription.Secret = ""
+	}
+	if subscriptions == nil {
+		subscriptions = []*domain.WebhookSubscription{}
+	}
+	return subscriptions, nil
+}
+
+// DeleteSubscription removes a webhook together with its delivery log
+func (u *webhookUsecase) DeleteSubscription(ctx context.Context, userID, subscriptionID int64) error {
+	if _, err := u.ownedSubscription(ctx, userID, subscriptionID); err != nil {
+		return err
+	}
+
+	if err := u.webhookRepo.DeleteSubscription(ctx, subscriptionID); err != nil {
+		return apperrors.WrapError(err, "failed to delete webhook subscription")
+	}
+	return nil
+}
+
+// ListDeliveries returns a page of a webhook's delivery log, newest first
+func (u *webhookUsecase) ListDeliveries(
+	ctx context.Context,
+	userID, subscriptionID int64,
+	pagination domain.PaginationRequest,
+) (*domain.WebhookDeliveryHistoryResponse, error) {
+	if _, err := u.ownedSubscription(ctx, userID, subscriptionID); err != nil {
+		return nil, err
+	}
+
+	deliveries, err := u.webhookRepo.ListDeliveries(ctx, subscriptionID, pagination.Limit, pagination.Offset)
+	if err != nil {
+		return nil, apperrors.WrapError(err, "failed to list webhook deliveries")
+	}
+
+	total, err := u.webhookRepo.CountDeliveries(ctx, subscriptionID)
+	if err != nil {
+		return nil, apperrors.WrapError(err, "failed to count webhook deliveries")
+	}
+
+	if deliveries == nil {
+		deliveries = []*domain.WebhookDelivery{}
+	}
+
+	return &domain.WebhookDeliveryHistoryResponse{
+		Deliveries: deliveries,
+		Total:      total,
+		Limit:      pagination.Limit,
+		Offset:     pagination.Offset,
+	}, nil
+}
+
+// Redeliver queues a delivery to be sent again straight away with a fresh set of retries,
+// whatever state it's in
+func (u *webhookUsecase) Redeliver(ctx context.Context, userID, subscriptionID, deliveryID int64) (*domain.WebhookDelivery, error) {
+	if _, err := u.ownedSubscription(ctx, userID, subscriptionID); err != nil {
+		return nil, err
+	}
+
+	delivery, err := u.webhookRepo.GetDelivery(ctx, deliveryID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrResourceNotFound) {
+			return nil, err
+		}
+		return nil, apperrors.WrapError(err, "failed to get webhook delivery")
+	}
+	if delivery.SubscriptionID != subscriptionID {
+		return nil, apperrors.ErrResourceNotFound
+	}
+
+	delivery.Status = domain.DeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = time.Now()
+
+	if err := u.webhookRepo.UpdateDelivery(ctx, delivery); err != nil {
+		return nil, apperrors.WrapError(err, "failed to queue redelivery")
+	}
+
+	return delivery, nil
+}
+
+// ownedSubscription loads a subscription, refusing ones that belong to another user
+func (u *webhookUsecase) ownedSubscription(ctx context.Context, userID, subscriptionID int64) (*domain.WebhookSubscription, error) {
+	subscription, err := u.webhookRepo.GetSubscription(ctx, subscriptionID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrResourceNotFound) {
+			return nil, err
+		}
+		return nil, apperrors.WrapError(err, "failed to get webhook subscription")
+	}
+
+	if subscription.UserID != userID {
+		return nil, fmt.Errorf("%w: webhook %d belongs to another user", apperrors.ErrForbidden, subscriptionID)
+	}
+	return subscription, nil
+}
+
+// newWebhookSecret generates a random signing secret
+func newWebhookSecret() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", apperrors.WrapError(err, "failed to generate webhook secret")
+	}
+	return webhookSecretPrefix + hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,151 @@
+package usecase_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	"github.com/ravindu/wallet-app-service/internal/usecase"
+	apperrors "github.com/ravindu/wallet-app-service/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCreateSubscription(t *testing.T) {
+	tests := []struct {
+		name          string
+		req           domain.CreateWebhookRequest
+		allowInsecure bool
+		expectedError error
+	}{
+		{
+			name: "valid subscription",
+			req:  domain.CreateWebhookRequest{URL: "https://merchant.example.com/hooks", EventTypes: []domain.EventType{domain.WalletCredited}},
+		},
+		{
+			name:          "relative URL",
+			req:           domain.CreateWebhookRequest{URL: "/hooks", EventTypes: []domain.EventType{domain.WalletCredited}},
+			expectedError: apperrors.ErrInvalidInput,
+		},
+		{
+			name:          "unsupported scheme",
+			req:           domain.CreateWebhookRequest{URL: "ftp://merchant.example.com", EventTypes: []domain.EventType{domain.WalletCredited}},
+			expectedError: apperrors.ErrInvalidInput,
+		},
+		{
+			name:          "plain http",
+			req:           domain.CreateWebhookRequest{URL: "http://merchant.example.com/hooks", EventTypes: []domain.EventType{domain.WalletCredited}},
+			expectedError: apperrors.ErrInvalidInput,
+		},
+		{
+			name:          "cloud metadata address",
+			req:           domain.CreateWebhookRequest{URL: "https://169.254.169.254/latest/meta-data", EventTypes: []domain.EventType{domain.WalletCredited}},
+			expectedError: apperrors.ErrInvalidInput,
+		},
+		{
+			name:          "localhost",
+			req:           domain.CreateWebhookRequest{URL: "https://localhost:8443/hooks", EventTypes: []domain.EventType{domain.WalletCredited}},
+			expectedError: apperrors.ErrInvalidInput,
+		},
+		{
+			name:          "private address",
+			req:           domain.CreateWebhookRequest{URL: "https://10.0.0.5/hooks", EventTypes: []domain.EventType{domain.WalletCredited}},
+			expectedError: apperrors.ErrInvalidInput,
+		},
+		{
+			name:          "local endpoint allowed in development",
+			req:           domain.CreateWebhookRequest{URL: "http://localhost:9000/hooks", EventTypes: []domain.EventType{domain.WalletCredited}},
+			allowInsecure: true,
+		},
+		{
+			name:          "unknown event type",
+			req:           domain.CreateWebhookRequest{URL: "https://merchant.example.com/hooks", EventTypes: []domain.EventType{"wallet.exploded"}},
+			expectedError: apperrors.ErrInvalidInput,
+		},
+		{
+			name:          "no event types",
+			req:           domain.CreateWebhookRequest{URL: "https://merchant.example.com/hooks"},
+			expectedError: apperrors.ErrInvalidInput,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			webhookRepo := new(mockWebhookRepository)
+			webhookRepo.On("CreateSubscription", mock.Anything, mock.AnythingOfType("*domain.WebhookSubscription")).Return(nil).Maybe()
+
+			uc := usecase.NewWebhookUsecase(webhookRepo, tc.allowInsecure)
+			subscription, err := uc.CreateSubscription(context.Background(), 1, tc.req)
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				webhookRepo.AssertNotCalled(t, "CreateSubscription", mock.Anything, mock.Anything)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, int64(1), subscription.UserID)
+				assert.True(t, strings.HasPrefix(subscription.Secret, "whsec_"))
+			}
+		})
+	}
+}
+
+func TestListSubscriptions_HidesSecrets(t *testing.T) {
+	webhookRepo := new(mockWebhookRepository)
+	webhookRepo.On("ListSubscriptions", mock.Anything, int64(1)).Return([]*domain.WebhookSubscription{
+		{ID: 1, UserID: 1, URL: "https://merchant.example.com/hooks", Secret: "whsec_abc"},
+	}, nil)
+
+	uc := usecase.NewWebhookUsecase(webhookRepo, false)
+	subscriptions, err := uc.ListSubscriptions(context.Background(), 1)
+
+	assert.NoError(t, err)
+	assert.Len(t, subscriptions, 1)
+	assert.Empty(t, subscriptions[0].Secret)
+}
+
+func TestRedeliver(t *testing.T) {
+	subscription := &domain.WebhookSubscription{ID: 5, UserID: 1}
+
+	t.Run("resets a dead delivery", func(t *testing.T) {
+		webhookRepo := new(mockWebhookRepository)
+		webhookRepo.On("GetSubscription", mock.Anything, int64(5)).Return(subscription, nil)
+		webhookRepo.On("GetDelivery", mock.Anything, int64(9)).Return(&domain.WebhookDelivery{
+			ID:             9,
+			SubscriptionID: 5,
+			Status:         domain.DeliveryDead,
+			Attempts:       10,
+		}, nil)
+		webhookRepo.On("UpdateDelivery", mock.Anything, mock.MatchedBy(func(delivery *domain.WebhookDelivery) bool {
+			return delivery.Status == domain.DeliveryPending && delivery.Attempts == 0
+		})).Return(nil)
+
+		uc := usecase.NewWebhookUsecase(webhookRepo, false)
+		delivery, err := uc.Redeliver(context.Background(), 1, 5, 9)
+
+		assert.NoError(t, err)
+		assert.Equal(t, domain.DeliveryPending, delivery.Status)
+		webhookRepo.AssertExpectations(t)
+	})
+
+	t.Run("another user's webhook", func(t *testing.T) {
+		webhookRepo := new(mockWebhookRepository)
+		webhookRepo.On("GetSubscription", mock.Anything, int64(5)).Return(subscription, nil)
+
+		uc := usecase.NewWebhookUsecase(webhookRepo, false)
+		_, err := uc.Redeliver(context.Background(), 2, 5, 9)
+
+		assert.ErrorIs(t, err, apperrors.ErrForbidden)
+	})
+
+	t.Run("delivery of a different webhook", func(t *testing.T) {
+		webhookRepo := new(mockWebhookRepository)
+		webhookRepo.On("GetSubscription", mock.Anything, int64(5)).Return(subscription, nil)
+		webhookRepo.On("GetDelivery", mock.Anything, int64(9)).Return(&domain.WebhookDelivery{ID: 9, SubscriptionID: 6}, nil)
+
+		uc := usecase.NewWebhookUsecase(webhookRepo, false)
+		_, err := uc.Redeliver(context.Background(), 1, 5, 9)
+
+		assert.ErrorIs(t, err, apperrors.ErrResourceNotFound)
+	})
+}
@@ -0,0 +1,16 @@
+package worker
+
+import "time"
+
+// backoff returns how long to wait before retry number attempt (starting at 1),
+// doubling from base and capped at max
+func backoff(attempt int, base, max time.Duration) time.Duration {
+	delay := base
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= max {
+			return max
+		}
+	}
+	return delay
+}
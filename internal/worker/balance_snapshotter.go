@@ -0,0 +1,124 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	"github.com/ravindu/wallet-app-service/pkg/logging"
+)
+
+// snapshotSettle is how long after midnight a day is snapshotted, so transfers
+// that were in flight at midnight have committed first
+const snapshotSettle = 5 * time.Minute
+
+// BalanceSnapshotterOptions tunes the daily balance snapshots
+type BalanceSnapshotterOptions struct {
+	// PollInterval is how often the snapshotter checks for a finished day
+	PollInterval time.Duration
+	// BatchSize caps the wallets snapshotted per query
+	BatchSize int
+	// MaxBackfillDays caps how many missed days are caught up on, counting back
+	// from the last finished day
+	MaxBackfillDays int
+}
+
+// BalanceSnapshotter records every wallet's balance at the end of each UTC
+// day. Snapshots already taken are kept, so replicas running one each only
+// repeat work, and a day cut short by a restart is finished on the next run.
+type BalanceSnapshotter struct {
+	balanceUsecase domain.BalanceUsecase
+	opts           BalanceSnapshotterOptions
+	logger         *logging.Logger
+
+	// doneThrough is the last day this process snapshotted in full
+	doneThrough time.Time
+}
+
+// NewBalanceSnapshotter creates a snapshotter, filling in defaults for unset options
+func NewBalanceSnapshotter(balanceUsecase domain.BalanceUsecase, opts BalanceSnapshotterOptions) *BalanceSnapshotter {
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = time.Hour
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 500
+	}
+	if opts.MaxBackfillDays <= 0 {
+		opts.MaxBackfillDays = 7
+	}
+
+	return &BalanceSnapshotter{
+		balanceUsecase: balanceUsecase,
+		opts:           opts,
+		logger:         logging.NewLogger(),
+	}
+}
+
+// Run snapshots each day as it ends until ctx is cancelled
+func (s *BalanceSnapshotter) Run(ctx context.Context) {
+	s.logger.Info(ctx, "Balance snapshotter started")
+
+	ticker := time.NewTicker(s.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Snapshot(ctx); err != nil && ctx.Err() == nil {
+			s.logger.Error(ctx, "Balance snapshot failed: "+err.Error())
+		}
+
+		select {
+		case <-ctx.Done():
+			s.logger.Info(ctx, "Balance snapshotter stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Snapshot takes the snapshots of every finished day not yet covered
+func (s *BalanceSnapshotter) Snapshot(ctx context.Context) error {
+	target := domain.SnapshotDay(time.Now().Add(-snapshotSettle))
+	if !s.doneThrough.IsZero() && !s.doneThrough.Before(target) {
+		return nil
+	}
+
+	start, err := s.firstDay(ctx, target)
+	if err != nil {
+		return err
+	}
+
+	for day := start; !day.After(target); day = day.AddDate(0, 0, 1) {
+		count, err := s.balanceUsecase.SnapshotBalances(ctx, day, s.opts.BatchSize)
+		if err != nil {
+			return err
+		}
+		s.doneThrough = day
+		s.logger.Info(ctx, fmt.Sprintf("Snapshotted %d wallet balances as of %s", count, day.Format(time.DateOnly)))
+	}
+
+	return nil
+}
+
+// firstDay picks up after the last day this process finished. On startup it
+// repeats the latest day in the table, which a restart may have cut short.
+func (s *BalanceSnapshotter) firstDay(ctx context.Context, target time.Time) (time.Time, error) {
+	earliest := target.AddDate(0, 0, 1-s.opts.MaxBackfillDays)
+
+	start := s.doneThrough.AddDate(0, 0, 1)
+	if s.doneThrough.IsZero() {
+		latest, err := s.balanceUsecase.LatestSnapshot(ctx)
+		if err != nil {
+			return time.Time{}, err
+		}
+		start = target
+		if !latest.IsZero() {
+			start = domain.SnapshotDay(latest)
+		}
+	}
+
+	if start.Before(earliest) {
+		start = earliest
+	}
+	return start, nil
+}
@@ -0,0 +1,114 @@
+package worker_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	"github.com/ravindu/wallet-app-service/internal/worker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockBalanceUsecase struct {
+	mock.Mock
+}
+
+func (m *mockBalanceUsecase) GetBalanceAt(ctx context.Context, userID int64, selector domain.WalletSelector, at time.Time) (*domain.HistoricalBalance, error) {
+	args := m.Called(ctx, userID, selector, at)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.HistoricalBalance), args.Error(1)
+}
+
+func (m *mockBalanceUsecase) SnapshotBalances(ctx context.Context, asOf time.Time, batchSize int) (int, error) {
+	args := m.Called(ctx, asOf, batchSize)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *mockBalanceUsecase) LatestSnapshot(ctx context.Context) (time.Time, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(time.Time), args.Error(1)
+}
+
+// snapshottedDays lists the days SnapshotBalances was called for, in order
+func snapshottedDays(m *mockBalanceUsecase) []time.Time {
+	var days []time.Time
+	for _, call := range m.Calls {
+		if call.Method == "SnapshotBalances" {
+			days = append(days, call.Arguments.Get(1).(time.Time))
+		}
+	}
+	return days
+}
+
+func TestBalanceSnapshotter_Snapshot(t *testing.T) {
+	ctx := context.Background()
+	// The day that ended most recently, allowing for the settling time
+	today := domain.SnapshotDay(time.Now().Add(-5 * time.Minute))
+
+	tests := []struct {
+		name         string
+		latest       time.Time
+		expectedDays []time.Time
+	}{
+		{
+			name:         "first run snapshots the day that just ended",
+			latest:       time.Time{},
+			expectedDays: []time.Time{today},
+		},
+		{
+			name:         "restart repeats the latest day and catches up",
+			latest:       today.AddDate(0, 0, -2),
+			expectedDays: []time.Time{today.AddDate(0, 0, -2), today.AddDate(0, 0, -1), today},
+		},
+		{
+			name:         "long outage is capped to the backfill window",
+			latest:       today.AddDate(0, 0, -30),
+			expectedDays: []time.Time{today.AddDate(0, 0, -2), today.AddDate(0, 0, -1), today},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			balanceUsecase := new(mockBalanceUsecase)
+			balanceUsecase.On("LatestSnapshot", ctx).Return(tc.latest, nil).Once()
+			balanceUsecase.On("SnapshotBalances", ctx, mock.Anything, 100).Return(3, nil)
+
+			snapshotter := worker.NewBalanceSnapshotter(balanceUsecase, worker.BalanceSnapshotterOptions{
+				BatchSize:       100,
+				MaxBackfillDays: 3,
+			})
+
+			assert.NoError(t, snapshotter.Snapshot(ctx))
+			assert.Equal(t, tc.expectedDays, snapshottedDays(balanceUsecase))
+
+			// The day is done, so polling again before the next one ends does nothing
+			assert.NoError(t, snapshotter.Snapshot(ctx))
+			assert.Len(t, snapshottedDays(balanceUsecase), len(tc.expectedDays))
+			balanceUsecase.AssertExpectations(t)
+		})
+	}
+}
+
+func TestBalanceSnapshotter_RetriesFailedDay(t *testing.T) {
+	ctx := context.Background()
+	today := domain.SnapshotDay(time.Now().Add(-5 * time.Minute))
+	yesterday := today.AddDate(0, 0, -1)
+
+	balanceUsecase := new(mockBalanceUsecase)
+	balanceUsecase.On("LatestSnapshot", ctx).Return(yesterday, nil).Once()
+	balanceUsecase.On("SnapshotBalances", ctx, yesterday, 100).Return(3, nil).Once()
+	balanceUsecase.On("SnapshotBalances", ctx, today, 100).Return(1, errors.New("connection reset")).Once()
+	balanceUsecase.On("SnapshotBalances", ctx, today, 100).Return(3, nil).Once()
+
+	snapshotter := worker.NewBalanceSnapshotter(balanceUsecase, worker.BalanceSnapshotterOptions{BatchSize: 100})
+
+	assert.Error(t, snapshotter.Snapshot(ctx))
+	assert.NoError(t, snapshotter.Snapshot(ctx))
+	assert.Equal(t, []time.Time{yesterday, today, today}, snapshottedDays(balanceUsecase))
+	balanceUsecase.AssertExpectations(t)
+}
@@ -0,0 +1,54 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	"github.com/ravindu/wallet-app-service/pkg/logging"
+)
+
+// BatchProcessorOptions tunes how often submitted transfer batches are picked up
+type BatchProcessorOptions struct {
+	// PollInterval is how often waiting batches are looked for
+	PollInterval time.Duration
+	// BatchSize caps the transfer batches processed per poll
+	BatchSize int
+}
+
+// BatchProcessor works through submitted transfer batches. Every replica can
+// run one; batches are leased in the database, so each is worked through by
+// one processor at a time.
+type BatchProcessor struct {
+	batchUsecase domain.TransferBatchUsecase
+	opts         BatchProcessorOptions
+	logger       *logging.Logger
+}
+
+// NewBatchProcessor creates a processor, filling in defaults for unset options
+func NewBatchProcessor(batchUsecase domain.TransferBatchUsecase, opts BatchProcessorOptions) *BatchProcessor {
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = 5 * time.Second
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 10
+	}
+
+	return &BatchProcessor{
+		batchUsecase: batchUsecase,
+		opts:         opts,
+		logger:       logging.NewLogger(),
+	}
+}
+
+// Run processes waiting transfer batches until ctx is cancelled
+func (p *BatchProcessor) Run(ctx context.Context) {
+	runPolling(ctx, p.logger, "Transfer batch processor", p.opts.PollInterval, p.opts.BatchSize, func(ctx context.Context) (int, error) {
+		processed, err := p.batchUsecase.ProcessBatches(ctx, p.opts.BatchSize)
+		if processed > 0 {
+			p.logger.Info(ctx, fmt.Sprintf("Processed %d transfer batches", processed))
+		}
+		return processed, err
+	})
+}
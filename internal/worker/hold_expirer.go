@@ -0,0 +1,54 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	"github.com/ravindu/wallet-app-service/pkg/logging"
+)
+
+// HoldExpirerOptions tunes how often stale holds are swept
+type HoldExpirerOptions struct {
+	// PollInterval is how often expired holds are looked for
+	PollInterval time.Duration
+	// BatchSize caps the holds ended per poll
+	BatchSize int
+}
+
+// HoldExpirer releases holds that ran out before being captured or voided.
+// Every replica can run one; each hold is locked while it is ended, so a hold
+// is only expired once.
+type HoldExpirer struct {
+	holdUsecase domain.HoldUsecase
+	opts        HoldExpirerOptions
+	logger      *logging.Logger
+}
+
+// NewHoldExpirer creates an expirer, filling in defaults for unset options
+func NewHoldExpirer(holdUsecase domain.HoldUsecase, opts HoldExpirerOptions) *HoldExpirer {
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = time.Minute
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+
+	return &HoldExpirer{
+		holdUsecase: holdUsecase,
+		opts:        opts,
+		logger:      logging.NewLogger(),
+	}
+}
+
+// Run expires holds until ctx is cancelled
+func (e *HoldExpirer) Run(ctx context.Context) {
+	runPolling(ctx, e.logger, "Hold expirer", e.opts.PollInterval, e.opts.BatchSize, func(ctx context.Context) (int, error) {
+		expired, err := e.holdUsecase.ExpireHolds(ctx, e.opts.BatchSize)
+		if expired > 0 {
+			e.logger.Info(ctx, fmt.Sprintf("Expired %d holds", expired))
+		}
+		return expired, err
+	})
+}
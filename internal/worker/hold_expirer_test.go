@@ -0,0 +1,47 @@
+package worker_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	"github.com/ravindu/wallet-app-service/internal/worker"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockHoldUsecase struct {
+	mock.Mock
+	domain.HoldUsecase
+}
+
+func (m *mockHoldUsecase) ExpireHolds(ctx context.Context, limit int) (int, error) {
+	args := m.Called(ctx, limit)
+	return args.Int(0), args.Error(1)
+}
+
+func TestHoldExpirer_Run(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// A full batch is followed straight away by another poll, well before the
+	// hour-long interval; a short one waits, and here the worker is stopped
+	holdUsecase := new(mockHoldUsecase)
+	holdUsecase.On("ExpireHolds", mock.Anything, 2).Return(2, nil).Once()
+	holdUsecase.On("ExpireHolds", mock.Anything, 2).Run(func(mock.Arguments) { cancel() }).Return(1, nil).Once()
+
+	expirer := worker.NewHoldExpirer(holdUsecase, worker.HoldExpirerOptions{PollInterval: time.Hour, BatchSize: 2})
+
+	done := make(chan struct{})
+	go func() {
+		expirer.Run(ctx)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expirer did not poll again after a full batch")
+	}
+	holdUsecase.AssertExpectations(t)
+}
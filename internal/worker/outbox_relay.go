@@ -0,0 +1,137 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	"github.com/ravindu/wallet-app-service/pkg/logging"
+)
+
+// OutboxRelayOptions tunes how the relay polls and retries
+type OutboxRelayOptions struct {
+	// PollInterval is how often the outbox is checked for new events
+	PollInterval time.Duration
+	// BatchSize caps the events relayed per poll
+	BatchSize int
+	// PublishTimeout bounds a single publish call
+	PublishTimeout time.Duration
+	// RetryBase and RetryMax shape the exponential backoff after a failed publish
+	RetryBase time.Duration
+	RetryMax  time.Duration
+}
+
+// OutboxRelay publishes outbox events with at-least-once delivery, in order per wallet.
+// Every replica can run one; an advisory lock lets only one relay at a time.
+type OutboxRelay struct {
+	outboxRepo domain.OutboxRepository
+	unitOfWork domain.UnitOfWork
+	publisher  domain.EventPublisher
+	opts       OutboxRelayOptions
+	logger     *logging.Logger
+}
+
+// NewOutboxRelay creates a relay, filling in defaults for unset options
+func NewOutboxRelay(
+	outboxRepo domain.OutboxRepository,
+	unitOfWork domain.UnitOfWork,
+	publisher domain.EventPublisher,
+	opts OutboxRelayOptions,
+) *OutboxRelay {
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = time.Second
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+	if opts.PublishTimeout <= 0 {
+		opts.PublishTimeout = 10 * time.Second
+	}
+	if opts.RetryBase <= 0 {
+		opts.RetryBase = time.Second
+	}
+	if opts.RetryMax <= 0 {
+		opts.RetryMax = 5 * time.Minute
+	}
+
+	return &OutboxRelay{
+		outboxRepo: outboxRepo,
+		unitOfWork: unitOfWork,
+		publisher:  publisher,
+		opts:       opts,
+		logger:     logging.NewLogger(),
+	}
+}
+
+// Run relays events until ctx is cancelled
+func (r *OutboxRelay) Run(ctx context.Context) {
+	runPolling(ctx, r.logger, "Outbox relay", r.opts.PollInterval, r.opts.BatchSize, r.RelayOnce)
+}
+
+// RelayOnce publishes one batch of pending events and returns how many went out.
+// Published and failed marks commit together once the batch is done, so a crash
+// part way through republishes the batch rather than losing events.
+func (r *OutboxRelay) RelayOnce(ctx context.Context) (int, error) {
+	published := 0
+
+	err := r.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		locked, err := r.outboxRepo.TryLock(ctx)
+		if err != nil {
+			return err
+		}
+		if !locked {
+			// Another replica is relaying
+			return nil
+		}
+
+		events, err := r.outboxRepo.FetchPending(ctx, r.opts.BatchSize)
+		if err != nil {
+			return err
+		}
+
+		// Once an event of a wallet fails, hold back the rest of that wallet's
+		// events so they are never delivered ahead of it
+		blocked := make(map[string]bool)
+
+		for _, event := range events {
+			aggregate := fmt.Sprintf("%s:%d", event.AggregateType, event.AggregateID)
+			if blocked[aggregate] {
+				continue
+			}
+
+			if err := r.publish(ctx, event); err != nil {
+				blocked[aggregate] = true
+
+				retryAt := time.Now().Add(backoff(event.Attempts+1, r.opts.RetryBase, r.opts.RetryMax))
+				r.logger.Warn(ctx, fmt.Sprintf("Failed to publish event %d (%s), retrying at %s: %v",
+					event.ID, event.Type, retryAt.Format(time.RFC3339), err))
+
+				if err := r.outboxRepo.MarkFailed(ctx, event.ID, err.Error(), retryAt); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if err := r.outboxRepo.MarkPublished(ctx, event.ID); err != nil {
+				return err
+			}
+			published++
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return published, nil
+}
+
+// publish sends one event, bounded by PublishTimeout
+func (r *OutboxRelay) publish(ctx context.Context, event *domain.OutboxEvent) error {
+	ctx, cancel := context.WithTimeout(ctx, r.opts.PublishTimeout)
+	defer cancel()
+
+	return r.publisher.Publish(ctx, event)
+}
@@ -0,0 +1,168 @@
+package worker_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	"github.com/ravindu/wallet-app-service/internal/worker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockOutboxRepository struct {
+	mock.Mock
+}
+
+func (m *mockOutboxRepository) Create(ctx context.Context, event *domain.OutboxEvent) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
+
+func (m *mockOutboxRepository) TryLock(ctx context.Context) (bool, error) {
+	args := m.Called(ctx)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockOutboxRepository) FetchPending(ctx context.Context, limit int) ([]*domain.OutboxEvent, error) {
+	args := m.Called(ctx, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.OutboxEvent), args.Error(1)
+}
+
+func (m *mockOutboxRepository) MarkPublished(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *mockOutboxRepository) MarkFailed(ctx context.Context, id int64, reason string, nextAttemptAt time.Time) error {
+	args := m.Called(ctx, id, reason, nextAttemptAt)
+	return args.Error(0)
+}
+
+// recordingPublisher remembers what it published and fails for chosen event IDs
+type recordingPublisher struct {
+	failIDs   map[int64]bool
+	published []int64
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, event *domain.OutboxEvent) error {
+	if p.failIDs[event.ID] {
+		return errors.New("broker unavailable")
+	}
+	p.published = append(p.published, event.ID)
+	return nil
+}
+
+// mockUnitOfWork runs the unit inline, standing in for a database transaction
+type mockUnitOfWork struct{}
+
+func (m *mockUnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func walletEvent(id, walletID int64, attempts int) *domain.OutboxEvent {
+	return &domain.OutboxEvent{
+		ID:            id,
+		AggregateType: domain.WalletAggregate,
+		AggregateID:   walletID,
+		Type:          domain.WalletCredited,
+		Payload:       []byte(`{}`),
+		Attempts:      attempts,
+	}
+}
+
+func TestOutboxRelay_RelayOnce(t *testing.T) {
+	opts := worker.OutboxRelayOptions{
+		BatchSize: 10,
+		RetryBase: time.Second,
+		RetryMax:  time.Minute,
+	}
+
+	t.Run("publishes events in order", func(t *testing.T) {
+		outboxRepo := new(mockOutboxRepository)
+		outboxRepo.On("TryLock", mock.Anything).Return(true, nil)
+		outboxRepo.On("FetchPending", mock.Anything, 10).Return([]*domain.OutboxEvent{
+			walletEvent(1, 1, 0),
+			walletEvent(2, 2, 0),
+			walletEvent(3, 1, 0),
+		}, nil)
+		outboxRepo.On("MarkPublished", mock.Anything, mock.Anything).Return(nil)
+
+		publisher := &recordingPublisher{}
+		relay := worker.NewOutboxRelay(outboxRepo, &mockUnitOfWork{}, publisher, opts)
+
+		published, err := relay.RelayOnce(context.Background())
+
+		assert.NoError(t, err)
+		assert.Equal(t, 3, published)
+		assert.Equal(t, []int64{1, 2, 3}, publisher.published)
+		outboxRepo.AssertNumberOfCalls(t, "MarkPublished", 3)
+	})
+
+	t.Run("a failure holds back later events of the same wallet only", func(t *testing.T) {
+		outboxRepo := new(mockOutboxRepository)
+		outboxRepo.On("TryLock", mock.Anything).Return(true, nil)
+		outboxRepo.On("FetchPending", mock.Anything, 10).Return([]*domain.OutboxEvent{
+			walletEvent(1, 1, 2),
+			walletEvent(2, 2, 0),
+			walletEvent(3, 1, 0),
+		}, nil)
+		outboxRepo.On("MarkPublished", mock.Anything, int64(2)).Return(nil)
+
+		// Third attempt backs off for base * 2^2
+		before := time.Now()
+		outboxRepo.On("MarkFailed", mock.Anything, int64(1), "broker unavailable", mock.MatchedBy(func(retryAt time.Time) bool {
+			delay := retryAt.Sub(before)
+			return delay >= 4*time.Second && delay < 5*time.Second
+		})).Return(nil)
+
+		publisher := &recordingPublisher{failIDs: map[int64]bool{1: true}}
+		relay := worker.NewOutboxRelay(outboxRepo, &mockUnitOfWork{}, publisher, opts)
+
+		published, err := relay.RelayOnce(context.Background())
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, published)
+		assert.Equal(t, []int64{2}, publisher.published)
+		outboxRepo.AssertExpectations(t)
+	})
+
+	t.Run("backoff is capped", func(t *testing.T) {
+		outboxRepo := new(mockOutboxRepository)
+		outboxRepo.On("TryLock", mock.Anything).Return(true, nil)
+		outboxRepo.On("FetchPending", mock.Anything, 10).Return([]*domain.OutboxEvent{walletEvent(1, 1, 30)}, nil)
+
+		before := time.Now()
+		outboxRepo.On("MarkFailed", mock.Anything, int64(1), mock.Anything, mock.MatchedBy(func(retryAt time.Time) bool {
+			delay := retryAt.Sub(before)
+			return delay >= time.Minute && delay < time.Minute+time.Second
+		})).Return(nil)
+
+		publisher := &recordingPublisher{failIDs: map[int64]bool{1: true}}
+		relay := worker.NewOutboxRelay(outboxRepo, &mockUnitOfWork{}, publisher, opts)
+
+		_, err := relay.RelayOnce(context.Background())
+
+		assert.NoError(t, err)
+		outboxRepo.AssertExpectations(t)
+	})
+
+	t.Run("another replica holds the lock", func(t *testing.T) {
+		outboxRepo := new(mockOutboxRepository)
+		outboxRepo.On("TryLock", mock.Anything).Return(false, nil)
+
+		publisher := &recordingPublisher{}
+		relay := worker.NewOutboxRelay(outboxRepo, &mockUnitOfWork{}, publisher, opts)
+
+		published, err := relay.RelayOnce(context.Background())
+
+		assert.NoError(t, err)
+		assert.Zero(t, published)
+		outboxRepo.AssertNotCalled(t, "FetchPending", mock.Anything, mock.Anything)
+	})
+}
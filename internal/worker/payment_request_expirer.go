@@ -0,0 +1,54 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	"github.com/ravindu/wallet-app-service/pkg/logging"
+)
+
+// PaymentRequestExpirerOptions tunes how often stale payment requests are swept
+type PaymentRequestExpirerOptions struct {
+	// PollInterval is how often expired requests are looked for
+	PollInterval time.Duration
+	// BatchSize caps the requests ended per poll
+	BatchSize int
+}
+
+// PaymentRequestExpirer closes payment requests that ran out before the payer
+// answered them. Every replica can run one; each request is locked while it is
+// ended, so a request is only expired once.
+type PaymentRequestExpirer struct {
+	requestUsecase domain.PaymentRequestUsecase
+	opts           PaymentRequestExpirerOptions
+	logger         *logging.Logger
+}
+
+// NewPaymentRequestExpirer creates an expirer, filling in defaults for unset options
+func NewPaymentRequestExpirer(requestUsecase domain.PaymentRequestUsecase, opts PaymentRequestExpirerOptions) *PaymentRequestExpirer {
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = time.Minute
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+
+	return &PaymentRequestExpirer{
+		requestUsecase: requestUsecase,
+		opts:           opts,
+		logger:         logging.NewLogger(),
+	}
+}
+
+// Run expires payment requests until ctx is cancelled
+func (e *PaymentRequestExpirer) Run(ctx context.Context) {
+	runPolling(ctx, e.logger, "Payment request expirer", e.opts.PollInterval, e.opts.BatchSize, func(ctx context.Context) (int, error) {
+		expired, err := e.requestUsecase.ExpireRequests(ctx, e.opts.BatchSize)
+		if expired > 0 {
+			e.logger.Info(ctx, fmt.Sprintf("Expired %d payment requests", expired))
+		}
+		return expired, err
+	})
+}
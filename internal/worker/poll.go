@@ -0,0 +1,44 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/ravindu/wallet-app-service/pkg/logging"
+)
+
+// runPolling calls step every interval until ctx is cancelled. step returns how
+// many items it handled; when that is a full batchSize it runs again straight
+// away, since there is probably more waiting. name starts the log lines for the
+// worker starting, stopping and failing.
+func runPolling(
+	ctx context.Context,
+	logger *logging.Logger,
+	name string,
+	interval time.Duration,
+	batchSize int,
+	step func(ctx context.Context) (int, error),
+) {
+	logger.Info(ctx, name+" started")
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		handled, err := step(ctx)
+		if err != nil && ctx.Err() == nil {
+			logger.Error(ctx, name+" failed: "+err.Error())
+		}
+
+		if handled == batchSize && ctx.Err() == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			logger.Info(ctx, name+" stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	"github.com/ravindu/wallet-app-service/pkg/logging"
+)
+
+// ReconcilerOptions tunes the scheduled reconciliation
+type ReconcilerOptions struct {
+	// Interval is the time between reconciliation runs
+	Interval time.Duration
+	// AutoFix resets balances that only disagree with an intact history
+	AutoFix bool
+	// BatchSize caps the wallet IDs read per query
+	BatchSize int
+}
+
+// Reconciler checks every wallet against its transactions and ledger on a
+// schedule and logs the report whenever something is wrong. Fixes are checked
+// again under the wallet's lock, so replicas running one each can't clash.
+type Reconciler struct {
+	reconciliationUsecase domain.ReconciliationUsecase
+	opts                  ReconcilerOptions
+	logger                *logging.Logger
+}
+
+// NewReconciler creates a reconciler, filling in defaults for unset options
+func NewReconciler(reconciliationUsecase domain.ReconciliationUsecase, opts ReconcilerOptions) *Reconciler {
+	if opts.Interval <= 0 {
+		opts.Interval = 24 * time.Hour
+	}
+
+	return &Reconciler{
+		reconciliationUsecase: reconciliationUsecase,
+		opts:                  opts,
+		logger:                logging.NewLogger(),
+	}
+}
+
+// Run reconciles once per interval until ctx is cancelled
+func (r *Reconciler) Run(ctx context.Context) {
+	r.logger.Info(ctx, "Reconciler started")
+
+	ticker := time.NewTicker(r.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			r.logger.Info(ctx, "Reconciler stopped")
+			return
+		case <-ticker.C:
+		}
+
+		if _, err := r.ReconcileOnce(ctx); err != nil && ctx.Err() == nil {
+			r.logger.Error(ctx, "Reconciliation failed: "+err.Error())
+		}
+	}
+}
+
+// ReconcileOnce runs one reconciliation and logs what it found
+func (r *Reconciler) ReconcileOnce(ctx context.Context) (*domain.ReconciliationReport, error) {
+	report, err := r.reconciliationUsecase.Reconcile(ctx, domain.ReconcileOptions{
+		AutoFix:   r.opts.AutoFix,
+		BatchSize: r.opts.BatchSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	summary := fmt.Sprintf("Reconciled %d wallets and %d transactions: %d discrepancies, %d fixed",
+		report.WalletsChecked, report.TransactionsChecked, len(report.Discrepancies), report.Fixed)
+	if len(report.Discrepancies) == 0 {
+		r.logger.Info(ctx, summary)
+		return report, nil
+	}
+
+	body, err := json.Marshal(report)
+	if err != nil {
+		return nil, err
+	}
+	r.logger.Warn(ctx, summary+": "+string(body))
+	return report, nil
+}
@@ -0,0 +1,49 @@
+package worker_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	"github.com/ravindu/wallet-app-service/internal/worker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockReconciliationUsecase struct {
+	mock.Mock
+}
+
+func (m *mockReconciliationUsecase) Reconcile(ctx context.Context, opts domain.ReconcileOptions) (*domain.ReconciliationReport, error) {
+	args := m.Called(ctx, opts)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ReconciliationReport), args.Error(1)
+}
+
+func TestReconciler_ReconcileOnce(t *testing.T) {
+	ctx := context.Background()
+	report := &domain.ReconciliationReport{
+		WalletsChecked: 2,
+		Discrepancies: []*domain.Discrepancy{
+			{WalletID: 7, Kind: domain.DiscrepancyBalanceMismatch, Fixed: true},
+		},
+		Fixed: 1,
+	}
+
+	reconciliationUsecase := new(mockReconciliationUsecase)
+	reconciliationUsecase.On("Reconcile", ctx, domain.ReconcileOptions{AutoFix: true, BatchSize: 50}).Return(report, nil).Once()
+	reconciliationUsecase.On("Reconcile", ctx, domain.ReconcileOptions{AutoFix: true, BatchSize: 50}).Return(nil, errors.New("connection reset")).Once()
+
+	reconciler := worker.NewReconciler(reconciliationUsecase, worker.ReconcilerOptions{AutoFix: true, BatchSize: 50})
+
+	got, err := reconciler.ReconcileOnce(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, report, got)
+
+	_, err = reconciler.ReconcileOnce(ctx)
+	assert.Error(t, err)
+	reconciliationUsecase.AssertExpectations(t)
+}
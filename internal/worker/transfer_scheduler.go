@@ -0,0 +1,54 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	"github.com/ravindu/wallet-app-service/pkg/logging"
+)
+
+// TransferSchedulerOptions tunes how often due scheduled transfers are run
+type TransferSchedulerOptions struct {
+	// PollInterval is how often due schedules are looked for
+	PollInterval time.Duration
+	// BatchSize caps the occurrences run per poll
+	BatchSize int
+}
+
+// TransferScheduler runs scheduled transfers as they fall due. Every replica
+// can run one; due schedules are leased in the database, so each occurrence
+// is run by one scheduler.
+type TransferScheduler struct {
+	scheduleUsecase domain.ScheduledTransferUsecase
+	opts            TransferSchedulerOptions
+	logger          *logging.Logger
+}
+
+// NewTransferScheduler creates a scheduler, filling in defaults for unset options
+func NewTransferScheduler(scheduleUsecase domain.ScheduledTransferUsecase, opts TransferSchedulerOptions) *TransferScheduler {
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = 30 * time.Second
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 50
+	}
+
+	return &TransferScheduler{
+		scheduleUsecase: scheduleUsecase,
+		opts:            opts,
+		logger:          logging.NewLogger(),
+	}
+}
+
+// Run runs due scheduled transfers until ctx is cancelled
+func (s *TransferScheduler) Run(ctx context.Context) {
+	runPolling(ctx, s.logger, "Transfer scheduler", s.opts.PollInterval, s.opts.BatchSize, func(ctx context.Context) (int, error) {
+		ran, err := s.scheduleUsecase.RunDueSchedules(ctx, s.opts.BatchSize)
+		if ran > 0 {
+			s.logger.Info(ctx, fmt.Sprintf("Ran %d scheduled transfers", ran))
+		}
+		return ran, err
+	})
+}
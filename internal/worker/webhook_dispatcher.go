@@ -0,0 +1,195 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	"github.com/ravindu/wallet-app-service/pkg/logging"
+	"github.com/ravindu/wallet-app-service/pkg/webhook"
+)
+
+// Headers sent with every webhook delivery, next to webhook.SignatureHeader
+const (
+	webhookIDHeader    = "Webhook-Id"
+	webhookEventHeader = "Webhook-Event"
+)
+
+// maxWebhookResponse caps how much of an endpoint's response is read
+const maxWebhookResponse = 64 << 10
+
+// WebhookDispatcherOptions tunes delivery and retries
+type WebhookDispatcherOptions struct {
+	// PollInterval is how often due deliveries are looked for
+	PollInterval time.Duration
+	// BatchSize caps the deliveries claimed per poll
+	BatchSize int
+	// Timeout bounds a single HTTP attempt
+	Timeout time.Duration
+	// MaxAttempts is how many failed attempts move a delivery to DEAD
+	MaxAttempts int
+	// RetryBase and RetryMax shape the exponential backoff between attempts
+	RetryBase time.Duration
+	RetryMax  time.Duration
+}
+
+// WebhookDispatcher posts signed webhook deliveries and retries failures with
+// exponential backoff. Several replicas can run one; claimed deliveries are
+// leased so each attempt is made by one dispatcher.
+type WebhookDispatcher struct {
+	webhookRepo domain.WebhookRepository
+	client      *http.Client
+	opts        WebhookDispatcherOptions
+	logger      *logging.Logger
+}
+
+// NewWebhookDispatcher creates a dispatcher, filling in defaults for unset options
+func NewWebhookDispatcher(webhookRepo domain.WebhookRepository, client *http.Client, opts WebhookDispatcherOptions) *WebhookDispatcher {
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = time.Second
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 50
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 10
+	}
+	if opts.RetryBase <= 0 {
+		opts.RetryBase = 10 * time.Second
+	}
+	if opts.RetryMax <= 0 {
+		opts.RetryMax = 6 * time.Hour
+	}
+	if client == nil {
+		client = webhook.NewClient(false)
+	}
+
+	return &WebhookDispatcher{
+		webhookRepo: webhookRepo,
+		client:      client,
+		opts:        opts,
+		logger:      logging.NewLogger(),
+	}
+}
+
+// Run dispatches deliveries until ctx is cancelled
+func (d *WebhookDispatcher) Run(ctx context.Context) {
+	runPolling(ctx, d.logger, "Webhook dispatcher", d.opts.PollInterval, d.opts.BatchSize, d.DispatchOnce)
+}
+
+// DispatchOnce makes one attempt for each due delivery, up to BatchSize, and
+// returns how many were attempted. Deliveries are claimed one at a time so each
+// lease only has to cover its own attempt.
+func (d *WebhookDispatcher) DispatchOnce(ctx context.Context) (int, error) {
+	sent := 0
+	for sent < d.opts.BatchSize && ctx.Err() == nil {
+		// Lease for longer than an attempt can take, so the lease never runs out mid-attempt
+		leaseUntil := time.Now().Add(2 * d.opts.Timeout)
+
+		jobs, err := d.webhookRepo.ClaimDueDeliveries(ctx, 1, leaseUntil)
+		if err != nil {
+			return sent, err
+		}
+		if len(jobs) == 0 {
+			break
+		}
+
+		job := jobs[0]
+		d.attempt(ctx, job)
+		sent++
+
+		saved, err := d.webhookRepo.SaveAttempt(ctx, job.Delivery, leaseUntil)
+		if err != nil {
+			// The lease runs out and the delivery is attempted again
+			return sent, err
+		}
+		if !saved {
+			d.logger.Warn(ctx, fmt.Sprintf("Lease on webhook delivery %d ran out before its outcome was saved", job.Delivery.ID))
+		}
+	}
+
+	return sent, nil
+}
+
+// attempt posts one delivery and records the outcome on it
+func (d *WebhookDispatcher) attempt(ctx context.Context, job *domain.WebhookJob) {
+	delivery := job.Delivery
+	delivery.Attempts++
+
+	statusCode, err := d.send(ctx, job)
+	if statusCode != 0 {
+		delivery.LastStatusCode = &statusCode
+	} else {
+		delivery.LastStatusCode = nil
+	}
+
+	if err == nil {
+		now := time.Now()
+		delivery.Status = domain.DeliverySucceeded
+		delivery.LastError = ""
+		delivery.DeliveredAt = &now
+		return
+	}
+
+	delivery.LastError = err.Error()
+	if delivery.Attempts >= d.opts.MaxAttempts {
+		delivery.Status = domain.DeliveryDead
+		d.logger.Warn(ctx, fmt.Sprintf("Webhook delivery %d is dead after %d attempts: %v", delivery.ID, delivery.Attempts, err))
+		return
+	}
+
+	delivery.Status = domain.DeliveryPending
+	delivery.NextAttemptAt = time.Now().Add(backoff(delivery.Attempts, d.opts.RetryBase, d.opts.RetryMax))
+}
+
+// send posts the signed envelope, returning the response status if one came back.
+// Anything but a 2xx response is an error.
+func (d *WebhookDispatcher) send(ctx context.Context, job *domain.WebhookJob) (int, error) {
+	delivery := job.Delivery
+
+	body, err := json.Marshal(domain.WebhookEnvelope{
+		ID:        delivery.EventID,
+		Type:      delivery.EventType,
+		CreatedAt: delivery.CreatedAt,
+		Data:      delivery.Payload,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode webhook body: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, d.opts.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, job.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to build webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "wallet-app-service-webhooks")
+	req.Header.Set(webhookIDHeader, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(webhookEventHeader, string(delivery.EventType))
+	req.Header.Set(webhook.SignatureHeader, webhook.Sign(job.Secret, time.Now(), body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Drain a little of the body so the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxWebhookResponse))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("webhook endpoint responded with %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
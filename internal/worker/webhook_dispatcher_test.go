@@ -0,0 +1,192 @@
+package worker_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ravindu/wallet-app-service/internal/domain"
+	"github.com/ravindu/wallet-app-service/internal/worker"
+	"github.com/ravindu/wallet-app-service/pkg/webhook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type mockWebhookRepository struct {
+	mock.Mock
+	domain.WebhookRepository
+}
+
+func (m *mockWebhookRepository) ClaimDueDeliveries(ctx context.Context, limit int, leaseUntil time.Time) ([]*domain.WebhookJob, error) {
+	args := m.Called(ctx, limit, leaseUntil)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.WebhookJob), args.Error(1)
+}
+
+func (m *mockWebhookRepository) SaveAttempt(ctx context.Context, delivery *domain.WebhookDelivery, leaseUntil time.Time) (bool, error) {
+	args := m.Called(ctx, delivery, leaseUntil)
+	return args.Bool(0), args.Error(1)
+}
+
+// expectClaims has the repository hand out jobs one claim at a time, then nothing
+func expectClaims(webhookRepo *mockWebhookRepository, jobs ...*domain.WebhookJob) {
+	for _, job := range jobs {
+		webhookRepo.On("ClaimDueDeliveries", mock.Anything, 1, mock.Anything).Return([]*domain.WebhookJob{job}, nil).Once()
+	}
+	webhookRepo.On("ClaimDueDeliveries", mock.Anything, 1, mock.Anything).Return([]*domain.WebhookJob{}, nil).Once()
+}
+
+const testWebhookSecret = "whsec_test"
+
+func webhookJob(url string, attempts int) *domain.WebhookJob {
+	return &domain.WebhookJob{
+		Delivery: &domain.WebhookDelivery{
+			ID:             9,
+			SubscriptionID: 5,
+			EventID:        42,
+			EventType:      domain.WalletCredited,
+			Payload:        json.RawMessage(`{"wallet_id":1}`),
+			Status:         domain.DeliveryPending,
+			Attempts:       attempts,
+		},
+		URL:    url,
+		Secret: testWebhookSecret,
+	}
+}
+
+func TestWebhookDispatcher_DispatchOnce(t *testing.T) {
+	opts := worker.WebhookDispatcherOptions{
+		BatchSize:   10,
+		Timeout:     time.Second,
+		MaxAttempts: 3,
+		RetryBase:   time.Second,
+		RetryMax:    time.Minute,
+	}
+
+	t.Run("posts a signed envelope", func(t *testing.T) {
+		var received domain.WebhookEnvelope
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			assert.NoError(t, webhook.Verify(testWebhookSecret, r.Header.Get(webhook.SignatureHeader), body, time.Minute, time.Now()))
+			assert.Equal(t, "wallet.credited", r.Header.Get("Webhook-Event"))
+			require.NoError(t, json.Unmarshal(body, &received))
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		job := webhookJob(server.URL, 0)
+		webhookRepo := new(mockWebhookRepository)
+		expectClaims(webhookRepo, job)
+		webhookRepo.On("SaveAttempt", mock.Anything, job.Delivery, mock.Anything).Return(true, nil)
+
+		dispatcher := worker.NewWebhookDispatcher(webhookRepo, server.Client(), opts)
+		sent, err := dispatcher.DispatchOnce(context.Background())
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, sent)
+		assert.Equal(t, domain.DeliverySucceeded, job.Delivery.Status)
+		assert.Equal(t, 1, job.Delivery.Attempts)
+		assert.NotNil(t, job.Delivery.DeliveredAt)
+		assert.Equal(t, int64(42), received.ID)
+		assert.JSONEq(t, `{"wallet_id":1}`, string(received.Data))
+	})
+
+	t.Run("failed attempt is retried with backoff", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		job := webhookJob(server.URL, 1)
+		webhookRepo := new(mockWebhookRepository)
+		expectClaims(webhookRepo, job)
+		webhookRepo.On("SaveAttempt", mock.Anything, job.Delivery, mock.Anything).Return(true, nil)
+
+		before := time.Now()
+		dispatcher := worker.NewWebhookDispatcher(webhookRepo, server.Client(), opts)
+		_, err := dispatcher.DispatchOnce(context.Background())
+
+		assert.NoError(t, err)
+		assert.Equal(t, domain.DeliveryPending, job.Delivery.Status)
+		assert.Equal(t, 2, job.Delivery.Attempts)
+		assert.Equal(t, http.StatusServiceUnavailable, *job.Delivery.LastStatusCode)
+		assert.NotEmpty(t, job.Delivery.LastError)
+
+		// Second attempt waits base * 2
+		delay := job.Delivery.NextAttemptAt.Sub(before)
+		assert.True(t, delay >= 2*time.Second && delay < 3*time.Second, "unexpected delay %s", delay)
+	})
+
+	t.Run("last attempt goes dead", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		job := webhookJob(server.URL, 2)
+		webhookRepo := new(mockWebhookRepository)
+		expectClaims(webhookRepo, job)
+		webhookRepo.On("SaveAttempt", mock.Anything, job.Delivery, mock.Anything).Return(true, nil)
+
+		dispatcher := worker.NewWebhookDispatcher(webhookRepo, server.Client(), opts)
+		_, err := dispatcher.DispatchOnce(context.Background())
+
+		assert.NoError(t, err)
+		assert.Equal(t, domain.DeliveryDead, job.Delivery.Status)
+		assert.Equal(t, 3, job.Delivery.Attempts)
+	})
+
+	t.Run("leases and saves each delivery on its own", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		first, second := webhookJob(server.URL, 0), webhookJob(server.URL, 0)
+		second.Delivery.ID = 10
+
+		var leases []time.Time
+		recordLease := func(args mock.Arguments) { leases = append(leases, args.Get(2).(time.Time)) }
+		webhookRepo := new(mockWebhookRepository)
+		webhookRepo.On("ClaimDueDeliveries", mock.Anything, 1, mock.Anything).Run(recordLease).Return([]*domain.WebhookJob{first}, nil).Once()
+		webhookRepo.On("ClaimDueDeliveries", mock.Anything, 1, mock.Anything).Run(recordLease).Return([]*domain.WebhookJob{second}, nil).Once()
+		webhookRepo.On("ClaimDueDeliveries", mock.Anything, 1, mock.Anything).Run(recordLease).Return([]*domain.WebhookJob{}, nil).Once()
+		// The first lease ran out and another dispatcher took the delivery
+		webhookRepo.On("SaveAttempt", mock.Anything, first.Delivery, mock.Anything).Return(false, nil)
+		webhookRepo.On("SaveAttempt", mock.Anything, second.Delivery, mock.Anything).Return(true, nil)
+
+		dispatcher := worker.NewWebhookDispatcher(webhookRepo, server.Client(), opts)
+		sent, err := dispatcher.DispatchOnce(context.Background())
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, sent)
+		require.Len(t, leases, 3)
+		webhookRepo.AssertCalled(t, "SaveAttempt", mock.Anything, first.Delivery, leases[0])
+		webhookRepo.AssertCalled(t, "SaveAttempt", mock.Anything, second.Delivery, leases[1])
+	})
+
+	t.Run("unreachable endpoint", func(t *testing.T) {
+		server := httptest.NewServer(http.NotFoundHandler())
+		url := server.URL
+		server.Close()
+
+		job := webhookJob(url, 0)
+		webhookRepo := new(mockWebhookRepository)
+		expectClaims(webhookRepo, job)
+		webhookRepo.On("SaveAttempt", mock.Anything, job.Delivery, mock.Anything).Return(true, nil)
+
+		dispatcher := worker.NewWebhookDispatcher(webhookRepo, nil, opts)
+		_, err := dispatcher.DispatchOnce(context.Background())
+
+		assert.NoError(t, err)
+		assert.Equal(t, domain.DeliveryPending, job.Delivery.Status)
+		assert.Nil(t, job.Delivery.LastStatusCode)
+	})
+}
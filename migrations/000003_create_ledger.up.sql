@@ -0,0 +1,92 @@
+-- Ledger accounts: one per wallet, plus platform accounts per currency
+CREATE TABLE IF NOT EXISTS ledger_accounts (
+  id SERIAL PRIMARY KEY,
+  code VARCHAR(100) NOT NULL,
+  type VARCHAR(20) NOT NULL,
+  wallet_id INTEGER UNIQUE REFERENCES wallets(id) ON DELETE RESTRICT,
+  currency VARCHAR(10) NOT NULL,
+  created_at TIMESTAMP NOT NULL,
+  UNIQUE(code, currency)
+);
+
+-- Journal entries group the postings of one money movement
+CREATE TABLE IF NOT EXISTS journal_entries (
+  id SERIAL PRIMARY KEY,
+  type VARCHAR(20) NOT NULL,
+  description TEXT,
+  created_at TIMESTAMP NOT NULL
+);
+
+-- Postings move money into (positive) or out of (negative) an account
+CREATE TABLE IF NOT EXISTS postings (
+  id SERIAL PRIMARY KEY,
+  journal_entry_id INTEGER NOT NULL REFERENCES journal_entries(id) ON DELETE RESTRICT,
+  account_id INTEGER NOT NULL REFERENCES ledger_accounts(id) ON DELETE RESTRICT,
+  amount DECIMAL(19, 4) NOT NULL CHECK (amount <> 0),
+  currency VARCHAR(10) NOT NULL,
+  created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_postings_journal_entry_id ON postings(journal_entry_id);
+CREATE INDEX IF NOT EXISTS idx_postings_account_id ON postings(account_id);
+
+-- Reject any journal entry whose postings don't sum to zero per currency.
+-- Checked at commit so all postings of an entry can be inserted first.
+CREATE OR REPLACE FUNCTION check_journal_entry_balanced() RETURNS TRIGGER AS $$
+BEGIN
+  IF EXISTS (
+    SELECT 1
+    FROM postings
+    WHERE journal_entry_id = NEW.journal_entry_id
+    GROUP BY currency
+    HAVING SUM(amount) <> 0
+  ) THEN
+    RAISE EXCEPTION 'journal entry % is not balanced', NEW.journal_entry_id;
+  END IF;
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER postings_balanced
+  AFTER INSERT OR UPDATE ON postings
+  DEFERRABLE INITIALLY DEFERRED
+  FOR EACH ROW EXECUTE FUNCTION check_journal_entry_balanced();
+
+-- Link each transaction to the journal entry that moved its money
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS journal_entry_id INTEGER REFERENCES journal_entries(id);
+
+-- Open accounts for existing wallets
+INSERT INTO ledger_accounts (code, type, wallet_id, currency, created_at)
+SELECT 'wallet:' || id, 'WALLET', id, currency, NOW()
+FROM wallets
+ON CONFLICT DO NOTHING;
+
+INSERT INTO ledger_accounts (code, type, wallet_id, currency, created_at)
+SELECT code, 'SYSTEM', NULL, currency, NOW()
+FROM (SELECT DISTINCT currency FROM wallets UNION SELECT 'USD') currencies
+CROSS JOIN (VALUES ('cash-in'), ('cash-out'), ('fees')) AS system_accounts(code)
+ON CONFLICT DO NOTHING;
+
+-- Bring existing balances onto the ledger against cash-in
+DO $$
+DECLARE
+  w RECORD;
+  entry_id INTEGER;
+BEGIN
+  FOR w IN
+    SELECT wa.id AS account_id, wa.currency, wl.balance
+    FROM wallets wl
+    JOIN ledger_accounts wa ON wa.wallet_id = wl.id
+    WHERE wl.balance <> 0
+  LOOP
+    INSERT INTO journal_entries (type, description, created_at)
+    VALUES ('OPENING_BALANCE', 'Opening balance', NOW())
+    RETURNING id INTO entry_id;
+
+    INSERT INTO postings (journal_entry_id, account_id, amount, currency, created_at)
+    VALUES
+      (entry_id, w.account_id, w.balance, w.currency, NOW()),
+      (entry_id, (SELECT id FROM ledger_accounts WHERE code = 'cash-in' AND currency = w.currency), -w.balance, w.currency, NOW());
+  END LOOP;
+END;
+$$;
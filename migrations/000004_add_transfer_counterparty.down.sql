@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_transactions_correlation_id;
+ALTER TABLE transactions DROP COLUMN IF EXISTS counterparty_user_id;
+ALTER TABLE transactions DROP COLUMN IF EXISTS counterparty_wallet_id;
+ALTER TABLE transactions DROP COLUMN IF EXISTS correlation_id;
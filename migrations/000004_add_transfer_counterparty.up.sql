@@ -0,0 +1,7 @@
+-- Transfers are recorded on both wallets and linked by a correlation ID
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS correlation_id UUID;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS counterparty_wallet_id INTEGER REFERENCES wallets(id) ON DELETE SET NULL;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS counterparty_user_id INTEGER REFERENCES users(id) ON DELETE SET NULL;
+
+-- Create index on correlation_id
+CREATE INDEX IF NOT EXISTS idx_transactions_correlation_id ON transactions(correlation_id);
@@ -0,0 +1,18 @@
+-- Domain events written in the same transaction as the change they describe,
+-- then relayed to the event publisher in id order
+CREATE TABLE IF NOT EXISTS outbox_events (
+  id BIGSERIAL PRIMARY KEY,
+  aggregate_type VARCHAR(50) NOT NULL,
+  aggregate_id BIGINT NOT NULL,
+  event_type VARCHAR(100) NOT NULL,
+  payload JSONB NOT NULL,
+  created_at TIMESTAMP NOT NULL,
+  published_at TIMESTAMP,
+  attempts INTEGER NOT NULL DEFAULT 0,
+  last_error TEXT,
+  next_attempt_at TIMESTAMP NOT NULL
+);
+
+-- The relay only ever looks at unpublished events, per aggregate in id order
+CREATE INDEX IF NOT EXISTS idx_outbox_events_pending ON outbox_events(id) WHERE published_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_outbox_events_pending_aggregate ON outbox_events(aggregate_type, aggregate_id, id) WHERE published_at IS NULL;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_transactions_original_transaction_id;
+ALTER TABLE transactions DROP COLUMN IF EXISTS reversal_status;
+ALTER TABLE transactions DROP COLUMN IF EXISTS reversed_amount;
+ALTER TABLE transactions DROP COLUMN IF EXISTS original_transaction_id;
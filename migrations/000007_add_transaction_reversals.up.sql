@@ -0,0 +1,9 @@
+-- Reversals and refunds are compensating rows pointing at the transaction they undo
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS original_transaction_id INTEGER REFERENCES transactions(id) ON DELETE SET NULL;
+
+-- How much of a transaction has been compensated so far, and whether by a reversal or refunds
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS reversed_amount DECIMAL(19, 4) NOT NULL DEFAULT 0;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS reversal_status VARCHAR(20);
+
+-- Create index on original_transaction_id
+CREATE INDEX IF NOT EXISTS idx_transactions_original_transaction_id ON transactions(original_transaction_id);
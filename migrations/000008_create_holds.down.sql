@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS holds;
+ALTER TABLE wallets DROP COLUMN IF EXISTS held_balance;
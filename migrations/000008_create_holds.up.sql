@@ -0,0 +1,22 @@
+-- Money reserved by active holds; available balance is balance - held_balance
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS held_balance DECIMAL(19, 4) NOT NULL DEFAULT 0;
+
+-- Holds reserve wallet funds until they are captured, voided or expire
+CREATE TABLE IF NOT EXISTS holds (
+  id BIGSERIAL PRIMARY KEY,
+  wallet_id INTEGER NOT NULL REFERENCES wallets(id) ON DELETE CASCADE,
+  amount DECIMAL(19, 4) NOT NULL CHECK (amount > 0),
+  captured_amount DECIMAL(19, 4) NOT NULL DEFAULT 0,
+  status VARCHAR(20) NOT NULL,
+  description TEXT,
+  transaction_id INTEGER REFERENCES transactions(id) ON DELETE SET NULL,
+  expires_at TIMESTAMP NOT NULL,
+  created_at TIMESTAMP NOT NULL,
+  updated_at TIMESTAMP NOT NULL
+);
+
+-- Create index on wallet_id
+CREATE INDEX IF NOT EXISTS idx_holds_wallet_id ON holds(wallet_id);
+
+-- The expiry job only looks at holds that are still active
+CREATE INDEX IF NOT EXISTS idx_holds_active_expires_at ON holds(expires_at) WHERE status = 'ACTIVE';
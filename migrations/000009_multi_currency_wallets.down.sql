@@ -0,0 +1,4 @@
+-- Fails if any user has more than one wallet
+ALTER TABLE wallets DROP COLUMN IF EXISTS label;
+ALTER TABLE wallets DROP CONSTRAINT IF EXISTS wallets_user_id_currency_key;
+ALTER TABLE wallets ADD CONSTRAINT wallets_user_id_key UNIQUE (user_id);
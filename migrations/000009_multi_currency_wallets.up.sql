@@ -0,0 +1,6 @@
+-- Users can hold one wallet per currency instead of a single wallet
+ALTER TABLE wallets DROP CONSTRAINT IF EXISTS wallets_user_id_key;
+ALTER TABLE wallets ADD CONSTRAINT wallets_user_id_currency_key UNIQUE (user_id, currency);
+
+-- Optional name the user gives a wallet, e.g. "Travel"
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS label VARCHAR(100);
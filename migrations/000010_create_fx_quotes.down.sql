@@ -0,0 +1,5 @@
+ALTER TABLE transactions DROP COLUMN IF EXISTS target_amount;
+ALTER TABLE transactions DROP COLUMN IF EXISTS source_amount;
+ALTER TABLE transactions DROP COLUMN IF EXISTS fx_rate;
+ALTER TABLE transactions DROP COLUMN IF EXISTS fx_quote_id;
+DROP TABLE IF EXISTS fx_quotes;
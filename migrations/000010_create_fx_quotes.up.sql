@@ -0,0 +1,20 @@
+-- Exchange rates locked for a user until they expire or are used once
+CREATE TABLE IF NOT EXISTS fx_quotes (
+  id UUID PRIMARY KEY,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  from_currency VARCHAR(10) NOT NULL,
+  to_currency VARCHAR(10) NOT NULL,
+  mid_rate DECIMAL(19, 8) NOT NULL CHECK (mid_rate > 0),
+  rate DECIMAL(19, 8) NOT NULL CHECK (rate > 0),
+  spread_bps INTEGER NOT NULL,
+  source VARCHAR(50) NOT NULL,
+  expires_at TIMESTAMP NOT NULL,
+  used_at TIMESTAMP,
+  created_at TIMESTAMP NOT NULL
+);
+
+-- Converted transactions record the rate and the amounts on either side
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS fx_quote_id UUID REFERENCES fx_quotes(id) ON DELETE SET NULL;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS fx_rate DECIMAL(19, 8);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS source_amount DECIMAL(19, 4);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS target_amount DECIMAL(19, 4);
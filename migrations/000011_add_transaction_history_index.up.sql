@@ -0,0 +1,2 @@
+-- Keyset pagination walks a wallet's history by (transaction_time, id), newest first
+CREATE INDEX IF NOT EXISTS idx_transactions_wallet_time_id ON transactions(wallet_id, transaction_time DESC, id DESC);
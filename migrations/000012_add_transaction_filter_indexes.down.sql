@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_transactions_description_trgm;
+DROP INDEX IF EXISTS idx_transactions_wallet_counterparty;
+DROP INDEX IF EXISTS idx_transactions_wallet_amount;
+DROP INDEX IF EXISTS idx_transactions_wallet_type_time;
+
+-- pg_trgm is left installed, other objects may have come to rely on it
@@ -0,0 +1,10 @@
+-- Trigram matching lets description searches with leading wildcards use an index
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Filtering a wallet's history by type, amount or counterparty
+CREATE INDEX IF NOT EXISTS idx_transactions_wallet_type_time ON transactions(wallet_id, type, transaction_time DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_transactions_wallet_amount ON transactions(wallet_id, amount);
+CREATE INDEX IF NOT EXISTS idx_transactions_wallet_counterparty ON transactions(wallet_id, counterparty_wallet_id, transaction_time DESC, id DESC);
+
+-- Free-text search on descriptions
+CREATE INDEX IF NOT EXISTS idx_transactions_description_trgm ON transactions USING GIN (description gin_trgm_ops);
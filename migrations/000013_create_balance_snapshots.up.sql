@@ -0,0 +1,12 @@
+-- Each wallet's balance at the end of every UTC day, written by the snapshot job.
+-- as_of is the midnight ending the day; the balance counts transactions before it.
+CREATE TABLE IF NOT EXISTS balance_snapshots (
+  wallet_id INTEGER NOT NULL REFERENCES wallets(id) ON DELETE CASCADE,
+  as_of TIMESTAMP NOT NULL,
+  balance DECIMAL(19, 4) NOT NULL,
+  created_at TIMESTAMP NOT NULL,
+  PRIMARY KEY (wallet_id, as_of)
+);
+
+-- The snapshot job resumes from the latest day snapshotted
+CREATE INDEX IF NOT EXISTS idx_balance_snapshots_as_of ON balance_snapshots(as_of);
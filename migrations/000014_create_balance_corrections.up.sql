@@ -0,0 +1,12 @@
+-- Audit trail of wallet balances reset by the reconciliation job
+CREATE TABLE IF NOT EXISTS balance_corrections (
+  id BIGSERIAL PRIMARY KEY,
+  wallet_id INTEGER NOT NULL REFERENCES wallets(id) ON DELETE CASCADE,
+  old_balance DECIMAL(19, 4) NOT NULL,
+  new_balance DECIMAL(19, 4) NOT NULL,
+  reason TEXT NOT NULL,
+  created_at TIMESTAMP NOT NULL
+);
+
+-- Create index on wallet_id
+CREATE INDEX IF NOT EXISTS idx_balance_corrections_wallet_id ON balance_corrections(wallet_id);
@@ -0,0 +1,49 @@
+-- Transfers that run once at a future time or repeat by cron expression or interval
+CREATE TABLE IF NOT EXISTS scheduled_transfers (
+  id BIGSERIAL PRIMARY KEY,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  receiver_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  sender_wallet_id INTEGER REFERENCES wallets(id) ON DELETE CASCADE,
+  receiver_wallet_id INTEGER REFERENCES wallets(id) ON DELETE CASCADE,
+  currency VARCHAR(10),
+  receiver_currency VARCHAR(10),
+  amount DECIMAL(19, 4) NOT NULL CHECK (amount > 0),
+  comment TEXT,
+  convert BOOLEAN NOT NULL DEFAULT FALSE,
+  cron_expr VARCHAR(100),
+  repeat_interval VARCHAR(50),
+  timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+  start_at TIMESTAMP NOT NULL,
+  ends_at TIMESTAMP,
+  on_insufficient_funds VARCHAR(10) NOT NULL,
+  max_retries INTEGER NOT NULL DEFAULT 0,
+  status VARCHAR(20) NOT NULL,
+  -- next_attempt_at doubles as the scheduler's lease while an occurrence runs
+  next_run_at TIMESTAMP,
+  next_attempt_at TIMESTAMP,
+  attempts INTEGER NOT NULL DEFAULT 0,
+  last_error TEXT,
+  created_at TIMESTAMP NOT NULL,
+  updated_at TIMESTAMP NOT NULL
+);
+
+-- Create index on user_id
+CREATE INDEX IF NOT EXISTS idx_scheduled_transfers_user_id ON scheduled_transfers(user_id, id);
+
+-- The scheduler only looks at schedules that are still active
+CREATE INDEX IF NOT EXISTS idx_scheduled_transfers_due ON scheduled_transfers(next_attempt_at) WHERE status = 'ACTIVE';
+
+-- One row per attempt at an occurrence
+CREATE TABLE IF NOT EXISTS schedule_executions (
+  id BIGSERIAL PRIMARY KEY,
+  schedule_id BIGINT NOT NULL REFERENCES scheduled_transfers(id) ON DELETE CASCADE,
+  scheduled_for TIMESTAMP NOT NULL,
+  attempt INTEGER NOT NULL,
+  status VARCHAR(20) NOT NULL,
+  transaction_id INTEGER REFERENCES transactions(id) ON DELETE SET NULL,
+  error TEXT,
+  executed_at TIMESTAMP NOT NULL
+);
+
+-- Create index on schedule_id
+CREATE INDEX IF NOT EXISTS idx_schedule_executions_schedule_id ON schedule_executions(schedule_id, id);
@@ -0,0 +1,39 @@
+-- Many transfers from one sender wallet, submitted together and processed in the background
+CREATE TABLE IF NOT EXISTS transfer_batches (
+  id BIGSERIAL PRIMARY KEY,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  sender_wallet_id INTEGER NOT NULL REFERENCES wallets(id) ON DELETE CASCADE,
+  currency VARCHAR(10) NOT NULL,
+  mode VARCHAR(20) NOT NULL,
+  status VARCHAR(20) NOT NULL,
+  item_count INTEGER NOT NULL,
+  total_amount DECIMAL(19, 4) NOT NULL,
+  succeeded_count INTEGER NOT NULL DEFAULT 0,
+  failed_count INTEGER NOT NULL DEFAULT 0,
+  error TEXT,
+  -- How long the worker processing the batch has it to itself
+  lease_until TIMESTAMP,
+  created_at TIMESTAMP NOT NULL,
+  updated_at TIMESTAMP NOT NULL,
+  completed_at TIMESTAMP
+);
+
+-- Workers only look at batches that aren't finished
+CREATE INDEX IF NOT EXISTS idx_transfer_batches_open ON transfer_batches(id) WHERE status IN ('PENDING', 'PROCESSING');
+
+CREATE TABLE IF NOT EXISTS transfer_batch_items (
+  id BIGSERIAL PRIMARY KEY,
+  batch_id BIGINT NOT NULL REFERENCES transfer_batches(id) ON DELETE CASCADE,
+  line INTEGER NOT NULL,
+  -- Receivers are checked when the item is processed, so an unknown one fails its item
+  receiver_id INTEGER NOT NULL,
+  receiver_wallet_id INTEGER,
+  amount DECIMAL(19, 4) NOT NULL CHECK (amount > 0),
+  comment TEXT,
+  status VARCHAR(20) NOT NULL,
+  transaction_id INTEGER REFERENCES transactions(id) ON DELETE SET NULL,
+  error TEXT
+);
+
+-- Items are read back in submission order
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transfer_batch_items_line ON transfer_batch_items(batch_id, line);
@@ -0,0 +1,24 @@
+-- Requests from one user to another for money, paid by a transfer when accepted
+CREATE TABLE IF NOT EXISTS payment_requests (
+  id BIGSERIAL PRIMARY KEY,
+  requester_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  payer_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  -- The requester's wallet the money is paid into
+  wallet_id INTEGER NOT NULL REFERENCES wallets(id) ON DELETE CASCADE,
+  currency VARCHAR(10) NOT NULL,
+  amount DECIMAL(19, 4) NOT NULL CHECK (amount > 0),
+  memo TEXT,
+  status VARCHAR(20) NOT NULL,
+  transaction_id INTEGER REFERENCES transactions(id) ON DELETE SET NULL,
+  expires_at TIMESTAMP NOT NULL,
+  created_at TIMESTAMP NOT NULL,
+  updated_at TIMESTAMP NOT NULL,
+  CHECK (requester_id <> payer_id)
+);
+
+-- Users list the requests they made and the ones sent to them
+CREATE INDEX IF NOT EXISTS idx_payment_requests_requester_id ON payment_requests(requester_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_payment_requests_payer_id ON payment_requests(payer_id, created_at);
+
+-- The expiry job only looks at requests that are still pending
+CREATE INDEX IF NOT EXISTS idx_payment_requests_pending_expires_at ON payment_requests(expires_at) WHERE status = 'PENDING';
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_transactions_parent_transaction_id;
+ALTER TABLE transactions DROP COLUMN IF EXISTS parent_transaction_id;
+ALTER TABLE transactions DROP COLUMN IF EXISTS fee_version;
+ALTER TABLE transactions DROP COLUMN IF EXISTS fee;
@@ -0,0 +1,9 @@
+-- Transactions that were charged a fee keep the fee and the schedule version it came from
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS fee DECIMAL(19, 4);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS fee_version VARCHAR(50);
+
+-- FEE rows point at the transaction they were charged for
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS parent_transaction_id INTEGER REFERENCES transactions(id);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_parent_transaction_id ON transactions(parent_transaction_id)
+  WHERE parent_transaction_id IS NOT NULL;
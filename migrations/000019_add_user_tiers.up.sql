@@ -0,0 +1,2 @@
+-- A user's tier decides which transaction limits apply to them
+ALTER TABLE users ADD COLUMN IF NOT EXISTS tier VARCHAR(20) NOT NULL DEFAULT 'standard';
@@ -0,0 +1,255 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// jwk is a single JSON Web Key as defined by RFC 7517
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	// Symmetric
+	K string `json:"k"`
+}
+
+// jwkSet is a JWKS document
+type jwkSet struct {
+	Keys []jwk `json:"keys"`
+}
+
+// KeySet holds verification keys read from a JWKS file, or from every *.json
+// file in a directory. Keys are re-read when the refresh interval passes or a
+// token names a key ID we haven't seen, so keys can be rotated by replacing files.
+type KeySet struct {
+	path            string
+	refreshInterval time.Duration
+
+	mu       sync.RWMutex
+	keys     map[string]any
+	loadedAt time.Time
+}
+
+// minReloadInterval stops tokens with made-up key IDs from hammering the disk
+const minReloadInterval = 10 * time.Second
+
+// NewKeySet loads the keys at path, a JWKS file or a directory of them
+func NewKeySet(path string, refreshInterval time.Duration) (*KeySet, error) {
+	ks := &KeySet{
+		path:            path,
+		refreshInterval: refreshInterval,
+	}
+
+	if err := ks.Reload(); err != nil {
+		return nil, err
+	}
+
+	return ks, nil
+}
+
+// Reload re-reads every key from disk
+func (ks *KeySet) Reload() error {
+	files, err := jwksFiles(ks.path)
+	if err != nil {
+		return err
+	}
+
+	keys := make(map[string]any)
+	for _, file := range files {
+		if err := loadJWKSFile(file, keys); err != nil {
+			return err
+		}
+	}
+
+	ks.mu.Lock()
+	ks.keys = keys
+	ks.loadedAt = time.Now()
+	ks.mu.Unlock()
+
+	return nil
+}
+
+// Key returns the key with the given ID. An empty kid matches the only key
+// in the set, if there is exactly one.
+func (ks *KeySet) Key(kid string) (any, error) {
+	ks.mu.RLock()
+	key, found := ks.lookup(kid)
+	stale := time.Since(ks.loadedAt)
+	ks.mu.RUnlock()
+
+	if found && (ks.refreshInterval <= 0 || stale < ks.refreshInterval) {
+		return key, nil
+	}
+
+	// Unknown or stale key, try the disk again
+	if !found && stale < minReloadInterval {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKey, kid)
+	}
+	if err := ks.Reload(); err != nil {
+		if found {
+			// Keep serving the last good keys if the files are mid-rotation
+			return key, nil
+		}
+		return nil, err
+	}
+
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	key, found = ks.lookup(kid)
+	if !found {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKey, kid)
+	}
+	return key, nil
+}
+
+// lookup finds a key by ID; callers hold ks.mu
+func (ks *KeySet) lookup(kid string) (any, bool) {
+	if kid == "" && len(ks.keys) == 1 {
+		for _, key := range ks.keys {
+			return key, true
+		}
+	}
+	key, ok := ks.keys[kid]
+	return key, ok
+}
+
+// jwksFiles lists the JWKS files at path
+func jwksFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWKS path: %w", err)
+	}
+
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(path, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list JWKS directory: %w", err)
+	}
+	sort.Strings(files)
+
+	return files, nil
+}
+
+// loadJWKSFile parses a JWKS document, or a single JWK, into keys
+func loadJWKSFile(file string, keys map[string]any) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("failed to read JWKS file %s: %w", file, err)
+	}
+
+	var set jwkSet
+	if err := json.Unmarshal(data, &set); err != nil {
+		return fmt.Errorf("failed to parse JWKS file %s: %w", file, err)
+	}
+
+	// Allow a file to hold one bare key instead of a set
+	if len(set.Keys) == 0 {
+		var single jwk
+		if err := json.Unmarshal(data, &single); err == nil && single.Kty != "" {
+			set.Keys = []jwk{single}
+		}
+	}
+
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+
+		key, err := k.publicKey()
+		if err != nil {
+			return fmt.Errorf("invalid key %q in %s: %w", k.Kid, file, err)
+		}
+		keys[k.Kid] = key
+	}
+
+	return nil
+}
+
+// publicKey converts the JWK into the key type the JWT library verifies with
+func (k jwk) publicKey() (any, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() {
+			return nil, errors.New("RSA exponent is too large")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("EC point is not on the curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+
+	case "oct":
+		secret, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(k.K, "="))
+		if err != nil {
+			return nil, fmt.Errorf("invalid symmetric key: %w", err)
+		}
+		return secret, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+// decodeBigInt reads a base64url-encoded unsigned big-endian integer
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64url value: %w", err)
+	}
+	if len(b) == 0 {
+		return nil, errors.New("empty key parameter")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
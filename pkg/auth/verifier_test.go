@@ -0,0 +1,187 @@
+package auth_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/ravindu/wallet-app-service/pkg/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "test-secret"
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, key any, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+func validClaims() jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"sub":   "42",
+		"iss":   "wallet-auth",
+		"aud":   "wallet-api",
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+		"roles": []string{"admin"},
+	}
+}
+
+func writeJWKS(t *testing.T, dir, name string, keys ...map[string]string) {
+	t.Helper()
+	data, err := json.Marshal(map[string]any{"keys": keys})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), data, 0o600))
+}
+
+func TestVerifier_HS256(t *testing.T) {
+	verifier, err := auth.NewVerifier(auth.Config{
+		HMACSecret: testSecret,
+		Issuer:     "wallet-auth",
+		Audience:   "wallet-api",
+	})
+	require.NoError(t, err)
+
+	expired := validClaims()
+	expired["exp"] = time.Now().Add(-time.Hour).Unix()
+
+	notYetValid := validClaims()
+	notYetValid["nbf"] = time.Now().Add(time.Hour).Unix()
+
+	wrongIssuer := validClaims()
+	wrongIssuer["iss"] = "someone-else"
+
+	wrongAudience := validClaims()
+	wrongAudience["aud"] = "another-api"
+
+	noExpiry := validClaims()
+	delete(noExpiry, "exp")
+
+	badSubject := validClaims()
+	badSubject["sub"] = "alice"
+
+	tests := []struct {
+		name          string
+		token         string
+		expectedError bool
+	}{
+		{name: "valid token", token: sign(t, jwt.SigningMethodHS256, []byte(testSecret), "", validClaims())},
+		{name: "wrong secret", token: sign(t, jwt.SigningMethodHS256, []byte("other"), "", validClaims()), expectedError: true},
+		{name: "expired", token: sign(t, jwt.SigningMethodHS256, []byte(testSecret), "", expired), expectedError: true},
+		{name: "not yet valid", token: sign(t, jwt.SigningMethodHS256, []byte(testSecret), "", notYetValid), expectedError: true},
+		{name: "wrong issuer", token: sign(t, jwt.SigningMethodHS256, []byte(testSecret), "", wrongIssuer), expectedError: true},
+		{name: "wrong audience", token: sign(t, jwt.SigningMethodHS256, []byte(testSecret), "", wrongAudience), expectedError: true},
+		{name: "missing expiry", token: sign(t, jwt.SigningMethodHS256, []byte(testSecret), "", noExpiry), expectedError: true},
+		{name: "non-numeric subject", token: sign(t, jwt.SigningMethodHS256, []byte(testSecret), "", badSubject), expectedError: true},
+		{name: "unsigned", token: sign(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, "", validClaims()), expectedError: true},
+		{name: "garbage", token: "not.a.token", expectedError: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			identity, err := verifier.Verify(tc.token)
+
+			if tc.expectedError {
+				assert.Error(t, err)
+				assert.Nil(t, identity)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, int64(42), identity.UserID)
+				assert.Equal(t, []string{"admin"}, identity.Roles)
+			}
+		})
+	}
+}
+
+func TestVerifier_JWKS(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	writeJWKS(t, dir, "rsa.json", map[string]string{
+		"kty": "RSA",
+		"kid": "rsa-1",
+		"use": "sig",
+		"n":   b64(rsaKey.N.Bytes()),
+		"e":   b64(big.NewInt(int64(rsaKey.E)).Bytes()),
+	})
+	writeJWKS(t, dir, "ec.json", map[string]string{
+		"kty": "EC",
+		"kid": "ec-1",
+		"crv": "P-256",
+		"x":   b64(ecKey.X.FillBytes(make([]byte, 32))),
+		"y":   b64(ecKey.Y.FillBytes(make([]byte, 32))),
+	})
+
+	keys, err := auth.NewKeySet(dir, time.Minute)
+	require.NoError(t, err)
+
+	verifier, err := auth.NewVerifier(auth.Config{Keys: keys})
+	require.NoError(t, err)
+
+	t.Run("RS256", func(t *testing.T) {
+		identity, err := verifier.Verify(sign(t, jwt.SigningMethodRS256, rsaKey, "rsa-1", validClaims()))
+		assert.NoError(t, err)
+		assert.Equal(t, int64(42), identity.UserID)
+	})
+
+	t.Run("ES256", func(t *testing.T) {
+		identity, err := verifier.Verify(sign(t, jwt.SigningMethodES256, ecKey, "ec-1", validClaims()))
+		assert.NoError(t, err)
+		assert.Equal(t, int64(42), identity.UserID)
+	})
+
+	t.Run("unknown key ID", func(t *testing.T) {
+		_, err := verifier.Verify(sign(t, jwt.SigningMethodRS256, rsaKey, "rsa-2", validClaims()))
+		assert.ErrorIs(t, err, auth.ErrUnknownKey)
+	})
+
+	t.Run("key ID pointing at the wrong key type", func(t *testing.T) {
+		_, err := verifier.Verify(sign(t, jwt.SigningMethodES256, ecKey, "rsa-1", validClaims()))
+		assert.Error(t, err)
+	})
+
+	t.Run("rotated key is picked up from disk", func(t *testing.T) {
+		rotated, err := rsa.GenerateKey(rand.Reader, 2048)
+		require.NoError(t, err)
+		writeJWKS(t, dir, "rsa-rotated.json", map[string]string{
+			"kty": "RSA",
+			"kid": "rsa-rotated",
+			"n":   b64(rotated.N.Bytes()),
+			"e":   b64(big.NewInt(int64(rotated.E)).Bytes()),
+		})
+		require.NoError(t, keys.Reload())
+
+		identity, err := verifier.Verify(sign(t, jwt.SigningMethodRS256, rotated, "rsa-rotated", validClaims()))
+		assert.NoError(t, err)
+		assert.Equal(t, int64(42), identity.UserID)
+	})
+}
+
+func TestNewVerifier_RequiresKeys(t *testing.T) {
+	_, err := auth.NewVerifier(auth.Config{})
+	assert.ErrorIs(t, err, auth.ErrNoKeysConfigured)
+}
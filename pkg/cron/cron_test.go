@@ -0,0 +1,85 @@
+package cron_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ravindu/wallet-app-service/pkg/cron"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchedule_Next(t *testing.T) {
+	from := time.Date(2024, time.January, 15, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		spec     string
+		from     time.Time
+		expected time.Time
+	}{
+		{name: "every minute", spec: "* * * * *", from: from, expected: from.Add(time.Minute)},
+		{name: "skips the current minute", spec: "30 10 * * *", from: from, expected: time.Date(2024, time.January, 16, 10, 30, 0, 0, time.UTC)},
+		{name: "first of the month", spec: "0 9 1 * *", from: from, expected: time.Date(2024, time.February, 1, 9, 0, 0, 0, time.UTC)},
+		{name: "step", spec: "*/15 * * * *", from: from, expected: time.Date(2024, time.January, 15, 10, 45, 0, 0, time.UTC)},
+		{name: "range and list", spec: "0 8,17 * * MON-FRI", from: from, expected: time.Date(2024, time.January, 15, 17, 0, 0, 0, time.UTC)},
+		{name: "weekday rolls over the weekend", spec: "0 8 * * 1-5", from: time.Date(2024, time.January, 19, 9, 0, 0, 0, time.UTC), expected: time.Date(2024, time.January, 22, 8, 0, 0, 0, time.UTC)},
+		{name: "sunday as 7", spec: "0 0 * * 7", from: from, expected: time.Date(2024, time.January, 21, 0, 0, 0, 0, time.UTC)},
+		{name: "either day field", spec: "0 0 1 * FRI", from: from, expected: time.Date(2024, time.January, 19, 0, 0, 0, 0, time.UTC)},
+		{name: "month name", spec: "0 0 1 mar *", from: from, expected: time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "leap day", spec: "0 0 29 2 *", from: from, expected: time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{name: "end of year", spec: "@yearly", from: time.Date(2024, time.December, 31, 23, 59, 30, 0, time.UTC), expected: time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "never", spec: "0 0 30 2 *", from: from, expected: time.Time{}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			schedule, err := cron.Parse(tc.spec)
+			require.NoError(t, err)
+			assert.True(t, tc.expected.Equal(schedule.Next(tc.from)), "got %s", schedule.Next(tc.from))
+		})
+	}
+}
+
+func TestSchedule_NextAcrossDST(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("time zone data unavailable")
+	}
+
+	// 2:30 doesn't exist on the day clocks go forward, so that day fires an hour later
+	schedule, err := cron.Parse("30 2 * * *")
+	require.NoError(t, err)
+	next := schedule.Next(time.Date(2024, time.March, 10, 0, 0, 0, 0, newYork))
+	assert.Equal(t, time.Date(2024, time.March, 10, 3, 30, 0, 0, newYork), next)
+
+	// 1:30 happens twice on the day clocks go back; it fires once
+	schedule, err = cron.Parse("30 1 * * *")
+	require.NoError(t, err)
+	first := schedule.Next(time.Date(2024, time.November, 3, 0, 0, 0, 0, newYork))
+	second := schedule.Next(first)
+	assert.Equal(t, 1, first.Hour())
+	assert.Equal(t, time.November, second.Month())
+	assert.Equal(t, 4, second.Day())
+}
+
+func TestParse_Invalid(t *testing.T) {
+	specs := []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"* * * FOO *",
+		"@every",
+	}
+
+	for _, spec := range specs {
+		_, err := cron.Parse(spec)
+		assert.Error(t, err, spec)
+	}
+}
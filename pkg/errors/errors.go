@@ -22,8 +22,39 @@ var (
 	ErrLockAcquisitionFailed = errors.New("could not acquire lock for operation")
 	ErrUnauthorized          = errors.New("unauthorized access")
 	ErrForbidden             = errors.New("forbidden action")
+	ErrInvalidAmountFormat   = errors.New("invalid amount format")
+	ErrAmountPrecision       = errors.New("amount has more decimal places than the currency allows")
+	ErrAmountOverflow        = errors.New("amount is out of range")
+	ErrUnsupportedCurrency   = errors.New("unsupported currency")
+	ErrUnbalancedEntry       = errors.New("journal entry is not balanced")
+	ErrIdempotencyInProgress = errors.New("a request with this idempotency key is already in progress")
+	ErrIdempotencyKeyReused  = errors.New("idempotency key was already used with a different request")
+	ErrUsernameTaken         = errors.New("username is already taken")
+	ErrEmailTaken            = errors.New("email is already registered")
+	ErrTransactionNotFound   = errors.New("transaction not found")
+	ErrNotReversible         = errors.New("transaction cannot be reversed")
+	ErrReversalTooLarge      = errors.New("amount exceeds what is left to reverse on the transaction")
+	ErrHoldNotFound          = errors.New("hold not found")
+	ErrHoldNotActive         = errors.New("hold is no longer active")
+	ErrCaptureTooLarge       = errors.New("capture amount exceeds the held amount")
+	ErrCurrencyMismatch      = errors.New("wallet currencies do not match")
+	ErrWalletExists          = errors.New("user already has a wallet in this currency")
+	ErrQuoteNotFound         = errors.New("fx quote not found")
+	ErrQuoteExpired          = errors.New("fx quote has expired or was already used")
+	ErrRateUnavailable       = errors.New("exchange rate is unavailable")
+	ErrInvalidCursor         = errors.New("invalid pagination cursor")
+	ErrScheduleNotFound      = errors.New("scheduled transfer not found")
+	ErrScheduleNotActive     = errors.New("scheduled transfer is no longer active")
+	ErrBatchNotFound         = errors.New("transfer batch not found")
+	ErrPaymentRequestNotFound   = errors.New("payment request not found")
+	ErrPaymentRequestNotPending = errors.New("payment request is no longer pending")
+	ErrLimitExceeded            = errors.New("transaction limit exceeded")
 )
 
+// ErrPerTransactionLimit is the ErrLimitExceeded of an amount over a limit on its
+// own, which no waiting for a daily or monthly window to pass will clear
+var ErrPerTransactionLimit = fmt.Errorf("%w", ErrLimitExceeded)
+
 // WrapError adds more context to an error
 func WrapError(err error, message string) error {
 	return fmt.Errorf("%s: %w", message, err)
@@ -50,6 +81,16 @@ func BadRequestError(requestID, message string) *ErrorResponse {
 	return NewErrorResponse(requestID, message, http.StatusBadRequest)
 }
 
+// ConflictError for 409 errors
+func ConflictError(requestID, message string) *ErrorResponse {
+	return NewErrorResponse(requestID, message, http.StatusConflict)
+}
+
+// UnprocessableEntityError for 422 errors
+func UnprocessableEntityError(requestID, message string) *ErrorResponse {
+	return NewErrorResponse(requestID, message, http.StatusUnprocessableEntity)
+}
+
 // PaymentRequiredError for 402 errors
 func PaymentRequiredError(requestID, message string) *ErrorResponse {
 	return NewErrorResponse(requestID, message, http.StatusPaymentRequired)
@@ -80,21 +121,40 @@ func TooManyRequestsError(requestID, message string) *ErrorResponse {
 	return NewErrorResponse(requestID, message, http.StatusTooManyRequests)
 }
 
+// ServiceUnavailableError for 503 errors
+func ServiceUnavailableError(requestID, message string) *ErrorResponse {
+	return NewErrorResponse(requestID, message, http.StatusServiceUnavailable)
+}
+
 // MapErrorToResponse converts domain errors to HTTP responses
 func MapErrorToResponse(requestID string, err error) *ErrorResponse {
 	switch {
-	case errors.Is(err, ErrInvalidInput), errors.Is(err, ErrInvalidAmount), errors.Is(err, ErrSenderReceiverSame):
+	case errors.Is(err, ErrInvalidInput), errors.Is(err, ErrInvalidAmount), errors.Is(err, ErrSenderReceiverSame),
+		errors.Is(err, ErrInvalidAmountFormat), errors.Is(err, ErrAmountPrecision), errors.Is(err, ErrAmountOverflow),
+		errors.Is(err, ErrUnsupportedCurrency), errors.Is(err, ErrCurrencyMismatch), errors.Is(err, ErrInvalidCursor):
 		return BadRequestError(requestID, err.Error())
 	case errors.Is(err, ErrInsufficientFunds):
 		return PaymentRequiredError(requestID, "Insufficient funds for this operation")
-	case errors.Is(err, ErrResourceNotFound), errors.Is(err, ErrUserNotFound), errors.Is(err, ErrWalletNotFound):
+	case errors.Is(err, ErrResourceNotFound), errors.Is(err, ErrUserNotFound), errors.Is(err, ErrWalletNotFound),
+		errors.Is(err, ErrTransactionNotFound), errors.Is(err, ErrHoldNotFound), errors.Is(err, ErrQuoteNotFound),
+		errors.Is(err, ErrScheduleNotFound), errors.Is(err, ErrBatchNotFound),
+		errors.Is(err, ErrPaymentRequestNotFound):
 		return NotFoundError(requestID, err.Error())
 	case errors.Is(err, ErrUnauthorized):
 		return UnauthorizedError(requestID, err.Error())
 	case errors.Is(err, ErrForbidden):
 		return ForbiddenError(requestID, err.Error())
+	case errors.Is(err, ErrIdempotencyInProgress), errors.Is(err, ErrUsernameTaken), errors.Is(err, ErrEmailTaken),
+		errors.Is(err, ErrNotReversible), errors.Is(err, ErrReversalTooLarge),
+		errors.Is(err, ErrHoldNotActive), errors.Is(err, ErrCaptureTooLarge), errors.Is(err, ErrWalletExists),
+		errors.Is(err, ErrQuoteExpired), errors.Is(err, ErrScheduleNotActive), errors.Is(err, ErrPaymentRequestNotPending):
+		return ConflictError(requestID, err.Error())
+	case errors.Is(err, ErrIdempotencyKeyReused), errors.Is(err, ErrLimitExceeded):
+		return UnprocessableEntityError(requestID, err.Error())
 	case errors.Is(err, ErrLockAcquisitionFailed):
 		return TooManyRequestsError(requestID, "Service is busy, please try again in a moment")
+	case errors.Is(err, ErrRateUnavailable):
+		return ServiceUnavailableError(requestID, "Exchange rates are unavailable, please try again later")
 	default:
 		// Don't leak internal errors to clients
 		return InternalServerError(requestID, "An unexpected error occurred")
@@ -0,0 +1,68 @@
+package webhook_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/ravindu/wallet-app-service/pkg/webhook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsPublicAddress(t *testing.T) {
+	tests := []struct {
+		address  string
+		expected bool
+	}{
+		{address: "93.184.216.34", expected: true},
+		{address: "2606:2800:220:1:248:1893:25c8:1946", expected: true},
+		{address: "127.0.0.1"},
+		{address: "::1"},
+		{address: "10.1.2.3"},
+		{address: "172.16.0.1"},
+		{address: "192.168.1.1"},
+		{address: "169.254.169.254"},
+		{address: "fe80::1"},
+		{address: "fd00::1"},
+		{address: "100.64.0.1"},
+		{address: "0.0.0.0"},
+		{address: "224.0.0.1"},
+		{address: "::ffff:127.0.0.1"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.address, func(t *testing.T) {
+			assert.Equal(t, tc.expected, webhook.IsPublicAddress(netip.MustParseAddr(tc.address)))
+		})
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	t.Run("refuses internal addresses", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t.Fatal("request should not reach a loopback address")
+		}))
+		defer server.Close()
+
+		_, err := webhook.NewClient(false).Post(server.URL, "application/json", nil)
+		assert.ErrorIs(t, err, webhook.ErrForbiddenAddress)
+	})
+
+	t.Run("does not follow redirects", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/hooks" {
+				http.Redirect(w, r, "/elsewhere", http.StatusTemporaryRedirect)
+				return
+			}
+			t.Fatal("redirect should not be followed")
+		}))
+		defer server.Close()
+
+		resp, err := webhook.NewClient(true).Post(server.URL+"/hooks", "application/json", nil)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode)
+	})
+}
@@ -0,0 +1,84 @@
+// Package webhook signs outbound webhook payloads, lets receivers check them,
+// and builds the HTTP client they are delivered with.
+//
+// The signature header has the form "t=<unix seconds>,v1=<hex HMAC-SHA256>",
+// where the HMAC is computed over "<unix seconds>.<raw body>" with the
+// subscription secret. Binding the timestamp into the MAC lets receivers
+// reject replays of old deliveries.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SignatureHeader carries the signature on every delivery
+const SignatureHeader = "Webhook-Signature"
+
+// Errors returned by Verify
+var (
+	ErrMalformedSignature = errors.New("malformed webhook signature header")
+	ErrSignatureMismatch  = errors.New("webhook signature does not match")
+	ErrSignatureExpired   = errors.New("webhook signature timestamp is outside the tolerance")
+)
+
+// Sign returns the signature header value for body sent at timestamp
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	ts := strconv.FormatInt(timestamp.Unix(), 10)
+	return "t=" + ts + ",v1=" + computeMAC(secret, ts, body)
+}
+
+// Verify checks a signature header against body. Signatures older or newer than
+// tolerance relative to now are rejected; a zero tolerance skips the check.
+func Verify(secret, header string, body []byte, tolerance time.Duration, now time.Time) error {
+	var ts string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return ErrMalformedSignature
+		}
+		switch key {
+		case "t":
+			ts = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+	if ts == "" || len(signatures) == 0 {
+		return ErrMalformedSignature
+	}
+
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return ErrMalformedSignature
+	}
+	if tolerance > 0 {
+		age := now.Sub(time.Unix(unix, 0))
+		if age > tolerance || age < -tolerance {
+			return ErrSignatureExpired
+		}
+	}
+
+	expected := computeMAC(secret, ts, body)
+	for _, signature := range signatures {
+		if hmac.Equal([]byte(signature), []byte(expected)) {
+			return nil
+		}
+	}
+	return ErrSignatureMismatch
+}
+
+// computeMAC returns the hex HMAC-SHA256 of "<ts>.<body>"
+func computeMAC(secret, ts string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
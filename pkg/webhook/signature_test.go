@@ -0,0 +1,42 @@
+package webhook_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ravindu/wallet-app-service/pkg/webhook"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	secret := "whsec_test"
+	body := []byte(`{"id":1,"type":"wallet.credited"}`)
+	sentAt := time.Unix(1700000000, 0)
+	header := webhook.Sign(secret, sentAt, body)
+
+	tests := []struct {
+		name          string
+		secret        string
+		header        string
+		body          []byte
+		now           time.Time
+		expectedError error
+	}{
+		{name: "valid", secret: secret, header: header, body: body, now: sentAt.Add(time.Minute)},
+		{name: "tampered body", secret: secret, header: header, body: []byte(`{"id":2}`), now: sentAt, expectedError: webhook.ErrSignatureMismatch},
+		{name: "wrong secret", secret: "other", header: header, body: body, now: sentAt, expectedError: webhook.ErrSignatureMismatch},
+		{name: "too old", secret: secret, header: header, body: body, now: sentAt.Add(10 * time.Minute), expectedError: webhook.ErrSignatureExpired},
+		{name: "malformed", secret: secret, header: "v1=abc", body: body, now: sentAt, expectedError: webhook.ErrMalformedSignature},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := webhook.Verify(tc.secret, tc.header, tc.body, 5*time.Minute, tc.now)
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}